@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Redaction profiles selectable per chat by admins with /redaction
+const (
+	REDACTION_FULL           = "full"
+	REDACTION_NO_USERNAMES   = "no_usernames"
+	REDACTION_NO_TEXT        = "no_text"
+	REDACTION_NO_PROBABILITY = "no_probability"
+	REDACTION_AMBASSADOR     = "ambassador"
+	REDACTION_ANONYMIZED     = "anonymized"
+)
+
+// RedactionProfile describes which alert fields a chat is allowed to see
+type RedactionProfile struct {
+	HideUsernames   bool
+	HideText        bool
+	HideProbability bool
+	SummaryOnly     bool
+}
+
+var redactionProfiles = map[string]RedactionProfile{
+	REDACTION_FULL:           {},
+	REDACTION_NO_USERNAMES:   {HideUsernames: true},
+	REDACTION_NO_TEXT:        {HideText: true},
+	REDACTION_NO_PROBABILITY: {HideProbability: true},
+	REDACTION_AMBASSADOR:     {HideUsernames: true, HideProbability: true},
+	REDACTION_ANONYMIZED:     {HideUsernames: true, HideText: true, HideProbability: true, SummaryOnly: true},
+}
+
+var mentionRegex = regexp.MustCompile(`@\w+`)
+
+// redactionProfileNames returns the known profile names in stable order
+func redactionProfileNames() []string {
+	var names []string
+	for name := range redactionProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isRedactedProfile reports whether the chat gets anything less than full alerts
+func isRedactedProfile(profile string) bool {
+	return profile != "" && profile != REDACTION_FULL
+}
+
+// FormatRedacted renders a sanitized alert for partially trusted chats. It never includes
+// tweet links or investigation commands because both reveal the account behind the alert.
+func (nf *NotificationFormatter) FormatRedacted(alert FUDAlertNotification, profileName string) string {
+	profile := redactionProfiles[profileName]
+	severityEmoji := nf.getSeverityEmoji(alert.AlertSeverity)
+	isFUDAlert := !strings.Contains(alert.FUDType, "manual_analysis_clean") && alert.FUDType != "none"
+
+	if profile.SummaryOnly {
+		if !isFUDAlert {
+			return "✅ An analyzed account was found clean."
+		}
+		return fmt.Sprintf("%s <b>%s severity FUD detected</b> (%s). Moderators are looking into it.",
+			severityEmoji, strings.Title(alert.AlertSeverity), nf.formatFUDType(alert.FUDType))
+	}
+
+	var message strings.Builder
+	if isFUDAlert {
+		message.WriteString(fmt.Sprintf("%s <b>FUD ALERT - %s SEVERITY</b>\n\n", severityEmoji, strings.ToUpper(alert.AlertSeverity)))
+		message.WriteString(fmt.Sprintf("%s <b>Attack Type:</b> %s\n", nf.getFUDTypeEmoji(alert.FUDType), nf.formatFUDType(alert.FUDType)))
+	} else {
+		message.WriteString("✅ <b>ANALYSIS COMPLETE - USER CLEAN</b>\n\n")
+	}
+
+	if profile.HideUsernames {
+		message.WriteString(fmt.Sprintf("🎯 <b>User:</b> %s\n", anonymizedUserLabel(alert.FUDUserID)))
+	} else {
+		message.WriteString(fmt.Sprintf("🎯 <b>User:</b> @%s\n", alert.FUDUsername))
+	}
+	if !profile.HideProbability {
+		message.WriteString(fmt.Sprintf("📊 <b>Confidence:</b> %.0f%%\n", alert.FUDProbability*100))
+	}
+	message.WriteString(fmt.Sprintf("⚡ <b>Action:</b> %s\n", nf.escape(alert.RecommendedAction)))
+
+	if !profile.HideText {
+		text := nf.truncateText(alert.MessagePreview, 500)
+		if profile.HideUsernames {
+			text = mentionRegex.ReplaceAllString(text, "@…")
+		}
+		message.WriteString(fmt.Sprintf("\n💬 <b>Message:</b>\n<i>%s</i>\n", nf.escape(text)))
+	}
+
+	message.WriteString(fmt.Sprintf("\n⏰ <b>Detected:</b> %s", nf.formatTime(alert.DetectedAt)))
+	return message.String()
+}
+
+// anonymizedUserLabel gives a stable pseudonym so redacted chats can correlate repeat offenders
+func anonymizedUserLabel(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return "account #" + hex.EncodeToString(sum[:])[:8]
+}
+
+// isInvestigationCommand reports commands that expose usernames or raw tweets
+func isInvestigationCommand(command string) bool {
+	for _, prefix := range []string{"/detail_", "/history_", "/export_", "/ticker_history_", "/cache_", "/graph_", "/network_", "/riskchart_", "/report_",
+		"/ack_", "/assign_", "/resolve_", "/analyze_"} {
+		if strings.HasPrefix(command, prefix) {
+			return true
+		}
+	}
+	switch command {
+	case "/search", "/fudlist", "/exportfudlist", "/topfud", "/openalerts", "/tasks", "/reports":
+		return true
+	}
+	return strings.HasPrefix(command, "/fudlist_") || strings.HasPrefix(command, "/topfud_") || strings.HasPrefix(command, "/search_p")
+}
+
+// isRedactedChat reports whether the chat has a redaction profile other than full
+func (b *BotController) isRedactedChat(chatID int64) bool {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to load settings for chat %d, treating it as redacted: %v", chatID, err)
+		return true
+	}
+	return isRedactedProfile(settings.Redaction)
+}
+
+// handleRedactionCommand shows the chat profile, or lets admins set it with /redaction <chat_id> <profile>
+func (b *BotController) handleRedactionCommand(chatID int64, args []string) {
+	profiles := strings.Join(redactionProfileNames(), "|")
+
+	if len(args) == 0 {
+		settings, err := b.dbService.GetChatSettings(chatID)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+			return
+		}
+		b.SendMessage(chatID, fmt.Sprintf("🕶 <b>Redaction profile:</b> %s\n\nAdmins can change it with /redaction &lt;chat_id&gt; %s", settings.Redaction, profiles))
+		return
+	}
+
+	if !b.isAdminChat(chatID) {
+		b.SendMessage(chatID, "❌ Access denied. Only administrators can change redaction profiles.")
+		return
+	}
+	if len(args) < 2 {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Usage: /redaction &lt;chat_id&gt; %s", profiles))
+		return
+	}
+
+	targetChatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.SendMessage(chatID, "❌ Invalid chat ID")
+		return
+	}
+	profile := strings.ToLower(args[1])
+	if _, ok := redactionProfiles[profile]; !ok {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Unknown profile. Use one of: %s", profiles))
+		return
+	}
+
+	settings, err := b.dbService.GetChatSettings(targetChatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+	settings.Redaction = profile
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	log.Printf("Redaction profile of chat %d set to %s by admin chat %d", targetChatID, profile, chatID)
+	b.SendMessage(chatID, fmt.Sprintf("✅ Chat %d now receives <b>%s</b> alerts", targetChatID, profile))
+}
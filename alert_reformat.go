@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const REFORMAT_BATCH = 50
+
+// reformatResult counts what /reformat_alerts regenerated
+type reformatResult struct {
+	Alerts int
+	Edited int
+	Failed int
+}
+
+// normalizeStoredAlert brings an alert stored by an older version to the structure the current
+// templates expect
+func normalizeStoredAlert(alert FUDAlertNotification) FUDAlertNotification {
+	alert.AlertSeverity = strings.ToLower(strings.TrimSpace(alert.AlertSeverity))
+	alert.FUDType = strings.ToLower(strings.TrimSpace(alert.FUDType))
+	alert.FUDUsername = strings.TrimPrefix(strings.TrimSpace(alert.FUDUsername), "@")
+	alert.ParentPostAuthor = strings.TrimPrefix(alert.ParentPostAuthor, "@")
+	alert.OriginalPostAuthor = strings.TrimPrefix(alert.OriginalPostAuthor, "@")
+	alert.GrandParentPostAuthor = strings.TrimPrefix(alert.GrandParentPostAuthor, "@")
+
+	var evidence []string
+	for _, item := range alert.KeyEvidence {
+		if item = strings.TrimSpace(item); item != "" {
+			evidence = append(evidence, item)
+		}
+	}
+	alert.KeyEvidence = evidence
+
+	// Older alerts stored the thread context without the flag
+	if alert.OriginalPostText != "" || alert.ParentPostText != "" || alert.GrandParentPostText != "" {
+		alert.HasThreadContext = true
+	}
+	return alert
+}
+
+// reformatStoredAlerts regenerates the stored alerts rendered with an older format: the payload is
+// normalized and the delivered alert messages are edited to the current templates
+func (b *BotController) reformatStoredAlerts() (reformatResult, error) {
+	var result reformatResult
+	for {
+		notifications, err := b.dbService.GetOutdatedNotifications(ALERT_FORMAT_VERSION, REFORMAT_BATCH)
+		if err != nil {
+			return result, err
+		}
+		if len(notifications) == 0 {
+			return result, nil
+		}
+		for _, notification := range notifications {
+			var alert FUDAlertNotification
+			if err := json.Unmarshal([]byte(notification.Payload), &alert); err != nil {
+				// Marked current anyway, an unreadable payload would stop every later run here
+				log.Printf("Failed to read stored alert %s: %v", notification.NotificationID, err)
+				result.Failed++
+				if err := b.dbService.SetNotificationFormatVersion(notification.NotificationID, ALERT_FORMAT_VERSION); err != nil {
+					return result, err
+				}
+				continue
+			}
+			alert = normalizeStoredAlert(alert)
+			if alert.FUDUsername == "" && alert.FUDUserID != "" {
+				if user, err := b.dbService.GetUser(alert.FUDUserID); err == nil {
+					alert.FUDUsername = user.Username
+				}
+			}
+			if err := b.dbService.UpdateNotificationPayload(notification.NotificationID, alert, ALERT_FORMAT_VERSION); err != nil {
+				return result, err
+			}
+			edited, failed := b.rerenderAlertMessages(notification.NotificationID, alert)
+			result.Alerts++
+			result.Edited += edited
+			result.Failed += failed
+		}
+	}
+}
+
+// rerenderAlertMessages edits the delivered messages of an alert with each chat's current settings
+func (b *BotController) rerenderAlertMessages(notificationID string, alert FUDAlertNotification) (int, int) {
+	alertMessages, err := b.dbService.GetAlertMessages(notificationID)
+	if err != nil {
+		log.Printf("Failed to load the alert messages of %s: %v", notificationID, err)
+		return 0, 1
+	}
+	b.attachPriorAlerts(&alert)
+	edited, failed := 0, 0
+	for _, alertMessage := range alertMessages {
+		settings, err := b.dbService.GetChatSettings(alertMessage.ChatID)
+		if err != nil {
+			settings = defaultChatSettings(alertMessage.ChatID)
+		}
+		text := b.formatAlertForChat(alert, notificationID, settings)
+		// Split alerts only kept their first message, it is re-rendered as far as it fits
+		if parts := splitTelegramMessage(text, TELEGRAM_MAX_MESSAGE_LENGTH); len(parts) > 1 {
+			text = parts[0]
+		}
+		if err := b.EditMessage(alertMessage.ChatID, alertMessage.MessageID, text); err != nil {
+			log.Printf("Failed to re-render alert %s in chat %d: %v", notificationID, alertMessage.ChatID, err)
+			failed++
+			continue
+		}
+		edited++
+	}
+	return edited, failed
+}
+
+// handleReformatAlertsCommand regenerates the stored alerts after template changes, "all" also
+// re-renders the alerts already in the current format
+func (b *BotController) handleReformatAlertsCommand(chatID int64, args []string) {
+	if len(args) > 0 {
+		if !strings.EqualFold(args[0], "all") {
+			b.SendMessage(chatID, "❌ Usage: /reformat_alerts [all]")
+			return
+		}
+		if err := b.dbService.ResetNotificationFormats(); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading stored alerts: %v", err))
+			return
+		}
+	}
+	outdated, err := b.dbService.CountOutdatedNotifications(ALERT_FORMAT_VERSION)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading stored alerts: %v", err))
+		return
+	}
+	if outdated == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("✅ All stored alerts use format v%d, nothing to regenerate.", ALERT_FORMAT_VERSION))
+		return
+	}
+	b.SendMessage(chatID, fmt.Sprintf("🔄 Regenerating %d stored alerts with format v%d...", outdated, ALERT_FORMAT_VERSION))
+
+	result, err := b.reformatStoredAlerts()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Regeneration stopped after %d alerts: %v", result.Alerts, err))
+		return
+	}
+	log.Printf("🔄 Regenerated %d stored alerts, %d messages edited, %d failed", result.Alerts, result.Edited, result.Failed)
+	b.SendMessage(chatID, fmt.Sprintf("✅ <b>Alerts regenerated</b>\n\n📋 Alerts: %d\n✏️ Messages edited: %d\n⚠️ Failed: %d", result.Alerts, result.Edited, result.Failed))
+}
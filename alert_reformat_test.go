@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeStoredAlert(t *testing.T) {
+	alert := benchmarkAlert()
+	alert.AlertSeverity = " HIGH"
+	alert.FUDUsername = "@suspicious_user"
+	alert.KeyEvidence = []string{"Repeated liquidity concerns", "  "}
+	alert.HasThreadContext = false
+
+	normalized := normalizeStoredAlert(alert)
+	assert.Equal(t, "high", normalized.AlertSeverity)
+	assert.Equal(t, "suspicious_user", normalized.FUDUsername)
+	assert.Equal(t, []string{"Repeated liquidity concerns"}, normalized.KeyEvidence)
+	assert.True(t, normalized.HasThreadContext)
+}
+
+func TestReformatAlerts_RegeneratesOutdatedAlerts(t *testing.T) {
+	t.Setenv(ENV_FOLLOW_UP_DELAYS, "off")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	alert := benchmarkAlert()
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	notifications, _, err := db.GetUserNotifications(alert.FUDUserID, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	notificationID := notifications[0].NotificationID
+
+	bot.handleReformatAlertsCommand(2, nil)
+	assert.Contains(t, transport.sentMessages()[len(transport.sentMessages())-1].Text, "nothing to regenerate")
+
+	// Stored by an older version
+	legacy := alert
+	legacy.AlertSeverity = "HIGH"
+	legacy.FUDUsername = "@" + alert.FUDUsername
+	require.NoError(t, db.UpdateNotificationPayload(notificationID, legacy, 0))
+
+	bot.handleReformatAlertsCommand(2, nil)
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "Messages edited: 1")
+	require.Len(t, transport.edited, 1)
+	assert.Equal(t, int64(1), transport.edited[0].ChatID)
+	assert.Contains(t, transport.edited[0].Text, "/detail_"+notificationID)
+
+	stored, err := db.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, "high", stored.AlertSeverity)
+	assert.Equal(t, alert.FUDUsername, stored.FUDUsername)
+	outdated, err := db.CountOutdatedNotifications(ALERT_FORMAT_VERSION)
+	require.NoError(t, err)
+	assert.Zero(t, outdated)
+
+	bot.handleReformatAlertsCommand(2, []string{"all"})
+	assert.Len(t, transport.edited, 2, "all re-renders current alerts too")
+}
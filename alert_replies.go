@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// alertReplyCommand is what a short command sent as a reply to an alert expands to
+type alertReplyCommand struct {
+	prefix         string
+	byNotification bool // completed with the notification ID instead of the username
+}
+
+// alertReplyCommands are the short commands understood in replies to an alert, e.g. "history" for /history_<user>
+var alertReplyCommands = map[string]alertReplyCommand{
+	"history":   {prefix: "/history_"},
+	"analyze":   {prefix: "/analyze_"},
+	"cache":     {prefix: "/cache_"},
+	"report":    {prefix: "/report_"},
+	"riskchart": {prefix: "/riskchart_"},
+	"graph":     {prefix: "/graph_"},
+	"network":   {prefix: "/network_"},
+	"export":    {prefix: "/export_"},
+	"detail":    {prefix: "/detail_", byNotification: true},
+	"ack":       {prefix: "/ack_", byNotification: true},
+	"assign":    {prefix: "/assign_", byNotification: true},
+	"resolve":   {prefix: "/resolve_", byNotification: true},
+}
+
+// handleAlertReply expands a short command replying to an alert of the bot into the full command about
+// the alerted user and routes it. Returns false when the message is no such reply.
+func (b *BotController) handleAlertReply(update TelegramUpdate, text string) bool {
+	reply := update.Message.ReplyToMessage
+	if reply == nil || !reply.From.IsBot {
+		return false
+	}
+	parts := strings.Fields(text)
+	if len(parts) == 0 {
+		return false
+	}
+	// "history", "/history" and "/history@botname" all work
+	word, _, _ := strings.Cut(strings.ToLower(strings.TrimPrefix(parts[0], "/")), "@")
+	target, ok := alertReplyCommands[word]
+	if !ok {
+		return false
+	}
+	// "/report <link> <reason>" reports someone else
+	if word == "report" && strings.HasPrefix(parts[0], "/") && len(parts) > 1 {
+		return false
+	}
+
+	chatID := update.Message.Chat.ID
+	alertMessage, err := b.dbService.GetAlertMessageByTelegramID(chatID, reply.MessageID)
+	if err != nil {
+		usage := target.prefix + "username"
+		if target.byNotification {
+			usage = target.prefix + "id"
+		}
+		go b.SendMessage(chatID, fmt.Sprintf("❌ Reply with %s to an alert message, or use %s", word, usage))
+		return true
+	}
+	subject := alertMessage.NotificationID
+	if !target.byNotification {
+		notification, err := b.dbService.GetNotificationRecord(alertMessage.NotificationID)
+		if err != nil {
+			go b.SendMessage(chatID, "❌ Notification not found or expired.")
+			return true
+		}
+		subject = notification.FUDUsername
+	}
+
+	expanded := strings.Join(append([]string{target.prefix + subject}, parts[1:]...), " ")
+	log.Printf("Reply to alert %s in chat %d: %q runs %s", alertMessage.NotificationID, chatID, parts[0], expanded)
+	if b.allowExpandedCommand(update, expanded) {
+		b.routeCommand(update, expanded, false)
+	}
+	return true
+}
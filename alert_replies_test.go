@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramUpdateReplyToMessage(t *testing.T) {
+	var update TelegramUpdate
+	require.NoError(t, json.Unmarshal([]byte(`{"update_id":1,"message":{"message_id":8,"chat":{"id":5},"text":"history",
+		"reply_to_message":{"message_id":3,"from":{"id":99,"is_bot":true,"username":"fud_bot"},"text":"🚨 FUD ALERT"}}}`), &update))
+	require.NotNil(t, update.Message.ReplyToMessage)
+	assert.Equal(t, int64(3), update.Message.ReplyToMessage.MessageID)
+	assert.True(t, update.Message.ReplyToMessage.From.IsBot)
+}
+
+func TestBotController_AlertReply(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[5] = true
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "attacker"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "t1", Text: "this project is a rug", CreatedAt: time.Now(), UserID: "u1", Username: "attacker"}))
+	require.NoError(t, db.SaveNotification("n1", FUDAlertNotification{FUDUserID: "u1", FUDUsername: "attacker", AlertSeverity: "high"}, time.Hour))
+	require.NoError(t, db.SaveAlertMessage("n1", 5, 30))
+
+	replyTo := func(messageID int64, fromBot bool, text string) TelegramUpdate {
+		update := newTestUpdate(5, text)
+		update.Message.ReplyToMessage = &TelegramReplyMessage{MessageID: messageID}
+		update.Message.ReplyToMessage.From.IsBot = fromBot
+		return update
+	}
+	lastText := func() string {
+		sent := transport.sentMessages()
+		if len(sent) == 0 {
+			return ""
+		}
+		return sent[len(sent)-1].Text
+	}
+
+	bot.handleUpdate(replyTo(30, true, "history"))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(lastText(), "this project is a rug")
+	}, time.Second, 10*time.Millisecond, "the user is taken from the replied alert")
+
+	bot.handleUpdate(replyTo(30, true, "/ack@fud_bot"))
+	assert.Eventually(t, func() bool {
+		notification, err := db.GetNotificationRecord("n1")
+		return err == nil && notification.State == ALERT_STATE_ACKED && notification.AckedBy == "@tester"
+	}, time.Second, 10*time.Millisecond)
+
+	bot.handleUpdate(replyTo(31, true, "history"))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(lastText(), "Reply with history to an alert message, or use /history_username")
+	}, time.Second, 10*time.Millisecond)
+
+	assert.False(t, bot.handleAlertReply(replyTo(30, false, "history"), "history"), "replies to people are left alone")
+	assert.False(t, bot.handleAlertReply(replyTo(30, true, "thanks"), "thanks"))
+	assert.False(t, bot.handleAlertReply(replyTo(30, true, "/report x.com/a/status/1 spreading lies"), "/report x.com/a/status/1 spreading lies"))
+
+	t.Run("Replies that run expensive commands use the expensive limit", func(t *testing.T) {
+		before := len(transport.sentMessages())
+		for i := 0; i < EXPENSIVE_COMMAND_BURST+2; i++ {
+			update := replyTo(30, true, "export")
+			update.Message.From.ID = 800
+			bot.handleUpdate(update)
+		}
+
+		warnings := func() int {
+			count := 0
+			for _, msg := range transport.sentMessages()[before:] {
+				if strings.Contains(msg.Text, "limit for analysis and export") {
+					count++
+				}
+			}
+			return count
+		}
+		assert.Eventually(t, func() bool { return warnings() == 1 }, time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 1, warnings())
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"slices"
+	"strings"
+)
+
+// How broadcast alerts of a severity are delivered, changed at runtime with /threshold
+const (
+	ALERT_DELIVERY_BROADCAST = "broadcast" // every registered chat and the other sinks such as Discord
+	ALERT_DELIVERY_USERS     = "users"     // private chats on the notification list only, no groups or other sinks
+	ALERT_DELIVERY_LOG       = "log"       // stored and logged, /detail_ and /handoff still list it
+)
+
+var alertSeverities = []string{"critical", "high", "medium", "low"}
+
+var alertDeliveryDescriptions = map[string]string{
+	ALERT_DELIVERY_BROADCAST: "all chats and sinks",
+	ALERT_DELIVERY_USERS:     "private chats only",
+	ALERT_DELIVERY_LOG:       "stored, not delivered",
+}
+
+// alertDelivery is how a broadcast alert of a severity is delivered. The war room delivers every severity.
+func (b *BotController) alertDelivery(severity string) string {
+	if b.warRoom.active() {
+		return ALERT_DELIVERY_BROADCAST
+	}
+	thresholds, err := b.dbService.GetAlertThresholds()
+	if err != nil {
+		log.Printf("Failed to load alert thresholds, broadcasting: %v", err)
+		return ALERT_DELIVERY_BROADCAST
+	}
+	if threshold, ok := thresholds[strings.ToLower(severity)]; ok {
+		return threshold.Delivery
+	}
+	return ALERT_DELIVERY_BROADCAST
+}
+
+// handleThresholdCommand shows or changes the delivery of each severity:
+// /threshold [severity broadcast|users|log] [reset [severity]]
+func (b *BotController) handleThresholdCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 {
+		b.sendAlertThresholds(chatID)
+		return
+	}
+
+	usage := "❌ Usage: /threshold critical|high|medium|low broadcast|users|log, /threshold reset [severity]"
+	thresholds, err := b.dbService.GetAlertThresholds()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading alert thresholds: %v", err))
+		return
+	}
+
+	if strings.ToLower(args[0]) == "reset" {
+		severities := alertSeverities
+		if len(args) > 1 {
+			severity := strings.ToLower(args[1])
+			if !slices.Contains(alertSeverities, severity) {
+				b.SendMessage(chatID, usage)
+				return
+			}
+			severities = []string{severity}
+		}
+		if err := b.dbService.DeleteAlertThresholds(severities); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error saving alert thresholds: %v", err))
+			return
+		}
+		for _, severity := range severities {
+			if threshold, ok := thresholds[severity]; ok {
+				b.dbService.RecordConfigChange("alert_threshold:"+severity, actor, threshold.Delivery, ALERT_DELIVERY_BROADCAST)
+			}
+		}
+		log.Printf("🎚 Alert thresholds of %s reset by %s", strings.Join(severities, ", "), actor)
+		b.sendAlertThresholds(chatID)
+		return
+	}
+
+	if len(args) != 2 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	severity, delivery := strings.ToLower(args[0]), strings.ToLower(args[1])
+	if _, ok := alertDeliveryDescriptions[delivery]; !ok || !slices.Contains(alertSeverities, severity) {
+		b.SendMessage(chatID, usage)
+		return
+	}
+
+	err = b.dbService.SaveAlertThreshold(AlertThresholdModel{Severity: severity, Delivery: delivery, UpdatedBy: actor})
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving alert thresholds: %v", err))
+		return
+	}
+	previous := ALERT_DELIVERY_BROADCAST
+	if threshold, ok := thresholds[severity]; ok {
+		previous = threshold.Delivery
+	}
+	b.dbService.RecordConfigChange("alert_threshold:"+severity, actor, previous, delivery)
+	log.Printf("🎚 %s alerts set to %s by %s", severity, delivery, actor)
+	b.sendAlertThresholds(chatID)
+}
+
+// sendAlertThresholds shows how each severity is delivered
+func (b *BotController) sendAlertThresholds(chatID int64) {
+	thresholds, err := b.dbService.GetAlertThresholds()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading alert thresholds: %v", err))
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString("🎚 <b>Alert thresholds</b>\n\n")
+	for _, severity := range alertSeverities {
+		delivery := ALERT_DELIVERY_BROADCAST
+		changed := ""
+		if threshold, ok := thresholds[severity]; ok {
+			delivery = threshold.Delivery
+			changed = fmt.Sprintf(", set by %s %s", html.EscapeString(threshold.UpdatedBy), threshold.UpdatedAt.UTC().Format("2006-01-02"))
+		}
+		message.WriteString(fmt.Sprintf("%s <b>%s</b>: %s (%s%s)\n", b.formatter.getSeverityEmoji(severity), severity, delivery, alertDeliveryDescriptions[delivery], changed))
+	}
+	message.WriteString("\nChange with /threshold high users, undo with /threshold reset [severity].")
+	message.WriteString("\nThe minimum severity each chat chose at onboarding still applies. The war room broadcasts every severity.")
+	b.SendMessage(chatID, message.String())
+}
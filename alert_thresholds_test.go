@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertThresholds(t *testing.T) {
+	t.Setenv(ENV_FOLLOW_UP_DELAYS, "off")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[5] = true
+	bot.chatIDs[-1005] = true
+
+	bot.handleThresholdCommand(1, "@admin", []string{"high", "users"})
+	bot.handleThresholdCommand(1, "@admin", []string{"low", "log"})
+	bot.handleThresholdCommand(1, "@admin", []string{"urgent", "log"})
+	sent := transport.sentMessages()
+	require.Len(t, sent, 3)
+	assert.Contains(t, sent[1].Text, "<b>high</b>: users (private chats only, set by @admin")
+	assert.Contains(t, sent[1].Text, "<b>critical</b>: broadcast")
+	assert.Contains(t, sent[2].Text, "Usage")
+	assert.Equal(t, ALERT_DELIVERY_USERS, bot.alertDelivery("HIGH"))
+
+	alert := benchmarkAlert()
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	sent = transport.sentMessages()[3:]
+	require.Len(t, sent, 1, "high alerts skip the group")
+	assert.Equal(t, int64(5), sent[0].ChatID)
+
+	alert.AlertSeverity = "low"
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	assert.Len(t, transport.sentMessages(), 4, "low alerts are only stored")
+	notifications, total, err := db.GetUserNotifications(alert.FUDUserID, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, notifications, 2)
+
+	bot.handleThresholdCommand(1, "@admin", []string{"reset"})
+	assert.Equal(t, ALERT_DELIVERY_BROADCAST, bot.alertDelivery("low"))
+	alert.AlertSeverity = "high"
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	assert.Len(t, transport.sentMessages(), 7, "the reset report and both chats")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+)
+
+// Handling states of an alert
+const (
+	ALERT_STATE_OPEN     = "open"
+	ALERT_STATE_ACKED    = "acked"
+	ALERT_STATE_RESOLVED = "resolved"
+)
+
+const OPEN_ALERTS_SHOWN = 20 // alerts listed by /openalerts, the rest are counted
+
+var alertStateEmojis = map[string]string{
+	ALERT_STATE_OPEN:     "🆕",
+	ALERT_STATE_ACKED:    "👀",
+	ALERT_STATE_RESOLVED: "✅",
+}
+
+// handleAlertStateCommand processes /ack_<id>, /assign_<id> @teammate and /resolve_<id>
+func (b *BotController) handleAlertStateCommand(chatID int64, actor string, command string, args []string) {
+	action, notificationID, _ := strings.Cut(strings.TrimPrefix(command, "/"), "_")
+	notification, err := b.dbService.GetNotificationRecord(notificationID)
+	if err != nil {
+		b.SendMessage(chatID, "❌ Notification not found or expired.")
+		return
+	}
+	if notification.State == ALERT_STATE_RESOLVED && action != "assign" {
+		b.SendMessage(chatID, fmt.Sprintf("ℹ️ Alert <code>%s</code> on @%s was resolved by %s already.", notificationID, notification.FUDUsername, html.EscapeString(notification.ResolvedBy)))
+		return
+	}
+
+	now := time.Now()
+	updates := make(map[string]interface{})
+	var reply string
+	switch action {
+	case "ack":
+		updates["state"] = ALERT_STATE_ACKED
+		updates["acked_by"] = actor
+		updates["acked_at"] = now
+		// Acknowledging an unassigned alert takes it
+		if notification.AssignedTo == "" {
+			updates["assigned_to"] = actor
+		}
+		reply = fmt.Sprintf("👀 Alert <code>%s</code> on @%s acknowledged by %s", notificationID, notification.FUDUsername, html.EscapeString(actor))
+	case "assign":
+		if len(args) != 1 {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Usage: /assign_%s @teammate", notificationID))
+			return
+		}
+		assignee := "@" + strings.TrimPrefix(args[0], "@")
+		updates["assigned_to"] = assignee
+		// Assigning a resolved alert reopens it
+		if notification.State == ALERT_STATE_RESOLVED {
+			updates["state"] = ALERT_STATE_OPEN
+		}
+		reply = fmt.Sprintf("📌 Alert <code>%s</code> on @%s assigned to %s by %s", notificationID, notification.FUDUsername, html.EscapeString(assignee), html.EscapeString(actor))
+	case "resolve":
+		updates["state"] = ALERT_STATE_RESOLVED
+		updates["resolved_by"] = actor
+		updates["resolved_at"] = now
+		reply = fmt.Sprintf("✅ Alert <code>%s</code> on @%s resolved by %s", notificationID, notification.FUDUsername, html.EscapeString(actor))
+	default:
+		return
+	}
+
+	if err := b.dbService.UpdateNotificationState(notificationID, updates); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error updating the alert: %v", err))
+		return
+	}
+	log.Printf("Alert %s on @%s: %s by %s", notificationID, notification.FUDUsername, action, actor)
+	b.SendMessage(chatID, reply)
+}
+
+// handleOpenAlertsCommand lists the alerts nobody resolved yet: /openalerts [mine|unassigned|@teammate]
+func (b *BotController) handleOpenAlertsCommand(chatID int64, actor string, args []string) {
+	assignee, label := "", "all"
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "mine":
+			assignee, label = actor, "assigned to "+actor
+		case "unassigned":
+			assignee, label = "-", "unassigned"
+		default:
+			assignee = "@" + strings.TrimPrefix(args[0], "@")
+			label = "assigned to " + assignee
+		}
+	}
+
+	notifications, total, err := b.dbService.GetOpenNotifications(assignee, OPEN_ALERTS_SHOWN)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading open alerts: %v", err))
+		return
+	}
+	if total == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("✅ No open alerts (%s)", html.EscapeString(label)))
+		return
+	}
+	b.SendMessage(chatID, b.formatOpenAlerts(notifications, total, label, time.Now()))
+}
+
+// formatOpenAlerts is the /openalerts list
+func (b *BotController) formatOpenAlerts(notifications []NotificationModel, total int64, label string, now time.Time) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("📋 <b>Open alerts</b> (%s): %d\n\n", html.EscapeString(label), total))
+	for _, notification := range notifications {
+		handling := "unassigned"
+		if notification.AssignedTo != "" {
+			handling = "assigned to " + html.EscapeString(notification.AssignedTo)
+		}
+		if notification.State == ALERT_STATE_ACKED {
+			handling = "acked by " + html.EscapeString(notification.AckedBy) + ", " + handling
+		}
+		message.WriteString(fmt.Sprintf("%s %s @%s · %s · %s ago\n   /detail_%s · /ack_%s · /resolve_%s\n",
+			alertStateEmojis[notification.State], b.formatter.getSeverityEmoji(notification.AlertSeverity), notification.FUDUsername,
+			handling, now.Sub(notification.CreatedAt).Round(time.Minute), notification.NotificationID, notification.NotificationID, notification.NotificationID))
+	}
+	if total > int64(len(notifications)) {
+		message.WriteString(fmt.Sprintf("... and %d more\n", total-int64(len(notifications))))
+	}
+	message.WriteString("\nFilter with /openalerts mine|unassigned|@teammate, hand over with /assign_&lt;id&gt; @teammate.")
+	return message.String()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertStateCommands(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveNotification("n1", FUDAlertNotification{FUDUserID: "u1", FUDUsername: "attacker", AlertSeverity: "critical"}, time.Hour))
+	require.NoError(t, db.SaveNotification("n2", FUDAlertNotification{FUDUserID: "u2", FUDUsername: "grumbler", AlertSeverity: "low"}, time.Hour))
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	notification, err := db.GetNotificationRecord("n1")
+	require.NoError(t, err)
+	assert.Equal(t, ALERT_STATE_OPEN, notification.State)
+
+	bot.handleAlertStateCommand(1, "@alice", "/ack_n1", nil)
+	notification, err = db.GetNotificationRecord("n1")
+	require.NoError(t, err)
+	assert.Equal(t, ALERT_STATE_ACKED, notification.State)
+	assert.Equal(t, "@alice", notification.AckedBy)
+	assert.Equal(t, "@alice", notification.AssignedTo, "acking an unassigned alert takes it")
+	require.NotNil(t, notification.AckedAt)
+
+	bot.handleAlertStateCommand(1, "@alice", "/assign_n2", []string{"bob"})
+	notification, err = db.GetNotificationRecord("n2")
+	require.NoError(t, err)
+	assert.Equal(t, "@bob", notification.AssignedTo)
+	assert.Equal(t, ALERT_STATE_OPEN, notification.State)
+
+	bot.handleAlertStateCommand(1, "@bob", "/resolve_n2", nil)
+	notification, err = db.GetNotificationRecord("n2")
+	require.NoError(t, err)
+	assert.Equal(t, ALERT_STATE_RESOLVED, notification.State)
+	assert.Equal(t, "@bob", notification.ResolvedBy)
+
+	bot.handleAlertStateCommand(1, "@alice", "/ack_n2", nil)
+	bot.handleAlertStateCommand(1, "@alice", "/ack_missing", nil)
+	sent := transport.sentMessages()
+	require.Len(t, sent, 5)
+	assert.Contains(t, sent[3].Text, "resolved by @bob already")
+	assert.True(t, strings.HasPrefix(sent[4].Text, "❌"))
+
+	// Assigning a resolved alert reopens it
+	bot.handleAlertStateCommand(1, "@alice", "/assign_n2", []string{"@carol"})
+	notification, err = db.GetNotificationRecord("n2")
+	require.NoError(t, err)
+	assert.Equal(t, ALERT_STATE_OPEN, notification.State)
+	assert.Equal(t, "@carol", notification.AssignedTo)
+}
+
+func TestOpenAlerts(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveNotification("n1", FUDAlertNotification{FUDUserID: "u1", FUDUsername: "attacker", AlertSeverity: "critical"}, time.Hour))
+	require.NoError(t, db.SaveNotification("n2", FUDAlertNotification{FUDUserID: "u2", FUDUsername: "grumbler", AlertSeverity: "low"}, time.Hour))
+	require.NoError(t, db.SaveNotification("n3", FUDAlertNotification{FUDUserID: "u3", FUDUsername: "settled", AlertSeverity: "high"}, time.Hour))
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.handleAlertStateCommand(1, "@alice", "/ack_n1", nil)
+	bot.handleAlertStateCommand(1, "@alice", "/resolve_n3", nil)
+
+	bot.handleOpenAlertsCommand(1, "@alice", nil)
+	bot.handleOpenAlertsCommand(1, "@Alice", []string{"mine"})
+	bot.handleOpenAlertsCommand(1, "@alice", []string{"unassigned"})
+	bot.handleOpenAlertsCommand(1, "@alice", []string{"@bob"})
+	sent := transport.sentMessages()
+	require.Len(t, sent, 6)
+
+	all := sent[2].Text
+	assert.Contains(t, all, "Open alerts</b> (all): 2")
+	assert.Contains(t, all, "@attacker · acked by @alice, assigned to @alice")
+	assert.Contains(t, all, "@grumbler · unassigned")
+	assert.Contains(t, all, "/ack_n2")
+	assert.NotContains(t, all, "@settled")
+
+	assert.Contains(t, sent[3].Text, "(assigned to @Alice): 1")
+	assert.Contains(t, sent[4].Text, "(unassigned): 1")
+	assert.Contains(t, sent[4].Text, "@grumbler")
+	assert.Contains(t, sent[5].Text, "No open alerts (assigned to @bob)")
+}
+
+func TestHandoffSkipsAcknowledgedAlerts(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveNotification("n1", FUDAlertNotification{FUDUserID: "u1", FUDUsername: "attacker", AlertSeverity: "critical"}, time.Hour))
+	require.NoError(t, db.SaveNotification("n2", FUDAlertNotification{FUDUserID: "u2", FUDUsername: "raider", AlertSeverity: "critical"}, time.Hour))
+
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	bot.handleAlertStateCommand(1, "@alice", "/ack_n1", nil)
+	report, err := bot.buildHandoffReport(time.Now(), HANDOFF_DEFAULT_PERIOD)
+	require.NoError(t, err)
+	require.Len(t, report.Unacknowledged, 1)
+	assert.Equal(t, "raider", report.Unacknowledged[0].FUDUsername)
+	assert.Contains(t, bot.formatHandoffReport(report), "/ack_n2")
+}
+
+func TestAlertWorkflowHiddenFromRedactedChats(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveNotification("n1", FUDAlertNotification{FUDUserID: "u1", FUDUsername: "attacker", AlertSeverity: "critical"}, time.Hour))
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+	bot.chatIDs[5] = true
+	bot.handleRedactionCommand(1, []string{"5", REDACTION_AMBASSADOR})
+
+	for i, text := range []string{"/openalerts", "/ack_n1", "/assign_n1 @bob", "/resolve_n1"} {
+		bot.handleUpdate(newTestUpdate(5, text))
+		assert.Eventually(t, func() bool {
+			sent := transport.sentMessages()
+			return len(sent) == i+2 && strings.Contains(sent[len(sent)-1].Text, "not available in this chat")
+		}, time.Second, 10*time.Millisecond, text)
+	}
+	for _, msg := range transport.sentMessages() {
+		assert.NotContains(t, msg.Text, "attacker")
+	}
+	notification, err := db.GetNotificationRecord("n1")
+	require.NoError(t, err)
+	assert.Equal(t, ALERT_STATE_OPEN, notification.State)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const ALIAS_MAX_PER_CHAT = 30
+const ALIAS_MAX_EXPANSION = 200
+
+var aliasNameRegex = regexp.MustCompile(`^[a-z0-9]{1,16}$`)
+
+// expandAlias replaces the command of text with the chat's alias expansion, keeping the arguments:
+// with "/alias set f fudlist" the text "/f 2" becomes "/fudlist 2"
+func (b *BotController) expandAlias(chatID int64, text string) (string, bool) {
+	parts := strings.Fields(text)
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "/") {
+		return "", false
+	}
+	name := strings.ToLower(strings.TrimPrefix(parts[0], "/"))
+	if !aliasNameRegex.MatchString(name) {
+		return "", false
+	}
+	alias, err := b.dbService.GetChatAlias(chatID, name)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load alias /%s of chat %d: %v", name, chatID, err)
+		}
+		return "", false
+	}
+	return strings.Join(append([]string{"/" + alias.Expansion}, parts[1:]...), " "), true
+}
+
+// handleAliasCommand manages the command shortcuts of a chat: /alias [list|set <name> <command>|remove <name>]
+func (b *BotController) handleAliasCommand(chatID int64, actor string, args []string) {
+	usage := "❌ Usage: /alias set f fudlist|remove f|list"
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleAliasList(chatID)
+		return
+	}
+	if len(args) < 2 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(args[1], "/"))
+	switch strings.ToLower(args[0]) {
+	case "set":
+		if !aliasNameRegex.MatchString(name) || len(args) < 3 {
+			b.SendMessage(chatID, "❌ Usage: /alias set &lt;name&gt; &lt;command&gt; [args]\nNames are up to 16 lowercase letters and digits.")
+			return
+		}
+		expansion := strings.TrimPrefix(strings.Join(args[2:], " "), "/")
+		if expansion == "" || len(expansion) > ALIAS_MAX_EXPANSION {
+			b.SendMessage(chatID, fmt.Sprintf("❌ The command must be 1 to %d characters", ALIAS_MAX_EXPANSION))
+			return
+		}
+		_, err := b.dbService.GetChatAlias(chatID, name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			count, err := b.dbService.CountChatAliases(chatID)
+			if err == nil && count >= ALIAS_MAX_PER_CHAT {
+				b.SendMessage(chatID, fmt.Sprintf("❌ This chat already has %d aliases, remove one first", ALIAS_MAX_PER_CHAT))
+				return
+			}
+		}
+		err = b.dbService.SetChatAlias(ChatAliasModel{ChatID: chatID, Alias: name, Expansion: expansion, CreatedBy: actor})
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error saving alias: %v", err))
+			return
+		}
+		log.Printf("⌨️ %s set alias /%s = /%s in chat %d", actor, name, expansion, chatID)
+		b.SendMessage(chatID, fmt.Sprintf("✅ /%s now runs <code>/%s</code>\nBuilt-in commands always take precedence over aliases.", name, html.EscapeString(expansion)))
+	case "remove":
+		removed, err := b.dbService.DeleteChatAlias(chatID, name)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error removing alias: %v", err))
+			return
+		}
+		if !removed {
+			b.SendMessage(chatID, fmt.Sprintf("❌ No alias /%s in this chat", html.EscapeString(name)))
+			return
+		}
+		b.SendMessage(chatID, fmt.Sprintf("🗑 Removed alias /%s", html.EscapeString(name)))
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+func (b *BotController) handleAliasList(chatID int64) {
+	aliases, err := b.dbService.GetChatAliases(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading aliases: %v", err))
+		return
+	}
+	if len(aliases) == 0 {
+		b.SendMessage(chatID, "⌨️ No aliases in this chat.\n\nUsage: /alias set f fudlist")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("⌨️ <b>Aliases</b> (%d)\n\n", len(aliases)))
+	for _, alias := range aliases {
+		message.WriteString(fmt.Sprintf("• /%s → <code>/%s</code>\n", alias.Alias, html.EscapeString(alias.Expansion)))
+	}
+	b.SendMessage(chatID, message.String())
+}
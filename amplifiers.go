@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	AMPLIFIER_RECHECK_DELAY     = time.Hour // retweets keep coming in after detection
+	AMPLIFIER_MAX_PAGES         = 3
+	AMPLIFIER_MIN_FLAGGED_POSTS = 2
+	AMPLIFIER_WINDOW            = 7 * 24 * time.Hour
+	AMPLIFIER_LIST_LIMIT        = 5
+)
+
+// TrackAmplifiers records who retweeted a flagged post now and once more after AMPLIFIER_RECHECK_DELAY.
+// The provider does not expose likers, so retweets are the only amplification signal.
+func TrackAmplifiers(twitterApi TwitterAPI, dbService *DatabaseService, tweetID string, fudUserID string) {
+	go recordAmplifiers(twitterApi, dbService, tweetID, fudUserID)
+	time.AfterFunc(AMPLIFIER_RECHECK_DELAY, func() {
+		recordAmplifiers(twitterApi, dbService, tweetID, fudUserID)
+	})
+}
+
+func recordAmplifiers(twitterApi TwitterAPI, dbService *DatabaseService, tweetID string, fudUserID string) {
+	var amplifications []AmplificationModel
+	cursor := ""
+	for page := 0; page < AMPLIFIER_MAX_PAGES; page++ {
+		resp, err := twitterApi.GetTweetRetweeters(twitterapi.TweetRetweetersRequest{TweetID: tweetID, Cursor: cursor})
+		if err != nil {
+			log.Printf("Failed to get retweeters of %s: %v", tweetID, err)
+			break
+		}
+		for _, user := range resp.Users {
+			amplifications = append(amplifications, AmplificationModel{
+				TweetID:           tweetID,
+				AmplifierID:       user.Id,
+				AmplifierUsername: user.UserName,
+				FUDUserID:         fudUserID,
+			})
+		}
+		if !resp.HasNextPage || resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	err := dbService.SaveAmplifications(amplifications)
+	if err != nil {
+		log.Printf("Failed to save amplifiers of %s: %v", tweetID, err)
+		return
+	}
+	if len(amplifications) > 0 {
+		log.Printf("📢 Recorded %d retweeters of flagged post %s", len(amplifications), tweetID)
+	}
+}
+
+// formatAmplifiers lists accounts that consistently amplify flagged posts for campaign alerts
+func (b *BotController) formatAmplifiers(since time.Time) string {
+	amplifiers, err := b.dbService.GetTopAmplifiers(since, AMPLIFIER_MIN_FLAGGED_POSTS, AMPLIFIER_LIST_LIMIT)
+	if err != nil {
+		log.Printf("Failed to get amplifiers: %v", err)
+		return ""
+	}
+	if len(amplifiers) == 0 {
+		return ""
+	}
+
+	var message strings.Builder
+	message.WriteString("\n📢 <b>Amplifiers:</b>\n")
+	for _, amplifier := range amplifiers {
+		message.WriteString(fmt.Sprintf("• @%s — retweeted %d flagged posts by %d users\n", amplifier.Username, amplifier.FlaggedPosts, amplifier.FUDAuthors))
+	}
+	return strings.TrimRight(message.String(), "\n")
+}
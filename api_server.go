@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const API_TOKEN_HEADER = "X-Api-Token"
+
+// StartAPIServer exposes read-only analyst endpoints. It follows the same rules as the
+// profiling server: disabled when addr is empty, loopback only unless a token is set.
+// When signal tokens are configured it also accepts POST /api/signals from external tools,
+// authenticated by their own per-source tokens instead of the analyst token. Federation
+// tokens likewise enable POST /api/federation/indicators for partner deployments.
+func StartAPIServer(addr string, token string, dbService *DatabaseService, signalTokens map[string]string, signals signalQueue, federationTokens map[string]string, federation federationReceiver) error {
+	if addr == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid API address %s: %w", addr, err)
+	}
+	if token == "" && !isLoopbackHost(host) {
+		return fmt.Errorf("API on non-loopback address %s requires %s to be set", addr, ENV_API_TOKEN)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start API listener: %w", err)
+	}
+
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/", tokenGuard(API_TOKEN_HEADER, token, newAPIHandler(dbService)))
+		if len(signalTokens) > 0 {
+			mux.Handle("POST /api/signals", newSignalHandler(signalTokens, signals))
+		}
+		if len(federationTokens) > 0 {
+			mux.Handle("POST "+FEDERATION_INDICATORS_PATH, newFederationHandler(federationTokens, federation))
+		}
+		err := http.Serve(listener, mux)
+		if err != nil {
+			log.Printf("API server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("🌐 API available at http://%s/api/", listener.Addr())
+	if len(signalTokens) > 0 {
+		log.Printf("📨 Accepting signals from %d sources at http://%s/api/signals", len(signalTokens), listener.Addr())
+	}
+	if len(federationTokens) > 0 {
+		log.Printf("🛰 Accepting federated indicators from %d peers at http://%s%s", len(federationTokens), listener.Addr(), FEDERATION_INDICATORS_PATH)
+	}
+	return nil
+}
+
+func newAPIHandler(dbService *DatabaseService) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/graph/{username}", func(w http.ResponseWriter, r *http.Request) {
+		handleGraphRequest(w, r, dbService)
+	})
+	mux.HandleFunc("GET /api/events", func(w http.ResponseWriter, r *http.Request) {
+		handleEventsRequest(w, r, dbService)
+	})
+	mux.HandleFunc("GET /api/events/stream", func(w http.ResponseWriter, r *http.Request) {
+		handleEventStream(w, r, dbService, EVENTS_STREAM_POLL)
+	})
+	return mux
+}
+
+// handleGraphRequest serves GET /api/graph/{username}?format=graphml|dot
+func handleGraphRequest(w http.ResponseWriter, r *http.Request, dbService *DatabaseService) {
+	username := strings.TrimPrefix(r.PathValue("username"), "@")
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = GRAPH_FORMAT_GRAPHML
+	}
+	if format != GRAPH_FORMAT_GRAPHML && format != GRAPH_FORMAT_DOT {
+		http.Error(w, "format must be graphml or dot", http.StatusBadRequest)
+		return
+	}
+
+	user, err := dbService.GetUserByUsername(username)
+	if err != nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	graph, err := dbService.GetUserGraph(user.ID)
+	if err != nil {
+		log.Printf("Failed to build graph for %s: %v", username, err)
+		http.Error(w, "failed to build graph", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/graphml+xml"
+	if format == GRAPH_FORMAT_DOT {
+		contentType = "text/vnd.graphviz"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_graph.%s"`, user.Username, format))
+	rendered := []byte(renderUserGraph(graph, format))
+	seal := sealEvidence(rendered)
+	w.Header().Set("X-Content-SHA256", seal.Digest)
+	if seal.Signature != "" {
+		w.Header().Set("X-Content-Signature", seal.Signature)
+		w.Header().Set("X-Content-Signing-Key", seal.PublicKey)
+	}
+	w.Write(rendered)
+}
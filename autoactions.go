@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AUTO_ACTION_NOTIFY           = "notify"
+	AUTO_ACTION_WEBHOOK          = "webhook"
+	AUTO_ACTION_INCIDENT         = "incident"
+	AUTO_ACTION_MAX_THRESHOLD    = 1000
+	AUTO_ACTION_INCIDENT_DEFAULT = 2 * time.Hour
+	AUTO_ACTION_WEBHOOK_TIMEOUT  = 10 * time.Second
+	AUTO_ACTION_COMMUNITY_PREFIX = "community:"
+)
+
+// AutoActionEvent is what an auto-action webhook receives
+type AutoActionEvent struct {
+	RuleID      uint                 `json:"rule_id"`
+	CommunityID string               `json:"community_id,omitempty"`
+	UserID      string               `json:"user_id"`
+	Username    string               `json:"username"`
+	Detections  int                  `json:"detections"`
+	FUDType     string               `json:"fud_type"`
+	Alert       FUDAlertNotification `json:"alert"`
+}
+
+// runAutoActions starts the rules a confirmed detection makes due. The count of confirmed detections
+// is the message count of the FUD list entry, so heuristic alerts and clean results never count.
+// Rules are claimed here and run in the background, a slow webhook must not hold up the alerts.
+func (b *BotController) runAutoActions(alert FUDAlertNotification, notificationID string) {
+	if !isFUDDetection(alert) || alert.FUDType == HEURISTIC_FUD_TYPE || alert.FUDUserID == "" {
+		return
+	}
+	fudUser, err := b.dbService.GetFUDUser(alert.FUDUserID)
+	if err != nil {
+		return
+	}
+	rules, err := b.dbService.GetAutoActionRules()
+	if err != nil {
+		log.Printf("Failed to load auto-action rules: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if fudUser.MessageCount < rule.Threshold || (rule.CommunityID != "" && rule.CommunityID != alert.CommunityID) {
+			continue
+		}
+		run := &AutoActionRunModel{RuleID: rule.ID, UserID: fudUser.UserID, Username: fudUser.Username, Detections: fudUser.MessageCount}
+		claimed, err := b.dbService.ClaimAutoActionRun(run)
+		if err != nil {
+			log.Printf("Failed to record auto-action %d for %s: %v", rule.ID, fudUser.Username, err)
+			continue
+		}
+		if claimed {
+			go b.runAutoAction(rule, run.ID, fudUser, alert, notificationID)
+		}
+	}
+}
+
+func (b *BotController) runAutoAction(rule AutoActionRuleModel, runID uint, fudUser *FUDUserModel, alert FUDAlertNotification, notificationID string) {
+	err := b.executeAutoAction(rule, fudUser, alert, notificationID)
+	if err != nil {
+		log.Printf("❌ Auto-action %d (%s) for %s failed: %v", rule.ID, rule.Action, fudUser.Username, err)
+		b.dbService.SetAutoActionRunError(runID, err.Error())
+		b.SendMessage(rule.ChatID, fmt.Sprintf("❌ Auto-action #%d (%s) for @%s failed: %s", rule.ID, rule.Action, fudUser.Username, html.EscapeString(err.Error())))
+		return
+	}
+	log.Printf("🤖 Auto-action %d (%s) ran for %s after %d detections", rule.ID, rule.Action, fudUser.Username, fudUser.MessageCount)
+}
+
+func (b *BotController) executeAutoAction(rule AutoActionRuleModel, fudUser *FUDUserModel, alert FUDAlertNotification, notificationID string) error {
+	headline := fmt.Sprintf("🤖 <b>Auto-action #%d:</b> @%s reached %d confirmed detections", rule.ID, fudUser.Username, fudUser.MessageCount)
+	switch rule.Action {
+	case AUTO_ACTION_NOTIFY:
+		chatID, err := strconv.ParseInt(rule.Target, 10, 64)
+		if err != nil {
+			return err
+		}
+		return b.SendMessage(chatID, headline+"\n\n"+b.formatter.FormatCompact(alert, notificationID))
+	case AUTO_ACTION_WEBHOOK:
+		event := AutoActionEvent{RuleID: rule.ID, CommunityID: alert.CommunityID, UserID: fudUser.UserID, Username: fudUser.Username, Detections: fudUser.MessageCount, FUDType: fudUser.FUDType, Alert: alert}
+		return postAutoActionWebhook(rule.Target, event)
+	case AUTO_ACTION_INCIDENT:
+		if b.warRoom.active() {
+			return b.SendMessage(rule.ChatID, headline+"\n🛡 The war room is already open.")
+		}
+		duration, err := time.ParseDuration(rule.Target)
+		if err != nil {
+			return err
+		}
+		b.SendMessage(rule.ChatID, headline+"\n🛡 Opening the war room for "+duration.String())
+		b.startWarRoom(rule.ChatID, duration)
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", rule.Action)
+}
+
+func postAutoActionWebhook(url string, event AutoActionEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: AUTO_ACTION_WEBHOOK_TIMEOUT}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// handleAutoActionCommand manages the auto-action rules:
+// /autoaction [list|add N notify chat_id|webhook url|incident [2h] [community:id]|remove id]
+func (b *BotController) handleAutoActionCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleAutoActionList(chatID)
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "add":
+		b.handleAutoActionAdd(chatID, actor, args[1:])
+	case "remove":
+		if len(args) != 2 {
+			b.SendMessage(chatID, "❌ Usage: /autoaction remove rule_id")
+			return
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			b.SendMessage(chatID, "❌ Usage: /autoaction remove rule_id")
+			return
+		}
+		removed, err := b.dbService.DeleteAutoActionRule(uint(id))
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error removing rule: %v", err))
+			return
+		}
+		if !removed {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Rule #%d not found", id))
+			return
+		}
+		log.Printf("🤖 %s removed auto-action rule %d", actor, id)
+		b.SendMessage(chatID, fmt.Sprintf("✅ Rule #%d removed", id))
+	default:
+		b.SendMessage(chatID, autoActionUsage())
+	}
+}
+
+func autoActionUsage() string {
+	return "❌ Usage: /autoaction add N notify chat_id|webhook https://url|incident [2h] [community:id]"
+}
+
+func (b *BotController) handleAutoActionAdd(chatID int64, actor string, args []string) {
+	rule := &AutoActionRuleModel{ChatID: chatID, CreatedBy: actor}
+	if len(args) > 0 && strings.HasPrefix(strings.ToLower(args[len(args)-1]), AUTO_ACTION_COMMUNITY_PREFIX) {
+		rule.CommunityID = args[len(args)-1][len(AUTO_ACTION_COMMUNITY_PREFIX):]
+		args = args[:len(args)-1]
+		if _, err := b.dbService.GetCommunity(rule.CommunityID); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Community %s not found, see /community", html.EscapeString(rule.CommunityID)))
+			return
+		}
+	}
+	if len(args) < 2 {
+		b.SendMessage(chatID, autoActionUsage())
+		return
+	}
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil || threshold < 1 || threshold > AUTO_ACTION_MAX_THRESHOLD {
+		b.SendMessage(chatID, fmt.Sprintf("❌ N must be a number of detections from 1 to %d", AUTO_ACTION_MAX_THRESHOLD))
+		return
+	}
+	rule.Threshold = threshold
+	rule.Action = strings.ToLower(args[1])
+
+	switch rule.Action {
+	case AUTO_ACTION_NOTIFY:
+		if len(args) != 3 {
+			b.SendMessage(chatID, autoActionUsage())
+			return
+		}
+		if _, err := strconv.ParseInt(args[2], 10, 64); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Invalid chat ID: %s", html.EscapeString(args[2])))
+			return
+		}
+		rule.Target = args[2]
+	case AUTO_ACTION_WEBHOOK:
+		if len(args) != 3 || !(strings.HasPrefix(args[2], "https://") || strings.HasPrefix(args[2], "http://")) {
+			b.SendMessage(chatID, autoActionUsage())
+			return
+		}
+		rule.Target = args[2]
+	case AUTO_ACTION_INCIDENT:
+		duration := AUTO_ACTION_INCIDENT_DEFAULT
+		if len(args) == 3 {
+			duration, err = time.ParseDuration(args[2])
+			if err != nil || duration <= 0 || duration > WARROOM_MAX_DURATION {
+				b.SendMessage(chatID, "❌ Invalid duration. Use something like 30m or 2h (max 24h)")
+				return
+			}
+		} else if len(args) > 3 {
+			b.SendMessage(chatID, autoActionUsage())
+			return
+		}
+		rule.Target = duration.String()
+	default:
+		b.SendMessage(chatID, autoActionUsage())
+		return
+	}
+
+	err = b.dbService.SaveAutoActionRule(rule)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving rule: %v", err))
+		return
+	}
+	log.Printf("🤖 %s added auto-action rule %d: %s", actor, rule.ID, describeAutoActionRule(*rule))
+	b.SendMessage(chatID, fmt.Sprintf("✅ Rule #%d: %s\n\nIt runs once per user, on their first detection at or above the threshold.", rule.ID, describeAutoActionRule(*rule)))
+}
+
+// describeAutoActionRule renders a rule, e.g. "after 3 detections in community 123: notify chat -100"
+func describeAutoActionRule(rule AutoActionRuleModel) string {
+	scope := ""
+	if rule.CommunityID != "" {
+		scope = " in community " + html.EscapeString(rule.CommunityID)
+	}
+	action := ""
+	switch rule.Action {
+	case AUTO_ACTION_NOTIFY:
+		action = "notify chat " + rule.Target
+	case AUTO_ACTION_WEBHOOK:
+		action = "call " + html.EscapeString(rule.Target)
+	case AUTO_ACTION_INCIDENT:
+		action = "open the war room for " + rule.Target
+	}
+	return fmt.Sprintf("after %d detections%s: %s", rule.Threshold, scope, action)
+}
+
+func (b *BotController) handleAutoActionList(chatID int64) {
+	rules, err := b.dbService.GetAutoActionRules()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading rules: %v", err))
+		return
+	}
+	if len(rules) == 0 {
+		b.SendMessage(chatID, "🤖 No auto-actions.\n\nUsage: /autoaction add 3 notify -1001234567890\n/autoaction add 5 webhook https://mod.example/fud\n/autoaction add 10 incident 2h community:1234567890")
+		return
+	}
+	runs, err := b.dbService.CountAutoActionRuns()
+	if err != nil {
+		log.Printf("Failed to count auto-action runs: %v", err)
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🤖 <b>Auto-actions</b> (%d rules)\n\n", len(rules)))
+	for _, rule := range rules {
+		message.WriteString(fmt.Sprintf("#%d %s\n   ↳ ran for %d users, added by %s\n", rule.ID, describeAutoActionRule(rule), runs[rule.ID], html.EscapeString(rule.CreatedBy)))
+	}
+	message.WriteString("\n/autoaction remove rule_id")
+	b.SendMessage(chatID, message.String())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_AutoActionRules(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveCommunity(CommunityModel{ID: "c1", Ticker: "RODF"}))
+	reply := func(text string) string {
+		bot.handleAutoActionCommand(1, "@admin", strings.Fields(text)[1:])
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply("/autoaction"), "No auto-actions")
+	assert.Contains(t, reply("/autoaction add 3"), "Usage")
+	assert.Contains(t, reply("/autoaction add 0 notify -100"), "from 1 to")
+	assert.Contains(t, reply("/autoaction add 3 notify chat"), "Invalid chat ID")
+	assert.Contains(t, reply("/autoaction add 3 webhook ftp://host"), "Usage")
+	assert.Contains(t, reply("/autoaction add 3 incident 48h"), "Invalid duration")
+	assert.Contains(t, reply("/autoaction add 3 notify -100 community:nope"), "Community nope not found")
+
+	assert.Contains(t, reply("/autoaction add 3 notify -100"), "✅ Rule #1: after 3 detections: notify chat -100")
+	assert.Contains(t, reply("/autoaction add 5 incident community:c1"), "✅ Rule #2: after 5 detections in community c1: open the war room for 2h0m0s")
+
+	list := reply("/autoaction list")
+	assert.Contains(t, list, "(2 rules)")
+	assert.Contains(t, list, "ran for 0 users, added by @admin")
+
+	assert.Contains(t, reply("/autoaction remove 7"), "Rule #7 not found")
+	assert.Contains(t, reply("/autoaction remove #2"), "Rule #2 removed")
+	rules, err := db.GetAutoActionRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+}
+
+func TestBotController_RunAutoActions(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	events := make(chan AutoActionEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event AutoActionEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer server.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	require.NoError(t, db.SaveAutoActionRule(&AutoActionRuleModel{Threshold: 2, Action: AUTO_ACTION_NOTIFY, Target: "-100", ChatID: 1, CreatedBy: "@admin"}))
+	require.NoError(t, db.SaveAutoActionRule(&AutoActionRuleModel{Threshold: 2, Action: AUTO_ACTION_WEBHOOK, Target: server.URL, ChatID: 1, CreatedBy: "@admin"}))
+	require.NoError(t, db.SaveAutoActionRule(&AutoActionRuleModel{Threshold: 2, Action: AUTO_ACTION_WEBHOOK, Target: failing.URL, ChatID: 1, CreatedBy: "@admin"}))
+	require.NoError(t, db.SaveAutoActionRule(&AutoActionRuleModel{Threshold: 1, Action: AUTO_ACTION_NOTIFY, Target: "-200", ChatID: 1, CommunityID: "c1", CreatedBy: "@admin"}))
+
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u1", Username: "Shady", FUDType: "coordinated_attack", DetectedAt: time.Now(), MessageCount: 1}))
+	alert := FUDAlertNotification{FUDUserID: "u1", FUDUsername: "Shady", FUDType: "coordinated_attack", AlertSeverity: "high"}
+
+	bot.runAutoActions(alert, "n1")
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, transport.sentMessages(), "below the threshold and outside the community")
+
+	require.NoError(t, db.IncrementFUDUserMessageCount("u1", "t2"))
+	bot.runAutoActions(alert, "n2")
+	select {
+	case event := <-events:
+		assert.Equal(t, "Shady", event.Username)
+		assert.Equal(t, 2, event.Detections)
+		assert.Equal(t, "coordinated_attack", event.FUDType)
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not called")
+	}
+	require.Eventually(t, func() bool { return len(transport.sentMessages()) == 2 }, time.Second, 10*time.Millisecond)
+	byChat := map[int64]string{}
+	for _, msg := range transport.sentMessages() {
+		byChat[msg.ChatID] = msg.Text
+	}
+	assert.Contains(t, byChat[-100], "@Shady reached 2 confirmed detections")
+	assert.Contains(t, byChat[1], "failed: webhook returned status 502")
+
+	// A rule runs once per user however many detections follow
+	require.NoError(t, db.IncrementFUDUserMessageCount("u1", "t3"))
+	bot.runAutoActions(alert, "n3")
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, transport.sentMessages(), 2)
+	runs, err := db.CountAutoActionRuns()
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int64{1: 1, 2: 1, 3: 1}, runs)
+
+	alert.CommunityID = "c1"
+	bot.runAutoActions(alert, "n4")
+	require.Eventually(t, func() bool { return len(transport.sentMessages()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(-200), transport.sentMessages()[2].ChatID)
+
+	heuristic := alert
+	heuristic.FUDType = HEURISTIC_FUD_TYPE
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u2", Username: "Other", DetectedAt: time.Now(), MessageCount: 9}))
+	heuristic.FUDUserID = "u2"
+	bot.runAutoActions(heuristic, "n5")
+	runs, err = db.CountAutoActionRuns()
+	require.NoError(t, err)
+	assert.Len(t, runs, 4, "heuristic alerts never count")
+}
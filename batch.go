@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const ANALYSIS_BATCH_REFRESH = 5 * time.Second // how often the batch progress message is edited
+
+// batchRow is one line of the batch summary table
+type batchRow struct {
+	username    string
+	verdict     string
+	probability float64
+	rank        int // FUD first, then clean, then unfinished
+}
+
+// batchTaskRow reads the verdict of a finished batch task
+func batchTaskRow(task AnalysisTaskModel) batchRow {
+	row := batchRow{username: task.Username, rank: 2}
+	switch task.Status {
+	case ANALYSIS_STATUS_FAILED:
+		row.verdict = "failed"
+		return row
+	case ANALYSIS_STATUS_CANCELLED:
+		row.verdict = "cancelled"
+		return row
+	case ANALYSIS_STATUS_COMPLETED:
+	default:
+		row.verdict = "pending"
+		return row
+	}
+
+	var result AnalysisTaskResult
+	if err := json.Unmarshal([]byte(task.ResultData), &result); err != nil {
+		row.verdict = "done"
+		return row
+	}
+	row.probability = result.FUDProbability
+	if !result.IsFUD {
+		row.verdict, row.rank = "clean", 1
+		return row
+	}
+	row.verdict, row.rank = "FUD", 0
+	if result.UserRiskLevel != "" {
+		row.verdict = "FUD " + result.UserRiskLevel
+	}
+	return row
+}
+
+func isFinishedTaskStatus(status string) bool {
+	return status == ANALYSIS_STATUS_COMPLETED || status == ANALYSIS_STATUS_FAILED || status == ANALYSIS_STATUS_CANCELLED
+}
+
+// formatBatchProgress renders the live progress message of a batch
+func (b *BotController) formatBatchProgress(batch *AnalysisBatchModel, tasks []AnalysisTaskModel) string {
+	finished, fud, clean, failed := 0, 0, 0, 0
+	var running []string
+	for _, task := range tasks {
+		if !isFinishedTaskStatus(task.Status) {
+			running = append(running, "@"+task.Username)
+			continue
+		}
+		finished++
+		switch batchTaskRow(task).rank {
+		case 0:
+			fud++
+		case 1:
+			clean++
+		default:
+			failed++
+		}
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🔄 <b>Batch Analysis</b> <code>%s</code>\n\n", batch.ID))
+	message.WriteString(fmt.Sprintf("📊 <b>Progress:</b> %d/%d %s\n", finished, len(tasks), progressBar(finished, len(tasks))))
+	message.WriteString(fmt.Sprintf("🚨 FUD: %d • ✅ Clean: %d • ❌ Failed: %d\n", fud, clean, failed))
+	if batch.Skipped > 0 {
+		message.WriteString(fmt.Sprintf("⏭️ Skipped: %d (already being analyzed)\n", batch.Skipped))
+	}
+	if len(running) > 0 {
+		if len(running) > 10 {
+			running = append(running[:10], fmt.Sprintf("+%d more", len(running)-10))
+		}
+		message.WriteString(fmt.Sprintf("\n⏳ <b>In progress:</b> %s\n", strings.Join(running, ", ")))
+		message.WriteString("🔍 Use /tasks to follow single analyses")
+	} else if len(tasks) == 0 {
+		message.WriteString("\n⏭️ Nothing new to analyze")
+	} else {
+		message.WriteString("\n✅ All analyses finished, summary below")
+	}
+	return message.String()
+}
+
+// formatBatchSummary renders the consolidated results of a batch as a table, FUD users first
+func (b *BotController) formatBatchSummary(batch *AnalysisBatchModel, tasks []AnalysisTaskModel) string {
+	rows := make([]batchRow, 0, len(tasks))
+	fud := 0
+	for _, task := range tasks {
+		row := batchTaskRow(task)
+		if row.rank == 0 {
+			fud++
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].rank != rows[j].rank {
+			return rows[i].rank < rows[j].rank
+		}
+		return rows[i].probability > rows[j].probability
+	})
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("%-16s %-13s %s\n", "User", "Verdict", "Prob"))
+	for _, row := range rows {
+		probability := "-"
+		if row.rank < 2 {
+			probability = fmt.Sprintf("%.0f%%", row.probability*100)
+		}
+		table.WriteString(fmt.Sprintf("%-16s %-13s %s\n", "@"+row.username, row.verdict, probability))
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("📋 <b>Batch Analysis Summary</b> <code>%s</code>\n\n", batch.ID))
+	message.WriteString(fmt.Sprintf("🚨 <b>%d of %d users flagged as FUD</b>\n\n", fud, len(rows)))
+	message.WriteString("<pre>" + html.EscapeString(table.String()) + "</pre>")
+	if batch.Skipped > 0 {
+		message.WriteString(fmt.Sprintf("\n⏭️ %d users were skipped because they were already being analyzed", batch.Skipped))
+	}
+	message.WriteString("\n💡 Use /history_username or /export_username to dig into a user")
+	return message.String()
+}
+
+// progressBar draws done/total as a 10 cell bar
+func progressBar(done int, total int) string {
+	if total == 0 {
+		return ""
+	}
+	filled := done * 10 / total
+	return strings.Repeat("▓", filled) + strings.Repeat("░", 10-filled)
+}
+
+// monitorAnalysisBatch edits the batch progress message until every task finished, then posts the summary
+func (b *BotController) monitorAnalysisBatch(batchID string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastProgress := ""
+	for range ticker.C {
+		if b.refreshAnalysisBatch(batchID, &lastProgress) {
+			return
+		}
+	}
+}
+
+// refreshAnalysisBatch updates the progress message of the batch and posts the summary once all
+// tasks finished. It reports whether monitoring can stop.
+func (b *BotController) refreshAnalysisBatch(batchID string, lastProgress *string) bool {
+	batch, err := b.dbService.GetAnalysisBatch(batchID)
+	if err != nil {
+		log.Printf("Failed to get analysis batch %s for monitoring: %v", batchID, err)
+		return true
+	}
+	tasks, err := b.dbService.GetAnalysisBatchTasks(batchID)
+	if err != nil {
+		log.Printf("Failed to get tasks of analysis batch %s: %v", batchID, err)
+		return false
+	}
+
+	progress := b.formatBatchProgress(batch, tasks)
+	if progress != *lastProgress && batch.MessageID != 0 {
+		if err := b.EditMessage(batch.ChatID, batch.MessageID, progress); err != nil {
+			log.Printf("Failed to update progress message for batch %s: %v", batchID, err)
+		}
+		*lastProgress = progress
+	}
+
+	for _, task := range tasks {
+		if !isFinishedTaskStatus(task.Status) {
+			return false
+		}
+	}
+	completed, err := b.dbService.CompleteAnalysisBatch(batchID)
+	if err != nil {
+		log.Printf("Failed to complete analysis batch %s: %v", batchID, err)
+		return false
+	}
+	if completed && len(tasks) > 0 {
+		b.SendMessage(batch.ChatID, b.formatBatchSummary(batch, tasks))
+		log.Printf("📋 Batch %s finished: summary of %d analyses sent to chat %d", batchID, len(tasks), batch.ChatID)
+	}
+	return true
+}
+
+// ResumeAnalysisBatches restarts the monitors of batches whose summary was not posted before a restart
+func (b *BotController) ResumeAnalysisBatches() {
+	batches, err := b.dbService.GetRunningAnalysisBatches()
+	if err != nil {
+		log.Printf("Failed to load unfinished analysis batches: %v", err)
+		return
+	}
+	for _, batch := range batches {
+		go b.monitorAnalysisBatch(batch.ID, ANALYSIS_BATCH_REFRESH)
+	}
+}
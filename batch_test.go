@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_AnalysisBatchSummary(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	batch := &AnalysisBatchModel{ID: "b1", ChatID: 5, MessageID: 42, Status: ANALYSIS_STATUS_RUNNING, Total: 3, Skipped: 1}
+	require.NoError(t, db.CreateAnalysisBatch(batch))
+	for _, username := range []string{"calm", "shady", "broken"} {
+		_, _, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: username, Username: username, TelegramChatID: 5, Kind: ANALYSIS_KIND_BATCH, BatchID: "b1", Status: ANALYSIS_STATUS_PENDING})
+		require.NoError(t, err)
+	}
+
+	lastProgress := ""
+	require.NoError(t, db.CompleteAnalysisTask("calm", `{"analysis_complete":true,"is_fud":false,"fud_probability":0.1}`))
+	assert.False(t, bot.refreshAnalysisBatch("b1", &lastProgress))
+	require.Len(t, transport.edited, 1)
+	assert.Equal(t, int64(42), transport.edited[0].MessageID)
+	assert.Contains(t, transport.edited[0].Text, "1/3")
+	assert.Contains(t, transport.edited[0].Text, "@shady, @broken")
+
+	// Unchanged progress is not edited again
+	assert.False(t, bot.refreshAnalysisBatch("b1", &lastProgress))
+	assert.Len(t, transport.edited, 1)
+	assert.Empty(t, transport.sentMessages())
+
+	require.NoError(t, db.CompleteAnalysisTask("shady", `{"analysis_complete":true,"is_fud":true,"fud_probability":0.92,"user_risk_level":"high"}`))
+	require.NoError(t, db.SetAnalysisTaskError("broken", TASK_ERROR_INTERNAL, "boom"))
+	assert.True(t, bot.refreshAnalysisBatch("b1", &lastProgress))
+
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	summary := sent[0].Text
+	assert.Contains(t, summary, "1 of 3 users flagged as FUD")
+	assert.Contains(t, summary, "1 users were skipped")
+	shady, calm, broken := strings.Index(summary, "@shady"), strings.Index(summary, "@calm"), strings.Index(summary, "@broken")
+	assert.True(t, shady < calm && calm < broken, "FUD users are listed first, failed ones last")
+	assert.Contains(t, summary, "FUD high      92%")
+	assert.Contains(t, summary, "clean         10%")
+
+	stored, err := db.GetAnalysisBatch("b1")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_COMPLETED, stored.Status)
+
+	// The summary is posted only once, e.g. when a resumed monitor races the original one
+	assert.True(t, bot.refreshAnalysisBatch("b1", &lastProgress))
+	assert.Len(t, transport.sentMessages(), 1)
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	BLOCKLIST_FUD_TYPE          = "blocklisted"
+	BLOCKLIST_FUD_PROBABILITY   = 0.9 // a listing is strong evidence, but not an analysis of our own
+	BLOCKLIST_IMPORTED_AT_START = "startup"
+)
+
+// blocklistColumns maps the column and field names lists use to what an entry keeps
+var blocklistColumns = map[string]string{
+	"username":    "username",
+	"handle":      "username",
+	"screen_name": "username",
+	"account":     "username",
+	"user_id":     "user_id",
+	"id":          "user_id",
+	"twitter_id":  "user_id",
+	"reason":      "reason",
+	"note":        "reason",
+	"description": "reason",
+	"fud_type":    "fud_type",
+	"type":        "fud_type",
+	"category":    "fud_type",
+}
+
+// blocklistSource is a list imported at startup, e.g. "scamwatch|https://example.org/scams.csv"
+type blocklistSource struct {
+	Name     string
+	Location string
+}
+
+// loadBlocklistSources reads ENV_BLOCKLIST_SOURCES, comma-separated name|file_or_url entries. A bare
+// location is named after its file.
+func loadBlocklistSources() ([]blocklistSource, error) {
+	var sources []blocklistSource
+	for _, entry := range strings.Split(os.Getenv(ENV_BLOCKLIST_SOURCES), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, location, found := strings.Cut(entry, "|")
+		if !found {
+			location = name
+			name = strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+		}
+		name, location = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(location)
+		if !importNameRegex.MatchString(name) || location == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected name|file_or_https_url", ENV_BLOCKLIST_SOURCES, entry)
+		}
+		sources = append(sources, blocklistSource{Name: name, Location: location})
+	}
+	return sources, nil
+}
+
+// parseBlocklist reads a JSON or CSV list of accounts and returns its entries with the number of rows
+// that named no valid account. JSON is an array of handles or of objects, or an object holding one
+// under "accounts", "users" or "entries". CSV starts with a header naming the columns, without one the
+// first column is the account and the second the reason.
+func parseBlocklist(data []byte) ([]BlocklistEntryModel, int, error) {
+	var records []map[string]string
+	var err error
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		records, err = parseBlocklistJSON(trimmed)
+	} else {
+		records, err = parseBlocklistCSV(trimmed)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]BlocklistEntryModel, 0, len(records))
+	seen := make(map[string]bool)
+	skipped := 0
+	for _, record := range records {
+		username, _ := parseTwitterReference(record["username"])
+		username = strings.ToLower(username)
+		userID := strings.TrimSpace(record["user_id"])
+		if username != "" && !twitterUsernameRegex.MatchString(username) || userID != "" && strings.Trim(userID, "0123456789") != "" {
+			skipped++
+			continue
+		}
+		if username == "" && userID == "" {
+			skipped++
+			continue
+		}
+		if seen[username+"|"+userID] {
+			continue
+		}
+		seen[username+"|"+userID] = true
+
+		fudType := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(record["fud_type"])), " ", "_")
+		if fudType == "" {
+			fudType = BLOCKLIST_FUD_TYPE
+		}
+		entries = append(entries, BlocklistEntryModel{Username: username, UserID: userID, Reason: strings.TrimSpace(record["reason"]), FUDType: fudType})
+	}
+	return entries, skipped, nil
+}
+
+func parseBlocklistJSON(data []byte) ([]map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // user IDs do not fit a float64
+	var parsed interface{}
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if object, ok := parsed.(map[string]interface{}); ok {
+		for _, key := range []string{"accounts", "users", "entries"} {
+			if list, ok := object[key]; ok {
+				parsed = list
+				break
+			}
+		}
+	}
+	items, ok := parsed.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON array of accounts, or an object with one under \"accounts\"")
+	}
+
+	records := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		record := make(map[string]string)
+		switch value := item.(type) {
+		case string:
+			record["username"] = value
+		case map[string]interface{}:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys) // the same field wins every time a list sets several
+			for _, key := range keys {
+				column, known := blocklistColumns[strings.ToLower(key)]
+				if !known || record[column] != "" {
+					continue
+				}
+				switch field := value[key].(type) {
+				case string:
+					record[column] = field
+				case json.Number:
+					record[column] = field.String()
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseBlocklistCSV(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[int]string)
+	for i, name := range rows[0] {
+		if column, known := blocklistColumns[strings.ToLower(strings.TrimSpace(name))]; known {
+			columns[i] = column
+		}
+	}
+	hasAccount := false
+	for _, column := range columns {
+		hasAccount = hasAccount || column == "username" || column == "user_id"
+	}
+	if hasAccount {
+		rows = rows[1:]
+	} else {
+		columns = map[int]string{0: "username", 1: "reason"}
+	}
+
+	records := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		record := make(map[string]string)
+		for i, value := range row {
+			if column, ok := columns[i]; ok && record[column] == "" {
+				record[column] = value
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// importBlocklist fetches a list and replaces what was imported under its name before
+func importBlocklist(dbService *DatabaseService, source blocklistSource, importedBy string) (int, int, error) {
+	data, err := fetchImport(source.Location)
+	if err != nil {
+		return 0, 0, err
+	}
+	entries, skipped, err := parseBlocklist(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(entries) == 0 {
+		return 0, skipped, fmt.Errorf("no accounts found in %s", source.Location)
+	}
+	now := time.Now()
+	for i := range entries {
+		entries[i].Source = source.Name
+		entries[i].Location = source.Location
+		entries[i].ImportedBy = importedBy
+		entries[i].ImportedAt = now
+	}
+	if err := dbService.ReplaceBlocklist(source.Name, entries); err != nil {
+		return 0, 0, err
+	}
+	return len(entries), skipped, nil
+}
+
+// seedBlocklists imports the lists of ENV_BLOCKLIST_SOURCES at startup. A list that fails keeps
+// the entries of its last import.
+func seedBlocklists(dbService *DatabaseService) {
+	sources, err := loadBlocklistSources()
+	if err != nil {
+		log.Printf("Warning: blocklist seeding disabled: %v", err)
+		return
+	}
+	for _, source := range sources {
+		imported, skipped, err := importBlocklist(dbService, source, BLOCKLIST_IMPORTED_AT_START)
+		if err != nil {
+			log.Printf("Warning: failed to import blocklist %s: %v", source.Name, err)
+			continue
+		}
+		log.Printf("🚫 Imported %d accounts from blocklist %s (%d rows skipped)", imported, source.Name, skipped)
+	}
+}
+
+// alertBlocklistedAuthor alerts on the first message of an account listed by a community blocklist
+// right away, without an analysis, and puts it on the FUD list. Its later messages go through the
+// known FUD check. A moderator's clean verdict overrides the list.
+func alertBlocklistedAuthor(newMessage twitterapi.NewMessage, dbService *DatabaseService, notificationCh chan FUDAlertNotification) bool {
+	if newMessage.IsManualAnalysis || dbService.IsFUDUser(newMessage.Author.ID) {
+		return false
+	}
+	entry, err := dbService.GetBlocklistEntry(newMessage.Author.ID, newMessage.Author.UserName)
+	if err != nil {
+		return false
+	}
+	if verdict, err := dbService.GetLatestLabeledVerdict(newMessage.Author.ID); err == nil && verdict.Label == LABEL_CLEAN {
+		return false
+	}
+	log.Printf("🚫 First contact of @%s, listed by blocklist %s", newMessage.Author.UserName, entry.Source)
+
+	err = dbService.SaveFUDUser(FUDUserModel{
+		UserID:         newMessage.Author.ID,
+		Username:       newMessage.Author.UserName,
+		FUDType:        entry.FUDType,
+		FUDProbability: BLOCKLIST_FUD_PROBABILITY,
+		DetectedAt:     time.Now(),
+		MessageCount:   1,
+		LastMessageID:  newMessage.TweetID,
+	})
+	if err != nil {
+		log.Printf("Failed to flag blocklisted user %s: %v", newMessage.Author.UserName, err)
+	} else if err := dbService.UpdateUserFUDStatus(newMessage.Author.ID, true, entry.FUDType); err != nil {
+		log.Printf("Failed to update FUD status for blocklisted user %s: %v", newMessage.Author.UserName, err)
+	}
+
+	source := fmt.Sprintf("%s (imported %s)", entry.Source, entry.ImportedAt.UTC().Format("2006-01-02"))
+	alert := FUDAlertNotification{
+		FUDMessageID:      newMessage.TweetID,
+		FUDUserID:         newMessage.Author.ID,
+		FUDUsername:       newMessage.Author.UserName,
+		ThreadID:          newMessage.ReplyTweetID,
+		DetectedAt:        time.Now().Format(time.RFC3339),
+		AlertSeverity:     "high",
+		FUDType:           entry.FUDType,
+		FUDProbability:    BLOCKLIST_FUD_PROBABILITY,
+		MessagePreview:    newMessage.Text,
+		RecommendedAction: "VERIFY_MANUALLY",
+		DecisionReason:    "Listed by the " + entry.Source + " community blocklist, alerted before any analysis",
+		UserSummary:       "Not analyzed, listed by a community blocklist",
+		BlocklistSource:   source,
+	}
+	if entry.Reason != "" {
+		alert.KeyEvidence = []string{entry.Reason}
+	}
+	setAlertThreadContext(&alert, newMessage)
+	routeAlert(&alert, newMessage)
+	notificationCh <- alert
+	return true
+}
+
+// handleBlocklistCommand manages the community blocklists whose accounts alert on first contact:
+// /blocklist [list|import name https://url|remove name|check username]
+func (b *BotController) handleBlocklistCommand(chatID int64, actor string, args []string) {
+	usage := "❌ Usage: /blocklist [list|import name https://url|remove name|check username]"
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleBlocklistList(chatID)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "import":
+		if len(args) != 3 || !importNameRegex.MatchString(args[1]) || !(strings.HasPrefix(args[2], "https://") || strings.HasPrefix(args[2], "http://")) {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		source := blocklistSource{Name: strings.ToLower(args[1]), Location: args[2]}
+		imported, skipped, err := importBlocklist(b.dbService, source, actor)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error importing blocklist %s: %s", source.Name, html.EscapeString(err.Error())))
+			return
+		}
+		log.Printf("🚫 %s imported %d accounts from blocklist %s", actor, imported, source.Name)
+		message := fmt.Sprintf("✅ Imported %d accounts as blocklist %s, they alert on their first message", imported, source.Name)
+		if skipped > 0 {
+			message += fmt.Sprintf("\n⚠️ %d rows without a valid username or user ID were skipped", skipped)
+		}
+		b.SendMessage(chatID, message)
+	case "remove":
+		if len(args) != 2 {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		name := strings.ToLower(args[1])
+		removed, err := b.dbService.RemoveBlocklist(name)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error updating blocklists: %v", err))
+			return
+		}
+		if removed == 0 {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Blocklist %s not found", html.EscapeString(name)))
+			return
+		}
+		log.Printf("🚫 %s removed blocklist %s", actor, name)
+		b.SendMessage(chatID, fmt.Sprintf("✅ Blocklist %s removed (%d accounts), users it already flagged stay on the FUD list", name, removed))
+	case "check":
+		if len(args) != 2 {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		username, _ := b.resolveTwitterReference(args[1])
+		userID := ""
+		if user, err := b.dbService.GetUserByUsername(username); err == nil {
+			userID = user.ID
+		}
+		entry, err := b.dbService.GetBlocklistEntry(userID, username)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("✅ @%s is on no blocklist", html.EscapeString(username)))
+			return
+		}
+		message := fmt.Sprintf("🚫 @%s is listed by %s as %s, imported by %s on %s", html.EscapeString(username), entry.Source, entry.FUDType, html.EscapeString(entry.ImportedBy), entry.ImportedAt.UTC().Format("2006-01-02"))
+		if entry.Reason != "" {
+			message += "\n   ↳ <i>" + html.EscapeString(entry.Reason) + "</i>"
+		}
+		b.SendMessage(chatID, message)
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+func (b *BotController) handleBlocklistList(chatID int64) {
+	sources, err := b.dbService.GetBlocklistSources()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading blocklists: %v", err))
+		return
+	}
+	if len(sources) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("🚫 No blocklists imported.\n\nUsage: /blocklist import name https://url, or set %s to import at startup", ENV_BLOCKLIST_SOURCES))
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🚫 <b>Blocklists</b> (%d)\n\n", len(sources)))
+	for _, source := range sources {
+		message.WriteString(fmt.Sprintf("• <b>%s</b>: %d accounts\n   ↳ %s, imported by %s on %s\n", source.Source, source.Entries, html.EscapeString(source.Location), html.EscapeString(source.ImportedBy), source.ImportedAt.UTC().Format("2006-01-02 15:04")))
+	}
+	b.SendMessage(chatID, strings.TrimRight(message.String(), "\n"))
+}
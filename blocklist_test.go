@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlocklist(t *testing.T) {
+	entries, skipped, err := parseBlocklist([]byte("\xef\xbb\xbfHandle,Reason,Category\n@ScamBot,fake airdrop links,scam promotion\nhttps://x.com/rugger,,\nnot a handle!,,\n@ScamBot,duplicate,\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+	require.Len(t, entries, 2)
+	assert.Equal(t, BlocklistEntryModel{Username: "scambot", Reason: "fake airdrop links", FUDType: "scam_promotion"}, entries[0])
+	assert.Equal(t, BlocklistEntryModel{Username: "rugger", FUDType: BLOCKLIST_FUD_TYPE}, entries[1])
+
+	entries, _, err = parseBlocklist([]byte("# community list\nshill1,paid shilling\nshill2\n"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "without a header the first column is the account")
+	assert.Equal(t, "paid shilling", entries[0].Reason)
+
+	entries, _, err = parseBlocklist([]byte(`{"accounts":[{"screen_name":"Drainer","id":1790000000000000123,"reason":"wallet drainer"},"@copycat"]}`))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "1790000000000000123", entries[0].UserID, "IDs keep every digit")
+	assert.Equal(t, "drainer", entries[0].Username)
+	assert.Equal(t, "copycat", entries[1].Username)
+
+	_, _, err = parseBlocklist([]byte(`{"count":2}`))
+	assert.Error(t, err)
+}
+
+func TestBlocklist_AlertsOnFirstContact(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user_id,username,reason\n42,,impersonates the team\n,drainer,wallet drainer\n"))
+	}))
+	defer server.Close()
+	reply := func(args ...string) string {
+		bot.handleBlocklistCommand(1, "@admin", args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply("list"), "No blocklists imported")
+	assert.Contains(t, reply("import", "scamwatch", "/etc/passwd"), "Usage", "only URLs from chat")
+	assert.Contains(t, reply("import", "scamwatch", server.URL), "Imported 2 accounts as blocklist scamwatch")
+	assert.Contains(t, reply("list"), "<b>scamwatch</b>: 2 accounts")
+	assert.Contains(t, reply("check", "@Drainer"), "listed by scamwatch as blocklisted, imported by @admin")
+	assert.Contains(t, reply("check", "someone"), "on no blocklist")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partners.json"), []byte(`["clean_one"]`), 0o644))
+	t.Setenv(ENV_BLOCKLIST_SOURCES, filepath.Join(dir, "partners.json"))
+	seedBlocklists(db)
+	assert.Contains(t, reply("list"), "<b>partners</b>: 1 accounts")
+
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "u2", Username: "clean_one", Label: LABEL_CLEAN}))
+	newMessageCh := make(chan twitterapi.NewMessage, 3)
+	for _, author := range []struct{ id, username string }{{"42", "RenamedImpostor"}, {"u2", "clean_one"}, {"42", "RenamedImpostor"}} {
+		message := twitterapi.NewMessage{TweetID: "t-" + author.username, Text: "claim the airdrop"}
+		message.Author.ID, message.Author.UserName = author.id, author.username
+		newMessageCh <- message
+	}
+	close(newMessageCh)
+	notificationCh := make(chan FUDAlertNotification, 3)
+	claudeApi := newMockClaudeAPI(`"is_fud":false,"fud_probability":0.1}`, nil)
+	fudChannel := make(chan twitterapi.NewMessage, 3)
+	FirstStepHandler(newMessageCh, fudChannel, claudeApi, nil, &mockUserStatusTracker{}, db, notificationCh, &warRoomState{}, nil)
+
+	require.Len(t, notificationCh, 1)
+	alert := <-notificationCh
+	assert.Equal(t, "RenamedImpostor", alert.FUDUsername, "matched by ID")
+	assert.Contains(t, alert.BlocklistSource, "scamwatch (imported ")
+	assert.Equal(t, []string{"impersonates the team"}, alert.KeyEvidence)
+	assert.True(t, db.IsFUDUser("42"))
+	assert.Len(t, claudeApi.recordedCalls(), 1, "the known FUD check of the second message goes to the model, the first contact does not")
+	forwarded := <-fudChannel
+	assert.Equal(t, "clean_one", forwarded.Author.UserName, "a moderator's clean verdict overrides the list")
+
+	assert.Contains(t, NewNotificationFormatter().FormatForTelegram(alert), "BLOCKLISTED ACCOUNT - HIGH SEVERITY")
+
+	assert.Contains(t, reply("remove", "scamwatch"), "removed (2 accounts)")
+	assert.Contains(t, reply("remove", "scamwatch"), "not found")
+}
@@ -0,0 +1,3060 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/grutapig/hackaton/twitterapi"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const CHAT_IDS_STORAGE_PATH = "users.txt"
+
+// BotController holds chat registry and command logic on top of a TelegramTransport
+type BotController struct {
+	transport     TelegramTransport
+	chatIDs       map[int64]bool
+	chatMutex     sync.RWMutex
+	lastOffset    int64
+	isRunning     bool
+	formatter     *NotificationFormatter
+	dbService     *DatabaseService
+	maintenance   maintenanceState
+	senders       senderLimiter
+	confirmations notifyConfirmations
+	warRoom       warRoomState
+	tail          tailState // /tail sessions of admin chats
+	retention     retentionState
+	watchdog      ingestionWatchdog
+	progress      progressEditor
+	taskContexts  analysisTaskContexts
+	federation    federationState
+	telegram      twitterapi.StatusTracker // outcome of the latest getUpdates poll, see /readyz
+	budget        budgetState
+	searches      searchQueries // last /search query per chat, see /search_p2
+	imports       importState   // the running /import, one at a time
+	// Services for manual analysis
+	twitterApi        TwitterAPI                 // Will be set later
+	claudeApi         ClaudeAPI                  // Will be set later
+	userStatusManager UserStatusTracker          // Will be set later
+	prompts           *PromptManager             // Will be set later
+	ticker            string                     // Will be set later
+	analysisChannel   chan twitterapi.NewMessage // Channel for manual analysis requests
+	priorityChannel   chan twitterapi.NewMessage // Channel for high priority requests such as user reports
+	communities       *communityMonitors         // Monitors of the communities, see /community
+}
+
+func NewBotController(transport TelegramTransport, initialChatIDs string, formatter *NotificationFormatter, dbService *DatabaseService, analysisChannel chan twitterapi.NewMessage) *BotController {
+	service := &BotController{
+		transport:       transport,
+		chatIDs:         make(map[int64]bool),
+		lastOffset:      0,
+		isRunning:       false,
+		formatter:       formatter,
+		dbService:       dbService,
+		analysisChannel: analysisChannel,
+	}
+	var fileChatIDs, configChatIDs []int64
+	//Init chatIds from file if exists
+	data, err := os.ReadFile(CHAT_IDS_STORAGE_PATH)
+	if err == nil {
+		chatIDStrings := strings.Split(string(data), "\n")
+		for _, chatIDStr := range chatIDStrings {
+			chatIDStr = strings.TrimSpace(chatIDStr) // Remove spaces
+			if chatIDStr != "" {
+				if chatID, err := strconv.ParseInt(chatIDStr, 10, 64); err == nil {
+					service.chatIDs[chatID] = true
+					log.Printf("Added initial Telegram chat ID: %d", chatID)
+					fileChatIDs = append(fileChatIDs, chatID)
+				} else {
+					log.Printf("Warning: Invalid chat ID format: %s", chatIDStr)
+				}
+			}
+		}
+	}
+	// Add initial chat IDs if provided (comma-separated)
+	if initialChatIDs != "" {
+		chatIDStrings := strings.Split(initialChatIDs, ",")
+		for _, chatIDStr := range chatIDStrings {
+			chatIDStr = strings.TrimSpace(chatIDStr) // Remove spaces
+			if chatIDStr != "" {
+				if chatID, err := strconv.ParseInt(chatIDStr, 10, 64); err == nil {
+					service.chatIDs[chatID] = true
+					log.Printf("Added initial Telegram chat ID: %d", chatID)
+					configChatIDs = append(configChatIDs, chatID)
+				} else {
+					log.Printf("Warning: Invalid chat ID format: %s", chatIDStr)
+				}
+			}
+		}
+	}
+	if dbService != nil {
+		service.migrateNotificationChats(fileChatIDs, configChatIDs)
+	}
+	//back users list every 5 seconds into file
+	go func() {
+		for {
+			time.Sleep(5 * time.Second)
+			chatList := []string{}
+			service.chatMutex.RLock()
+			for chatId, _ := range service.chatIDs {
+				chatList = append(chatList, strconv.Itoa(int(chatId)))
+			}
+			service.chatMutex.RUnlock()
+			err := os.WriteFile(CHAT_IDS_STORAGE_PATH, []byte(strings.Join(chatList, "\n")), 0655)
+			if err != nil {
+				log.Println("cannot write file with notification users list.", err)
+			}
+		}
+	}()
+
+	return service
+}
+
+// SetAnalysisServices sets the services needed for manual analysis
+func (b *BotController) SetAnalysisServices(twitterApi TwitterAPI, claudeApi ClaudeAPI, userStatusManager UserStatusTracker, prompts *PromptManager, ticker string) {
+	b.twitterApi = twitterApi
+	b.claudeApi = claudeApi
+	b.userStatusManager = userStatusManager
+	b.prompts = prompts
+	b.ticker = ticker
+}
+
+// SetPriorityChannel sets the channel that the second step drains before regular FUD messages
+func (b *BotController) SetPriorityChannel(priorityChannel chan twitterapi.NewMessage) {
+	b.priorityChannel = priorityChannel
+}
+
+func (b *BotController) StartListening() {
+	if b.isRunning {
+		return
+	}
+	b.isRunning = true
+
+	go func() {
+		for b.isRunning {
+			err := b.processUpdates()
+			if err != nil {
+				log.Printf("Error processing Telegram updates: %v", err)
+			}
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	log.Println("Telegram bot started listening for updates")
+}
+
+func (b *BotController) StopListening() {
+	b.isRunning = false
+	log.Println("Telegram bot stopped listening")
+}
+
+func (b *BotController) isAdminChat(chatID int64) bool {
+	for _, adminChatID := range adminChatIDs() {
+		if adminChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BotController) processUpdates() error {
+	updates, err := b.transport.GetUpdates(b.lastOffset)
+	b.telegram.Record(healthSafeError("getUpdates", err))
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		b.lastOffset = update.UpdateID + 1
+		b.handleUpdate(update)
+	}
+
+	return nil
+}
+
+// handleUpdate registers the chat and routes the message to its command handler
+func (b *BotController) handleUpdate(update TelegramUpdate) {
+	// Votes in verdict polls carry no message
+	if update.PollAnswer != nil {
+		go b.handlePollAnswer(*update.PollAnswer)
+		return
+	}
+
+	// Strangers and flooding senders are dropped before any work is done
+	if commandText(update) != "" && !b.allowSender(update) {
+		return
+	}
+
+	// Unknown chats go through onboarding before they are registered for broadcasts
+	chatID := update.Message.Chat.ID
+	if !b.isRegisteredChat(chatID) {
+		b.handleOnboarding(update)
+		return
+	}
+
+	// Topics named like the alerts or reports topic are picked up as they are created
+	if update.Message.ForumTopicCreated != nil {
+		go b.handleForumTopicCreated(chatID, update.Message.MessageThreadID, update.Message.ForumTopicCreated.Name)
+		return
+	}
+
+	// Handle commands and messages
+	if text := commandText(update); text != "" {
+		if b.handleAlertReply(update, strings.TrimSpace(text)) {
+			return
+		}
+		b.routeCommand(update, strings.TrimSpace(text), false)
+	}
+}
+
+// commandText is the text of a message, the caption for uploaded documents such as /import
+func commandText(update TelegramUpdate) string {
+	if update.Message.Text == "" && update.Message.Document != nil {
+		return update.Message.Caption
+	}
+	return update.Message.Text
+}
+
+// routeCommand dispatches a command to its handler. A command that matches none is expanded once
+// with the chat's aliases, so aliases never shadow built-in commands (see /alias).
+func (b *BotController) routeCommand(update TelegramUpdate, text string, aliased bool) {
+	chatID := update.Message.Chat.ID
+
+	// Parse command and arguments
+	parts := strings.Fields(text)
+	if len(parts) == 0 {
+		return
+	}
+
+	command := parts[0]
+	args := parts[1:]
+	logFor("commands").Debug("command received", "chat_id", chatID, "command", command, "args", len(args), "aliased", aliased)
+
+	// "/history https://x.com/alice" is the same as "/history_alice"
+	if usernameCommands[command] && len(args) > 0 {
+		command = command + "_" + args[0]
+		text = command
+		args = args[1:]
+	}
+
+	if isInvestigationCommand(command) && b.isRedactedChat(chatID) {
+		go b.SendMessage(chatID, "❌ Investigation commands are not available in this chat.")
+		return
+	}
+	if strings.HasPrefix(command, "/") && !isGuestCommand(command) && b.isGuestChat(chatID) {
+		go b.SendMessage(chatID, "❌ This chat has read-only guest access, see /help for the available commands.")
+		return
+	}
+	if strings.HasPrefix(command, "/") && b.isAdminChat(chatID) {
+		allowed, known := b.cachedModeratorDecision(update)
+		if !known {
+			// Verifying the sender asks Telegram, the polling loop does not wait for the answer
+			go func() {
+				if !b.isModeratorSender(update) {
+					b.SendMessage(chatID, MODERATOR_ACCESS_DENIED)
+					return
+				}
+				b.dispatchCommand(update, command, text, args, aliased)
+			}()
+			return
+		}
+		if !allowed {
+			go b.SendMessage(chatID, MODERATOR_ACCESS_DENIED)
+			return
+		}
+	}
+	b.dispatchCommand(update, command, text, args, aliased)
+}
+
+// dispatchCommand runs the handler of a command that passed the access checks of routeCommand
+func (b *BotController) dispatchCommand(update TelegramUpdate, command string, text string, args []string, aliased bool) {
+	chatID := update.Message.Chat.ID
+	switch {
+	case strings.HasPrefix(command, "/detail_"):
+		go b.handleDetailCommand(chatID, text)
+	case strings.HasPrefix(command, "/ack_") || strings.HasPrefix(command, "/assign_") || strings.HasPrefix(command, "/resolve_"):
+		go b.handleAlertStateCommand(chatID, senderName(update), command, args)
+	case command == "/openalerts":
+		go b.handleOpenAlertsCommand(chatID, senderName(update), args)
+	case strings.HasPrefix(command, "/history_"):
+		go b.handleHistoryCommand(chatID, text)
+	case command == "/export_opinions":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleExportOpinionsCommand(chatID, args)
+	case strings.HasPrefix(command, "/export_"):
+		go b.handleExportCommand(chatID, command, args)
+	case strings.HasPrefix(command, "/report_"):
+		go b.handleUserReportCommand(chatID, command)
+	case strings.HasPrefix(command, "/ticker_history_"):
+		go b.handleTickerHistoryCommand(chatID, text)
+	case strings.HasPrefix(command, "/cache_"):
+		go b.handleCacheCommand(chatID, text)
+	case strings.HasPrefix(command, "/riskchart_"):
+		go b.handleRiskChartCommand(chatID, command)
+	case strings.HasPrefix(command, "/graph_"):
+		go b.handleGraphCommand(chatID, command, args)
+	case strings.HasPrefix(command, "/network_"):
+		go b.handleNetworkCommand(chatID, command)
+	case command == "/analyze_tweet":
+		go b.handleAnalyzeTweetCommand(chatID, args)
+	case command == "/analyze_all":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleAnalyzeAllCommand(chatID)
+	case strings.HasPrefix(command, "/analyze_"):
+		go b.handleAnalyzeCommand(chatID, text)
+	case command == "/search" || strings.HasPrefix(command, "/search_p"):
+		go b.handleSearchCommand(chatID, command, args)
+	case command == "/fudlist" || strings.HasPrefix(command, "/fudlist_"):
+		go b.handleFudListCommand(chatID, args, command)
+	case command == "/exportfudlist":
+		go b.handleExportFudListCommand(chatID)
+	case command == "/topfud" || strings.HasPrefix(command, "/topfud_"):
+		go b.handleTopFudCommand(chatID, args, command)
+	case command == "/tasks":
+		go b.handleTasksCommand(chatID)
+	case strings.HasPrefix(command, "/cancel_"):
+		go b.handleCancelCommand(chatID, senderName(update), command)
+	case command == "/u":
+		b.SendMessage(chatID, fmt.Sprintf("users: %d", len(b.chatIDs)))
+	case command == "/top20_analyze":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleTop20AnalyzeCommand(chatID)
+	case command == "/top100_analyze":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleTop100AnalyzeCommand(chatID)
+	case command == "/batch_analyze":
+		go b.handleBatchAnalyzeCommand(chatID, args)
+	case command == "/verbosity":
+		go b.handleVerbosityCommand(chatID, args)
+	case command == "/silent":
+		go b.handleSilentCommand(chatID, args)
+	case command == "/quiet":
+		go b.handleQuietCommand(chatID, args)
+	case command == "/topic":
+		go b.handleTopicCommand(chatID, topicThreadID(update), args)
+	case command == "/subscribe":
+		go b.handleSubscribeCommand(chatID, args)
+	case command == "/subscribe_ticker" || command == "/unsubscribe_ticker":
+		go b.handleTickerSubscriptionCommand(chatID, command, args)
+	case command == "/approve_chat" || command == "/reject_chat":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleChatApprovalCommand(chatID, senderName(update), args, command == "/approve_chat")
+	case command == "/report":
+		go b.handleReportCommand(chatID, update.Message.From.ID, senderName(update), args)
+	case command == "/reports":
+		go b.handleReportsCommand(chatID)
+	case command == "/redaction":
+		go b.handleRedactionCommand(chatID, args)
+	case command == "/chatrole":
+		go b.handleChatRoleCommand(chatID, args)
+	case command == "/stats":
+		go b.handleStatsCommand(chatID, args)
+	case command == "/health":
+		go b.handleHealthCommand(chatID)
+	case command == "/sentiment":
+		go b.handleSentimentCommand(chatID, args)
+	case command == "/maintenance":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleMaintenanceCommand(chatID, args)
+	case command == "/usage":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleUsageCommand(chatID, args)
+	case command == "/anonstats":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleAnonStatsCommand(chatID, args)
+	case command == "/tune":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleTuneCommand(chatID, args)
+	case command == "/costs" || strings.HasPrefix(command, "/costs_"):
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleCostsCommand(chatID, command, args)
+	case command == "/warroom":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleWarRoomCommand(chatID, args)
+	case command == "/tail":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleTailCommand(chatID, args)
+	case command == "/restore_user" || strings.HasPrefix(command, "/restore_user_"):
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleRestoreUserCommand(chatID, senderName(update), command)
+	case command == "/community":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleCommunityCommand(chatID, senderName(update), args)
+	case command == "/notify":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleNotifyCommand(chatID, senderName(update), args)
+	case command == "/whitelist":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleWhitelistCommand(chatID, senderName(update), args)
+	case command == "/watch":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleWatchCommand(chatID, senderName(update), args)
+	case command == "/blocklist":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleBlocklistCommand(chatID, senderName(update), args)
+	case command == "/eval":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleEvalCommand(chatID, senderName(update), args)
+	case command == "/poll":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handlePollCommand(chatID, senderName(update), args)
+	case command == "/mark_clean" || command == "/mark_fud":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleMarkCommand(chatID, senderName(update), strings.TrimPrefix(command, "/mark_"), args)
+	case command == "/prompt":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handlePromptCommand(chatID, senderName(update), text, args)
+	case command == "/loglevel":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleLogLevelCommand(chatID, senderName(update), args)
+	case command == "/filters":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleFiltersCommand(chatID, senderName(update), args)
+	case command == "/threshold":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleThresholdCommand(chatID, senderName(update), args)
+	case command == "/import":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleImportCommand(chatID, update.Message.Document, args)
+	case command == "/reformat_alerts":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleReformatAlertsCommand(chatID, args)
+	case command == "/moderators":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleModeratorsCommand(chatID)
+	case command == "/followups":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleFollowUpsCommand(chatID)
+	case command == "/events":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleEventsCommand(chatID, args)
+	case command == "/dedup":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleDedupCommand(chatID)
+	case command == "/archive_status":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleArchiveStatusCommand(chatID)
+	case command == "/handoff":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleHandoffCommand(chatID, args)
+	case command == "/dbversion":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleDBVersionCommand(chatID)
+	case command == "/autoaction":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleAutoActionCommand(chatID, senderName(update), args)
+	case command == "/federation":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handleFederationCommand(chatID)
+	case command == "/pending_chats":
+		if !b.isAdminChat(chatID) {
+			go b.SendMessage(chatID, "❌ Access denied. This command is restricted to administrators only.")
+			return
+		}
+		go b.handlePendingChatsCommand(chatID)
+	case command == "/alias":
+		go b.handleAliasCommand(chatID, senderName(update), args)
+	case command == "/help" || command == "/start":
+		go b.handleHelpCommand(chatID)
+	default:
+		if !aliased {
+			if expanded, ok := b.expandAlias(chatID, text); ok {
+				if b.allowExpandedCommand(update, expanded) {
+					b.routeCommand(update, expanded, true)
+				}
+				return
+			}
+		}
+		go b.handleHelpCommand(chatID)
+	}
+}
+
+// senderName returns the @username of the sender, or the first name if they have none
+func senderName(update TelegramUpdate) string {
+	if update.Message.From.Username != "" {
+		return "@" + update.Message.From.Username
+	}
+	return update.Message.From.FirstName
+}
+
+func (b *BotController) SendMessage(chatID int64, text string) error {
+	_, err := b.SendMessageWithID(chatID, text)
+	return err
+}
+
+func (b *BotController) SendMessageWithID(chatID int64, text string) (int64, error) {
+	return b.sendSplitMessage(TelegramSendMessageRequest{
+		ChatID:         chatID,
+		Text:           text,
+		ParseMode:      "HTML",
+		DisablePreview: true,
+	})
+}
+
+// SendMessageToTopic sends a message to a forum topic, threadID 0 is the General topic
+func (b *BotController) SendMessageToTopic(chatID int64, threadID int64, text string) error {
+	_, err := b.sendSplitMessage(TelegramSendMessageRequest{
+		ChatID:          chatID,
+		MessageThreadID: threadID,
+		Text:            text,
+		ParseMode:       "HTML",
+		DisablePreview:  true,
+	})
+	return err
+}
+
+// sendSplitMessage sends text over Telegram's length limit as several parts and returns the first message ID
+func (b *BotController) sendSplitMessage(req TelegramSendMessageRequest) (int64, error) {
+	var firstMessageID int64
+	for _, part := range splitTelegramMessage(req.Text, TELEGRAM_MAX_MESSAGE_LENGTH) {
+		req.Text = part
+		messageID, err := b.transport.SendMessage(req)
+		if req.MessageThreadID != 0 && isTopicGoneError(err) {
+			// A deleted or closed topic must not swallow alerts, the General topic gets them instead
+			log.Printf("Forum topic %d of chat %d is gone, sending to the General topic: %v", req.MessageThreadID, req.ChatID, err)
+			req.MessageThreadID = 0
+			messageID, err = b.transport.SendMessage(req)
+		}
+		if err != nil {
+			return firstMessageID, err
+		}
+		if firstMessageID == 0 {
+			firstMessageID = messageID
+		}
+	}
+	return firstMessageID, nil
+}
+
+func (b *BotController) EditMessage(chatID int64, messageID int64, text string) error {
+	return b.transport.EditMessage(TelegramEditMessageRequest{
+		ChatID:         chatID,
+		MessageID:      messageID,
+		Text:           text,
+		ParseMode:      "HTML",
+		DisablePreview: true,
+	})
+}
+
+func (b *BotController) SendDocument(chatID int64, filePath string, caption string) error {
+	return b.transport.SendDocument(TelegramSendDocumentRequest{
+		ChatID:    chatID,
+		Caption:   caption,
+		ParseMode: "HTML",
+	}, filePath)
+}
+
+func (b *BotController) SendPhoto(chatID int64, filename string, photo []byte, caption string) error {
+	return b.transport.SendPhoto(TelegramSendPhotoRequest{
+		ChatID:    chatID,
+		Caption:   caption,
+		ParseMode: "HTML",
+	}, filename, photo)
+}
+
+func (b *BotController) generateTaskID() (string, error) {
+	bytes := make([]byte, 8)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func (b *BotController) BroadcastMessage(text string) error {
+	b.chatMutex.RLock()
+	defer b.chatMutex.RUnlock()
+
+	if len(b.chatIDs) == 0 {
+		log.Println("No registered Telegram chats to broadcast to")
+		return nil
+	}
+
+	var errors []error
+	for chatID := range b.chatIDs {
+		err := b.SendMessage(chatID, text)
+		if err != nil {
+			log.Printf("Failed to send message to chat %d: %v", chatID, err)
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("failed to send to %d chats", len(errors))
+	}
+
+	log.Printf("Successfully broadcasted message to %d chats", len(b.chatIDs))
+	return nil
+}
+
+func (b *BotController) isRegisteredChat(chatID int64) bool {
+	b.chatMutex.RLock()
+	defer b.chatMutex.RUnlock()
+	return b.chatIDs[chatID]
+}
+
+func (b *BotController) GetRegisteredChats() []int64 {
+	b.chatMutex.RLock()
+	defer b.chatMutex.RUnlock()
+
+	var chats []int64
+	for chatID := range b.chatIDs {
+		chats = append(chats, chatID)
+	}
+	return chats
+}
+
+// notificationTTL returns how long stored notifications stay available for /detail_
+func notificationTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv(ENV_NOTIFICATION_TTL_HOURS))
+	if err != nil || hours <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func (b *BotController) generateNotificationID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+func (b *BotController) StoreAndBroadcastNotification(alert FUDAlertNotification) error {
+	// Earlier alerts are looked up before this one is stored so it does not list itself
+	b.attachPriorAlerts(&alert)
+
+	// Generate unique ID and store notification, /detail_ links read it back until it expires
+	notificationID := b.generateNotificationID()
+	err := b.dbService.SaveNotification(notificationID, alert, notificationTTL())
+	if err != nil {
+		log.Printf("Failed to persist notification %s: %v", notificationID, err)
+	}
+
+	b.noteWarRoomAlert(alert)
+
+	// Pre-warn partner deployments about confirmed FUD accounts
+	go publishFederatedIndicator(b.dbService, alert)
+
+	// Follow-up steps the operators configured for repeat offenders
+	b.runAutoActions(alert, notificationID)
+
+	// Watch flagged tweets for edits during the edit window
+	isFUDAlert := !strings.Contains(alert.FUDType, "manual_analysis_clean") && alert.FUDType != "none"
+	if isFUDAlert && isTrackableTweetID(alert.FUDMessageID) {
+		err = b.dbService.RecordFlaggedTweet(alert.FUDMessageID, alert.MessagePreview)
+		if err != nil {
+			log.Printf("Failed to record flagged tweet %s: %v", alert.FUDMessageID, err)
+		}
+	}
+
+	// Re-evaluate the user later, the outcomes are replied to this alert
+	if isFUDAlert {
+		b.scheduleFollowUpChecks(alert, notificationID)
+	}
+
+	if b.alertDelivery(alert.AlertSeverity) == ALERT_DELIVERY_LOG {
+		log.Printf("Stored %s alert for @%s without delivering it, see /threshold", alert.AlertSeverity, alert.FUDUsername)
+		return nil
+	}
+
+	if b.queueIfInMaintenance(alert, notificationID) {
+		return nil
+	}
+
+	// Broadcast to all chats, each in its own verbosity profile
+	return b.broadcastAlert(alert, notificationID)
+}
+
+// broadcastAlert sends the alert to every registered chat formatted with the chat's verbosity
+func (b *BotController) broadcastAlert(alert FUDAlertNotification, notificationID string) error {
+	b.chatMutex.RLock()
+	defer b.chatMutex.RUnlock()
+
+	if len(b.chatIDs) == 0 {
+		log.Println("No registered Telegram chats to broadcast to")
+		return nil
+	}
+
+	settings, err := b.dbService.GetAllChatSettings()
+	if err != nil {
+		log.Printf("Failed to load chat settings, using defaults: %v", err)
+	}
+
+	now := time.Now()
+	warRoomActive := b.warRoom.active()
+	usersOnly := b.alertDelivery(alert.AlertSeverity) == ALERT_DELIVERY_USERS
+	community := b.alertCommunity(alert)
+	formatted := make(map[string]string)
+	var errors []error
+	for chatID := range b.chatIDs {
+		chatSettings, ok := settings[chatID]
+		if !ok {
+			chatSettings = *defaultChatSettings(chatID)
+		}
+		// The war room delivers alerts of every severity
+		if !warRoomActive && severityRank(alert.AlertSeverity) < severityRank(chatSettings.MinSeverity) {
+			continue
+		}
+		if !communityReceivesAlert(community, chatID, &chatSettings) {
+			continue
+		}
+		// Private chats have positive IDs, groups and channels negative ones
+		if usersOnly && chatID < 0 {
+			continue
+		}
+		// Guests only get digests and summaries
+		if chatSettings.Role == CHAT_ROLE_GUEST {
+			continue
+		}
+		if !warRoomActive && b.holdForQuietHours(alert, notificationID, &chatSettings, now) {
+			continue
+		}
+
+		formatKey := chatSettings.Verbosity + "|" + chatSettings.Timezone + "|" + chatSettings.Redaction
+		text, ok := formatted[formatKey]
+		if !ok {
+			text = b.formatAlertForChat(alert, notificationID, &chatSettings)
+			formatted[formatKey] = text
+		}
+
+		messageID, err := b.sendAlertMessage(chatID, chatSettings.AlertsTopicID, text, isSilentAlert(alert.AlertSeverity, chatSettings.SilentUpTo))
+		if err != nil {
+			log.Printf("Failed to send alert to chat %d: %v", chatID, err)
+			errors = append(errors, err)
+			continue
+		}
+		// Follow-up checks reply to the alert
+		if err := b.dbService.SaveAlertMessage(notificationID, chatID, messageID); err != nil {
+			log.Printf("Failed to record alert message in chat %d: %v", chatID, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("failed to send to %d chats", len(errors))
+	}
+
+	log.Printf("Successfully broadcasted alert to %d chats", len(b.chatIDs))
+	return nil
+}
+
+// SendAlertToChat sends an alert to a single chat using the chat's verbosity and silent settings
+func (b *BotController) SendAlertToChat(chatID int64, alert FUDAlertNotification, notificationID string) error {
+	b.attachPriorAlerts(&alert)
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to load settings for chat %d, using defaults: %v", chatID, err)
+		settings = defaultChatSettings(chatID)
+	}
+	if settings.Role == CHAT_ROLE_GUEST {
+		log.Printf("Not sending alert %s to guest chat %d", notificationID, chatID)
+		return nil
+	}
+	text := b.formatAlertForChat(alert, notificationID, settings)
+	_, err = b.sendAlertMessage(chatID, settings.AlertsTopicID, text, isSilentAlert(alert.AlertSeverity, settings.SilentUpTo))
+	return err
+}
+
+// formatAlertForChat applies the chat timezone, redaction profile and verbosity
+func (b *BotController) formatAlertForChat(alert FUDAlertNotification, notificationID string, settings *ChatSettingsModel) string {
+	alert = alertInTimezone(alert, settings.Timezone)
+	if isRedactedProfile(settings.Redaction) {
+		return b.formatter.FormatRedacted(alert, settings.Redaction)
+	}
+	return b.formatter.FormatAlert(alert, notificationID, settings.Verbosity)
+}
+
+// sendAlertMessage sends alert text, optionally without a notification sound, and returns its message ID
+func (b *BotController) sendAlertMessage(chatID int64, threadID int64, text string, silent bool) (int64, error) {
+	return b.sendSplitMessage(TelegramSendMessageRequest{
+		ChatID:              chatID,
+		MessageThreadID:     threadID,
+		Text:                text,
+		ParseMode:           "HTML",
+		DisablePreview:      true,
+		DisableNotification: silent,
+	})
+}
+
+// alertInTimezone returns a copy of the alert with DetectedAt converted to the chat timezone
+func alertInTimezone(alert FUDAlertNotification, timezone string) FUDAlertNotification {
+	if timezone == "" {
+		return alert
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return alert
+	}
+	detectedAt, err := time.Parse(time.RFC3339, alert.DetectedAt)
+	if err != nil {
+		return alert
+	}
+	alert.DetectedAt = detectedAt.In(location).Format(time.RFC3339)
+	return alert
+}
+
+// isSilentAlert reports whether an alert of this severity should be delivered without sound
+func isSilentAlert(severity string, silentUpTo string) bool {
+	if silentUpTo == "" || silentUpTo == "none" {
+		return false
+	}
+	return severityRank(severity) <= severityRank(silentUpTo)
+}
+
+// handleSilentCommand shows or changes up to which severity alerts arrive without sound
+func (b *BotController) handleSilentCommand(chatID int64, args []string) {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+
+	if len(args) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("🔕 <b>Silent alerts up to:</b> %s\n\nUsage: /silent none|low|medium|high\nAlerts at or below the chosen severity are delivered without sound. Critical alerts always ring.", settings.SilentUpTo))
+		return
+	}
+
+	level := strings.ToLower(args[0])
+	switch level {
+	case "none", "low", "medium", "high":
+	default:
+		b.SendMessage(chatID, "❌ Unknown level. Use: /silent none|low|medium|high")
+		return
+	}
+
+	settings.SilentUpTo = level
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	if level == "none" {
+		b.SendMessage(chatID, "🔔 All alerts will be delivered with sound")
+		return
+	}
+	b.SendMessage(chatID, fmt.Sprintf("✅ Alerts up to <b>%s</b> severity will be delivered silently", level))
+}
+
+// handleVerbosityCommand shows or changes the alert verbosity of the chat
+func (b *BotController) handleVerbosityCommand(chatID int64, args []string) {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+
+	if len(args) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("🔧 <b>Alert verbosity:</b> %s\n\nUsage: /verbosity compact|normal|detailed\n• compact - one line per alert\n• normal - standard alert card\n• detailed - evidence excerpts and linked tweets", settings.Verbosity))
+		return
+	}
+
+	verbosity := strings.ToLower(args[0])
+	switch verbosity {
+	case VERBOSITY_COMPACT, VERBOSITY_NORMAL, VERBOSITY_DETAILED:
+	default:
+		b.SendMessage(chatID, "❌ Unknown verbosity. Use: /verbosity compact|normal|detailed")
+		return
+	}
+
+	settings.Verbosity = verbosity
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	b.SendMessage(chatID, fmt.Sprintf("✅ Alert verbosity set to <b>%s</b>", verbosity))
+}
+
+func (b *BotController) handleDetailCommand(chatID int64, command string) {
+	// Extract notification ID from command "/detail_12345abc"
+	prefix := "/detail_"
+	if !strings.HasPrefix(command, prefix) {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /detail_<id>")
+		return
+	}
+
+	notificationID := strings.TrimPrefix(command, prefix)
+	alert, err := b.dbService.GetNotification(notificationID)
+	if err != nil {
+		b.SendMessage(chatID, "❌ Notification not found or expired.")
+		return
+	}
+
+	// Send detailed information
+	detailMessage := b.formatter.FormatDetailedView(*alert) + b.formatEditHistory(alert.FUDMessageID)
+	b.SendMessage(chatID, detailMessage)
+}
+
+func (b *BotController) handleHistoryCommand(chatID int64, command string) {
+	// Extract username from command "/history_username"
+	prefix := "/history_"
+	if !strings.HasPrefix(command, prefix) {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /history_username")
+		return
+	}
+
+	reference, page := splitPageSuffix(strings.TrimPrefix(command, prefix))
+	username, _ := b.resolveTwitterReference(reference)
+
+	// One extra message tells whether there is a next page
+	tweets, err := b.dbService.GetUserMessagesPageByUsername(username, HISTORY_PAGE_SIZE+1, (page-1)*HISTORY_PAGE_SIZE)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving messages for @%s: %v", username, err))
+		return
+	}
+
+	if len(tweets) == 0 {
+		if page > 1 {
+			b.SendMessage(chatID, fmt.Sprintf("📭 No more messages for @%s, back to the first page: /history_%s", username, username))
+			return
+		}
+		b.SendMessage(chatID, fmt.Sprintf("📭 No messages found for @%s", username))
+		return
+	}
+	hasNext := len(tweets) > HISTORY_PAGE_SIZE
+	if hasNext {
+		tweets = tweets[:HISTORY_PAGE_SIZE]
+	}
+
+	// Format the message history
+	var historyMessage strings.Builder
+	historyMessage.WriteString(fmt.Sprintf("📝 <b>Message History for @%s</b> (Page %d)\n\n", username, page))
+
+	for i, tweet := range tweets {
+		historyMessage.WriteString(fmt.Sprintf("<b>%d.</b> %s\n", (page-1)*HISTORY_PAGE_SIZE+i+1, tweet.CreatedAt.Format("2006-01-02 15:04")))
+		historyMessage.WriteString(fmt.Sprintf("📝 <i>%s</i>\n", b.formatter.escapeTruncated(tweet.Text, 200)))
+		if tweet.InReplyToID != "" {
+			historyMessage.WriteString("↳ <i>Reply to tweet</i>\n")
+		}
+		historyMessage.WriteString(fmt.Sprintf("🆔 <code>%s</code>\n\n", tweet.ID))
+	}
+
+	historyMessage.WriteString(pageNavigation("/history_"+username, page, hasNext))
+
+	// Add command for full export
+	historyMessage.WriteString(fmt.Sprintf("📄 For full message history: /export_%s", username))
+
+	b.SendMessage(chatID, historyMessage.String())
+}
+
+func (b *BotController) handleTickerHistoryCommand(chatID int64, command string) {
+	// Extract username from command "/ticker_history_username"
+	prefix := "/ticker_history_"
+	if !strings.HasPrefix(command, prefix) {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /ticker_history_username")
+		return
+	}
+
+	username, _ := b.resolveTwitterReference(strings.TrimPrefix(command, prefix))
+	ticker := b.ticker // Use the ticker from the environment
+
+	// Get ALL ticker-related messages for the user (no limit for checking count)
+	allOpinions, err := b.dbService.GetUserTickerOpinionsByUsername(username, ticker, 0)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving ticker history for @%s: %v", username, err))
+		return
+	}
+
+	if len(allOpinions) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No ticker-related messages found for @%s and %s", username, ticker))
+		return
+	}
+
+	// If more than 15 items, export as file
+	if len(allOpinions) > 15 {
+		b.SendMessage(chatID, fmt.Sprintf("📊 Found %d ticker mentions for @%s (%s). Generating file...", len(allOpinions), username, ticker))
+		b.exportTickerHistoryAsFile(chatID, username, ticker, allOpinions)
+		return
+	}
+
+	// Format the ticker history message for small datasets
+	var historyMessage strings.Builder
+	historyMessage.WriteString(fmt.Sprintf("💰 <b>Ticker History for @%s (%s)</b> (%d messages)\n\n", username, ticker, len(allOpinions)))
+
+	for i, opinion := range allOpinions {
+		historyMessage.WriteString(fmt.Sprintf("<b>%d.</b> %s\n", i+1, opinion.TweetCreatedAt.Format("2006-01-02 15:04")))
+		historyMessage.WriteString(fmt.Sprintf("💬 <i>%s</i>\n", b.formatter.escapeTruncated(opinion.Text, 200)))
+
+		// Show reply context if available
+		if opinion.InReplyToID != "" && opinion.RepliedToAuthor != "" {
+			historyMessage.WriteString(fmt.Sprintf("↳ <i>Reply to @%s: %s</i>\n",
+				opinion.RepliedToAuthor,
+				b.formatter.escapeTruncated(opinion.RepliedToText, 100)))
+		}
+
+		historyMessage.WriteString(fmt.Sprintf("🆔 <code>%s</code>\n", opinion.TweetID))
+		historyMessage.WriteString(fmt.Sprintf("🔍 <i>Search: %s</i>\n\n", b.formatter.escape(opinion.SearchQuery)))
+	}
+
+	// Add summary
+	historyMessage.WriteString(fmt.Sprintf("📊 Total ticker mentions: %d\n", len(allOpinions)))
+	historyMessage.WriteString(fmt.Sprintf("📄 For full message history: /export_%s", username))
+
+	b.SendMessage(chatID, historyMessage.String())
+}
+
+func (b *BotController) handleCacheCommand(chatID int64, command string) {
+	// Extract user identifier from command "/cache_username_or_id"
+	prefix := "/cache_"
+	if !strings.HasPrefix(command, prefix) {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /cache_<username_or_id>")
+		return
+	}
+
+	userIdentifier, _ := b.resolveTwitterReference(strings.TrimPrefix(command, prefix))
+	if userIdentifier == "" {
+		b.SendMessage(chatID, "❌ Please provide username or user ID. Use /cache_<username_or_id>")
+		return
+	}
+
+	// Try to find user by username first (case insensitive), then by ID
+	var user *UserModel
+	var err error
+
+	if user, err = b.dbService.GetUserByUsername(userIdentifier); err != nil {
+		// If not found by username, try by ID
+		if user, err = b.dbService.GetUser(userIdentifier); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ User not found: %s\nTried both username and ID lookup.", userIdentifier))
+			return
+		}
+	}
+
+	// Get cached analysis for the user
+	cachedAnalysis, err := b.dbService.GetCachedAnalysis(user.ID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("💾 <b>No Cached Analysis Found</b>\n\n👤 User: @%s (ID: %s)\n❌ No cached analysis available or cache has expired.", user.Username, user.ID))
+		return
+	}
+
+	// Format cached analysis information
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("💾 <b>Cached Analysis for @%s</b>\n\n", user.Username))
+
+	// User information
+	message.WriteString(fmt.Sprintf("👤 <b>User Details:</b>\n"))
+	message.WriteString(fmt.Sprintf("• Username: @%s\n", user.Username))
+	message.WriteString(fmt.Sprintf("• Name: %s\n", user.Name))
+	message.WriteString(fmt.Sprintf("• https://x.com/%s\n", user.Username))
+	message.WriteString(fmt.Sprintf("• User ID: <code>%s</code>\n\n", user.ID))
+
+	// Analysis results
+	message.WriteString(fmt.Sprintf("🔍 <b>Analysis Results:</b>\n"))
+
+	statusEmoji := "✅"
+	statusText := "Clean User"
+	if cachedAnalysis.IsFUDUser {
+		statusEmoji = "🚨"
+		statusText = "FUD User Detected"
+	}
+
+	message.WriteString(fmt.Sprintf("• %s Status: <b>%s</b>\n", statusEmoji, statusText))
+	message.WriteString(fmt.Sprintf("• 🎯 FUD Type: %s\n", cachedAnalysis.FUDType))
+	message.WriteString(fmt.Sprintf("• 📊 Confidence: %.1f%%\n", cachedAnalysis.FUDProbability*100))
+	message.WriteString(fmt.Sprintf("• ⚡ Risk Level: %s\n", strings.ToUpper(cachedAnalysis.UserRiskLevel)))
+
+	if cachedAnalysis.UserSummary != "" {
+		message.WriteString(fmt.Sprintf("• 👤 Profile: %s\n", b.formatter.escape(cachedAnalysis.UserSummary)))
+	}
+
+	message.WriteString("\n")
+
+	// Key evidence
+	if len(cachedAnalysis.KeyEvidence) > 0 {
+		message.WriteString("🔍 <b>Key Evidence:</b>\n")
+		for i, evidence := range cachedAnalysis.KeyEvidence {
+			message.WriteString(fmt.Sprintf("%d. %s\n", i+1, b.formatter.escape(evidence)))
+		}
+		message.WriteString("\n")
+	}
+
+	// Decision reasoning
+	if cachedAnalysis.DecisionReason != "" {
+		message.WriteString(fmt.Sprintf("🧠 <b>Decision Reasoning:</b>\n<i>%s</i>\n\n", b.formatter.escape(cachedAnalysis.DecisionReason)))
+	}
+
+	// Cache metadata - get cache record for metadata
+	var cacheRecord CachedAnalysisModel
+	err = b.dbService.db.Where("user_id = ?", user.ID).First(&cacheRecord).Error
+	if err == nil {
+		message.WriteString("📅 <b>Cache Information:</b>\n")
+		message.WriteString(fmt.Sprintf("• 🕐 Analyzed At: %s\n", cacheRecord.AnalyzedAt.Format("2006-01-02 15:04:05 UTC")))
+		message.WriteString(fmt.Sprintf("• ⏰ Expires At: %s\n", cacheRecord.ExpiresAt.Format("2006-01-02 15:04:05 UTC")))
+
+		// Calculate time remaining
+		timeRemaining := time.Until(cacheRecord.ExpiresAt)
+		if timeRemaining > 0 {
+			hours := int(timeRemaining.Hours())
+			minutes := int(timeRemaining.Minutes()) % 60
+			message.WriteString(fmt.Sprintf("• ⏳ Valid for: %dh %dm\n", hours, minutes))
+		} else {
+			message.WriteString("• ⏳ Status: <b>Expired</b>\n")
+		}
+		message.WriteString("\n")
+	}
+
+	// Related commands
+	message.WriteString("🔍 <b>Related Commands:</b>\n")
+	message.WriteString(fmt.Sprintf("• /history_%s - Message history\n", user.Username))
+	message.WriteString(fmt.Sprintf("• /ticker_history_%s - Ticker posts\n", user.Username))
+	message.WriteString(fmt.Sprintf("• /export_%s - Full export\n", user.Username))
+	message.WriteString(fmt.Sprintf("• /report_%s - PDF report\n", user.Username))
+	message.WriteString(fmt.Sprintf("• /analyze_%s - Force new analysis\n", user.Username))
+
+	b.SendMessage(chatID, message.String())
+}
+
+func (b *BotController) exportTickerHistoryAsFile(chatID int64, username, ticker string, opinions []UserTickerOpinionModel) {
+	// Build file content
+	var fileContent strings.Builder
+	fileContent.WriteString(fmt.Sprintf("TICKER HISTORY EXPORT\n"))
+	fileContent.WriteString(fmt.Sprintf("User: @%s\n", username))
+	fileContent.WriteString(fmt.Sprintf("Ticker: %s\n", ticker))
+	fileContent.WriteString(fmt.Sprintf("Total Messages: %d\n", len(opinions)))
+	fileContent.WriteString(fmt.Sprintf("Generated: %s\n\n", time.Now().Format("2006-01-02 15:04:05 UTC")))
+	fileContent.WriteString(strings.Repeat("=", 60) + "\n\n")
+
+	for i, opinion := range opinions {
+		fileContent.WriteString(fmt.Sprintf("[%d] %s\n", i+1, opinion.TweetCreatedAt.Format("2006-01-02 15:04:05 UTC")))
+		fileContent.WriteString(fmt.Sprintf("Tweet ID: %s\n", opinion.TweetID))
+
+		// Add reply context if available
+		if opinion.InReplyToID != "" {
+			fileContent.WriteString(fmt.Sprintf("Reply to: %s\n", opinion.InReplyToID))
+			if opinion.RepliedToAuthor != "" {
+				fileContent.WriteString(fmt.Sprintf("Reply to @%s: %s\n", opinion.RepliedToAuthor, opinion.RepliedToText))
+			}
+		}
+
+		fileContent.WriteString(fmt.Sprintf("Search Query: %s\n", opinion.SearchQuery))
+		fileContent.WriteString(fmt.Sprintf("Found At: %s\n", opinion.FoundAt.Format("2006-01-02 15:04:05 UTC")))
+		fileContent.WriteString("Message:\n")
+		fileContent.WriteString(opinion.Text)
+		fileContent.WriteString("\n" + strings.Repeat("-", 40) + "\n\n")
+	}
+
+	// Write to file
+	filename := fmt.Sprintf("%s_ticker_%s_%s.txt", username, ticker, time.Now().Format("20060102_150405"))
+	sealed, seal := sealEvidenceText(fileContent.String())
+
+	// Send file to Telegram
+	caption := fmt.Sprintf("💰 <b>Ticker History Export</b>\n\n👤 User: @%s\n🏷️ Ticker: %s\n📊 Total Messages: %d\n📅 Generated: %s",
+		username,
+		ticker,
+		len(opinions),
+		time.Now().Format("2006-01-02 15:04:05")) + seal.caption()
+	log.Printf("🔐 Ticker history export of @%s for chat %d sealed, sha256 %s", username, chatID, seal.Digest)
+
+	err := b.sendExportDocument(chatID, filename, sealed, caption)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+		return
+	}
+
+	// Send confirmation message
+	b.SendMessage(chatID, "✅ Ticker history file sent successfully!")
+}
+
+// handleExportCommand sends the full message history of a user as a file: /export_username [txt|csv|json]
+func (b *BotController) handleExportCommand(chatID int64, command string, args []string) {
+	// Extract username from command "/export_username"
+	prefix := "/export_"
+	if !strings.HasPrefix(command, prefix) {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /export_username")
+		return
+	}
+	format, ok := parseExportFormat(args)
+	if !ok {
+		b.SendMessage(chatID, "❌ Unknown format. Use /export_username [txt|csv|json]")
+		return
+	}
+
+	username, _ := b.resolveTwitterReference(strings.TrimPrefix(command, prefix))
+
+	// Get all messages for the user
+	tweets, err := b.dbService.GetAllUserMessagesByUsername(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving messages for @%s: %v", username, err))
+		return
+	}
+
+	if len(tweets) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No messages found for @%s", username))
+		return
+	}
+
+	tweetIDs := make([]string, len(tweets))
+	for i, tweet := range tweets {
+		tweetIDs[i] = tweet.ID
+	}
+	revisions, err := b.dbService.GetTweetRevisions(tweetIDs)
+	if err != nil {
+		log.Printf("Failed to load tweet revisions for export: %v", err)
+	}
+	if format != EXPORT_FORMAT_TXT {
+		b.sendStructuredExport(chatID, username, format, tweets, revisions)
+		return
+	}
+
+	// Create text file content
+	var fileContent strings.Builder
+	fileContent.WriteString(fmt.Sprintf("FULL MESSAGE HISTORY FOR @%s\n", strings.ToUpper(username)))
+	fileContent.WriteString(fmt.Sprintf("Generated: %s\n", time.Now().Format("2006-01-02 15:04:05 UTC")))
+	fileContent.WriteString(fmt.Sprintf("Total Messages: %d\n", len(tweets)))
+	fileContent.WriteString(strings.Repeat("=", 80) + "\n\n")
+
+	for i, tweet := range tweets {
+		fileContent.WriteString(fmt.Sprintf("[%d] %s\n", i+1, tweet.CreatedAt.Format("2006-01-02 15:04:05 UTC")))
+		fileContent.WriteString(fmt.Sprintf("ID: %s\n", tweet.ID))
+		if tweet.InReplyToID != "" {
+			fileContent.WriteString(fmt.Sprintf("Reply to: %s\n", tweet.InReplyToID))
+		}
+		fileContent.WriteString(fmt.Sprintf("Source: %s\n", tweet.SourceType))
+		if tweet.TickerMention != "" {
+			fileContent.WriteString(fmt.Sprintf("Ticker: %s\n", tweet.TickerMention))
+		}
+		fileContent.WriteString("Message:\n")
+		fileContent.WriteString(tweet.Text)
+		fileContent.WriteString("\n")
+		writeEditHistory(&fileContent, revisions[tweet.ID])
+		fileContent.WriteString(strings.Repeat("-", 40) + "\n\n")
+	}
+
+	// Write to file
+	filename := fmt.Sprintf("%s_messages_%s.txt", username, time.Now().Format("20060102_150405"))
+	sealed, seal := sealEvidenceText(fileContent.String())
+
+	// Send file to Telegram
+	caption := fmt.Sprintf("📄 <b>Full Message Export</b>\n\n👤 User: @%s\n📊 Total Messages: %d\n📅 Generated: %s",
+		username,
+		len(tweets),
+		time.Now().Format("2006-01-02 15:04:05")) + seal.caption()
+	log.Printf("🔐 Message export of @%s for chat %d sealed, sha256 %s", username, chatID, seal.Digest)
+
+	err = b.sendExportDocument(chatID, filename, sealed, caption)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+		return
+	}
+
+	// Send confirmation message
+	b.SendMessage(chatID, "✅ Export file sent successfully!")
+}
+
+// sendStructuredExport sends the history as CSV or JSON. The formats have no room for a trailer,
+// the seal goes into the caption only.
+func (b *BotController) sendStructuredExport(chatID int64, username string, format string, tweets []TweetModel, revisions map[string][]TweetRevisionModel) {
+	now := time.Now()
+	var rendered string
+	var err error
+	if format == EXPORT_FORMAT_CSV {
+		rendered, err = renderHistoryCSV(tweets, revisions)
+	} else {
+		rendered, err = renderHistoryJSON(username, tweets, revisions, now)
+	}
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error encoding messages: %v", err))
+		return
+	}
+
+	filename := fmt.Sprintf("%s_messages_%s.%s", username, now.Format("20060102_150405"), format)
+	seal := sealEvidence([]byte(rendered))
+	caption := fmt.Sprintf("📄 <b>Full Message Export</b>\n\n👤 User: @%s\n📊 Total Messages: %d\n📐 Format: %s\n📅 Generated: %s",
+		username, len(tweets), strings.ToUpper(format), now.Format("2006-01-02 15:04:05")) + seal.caption()
+	log.Printf("🔐 Message export of @%s for chat %d sealed, sha256 %s", username, chatID, seal.Digest)
+
+	err = b.sendExportDocument(chatID, filename, rendered, caption)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
+
+func (b *BotController) writeToFile(filename, content string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	return err
+}
+
+// handleSearchCommand searches users by username or name, /search_p2 shows the next page of the chat's last search
+func (b *BotController) handleSearchCommand(chatID int64, command string, args []string) {
+	var users []UserModel
+	var err error
+	var searchTitle string
+
+	query := strings.TrimSpace(strings.Join(args, " "))
+	_, page := splitPageSuffix(command)
+	if command == "/search" {
+		b.searches.set(chatID, query)
+	} else if query == "" {
+		query = b.searches.get(chatID)
+	}
+
+	// One extra user tells whether there is a next page
+	offset := (page - 1) * SEARCH_PAGE_SIZE
+	if query == "" {
+		// No query provided - show the most active users
+		users, err = b.dbService.GetTopActiveUsersPage(SEARCH_PAGE_SIZE+1, offset)
+		searchTitle = fmt.Sprintf("🔥 <b>Most Active Users</b> (Page %d)", page)
+	} else {
+		// Search by query
+		users, err = b.dbService.SearchUsers(query, SEARCH_PAGE_SIZE+1, offset)
+		searchTitle = fmt.Sprintf("🔍 <b>Search Results for '%s'</b> (Page %d)", html.EscapeString(query), page)
+	}
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error searching users: %v", err))
+		return
+	}
+
+	if len(users) == 0 {
+		if page > 1 {
+			b.SendMessage(chatID, "📭 No more users, back to the first page: /search_p1")
+		} else if query == "" {
+			b.SendMessage(chatID, "📭 No active users found in database")
+		} else {
+			b.SendMessage(chatID, fmt.Sprintf("🔍 No users found matching '%s'", html.EscapeString(query)))
+		}
+		return
+	}
+	hasNext := len(users) > SEARCH_PAGE_SIZE
+	if hasNext {
+		users = users[:SEARCH_PAGE_SIZE]
+	}
+
+	// Format search results
+	var searchResults strings.Builder
+	searchResults.WriteString(searchTitle + "\n\n")
+
+	for i, user := range users {
+		fudStatus := ""
+		if b.dbService.IsFUDUser(user.ID) {
+			fudStatus = " 🚨 <b>FUD USER</b>"
+		}
+
+		analyzedStatus := ""
+		if b.dbService.IsUserDetailAnalyzed(user.ID) {
+			analyzedStatus = " ✅ Analyzed"
+		}
+
+		searchResults.WriteString(fmt.Sprintf("<b>%d.</b> @%s%s%s\n", offset+i+1, user.Username, fudStatus, analyzedStatus))
+		if user.Name != "" && user.Name != user.Username {
+			searchResults.WriteString(fmt.Sprintf("    Name: %s\n", user.Name))
+		}
+		searchResults.WriteString(fmt.Sprintf("    ID: <code>%s</code>\n", user.ID))
+
+		// Add quick action commands
+		searchResults.WriteString(fmt.Sprintf("    Commands: /history_%s | /analyze_%s\n\n", user.Username, user.Username))
+	}
+
+	searchResults.WriteString(pageNavigation("/search", page, hasNext))
+
+	// Add note about commands
+	searchResults.WriteString("💡 <b>Quick Actions:</b>\n• Tap /history_username to view recent messages\n• Tap /analyze_username to run second step analysis")
+
+	b.SendMessage(chatID, searchResults.String())
+}
+
+func (b *BotController) handleAnalyzeCommand(chatID int64, command string) {
+	prefix := "/analyze_"
+	if !strings.HasPrefix(command, prefix) {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /cache_<username_or_id>")
+		return
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(command, prefix))
+	if len(fields) == 0 {
+		fields = []string{""}
+	}
+	username, tweetID := b.resolveTwitterReference(fields[0])
+	if username == "" {
+		b.SendMessage(chatID, "❌ Please provide username, user ID or tweet link. Use /analyze_<username_or_id>")
+		return
+	}
+
+	task := &AnalysisTaskModel{Username: username, TweetID: tweetID, Priority: ANALYSIS_PRIORITY_NORMAL}
+	for _, option := range fields[1:] {
+		if err := b.applyAnalysisOption(task, option); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+	}
+	b.startAnalysisTask(chatID, task)
+}
+
+// startAnalysisTask posts the progress message, stores the task and hands it to the analysis pipeline
+func (b *BotController) startAnalysisTask(chatID int64, task *AnalysisTaskModel) (string, error) {
+	// Generate unique task ID
+	taskID := b.generateNotificationID()
+
+	// Send initial progress message
+	initialText := fmt.Sprintf("🔄 <b>Starting Analysis for @%s</b>\n\n📋 <b>Status:</b> Initializing...\n🆔 <b>Task ID:</b> <code>%s</code>\n🔔 <b>Results:</b> %s\n\n⏳ Please wait, this may take a few minutes.", task.Username, taskID, describeNotifyRoute(task))
+	messageID, err := b.SendMessageWithID(chatID, initialText)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Failed to start analysis: %v", err))
+		return "", err
+	}
+
+	task.ID = taskID
+	task.TelegramChatID = chatID
+	task.MessageID = messageID
+	queuedID, err := b.queueAnalysisTask(task)
+	if err != nil {
+		b.EditMessage(chatID, messageID, fmt.Sprintf("❌ <b>Analysis Failed</b>\n\nFailed to create analysis task: %v", err))
+		return "", err
+	}
+	if queuedID != taskID {
+		b.EditMessage(chatID, messageID, fmt.Sprintf("⏳ <b>Analysis for @%s is already in progress</b>\n\n🆔 <b>Task ID:</b> <code>%s</code>\n\nUse /tasks to follow it.", task.Username, queuedID))
+		return queuedID, nil
+	}
+
+	// Start progress monitor
+	go b.monitorAnalysisProgress(taskID)
+
+	return taskID, nil
+}
+
+// queueAnalysisTask stores the task and starts processing it without any Telegram progress message.
+// Other notification sinks use it directly. When an identical task is already pending or running,
+// its ID is returned instead and nothing new is started.
+func (b *BotController) queueAnalysisTask(task *AnalysisTaskModel) (string, error) {
+	if task.ID == "" {
+		task.ID = b.generateNotificationID()
+	}
+	task.Status = ANALYSIS_STATUS_PENDING
+	task.CurrentStep = ANALYSIS_STEP_INIT
+	task.ProgressText = "Initializing analysis..."
+	task.StartedAt = time.Now()
+
+	taskID, created, err := b.createAnalysisTask(task)
+	if err != nil {
+		return "", err
+	}
+
+	// Start analysis in goroutine
+	if created {
+		go b.runAnalysisTask(taskID, task.Kind)
+	}
+
+	return taskID, nil
+}
+
+func (b *BotController) handleHelpCommand(chatID int64) {
+	if b.isGuestChat(chatID) {
+		b.SendMessage(chatID, fmt.Sprintf(guestHelpMessage, chatID))
+		return
+	}
+	helpMessage := `🤖 <b>FUD Detection Bot - Available Commands</b>
+
+🔍 <b>Search & Analysis Commands:</b>
+• /search - Search users by username/name, /search_p2 for the next page
+• /analyze_username - Run manual FUD analysis
+• /analyze_username to:broadcast - Send the result to all chats (or to:&lt;chat_id&gt;)
+• /analyze_username history:50 - Limit the history analyzed (history:30d, history:ticker, combine with commas)
+• /analyze_tweet id_or_link - Judge one specific tweet in its thread
+• /report link_or_username reason - Flag suspicious content for priority analysis
+• /reports - Recent reports and their verdicts
+
+📊 <b>User Investigation Commands:</b>
+• /history_username - View recent messages, 20 per page (/history_username_p2 for older)
+• /ticker_history_username - View ticker-related messages
+• /cache_username - View cached analysis results
+• /export_username [csv|json] - Export full message history as text, CSV or JSON file
+• /report_username - PDF report with verdict, evidence and ticker timeline
+• /riskchart_username - Chart of the FUD probability over every analysis
+• /graph_username [dot] - Export follower and reply graph (GraphML or DOT) for Gephi/Graphviz
+• /network_username - Show how a user connects to known FUD accounts
+• /detail_id - View detailed FUD analysis
+• /ack_id, /assign_id @teammate, /resolve_id - Take, hand over or close an alert
+• /openalerts [mine|unassigned|@teammate] - Alerts nobody resolved yet
+• Reply to an alert with history, analyze, detail, ack, resolve, riskchart… to run the command on it
+
+📊 <b>Analysis Management:</b>
+• /fudlist - Show all detected FUD users
+• /topfud - Show cached FUD users sorted by last message
+• /exportfudlist - Export FUD usernames as comma-separated list
+• /tasks - Show running analysis tasks
+• /stats [daily|weekly] - FUD summary of the last day or week
+• /health - Monitoring status
+• /sentiment [days] - Ticker mentions and the share coming from FUD accounts
+• /cancel_&lt;task_id&gt; - Stop a running analysis
+• /batch_analyze user1,user2,user3 [to:broadcast|to:&lt;chat_id&gt;] [history:...] - Analyze multiple users
+
+⚙️ <b>Chat Settings:</b>
+• /alias set f fudlist|remove f|list - Shortcuts for frequent commands in this chat
+• /verbosity compact|normal|detailed - Alert format for this chat
+• /silent none|low|medium|high - Deliver alerts up to this severity without sound
+• /quiet 23:00-07:00|off - Hold non-critical alerts overnight and deliver them as one digest, critical alerts still pass
+• /subscribe daily|weekly|off - Scheduled FUD summary for this chat
+• /topic alerts|reports [off] - Post alerts or digests in the forum topic the command is sent in
+• /subscribe_ticker BTC[,ETH]|all, /unsubscribe_ticker BTC - Tickers whose community alerts this chat receives
+• /redaction - Show the redaction profile of this chat (admins: /redaction chat_id profile)
+• /chatrole - Show the role of this chat (admins: /chatrole chat_id guest|member for read-only stakeholder chats)
+
+❓ <b>Help Commands:</b>
+• /help - Show this help message
+• /start - Show this help message
+
+💡 <b>Usage Tips:</b>
+• Commands with underscore (_) need exact format: /analyze_john
+• Commands with space accept parameters: /search john
+• Tweet or profile links work instead of usernames: /analyze https://x.com/john/status/123
+• All commands are case-sensitive
+• Bot responds to FUD alerts automatically
+
+🔔 <b>Alert Types:</b>
+• 🚨🔥 Critical - Immediate action required
+• 🚨 High - Monitor closely  
+• ⚠️ Medium - Standard monitoring
+• ℹ️ Low - Log and watch
+
+👤 <b>Your Chat ID:</b> %d`
+
+	// Admin commands only make sense where they are allowed and would not fit in one message with the rest
+	if b.isAdminChat(chatID) {
+		helpMessage = strings.Replace(helpMessage, "\n\n⚙️", "\n\n"+adminHelpMessage+"\n\n⚙️", 1)
+	}
+	b.SendMessage(chatID, fmt.Sprintf(helpMessage, chatID))
+}
+
+const adminHelpMessage = `🛡 <b>Admin Commands:</b>
+• /top20_analyze - Analyze top 20 most active users
+• /analyze_all - Analyze ALL users with messages
+• /pending_chats - Chats waiting for approval
+• /notify add id1,id2 [note: why]|remove id|clear|audit - Manage chats receiving notifications
+• /whitelist add username [note: why]|remove username|list - Accounts that are never analyzed or flagged
+• /watch add username [note: why]|remove username|list - Run every new message of a user through the detailed analysis and summarize it here
+• /blocklist import name url|remove name|check username|list - Community FUD blocklists, listed accounts alert on first contact
+• /mark_clean username [note: why], /mark_fud username [fud_type] [note: why] - Record a human verdict, overriding the analysis
+• /poll username [quorum] - Ask the moderators in a Telegram poll, the answer reaching the quorum becomes the verdict
+• /eval export [local|name|all]|import name url|remove name|list - Share labeled examples between deployments as JSONL
+• /prompt [list|show step|set step text|rollback step [version]|reload] - Versioned system prompts, applied without a restart
+• /loglevel [debug|info|warn|error] - Show or change the log level until the next restart
+• /dbversion - Database schema version and applied migrations
+• /archive_status - Database size, retention policy, rows due for archival and the next purge
+• /handoff [8h] - Shift hand-off: alerts of the last hours, open incidents, unacknowledged criticals and running tasks
+• /dedup - Merge tweets stored under ID variants and report tweets merged across sources
+• /events [type] [N] - Latest state changes: FUD users, verdicts, user status and config, also at /api/events
+• /followups - Scheduled re-evaluations of flagged users and their latest outcomes
+• /moderators - Verified moderators and revoked bot privileges
+• /import [format:csv|twitterapi|archive] [author:username] [backfill:N] [--dry-run] - Send a CSV, twitterapi JSON dump or X archive tweets.js with this caption to import its tweets
+• /reformat_alerts [all] - Re-render stored alerts and their messages after template changes
+• /filters [min_age|retweets|lang|mute ...] - Tweets dropped before storage and analysis: new accounts, retweets, languages, muted bots
+• /threshold [severity broadcast|users|log|reset] - Deliver alerts of a severity to all chats, private chats only, or just store them
+• /autoaction add N notify chat_id|webhook url|incident [2h] [community:id]|remove id|list - Follow-up steps after N confirmed detections of a user
+• /federation - FUD accounts and narratives shared with partner deployments
+• /maintenance 30m|off - Pause non-critical alerts and summarize them afterwards
+• /warroom on 2h|off - Elevated monitoring during an attack, with live status and incident summary
+• /tail on [10m]|off - Stream a line per ingested message with its first step verdict, to check the pipeline on live traffic
+• /export_opinions [all|today|7d|30d] - CSV of the stored ticker opinions with search query and reply context, for analytics and training
+• /usage [today|7d|30d] - Tweets ingested, LLM calls and tokens by step, Twitter API calls and cache hits
+• /anonstats [days] [hashed] - Anonymized detection statistics as JSON, safe to share with partners
+• /tune [30d] [0.6,0.7,0.8] - Replay stored analyses against FUD probability cutoffs, alert volume and confirmed FUD caught
+• /costs [today|7d|30d], /costs_username - Spend per command and daily budget, LLM calls and tokens per analyzed user
+• /approve_chat id, /reject_chat id - Allow or deny alerts for a chat
+• /restore_user, /restore_user_username - List and restore deleted users, tweets and FUD records
+• /community add id TICKER [chats:id1,id2] [context: text]|remove id|list - Monitored communities and where their alerts go`
+
+// processAnalysisTask processes the actual analysis work
+func (b *BotController) processAnalysisTask(taskID string) {
+	defer func() {
+		if r := recover(); r != nil {
+			logFor("analysis_task").Error("analysis task panicked", "task_id", taskID, "panic", r)
+			b.dbService.SetAnalysisTaskError(taskID, TASK_ERROR_INTERNAL, fmt.Sprintf("Internal error: %v", r))
+		}
+	}()
+	ctx, done := b.analysisTaskContext(taskID)
+	defer done()
+
+	// Get task details
+	task, err := b.dbService.GetAnalysisTask(taskID)
+	if err != nil {
+		logFor("analysis_task").Error("failed to get analysis task", "task_id", taskID, "error", err)
+		return
+	}
+	logger := taskLogger("analysis_task", task)
+
+	username := task.Username
+
+	// Step 1: User lookup
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_USER_LOOKUP, "Looking up user information...")
+	user, err := b.dbService.GetUserByUsername(username)
+	var userID string
+	if err != nil && task.UserID != "" {
+		// Re-analyses of known FUD users come with the user ID
+		userID = task.UserID
+	} else if err != nil {
+		userID = "unknown_" + username
+		logger.Info("user not found in database, using placeholder ID")
+	} else {
+		userID = user.ID
+		// Update task with found user ID
+		task.UserID = userID
+		b.dbService.UpdateAnalysisTask(task)
+	}
+
+	// Step 2: Get user tweet for analysis context
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_TICKER_SEARCH, "Searching for user's ticker mentions...")
+	tweet, err := b.dbService.GetUserTweetForAnalysis(username)
+	if task.TweetID != "" {
+		// Reports point at a specific tweet, prefer it over the latest one
+		if reported, reportedErr := b.dbService.GetTweet(task.TweetID); reportedErr == nil {
+			tweet, err = reported, nil
+		}
+	}
+
+	// Users outside the community are judged on their public timeline rather than a placeholder
+	if limit := externalTimelineLimit(); err != nil && b.twitterApi != nil && limit > 0 {
+		b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_USER_LOOKUP, "No local data, fetching the public timeline...")
+		fetched, fetchErr := fetchExternalTimeline(b.twitterApi, b.dbService, username, limit)
+		switch {
+		case classifyTaskError(fetchErr) == TASK_ERROR_USER_NOT_FOUND:
+			b.dbService.SetAnalysisTaskError(taskID, TASK_ERROR_USER_NOT_FOUND, html.EscapeString(fetchErr.Error()))
+			return
+		case fetchErr != nil:
+			logger.Warn("failed to fetch the public timeline of an external user", "error", fetchErr)
+		case fetched > 0:
+			logger.Info("fetched the public timeline of an external user", "tweets", fetched)
+			tweet, err = b.dbService.GetUserTweetForAnalysis(username)
+			if user, userErr := b.dbService.GetUserByUsername(username); userErr == nil {
+				userID = user.ID
+				task.UserID = userID
+				b.dbService.UpdateAnalysisTask(task)
+			}
+		}
+	}
+
+	var newMessage twitterapi.NewMessage
+
+	if err != nil {
+		logger.Info("no tweet found, creating placeholder data")
+
+		newMessage = twitterapi.NewMessage{
+			TweetID:      "manual_analysis_" + username,
+			ReplyTweetID: "",
+			Author: struct {
+				UserName string
+				Name     string
+				ID       string
+			}{
+				UserName: username,
+				Name:     username,
+				ID:       userID,
+			},
+			Text:      "Manual analysis request - no recent tweets found",
+			CreatedAt: time.Now().Format(time.RFC3339),
+			ParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "placeholder_parent",
+				Author: "system",
+				Text:   "No parent tweet available - manual analysis",
+			},
+			GrandParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "",
+				Author: "",
+				Text:   "",
+			},
+			IsManualAnalysis:  true,
+			ForceNotification: true,
+			TaskID:            taskID,
+			TelegramChatID:    task.notificationChatID(),
+			HistoryWindow:     task.HistoryWindow,
+			DiscordChannelID:  task.DiscordChannel,
+			NotificationRoute: task.NotifyRoute,
+			RequestSource:     task.RequestSource,
+			RequestReason:     task.RequestReason,
+		}
+	} else {
+		newMessage = twitterapi.NewMessage{
+			TweetID:      tweet.ID,
+			ReplyTweetID: tweet.InReplyToID,
+			Author: struct {
+				UserName string
+				Name     string
+				ID       string
+			}{
+				UserName: username,
+				Name:     username,
+				ID:       tweet.UserID,
+			},
+			Text:      tweet.Text,
+			CreatedAt: tweet.CreatedAt.Format(time.RFC3339),
+			ParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "manual_parent",
+				Author: "system",
+				Text:   "Manual analysis - limited context available",
+			},
+			GrandParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "",
+				Author: "",
+				Text:   "",
+			},
+			IsManualAnalysis:  true,
+			ForceNotification: true,
+			TaskID:            taskID,
+			TelegramChatID:    task.notificationChatID(),
+			HistoryWindow:     task.HistoryWindow,
+			DiscordChannelID:  task.DiscordChannel,
+			NotificationRoute: task.NotifyRoute,
+			RequestSource:     task.RequestSource,
+			RequestReason:     task.RequestReason,
+		}
+	}
+
+	// Scheduled re-checks report the change of verdict to the admin chats instead of an alert
+	if task.Kind == ANALYSIS_KIND_REANALYSIS {
+		newMessage.ForceNotification = false
+		newMessage.Reanalysis = true
+	}
+
+	// Step 3: Send to analysis channel
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_CLAUDE_ANALYSIS, "Sending for FUD analysis...")
+
+	analysisChannel := b.analysisChannel
+	if task.Priority == ANALYSIS_PRIORITY_HIGH && b.priorityChannel != nil {
+		analysisChannel = b.priorityChannel
+	}
+
+	if ctx.Err() != nil {
+		logger.Info("analysis task cancelled before it was sent for analysis")
+		return
+	}
+	select {
+	case analysisChannel <- newMessage:
+		// Successfully sent to analysis - now wait for neural network processing
+		b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_CLAUDE_ANALYSIS, "Processing with neural network...")
+
+		// Task completion will be handled by SecondStepHandler after Claude analysis
+		logger.Info("manual analysis task sent to Claude processing pipeline", "tweet_id", newMessage.TweetID)
+
+	default:
+		// Analysis channel is full
+		b.dbService.SetAnalysisTaskError(taskID, TASK_ERROR_QUEUE_FULL, "Analysis channel is full, please try again later")
+	}
+}
+
+// monitorAnalysisProgress monitors task progress and updates Telegram message. The message is
+// edited when the step changes and every PROGRESS_REFRESH_INTERVAL otherwise, through the shared
+// progress editor.
+func (b *BotController) monitorAnalysisProgress(taskID string) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lastStep := ""
+	var lastEdit time.Time
+	for {
+		select {
+		case <-ticker.C:
+			task, err := b.dbService.GetAnalysisTask(taskID)
+			if err != nil {
+				logFor("analysis_task").Error("failed to get analysis task for monitoring", "task_id", taskID, "error", err)
+				return
+			}
+
+			progressText := b.formatAnalysisProgress(task)
+
+			// Stop monitoring if task is completed, failed or cancelled
+			if task.Status == ANALYSIS_STATUS_COMPLETED || task.Status == ANALYSIS_STATUS_FAILED || task.Status == ANALYSIS_STATUS_CANCELLED {
+				err = b.progress.editNow(b, task.TelegramChatID, task.MessageID, progressText)
+				if err != nil {
+					taskLogger("analysis_task", task).Error("failed to update progress message", "error", err)
+				}
+				return
+			}
+
+			// Update progress message
+			step := task.Status + "|" + task.CurrentStep
+			if step != lastStep || time.Since(lastEdit) >= PROGRESS_REFRESH_INTERVAL {
+				b.progress.queue(b, task.TelegramChatID, task.MessageID, progressText)
+				lastStep, lastEdit = step, time.Now()
+			}
+		}
+	}
+}
+
+// formatTaskRetries notes the transient API failures a task got through, if any
+func formatTaskRetries(task *AnalysisTaskModel) string {
+	if task.Retries == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n🔁 <b>Retries:</b> %d transient API failures", task.Retries)
+}
+
+// formatAnalysisProgress formats the progress message for Telegram
+func (b *BotController) formatAnalysisProgress(task *AnalysisTaskModel) string {
+	if task.Status == ANALYSIS_STATUS_FAILED {
+		if task.ErrorCode != "" {
+			return fmt.Sprintf(`❌ <b>Analysis Failed for @%s</b>
+
+%s
+🆔 <b>Task ID:</b> <code>%s</code>%s`,
+				task.Username,
+				formatTaskError(task),
+				task.ID,
+				formatTaskRetries(task))
+		}
+		return fmt.Sprintf(`❌ <b>Analysis Failed for @%s</b>
+
+⚠️ <b>Error:</b> %s
+🆔 <b>Task ID:</b> <code>%s</code>%s
+
+🔄 You can try running the analysis again.`,
+			task.Username,
+			task.ErrorMessage,
+			task.ID,
+			formatTaskRetries(task))
+	}
+
+	if task.Status == ANALYSIS_STATUS_CANCELLED {
+		return fmt.Sprintf(`🛑 <b>Analysis Cancelled for @%s</b>
+
+📋 <b>Status:</b> %s
+🆔 <b>Task ID:</b> <code>%s</code>`,
+			task.Username,
+			html.EscapeString(task.ErrorMessage),
+			task.ID)
+	}
+
+	if task.Status == ANALYSIS_STATUS_COMPLETED {
+		return fmt.Sprintf(`✅ <b>Analysis Completed for @%s</b>
+
+📋 <b>Status:</b> Finished successfully
+🔍 <b>Results:</b> Check FUD alerts for analysis results
+🆔 <b>Task ID:</b> <code>%s</code>%s%s
+
+✅ Analysis has been completed and results sent to notification system.`,
+			task.Username,
+			task.ID,
+			formatTaskRetries(task)+formatTaskHistoryWindow(task),
+			formatTweetVerdict(task))
+	}
+
+	// Running status with progress steps
+	stepEmoji := "🔄"
+	stepText := task.ProgressText
+
+	switch task.CurrentStep {
+	case ANALYSIS_STEP_INIT:
+		stepEmoji = "⚙️"
+	case ANALYSIS_STEP_USER_LOOKUP:
+		stepEmoji = "🔍"
+	case ANALYSIS_STEP_TICKER_SEARCH:
+		stepEmoji = "📊"
+	case ANALYSIS_STEP_FOLLOWERS:
+		stepEmoji = "👥"
+	case ANALYSIS_STEP_FOLLOWINGS:
+		stepEmoji = "👤"
+	case ANALYSIS_STEP_COMMUNITY_ACTIVITY:
+		stepEmoji = "🏠"
+	case ANALYSIS_STEP_CLAUDE_ANALYSIS:
+		stepEmoji = "🤖"
+	case ANALYSIS_STEP_SAVING_RESULTS:
+		stepEmoji = "💾"
+	}
+
+	// Calculate elapsed time
+	elapsed := time.Since(task.StartedAt)
+	elapsedStr := fmt.Sprintf("%.0fs", elapsed.Seconds())
+	if elapsed.Minutes() >= 1 {
+		elapsedStr = fmt.Sprintf("%.1fm", elapsed.Minutes())
+	}
+
+	return fmt.Sprintf(`🔄 <b>Analyzing @%s</b>
+
+%s <b>Current Step:</b> %s
+⏱️ <b>Running Time:</b> %s
+🆔 <b>Task ID:</b> <code>%s</code>
+
+⏳ Please wait, analysis in progress...
+🛑 /cancel_%s to stop it`,
+		task.Username,
+		stepEmoji, stepText,
+		elapsedStr,
+		task.ID, task.ID)
+}
+
+func (b *BotController) handleFudListCommand(chatID int64, args []string, command string) {
+	// Parse page number from command or arguments
+	page := 1
+
+	// Check if page number is in command format /fudlist_X
+	if strings.HasPrefix(command, "/fudlist_") {
+		pageStr := strings.TrimPrefix(command, "/fudlist_")
+		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	} else if len(args) > 0 {
+		// Fallback to old format with arguments
+		if pageNum, err := strconv.Atoi(args[0]); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+
+	const pageSize = 10 // Users per page
+
+	fudUsers, err := b.dbService.GetAllFUDUsersFromCache()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving FUD users: %v", err))
+		return
+	}
+
+	if len(fudUsers) == 0 {
+		b.SendMessage(chatID, "✅ <b>No FUD Users Detected</b>\n\n🎉 Great news! No FUD users have been detected in the system.")
+		return
+	}
+
+	totalPages := (len(fudUsers) + pageSize - 1) / pageSize
+	if page > totalPages {
+		page = totalPages
+	}
+
+	startIdx := (page - 1) * pageSize
+	endIdx := startIdx + pageSize
+	if endIdx > len(fudUsers) {
+		endIdx = len(fudUsers)
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🚨 <b>FUD Users (%d total) - Page %d/%d</b>\n\n", len(fudUsers), page, totalPages))
+
+	activeFUD := 0
+	cachedFUD := 0
+
+	// Show users for current page
+	for i := startIdx; i < endIdx; i++ {
+		user := fudUsers[i]
+		source := user["source"].(string)
+		if source == "active" {
+			activeFUD++
+		} else {
+			cachedFUD++
+		}
+
+		username := user["username"].(string)
+		userID := user["user_id"].(string)
+		fudType := user["fud_type"].(string)
+		probability := user["fud_probability"].(float64)
+		detectedAt := user["detected_at"].(time.Time)
+
+		sourceEmoji := "🔥"
+		if source == "cached" {
+			sourceEmoji = "💾"
+		}
+
+		// Get last message info
+		lastMessageDate := user["last_message_date"].(time.Time)
+		isAlive := user["is_alive"].(bool)
+		status := user["status"].(string)
+
+		statusEmoji := "💀"
+		if isAlive {
+			statusEmoji = "🟢"
+		}
+
+		message.WriteString(fmt.Sprintf("<b>%d.</b> %s @%s (%s) %s %s\n", i+1, sourceEmoji, username, userID, statusEmoji, status))
+		message.WriteString(fmt.Sprintf("    🎯 Type: %s (%.0f%%)\n", fudType, probability*100))
+		message.WriteString(fmt.Sprintf("    📅 Detected: %s\n", detectedAt.Format("2006-01-02 15:04")))
+
+		if !lastMessageDate.IsZero() {
+			message.WriteString(fmt.Sprintf("    💬 Last msg: %s\n", lastMessageDate.Format("2006-01-02 15:04")))
+		} else {
+			message.WriteString("    💬 Last msg: unknown\n")
+		}
+
+		if userSummary, ok := user["user_summary"].(string); ok && userSummary != "" {
+			message.WriteString(fmt.Sprintf("    👤 Profile: %s\n", userSummary))
+		}
+		if promoted, ok := user["promoted"].(string); ok && promoted != "" {
+			message.WriteString(fmt.Sprintf("    🏷 Promotes: %s\n", strings.ReplaceAll(promoted, ",", ", ")))
+		}
+
+		// Add enhanced command links
+		message.WriteString("    🔍 <b>Commands:</b>\n")
+		message.WriteString(fmt.Sprintf("      /export_%s - Message history\n", username))
+		message.WriteString(fmt.Sprintf("      /ticker_history_%s - Ticker posts\n", username))
+		message.WriteString(fmt.Sprintf("      /cache_%s - detailed analysis\n", username))
+		message.WriteString(fmt.Sprintf("      https://x.com/%s\n", username))
+		message.WriteString("\n")
+	}
+
+	// Add pagination controls
+	if totalPages > 1 {
+		message.WriteString("📄 <b>Navigation:</b>\n")
+		if page > 1 {
+			message.WriteString(fmt.Sprintf("  ⬅️ /fudlist_%d (Previous)\n", page-1))
+		}
+		if page < totalPages {
+			message.WriteString(fmt.Sprintf("  ➡️ /fudlist_%d (Next)\n", page+1))
+		}
+		message.WriteString("\n")
+	}
+
+	// Count all users for summary
+	totalActiveFUD := 0
+	totalCachedFUD := 0
+	for _, user := range fudUsers {
+		source := user["source"].(string)
+		if source == "active" {
+			totalActiveFUD++
+		} else {
+			totalCachedFUD++
+		}
+	}
+
+	// Count alive and dead users
+	aliveCount := 0
+	deadCount := 0
+	for _, user := range fudUsers {
+		if user["is_alive"].(bool) {
+			aliveCount++
+		} else {
+			deadCount++
+		}
+	}
+
+	message.WriteString(fmt.Sprintf("📊 <b>Summary:</b>\n• 🔥 Active FUD users: %d\n• 💾 Cached detections: %d\n• 🟢 Alive users: %d\n• 💀 Dead users: %d\n\n", totalActiveFUD, totalCachedFUD, aliveCount, deadCount))
+	message.WriteString("💡 <b>Legend:</b>\n• 🔥 Active (persistent in database)\n• 💾 Cached (expires in 24h)\n• 🟢 Alive (active within 30 days)\n• 💀 Dead (no activity >30 days)")
+
+	if totalPages > 1 {
+		message.WriteString(fmt.Sprintf("\n\n📖 Use <code>/fudlist_[page]</code> to navigate\nExample: <code>/fudlist_2</code>"))
+	}
+
+	b.SendMessage(chatID, message.String())
+}
+
+func (b *BotController) handleExportFudListCommand(chatID int64) {
+	fudUsers, err := b.dbService.GetAllFUDUsersFromCache()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving FUD users: %v", err))
+		return
+	}
+
+	if len(fudUsers) == 0 {
+		b.SendMessage(chatID, "✅ No FUD users detected")
+		return
+	}
+
+	// Collect usernames without @ symbol
+	var usernames []string
+	for _, user := range fudUsers {
+		username := user["username"].(string)
+		usernames = append(usernames, username)
+	}
+
+	// Join with commas
+	exportText := strings.Join(usernames, ", ")
+
+	message := fmt.Sprintf("📋 <b>FUD Users Export (%d total)</b>\n\n<code>%s</code>", len(fudUsers), exportText)
+
+	b.SendMessage(chatID, message)
+}
+
+func (b *BotController) handleTopFudCommand(chatID int64, args []string, command string) {
+	logger := logFor("topfud").With("chat_id", chatID)
+	logger.Debug("🔍 topfud command started", "command", command)
+	b.SendMessage(chatID, "🔄 Starting TopFud analysis...")
+
+	// Parse page number from command or arguments
+	page := 1
+
+	// Check if page number is in command format /topfud_X
+	if strings.HasPrefix(command, "/topfud_") {
+		pageStr := strings.TrimPrefix(command, "/topfud_")
+		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+		logger.Debug("📄 page number from command", "page", page)
+	} else if len(args) > 0 {
+		// Fallback to old format with arguments
+		if pageNum, err := strconv.Atoi(args[0]); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+		logger.Debug("📄 page number from args", "page", page)
+	}
+
+	const pageSize = 10 // Users per page
+
+	logger.Debug("🔍 calling GetActiveFUDUsersSortedByLastMessage")
+	b.SendMessage(chatID, "🔍 Querying database for FUD users...")
+
+	fudUsers, err := b.dbService.GetActiveFUDUsersSortedByLastMessage()
+	if err != nil {
+		logger.Error("❌ error retrieving active FUD users", "error", err)
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving active FUD users: %v", err))
+		return
+	}
+
+	logger.Debug("📊 found FUD users from cache", "count", len(fudUsers))
+	b.SendMessage(chatID, fmt.Sprintf("📊 Found %d FUD users in cache", len(fudUsers)))
+
+	if len(fudUsers) == 0 {
+		b.SendMessage(chatID, "✅ <b>No Active FUD Users Found</b>\n\n🎉 Great news! No active FUD users have been detected in the cache.")
+		return
+	}
+
+	logger.Debug("📊 preparing to display results")
+	b.SendMessage(chatID, "📊 Preparing results display...")
+
+	totalPages := (len(fudUsers) + pageSize - 1) / pageSize
+	if page > totalPages {
+		page = totalPages
+	}
+
+	startIdx := (page - 1) * pageSize
+	endIdx := startIdx + pageSize
+	if endIdx > len(fudUsers) {
+		endIdx = len(fudUsers)
+	}
+
+	logger.Debug("📄 page info", "page", page, "total_pages", totalPages, "from", startIdx+1, "to", endIdx)
+
+	var message strings.Builder
+
+	aliveCount := 0
+	deadCount := 0
+
+	// Show users for current page
+	for i := startIdx; i < endIdx; i++ {
+		user := fudUsers[i]
+
+		username := user["username"].(string)
+		userID := user["user_id"].(string)
+		lastMessageDate := user["last_message_date"].(time.Time)
+		isAlive := user["is_alive"].(bool)
+		status := user["status"].(string)
+
+		if isAlive {
+			aliveCount++
+		} else {
+			deadCount++
+		}
+
+		statusEmoji := "💀"
+		if isAlive {
+			statusEmoji = "🟢"
+		}
+
+		message.WriteString(fmt.Sprintf("<b>%d.</b> 💾 @%s (%s) %s %s\n", i+1, username, userID, statusEmoji, status))
+
+		if !lastMessageDate.IsZero() {
+			message.WriteString(fmt.Sprintf("    💬 Last msg: %s\n", lastMessageDate.Format("2006-01-02 15:04")))
+		} else {
+			message.WriteString("    💬 Last msg: unknown\n")
+		}
+
+		// Add enhanced command links
+		message.WriteString(fmt.Sprintf("      https://x.com/%s\n", username))
+		message.WriteString("\n")
+	}
+
+	// Add pagination controls
+	if totalPages > 1 {
+		message.WriteString("📄 <b>Navigation:</b>\n")
+		if page > 1 {
+			message.WriteString(fmt.Sprintf("  ⬅️ /topfud_%d (Previous)\n", page-1))
+		}
+		if page < totalPages {
+			message.WriteString(fmt.Sprintf("  ➡️ /topfud_%d (Next)\n", page+1))
+		}
+		message.WriteString("\n")
+	}
+
+	if totalPages > 1 {
+		message.WriteString(fmt.Sprintf("\n\n📖 Use <code>/topfud_[page]</code> to navigate\nExample: <code>/topfud_2</code>"))
+	}
+
+	b.SendMessage(chatID, message.String())
+}
+
+func (b *BotController) handleTasksCommand(chatID int64) {
+	logger := logFor("tasks").With("chat_id", chatID)
+	logger.Debug("📋 tasks command started")
+
+	tasks, err := b.dbService.GetAllRunningAnalysisTasks()
+	if err != nil {
+		logger.Error("❌ error retrieving analysis tasks", "error", err)
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving analysis tasks: %v", err))
+		return
+	}
+
+	logger.Debug("📊 found running analysis tasks", "count", len(tasks))
+
+	failed, err := b.dbService.GetRecentFailedAnalysisTasks(time.Now().Add(-TASK_FAILURES_WINDOW), TASK_FAILURES_LIMIT)
+	if err != nil {
+		logger.Error("❌ error retrieving failed analysis tasks", "error", err)
+	}
+
+	if len(tasks) == 0 {
+		logger.Debug("✅ no running tasks, sending empty message")
+		b.SendMessage(chatID, "✅ <b>No Running Analysis Tasks</b>\n\n🎯 All analysis tasks have been completed.\n"+formatRecentTaskFailures(failed))
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🔄 <b>Running Analysis Tasks (%d total)</b>\n\n", len(tasks)))
+
+	// Limit to first 20 tasks to avoid message being too long
+	maxTasks := 20
+	if len(tasks) > maxTasks {
+		message.WriteString(fmt.Sprintf("📄 <i>Showing first %d tasks</i>\n\n", maxTasks))
+	}
+
+	for i, task := range tasks {
+		// Limit number of tasks to prevent message being too long
+		if i >= maxTasks {
+			message.WriteString(fmt.Sprintf("... and %d more tasks\n\n", len(tasks)-maxTasks))
+			break
+		}
+
+		statusEmoji := "⏳"
+		if task.Status == ANALYSIS_STATUS_RUNNING {
+			statusEmoji = "🔄"
+		}
+
+		stepEmoji := "🔄"
+		switch task.CurrentStep {
+		case ANALYSIS_STEP_INIT:
+			stepEmoji = "⚙️"
+		case ANALYSIS_STEP_USER_LOOKUP:
+			stepEmoji = "🔍"
+		case ANALYSIS_STEP_TICKER_SEARCH:
+			stepEmoji = "📊"
+		case ANALYSIS_STEP_FOLLOWERS:
+			stepEmoji = "👥"
+		case ANALYSIS_STEP_FOLLOWINGS:
+			stepEmoji = "👤"
+		case ANALYSIS_STEP_COMMUNITY_ACTIVITY:
+			stepEmoji = "🏠"
+		case ANALYSIS_STEP_CLAUDE_ANALYSIS:
+			stepEmoji = "🤖"
+		case ANALYSIS_STEP_SAVING_RESULTS:
+			stepEmoji = "💾"
+		}
+
+		elapsed := time.Since(task.StartedAt)
+		elapsedStr := fmt.Sprintf("%.0fs", elapsed.Seconds())
+		if elapsed.Minutes() >= 1 {
+			elapsedStr = fmt.Sprintf("%.1fm", elapsed.Minutes())
+		}
+
+		message.WriteString(fmt.Sprintf("<b>%d.</b> %s @%s\n", i+1, statusEmoji, task.Username))
+		message.WriteString(fmt.Sprintf("    %s Step: %s\n", stepEmoji, task.ProgressText))
+		message.WriteString(fmt.Sprintf("    ⏱️ Running: %s\n", elapsedStr))
+		message.WriteString(fmt.Sprintf("    🆔 Task ID: <code>%s</code>\n", task.ID))
+		message.WriteString(fmt.Sprintf("    🛑 /cancel_%s\n\n", task.ID))
+
+		logger.Debug("📋 added task", "task_id", task.ID, "username", task.Username, "step", task.CurrentStep)
+	}
+
+	message.WriteString(formatRecentTaskFailures(failed))
+	message.WriteString("\n💡 Use <code>/analyze_&lt;username&gt;</code> to start new analysis")
+
+	finalMessage := message.String()
+	logger.Debug("📤 sending tasks message", "length", len(finalMessage))
+
+	err = b.SendMessage(chatID, finalMessage)
+	if err != nil {
+		logger.Error("❌ failed to send tasks message", "error", err)
+		b.SendMessage(chatID, "❌ Failed to send tasks list - message might be too long")
+	} else {
+		logger.Debug("✅ successfully sent tasks message")
+	}
+}
+
+func (b *BotController) handleTop20AnalyzeCommand(chatID int64) {
+	// Get top 20 most active users
+	users, err := b.dbService.GetTopActiveUsers(20)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving top users: %v", err))
+		return
+	}
+
+	if len(users) == 0 {
+		b.SendMessage(chatID, "📭 No users found in database")
+		return
+	}
+
+	// Send initial confirmation
+	b.SendMessage(chatID, fmt.Sprintf("🔄 <b>Starting Top 20 Analysis</b>\n\n📊 Found %d users to analyze\n⏳ This will take several minutes...\n\n💡 Use /tasks to monitor progress", len(users)))
+
+	// Start analysis for each user in background
+	analysisCount := 0
+	skippedCount := 0
+
+	for _, user := range users {
+		// Check if user already has recent cached analysis
+		if b.dbService.HasValidCachedAnalysis(user.ID) {
+			log.Printf("Skipping user %s - has valid cached analysis", user.Username)
+			skippedCount++
+			continue
+		}
+
+		// Generate task ID for tracking
+		taskID := b.generateNotificationID()
+
+		// Create analysis task in database
+		task := &AnalysisTaskModel{
+			ID:             taskID,
+			Username:       user.Username,
+			UserID:         user.ID,
+			Status:         ANALYSIS_STATUS_PENDING,
+			CurrentStep:    ANALYSIS_STEP_INIT,
+			ProgressText:   "Queued for analysis...",
+			TelegramChatID: chatID,
+			MessageID:      0, // No progress messages for batch analysis
+			StartedAt:      time.Now(),
+		}
+
+		_, created, err := b.createAnalysisTask(task)
+		if err != nil {
+			log.Printf("Failed to create analysis task for user %s: %v", user.Username, err)
+			continue
+		}
+		if !created {
+			skippedCount++
+			continue
+		}
+
+		// Start analysis in background
+		go b.runAnalysisTask(taskID, task.Kind)
+		analysisCount++
+
+		// Small delay between launches to avoid overwhelming the system
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Send summary
+	summaryMessage := fmt.Sprintf("🚀 <b>Top 20 Analysis Started</b>\n\n📊 <b>Statistics:</b>\n• ✅ Started: %d analyses\n• ⏭️ Skipped: %d (cached)\n• 📋 Total: %d users\n\n🔍 Use /tasks to monitor progress\n💡 Use /fudlist to see detected FUD users", analysisCount, skippedCount, len(users))
+	b.SendMessage(chatID, summaryMessage)
+
+	log.Printf("Started top 20 analysis: %d analyses queued, %d skipped", analysisCount, skippedCount)
+}
+func (b *BotController) handleTop100AnalyzeCommand(chatID int64) {
+	// Get top 20 most active users
+	users, err := b.dbService.GetTopActiveUsers(100)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving top users: %v", err))
+		return
+	}
+
+	if len(users) == 0 {
+		b.SendMessage(chatID, "📭 No users found in database")
+		return
+	}
+
+	// Send initial confirmation
+	b.SendMessage(chatID, fmt.Sprintf("🔄 <b>Starting Top 100 Analysis</b>\n\n📊 Found %d users to analyze\n⏳ This will take several minutes...\n\n💡 Use /tasks to monitor progress", len(users)))
+
+	// Start analysis for each user in background
+	analysisCount := 0
+	skippedCount := 0
+
+	for _, user := range users {
+		// Check if user already has recent cached analysis
+		if b.dbService.HasValidCachedAnalysis(user.ID) {
+			log.Printf("Skipping user %s - has valid cached analysis", user.Username)
+			skippedCount++
+			continue
+		}
+
+		// Generate task ID for tracking
+		taskID := b.generateNotificationID()
+
+		// Create analysis task in database
+		task := &AnalysisTaskModel{
+			ID:             taskID,
+			Username:       user.Username,
+			UserID:         user.ID,
+			Status:         ANALYSIS_STATUS_PENDING,
+			CurrentStep:    ANALYSIS_STEP_INIT,
+			ProgressText:   "Queued for analysis...",
+			TelegramChatID: chatID,
+			MessageID:      0, // No progress messages for batch analysis
+			StartedAt:      time.Now(),
+		}
+
+		_, created, err := b.createAnalysisTask(task)
+		if err != nil {
+			log.Printf("Failed to create analysis task for user %s: %v", user.Username, err)
+			continue
+		}
+		if !created {
+			skippedCount++
+			continue
+		}
+
+		// Start analysis in background
+		go b.runAnalysisTask(taskID, task.Kind)
+		analysisCount++
+
+		// Small delay between launches to avoid overwhelming the system
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Send summary
+	summaryMessage := fmt.Sprintf("🚀 <b>Top 100 Analysis Started</b>\n\n📊 <b>Statistics:</b>\n• ✅ Started: %d analyses\n• ⏭️ Skipped: %d (cached)\n• 📋 Total: %d users\n\n🔍 Use /tasks to monitor progress\n💡 Use /fudlist to see detected FUD users", analysisCount, skippedCount, len(users))
+	b.SendMessage(chatID, summaryMessage)
+
+	log.Printf("Started top 20 analysis: %d analyses queued, %d skipped", analysisCount, skippedCount)
+}
+
+func (b *BotController) handleBatchAnalyzeCommand(chatID int64, args []string) {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /batch_analyze <user1,user2,user3>\n\n📝 <b>Examples:</b>\n• <code>/batch_analyze john,mary,bob</code>\n• <code>/batch_analyze user1, user2, user3</code>\n\n💡 Separate usernames with commas")
+		return
+	}
+
+	// Routing and history options, the rest is the user list
+	route := &AnalysisTaskModel{}
+	var userArgs []string
+	for _, arg := range args {
+		if !isAnalysisOption(arg) {
+			userArgs = append(userArgs, arg)
+			continue
+		}
+		if err := b.applyAnalysisOption(route, arg); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+	}
+
+	// Join all arguments and split by comma
+	userListStr := strings.Join(userArgs, " ")
+	usernames := strings.Split(userListStr, ",")
+
+	// Clean and validate usernames
+	var validUsernames []string
+	var invalidUsernames []string
+
+	for _, username := range usernames {
+		username, _ = b.resolveTwitterReference(username) // Accepts @handles and links
+
+		if username == "" {
+			continue
+		}
+
+		// Basic validation - check if it looks like a valid username
+		if len(username) > 50 || strings.Contains(username, " ") {
+			invalidUsernames = append(invalidUsernames, username)
+			continue
+		}
+
+		validUsernames = append(validUsernames, username)
+	}
+
+	if len(validUsernames) == 0 {
+		b.SendMessage(chatID, "❌ No valid usernames provided. Please check your input format.")
+		return
+	}
+
+	if len(validUsernames) > 100 {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Too many users requested (%d). Maximum limit is 20 users per batch.", len(validUsernames)))
+		return
+	}
+
+	// Send initial confirmation
+	var confirmationMessage strings.Builder
+	confirmationMessage.WriteString(fmt.Sprintf("🔄 <b>Starting Batch Analysis</b>\n\n📊 <b>Users to analyze (%d):</b>\n", len(validUsernames)))
+
+	for i, username := range validUsernames {
+		confirmationMessage.WriteString(fmt.Sprintf("%d. @%s\n", i+1, username))
+	}
+
+	if len(invalidUsernames) > 0 {
+		confirmationMessage.WriteString(fmt.Sprintf("\n⚠️ <b>Skipped invalid usernames (%d):</b>\n", len(invalidUsernames)))
+		for _, username := range invalidUsernames {
+			confirmationMessage.WriteString(fmt.Sprintf("• %s\n", username))
+		}
+	}
+
+	confirmationMessage.WriteString(fmt.Sprintf("\n⏳ Analysis will start shortly...\n💡 Results will be sent as notifications to %s, with a summary table here once all are done", describeNotifyRoute(route)))
+
+	b.SendMessage(chatID, confirmationMessage.String())
+
+	batch := &AnalysisBatchModel{ID: b.generateNotificationID(), ChatID: chatID, Status: ANALYSIS_STATUS_RUNNING}
+	if err := b.dbService.CreateAnalysisBatch(batch); err != nil {
+		log.Printf("Failed to create analysis batch for chat %d: %v", chatID, err)
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error starting batch analysis: %v", err))
+		return
+	}
+
+	// Start analysis for each user
+	analysisCount := 0
+	skippedCount := 0
+
+	for _, username := range validUsernames {
+		// Check if user already has recent cached analysis
+		user, err := b.dbService.GetUserByUsername(username)
+
+		// Generate task ID for tracking
+		taskID := b.generateNotificationID()
+
+		// Create analysis task in database
+		task := &AnalysisTaskModel{
+			ID:             taskID,
+			Username:       username,
+			Status:         ANALYSIS_STATUS_PENDING,
+			CurrentStep:    ANALYSIS_STEP_INIT,
+			ProgressText:   "Queued for batch analysis...",
+			TelegramChatID: chatID,
+			MessageID:      0, // No progress messages for batch analysis
+			NotifyRoute:    route.NotifyRoute,
+			NotifyChatID:   route.NotifyChatID,
+			HistoryWindow:  route.HistoryWindow,
+			Kind:           ANALYSIS_KIND_BATCH,
+			BatchID:        batch.ID,
+			StartedAt:      time.Now(),
+		}
+
+		if user != nil {
+			task.UserID = user.ID
+		}
+
+		_, created, err := b.createAnalysisTask(task)
+		if err != nil {
+			log.Printf("Failed to create analysis task for user %s: %v", username, err)
+			continue
+		}
+		if !created {
+			skippedCount++
+			continue
+		}
+
+		// Start analysis in background, results follow the task's route
+		go b.runAnalysisTask(taskID, task.Kind)
+		analysisCount++
+
+		// Small delay between launches to avoid overwhelming the system
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	// Live progress message, replaced by the summary table once every task finished
+	batch.Total = analysisCount
+	batch.Skipped = skippedCount
+	tasks, _ := b.dbService.GetAnalysisBatchTasks(batch.ID)
+	messageID, err := b.SendMessageWithID(chatID, b.formatBatchProgress(batch, tasks))
+	if err != nil {
+		log.Printf("Failed to send progress message for batch %s: %v", batch.ID, err)
+	}
+	batch.MessageID = messageID
+	b.dbService.UpdateAnalysisBatch(batch)
+	go b.monitorAnalysisBatch(batch.ID, ANALYSIS_BATCH_REFRESH)
+
+	log.Printf("Started batch analysis for chat %d: %d analyses queued, %d skipped", chatID, analysisCount, skippedCount)
+}
+
+// processBatchAnalysisTask processes analysis task for batch analysis with specific chat notifications
+func (b *BotController) processBatchAnalysisTask(taskID string) {
+	defer func() {
+		if r := recover(); r != nil {
+			logFor("analysis_task").Error("batch analysis task panicked", "task_id", taskID, "panic", r)
+			b.dbService.SetAnalysisTaskError(taskID, TASK_ERROR_INTERNAL, fmt.Sprintf("Internal error: %v", r))
+		}
+	}()
+	ctx, done := b.analysisTaskContext(taskID)
+	defer done()
+
+	// Get task details
+	task, err := b.dbService.GetAnalysisTask(taskID)
+	if err != nil {
+		logFor("analysis_task").Error("failed to get batch analysis task", "task_id", taskID, "error", err)
+		return
+	}
+	logger := taskLogger("analysis_task", task).With("batch_id", task.BatchID)
+
+	username := task.Username
+
+	// Step 1: User lookup
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_USER_LOOKUP, "Looking up user information...")
+	user, err := b.dbService.GetUserByUsername(username)
+	var userID string
+	if err != nil {
+		userID = "unknown_" + username
+		logger.Info("user not found in database, using placeholder ID")
+	} else {
+		userID = user.ID
+		// Update task with found user ID
+		task.UserID = userID
+		b.dbService.UpdateAnalysisTask(task)
+	}
+
+	// Step 2: Get user tweet for analysis context
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_TICKER_SEARCH, "Searching for user's ticker mentions...")
+	tweet, err := b.dbService.GetUserTweetForAnalysis(username)
+
+	var newMessage twitterapi.NewMessage
+
+	if err != nil {
+		logger.Info("no tweet found, creating placeholder data")
+
+		newMessage = twitterapi.NewMessage{
+			TweetID:      "batch_analysis_" + username,
+			ReplyTweetID: "",
+			Author: struct {
+				UserName string
+				Name     string
+				ID       string
+			}{
+				UserName: username,
+				Name:     username,
+				ID:       userID,
+			},
+			Text:      "Batch analysis request - no recent tweets found",
+			CreatedAt: time.Now().Format(time.RFC3339),
+			ParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "placeholder_parent",
+				Author: "system",
+				Text:   "No parent tweet available - batch analysis",
+			},
+			GrandParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "",
+				Author: "",
+				Text:   "",
+			},
+			IsManualAnalysis:  true,
+			ForceNotification: true,
+			TaskID:            taskID,
+			TelegramChatID:    task.notificationChatID(),
+			HistoryWindow:     task.HistoryWindow,
+			NotificationRoute: task.NotifyRoute,
+		}
+	} else {
+		newMessage = twitterapi.NewMessage{
+			TweetID:      tweet.ID,
+			ReplyTweetID: tweet.InReplyToID,
+			Author: struct {
+				UserName string
+				Name     string
+				ID       string
+			}{
+				UserName: username,
+				Name:     username,
+				ID:       tweet.UserID,
+			},
+			Text:      tweet.Text,
+			CreatedAt: tweet.CreatedAt.Format(time.RFC3339),
+			ParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "batch_parent",
+				Author: "system",
+				Text:   "Batch analysis - limited context available",
+			},
+			GrandParentTweet: struct {
+				ID     string
+				Author string
+				Text   string
+			}{
+				ID:     "",
+				Author: "",
+				Text:   "",
+			},
+			IsManualAnalysis:  true,
+			ForceNotification: true,
+			TaskID:            taskID,
+			TelegramChatID:    task.notificationChatID(),
+			HistoryWindow:     task.HistoryWindow,
+			NotificationRoute: task.NotifyRoute,
+		}
+	}
+
+	// Send to analysis channel for processing
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_CLAUDE_ANALYSIS, "Starting AI analysis...")
+	select {
+	case b.analysisChannel <- newMessage:
+	case <-ctx.Done():
+		logger.Info("batch analysis task cancelled while waiting for the analysis channel")
+		return
+	}
+
+	logger.Info("sent batch analysis request to analysis channel")
+}
+
+// sendCachedBatchNotification sends cached result as notification to specific chat
+func (b *BotController) sendCachedBatchNotification(username, userID string, cachedResult SecondStepClaudeResponse, targetChatID int64) {
+	// Create a formatted message for cached result
+	alertType := cachedResult.FUDType
+	if !cachedResult.IsFUDUser {
+		alertType = "clean_user"
+	}
+
+	severityEmoji := "✅"
+	if cachedResult.IsFUDUser {
+		switch cachedResult.UserRiskLevel {
+		case "critical":
+			severityEmoji = "🚨🔥"
+		case "high":
+			severityEmoji = "🚨"
+		case "medium":
+			severityEmoji = "⚠️"
+		default:
+			severityEmoji = "ℹ️"
+		}
+	}
+
+	message := fmt.Sprintf(`%s <b>Batch Analysis Result (Cached)</b>
+
+👤 <b>User:</b> @%s
+📊 <b>Status:</b> %s
+🎯 <b>Type:</b> %s
+📈 <b>Confidence:</b> %.0f%%
+👥 <b>Profile:</b> %s
+
+💾 <b>Source:</b> Cached analysis (< 24h)
+🔍 <b>Commands:</b> /history_%s | /analyze_%s`,
+		severityEmoji,
+		username,
+		map[bool]string{true: "FUD User Detected", false: "Clean User"}[cachedResult.IsFUDUser],
+		alertType,
+		cachedResult.FUDProbability*100,
+		cachedResult.UserSummary,
+		username, username)
+
+	err := b.SendMessage(targetChatID, message)
+	if err != nil {
+		log.Printf("Failed to send cached batch notification for %s to chat %d: %v", username, targetChatID, err)
+	} else {
+		log.Printf("Sent cached batch analysis result for %s to chat %d", username, targetChatID)
+	}
+}
+
+// handleAnalyzeAllCommand analyzes all users with messages, sorted by message count (descending)
+func (b *BotController) handleAnalyzeAllCommand(chatID int64) {
+	// Send initial confirmation
+	b.SendMessage(chatID, "🔄 <b>Starting Full Database Analysis</b>\n\n📊 Getting list of all users with messages...\nThis may take a moment.")
+
+	// Start analysis in background
+	go b.processAnalyzeAllUsers(chatID)
+}
+
+// processAnalyzeAllUsers processes analysis for all users with progress tracking
+func (b *BotController) processAnalyzeAllUsers(chatID int64) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Analyze all users panicked: %v", r)
+			b.SendMessage(chatID, fmt.Sprintf("❌ Analysis failed with error: %v", r))
+		}
+	}()
+
+	// Get all users sorted by message count (descending)
+	users, err := b.dbService.GetTopActiveUsers(0) // 0 = no limit, get all users
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error getting users list: %v", err))
+		return
+	}
+
+	if len(users) == 0 {
+		b.SendMessage(chatID, "📭 No users found with messages in database")
+		return
+	}
+
+	// Filter out users who already have cached analysis (< 24h)
+	var usersToAnalyze []UserModel
+	var skippedCount int
+
+	for _, user := range users {
+		if b.dbService.HasValidCachedAnalysis(user.ID) {
+			skippedCount++
+			continue
+		}
+		usersToAnalyze = append(usersToAnalyze, user)
+	}
+
+	totalUsers := len(users)
+	toAnalyzeCount := len(usersToAnalyze)
+
+	// Send status update
+	statusMessage := fmt.Sprintf(`📊 <b>Analysis Preparation Complete</b>
+
+👥 <b>Total users with messages:</b> %d
+🔍 <b>Users to analyze:</b> %d
+💾 <b>Cached (skipped):</b> %d
+
+🚀 Starting analysis with buffer of 5 concurrent tasks...`, totalUsers, toAnalyzeCount, skippedCount)
+
+	statusMessageID, err := b.SendMessageWithID(chatID, statusMessage)
+	if err != nil {
+		log.Printf("Failed to send status message: %v", err)
+		return
+	}
+
+	if toAnalyzeCount == 0 {
+		b.EditMessage(chatID, statusMessageID, "✅ All users already have recent analysis (cached). No new analysis needed.")
+		return
+	}
+
+	// Start progress monitoring goroutine
+	progressCtx := make(chan bool, 1)
+	go b.monitorAnalysisAllProgress(chatID, statusMessageID, toAnalyzeCount, progressCtx)
+
+	// Process users in chunks, feeding to existing analysis channel
+	sentCount := 0
+	for i, user := range usersToAnalyze {
+		// Create analysis task
+		taskID, err := b.generateTaskID()
+		if err != nil {
+			log.Printf("Failed to generate task ID for user %s: %v", user.Username, err)
+			continue
+		}
+
+		// Create task in database
+		task := &AnalysisTaskModel{
+			ID:             taskID,
+			Username:       user.Username,
+			UserID:         user.ID,
+			Status:         ANALYSIS_STATUS_PENDING,
+			CurrentStep:    ANALYSIS_STEP_INIT,
+			ProgressText:   fmt.Sprintf("Queued for analysis (%d/%d)", i+1, toAnalyzeCount),
+			TelegramChatID: chatID,
+			MessageID:      0,
+			StartedAt:      time.Now(),
+		}
+
+		_, created, err := b.createAnalysisTask(task)
+		if err != nil {
+			log.Printf("Failed to create analysis task for user %s: %v", user.Username, err)
+			continue
+		}
+		if !created {
+			continue
+		}
+
+		// Send to existing analysis channel (will block if buffer is full - channel has buffer of 30)
+		newMessage := twitterapi.NewMessage{
+			Author: struct {
+				UserName string
+				Name     string
+				ID       string
+			}{
+				UserName: user.Username,
+				Name:     user.Name,
+				ID:       user.ID,
+			},
+			TweetID:          "", // Not a specific tweet analysis
+			IsManualAnalysis: true,
+			TaskID:           taskID,
+			TelegramChatID:   chatID,
+		}
+
+		b.analysisChannel <- newMessage
+		sentCount++
+
+		log.Printf("Sent user %s (%d/%d) to main analysis channel", user.Username, i+1, toAnalyzeCount)
+
+		// Small delay to control flow and prevent overwhelming
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	// Stop progress monitoring
+	progressCtx <- true
+
+	// Send final status
+	finalMessage := fmt.Sprintf("✅ <b>Analysis Complete</b>\n\n📊 <b>Final Statistics:</b>\n• 🚀 Sent for analysis: %d\n• 💾 Cached (skipped): %d\n• 📋 Total processed: %d\n\n🔔 All results have been sent to this chat", sentCount, skippedCount, totalUsers)
+	b.SendMessage(chatID, finalMessage)
+
+	log.Printf("Completed full database analysis: %d sent, %d skipped, %d total", sentCount, skippedCount, totalUsers)
+}
+
+// monitorAnalysisProgress monitors and reports analysis progress
+func (b *BotController) monitorAnalysisAllProgress(chatID int64, messageID int64, totalUsers int, ctx chan bool) {
+	ticker := time.NewTicker(PROGRESS_REFRESH_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx:
+			// Analysis complete
+			return
+		case <-ticker.C:
+			// Get current task statistics
+			stats, err := b.getAnalysisStatistics()
+			if err != nil {
+				log.Printf("Failed to get analysis statistics: %v", err)
+				continue
+			}
+
+			// Update status message
+			statusMessage := fmt.Sprintf(`🔄 <b>Full Database Analysis Progress</b>
+
+👥 <b>Total users to analyze:</b> %d
+
+📊 <b>Current Status:</b>
+• 📋 Pending: %d
+• 🔄 Running: %d
+• ✅ Completed: %d
+• ❌ Failed: %d
+
+⏱️ <b>Last updated:</b> %s`,
+				totalUsers,
+				stats["pending"],
+				stats["running"],
+				stats["completed"],
+				stats["failed"],
+				time.Now().Format("15:04:05"))
+
+			b.progress.queue(b, chatID, messageID, statusMessage)
+		}
+	}
+}
+
+// getAnalysisStatistics returns current analysis task statistics
+func (b *BotController) getAnalysisStatistics() (map[string]int, error) {
+	stats := make(map[string]int)
+
+	// Get counts for each status
+	var pending, running, completed, failed int64
+
+	b.dbService.db.Model(&AnalysisTaskModel{}).Where("status = ?", ANALYSIS_STATUS_PENDING).Count(&pending)
+	b.dbService.db.Model(&AnalysisTaskModel{}).Where("status = ?", ANALYSIS_STATUS_RUNNING).Count(&running)
+	b.dbService.db.Model(&AnalysisTaskModel{}).Where("status = ?", ANALYSIS_STATUS_COMPLETED).Count(&completed)
+	b.dbService.db.Model(&AnalysisTaskModel{}).Where("status = ?", ANALYSIS_STATUS_FAILED).Count(&failed)
+
+	stats["pending"] = int(pending)
+	stats["running"] = int(running)
+	stats["completed"] = int(completed)
+	stats["failed"] = int(failed)
+
+	return stats, nil
+}
@@ -0,0 +1,921 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTelegramTransport records outgoing calls instead of talking to the Bot API
+type fakeTelegramTransport struct {
+	mu            sync.Mutex
+	nextMessageID int64
+	sent          []TelegramSendMessageRequest
+	edited        []TelegramEditMessageRequest
+	documents     []TelegramSendDocumentRequest
+	photos        []TelegramSendPhotoRequest
+	photoData     [][]byte
+	mediaGroups   []TelegramSendMediaGroupRequest
+	polls         []TelegramSendPollRequest
+	stoppedPolls  []int64
+	members       map[int64]string  // status in every chat by user ID, left when missing
+	files         map[string]string // content of uploaded files by file ID
+}
+
+func (f *fakeTelegramTransport) GetUpdates(offset int64) ([]TelegramUpdate, error) {
+	return nil, nil
+}
+
+func (f *fakeTelegramTransport) SendMessage(req TelegramSendMessageRequest) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextMessageID++
+	f.sent = append(f.sent, req)
+	return f.nextMessageID, nil
+}
+
+func (f *fakeTelegramTransport) EditMessage(req TelegramEditMessageRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edited = append(f.edited, req)
+	return nil
+}
+
+func (f *fakeTelegramTransport) SendDocument(req TelegramSendDocumentRequest, filePath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.documents = append(f.documents, req)
+	return nil
+}
+
+func (f *fakeTelegramTransport) SendPhoto(req TelegramSendPhotoRequest, filename string, photo []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.photos = append(f.photos, req)
+	f.photoData = append(f.photoData, photo)
+	return nil
+}
+
+func (f *fakeTelegramTransport) SendMediaGroup(req TelegramSendMediaGroupRequest, uploads map[string][]byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mediaGroups = append(f.mediaGroups, req)
+	return nil
+}
+
+func (f *fakeTelegramTransport) SendPoll(req TelegramSendPollRequest) (TelegramSentPoll, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextMessageID++
+	f.polls = append(f.polls, req)
+	return TelegramSentPoll{MessageID: f.nextMessageID, PollID: fmt.Sprintf("poll%d", len(f.polls))}, nil
+}
+
+func (f *fakeTelegramTransport) GetChatMember(chatID int64, userID int64) (TelegramChatMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	member := TelegramChatMember{Status: f.members[userID]}
+	if member.Status == "" {
+		member.Status = TELEGRAM_MEMBER_LEFT
+	}
+	member.User.ID = userID
+	return member, nil
+}
+
+func (f *fakeTelegramTransport) DownloadFile(fileID string, destPath string) error {
+	f.mu.Lock()
+	content, ok := f.files[fileID]
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("file %s not found", fileID)
+	}
+	return os.WriteFile(destPath, []byte(content), 0o644)
+}
+
+func (f *fakeTelegramTransport) StopPoll(chatID int64, messageID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stoppedPolls = append(f.stoppedPolls, messageID)
+	return nil
+}
+
+func (f *fakeTelegramTransport) sentMessages() []TelegramSendMessageRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]TelegramSendMessageRequest(nil), f.sent...)
+}
+
+// newTestBotController builds a controller without the chat id file persistence
+func newTestBotController(transport TelegramTransport, dbService *DatabaseService) *BotController {
+	return &BotController{
+		transport: transport,
+		chatIDs:   make(map[int64]bool),
+		formatter: NewNotificationFormatter(),
+		dbService: dbService,
+	}
+}
+
+// useTempNotifyAuditLog keeps changes to the notification list out of the working directory
+func useTempNotifyAuditLog(t *testing.T) {
+	auditPath := notificationUsersAuditPath
+	notificationUsersAuditPath = t.TempDir() + "/audit.log"
+	t.Cleanup(func() { notificationUsersAuditPath = auditPath })
+}
+
+func newTestUpdate(chatID int64, text string) TelegramUpdate {
+	var update TelegramUpdate
+	update.Message.Chat.ID = chatID
+	update.Message.From.FirstName = "Tester"
+	update.Message.From.Username = "tester"
+	update.Message.Text = text
+	return update
+}
+
+func TestBotController_HandleUpdate(t *testing.T) {
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, setupTestDB(t))
+
+	t.Run("New chat starts onboarding instead of registering", func(t *testing.T) {
+		bot.handleUpdate(newTestUpdate(42, "/help"))
+
+		assert.NotContains(t, bot.GetRegisteredChats(), int64(42))
+		sent := transport.sentMessages()
+		require.Len(t, sent, 1)
+		assert.Equal(t, int64(42), sent[0].ChatID)
+		assert.Contains(t, sent[0].Text, "Welcome")
+	})
+
+	t.Run("Registered chat receives help", func(t *testing.T) {
+		bot.chatIDs[42] = true
+		before := len(transport.sentMessages())
+
+		bot.handleUpdate(newTestUpdate(42, "/help"))
+
+		assert.Eventually(t, func() bool {
+			return len(transport.sentMessages()) == before+1
+		}, time.Second, 10*time.Millisecond)
+		msg := transport.sentMessages()[before]
+		assert.Equal(t, "HTML", msg.ParseMode)
+		assert.Contains(t, msg.Text, "Available Commands")
+	})
+
+	t.Run("Admin commands are rejected for regular chats", func(t *testing.T) {
+		t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+		before := len(transport.sentMessages())
+
+		bot.handleUpdate(newTestUpdate(42, "/top20_analyze"))
+
+		assert.Eventually(t, func() bool {
+			return len(transport.sentMessages()) == before+1
+		}, time.Second, 10*time.Millisecond)
+		assert.Contains(t, transport.sentMessages()[before].Text, "Access denied")
+	})
+}
+
+func TestBotController_Onboarding(t *testing.T) {
+	t.Setenv(ENV_TWITTER_COMMUNITY_TICKER, "$GRUT")
+	useTempNotifyAuditLog(t)
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	steps := []struct {
+		text     string
+		expected string
+	}{
+		{"", "Welcome"},
+		{"$pump", "Severity threshold"},
+		{"extreme", "Unknown severity"},
+		{"medium", "Timezone"},
+		{"Mars/Olympus", "Unknown timezone"},
+		{"Europe/Berlin", "Confirm setup"},
+	}
+	for _, step := range steps {
+		bot.handleUpdate(newTestUpdate(-100, step.text))
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, step.expected, "after %q", step.text)
+	}
+	assert.NotContains(t, bot.GetRegisteredChats(), int64(-100))
+
+	t.Run("Only the initiator can confirm", func(t *testing.T) {
+		update := newTestUpdate(-100, "/confirm")
+		update.Message.From.ID = 999
+		bot.handleUpdate(update)
+
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "Only the user who started")
+		assert.NotContains(t, bot.GetRegisteredChats(), int64(-100))
+	})
+
+	t.Run("Confirmation registers the chat with chosen settings", func(t *testing.T) {
+		bot.handleUpdate(newTestUpdate(-100, "/confirm@fud_bot"))
+
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "Chat registered!")
+		assert.Contains(t, bot.GetRegisteredChats(), int64(-100))
+
+		settings, err := db.GetChatSettings(-100)
+		require.NoError(t, err)
+		assert.Equal(t, "PUMP", settings.Ticker)
+		assert.Equal(t, "medium", settings.MinSeverity)
+		assert.Equal(t, "Europe/Berlin", settings.Timezone)
+		assert.Empty(t, settings.OnboardingStep)
+		assert.NotNil(t, settings.OnboardedAt)
+	})
+}
+
+func TestBotController_ChatApproval(t *testing.T) {
+	t.Setenv(ENV_TWITTER_COMMUNITY_TICKER, "GRUT")
+	t.Setenv(ENV_CHAT_APPROVAL_MODE, "true")
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	useTempNotifyAuditLog(t)
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	for _, text := range []string{"", "/skip", "/skip", "/skip", "/confirm"} {
+		update := newTestUpdate(-200, text)
+		update.Message.Chat.Title = "<b>Rug & Co</b>"
+		bot.handleUpdate(update)
+	}
+
+	settings, err := db.GetChatSettings(-200)
+	require.NoError(t, err)
+	assert.Equal(t, CHAT_APPROVAL_PENDING, settings.ApprovalStatus)
+	assert.NotContains(t, bot.GetRegisteredChats(), int64(-200))
+
+	sent := transport.sentMessages()
+	adminNotice := sent[len(sent)-1]
+	assert.Equal(t, int64(1), adminNotice.ChatID)
+	assert.Contains(t, adminNotice.Text, "/approve_chat -200")
+	assert.Contains(t, adminNotice.Text, "Chat: &lt;b&gt;Rug &amp; Co&lt;/b&gt;", "chat titles are escaped")
+
+	t.Run("Pending chats are listed with escaped titles", func(t *testing.T) {
+		bot.handlePendingChatsCommand(1)
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "💬 &lt;b&gt;Rug &amp; Co&lt;/b&gt; — <code>-200</code>")
+	})
+
+	t.Run("Pending chat does not restart onboarding", func(t *testing.T) {
+		bot.handleUpdate(newTestUpdate(-200, "/help"))
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "waiting for admin approval")
+	})
+
+	t.Run("Admin approval registers the chat", func(t *testing.T) {
+		bot.handleChatApprovalCommand(1, "@admin", []string{"-200"}, true)
+
+		assert.Contains(t, bot.GetRegisteredChats(), int64(-200))
+		settings, err := db.GetChatSettings(-200)
+		require.NoError(t, err)
+		assert.Equal(t, CHAT_APPROVAL_APPROVED, settings.ApprovalStatus)
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "Chat -200 (&lt;b&gt;Rug &amp; Co&lt;/b&gt;) approved")
+		records, err := db.GetNotificationChats()
+		require.NoError(t, err)
+		assert.Equal(t, NOTIFY_SOURCE_APPROVAL, records[-200].Source)
+		assert.Equal(t, "@admin", records[-200].AddedBy)
+
+		bot.handleChatApprovalCommand(1, "@admin", []string{"-200"}, true)
+		sent = transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "not waiting for approval")
+	})
+}
+
+func TestBotController_Redaction(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+	bot.chatIDs[5] = true
+
+	t.Run("Only admins can change profiles", func(t *testing.T) {
+		bot.handleRedactionCommand(5, []string{"5", REDACTION_FULL})
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "Access denied")
+	})
+
+	t.Run("Redacted chat gets sanitized alerts and no investigation commands", func(t *testing.T) {
+		bot.handleRedactionCommand(1, []string{"5", REDACTION_AMBASSADOR})
+
+		require.NoError(t, bot.StoreAndBroadcastNotification(benchmarkAlert()))
+		var toAdmin, toAmbassador string
+		for _, msg := range transport.sentMessages() {
+			switch msg.ChatID {
+			case 1:
+				toAdmin = msg.Text
+			case 5:
+				toAmbassador = msg.Text
+			}
+		}
+		assert.Contains(t, toAdmin, "@suspicious_user")
+		assert.NotContains(t, toAmbassador, "suspicious_user")
+		assert.NotContains(t, toAmbassador, "/detail_")
+
+		bot.handleUpdate(newTestUpdate(5, "/history_suspicious_user"))
+		assert.Eventually(t, func() bool {
+			sent := transport.sentMessages()
+			return strings.Contains(sent[len(sent)-1].Text, "not available in this chat")
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Redacted chat cannot map the network of a user", func(t *testing.T) {
+		sentBefore := len(transport.sentMessages())
+		bot.handleUpdate(newTestUpdate(5, "/network_suspicious_user"))
+		assert.Eventually(t, func() bool {
+			sent := transport.sentMessages()
+			return len(sent) > sentBefore && strings.Contains(sent[len(sent)-1].Text, "not available in this chat")
+		}, time.Second, 10*time.Millisecond)
+		for _, msg := range transport.sentMessages()[sentBefore:] {
+			assert.NotContains(t, msg.Text, "suspicious_user")
+		}
+	})
+
+	t.Run("Redacted chat cannot analyze users or list tasks and reports", func(t *testing.T) {
+		for _, command := range []string{"/analyze_tweet 123", "/analyze_suspicious_user", "/tasks", "/reports"} {
+			sentBefore := len(transport.sentMessages())
+			bot.handleUpdate(newTestUpdate(5, command))
+			assert.Eventually(t, func() bool {
+				sent := transport.sentMessages()
+				return len(sent) > sentBefore && strings.Contains(sent[len(sent)-1].Text, "not available in this chat")
+			}, time.Second, 10*time.Millisecond, command)
+		}
+	})
+}
+
+func TestBotController_DetailSurvivesRestart(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	require.NoError(t, bot.StoreAndBroadcastNotification(benchmarkAlert()))
+	notifications, err := db.GetNotificationsSince(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	notificationID := notifications[0].NotificationID
+
+	restarted := newTestBotController(transport, db)
+	restarted.handleDetailCommand(1, "/detail_"+notificationID)
+
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "DETAILED FUD ANALYSIS")
+	assert.Contains(t, sent[len(sent)-1].Text, "Coordinated timing with other accounts")
+
+	// Once the resolved alert expires its details are gone
+	require.NoError(t, db.UpdateNotificationState(notificationID, map[string]interface{}{"state": ALERT_STATE_RESOLVED, "expires_at": time.Now().Add(-time.Minute)}))
+	bot.handleDetailCommand(1, "/detail_"+notificationID)
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "Notification not found or expired")
+}
+
+func TestBotController_Maintenance(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	bot.handleMaintenanceCommand(1, []string{"1h"})
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "Scheduled maintenance")
+
+	medium := benchmarkAlert()
+	medium.AlertSeverity = "medium"
+	medium.FUDUsername = "queued_user"
+	require.NoError(t, bot.StoreAndBroadcastNotification(medium))
+	critical := benchmarkAlert()
+	critical.AlertSeverity = "critical"
+	require.NoError(t, bot.StoreAndBroadcastNotification(critical))
+
+	sent = transport.sentMessages()
+	require.Len(t, sent, 2, "only the critical alert is delivered during maintenance")
+	assert.Contains(t, sent[1].Text, "CRITICAL")
+
+	bot.handleMaintenanceCommand(1, []string{"off"})
+	sent = transport.sentMessages()
+	require.Len(t, sent, 3)
+	assert.Contains(t, sent[2].Text, "Maintenance finished")
+	assert.Contains(t, sent[2].Text, "@queued_user")
+	assert.Contains(t, sent[2].Text, "/detail_")
+
+	require.NoError(t, bot.StoreAndBroadcastNotification(medium))
+	assert.Len(t, transport.sentMessages(), 4)
+
+	t.Run("Summaries follow the alert routing of each chat", func(t *testing.T) {
+		bot.chatIDs[2] = true
+		bot.chatIDs[-100] = true
+		require.NoError(t, db.SaveChatSettings(&ChatSettingsModel{ChatID: 2, Ticker: "PEPE"}))
+		require.NoError(t, db.SaveCommunity(CommunityModel{ID: "1111111111", Ticker: "$GRUT"}))
+		bot.handleThresholdCommand(1, "@admin", []string{"medium", "users"})
+
+		bot.handleMaintenanceCommand(1, []string{"1h"})
+		grut := medium
+		grut.CommunityID, grut.Ticker = "1111111111", "$GRUT"
+		require.NoError(t, bot.StoreAndBroadcastNotification(grut))
+
+		before := len(transport.sentMessages())
+		bot.handleMaintenanceCommand(1, []string{"off"})
+		summaries := make(map[int64]string)
+		for _, msg := range transport.sentMessages()[before:] {
+			summaries[msg.ChatID] = msg.Text
+		}
+		require.Len(t, summaries, 3)
+		assert.Contains(t, summaries[1], "@queued_user")
+		assert.NotContains(t, summaries[2], "@queued_user", "the chat follows another ticker")
+		assert.Contains(t, summaries[2], "No alerts were held back")
+		assert.NotContains(t, summaries[-100], "@queued_user", "medium alerts go to private chats only")
+		assert.NotContains(t, summaries[-100], "/detail_")
+	})
+}
+
+func TestBotController_HandleHistoryCommand(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	t.Run("Unknown user", func(t *testing.T) {
+		bot.handleHistoryCommand(7, "/history_nobody")
+
+		sent := transport.sentMessages()
+		require.Len(t, sent, 1)
+		assert.Contains(t, sent[0].Text, "No messages found for @nobody")
+	})
+
+	t.Run("User with messages", func(t *testing.T) {
+		require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "alice", Name: "Alice"}))
+		require.NoError(t, db.SaveTweet(TweetModel{
+			ID:        "t1",
+			Text:      "hello community",
+			CreatedAt: time.Now(),
+			UserID:    "u1",
+			Username:  "alice",
+		}))
+
+		bot.handleHistoryCommand(7, "/history_alice")
+
+		sent := transport.sentMessages()
+		require.Len(t, sent, 2)
+		assert.Contains(t, sent[1].Text, "Message History for @alice")
+		assert.Contains(t, sent[1].Text, "hello community")
+		assert.Contains(t, sent[1].Text, "/export_alice")
+	})
+}
+
+func TestBotController_Report(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.analysisChannel = make(chan twitterapi.NewMessage, 1)
+	bot.SetPriorityChannel(make(chan twitterapi.NewMessage, 1))
+	bot.chatIDs[1] = true
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "123", UserID: "u1", Text: "devs are about to rug"}))
+
+	bot.handleReportCommand(1, 5, "tipster", []string{"https://x.com/shady/status/123", "rug", "rumours"})
+
+	select {
+	case newMessage := <-bot.priorityChannel:
+		assert.Equal(t, "123", newMessage.TweetID)
+		assert.Equal(t, "shady", newMessage.Author.UserName)
+		assert.True(t, newMessage.IsManualAnalysis)
+	case <-bot.analysisChannel:
+		t.Fatal("reports must use the priority channel")
+	case <-time.After(2 * time.Second):
+		t.Fatal("report was not queued for analysis")
+	}
+
+	reports, err := db.GetRecentUserReports(1, 10)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "shady", reports[0].TargetUsername)
+	assert.Equal(t, "rug rumours", reports[0].Reason)
+
+	bot.handleReportsCommand(1)
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "<b>@shady</b> — ⏳ analyzing")
+
+	require.NoError(t, db.CompleteAnalysisTask(reports[0].TaskID, `{"analysis_complete": true, "is_fud": true, "fud_type": "casual_criticism"}`))
+	bot.handleReportsCommand(1)
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "<b>@shady</b> — 🚨 FUD (Casual Criticism)")
+
+	t.Run("Invalid target", func(t *testing.T) {
+		bot.handleReportCommand(1, 5, "tipster", []string{"not a user!", "spam"})
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "Invalid target")
+	})
+}
+
+func TestBotController_SpamProtection(t *testing.T) {
+	t.Run("Private chats outside the allow-list are ignored", func(t *testing.T) {
+		t.Setenv(ENV_PRIVATE_CHAT_ALLOWLIST, "@friend, 77")
+		transport := &fakeTelegramTransport{}
+		bot := newTestBotController(transport, setupTestDB(t))
+
+		stranger := newTestUpdate(500, "/start")
+		stranger.Message.Chat.Type = "private"
+		stranger.Message.From.ID = 500
+		bot.handleUpdate(stranger)
+		assert.Empty(t, transport.sentMessages())
+
+		friend := newTestUpdate(501, "/start")
+		friend.Message.Chat.Type = "private"
+		friend.Message.From.ID = 501
+		friend.Message.From.Username = "Friend"
+		bot.handleUpdate(friend)
+		require.Len(t, transport.sentMessages(), 1)
+		assert.Contains(t, transport.sentMessages()[0].Text, "Welcome")
+	})
+
+	t.Run("Expensive commands are limited per sender", func(t *testing.T) {
+		transport := &fakeTelegramTransport{}
+		bot := newTestBotController(transport, setupTestDB(t))
+		bot.chatIDs[-300] = true
+
+		for i := 0; i < EXPENSIVE_COMMAND_BURST+2; i++ {
+			update := newTestUpdate(-300, "/export_nobody")
+			update.Message.From.ID = 600
+			bot.handleUpdate(update)
+		}
+
+		// One reply per allowed export plus a single warning
+		assert.Eventually(t, func() bool {
+			return len(transport.sentMessages()) == EXPENSIVE_COMMAND_BURST+1
+		}, 2*time.Second, 10*time.Millisecond)
+		warnings := 0
+		for _, msg := range transport.sentMessages() {
+			if strings.Contains(msg.Text, "limit for analysis and export") {
+				warnings++
+			}
+		}
+		assert.Equal(t, 1, warnings)
+
+		other := newTestUpdate(-300, "/export_nobody")
+		other.Message.From.ID = 601
+		assert.True(t, bot.allowSender(other), "other senders keep their own budget")
+	})
+}
+
+func TestBotController_NotifyCommand(t *testing.T) {
+	useTempNotifyAuditLog(t)
+
+	transport := &fakeTelegramTransport{}
+	db := setupTestDB(t)
+	bot := newTestBotController(transport, db)
+	lastMessage := func() string {
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	bot.handleNotifyCommand(1, "@admin", []string{"add", "10,", "11,abc", "note:", "partner", "group"})
+	assert.Contains(t, lastMessage(), "Added 2 chats")
+	assert.Contains(t, lastMessage(), "abc")
+	assert.ElementsMatch(t, []int64{10, 11}, bot.GetRegisteredChats())
+
+	t.Run("List shows who added each chat and why", func(t *testing.T) {
+		bot.handleNotifyCommand(1, "@admin", []string{"list"})
+		assert.Contains(t, lastMessage(), "added by @admin via /notify on "+time.Now().UTC().Format("2006-01-02")+" — <i>partner group</i>")
+	})
+
+	t.Run("Remove waits for confirmation", func(t *testing.T) {
+		bot.handleNotifyCommand(1, "@admin", []string{"remove", "10"})
+		assert.Contains(t, lastMessage(), "/notify confirm")
+		assert.True(t, bot.isRegisteredChat(10))
+
+		bot.handleNotifyCommand(1, "@admin", []string{"confirm"})
+		assert.False(t, bot.isRegisteredChat(10))
+		records, err := db.GetNotificationChats()
+		require.NoError(t, err)
+		assert.False(t, records[10].Active)
+		assert.Equal(t, "@admin", records[10].RemovedBy)
+	})
+
+	t.Run("Removed chat cannot onboard itself back", func(t *testing.T) {
+		sentBefore := len(transport.sentMessages())
+		bot.handleUpdate(newTestUpdate(10, "hello"))
+		assert.Len(t, transport.sentMessages(), sentBefore)
+		bot.handleUpdate(newTestUpdate(10, "/restart"))
+		assert.Contains(t, lastMessage(), "removed from notifications by an admin")
+		assert.False(t, bot.isRegisteredChat(10))
+
+		settings, err := db.GetChatSettings(10)
+		require.NoError(t, err)
+		assert.Empty(t, settings.OnboardingStep)
+		assert.Equal(t, "@admin", settings.RemovedBy)
+	})
+
+	t.Run("Cancelled clear keeps the list", func(t *testing.T) {
+		bot.handleNotifyCommand(1, "@admin", []string{"clear"})
+		bot.handleNotifyCommand(1, "@admin", []string{"cancel"})
+		bot.handleNotifyCommand(1, "@admin", []string{"confirm"})
+		assert.Contains(t, lastMessage(), "Nothing to confirm")
+		assert.True(t, bot.isRegisteredChat(11))
+
+		bot.handleNotifyCommand(1, "@admin", []string{"clear"})
+		bot.handleNotifyCommand(1, "@admin", []string{"confirm"})
+		assert.Empty(t, bot.GetRegisteredChats())
+	})
+
+	t.Run("Adding a removed chat back lifts the block", func(t *testing.T) {
+		bot.handleNotifyCommand(1, "@admin", []string{"add", "10"})
+		assert.True(t, bot.isRegisteredChat(10))
+		settings, err := db.GetChatSettings(10)
+		require.NoError(t, err)
+		assert.Nil(t, settings.RemovedAt)
+	})
+
+	t.Run("Audit log records who changed the list", func(t *testing.T) {
+		bot.handleNotifyCommand(1, "@admin", []string{"audit"})
+		audit := lastMessage()
+		assert.Contains(t, audit, "@admin (chat 1) added 10,11 (note: partner group)")
+		assert.Contains(t, audit, "@admin (chat 1) removed 10")
+		assert.Contains(t, audit, "@admin (chat 1) cleared 11")
+	})
+}
+
+func TestBotController_MigrateNotificationChats(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	useTempNotifyAuditLog(t)
+	db := setupTestDB(t)
+	require.NoError(t, db.AddNotificationChat(NotificationChatModel{ChatID: 30, Source: NOTIFY_SOURCE_COMMAND, AddedBy: "@admin"}))
+	require.NoError(t, db.AddNotificationChat(NotificationChatModel{ChatID: 40, Source: NOTIFY_SOURCE_COMMAND, AddedBy: "@admin"}))
+	require.NoError(t, db.RemoveNotificationChats([]int64{40}, "@admin"))
+
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	for _, id := range []int64{1, 20, 40} {
+		bot.chatIDs[id] = true
+	}
+	bot.migrateNotificationChats([]int64{20, 40}, []int64{1})
+
+	assert.ElementsMatch(t, []int64{1, 20, 30}, bot.GetRegisteredChats(), "chats removed in the database stay removed, recorded ones come back")
+	records, err := db.GetNotificationChats()
+	require.NoError(t, err)
+	assert.Equal(t, NOTIFY_SOURCE_MIGRATION, records[20].Source)
+	assert.Equal(t, NOTIFY_SOURCE_CONFIG, records[1].Source)
+	assert.Equal(t, "@admin", records[30].AddedBy)
+
+	// A second start does not overwrite the provenance
+	bot.migrateNotificationChats([]int64{20, 30}, []int64{1})
+	records, err = db.GetNotificationChats()
+	require.NoError(t, err)
+	assert.Equal(t, NOTIFY_SOURCE_COMMAND, records[30].Source)
+}
+
+// settingsChangingTransport runs onSend before the first message goes out, like a command arriving meanwhile
+type settingsChangingTransport struct {
+	*fakeTelegramTransport
+	onSend func()
+}
+
+func (s *settingsChangingTransport) SendMessage(req TelegramSendMessageRequest) (int64, error) {
+	if s.onSend != nil {
+		s.onSend()
+		s.onSend = nil
+	}
+	return s.fakeTelegramTransport.SendMessage(req)
+}
+
+func TestBotController_Digest(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	fud := FUDAlertNotification{FUDUserID: "u1", FUDUsername: "shady", AlertSeverity: "high", FUDType: "professional_trojan_horse"}
+	require.NoError(t, db.SaveNotification("n1", fud, time.Hour))
+	require.NoError(t, db.SaveNotification("n2", fud, time.Hour))
+	clean := FUDAlertNotification{FUDUserID: "u2", FUDUsername: "holder", AlertSeverity: "low", FUDType: "manual_analysis_clean"}
+	require.NoError(t, db.SaveNotification("n3", clean, time.Hour))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u1", Username: "shady", FUDType: "professional_trojan_horse", FUDProbability: 0.9, DetectedAt: time.Now()}))
+
+	stats, err := db.GetFUDDigestStats(time.Now().Add(-time.Hour), time.Now().Add(time.Minute), DIGEST_TOP_OFFENDERS)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Detections)
+	assert.Equal(t, 2, stats.BySeverity["high"])
+	require.Len(t, stats.NewFUDUsers, 1)
+	require.Len(t, stats.TopOffenders, 1)
+	assert.Equal(t, DigestOffender{UserID: "u1", Username: "shady", Alerts: 2}, stats.TopOffenders[0])
+
+	bot.handleSubscribeCommand(1, []string{"weekly"})
+	settings, err := db.GetChatSettings(1)
+	require.NoError(t, err)
+	assert.Equal(t, DIGEST_WEEKLY, settings.Digest)
+
+	t.Run("Due once a day after the configured hour", func(t *testing.T) {
+		monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+		daily := ChatSettingsModel{Digest: DIGEST_DAILY, Timezone: "UTC"}
+		assert.True(t, digestDue(daily, monday, 9))
+		assert.False(t, digestDue(daily, monday, 11))
+
+		sentAt := monday.Add(-time.Hour)
+		daily.LastDigestAt = &sentAt
+		assert.False(t, digestDue(daily, monday, 9))
+		assert.True(t, digestDue(daily, monday.Add(24*time.Hour), 9))
+
+		weekly := ChatSettingsModel{Digest: DIGEST_WEEKLY, Timezone: "UTC"}
+		assert.True(t, digestDue(weekly, monday, 9))
+		assert.False(t, digestDue(weekly, monday.Add(24*time.Hour), 9))
+	})
+
+	t.Run("A late digest does not push the next one back", func(t *testing.T) {
+		tuesday := time.Date(2026, 10, 13, 9, 1, 0, 0, time.UTC)
+		sentLate := time.Date(2026, 10, 12, 14, 30, 0, 0, time.UTC)
+		daily := ChatSettingsModel{Digest: DIGEST_DAILY, Timezone: "UTC", LastDigestAt: &sentLate}
+		assert.True(t, digestDue(daily, tuesday, 9))
+
+		// 23:30 UTC is already Tuesday in Berlin, so the Tuesday digest went out
+		sentBerlinTuesday := time.Date(2026, 10, 12, 23, 30, 0, 0, time.UTC)
+		berlin := ChatSettingsModel{Digest: DIGEST_DAILY, Timezone: "Europe/Berlin", LastDigestAt: &sentBerlinTuesday}
+		assert.False(t, digestDue(berlin, tuesday, 9))
+
+		nextMonday := time.Date(2026, 10, 19, 9, 1, 0, 0, time.UTC)
+		weekly := ChatSettingsModel{Digest: DIGEST_WEEKLY, Timezone: "UTC", LastDigestAt: &sentLate}
+		assert.True(t, digestDue(weekly, nextMonday, 9))
+	})
+
+	t.Run("Sends the digest on request", func(t *testing.T) {
+		bot.handleSubscribeCommand(1, []string{"now"})
+		sent := transport.sentMessages()
+		text := sent[len(sent)-1].Text
+		assert.Contains(t, text, "Weekly FUD Digest")
+		assert.Contains(t, text, "Detections:</b> 2")
+		assert.Contains(t, text, "@shady — 2 alerts")
+		assert.NotContains(t, text, "holder")
+	})
+
+	t.Run("Digests follow the redaction profile", func(t *testing.T) {
+		digest := func(profile string) string {
+			settings, err := db.GetChatSettings(1)
+			require.NoError(t, err)
+			settings.Redaction = profile
+			require.NoError(t, db.SaveChatSettings(settings))
+			bot.handleSubscribeCommand(1, []string{"now"})
+			sent := transport.sentMessages()
+			return sent[len(sent)-1].Text
+		}
+
+		text := digest(REDACTION_NO_PROBABILITY)
+		assert.Contains(t, text, "@shady (")
+		assert.NotContains(t, text, "90%")
+
+		text = digest(REDACTION_AMBASSADOR)
+		assert.NotContains(t, text, "shady")
+		assert.NotContains(t, text, "90%")
+		assert.Contains(t, text, anonymizedUserLabel("u1"))
+
+		text = digest(REDACTION_ANONYMIZED)
+		assert.Contains(t, text, "New FUD users:</b> 1")
+		assert.NotContains(t, text, anonymizedUserLabel("u1"))
+		digest(REDACTION_FULL)
+	})
+
+	t.Run("Sending a digest keeps settings changed meanwhile", func(t *testing.T) {
+		monday := time.Now().UTC().AddDate(0, 0, 7)
+		for monday.Weekday() != time.Monday {
+			monday = monday.AddDate(0, 0, 1)
+		}
+		monday = time.Date(monday.Year(), monday.Month(), monday.Day(), 23, 0, 0, 0, time.UTC)
+
+		racing := &settingsChangingTransport{fakeTelegramTransport: transport, onSend: func() {
+			settings, err := db.GetChatSettings(1)
+			require.NoError(t, err)
+			settings.MinSeverity = "critical"
+			require.NoError(t, db.SaveChatSettings(settings))
+		}}
+		bot.transport = racing
+		defer func() { bot.transport = transport }()
+		bot.sendDueDigests(monday)
+
+		settings, err := db.GetChatSettings(1)
+		require.NoError(t, err)
+		assert.Equal(t, "critical", settings.MinSeverity)
+		require.NotNil(t, settings.LastDigestAt)
+		assert.True(t, settings.LastDigestAt.Equal(monday))
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		bot.handleSubscribeCommand(1, []string{"off"})
+		subscriptions, err := db.GetDigestSubscriptions()
+		require.NoError(t, err)
+		assert.Empty(t, subscriptions)
+	})
+}
+
+func TestBotController_WarRoom(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+	bot.chatIDs[2] = true
+	require.NoError(t, db.SaveChatSettings(&ChatSettingsModel{ChatID: 2, Verbosity: VERBOSITY_COMPACT, MinSeverity: "high", Timezone: "UTC"}))
+
+	assert.Equal(t, MONITORING_POLL_INTERVAL, bot.warRoom.pollInterval())
+	assert.False(t, bot.warRoom.escalates(60))
+
+	bot.handleWarRoomCommand(1, []string{"on", "1h"})
+	require.True(t, bot.warRoom.active())
+	assert.Equal(t, WARROOM_POLL_INTERVAL, bot.warRoom.pollInterval())
+	assert.True(t, bot.warRoom.escalates(60))
+	assert.False(t, bot.warRoom.escalates(20))
+	bot.warRoom.recordMessage()
+	require.NoError(t, db.SaveAmplifications([]AmplificationModel{
+		{TweetID: "f1", AmplifierID: "a1", AmplifierUsername: "booster", FUDUserID: "shady"},
+		{TweetID: "f2", AmplifierID: "a1", AmplifierUsername: "booster", FUDUserID: "puppet"},
+	}))
+
+	for _, username := range []string{"shady", "shady", "puppet"} {
+		require.NoError(t, bot.StoreAndBroadcastNotification(FUDAlertNotification{FUDUserID: username, FUDUsername: username, AlertSeverity: "low", FUDType: "casual_criticism"}))
+	}
+	// Chat 2 only takes high alerts normally, the war room delivers everything
+	chat2, bursts := 0, 0
+	for _, message := range transport.sentMessages() {
+		if message.ChatID == 2 && strings.Contains(message.Text, "@shady") {
+			chat2++
+		}
+		if message.ChatID == 1 && strings.Contains(message.Text, "Burst detected:</b> 3 alerts") {
+			bursts++
+			assert.Contains(t, message.Text, "@booster — retweeted 2 flagged posts by 2 users")
+		}
+	}
+	assert.Equal(t, 2, chat2)
+	assert.Equal(t, 1, bursts)
+
+	// The timer of the first hour fires while /warroom on extends it, the extension wins
+	bot.warRoom.mu.Lock()
+	replaced := bot.warRoom.generation
+	bot.warRoom.mu.Unlock()
+	bot.handleWarRoomCommand(1, []string{"on", "2h"})
+	assert.False(t, bot.endWarRoomGeneration(replaced))
+	assert.True(t, bot.warRoom.active())
+
+	bot.handleWarRoomCommand(1, []string{"off"})
+	assert.False(t, bot.warRoom.active())
+	sent := transport.sentMessages()
+	summary := sent[len(sent)-1].Text
+	assert.Contains(t, summary, "War room incident summary")
+	assert.Contains(t, summary, "Messages scanned: 1")
+	assert.Contains(t, summary, "Escalated to detailed analysis: 1")
+	assert.Contains(t, summary, "Bursts: 1")
+	assert.Contains(t, summary, "1. @shady — 2 alerts")
+	assert.Contains(t, summary, "Amplifiers:")
+}
+
+func TestBotController_Aliases(t *testing.T) {
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, setupTestDB(t))
+	bot.chatIDs[42] = true
+	bot.chatIDs[43] = true
+
+	send := func(chatID int64, text string) string {
+		before := len(transport.sentMessages())
+		bot.handleUpdate(newTestUpdate(chatID, text))
+		require.Eventually(t, func() bool {
+			return len(transport.sentMessages()) == before+1
+		}, time.Second, 10*time.Millisecond)
+		return transport.sentMessages()[before].Text
+	}
+
+	assert.Contains(t, send(42, "/alias set al alias list"), "/al now runs")
+	assert.Contains(t, send(42, "/al"), "<b>Aliases</b> (1)")
+	assert.Contains(t, send(43, "/al"), "Available Commands", "aliases belong to the chat that set them")
+
+	// Aliases pointing at each other expand once and end in help instead of looping
+	send(42, "/alias set x y")
+	send(42, "/alias set y x")
+	assert.Contains(t, send(42, "/x"), "Available Commands")
+
+	assert.Contains(t, send(42, "/alias set Bad_Name fudlist"), "Usage")
+	assert.Contains(t, send(42, "/alias remove al"), "Removed alias /al")
+	assert.Contains(t, send(42, "/al"), "Available Commands")
+
+	t.Run("Aliases to expensive commands use the expensive limit", func(t *testing.T) {
+		send(42, "/alias set ex export_nobody")
+		before := len(transport.sentMessages())
+		for i := 0; i < EXPENSIVE_COMMAND_BURST+2; i++ {
+			update := newTestUpdate(42, "/ex")
+			update.Message.From.ID = 700
+			bot.handleUpdate(update)
+		}
+
+		// One reply per allowed export plus a single warning
+		assert.Eventually(t, func() bool {
+			return len(transport.sentMessages()) == before+EXPENSIVE_COMMAND_BURST+1
+		}, 2*time.Second, 10*time.Millisecond)
+		warnings := 0
+		for _, msg := range transport.sentMessages()[before:] {
+			if strings.Contains(msg.Text, "limit for analysis and export") {
+				warnings++
+			}
+		}
+		assert.Equal(t, 1, warnings)
+	})
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// Command types analysis costs are counted under
+const (
+	COST_COMMAND_MONITORING = "monitoring" // community messages, the only analyses the daily budget pauses
+	COST_COMMAND_ANALYZE    = "analyze"
+	COST_COMMAND_BATCH      = "batch"
+	COST_COMMAND_REPORT     = "report"
+	COST_COMMAND_API        = "api" // signals from external tools
+)
+
+const (
+	COSTS_OVERVIEW_DAYS   = 30
+	BUDGET_CHECK_INTERVAL = time.Minute
+)
+
+// llmDefaultPrices are the USD prices per million input and output tokens of the default model of each
+// provider, used when <provider>_price_input and <provider>_price_output are not set
+var llmDefaultPrices = map[string][2]float64{
+	LLM_PROVIDER_CLAUDE: {3, 15},      // claude-sonnet-4
+	LLM_PROVIDER_OPENAI: {0.15, 0.6},  // gpt-4o-mini
+	LLM_PROVIDER_GEMINI: {0.075, 0.3}, // gemini-1.5-flash
+}
+
+// budgetPause remembers the day the budget pause was logged, so a storm of messages logs it once
+var budgetPause struct {
+	mu  sync.Mutex
+	day string
+}
+
+// budgetState tells the admin chats once when the budget runs out and once when it resets
+type budgetState struct {
+	mu        sync.Mutex
+	exhausted bool
+}
+
+// llmPrices returns the USD price per million input and output tokens of a provider, local models are free
+func llmPrices(provider string) (float64, float64) {
+	if provider == "" {
+		provider = LLM_PROVIDER_CLAUDE
+	}
+	defaults := llmDefaultPrices[provider]
+	input, err := strconv.ParseFloat(os.Getenv(provider+ENV_LLM_PRICE_INPUT_SUFFIX), 64)
+	if err != nil || input < 0 {
+		input = defaults[0]
+	}
+	output, err := strconv.ParseFloat(os.Getenv(provider+ENV_LLM_PRICE_OUTPUT_SUFFIX), 64)
+	if err != nil || output < 0 {
+		output = defaults[1]
+	}
+	return input, output
+}
+
+func analysisCost(provider string, usage Usage) float64 {
+	input, output := llmPrices(provider)
+	return (float64(usage.InputTokens)*input + float64(usage.OutputTokens)*output) / 1_000_000
+}
+
+// dailyBudget returns the daily LLM budget in USD, 0 when there is none
+func dailyBudget() float64 {
+	budget, err := strconv.ParseFloat(os.Getenv(ENV_DAILY_BUDGET_USD), 64)
+	if err != nil || budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// dailyTokenBudget returns the daily LLM budget in input and output tokens, 0 when there is none
+func dailyTokenBudget() int64 {
+	budget, err := strconv.ParseInt(os.Getenv(ENV_DAILY_TOKEN_BUDGET), 10, 64)
+	if err != nil || budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// budgetExhausted tells whether today's spend or tokens reached a daily budget, and which one
+func budgetExhausted(dbService *DatabaseService) (bool, string) {
+	budget, tokenBudget := dailyBudget(), dailyTokenBudget()
+	today := time.Now().UTC().Format(time.DateOnly)
+	if budget > 0 {
+		spent, err := dbService.GetAnalysisCostOn(today)
+		if err != nil {
+			log.Printf("Failed to check the daily budget: %v", err)
+			return false, ""
+		}
+		if spent >= budget {
+			return true, fmt.Sprintf("$%.2f of $%.2f spent", spent, budget)
+		}
+	}
+	if tokenBudget > 0 {
+		tokens, err := dbService.GetAnalysisTokensOn(today)
+		if err != nil {
+			log.Printf("Failed to check the daily token budget: %v", err)
+			return false, ""
+		}
+		if tokens >= tokenBudget {
+			return true, fmt.Sprintf("%d of %d tokens used", tokens, tokenBudget)
+		}
+	}
+	return false, ""
+}
+
+// analysisCommandType tells which command an analysis call is spent on
+func analysisCommandType(dbService *DatabaseService, newMessage twitterapi.NewMessage) string {
+	if !newMessage.IsManualAnalysis {
+		return COST_COMMAND_MONITORING
+	}
+	if newMessage.RequestSource != "" {
+		return COST_COMMAND_API
+	}
+	if newMessage.TaskID != "" {
+		task, err := dbService.GetAnalysisTask(newMessage.TaskID)
+		if err == nil && task.BatchID != "" {
+			return COST_COMMAND_BATCH
+		}
+		if err == nil && task.Priority == ANALYSIS_PRIORITY_HIGH {
+			return COST_COMMAND_REPORT
+		}
+	}
+	return COST_COMMAND_ANALYZE
+}
+
+// recordAnalysisCost stores the tokens and price of one analysis call
+func recordAnalysisCost(dbService *DatabaseService, newMessage twitterapi.NewMessage, step string, resp *ClaudeMessageResponse) {
+	dbService.RecordAnalysisCost(AnalysisCostModel{
+		Day:          time.Now().UTC().Format(time.DateOnly),
+		UserID:       newMessage.Author.ID,
+		Username:     newMessage.Author.UserName,
+		CommandType:  analysisCommandType(dbService, newMessage),
+		Step:         step,
+		TaskID:       newMessage.TaskID,
+		LLMModel:     resp.Model,
+		InputTokens:  int64(resp.Usage.InputTokens),
+		OutputTokens: int64(resp.Usage.OutputTokens),
+		CostUSD:      analysisCost(resp.Provider, resp.Usage),
+	})
+}
+
+// analysisPaused tells whether a message has to wait for tomorrow's budget. Manual analyses always run.
+func analysisPaused(dbService *DatabaseService, newMessage twitterapi.NewMessage) bool {
+	if newMessage.IsManualAnalysis {
+		return false
+	}
+	exhausted, reason := budgetExhausted(dbService)
+	if !exhausted {
+		return false
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	budgetPause.mu.Lock()
+	first := budgetPause.day != today
+	budgetPause.day = today
+	budgetPause.mu.Unlock()
+	if first {
+		log.Printf("⏸ Daily budget exhausted (%s), monitoring runs on heuristics only until 00:00 UTC", reason)
+	}
+	return true
+}
+
+// deferAnalysis queues a monitoring message for the second step until the budget resets
+func deferAnalysis(dbService *DatabaseService, newMessage twitterapi.NewMessage) {
+	payload, err := json.Marshal(newMessage)
+	if err != nil {
+		log.Printf("Failed to encode message %s for the budget queue: %v", newMessage.TweetID, err)
+		return
+	}
+	err = dbService.DeferAnalysis(DeferredAnalysisModel{
+		TweetID:  newMessage.TweetID,
+		UserID:   newMessage.Author.ID,
+		Username: newMessage.Author.UserName,
+		Payload:  string(payload),
+	})
+	if err != nil {
+		log.Printf("Failed to queue @%s for the detailed analysis: %v", newMessage.Author.UserName, err)
+		return
+	}
+	log.Printf("📥 Budget spent - @%s queued for the detailed analysis", newMessage.Author.UserName)
+}
+
+// StartBudgetScheduler checks the daily budget periodically, tells the admin chats when it runs out or
+// resets, and hands the queued messages to the second step once it resets
+func (b *BotController) StartBudgetScheduler(secondStep chan twitterapi.NewMessage, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			b.checkBudget(secondStep)
+		}
+	}()
+}
+
+func (b *BotController) checkBudget(secondStep chan twitterapi.NewMessage) {
+	exhausted, reason := budgetExhausted(b.dbService)
+
+	b.budget.mu.Lock()
+	wasExhausted := b.budget.exhausted
+	b.budget.exhausted = exhausted
+	b.budget.mu.Unlock()
+
+	var message string
+	switch {
+	case exhausted && !wasExhausted:
+		message = fmt.Sprintf("⏸ <b>Daily LLM budget exhausted</b> (%s)\n\nMonitoring runs on heuristics only until 00:00 UTC, flagged users are queued for the detailed analysis. Manual analyses still run.", reason)
+	case !exhausted && wasExhausted:
+		queued, _ := b.dbService.CountDeferredAnalyses()
+		message = fmt.Sprintf("▶️ <b>Daily LLM budget reset</b>, %d queued analyses are sent to the detailed analysis.", queued)
+	}
+	if !exhausted {
+		b.replayDeferredAnalyses(secondStep)
+	}
+	if message == "" {
+		return
+	}
+	log.Println(message)
+	for _, chatID := range adminChatIDs() {
+		err := b.SendMessage(chatID, message)
+		if err != nil {
+			log.Printf("Failed to send budget notice to admin chat %d: %v", chatID, err)
+		}
+	}
+}
+
+// replayDeferredAnalyses moves as many queued messages to the second step as it has room for
+func (b *BotController) replayDeferredAnalyses(secondStep chan twitterapi.NewMessage) {
+	room := cap(secondStep) - len(secondStep)
+	if room <= 0 {
+		return
+	}
+	deferred, err := b.dbService.GetDeferredAnalyses(room)
+	if err != nil {
+		log.Printf("Failed to load the budget queue: %v", err)
+		return
+	}
+	for _, item := range deferred {
+		var newMessage twitterapi.NewMessage
+		if err := json.Unmarshal([]byte(item.Payload), &newMessage); err == nil {
+			select {
+			case secondStep <- newMessage:
+			default:
+				return
+			}
+		} else {
+			log.Printf("Dropping unreadable queued message %s: %v", item.TweetID, err)
+		}
+		if err := b.dbService.DeleteDeferredAnalysis(item.ID); err != nil {
+			log.Printf("Failed to remove queued message %s: %v", item.TweetID, err)
+		}
+	}
+}
+
+// formatCostsOverview renders the spend per command type today, this week and this month, with the budget
+func formatCostsOverview(totals []AnalysisCostTotal) string {
+	now := time.Now().UTC()
+	periods := []string{now.Format(time.DateOnly), now.AddDate(0, 0, -6).Format(time.DateOnly), now.AddDate(0, 0, -(COSTS_OVERVIEW_DAYS - 1)).Format(time.DateOnly)}
+	spend := make(map[string]*[3]float64)
+	calls := make(map[string]int64)
+	var sum [3]float64
+	for _, total := range totals {
+		if spend[total.CommandType] == nil {
+			spend[total.CommandType] = &[3]float64{}
+		}
+		calls[total.CommandType] += total.Calls
+		for i, since := range periods {
+			if total.Day >= since {
+				spend[total.CommandType][i] += total.CostUSD
+				sum[i] += total.CostUSD
+			}
+		}
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("💵 <b>Spend per command</b> (today · 7d · %dd)\n", COSTS_OVERVIEW_DAYS))
+	if len(spend) == 0 {
+		message.WriteString("📭 No analyses recorded.\n")
+	}
+	types := make([]string, 0, len(spend))
+	for commandType := range spend {
+		types = append(types, commandType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if spend[types[i]][2] != spend[types[j]][2] {
+			return spend[types[i]][2] > spend[types[j]][2]
+		}
+		return types[i] < types[j]
+	})
+	for _, commandType := range types {
+		periodSpend := spend[commandType]
+		message.WriteString(fmt.Sprintf("  • %s: $%.2f · $%.2f · $%.2f (%d calls)\n", commandType, periodSpend[0], periodSpend[1], periodSpend[2], calls[commandType]))
+	}
+	if len(spend) > 1 {
+		message.WriteString(fmt.Sprintf("  Total: $%.2f · $%.2f · $%.2f\n", sum[0], sum[1], sum[2]))
+	}
+
+	budget := dailyBudget()
+	switch {
+	case budget == 0:
+		message.WriteString("💰 No daily budget set")
+	case sum[0] >= budget:
+		message.WriteString(fmt.Sprintf("⏸ Daily budget of $%.2f spent, monitoring runs on heuristics only until 00:00 UTC. Manual analyses still run.", budget))
+	default:
+		message.WriteString(fmt.Sprintf("💰 Daily budget: $%.2f of $%.2f (%.0f%%)", sum[0], budget, sum[0]*100/budget))
+	}
+	return message.String()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalysisCosts(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	t.Setenv(ENV_DAILY_BUDGET_USD, "1")
+
+	monitored := twitterapi.NewMessage{}
+	monitored.Author.ID = "u1"
+	monitored.Author.UserName = "loud"
+	manual := monitored
+	manual.IsManualAnalysis = true
+	reported := manual
+	reported.TaskID = "task_report"
+	require.NoError(t, db.CreateAnalysisTask(&AnalysisTaskModel{ID: "task_report", Username: "loud", Status: ANALYSIS_STATUS_RUNNING, Priority: ANALYSIS_PRIORITY_HIGH}))
+
+	response := &ClaudeMessageResponse{Model: CLAUDE_MODEL, Usage: Usage{InputTokens: 100000, OutputTokens: 20000}}
+	assert.InDelta(t, 0.6, analysisCost(LLM_PROVIDER_CLAUDE, response.Usage), 1e-9, "$3 and $15 per million tokens by default")
+	recordUserUsage(db, monitored, USAGE_STEP_FIRST, response)
+	recordUserUsage(db, manual, USAGE_STEP_SECOND, response)
+	recordUserUsage(db, reported, USAGE_STEP_SECOND, response)
+	db.RecordAnalysisCost(AnalysisCostModel{Day: time.Now().UTC().AddDate(0, 0, -3).Format(time.DateOnly), CommandType: COST_COMMAND_MONITORING, CostUSD: 2})
+
+	assert.True(t, analysisPaused(db, monitored), "$1.80 spent today on a $1 budget")
+	assert.False(t, analysisPaused(db, manual), "manual analyses always run")
+	t.Setenv(ENV_DAILY_BUDGET_USD, "")
+	assert.False(t, analysisPaused(db, monitored))
+
+	t.Setenv(ENV_DAILY_BUDGET_USD, "5")
+	bot.handleCostsCommand(1, "/costs", nil)
+	sent := transport.sentMessages()
+	report := sent[len(sent)-1].Text
+	assert.Contains(t, report, "• monitoring: $0.60 · $2.60 · $2.60 (2 calls)")
+	assert.Contains(t, report, "• analyze: $0.60 · $0.60 · $0.60 (1 calls)")
+	assert.Contains(t, report, "• report: $0.60 · $0.60 · $0.60 (1 calls)")
+	assert.Contains(t, report, "Total: $1.80 · $3.80 · $3.80")
+	assert.Contains(t, report, "Daily budget: $1.80 of $5.00 (36%)")
+	assert.Contains(t, report, "@loud — 3 calls", "the per-user report follows")
+}
+
+func TestBudgetHardStop(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	t.Setenv(ENV_DAILY_TOKEN_BUDGET, "1000")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	storm := twitterapi.NewMessage{TweetID: "storm-1", Text: "this is a scam and a rug pull"}
+	storm.Author.ID, storm.Author.UserName = "u-storm", "stormer"
+	db.RecordAnalysisCost(AnalysisCostModel{Day: time.Now().UTC().Format(time.DateOnly), CommandType: COST_COMMAND_MONITORING, InputTokens: 900, OutputTokens: 100})
+
+	newMessageCh := make(chan twitterapi.NewMessage, 2)
+	newMessageCh <- storm
+	newMessageCh <- storm
+	close(newMessageCh)
+	notificationCh := make(chan FUDAlertNotification, 2)
+	fudChannel := make(chan twitterapi.NewMessage, 2)
+	claudeApi := newMockClaudeAPI(`"is_fud":true,"fud_probability":90}`, nil)
+	FirstStepHandler(newMessageCh, fudChannel, claudeApi, nil, &mockUserStatusTracker{}, db, notificationCh, &warRoomState{}, nil)
+
+	assert.Empty(t, claudeApi.recordedCalls(), "1000 of 1000 tokens used")
+	assert.Empty(t, fudChannel)
+	require.Len(t, notificationCh, 2)
+	alert := <-notificationCh
+	assert.Equal(t, HEURISTIC_FUD_TYPE, alert.FUDType)
+	assert.Equal(t, "Heuristic assessment only, the daily LLM budget is spent", alert.DecisionReason)
+	queued, err := db.CountDeferredAnalyses()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queued, "a tweet is queued once")
+
+	secondStep := make(chan twitterapi.NewMessage, 5)
+	bot.checkBudget(secondStep)
+	bot.checkBudget(secondStep)
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1, "admins are told once")
+	assert.Contains(t, sent[0].Text, "Daily LLM budget exhausted</b> (1000 of 1000 tokens used)")
+	assert.Empty(t, secondStep)
+
+	t.Setenv(ENV_DAILY_TOKEN_BUDGET, "5000")
+	bot.checkBudget(secondStep)
+	sent = transport.sentMessages()
+	require.Len(t, sent, 2)
+	assert.Contains(t, sent[1].Text, "1 queued analyses are sent to the detailed analysis")
+	require.Len(t, secondStep, 1)
+	assert.Equal(t, "stormer", (<-secondStep).Author.UserName)
+	queued, err = db.CountDeferredAnalyses()
+	require.NoError(t, err)
+	assert.Zero(t, queued)
+}
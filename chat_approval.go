@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isChatApprovalMode reports whether onboarded chats need admin approval before receiving broadcasts
+func isChatApprovalMode() bool {
+	return strings.ToLower(os.Getenv(ENV_CHAT_APPROVAL_MODE)) == "true"
+}
+
+// adminChatIDs returns chat IDs listed in tg_admin_chat_id
+func adminChatIDs() []int64 {
+	var chatIDs []int64
+	for _, chatIDStr := range strings.Split(os.Getenv(ENV_TELEGRAM_ADMIN_CHAT_ID), ",") {
+		chatID, err := strconv.ParseInt(strings.TrimSpace(chatIDStr), 10, 64)
+		if err == nil {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+	return chatIDs
+}
+
+func (b *BotController) notifyAdminsAboutPendingChat(settings *ChatSettingsModel) {
+	message := fmt.Sprintf(`🆕 <b>Chat waiting for approval</b>
+
+💬 Chat: %s
+🆔 ID: <code>%d</code>
+🪙 Ticker: $%s
+🚨 Minimum severity: %s
+
+• /approve_chat %d - Allow alerts
+• /reject_chat %d - Deny alerts`,
+		html.EscapeString(settings.ChatTitle), settings.ChatID, settings.Ticker, settings.MinSeverity, settings.ChatID, settings.ChatID)
+
+	for _, adminChatID := range adminChatIDs() {
+		err := b.SendMessage(adminChatID, message)
+		if err != nil {
+			log.Printf("Failed to notify admin chat %d about pending chat %d: %v", adminChatID, settings.ChatID, err)
+		}
+	}
+}
+
+// handleChatApprovalCommand processes /approve_chat <id> and /reject_chat <id>
+func (b *BotController) handleChatApprovalCommand(chatID int64, actor string, args []string, approve bool) {
+	usage := "❌ Usage: /approve_chat <chat_id> or /reject_chat <chat_id>"
+	if len(args) == 0 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	targetChatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.SendMessage(chatID, usage)
+		return
+	}
+
+	settings, err := b.dbService.GetChatSettings(targetChatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+	if settings.ApprovalStatus != CHAT_APPROVAL_PENDING {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Chat %d is not waiting for approval", targetChatID))
+		return
+	}
+
+	if approve {
+		settings.ApprovalStatus = CHAT_APPROVAL_APPROVED
+	} else {
+		settings.ApprovalStatus = CHAT_APPROVAL_REJECTED
+	}
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	if approve {
+		log.Printf("Chat %d approved by admin chat %d", targetChatID, chatID)
+		b.registerChat(settings, NOTIFY_SOURCE_APPROVAL, actor, chatID)
+		b.SendMessage(chatID, fmt.Sprintf("✅ Chat %d (%s) approved", targetChatID, html.EscapeString(settings.ChatTitle)))
+		return
+	}
+
+	log.Printf("Chat %d rejected by admin chat %d", targetChatID, chatID)
+	b.SendMessage(targetChatID, "❌ An administrator declined alerts for this chat.")
+	b.SendMessage(chatID, fmt.Sprintf("🚫 Chat %d (%s) rejected", targetChatID, html.EscapeString(settings.ChatTitle)))
+}
+
+// handlePendingChatsCommand lists chats waiting for approval
+func (b *BotController) handlePendingChatsCommand(chatID int64) {
+	pending, err := b.dbService.GetChatSettingsByApprovalStatus(CHAT_APPROVAL_PENDING)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error retrieving pending chats: %v", err))
+		return
+	}
+	if len(pending) == 0 {
+		b.SendMessage(chatID, "✅ No chats waiting for approval")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🕐 <b>Chats waiting for approval (%d)</b>\n\n", len(pending)))
+	for _, settings := range pending {
+		message.WriteString(fmt.Sprintf("💬 %s — <code>%d</code> ($%s, from %s)\n• /approve_chat %d  • /reject_chat %d\n\n",
+			html.EscapeString(settings.ChatTitle), settings.ChatID, settings.Ticker, settings.MinSeverity, settings.ChatID, settings.ChatID))
+	}
+	b.SendMessage(chatID, message.String())
+}
@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
+	"github.com/grutapig/hackaton/httpretry"
+	"github.com/grutapig/hackaton/twitterapi"
 	"net/http"
 	"net/url"
 )
@@ -15,6 +16,9 @@ type ClaudeApi struct {
 	model       string
 	maxTokens   int
 	temperature float32
+	step        string                         // pipeline step the calls are counted under
+	usageHook   func(step string, usage Usage) // called after every call, for usage stats
+	retry       httpretry.RetryPolicy
 }
 
 const ROLE_USER = "user"
@@ -56,6 +60,8 @@ type ClaudeMessageResponse struct {
 	StopReason   string    `json:"stop_reason"`
 	StopSequence *string   `json:"stop_sequence"`
 	Usage        Usage     `json:"usage"`
+	Provider     string    `json:"-"` // LLM provider that answered, set by the client
+	Retries      int       `json:"-"` // transient failures retried before the answer
 }
 
 type ClaudeMessageErrorResponse struct {
@@ -71,6 +77,16 @@ type Usage struct {
 	OutputTokens int `json:"output_tokens"`
 }
 
+// ClaudeStatusError is returned when the API answers with a non 200 status
+type ClaudeStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ClaudeStatusError) Error() string {
+	return e.Message
+}
+
 func NewClaudeClient(apiKey string, proxyDSN string, defaultModel string) (api *ClaudeApi, err error) {
 	transport := &http.Transport{}
 	if proxyDSN != "" {
@@ -89,10 +105,32 @@ func NewClaudeClient(apiKey string, proxyDSN string, defaultModel string) (api *
 		model:       defaultModel,
 		maxTokens:   DEFAULT_MAX_TOKENS,
 		temperature: DEFAULT_TEMPERATURE,
+		retry:       httpretry.NewRetryPolicy(LLM_PROVIDER_CLAUDE),
 	}
 	return api, nil
 }
 
+// ForStep returns a client sharing this one's connection whose calls are counted under the given step
+func (c *ClaudeApi) ForStep(step string) ClaudeAPI {
+	stepClient := *c
+	stepClient.step = step
+	return &stepClient
+}
+
+// SetUsageHook registers a function called with the token usage of every call
+func (c *ClaudeApi) SetUsageHook(hook func(step string, usage Usage)) {
+	c.usageHook = hook
+}
+
+func (c *ClaudeApi) Name() string {
+	return LLM_PROVIDER_CLAUDE
+}
+
+// LogRequests records every Claude request in the request log
+func (c *ClaudeApi) LogRequests(logger *twitterapi.RequestLogger) {
+	c.client.Transport = logger.Wrap("claude", c.client.Transport)
+}
+
 func (c *ClaudeApi) SendMessage(claudeMessages ClaudeMessages, systemMessage string) (*ClaudeMessageResponse, error) {
 	request := ClaudeMessageRequest{
 		Model:       c.model,
@@ -106,38 +144,54 @@ func (c *ClaudeApi) SendMessage(claudeMessages ClaudeMessages, systemMessage str
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequest("POST", CLAUDE_API_URL, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-	resp, err := c.client.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	resp, body, retries, err := c.retry.Do(c.client, func() (*http.Request, error) {
+		httpReq, err := http.NewRequest("POST", CLAUDE_API_URL, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("x-api-key", c.apiKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+		return httpReq, nil
+	})
 	if err != nil {
+		c.recordUsage(Usage{})
 		return nil, err
 	}
 	if resp.StatusCode != 200 {
+		c.recordUsage(Usage{})
 		var respData ClaudeMessageErrorResponse
-		err = json.Unmarshal(body, &respData)
-		if err != nil {
-			return nil, fmt.Errorf("claude SendMessage status code non 200, %d, unmarshall err: %s, body: %s", resp.StatusCode, err, string(body))
+		statusErr := &ClaudeStatusError{StatusCode: resp.StatusCode}
+		if err = json.Unmarshal(body, &respData); err != nil {
+			statusErr.Message = fmt.Sprintf("claude SendMessage status code non 200, %d, unmarshall err: %s, body: %s", resp.StatusCode, err, string(body))
+		} else {
+			statusErr.Message = fmt.Sprintf("claude SendMessage status not 200(%d) error: message: %s, type: %s", resp.StatusCode, respData.Error.Message, respData.Error.Type)
 		}
-		return nil, fmt.Errorf("claude SendMessage status not 200(%d) error: message: %s, type: %s", resp.StatusCode, respData.Error.Message, respData.Error.Type)
+		return nil, retriedError(statusErr, retries)
 	}
 
 	var respData ClaudeMessageResponse
 	err = json.Unmarshal(body, &respData)
+	c.recordUsage(respData.Usage)
 	if err != nil {
 		return nil, fmt.Errorf("claude SendMessage unmarshall err: %s, body: %s", err, string(body))
 	}
+	respData.Provider = LLM_PROVIDER_CLAUDE
+	respData.Retries = retries
 
 	return &respData, nil
 }
+
+// retriedError records the retries behind a failed call so callers can report them
+func retriedError(err error, retries int) error {
+	if retries == 0 {
+		return err
+	}
+	return &httpretry.RetryError{Attempts: retries + 1, Err: err}
+}
+
+func (c *ClaudeApi) recordUsage(usage Usage) {
+	if c.usageHook != nil {
+		c.usageHook(c.step, usage)
+	}
+}
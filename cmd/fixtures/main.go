@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/grutapig/hackaton/twitterapi"
+	"os"
+	"strings"
+)
+
+// Converts logged Twitter/Claude exchanges (request_log_dir) into test fixtures.
+//
+//	go run ./cmd/fixtures -log ./request_log -list -provider twitter -match /tweet/replies
+//	go run ./cmd/fixtures -log ./request_log -id twitter-1719...-42 -out testdata/fixtures
+//
+// Load the written files with twitterapi.NewReplayTransport to replay them in tests.
+func main() {
+	logPath := flag.String("log", "request_log", "request log directory or file")
+	provider := flag.String("provider", "", "only exchanges of this provider (twitter, claude)")
+	match := flag.String("match", "", "only exchanges whose URL or request body contains this text")
+	ids := flag.String("id", "", "comma-separated exchange IDs to convert")
+	status := flag.Int("status", 0, "only exchanges with this status code")
+	list := flag.Bool("list", false, "list matching exchanges instead of writing fixtures")
+	out := flag.String("out", "testdata/fixtures", "fixture output directory")
+	flag.Parse()
+
+	exchanges, err := twitterapi.LoadExchanges(*logPath)
+	panicErr(err)
+
+	wanted := make(map[string]bool)
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
+		}
+	}
+
+	selected := 0
+	for _, exchange := range exchanges {
+		if len(wanted) > 0 && !wanted[exchange.ID] {
+			continue
+		}
+		if *provider != "" && exchange.Provider != *provider {
+			continue
+		}
+		if *status != 0 && exchange.StatusCode != *status {
+			continue
+		}
+		if *match != "" && !strings.Contains(exchange.URL, *match) && !strings.Contains(exchange.RequestBody, *match) {
+			continue
+		}
+		selected++
+
+		if *list {
+			fmt.Printf("%s  %s  %s %s -> %d (%dms)\n", exchange.ID, exchange.Time.Format("2006-01-02 15:04:05"), exchange.Method, exchange.URL, exchange.StatusCode, exchange.DurationMs)
+			continue
+		}
+		path, err := twitterapi.WriteFixture(*out, exchange)
+		panicErr(err)
+		fmt.Printf("✅ %s\n", path)
+	}
+
+	if selected == 0 {
+		fmt.Fprintln(os.Stderr, "No matching exchanges")
+		os.Exit(1)
+	}
+	if !*list && len(wanted) == 0 && *match == "" && *provider == "" && *status == 0 {
+		fmt.Printf("⚠️ Converted all %d exchanges, use -id, -match, -provider or -status to pick a few\n", selected)
+	}
+}
+
+func panicErr(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const evidenceMarker = "\n===== CHAIN OF CUSTODY =====\n"
+
+// Checks the chain of custody trailer of a text export (/export_, /ticker_history_).
+//
+//	go run ./cmd/verify_export alice_messages_20250101_120000.txt
+//	go run ./cmd/verify_export -key <hex public key> alice_messages_20250101_120000.txt
+//
+// Without -key only the SHA-256 is checked; with it the signature must verify with that key.
+// Graph exports carry their hash in the caption only, compare it with sha256sum.
+func main() {
+	key := flag.String("key", "", "hex Ed25519 public key the export must be signed with")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: verify_export [-key <hex public key>] <file>")
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+	panicErr(err)
+	sealed := string(data)
+
+	i := strings.LastIndex(sealed, evidenceMarker)
+	if i < 0 {
+		fail("no chain of custody trailer found")
+	}
+	content, trailer := sealed[:i], sealed[i+len(evidenceMarker):]
+	fields := map[string]string{}
+	for _, line := range strings.Split(trailer, "\n") {
+		if name, value, ok := strings.Cut(line, ": "); ok {
+			fields[name] = value
+		}
+	}
+
+	digest := sha256.Sum256([]byte(content))
+	if hex.EncodeToString(digest[:]) != fields["SHA-256"] {
+		fail("content does not match its SHA-256, the export was modified")
+	}
+	fmt.Printf("✅ SHA-256 matches: %s\n", fields["SHA-256"])
+
+	if *key == "" {
+		if fields["Public key"] != "" {
+			fmt.Printf("ℹ️ Signed with key %s, pass -key to check the signature\n", fields["Public key"])
+		}
+		return
+	}
+	publicKey, err := hex.DecodeString(*key)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		fail("-key must be a hex encoded Ed25519 public key")
+	}
+	signature, err := hex.DecodeString(fields["Signature (Ed25519 over the SHA-256 digest)"])
+	if err != nil || !ed25519.Verify(publicKey, digest[:], signature) {
+		fail("signature does not verify with the given public key")
+	}
+	fmt.Println("✅ Signature verified")
+}
+
+func fail(reason string) {
+	fmt.Fprintf(os.Stderr, "❌ %s\n", reason)
+	os.Exit(1)
+}
+
+func panicErr(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
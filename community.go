@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const COMMUNITY_CHATS_PREFIX = "chats:"
+const COMMUNITY_CONTEXT_PREFIX = "context:"
+const TICKER_SUBSCRIPTION_ALL = "*"
+
+var communityIDRegex = regexp.MustCompile(`^[0-9]{5,25}$`)
+
+// communityMonitors runs one monitoring goroutine per active community
+type communityMonitors struct {
+	mu      sync.Mutex
+	running map[string]chan struct{}
+	monitor func(community CommunityModel, stop <-chan struct{})
+}
+
+func newCommunityMonitors(monitor func(community CommunityModel, stop <-chan struct{})) *communityMonitors {
+	return &communityMonitors{running: make(map[string]chan struct{}), monitor: monitor}
+}
+
+// Start begins monitoring a community, restarting its monitor if it runs already so changed settings apply
+func (m *communityMonitors) Start(community CommunityModel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stop, ok := m.running[community.ID]; ok {
+		close(stop)
+	}
+	stop := make(chan struct{})
+	m.running[community.ID] = stop
+	go m.monitor(community, stop)
+}
+
+// Stop ends monitoring of a community and reports whether it was monitored
+func (m *communityMonitors) Stop(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stop, ok := m.running[id]
+	if ok {
+		close(stop)
+		delete(m.running, id)
+	}
+	return ok
+}
+
+// IsRunning reports whether a community is being monitored
+func (m *communityMonitors) IsRunning(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.running[id]
+	return ok
+}
+
+// sleepOrStop waits for d and reports false if the monitor was stopped in the meantime
+func sleepOrStop(d time.Duration, stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// normalizeTicker makes "$grut" and "GRUT" compare equal
+func normalizeTicker(ticker string) string {
+	return strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(ticker), "$"))
+}
+
+// communityPromptContext tells the model which ticker the analyzed message is about, the one of its
+// community or the configured one for manual analyses, and adds the community's prompt context
+func communityPromptContext(newMessage twitterapi.NewMessage) string {
+	ticker := newMessage.Ticker
+	if ticker == "" {
+		ticker = os.Getenv(ENV_TWITTER_COMMUNITY_TICKER)
+	}
+	context := "\nthe system ticker is:" + ticker + ", it cannot be used for any criteria or flag about decision FUD or not"
+	if newMessage.CommunityContext != "" {
+		context += "\n<community_context>" + newMessage.CommunityContext + "</community_context>"
+	}
+	return context
+}
+
+// communityReceivesAlert decides whether a chat gets the alerts of a community: the chats configured
+// for it if any, otherwise chats subscribed to its ticker (the onboarding ticker unless the chat used
+// /subscribe_ticker) and chats without a ticker
+func communityReceivesAlert(community *CommunityModel, chatID int64, settings *ChatSettingsModel) bool {
+	if community == nil {
+		return true
+	}
+	if community.NotifyChatIDs != "" {
+		for _, id := range strings.Split(community.NotifyChatIDs, ",") {
+			if strings.TrimSpace(id) == strconv.FormatInt(chatID, 10) {
+				return true
+			}
+		}
+		return false
+	}
+	tickers := chatTickers(settings)
+	if len(tickers) == 0 {
+		return true
+	}
+	for _, ticker := range tickers {
+		if ticker == TICKER_SUBSCRIPTION_ALL || ticker == normalizeTicker(community.Ticker) {
+			return true
+		}
+	}
+	return false
+}
+
+// sinksReceiveAlert applies the community routing to the other sinks such as Discord. They have no
+// ticker subscriptions, so like chats without a ticker they get every alert except those of
+// communities routed to configured chats.
+func (b *BotController) sinksReceiveAlert(alert FUDAlertNotification) bool {
+	return communityReceivesAlert(b.alertCommunity(alert), 0, &ChatSettingsModel{})
+}
+
+// chatTickers returns the normalized tickers a chat is subscribed to
+func chatTickers(settings *ChatSettingsModel) []string {
+	subscribed := settings.Tickers
+	if subscribed == "" {
+		subscribed = settings.Ticker
+	}
+	var tickers []string
+	for _, ticker := range strings.Split(subscribed, ",") {
+		if ticker = normalizeTicker(ticker); ticker != "" {
+			tickers = append(tickers, ticker)
+		}
+	}
+	return tickers
+}
+
+// handleTickerSubscriptionCommand processes /subscribe_ticker [TICKER[,TICKER]|all] and /unsubscribe_ticker TICKER
+func (b *BotController) handleTickerSubscriptionCommand(chatID int64, command string, args []string) {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+	current := chatTickers(settings)
+
+	var requested []string
+	for _, ticker := range strings.Split(strings.Join(args, ","), ",") {
+		ticker = normalizeTicker(ticker)
+		if ticker == "ALL" {
+			ticker = TICKER_SUBSCRIPTION_ALL
+		}
+		if ticker != "" {
+			requested = append(requested, ticker)
+		}
+	}
+	if len(requested) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("🏷 <b>Ticker subscriptions:</b> %s\n\nUsage: /subscribe_ticker BTC[,ETH]|all, /unsubscribe_ticker BTC\nAlerts of monitored communities only reach chats subscribed to their ticker. Manual analyses are not filtered.", formatChatTickers(current)))
+		return
+	}
+
+	if command == "/unsubscribe_ticker" {
+		var kept []string
+		for _, ticker := range current {
+			if !slices.Contains(requested, ticker) {
+				kept = append(kept, ticker)
+			}
+		}
+		if len(kept) == len(current) {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Not subscribed to %s", html.EscapeString(strings.Join(requested, ", "))))
+			return
+		}
+		if len(kept) == 0 {
+			// An empty list would fall back to the onboarding ticker or deliver everything
+			b.SendMessage(chatID, "❌ A chat must keep at least one ticker, use /subscribe_ticker all to receive every community")
+			return
+		}
+		current = kept
+	} else {
+		for _, ticker := range requested {
+			if ticker == TICKER_SUBSCRIPTION_ALL {
+				current = []string{TICKER_SUBSCRIPTION_ALL}
+				break
+			}
+			if !onboardingTickerRegex.MatchString(ticker) {
+				b.SendMessage(chatID, fmt.Sprintf("❌ Invalid ticker: %s", html.EscapeString(ticker)))
+				return
+			}
+			if !slices.Contains(current, ticker) {
+				current = append(slices.DeleteFunc(current, func(t string) bool { return t == TICKER_SUBSCRIPTION_ALL }), ticker)
+			}
+		}
+	}
+
+	settings.Tickers = strings.Join(current, ",")
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving subscriptions: %v", err))
+		return
+	}
+
+	message := fmt.Sprintf("✅ <b>Ticker subscriptions:</b> %s", formatChatTickers(current))
+	if unmonitored := b.unmonitoredTickers(current); len(unmonitored) > 0 {
+		message += fmt.Sprintf("\n⚠️ No monitored community for %s yet", html.EscapeString(strings.Join(unmonitored, ", ")))
+	}
+	b.SendMessage(chatID, message)
+}
+
+func formatChatTickers(tickers []string) string {
+	if len(tickers) == 0 || slices.Contains(tickers, TICKER_SUBSCRIPTION_ALL) {
+		return "all tickers"
+	}
+	return html.EscapeString("$" + strings.Join(tickers, ", $"))
+}
+
+// unmonitoredTickers returns the subscribed tickers no active community is about
+func (b *BotController) unmonitoredTickers(tickers []string) []string {
+	communities, err := b.dbService.GetActiveCommunities()
+	if err != nil {
+		return nil
+	}
+	monitored := make(map[string]bool)
+	for _, community := range communities {
+		monitored[normalizeTicker(community.Ticker)] = true
+	}
+	var unmonitored []string
+	for _, ticker := range tickers {
+		if ticker != TICKER_SUBSCRIPTION_ALL && !monitored[ticker] {
+			unmonitored = append(unmonitored, "$"+ticker)
+		}
+	}
+	return unmonitored
+}
+
+// alertCommunity loads the community an alert came from, nil for alerts of manual analyses
+func (b *BotController) alertCommunity(alert FUDAlertNotification) *CommunityModel {
+	if alert.CommunityID == "" {
+		return nil
+	}
+	community, err := b.dbService.GetCommunity(alert.CommunityID)
+	if err != nil {
+		log.Printf("Failed to load community %s, alert goes to all chats: %v", alert.CommunityID, err)
+		return nil
+	}
+	return community
+}
+
+// SetCommunityMonitors sets the registry that /community add and remove start and stop monitors in
+func (b *BotController) SetCommunityMonitors(monitors *communityMonitors) {
+	b.communities = monitors
+}
+
+// handleCommunityCommand manages the monitored communities:
+// /community [list|add <id> <TICKER> [chats:id1,id2] [context: text]|remove <id>]
+func (b *BotController) handleCommunityCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleCommunityList(chatID)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		b.handleCommunityAdd(chatID, actor, args[1:])
+	case "remove":
+		if len(args) < 2 {
+			b.SendMessage(chatID, "❌ Usage: /community remove &lt;community_id&gt;")
+			return
+		}
+		removed, err := b.dbService.DeactivateCommunity(args[1])
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error removing community: %v", err))
+			return
+		}
+		if b.communities != nil {
+			b.communities.Stop(args[1])
+		}
+		b.watchdog.forget(args[1])
+		if !removed {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Community <code>%s</code> is not monitored", html.EscapeString(args[1])))
+			return
+		}
+		log.Printf("🏘 %s removed community %s", actor, args[1])
+		b.SendMessage(chatID, fmt.Sprintf("🗑 Stopped monitoring community <code>%s</code>. Its tweets stay in the database.", html.EscapeString(args[1])))
+	default:
+		b.SendMessage(chatID, "❌ Usage: /community [list|add &lt;id&gt; &lt;TICKER&gt; [chats:id1,id2] [context: text]|remove &lt;id&gt;]")
+	}
+}
+
+func (b *BotController) handleCommunityAdd(chatID int64, actor string, args []string) {
+	usage := "❌ Usage: /community add &lt;community_id&gt; &lt;TICKER&gt; [chats:id1,id2] [context: what the community is about]"
+	community := CommunityModel{AddedBy: actor}
+	for i, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), COMMUNITY_CONTEXT_PREFIX) {
+			community.PromptContext = strings.TrimSpace(arg[len(COMMUNITY_CONTEXT_PREFIX):] + " " + strings.Join(args[i+1:], " "))
+			args = args[:i]
+			break
+		}
+	}
+	var positional []string
+	for _, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), COMMUNITY_CHATS_PREFIX) {
+			for _, idStr := range strings.Split(arg[len(COMMUNITY_CHATS_PREFIX):], ",") {
+				if _, err := strconv.ParseInt(idStr, 10, 64); err != nil {
+					b.SendMessage(chatID, fmt.Sprintf("❌ Invalid chat ID: %s", html.EscapeString(idStr)))
+					return
+				}
+			}
+			community.NotifyChatIDs = arg[len(COMMUNITY_CHATS_PREFIX):]
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	if len(positional) != 2 || !communityIDRegex.MatchString(positional[0]) || !onboardingTickerRegex.MatchString(normalizeTicker(positional[1])) {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	community.ID = positional[0]
+	community.Ticker = "$" + normalizeTicker(positional[1])
+
+	err := b.dbService.SaveCommunity(community)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving community: %v", err))
+		return
+	}
+	if b.communities != nil {
+		b.communities.Start(community)
+	}
+	log.Printf("🏘 %s added community %s (%s)", actor, community.ID, community.Ticker)
+
+	routing := "chats onboarded for " + community.Ticker + " and chats without a ticker"
+	if community.NotifyChatIDs != "" {
+		routing = "chats " + community.NotifyChatIDs
+	}
+	b.SendMessage(chatID, fmt.Sprintf("✅ Monitoring community <code>%s</code> for <b>%s</b>\n📬 Alerts go to %s",
+		community.ID, html.EscapeString(community.Ticker), html.EscapeString(routing)))
+}
+
+func (b *BotController) handleCommunityList(chatID int64) {
+	communities, err := b.dbService.GetActiveCommunities()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading communities: %v", err))
+		return
+	}
+	if len(communities) == 0 {
+		b.SendMessage(chatID, "🏘 No communities are monitored.\n\nUsage: /community add &lt;community_id&gt; &lt;TICKER&gt; [chats:id1,id2] [context: text]")
+		return
+	}
+	counts, err := b.dbService.GetCommunityTweetCounts()
+	if err != nil {
+		log.Printf("Failed to count community tweets: %v", err)
+	}
+	sort.SliceStable(communities, func(i, j int) bool { return communities[i].Ticker < communities[j].Ticker })
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🏘 <b>Monitored communities</b> (%d)\n\n", len(communities)))
+	for _, community := range communities {
+		status := "🟢"
+		if b.communities != nil && !b.communities.IsRunning(community.ID) {
+			status = "⚪️"
+		}
+		message.WriteString(fmt.Sprintf("%s <b>%s</b> — <code>%s</code>, %d tweets\n", status, html.EscapeString(community.Ticker), community.ID, counts[community.ID]))
+		if community.NotifyChatIDs != "" {
+			message.WriteString(fmt.Sprintf("   📬 Chats: %s\n", html.EscapeString(community.NotifyChatIDs)))
+		}
+		if community.PromptContext != "" {
+			message.WriteString(fmt.Sprintf("   📝 <i>%s</i>\n", b.formatter.escapeTruncated(community.PromptContext, 100)))
+		}
+	}
+	b.SendMessage(chatID, message.String())
+}
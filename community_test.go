@@ -0,0 +1,179 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// detectFresh runs a message through the full second step analysis and returns the alert it raises
+func detectFresh(t *testing.T, db *DatabaseService, message twitterapi.NewMessage) FUDAlertNotification {
+	t.Helper()
+	claudeApi := newMockClaudeAPI(`"is_fud_user":true,"fud_type":"direct_attack","fud_probability":0.9,"user_risk_level":"high"}`, nil)
+	notificationCh := make(chan FUDAlertNotification, 1)
+	SecondStepHandler(message, notificationCh, &mockTwitterAPI{}, claudeApi, nil, &mockUserStatusTracker{}, "GRUT", db)
+	require.Len(t, notificationCh, 1)
+	return <-notificationCh
+}
+
+func TestBotController_CommunityCommand(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	var mu sync.Mutex
+	started := map[string]CommunityModel{}
+	bot.SetCommunityMonitors(newCommunityMonitors(func(community CommunityModel, stop <-chan struct{}) {
+		mu.Lock()
+		started[community.ID] = community
+		mu.Unlock()
+		<-stop
+	}))
+
+	seeded, err := db.SeedCommunity("1111111111", "$GRUT")
+	require.NoError(t, err)
+	assert.True(t, seeded)
+
+	bot.handleCommunityCommand(1, "@admin", []string{"add", "2222222222", "pepe", "chats:-100,-200", "context:", "memecoin", "fans"})
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "Monitoring community <code>2222222222</code> for <b>$PEPE</b>")
+
+	community, err := db.GetCommunity("2222222222")
+	require.NoError(t, err)
+	assert.Equal(t, "$PEPE", community.Ticker)
+	assert.Equal(t, "-100,-200", community.NotifyChatIDs)
+	assert.Equal(t, "memecoin fans", community.PromptContext)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return started["2222222222"].Ticker == "$PEPE"
+	}, time.Second, 10*time.Millisecond)
+
+	bot.handleCommunityCommand(1, "@admin", []string{"add", "not-a-community", "PEPE"})
+	assert.Contains(t, transport.sentMessages()[1].Text, "Usage")
+
+	bot.handleCommunityCommand(1, "@admin", []string{"list"})
+	list := transport.sentMessages()[2].Text
+	assert.Contains(t, list, "$GRUT")
+	assert.Contains(t, list, "$PEPE")
+	assert.Contains(t, list, "memecoin fans")
+
+	bot.handleCommunityCommand(1, "@admin", []string{"remove", "2222222222"})
+	assert.Contains(t, transport.sentMessages()[3].Text, "Stopped monitoring")
+	assert.False(t, bot.communities.IsRunning("2222222222"))
+	active, err := db.GetActiveCommunities()
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "1111111111", active[0].ID)
+
+	seeded, err = db.SeedCommunity("2222222222", "$PEPE")
+	require.NoError(t, err)
+	assert.False(t, seeded, "a removed community stays removed after a restart")
+}
+
+func TestBotController_CommunityAlertRouting(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	for _, chatID := range []int64{1, 2, 3} {
+		bot.chatIDs[chatID] = true
+	}
+	require.NoError(t, db.SaveChatSettings(&ChatSettingsModel{ChatID: 1, Ticker: "GRUT"}))
+	require.NoError(t, db.SaveChatSettings(&ChatSettingsModel{ChatID: 2, Ticker: "PEPE"}))
+	require.NoError(t, db.SaveCommunity(CommunityModel{ID: "1111111111", Ticker: "$GRUT"}))
+	require.NoError(t, db.SaveCommunity(CommunityModel{ID: "2222222222", Ticker: "$PEPE", NotifyChatIDs: "3"}))
+
+	recipients := func(alert FUDAlertNotification) []int64 {
+		before := len(transport.sentMessages())
+		require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+		var chats []int64
+		for _, message := range transport.sentMessages()[before:] {
+			chats = append(chats, message.ChatID)
+		}
+		return chats
+	}
+
+	alert := benchmarkAlert()
+	assert.ElementsMatch(t, []int64{1, 2, 3}, recipients(alert), "manual analyses go to every chat")
+
+	alert.CommunityID, alert.Ticker = "1111111111", "$GRUT"
+	assert.ElementsMatch(t, []int64{1, 3}, recipients(alert), "chats subscribed to the ticker and chats without one")
+
+	alert.CommunityID, alert.Ticker = "2222222222", "$PEPE"
+	assert.ElementsMatch(t, []int64{3}, recipients(alert), "configured chats only")
+	assert.Contains(t, transport.sentMessages()[len(transport.sentMessages())-1].Text, "🏘 <b>Community:</b> $PEPE")
+
+	t.Run("Fresh detections are routed by their community", func(t *testing.T) {
+		message := twitterapi.NewMessage{TweetID: "t1", Text: "$PEPE is a rug", CommunityID: "2222222222", Ticker: "$PEPE"}
+		message.Author.ID, message.Author.UserName = "u1", "rugger"
+		fresh := detectFresh(t, db, message)
+		assert.Equal(t, "2222222222", fresh.CommunityID)
+		assert.ElementsMatch(t, []int64{3}, recipients(fresh))
+	})
+}
+
+func TestBotController_TickerSubscriptions(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveChatSettings(&ChatSettingsModel{ChatID: 5, Ticker: "PEPE"}))
+	require.NoError(t, db.SaveCommunity(CommunityModel{ID: "1111111111", Ticker: "$GRUT"}))
+	require.NoError(t, db.SaveCommunity(CommunityModel{ID: "2222222222", Ticker: "$PEPE"}))
+
+	reply := func(command string, args ...string) string {
+		bot.handleTickerSubscriptionCommand(5, command, args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+	receives := func(ticker string) bool {
+		settings, err := db.GetChatSettings(5)
+		require.NoError(t, err)
+		return communityReceivesAlert(&CommunityModel{Ticker: ticker}, 5, settings)
+	}
+
+	assert.Contains(t, reply("/subscribe_ticker"), "$PEPE", "the onboarding ticker is the default subscription")
+	assert.False(t, receives("$GRUT"))
+
+	assert.Contains(t, reply("/subscribe_ticker", "$grut,BTC"), "$PEPE, $GRUT, $BTC")
+	assert.Contains(t, reply("/subscribe_ticker", "ETH"), "No monitored community for $BTC, $ETH")
+	assert.True(t, receives("$GRUT"))
+	assert.True(t, receives("PEPE"))
+
+	assert.Contains(t, reply("/unsubscribe_ticker", "PEPE", "BTC", "ETH"), "$GRUT")
+	assert.False(t, receives("$PEPE"))
+	assert.Contains(t, reply("/unsubscribe_ticker", "GRUT"), "at least one ticker")
+
+	assert.Contains(t, reply("/subscribe_ticker", "all"), "all tickers")
+	assert.True(t, receives("$ANY"))
+	assert.Contains(t, reply("/subscribe_ticker", "PEPE"), "$PEPE")
+	assert.False(t, receives("$ANY"), "subscribing to a ticker ends the all subscription")
+
+	t.Run("Fresh detections reach the subscribed chats only", func(t *testing.T) {
+		bot.chatIDs[5] = true
+		bot.chatIDs[6] = true
+		require.NoError(t, db.SaveChatSettings(&ChatSettingsModel{ChatID: 6, Ticker: "GRUT"}))
+
+		for _, community := range []CommunityModel{{ID: "1111111111", Ticker: "$GRUT"}, {ID: "2222222222", Ticker: "$PEPE"}} {
+			message := twitterapi.NewMessage{TweetID: "t-" + community.ID, Text: "dump it", CommunityID: community.ID, Ticker: community.Ticker}
+			message.Author.ID, message.Author.UserName = "u-"+community.ID, "dumper"
+			fresh := detectFresh(t, db, message)
+			assert.Equal(t, community.Ticker, fresh.Ticker)
+
+			before := len(transport.sentMessages())
+			require.NoError(t, bot.StoreAndBroadcastNotification(fresh))
+			sent := transport.sentMessages()[before:]
+			require.Len(t, sent, 1, community.Ticker)
+			assert.Contains(t, sent[0].Text, "🏘 <b>Community:</b> "+community.Ticker)
+			if community.Ticker == "$PEPE" {
+				assert.Equal(t, int64(5), sent[0].ChatID)
+			} else {
+				assert.Equal(t, int64(6), sent[0].ChatID)
+			}
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// competitorList holds the rival cashtags and accounts configured for the monitored community
+type competitorList struct {
+	Tickers  map[string]bool // upper case with $, e.g. $XYZ
+	Accounts map[string]bool // lower case without @
+}
+
+// loadCompetitorList reads competitor_tickers and competitor_accounts from the environment
+func loadCompetitorList() competitorList {
+	list := competitorList{Tickers: make(map[string]bool), Accounts: make(map[string]bool)}
+	for _, ticker := range strings.Split(os.Getenv(ENV_COMPETITOR_TICKERS), ",") {
+		ticker = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(ticker), "$"))
+		if ticker != "" {
+			list.Tickers["$"+ticker] = true
+		}
+	}
+	for _, account := range strings.Split(os.Getenv(ENV_COMPETITOR_ACCOUNTS), ",") {
+		account = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(account), "@"))
+		if account != "" {
+			list.Accounts[account] = true
+		}
+	}
+	return list
+}
+
+// findPromotions returns the configured competitors mentioned in texts, in order of first appearance
+func (c competitorList) findPromotions(texts ...string) []string {
+	var promotions []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, cashtag := range cashtagRegex.FindAllString(text, -1) {
+			cashtag = strings.ToUpper(cashtag)
+			if c.Tickers[cashtag] && !seen[cashtag] {
+				seen[cashtag] = true
+				promotions = append(promotions, cashtag)
+			}
+		}
+		for _, mention := range mentionRegex.FindAllString(text, -1) {
+			account := strings.ToLower(strings.TrimPrefix(mention, "@"))
+			if c.Accounts[account] && !seen["@"+account] {
+				seen["@"+account] = true
+				promotions = append(promotions, "@"+account)
+			}
+		}
+	}
+	return promotions
+}
+
+// findCompetitorPromotions checks the analyzed reply, the user's ticker history and profile for configured competitors
+func findCompetitorPromotions(messageText string, mentions *UserTickerMentionsData, profile *UserProfileData) []string {
+	texts := []string{messageText}
+	if mentions != nil {
+		for _, message := range mentions.UserMessages {
+			texts = append(texts, message.Text)
+		}
+	}
+	if profile != nil {
+		texts = append(texts, profile.Bio)
+		texts = append(texts, profile.PinnedTweets...)
+	}
+	return loadCompetitorList().findPromotions(texts...)
+}
+
+// prepareCompetitorPromotionMessage tells the second step which competitors the user promotes
+func prepareCompetitorPromotionMessage(promotions []string) ClaudeMessage {
+	return ClaudeMessage{
+		Role:    ROLE_USER,
+		Content: fmt.Sprintf("COMPETITOR PROMOTION SIGNAL: the user promotes competitor projects %s. FUD about our ticker combined with shilling a rival is a strong sign of a coordinated attack.", strings.Join(promotions, ", ")),
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCompetitorPromotions(t *testing.T) {
+	t.Setenv(ENV_COMPETITOR_TICKERS, "xyz, $ABC")
+	t.Setenv(ENV_COMPETITOR_ACCOUNTS, "@XyzProject")
+
+	mentions := &UserTickerMentionsData{UserMessages: []UserMessageWithReplies{
+		{Text: "$DOGE is finished, rotate into $xyz before it's too late"},
+		{Text: "follow @xyzproject for real alpha, $XYZ to the moon"},
+	}}
+	profile := &UserProfileData{Bio: "early $ABC holder", PinnedTweets: []string{"$SOL and $BTC only"}}
+
+	promotions := findCompetitorPromotions("dev is dumping again", mentions, profile)
+	assert.Equal(t, []string{"$XYZ", "@xyzproject", "$ABC"}, promotions)
+
+	assert.Empty(t, findCompetitorPromotions("$SOL looks good", nil, nil))
+}
@@ -9,8 +9,47 @@ import (
 	"time"
 )
 
+const IMPORT_PROGRESS_ROWS = 200 // rows between progress reports within a step
+
+// Import steps reported to progress callbacks
+const (
+	IMPORT_STEP_PARSING   = "Parsing rows"
+	IMPORT_STEP_ORIGINALS = "Importing original tweets"
+	IMPORT_STEP_REPLIES   = "Importing replies"
+	IMPORT_STEP_REMAINING = "Importing remaining replies"
+)
+
 type CSVImporter struct {
-	dbService *DatabaseService
+	dbService     *DatabaseService
+	progress      func(ImportProgress)
+	startedAt     time.Time
+	backfillApi   TwitterAPI // fetches missing parents of orphaned replies when set
+	backfillLimit int
+}
+
+// ImportProgress is reported while an import runs. Processed counts the rows that are settled:
+// imported, already stored, or given up on.
+type ImportProgress struct {
+	Step      string
+	Processed int
+	Total     int
+	Elapsed   time.Duration
+}
+
+// Percent returns the share of settled rows, 0 to 100
+func (p ImportProgress) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Processed) * 100 / float64(p.Total)
+}
+
+// ETA extrapolates the remaining time from the rate so far, 0 when nothing is settled yet
+func (p ImportProgress) ETA() time.Duration {
+	if p.Processed == 0 || p.Processed >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Elapsed) / float64(p.Processed) * float64(p.Total-p.Processed)).Round(time.Second)
 }
 
 type CSVTweetData struct {
@@ -29,50 +68,86 @@ func NewCSVImporter(dbService *DatabaseService) *CSVImporter {
 	}
 }
 
+// OnProgress sets a callback called at every step and every IMPORT_PROGRESS_ROWS rows
+func (c *CSVImporter) OnProgress(progress func(ImportProgress)) {
+	c.progress = progress
+}
+
+func (c *CSVImporter) reportProgress(step string, processed int, total int) {
+	if c.progress == nil {
+		return
+	}
+	c.progress(ImportProgress{Step: step, Processed: min(processed, total), Total: total, Elapsed: time.Since(c.startedAt)})
+}
+
 func (c *CSVImporter) ImportCSV(csvFilePath string) (*ImportResult, error) {
+	c.startedAt = time.Now()
+	tweetsData, rowErrors, err := c.parseCSV(csvFilePath)
+	if err != nil {
+		return nil, err
+	}
+	logImportRowErrors(rowErrors)
+	return c.ImportTweets(tweetsData), nil
+}
+
+// parseCSV reads the tweets of a community export, rows with missing columns are returned as row errors
+func (c *CSVImporter) parseCSV(csvFilePath string) ([]CSVTweetData, []string, error) {
 	if _, err := os.Stat(csvFilePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("CSV file not found: %s", csvFilePath)
+		return nil, nil, fmt.Errorf("CSV file not found: %s", csvFilePath)
 	}
 
 	file, err := os.Open(csvFilePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open CSV file: %w", err)
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// Short rows are reported as row errors rather than failing the whole file
+	reader.FieldsPerRecord = -1
 	records, err := reader.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
+		return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
 	}
 
 	if len(records) == 0 {
-		return nil, fmt.Errorf("CSV file is empty")
+		return nil, nil, fmt.Errorf("CSV file is empty")
 	}
 
 	header := records[0]
 	columnMap := c.mapColumns(header)
 
 	if err := c.validateColumns(columnMap); err != nil {
-		return nil, fmt.Errorf("CSV validation failed: %w", err)
+		return nil, nil, fmt.Errorf("CSV validation failed: %w", err)
 	}
 
+	c.reportProgress(IMPORT_STEP_PARSING, 0, len(records)-1)
 	tweetsData := []CSVTweetData{}
+	var rowErrors []string
 	for i, record := range records[1:] {
 		if len(record) < len(header) {
+			// Rows are numbered as in a spreadsheet, the header is row 1
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: %d columns, expected %d", i+2, len(record), len(header)))
 			continue
 		}
 
-		replyCount, _ := strconv.Atoi(record[columnMap["reply_count"]])
+		// reply_count and reply_to_id are optional, a missing column must not read column 0
+		field := func(name string) string {
+			if index, ok := columnMap[name]; ok {
+				return record[index]
+			}
+			return ""
+		}
+		replyCount, _ := strconv.Atoi(field("reply_count"))
 
 		tweetData := CSVTweetData{
-			AuthorUsername: record[columnMap["author_username"]],
-			TweetID:        record[columnMap["tweet_id"]],
-			AuthorID:       record[columnMap["author_id"]],
-			Date:           record[columnMap["date"]],
+			AuthorUsername: field("author_username"),
+			TweetID:        field("tweet_id"),
+			AuthorID:       field("author_id"),
+			Date:           field("date"),
 			ReplyCount:     replyCount,
-			ReplyToID:      record[columnMap["reply_to_id"]],
-			Text:           record[columnMap["text"]],
+			ReplyToID:      field("reply_to_id"),
+			Text:           field("text"),
 		}
 
 		tweetsData = append(tweetsData, tweetData)
@@ -82,31 +157,53 @@ func (c *CSVImporter) ImportCSV(csvFilePath string) (*ImportResult, error) {
 		}
 	}
 
+	return tweetsData, rowErrors, nil
+}
+
+// ImportTweets stores parsed tweets of any format, originals first and then replies whose parent is
+// stored, skipping tweets that already exist. Replies whose parent never shows up are skipped.
+func (c *CSVImporter) ImportTweets(tweetsData []CSVTweetData) *ImportResult {
+	if c.startedAt.IsZero() {
+		c.startedAt = time.Now()
+	}
 	fmt.Printf("Found %d tweets to import\n", len(tweetsData))
 
 	result := &ImportResult{}
+	total := len(tweetsData)
+	settled := 0
 
 	fmt.Println("Step 1: Importing original tweets...")
-	for _, tweetData := range tweetsData {
+	c.reportProgress(IMPORT_STEP_ORIGINALS, settled, total)
+	for i, tweetData := range tweetsData {
 		if tweetData.ReplyToID == "" {
+			settled++
 			if c.importTweet(tweetData, "") {
 				result.OriginalTweets++
 			}
 		}
+		if (i+1)%IMPORT_PROGRESS_ROWS == 0 {
+			c.reportProgress(IMPORT_STEP_ORIGINALS, settled, total)
+		}
 	}
 
 	fmt.Println("Step 2: Importing replies to existing tweets...")
-	for _, tweetData := range tweetsData {
+	c.reportProgress(IMPORT_STEP_REPLIES, settled, total)
+	for i, tweetData := range tweetsData {
 		if tweetData.ReplyToID != "" {
 			if c.dbService.TweetExists(tweetData.ReplyToID) {
+				settled++
 				if c.importTweet(tweetData, tweetData.ReplyToID) {
 					result.ReplyTweets++
 				}
 			}
 		}
+		if (i+1)%IMPORT_PROGRESS_ROWS == 0 {
+			c.reportProgress(IMPORT_STEP_REPLIES, settled, total)
+		}
 	}
 
 	fmt.Println("Step 3: Importing remaining tweets...")
+	c.reportProgress(IMPORT_STEP_REMAINING, settled, total)
 	remainingTweets := []CSVTweetData{}
 
 	for _, tweetData := range tweetsData {
@@ -115,8 +212,27 @@ func (c *CSVImporter) ImportCSV(csvFilePath string) (*ImportResult, error) {
 		}
 	}
 
+	var imported int
+	imported, remainingTweets = c.importLinkable(remainingTweets, IMPORT_STEP_REMAINING, &settled, total)
+	result.RemainingTweets += imported
+
+	if len(remainingTweets) > 0 && c.backfillApi != nil {
+		remainingTweets = c.backfillParents(remainingTweets, result, &settled, total)
+	}
+
+	result.SkippedTweets = len(remainingTweets)
+	c.reportProgress(IMPORT_STEP_REMAINING, total, total)
+	result.TotalProcessed = result.OriginalTweets + result.ReplyTweets + result.RemainingTweets + result.ResolvedOrphans
+
+	return result
+}
+
+// importLinkable imports the replies whose parent is stored, again and again so chains of replies
+// link up, and returns how many were imported and the ones still missing their parent
+func (c *CSVImporter) importLinkable(remainingTweets []CSVTweetData, step string, settled *int, total int) (int, []CSVTweetData) {
 	maxIterations := 10
 	iteration := 0
+	imported := 0
 
 	for len(remainingTweets) > 0 && iteration < maxIterations {
 		iteration++
@@ -125,28 +241,28 @@ func (c *CSVImporter) ImportCSV(csvFilePath string) (*ImportResult, error) {
 		importedThisRound := 0
 		newRemaining := []CSVTweetData{}
 
-		for _, tweetData := range remainingTweets {
+		for i, tweetData := range remainingTweets {
 			if c.dbService.TweetExists(tweetData.ReplyToID) {
+				*settled++
 				if c.importTweet(tweetData, tweetData.ReplyToID) {
 					importedThisRound++
-					result.RemainingTweets++
 				}
 			} else {
 				newRemaining = append(newRemaining, tweetData)
 			}
+			if (i+1)%IMPORT_PROGRESS_ROWS == 0 {
+				c.reportProgress(step, *settled, total)
+			}
 		}
 
 		remainingTweets = newRemaining
+		imported += importedThisRound
 
 		if importedThisRound == 0 {
 			break
 		}
 	}
-
-	result.SkippedTweets = len(remainingTweets)
-	result.TotalProcessed = result.OriginalTweets + result.ReplyTweets + result.RemainingTweets
-
-	return result, nil
+	return imported, remainingTweets
 }
 
 func (c *CSVImporter) mapColumns(header []string) map[string]int {
@@ -187,24 +303,8 @@ func (c *CSVImporter) validateColumns(columnMap map[string]int) error {
 	return nil
 }
 
+// importTweet stores a tweet of the file and reports whether it is new
 func (c *CSVImporter) importTweet(tweetData CSVTweetData, replyToID string) bool {
-	if c.dbService.TweetExists(tweetData.TweetID) {
-		return false
-	}
-
-	if !c.dbService.UserExists(tweetData.AuthorID) {
-		user := UserModel{
-			ID:       tweetData.AuthorID,
-			Username: tweetData.AuthorUsername,
-			Name:     tweetData.AuthorUsername,
-		}
-		err := c.dbService.SaveUser(user)
-		if err != nil {
-			fmt.Printf("Error saving user %s: %v\n", tweetData.AuthorUsername, err)
-			return false
-		}
-	}
-
 	createdAt, err := c.parseDate(tweetData.Date)
 	if err != nil {
 		fmt.Printf("Error parsing date %s: %v\n", tweetData.Date, err)
@@ -222,6 +322,27 @@ func (c *CSVImporter) importTweet(tweetData CSVTweetData, replyToID string) bool
 		SourceType:  TWEET_SOURCE_COMMUNITY,
 	}
 
+	if c.dbService.TweetExists(tweetData.TweetID) {
+		// The stored copy is merged with the imported one, which can fill fields another source left empty
+		if err := c.dbService.SaveTweet(tweet); err != nil {
+			fmt.Printf("Error merging tweet %s: %v\n", tweetData.TweetID, err)
+		}
+		return false
+	}
+
+	if !c.dbService.UserExists(tweetData.AuthorID) {
+		user := UserModel{
+			ID:       tweetData.AuthorID,
+			Username: tweetData.AuthorUsername,
+			Name:     tweetData.AuthorUsername,
+		}
+		err := c.dbService.SaveUser(user)
+		if err != nil {
+			fmt.Printf("Error saving user %s: %v\n", tweetData.AuthorUsername, err)
+			return false
+		}
+	}
+
 	err = c.dbService.SaveTweet(tweet)
 	if err != nil {
 		fmt.Printf("Error saving tweet %s: %v\n", tweetData.TweetID, err)
@@ -249,14 +370,24 @@ func (c *CSVImporter) parseDate(dateStr string) (time.Time, error) {
 }
 
 type ImportResult struct {
-	OriginalTweets  int
-	ReplyTweets     int
-	RemainingTweets int
-	SkippedTweets   int
-	TotalProcessed  int
+	OriginalTweets    int
+	ReplyTweets       int
+	RemainingTweets   int
+	SkippedTweets     int
+	TotalProcessed    int
+	BackfilledParents int    // missing parents fetched from the provider
+	ResolvedOrphans   int    // replies imported thanks to the fetched parents
+	BackfillError     string // why the backfill stopped early, if it did
 }
 
 func (r *ImportResult) String() string {
-	return fmt.Sprintf("Import Result:\n  Original tweets: %d\n  Reply tweets: %d\n  Remaining tweets: %d\n  Skipped tweets: %d\n  Total processed: %d",
+	text := fmt.Sprintf("Import Result:\n  Original tweets: %d\n  Reply tweets: %d\n  Remaining tweets: %d\n  Skipped tweets: %d\n  Total processed: %d",
 		r.OriginalTweets, r.ReplyTweets, r.RemainingTweets, r.SkippedTweets, r.TotalProcessed)
+	if r.BackfilledParents > 0 || r.BackfillError != "" {
+		text += fmt.Sprintf("\n  Backfilled parents: %d\n  Resolved orphans: %d", r.BackfilledParents, r.ResolvedOrphans)
+	}
+	if r.BackfillError != "" {
+		text += "\n  Backfill stopped: " + r.BackfillError
+	}
+	return text
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeBenchmarkCSV writes a community export with the given number of posts, each with repliesPerPost replies
+func writeBenchmarkCSV(tb testing.TB, dir string, prefix string, posts int, repliesPerPost int) string {
+	path := filepath.Join(dir, prefix+".csv")
+	file, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"message_author", "message_number", "message_date", "reply_count", "reply_to_tweet", "message_text", "author_id", "tweet_id"})
+
+	number := 0
+	for p := 0; p < posts; p++ {
+		postID := fmt.Sprintf("%s_post_%d", prefix, p)
+		number++
+		writer.Write([]string{fmt.Sprintf("author_%d", p%50), strconv.Itoa(number), "Mon Jan 02 15:04:05 -0700 2006", strconv.Itoa(repliesPerPost), "", "Post about $RODF", fmt.Sprintf("uid_%d", p%50), postID})
+		for r := 0; r < repliesPerPost; r++ {
+			number++
+			writer.Write([]string{fmt.Sprintf("author_%d", r%50), strconv.Itoa(number), "Mon Jan 02 15:04:05 -0700 2006", "0", postID, "Reply text", fmt.Sprintf("uid_%d", r%50), fmt.Sprintf("%s_reply_%d_%d", prefix, p, r)})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestCSVImporter_ImportCSV(t *testing.T) {
+	db := setupTestDB(t)
+	path := writeBenchmarkCSV(t, t.TempDir(), "test", 5, 3)
+
+	result, err := NewCSVImporter(db).ImportCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OriginalTweets != 5 || result.ReplyTweets != 15 || result.SkippedTweets != 0 {
+		t.Fatalf("unexpected import result: %s", result.String())
+	}
+}
+
+func TestCSVImporter_Progress(t *testing.T) {
+	db := setupTestDB(t)
+	path := writeBenchmarkCSV(t, t.TempDir(), "progress", 50, 9)
+
+	importer := NewCSVImporter(db)
+	var reports []ImportProgress
+	importer.OnProgress(func(progress ImportProgress) {
+		reports = append(reports, progress)
+	})
+	if _, err := importer.ImportCSV(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) < 4 {
+		t.Fatalf("expected a report per step and every %d rows, got %d", IMPORT_PROGRESS_ROWS, len(reports))
+	}
+	steps := map[string]bool{}
+	for i, report := range reports {
+		steps[report.Step] = true
+		if report.Total != 500 {
+			t.Fatalf("report %d: total %d, want 500", i, report.Total)
+		}
+		if i > 0 && report.Processed < reports[i-1].Processed {
+			t.Fatalf("report %d: processed went back from %d to %d", i, reports[i-1].Processed, report.Processed)
+		}
+	}
+	for _, step := range []string{IMPORT_STEP_ORIGINALS, IMPORT_STEP_REPLIES, IMPORT_STEP_REMAINING} {
+		if !steps[step] {
+			t.Errorf("step %q was not reported", step)
+		}
+	}
+	if last := reports[len(reports)-1]; last.Processed != last.Total || last.Percent() != 100 {
+		t.Fatalf("last report is not complete: %+v", last)
+	}
+}
+
+func TestImportProgress_ETA(t *testing.T) {
+	progress := ImportProgress{Processed: 250, Total: 1000, Elapsed: 10 * time.Second}
+	if progress.Percent() != 25 {
+		t.Errorf("percent %v, want 25", progress.Percent())
+	}
+	if progress.ETA() != 30*time.Second {
+		t.Errorf("ETA %v, want 30s", progress.ETA())
+	}
+	if (ImportProgress{Total: 1000, Elapsed: time.Second}).ETA() != 0 {
+		t.Error("ETA is unknown before any row is settled")
+	}
+}
+
+// BenchmarkCSVImporter_ImportCSV measures ingestion of 1000 rows (100 posts x 9 replies) into a growing database
+func BenchmarkCSVImporter_ImportCSV(b *testing.B) {
+	db := setupTestDB(b)
+	importer := NewCSVImporter(db)
+	dir := b.TempDir()
+
+	paths := make([]string, b.N)
+	for i := 0; i < b.N; i++ {
+		paths[i] = writeBenchmarkCSV(b, dir, fmt.Sprintf("bench%d", i), 100, 9)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := importer.ImportCSV(paths[i])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(1000*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
@@ -16,9 +16,10 @@ type TweetModel struct {
 	Username      string    `gorm:"column:username;index" json:"username"`
 	InReplyToID   string    `gorm:"column:in_reply_to_id;index" json:"in_reply_to_id,omitempty"`
 	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
-	SourceType    string    `gorm:"column:source_type;index" json:"source_type"`       // "community", "ticker_search", "context", "monitoring"
-	TickerMention string    `gorm:"column:ticker_mention;index" json:"ticker_mention"` // Тикер, если твит получен через поиск
-	SearchQuery   string    `gorm:"column:search_query" json:"search_query,omitempty"` // Оригинальный запрос поиска
+	SourceType    string    `gorm:"column:source_type;index" json:"source_type"`             // "community", "ticker_search", "context", "monitoring"
+	TickerMention string    `gorm:"column:ticker_mention;index" json:"ticker_mention"`       // Тикер, если твит получен через поиск
+	SearchQuery   string    `gorm:"column:search_query" json:"search_query,omitempty"`       // Оригинальный запрос поиска
+	CommunityID   string    `gorm:"column:community_id;index" json:"community_id,omitempty"` // Community the post was monitored in
 }
 
 func (TweetModel) TableName() string {
@@ -34,6 +35,8 @@ type UserModel struct {
 	IsFUD            bool      `gorm:"column:is_fud;default:false" json:"is_fud"`
 	FUDType          string    `gorm:"column:fud_type" json:"fud_type,omitempty"`
 	IsDetailAnalyzed bool      `gorm:"column:is_detail_analyzed;default:false" json:"is_detail_analyzed"` // Has user been through detailed analysis
+	Bio              string    `gorm:"column:bio" json:"bio,omitempty"`
+	PinnedTweetText  string    `gorm:"column:pinned_tweet_text" json:"pinned_tweet_text,omitempty"`
 	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
@@ -55,6 +58,8 @@ type FUDUserModel struct {
 	LastMessageID  string    `gorm:"column:last_message_id" json:"last_message_id"`
 	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+	// Comma-separated competitor cashtags and accounts the user promotes, e.g. "$XYZ,@xyzproject"
+	PromotedCompetitors string `gorm:"column:promoted_competitors" json:"promoted_competitors"`
 }
 
 func (FUDUserModel) TableName() string {
@@ -105,16 +110,30 @@ type UserReply struct {
 // AnalysisTask model for tracking manual analysis progress
 type AnalysisTaskModel struct {
 	gorm.Model
-	ID             string     `gorm:"primaryKey;column:id" json:"id"`                      // Unique task ID
-	Username       string     `gorm:"column:username;index" json:"username"`               // Target username
-	UserID         string     `gorm:"column:user_id;index" json:"user_id"`                 // Target user ID (if found)
-	Status         string     `gorm:"column:status;index" json:"status"`                   // pending, running, completed, failed
-	CurrentStep    string     `gorm:"column:current_step" json:"current_step"`             // Current processing step
-	ProgressText   string     `gorm:"column:progress_text" json:"progress_text"`           // Human readable progress
-	TelegramChatID int64      `gorm:"column:telegram_chat_id" json:"telegram_chat_id"`     // Chat where analysis was requested
-	MessageID      int64      `gorm:"column:message_id" json:"message_id"`                 // Telegram message ID to edit
-	ErrorMessage   string     `gorm:"column:error_message" json:"error_message,omitempty"` // Error details if failed
-	ResultData     string     `gorm:"column:result_data" json:"result_data,omitempty"`     // JSON result of analysis
+	ID             string     `gorm:"primaryKey;column:id" json:"id"`                         // Unique task ID
+	Username       string     `gorm:"column:username;index" json:"username"`                  // Target username
+	UserID         string     `gorm:"column:user_id;index" json:"user_id"`                    // Target user ID (if found)
+	Status         string     `gorm:"column:status;index" json:"status"`                      // pending, running, completed, failed
+	CurrentStep    string     `gorm:"column:current_step" json:"current_step"`                // Current processing step
+	ProgressText   string     `gorm:"column:progress_text" json:"progress_text"`              // Human readable progress
+	TelegramChatID int64      `gorm:"column:telegram_chat_id" json:"telegram_chat_id"`        // Chat where analysis was requested
+	MessageID      int64      `gorm:"column:message_id" json:"message_id"`                    // Telegram message ID to edit
+	DiscordChannel string     `gorm:"column:discord_channel" json:"discord_channel"`          // Discord channel where analysis was requested
+	NotifyRoute    string     `gorm:"column:notify_route" json:"notify_route,omitempty"`      // origin (default), broadcast or chat
+	NotifyChatID   int64      `gorm:"column:notify_chat_id" json:"notify_chat_id"`            // Target chat for the chat route
+	ErrorMessage   string     `gorm:"column:error_message" json:"error_message,omitempty"`    // Error details if failed
+	ErrorCode      string     `gorm:"column:error_code;index" json:"error_code,omitempty"`    // TASK_ERROR_* code of the failure, see classifyTaskError
+	ResultData     string     `gorm:"column:result_data" json:"result_data,omitempty"`        // JSON result of analysis
+	Priority       string     `gorm:"column:priority;default:normal" json:"priority"`         // normal, high (user reports)
+	TweetID        string     `gorm:"column:tweet_id" json:"tweet_id,omitempty"`              // Specific tweet to analyze, if any
+	Kind           string     `gorm:"column:kind;default:single" json:"kind"`                 // single, batch or tweet, picks the processor when the task is resumed
+	IdempotencyKey string     `gorm:"column:idempotency_key;index" json:"idempotency_key"`    // identical requests share one pending or running task
+	Attempts       int        `gorm:"column:attempts" json:"attempts"`                        // times processing was started, including resumes after a restart
+	Retries        int        `gorm:"column:retries;default:0" json:"retries"`                // transient API failures retried while processing
+	RequestSource  string     `gorm:"column:request_source" json:"request_source,omitempty"`  // external tool that submitted the task through /api/signals
+	RequestReason  string     `gorm:"column:request_reason" json:"request_reason,omitempty"`  // why the external tool flagged the target
+	BatchID        string     `gorm:"column:batch_id;index" json:"batch_id,omitempty"`        // /batch_analyze run the task belongs to
+	HistoryWindow  string     `gorm:"column:history_window;default:''" json:"history_window"` // user history the second step was given, see parseHistoryWindow
 	StartedAt      time.Time  `gorm:"column:started_at" json:"started_at"`
 	CompletedAt    *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
 	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
@@ -125,12 +144,55 @@ func (AnalysisTaskModel) TableName() string {
 	return "analysis_tasks"
 }
 
+// AnalysisTaskResult is the ResultData of a completed analysis task
+type AnalysisTaskResult struct {
+	AnalysisComplete bool    `json:"analysis_complete"`
+	IsFUD            bool    `json:"is_fud"`
+	FUDType          string  `json:"fud_type"`
+	FUDProbability   float64 `json:"fud_probability"`
+	UserRiskLevel    string  `json:"user_risk_level"`
+	UserSummary      string  `json:"user_summary"`
+	Timestamp        string  `json:"timestamp"`
+}
+
+// AnalysisBatchModel tracks one /batch_analyze run, so its results can be summarized in a single message
+type AnalysisBatchModel struct {
+	ID          string     `gorm:"primaryKey;column:id" json:"id"`
+	ChatID      int64      `gorm:"column:chat_id;index" json:"chat_id"` // chat that started the batch and receives the summary
+	MessageID   int64      `gorm:"column:message_id" json:"message_id"` // live-edited progress message
+	Total       int        `gorm:"column:total" json:"total"`           // tasks started for this batch
+	Skipped     int        `gorm:"column:skipped" json:"skipped"`       // users already being analyzed by another task
+	Status      string     `gorm:"column:status;index" json:"status"`   // running or completed
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (AnalysisBatchModel) TableName() string {
+	return "analysis_batches"
+}
+
 // Analysis task status constants
 const (
 	ANALYSIS_STATUS_PENDING   = "pending"
 	ANALYSIS_STATUS_RUNNING   = "running"
 	ANALYSIS_STATUS_COMPLETED = "completed"
 	ANALYSIS_STATUS_FAILED    = "failed"
+	ANALYSIS_STATUS_CANCELLED = "cancelled"
+)
+
+// Analysis task kind constants
+const (
+	ANALYSIS_KIND_SINGLE     = "single"
+	ANALYSIS_KIND_BATCH      = "batch"
+	ANALYSIS_KIND_TWEET      = "tweet"      // /analyze_tweet, one exact tweet instead of the user's latest
+	ANALYSIS_KIND_REANALYSIS = "reanalysis" // scheduled re-check of a known FUD user, reports the change of verdict instead of an alert
+)
+
+// Analysis task priority constants
+const (
+	ANALYSIS_PRIORITY_NORMAL = "normal"
+	ANALYSIS_PRIORITY_HIGH   = "high"
 )
 
 // Analysis task step constants
@@ -158,6 +220,7 @@ type CachedAnalysisModel struct {
 	UserSummary    string    `gorm:"column:user_summary" json:"user_summary"`
 	KeyEvidence    string    `gorm:"column:key_evidence" json:"key_evidence"` // JSON array as string
 	DecisionReason string    `gorm:"column:decision_reason" json:"decision_reason"`
+	PromptVersion  string    `gorm:"column:prompt_version" json:"prompt_version,omitempty"` // e.g. "second v3", empty for human verdicts
 	AnalyzedAt     time.Time `gorm:"column:analyzed_at;index" json:"analyzed_at"`
 	ExpiresAt      time.Time `gorm:"column:expires_at;index" json:"expires_at"`
 	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
@@ -189,3 +252,612 @@ type UserTickerOpinionModel struct {
 func (UserTickerOpinionModel) TableName() string {
 	return "user_ticker_opinions"
 }
+
+// ChatSettings model for per-chat bot preferences
+type ChatSettingsModel struct {
+	gorm.Model
+	ChatID     int64  `gorm:"column:chat_id;uniqueIndex" json:"chat_id"`
+	Verbosity  string `gorm:"column:verbosity;default:normal" json:"verbosity"`       // compact, normal, detailed
+	SilentUpTo string `gorm:"column:silent_up_to;default:medium" json:"silent_up_to"` // alerts at or below this severity are delivered without sound
+	Redaction  string `gorm:"column:redaction;default:full" json:"redaction"`         // redaction profile, set by admins for partially trusted chats
+	Role       string `gorm:"column:role;default:member" json:"role"`                 // member, or guest for read-only stakeholder chats, see /chatrole
+	// Onboarding answers and state
+	Ticker           string     `gorm:"column:ticker" json:"ticker"`
+	MinSeverity      string     `gorm:"column:min_severity;default:low" json:"min_severity"` // alerts below this severity are not delivered
+	Timezone         string     `gorm:"column:timezone;default:UTC" json:"timezone"`         // IANA name used for alert timestamps
+	OnboardingStep   string     `gorm:"column:onboarding_step" json:"onboarding_step,omitempty"`
+	OnboardingUserID int64      `gorm:"column:onboarding_user_id" json:"onboarding_user_id,omitempty"` // user who started onboarding and must confirm it
+	OnboardedAt      *time.Time `gorm:"column:onboarded_at" json:"onboarded_at,omitempty"`
+	ApprovalStatus   string     `gorm:"column:approval_status;index" json:"approval_status,omitempty"` // pending, approved, rejected; empty when approval mode is off
+	ChatTitle        string     `gorm:"column:chat_title" json:"chat_title,omitempty"`
+	// Set by /notify remove and clear: the chat cannot onboard itself back until an admin runs /notify add
+	RemovedAt *time.Time `gorm:"column:removed_at" json:"removed_at,omitempty"`
+	RemovedBy string     `gorm:"column:removed_by" json:"removed_by,omitempty"`
+	// Scheduled summary subscription, see /subscribe
+	Digest       string     `gorm:"column:digest;index" json:"digest,omitempty"` // daily or weekly, empty when not subscribed
+	LastDigestAt *time.Time `gorm:"column:last_digest_at" json:"last_digest_at,omitempty"`
+	// Tickers whose community alerts the chat receives, see /subscribe_ticker: comma separated,
+	// "*" for all, empty to follow the onboarding ticker
+	Tickers string `gorm:"column:tickers" json:"tickers,omitempty"`
+	// Daily window in the chat timezone, e.g. 23:00-07:00, when non-critical alerts are held, see /quiet
+	QuietHours string `gorm:"column:quiet_hours" json:"quiet_hours,omitempty"`
+	// Forum topics of supergroups with topics, 0 posts to the General topic, see /topic
+	AlertsTopicID  int64 `gorm:"column:alerts_topic_id" json:"alerts_topic_id,omitempty"`   // alerts and the summaries of held alerts
+	ReportsTopicID int64 `gorm:"column:reports_topic_id" json:"reports_topic_id,omitempty"` // scheduled digests
+}
+
+func (ChatSettingsModel) TableName() string {
+	return "chat_settings"
+}
+
+// Digest subscription periods
+const (
+	DIGEST_DAILY  = "daily"
+	DIGEST_WEEKLY = "weekly"
+)
+
+// Chat approval status constants
+const (
+	CHAT_APPROVAL_PENDING  = "pending"
+	CHAT_APPROVAL_APPROVED = "approved"
+	CHAT_APPROVAL_REJECTED = "rejected"
+)
+
+// Chat onboarding step constants, an empty step means onboarding is not running
+const (
+	ONBOARDING_STEP_TICKER   = "ticker"
+	ONBOARDING_STEP_SEVERITY = "severity"
+	ONBOARDING_STEP_TIMEZONE = "timezone"
+	ONBOARDING_STEP_CONFIRM  = "confirm"
+)
+
+// Notification model for persisting alerts behind /detail_<id> across restarts
+type NotificationModel struct {
+	gorm.Model
+	NotificationID string    `gorm:"column:notification_id;uniqueIndex" json:"notification_id"`
+	FUDUserID      string    `gorm:"column:fud_user_id;index" json:"fud_user_id"`
+	FUDUsername    string    `gorm:"column:fud_username;index" json:"fud_username"`
+	AlertSeverity  string    `gorm:"column:alert_severity" json:"alert_severity"`
+	Payload        string    `gorm:"column:payload" json:"payload"`                     // FUDAlertNotification as JSON
+	FormatVersion  int       `gorm:"column:format_version;index" json:"format_version"` // ALERT_FORMAT_VERSION the alert was rendered with
+	ExpiresAt      time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+	// Handling by the moderation team, see /ack_, /assign_ and /openalerts
+	State      string     `gorm:"column:state;index;default:open" json:"state"` // open, acked or resolved
+	AssignedTo string     `gorm:"column:assigned_to;index" json:"assigned_to,omitempty"`
+	AckedBy    string     `gorm:"column:acked_by" json:"acked_by,omitempty"`
+	AckedAt    *time.Time `gorm:"column:acked_at" json:"acked_at,omitempty"`
+	ResolvedBy string     `gorm:"column:resolved_by" json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `gorm:"column:resolved_at" json:"resolved_at,omitempty"`
+}
+
+func (NotificationModel) TableName() string {
+	return "notifications"
+}
+
+// AlertLogModel records every alert sent. Unlike notifications it never expires, the prior alerts of a user are read from it.
+type AlertLogModel struct {
+	ID             uint      `gorm:"primaryKey;column:id" json:"id"`
+	NotificationID string    `gorm:"column:notification_id;uniqueIndex" json:"notification_id"`
+	FUDUserID      string    `gorm:"column:fud_user_id;index" json:"fud_user_id"`
+	FUDUsername    string    `gorm:"column:fud_username" json:"fud_username"`
+	AlertSeverity  string    `gorm:"column:alert_severity" json:"alert_severity"`
+	FUDType        string    `gorm:"column:fud_type" json:"fud_type"`
+	CreatedAt      time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (AlertLogModel) TableName() string {
+	return "alert_log"
+}
+
+// UserReport model for community tips submitted with /report
+type UserReportModel struct {
+	gorm.Model
+	ReporterChatID int64  `gorm:"column:reporter_chat_id;index" json:"reporter_chat_id"`
+	ReporterUserID int64  `gorm:"column:reporter_user_id" json:"reporter_user_id"`
+	ReporterName   string `gorm:"column:reporter_name" json:"reporter_name"`
+	TargetUsername string `gorm:"column:target_username;index" json:"target_username"`
+	TweetID        string `gorm:"column:tweet_id" json:"tweet_id,omitempty"`
+	Reason         string `gorm:"column:reason" json:"reason"`
+	TaskID         string `gorm:"column:task_id;index" json:"task_id"`
+}
+
+func (UserReportModel) TableName() string {
+	return "user_reports"
+}
+
+// AmplificationModel records an account that retweeted a flagged FUD post
+type AmplificationModel struct {
+	gorm.Model
+	TweetID           string `gorm:"column:tweet_id;uniqueIndex:idx_amplification" json:"tweet_id"`
+	AmplifierID       string `gorm:"column:amplifier_id;uniqueIndex:idx_amplification" json:"amplifier_id"`
+	AmplifierUsername string `gorm:"column:amplifier_username" json:"amplifier_username"`
+	FUDUserID         string `gorm:"column:fud_user_id;index" json:"fud_user_id"` // author of the flagged post
+}
+
+func (AmplificationModel) TableName() string {
+	return "amplifications"
+}
+
+// TweetRevisionModel stores the text of a flagged tweet each time it is seen to change.
+// Revision 0 is the text at the time it was flagged.
+type TweetRevisionModel struct {
+	gorm.Model
+	TweetID   string    `gorm:"column:tweet_id;uniqueIndex:idx_tweet_revision" json:"tweet_id"`
+	Revision  int       `gorm:"column:revision;uniqueIndex:idx_tweet_revision" json:"revision"`
+	Text      string    `gorm:"column:text" json:"text"`
+	FetchedAt time.Time `gorm:"column:fetched_at" json:"fetched_at"`
+}
+
+func (TweetRevisionModel) TableName() string {
+	return "tweet_revisions"
+}
+
+// UsageStatModel is a per-day counter behind /usage, e.g. LLM calls and tokens of one pipeline step
+type UsageStatModel struct {
+	gorm.Model
+	Day          string `gorm:"column:day;uniqueIndex:idx_usage_stat" json:"day"` // YYYY-MM-DD, UTC
+	Category     string `gorm:"column:category;uniqueIndex:idx_usage_stat" json:"category"`
+	Name         string `gorm:"column:name;uniqueIndex:idx_usage_stat" json:"name"`
+	Count        int64  `gorm:"column:count" json:"count"`
+	InputTokens  int64  `gorm:"column:input_tokens" json:"input_tokens"`
+	OutputTokens int64  `gorm:"column:output_tokens" json:"output_tokens"`
+}
+
+func (UsageStatModel) TableName() string {
+	return "usage_stats"
+}
+
+// UserUsageModel is a per-day counter of the LLM calls and tokens spent analyzing one user, behind /costs
+type UserUsageModel struct {
+	gorm.Model
+	Day          string `gorm:"column:day;uniqueIndex:idx_user_usage" json:"day"` // YYYY-MM-DD, UTC
+	UserID       string `gorm:"column:user_id;uniqueIndex:idx_user_usage" json:"user_id"`
+	Step         string `gorm:"column:step;uniqueIndex:idx_user_usage" json:"step"`
+	Username     string `gorm:"column:username;index" json:"username"`
+	Calls        int64  `gorm:"column:calls" json:"calls"`
+	InputTokens  int64  `gorm:"column:input_tokens" json:"input_tokens"`
+	OutputTokens int64  `gorm:"column:output_tokens" json:"output_tokens"`
+}
+
+func (UserUsageModel) TableName() string {
+	return "user_usage_stats"
+}
+
+// AnalysisCostModel is one LLM call of an analysis with its price, behind /costs and the daily budget
+type AnalysisCostModel struct {
+	gorm.Model
+	Day          string  `gorm:"column:day;index" json:"day"` // YYYY-MM-DD, UTC
+	UserID       string  `gorm:"column:user_id;index" json:"user_id"`
+	Username     string  `gorm:"column:username" json:"username"`
+	CommandType  string  `gorm:"column:command_type;index" json:"command_type"` // monitoring, analyze, batch, report or api
+	Step         string  `gorm:"column:step" json:"step"`
+	TaskID       string  `gorm:"column:task_id" json:"task_id,omitempty"`
+	LLMModel     string  `gorm:"column:llm_model" json:"llm_model"`
+	InputTokens  int64   `gorm:"column:input_tokens" json:"input_tokens"`
+	OutputTokens int64   `gorm:"column:output_tokens" json:"output_tokens"`
+	CostUSD      float64 `gorm:"column:cost_usd" json:"cost_usd"`
+}
+
+func (AnalysisCostModel) TableName() string {
+	return "analysis_costs"
+}
+
+// DeferredAnalysisModel is a monitoring message waiting for the second step until the daily budget resets
+type DeferredAnalysisModel struct {
+	gorm.Model
+	TweetID  string `gorm:"column:tweet_id;uniqueIndex" json:"tweet_id"`
+	UserID   string `gorm:"column:user_id;index" json:"user_id"`
+	Username string `gorm:"column:username" json:"username"`
+	Payload  string `gorm:"column:payload" json:"payload"` // the message as JSON
+}
+
+func (DeferredAnalysisModel) TableName() string {
+	return "deferred_analyses"
+}
+
+// NotificationChatModel records where each chat on the notification list came from.
+// Removed chats keep their row with Active false so a restart does not bring them back.
+type NotificationChatModel struct {
+	gorm.Model
+	ChatID        int64      `gorm:"column:chat_id;uniqueIndex" json:"chat_id"`
+	Active        bool       `gorm:"column:active;index" json:"active"`
+	Source        string     `gorm:"column:source" json:"source"`     // notify, onboarding, approval, config or users.txt
+	AddedBy       string     `gorm:"column:added_by" json:"added_by"` // @username, user ID or "migration"
+	AddedFromChat int64      `gorm:"column:added_from_chat" json:"added_from_chat,omitempty"`
+	AddedAt       time.Time  `gorm:"column:added_at" json:"added_at"`
+	Note          string     `gorm:"column:note" json:"note,omitempty"`
+	RemovedBy     string     `gorm:"column:removed_by" json:"removed_by,omitempty"`
+	RemovedAt     *time.Time `gorm:"column:removed_at" json:"removed_at,omitempty"`
+}
+
+func (NotificationChatModel) TableName() string {
+	return "notification_chats"
+}
+
+// CommunityModel is an X community monitored by this process, with the ticker it is about.
+// Removed communities keep their row with Active false, their tweets stay in the database.
+type CommunityModel struct {
+	ID            string    `gorm:"primaryKey;column:id" json:"id"` // X community ID
+	Ticker        string    `gorm:"column:ticker;index" json:"ticker"`
+	PromptContext string    `gorm:"column:prompt_context" json:"prompt_context,omitempty"`   // appended to the analysis prompts of its messages
+	NotifyChatIDs string    `gorm:"column:notify_chat_ids" json:"notify_chat_ids,omitempty"` // comma separated chats that get its alerts, empty routes by chat ticker
+	Active        bool      `gorm:"column:active;index" json:"active"`
+	AddedBy       string    `gorm:"column:added_by" json:"added_by"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (CommunityModel) TableName() string {
+	return "communities"
+}
+
+// ChatAliasModel is a per-chat command shortcut, e.g. /f for /fudlist
+type ChatAliasModel struct {
+	gorm.Model
+	ChatID    int64  `gorm:"column:chat_id;uniqueIndex:idx_chat_alias" json:"chat_id"`
+	Alias     string `gorm:"column:alias;uniqueIndex:idx_chat_alias" json:"alias"` // without the leading slash
+	Expansion string `gorm:"column:expansion" json:"expansion"`                    // command and arguments it stands for, without the leading slash
+	CreatedBy string `gorm:"column:created_by" json:"created_by"`
+}
+
+func (ChatAliasModel) TableName() string {
+	return "chat_aliases"
+}
+
+// TrustedUserModel is a whitelisted account, team members and known community leaders are never analyzed or flagged
+type TrustedUserModel struct {
+	gorm.Model
+	Username      string     `gorm:"column:username;uniqueIndex" json:"username"` // lowercase, without @
+	UserID        string     `gorm:"column:user_id;index" json:"user_id,omitempty"`
+	Active        bool       `gorm:"column:active;index" json:"active"`
+	AddedBy       string     `gorm:"column:added_by" json:"added_by"`
+	AddedFromChat int64      `gorm:"column:added_from_chat" json:"added_from_chat,omitempty"`
+	AddedAt       time.Time  `gorm:"column:added_at" json:"added_at"`
+	Note          string     `gorm:"column:note" json:"note,omitempty"`
+	RemovedBy     string     `gorm:"column:removed_by" json:"removed_by,omitempty"`
+	RemovedAt     *time.Time `gorm:"column:removed_at" json:"removed_at,omitempty"`
+}
+
+func (TrustedUserModel) TableName() string {
+	return "trusted_users"
+}
+
+// FederatedIndicatorModel is a confirmed FUD account reported by a federated deployment. The account
+// is only known by its keyed hash and the message by its narrative fingerprint.
+type FederatedIndicatorModel struct {
+	gorm.Model
+	Peer        string    `gorm:"column:peer;uniqueIndex:idx_federated_indicator" json:"peer"`
+	AccountHash string    `gorm:"column:account_hash;uniqueIndex:idx_federated_indicator;index" json:"account_hash"`
+	Fingerprint string    `gorm:"column:fingerprint;uniqueIndex:idx_federated_indicator" json:"fingerprint,omitempty"`
+	FUDType     string    `gorm:"column:fud_type" json:"fud_type"`
+	Severity    string    `gorm:"column:severity" json:"severity"`
+	DetectedAt  time.Time `gorm:"column:detected_at" json:"detected_at"`
+}
+
+func (FederatedIndicatorModel) TableName() string {
+	return "federated_indicators"
+}
+
+// LabeledVerdictModel is a human verdict on a user, kept with what the model had concluded and the
+// messages it was based on, as a labeled example for prompt tuning and evaluation
+type LabeledVerdictModel struct {
+	gorm.Model
+	UserID           string  `gorm:"column:user_id;index" json:"user_id"`
+	Username         string  `gorm:"column:username" json:"username"`
+	Label            string  `gorm:"column:label;index" json:"label"` // "clean" or "fud"
+	FUDType          string  `gorm:"column:fud_type" json:"fud_type,omitempty"`
+	Note             string  `gorm:"column:note" json:"note,omitempty"`
+	LabeledBy        string  `gorm:"column:labeled_by" json:"labeled_by"`
+	LabeledFromChat  int64   `gorm:"column:labeled_from_chat" json:"labeled_from_chat"`
+	ModelAnalyzed    bool    `gorm:"column:model_analyzed" json:"model_analyzed"` // false when there was no analysis to compare with
+	ModelIsFUD       bool    `gorm:"column:model_is_fud" json:"model_is_fud"`
+	ModelFUDType     string  `gorm:"column:model_fud_type" json:"model_fud_type,omitempty"`
+	ModelProbability float64 `gorm:"column:model_probability" json:"model_probability"`
+	ModelReason      string  `gorm:"column:model_reason" json:"model_reason,omitempty"`
+	Messages         string  `gorm:"column:messages" json:"messages"`                          // JSON array of the latest stored messages
+	Dataset          string  `gorm:"column:dataset;index;default:''" json:"dataset,omitempty"` // empty for verdicts of this deployment, else the /eval import name
+}
+
+func (LabeledVerdictModel) TableName() string {
+	return "labeled_verdicts"
+}
+
+// PromptVersionModel is one version of the system prompt of an analysis step. Exactly one
+// version per step is active, see /prompt.
+type PromptVersionModel struct {
+	gorm.Model
+	Step        string    `gorm:"column:step;uniqueIndex:idx_prompt_version" json:"step"`
+	Version     int       `gorm:"column:version;uniqueIndex:idx_prompt_version" json:"version"`
+	Content     string    `gorm:"column:content" json:"content"`
+	Checksum    string    `gorm:"column:checksum" json:"checksum"`
+	Origin      string    `gorm:"column:origin" json:"origin"` // "file" or "telegram"
+	CreatedBy   string    `gorm:"column:created_by" json:"created_by"`
+	Note        string    `gorm:"column:note" json:"note,omitempty"`
+	Active      bool      `gorm:"column:active;index" json:"active"`
+	ActivatedAt time.Time `gorm:"column:activated_at" json:"activated_at"`
+}
+
+func (PromptVersionModel) TableName() string {
+	return "prompt_versions"
+}
+
+// AutoActionRuleModel runs a follow-up step once a user reaches a number of confirmed detections,
+// for one monitored community or all of them
+type AutoActionRuleModel struct {
+	gorm.Model
+	CommunityID string `gorm:"column:community_id;index" json:"community_id,omitempty"` // empty applies to every community
+	Threshold   int    `gorm:"column:threshold" json:"threshold"`                       // confirmed detections that trigger the action
+	Action      string `gorm:"column:action" json:"action"`                             // "notify", "webhook" or "incident"
+	Target      string `gorm:"column:target" json:"target"`                             // chat ID, webhook URL or incident duration
+	ChatID      int64  `gorm:"column:chat_id" json:"chat_id"`                           // chat the rule was added from, gets incident status and failures
+	CreatedBy   string `gorm:"column:created_by" json:"created_by"`
+}
+
+func (AutoActionRuleModel) TableName() string {
+	return "auto_action_rules"
+}
+
+// AutoActionRunModel records that a rule ran for a user, every rule runs once per user
+type AutoActionRunModel struct {
+	gorm.Model
+	RuleID     uint   `gorm:"column:rule_id;uniqueIndex:idx_auto_action_run" json:"rule_id"`
+	UserID     string `gorm:"column:user_id;uniqueIndex:idx_auto_action_run" json:"user_id"`
+	Username   string `gorm:"column:username" json:"username"`
+	Detections int    `gorm:"column:detections" json:"detections"`
+	Error      string `gorm:"column:error" json:"error,omitempty"`
+}
+
+func (AutoActionRunModel) TableName() string {
+	return "auto_action_runs"
+}
+
+// BlocklistEntryModel is an account listed by a community-maintained blocklist. Entries are replaced
+// source by source on every import and known by their user ID, their username or both.
+type BlocklistEntryModel struct {
+	gorm.Model
+	Source     string    `gorm:"column:source;index" json:"source"`         // name the list was imported under
+	Location   string    `gorm:"column:location" json:"location"`           // file or URL it was imported from
+	Username   string    `gorm:"column:username;index" json:"username"`     // lowercase, without @
+	UserID     string    `gorm:"column:user_id;index" json:"user_id"`       // numeric Twitter ID
+	Reason     string    `gorm:"column:reason" json:"reason,omitempty"`     // why the list flags the account
+	FUDType    string    `gorm:"column:fud_type" json:"fud_type,omitempty"` // e.g. scam_promotion, default blocklisted
+	ImportedBy string    `gorm:"column:imported_by" json:"imported_by"`     // "startup" or the admin who ran /blocklist import
+	ImportedAt time.Time `gorm:"column:imported_at" json:"imported_at"`
+}
+
+func (BlocklistEntryModel) TableName() string {
+	return "blocklist_entries"
+}
+
+// VerdictPollModel is a Telegram poll asking the moderators whether a user is FUD. The first answer
+// to reach the quorum closes it, a FUD or clean majority is recorded as the human verdict.
+type VerdictPollModel struct {
+	gorm.Model
+	PollID    string     `gorm:"column:poll_id;uniqueIndex" json:"poll_id"`
+	ChatID    int64      `gorm:"column:chat_id" json:"chat_id"`
+	MessageID int64      `gorm:"column:message_id" json:"message_id"`
+	UserID    string     `gorm:"column:user_id;index" json:"user_id"`
+	Username  string     `gorm:"column:username" json:"username"`
+	Quorum    int        `gorm:"column:quorum" json:"quorum"`
+	CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+	Result    string     `gorm:"column:result" json:"result,omitempty"` // "fud", "clean" or "needs_more_info" once closed
+	ClosedAt  *time.Time `gorm:"column:closed_at;index" json:"closed_at,omitempty"`
+}
+
+func (VerdictPollModel) TableName() string {
+	return "verdict_polls"
+}
+
+// VerdictPollVoteModel is the current answer of one moderator in a verdict poll
+type VerdictPollVoteModel struct {
+	gorm.Model
+	PollID    string `gorm:"column:poll_id;uniqueIndex:idx_verdict_poll_vote" json:"poll_id"`
+	VoterID   int64  `gorm:"column:voter_id;uniqueIndex:idx_verdict_poll_vote" json:"voter_id"`
+	VoterName string `gorm:"column:voter_name" json:"voter_name"`
+	Choice    int    `gorm:"column:choice" json:"choice"` // index of the poll option
+}
+
+func (VerdictPollVoteModel) TableName() string {
+	return "verdict_poll_votes"
+}
+
+// SchemaVersionModel records a migration applied to the database, see migrations.go
+type SchemaVersionModel struct {
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"column:name" json:"name"`
+	AppliedAt time.Time `gorm:"column:applied_at" json:"applied_at"`
+}
+
+func (SchemaVersionModel) TableName() string {
+	return "schema_version"
+}
+
+// EventModel is one entry of the append-only log of state changes, see /events and /api/events
+type EventModel struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"` // position in the log, consumers resume after it
+	CreatedAt time.Time `gorm:"column:created_at;index" json:"created_at"`
+	Type      string    `gorm:"column:type;index" json:"type"`
+	Subject   string    `gorm:"column:subject;index" json:"subject"` // user ID, chat ID or setting the change is about
+	Actor     string    `gorm:"column:actor" json:"actor,omitempty"`
+	Data      string    `gorm:"column:data" json:"data,omitempty"` // JSON details of the change
+}
+
+func (EventModel) TableName() string {
+	return "events"
+}
+
+// IngestionFilterModel is one runtime setting of the ingestion filters, see /filters
+type IngestionFilterModel struct {
+	Name      string    `gorm:"primaryKey;column:name" json:"name"`
+	Value     string    `gorm:"column:value" json:"value"`
+	UpdatedBy string    `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (IngestionFilterModel) TableName() string {
+	return "ingestion_filters"
+}
+
+// AlertMessageModel is the Telegram message an alert was delivered as, follow-up checks reply to it
+type AlertMessageModel struct {
+	ID             uint      `gorm:"primaryKey;column:id" json:"id"`
+	NotificationID string    `gorm:"column:notification_id;index" json:"notification_id"`
+	ChatID         int64     `gorm:"column:chat_id;index:idx_alert_messages_chat_message" json:"chat_id"`
+	MessageID      int64     `gorm:"column:message_id;index:idx_alert_messages_chat_message" json:"message_id"` // replies to it are resolved to the alert
+	CreatedAt      time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (AlertMessageModel) TableName() string {
+	return "alert_messages"
+}
+
+// FollowUpCheckModel is a scheduled re-evaluation of a flagged user, see follow_ups.go
+type FollowUpCheckModel struct {
+	ID             uint       `gorm:"primaryKey;column:id" json:"id"`
+	NotificationID string     `gorm:"column:notification_id;index" json:"notification_id"`
+	UserID         string     `gorm:"column:user_id;index" json:"user_id"`
+	Username       string     `gorm:"column:username" json:"username"`
+	TweetID        string     `gorm:"column:tweet_id" json:"tweet_id"`
+	FUDType        string     `gorm:"column:fud_type" json:"fud_type"`
+	Delay          string     `gorm:"column:delay" json:"delay"` // as configured, e.g. 24h or 7d
+	FlaggedAt      time.Time  `gorm:"column:flagged_at" json:"flagged_at"`
+	DueAt          time.Time  `gorm:"column:due_at;index" json:"due_at"`
+	Status         string     `gorm:"column:status;index" json:"status"`       // pending or done
+	Outcome        string     `gorm:"column:outcome" json:"outcome,omitempty"` // escalated, active, quiet or cleared
+	Summary        string     `gorm:"column:summary" json:"summary,omitempty"` // what the check found, as posted to the alert thread
+	CompletedAt    *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (FollowUpCheckModel) TableName() string {
+	return "follow_up_checks"
+}
+
+// ModeratorModel is a member of the moderator group as last verified with getChatMember, see /moderators
+type ModeratorModel struct {
+	UserID        int64      `gorm:"primaryKey;column:user_id;autoIncrement:false" json:"user_id"`
+	Username      string     `gorm:"column:username" json:"username"`
+	Status        string     `gorm:"column:status;index" json:"status"`                     // admin or revoked
+	MemberStatus  string     `gorm:"column:member_status" json:"member_status"`             // Telegram status in the moderator group
+	VerifiedAt    time.Time  `gorm:"column:verified_at" json:"verified_at"`                 // last successful getChatMember
+	RevokedAt     *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`         // when the bot privileges were revoked
+	RevokedReason string     `gorm:"column:revoked_reason" json:"revoked_reason,omitempty"` // e.g. left the group
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ModeratorModel) TableName() string {
+	return "moderators"
+}
+
+// FUDTypeModel is a FUD type the second step has reported, see new_fud_types.go
+type FUDTypeModel struct {
+	Type          string    `gorm:"primaryKey;column:type" json:"type"`
+	FirstSeenAt   time.Time `gorm:"column:first_seen_at" json:"first_seen_at"`
+	FirstUserID   string    `gorm:"column:first_user_id" json:"first_user_id"` // empty for types seeded from the history
+	FirstUsername string    `gorm:"column:first_username" json:"first_username"`
+	FirstTweetID  string    `gorm:"column:first_tweet_id" json:"first_tweet_id"`
+	Sightings     int       `gorm:"column:sightings;default:1" json:"sightings"`
+	LastSeenAt    time.Time `gorm:"column:last_seen_at" json:"last_seen_at"`
+}
+
+func (FUDTypeModel) TableName() string {
+	return "fud_types"
+}
+
+// TweetMergeModel is a tweet delivered again by another source and merged into the stored record, see tweet_dedup.go
+type TweetMergeModel struct {
+	ID           uint      `gorm:"primaryKey;column:id" json:"id"`
+	TweetID      string    `gorm:"column:tweet_id;index" json:"tweet_id"`
+	KeptSource   string    `gorm:"column:kept_source" json:"kept_source"`     // source_type of the record after the merge
+	MergedSource string    `gorm:"column:merged_source" json:"merged_source"` // source that delivered the duplicate, or a stored ID variant
+	Fields       string    `gorm:"column:fields" json:"fields,omitempty"`     // comma-separated fields taken from the duplicate
+	MergedAt     time.Time `gorm:"column:merged_at;index" json:"merged_at"`
+}
+
+func (TweetMergeModel) TableName() string {
+	return "tweet_merges"
+}
+
+// AlertThresholdModel is how alerts of one severity are delivered, see /threshold. Missing severities are broadcast.
+type AlertThresholdModel struct {
+	Severity  string    `gorm:"primaryKey;column:severity" json:"severity"`
+	Delivery  string    `gorm:"column:delivery" json:"delivery"` // broadcast, users or log
+	UpdatedBy string    `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (AlertThresholdModel) TableName() string {
+	return "alert_thresholds"
+}
+
+// QuietAlertModel is an alert held back during the quiet hours of a chat, delivered in the digest when they end
+type QuietAlertModel struct {
+	ID             uint      `gorm:"primaryKey;column:id" json:"id"`
+	ChatID         int64     `gorm:"column:chat_id;index" json:"chat_id"`
+	NotificationID string    `gorm:"column:notification_id" json:"notification_id"`
+	Severity       string    `gorm:"column:severity" json:"severity"`
+	Username       string    `gorm:"column:username" json:"username"`
+	FUDType        string    `gorm:"column:fud_type" json:"fud_type"`
+	QueuedAt       time.Time `gorm:"column:queued_at" json:"queued_at"`
+}
+
+func (QuietAlertModel) TableName() string {
+	return "quiet_alerts"
+}
+
+// WatchlistModel is a user a chat watches with /watch: each of their messages gets the detailed analysis
+// and is summarized to the chat
+type WatchlistModel struct {
+	ID        uint      `gorm:"primaryKey;column:id" json:"id"`
+	ChatID    int64     `gorm:"column:chat_id;uniqueIndex:idx_watchlist_chat_username" json:"chat_id"`
+	Username  string    `gorm:"column:username;uniqueIndex:idx_watchlist_chat_username;index:idx_watchlist_username" json:"username"` // lowercase
+	UserID    string    `gorm:"column:user_id;index" json:"user_id"`                                                                  // empty until the user is stored
+	AddedBy   string    `gorm:"column:added_by" json:"added_by"`
+	Note      string    `gorm:"column:note" json:"note"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (WatchlistModel) TableName() string {
+	return "watchlist"
+}
+
+// ReanalysisModel is a scheduled re-check of a known FUD user with the verdict it is compared against
+type ReanalysisModel struct {
+	ID                  uint       `gorm:"primaryKey;column:id" json:"id"`
+	UserID              string     `gorm:"column:user_id;index" json:"user_id"`
+	Username            string     `gorm:"column:username" json:"username"`
+	TaskID              string     `gorm:"column:task_id" json:"task_id"`
+	Status              string     `gorm:"column:status;index" json:"status"` // pending until the task finishes, then done
+	PreviousIsFUD       bool       `gorm:"column:previous_is_fud" json:"previous_is_fud"`
+	PreviousFUDType     string     `gorm:"column:previous_fud_type" json:"previous_fud_type"`
+	PreviousProbability float64    `gorm:"column:previous_probability" json:"previous_probability"`
+	PreviousRiskLevel   string     `gorm:"column:previous_risk_level" json:"previous_risk_level"`
+	PreviousAnalyzedAt  time.Time  `gorm:"column:previous_analyzed_at" json:"previous_analyzed_at"`
+	Outcome             string     `gorm:"column:outcome;index" json:"outcome"` // REANALYSIS_* once done
+	Summary             string     `gorm:"column:summary" json:"summary"`
+	CreatedAt           time.Time  `gorm:"column:created_at" json:"created_at"`
+	CompletedAt         *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	RetryAfter          *time.Time `gorm:"column:retry_after;index" json:"retry_after,omitempty"` // set when it failed, the user is not re-analyzed before
+}
+
+func (ReanalysisModel) TableName() string {
+	return "reanalyses"
+}
+
+// RiskScoreModel is one verdict in the risk score time series of a user, kept after the cached analysis is replaced
+type RiskScoreModel struct {
+	ID             uint      `gorm:"primaryKey;column:id" json:"id"`
+	UserID         string    `gorm:"column:user_id;index:idx_risk_scores_user_recorded" json:"user_id"`
+	Username       string    `gorm:"column:username" json:"username"`
+	IsFUD          bool      `gorm:"column:is_fud" json:"is_fud"`
+	FUDProbability float64   `gorm:"column:fud_probability" json:"fud_probability"`
+	RiskLevel      string    `gorm:"column:risk_level" json:"risk_level"`
+	FUDType        string    `gorm:"column:fud_type" json:"fud_type"`
+	PromptVersion  string    `gorm:"column:prompt_version" json:"prompt_version"` // empty for verdicts set by a moderator
+	RecordedAt     time.Time `gorm:"column:recorded_at;index:idx_risk_scores_user_recorded" json:"recorded_at"`
+}
+
+func (RiskScoreModel) TableName() string {
+	return "risk_scores"
+}
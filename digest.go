@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_DIGEST_HOUR    = 9
+	DIGEST_CHECK_INTERVAL  = time.Minute
+	DIGEST_TOP_OFFENDERS   = 5
+	DIGEST_MAX_NEW_FUD_IDS = 10
+)
+
+// digestWindow returns how far back a digest of the given period looks
+func digestWindow(period string) time.Duration {
+	if period == DIGEST_WEEKLY {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func digestHour() int {
+	hour, err := strconv.Atoi(os.Getenv(ENV_DIGEST_HOUR))
+	if err != nil || hour < 0 || hour > 23 {
+		return DEFAULT_DIGEST_HOUR
+	}
+	return hour
+}
+
+// digestDue reports whether a subscribed chat should get its digest now. Digests go out once the
+// configured hour has passed in the chat's timezone, once per local day; weekly digests only on
+// Mondays, once per ISO week.
+func digestDue(settings ChatSettingsModel, now time.Time, hour int) bool {
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	if local.Hour() < hour {
+		return false
+	}
+	if settings.Digest == DIGEST_WEEKLY && local.Weekday() != time.Monday {
+		return false
+	}
+	if settings.LastDigestAt == nil {
+		return true
+	}
+	// Compared by calendar, a digest sent late does not push the next one back
+	last := settings.LastDigestAt.In(location)
+	if settings.Digest == DIGEST_WEEKLY {
+		lastYear, lastWeek := last.ISOWeek()
+		year, week := local.ISOWeek()
+		return lastYear != year || lastWeek != week
+	}
+	return last.Year() != local.Year() || last.YearDay() != local.YearDay()
+}
+
+// StartDigestScheduler periodically sends daily and weekly digests to subscribed chats
+func (b *BotController) StartDigestScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			b.sendDueDigests(now)
+		}
+	}()
+}
+
+func (b *BotController) sendDueDigests(now time.Time) {
+	subscriptions, err := b.dbService.GetDigestSubscriptions()
+	if err != nil {
+		log.Printf("Failed to load digest subscriptions: %v", err)
+		return
+	}
+
+	hour := digestHour()
+	for _, settings := range subscriptions {
+		if !b.isRegisteredChat(settings.ChatID) || !digestDue(settings, now, hour) {
+			continue
+		}
+		err := b.sendDigest(&settings, now)
+		if err != nil {
+			log.Printf("Failed to send %s digest to chat %d: %v", settings.Digest, settings.ChatID, err)
+			continue
+		}
+
+		// Only the digest time, the loaded row may be older than a settings command that ran meanwhile
+		err = b.dbService.SetLastDigestAt(settings.ChatID, now)
+		if err != nil {
+			log.Printf("Failed to save digest time for chat %d: %v", settings.ChatID, err)
+		}
+		log.Printf("📰 Sent %s digest to chat %d", settings.Digest, settings.ChatID)
+	}
+}
+
+// sendDigest aggregates the chat's digest window and sends the summary
+func (b *BotController) sendDigest(settings *ChatSettingsModel, now time.Time) error {
+	stats, err := b.dbService.GetFUDDigestStats(now.Add(-digestWindow(settings.Digest)), now, DIGEST_TOP_OFFENDERS)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.sendSplitMessage(TelegramSendMessageRequest{
+		ChatID:              settings.ChatID,
+		MessageThreadID:     settings.ReportsTopicID,
+		Text:                b.formatter.FormatDigest(stats, settings.Digest, settings.Timezone, redactionProfiles[settings.Redaction]),
+		ParseMode:           "HTML",
+		DisablePreview:      true,
+		DisableNotification: true,
+	})
+	return err
+}
+
+// handleSubscribeCommand manages the chat's digest subscription: /subscribe [daily|weekly|off|now]
+func (b *BotController) handleSubscribeCommand(chatID int64, args []string) {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+
+	if len(args) == 0 {
+		current := settings.Digest
+		if current == "" {
+			current = "off"
+		}
+		b.SendMessage(chatID, fmt.Sprintf("📰 <b>FUD digest:</b> %s\n\nUsage: /subscribe daily|weekly|off|now\n• daily - summary of the last 24 hours every day at %02d:00 (%s)\n• weekly - summary of the last 7 days every Monday\n• now - send the current summary right away", current, digestHour(), settings.Timezone))
+		return
+	}
+
+	switch period := strings.ToLower(args[0]); period {
+	case DIGEST_DAILY, DIGEST_WEEKLY:
+		settings.Digest = period
+		// Start counting from now so the first digest doesn't repeat alerts the chat already saw
+		now := time.Now()
+		settings.LastDigestAt = &now
+	case "off":
+		settings.Digest = ""
+	case "now":
+		if settings.Digest == "" {
+			settings.Digest = DIGEST_DAILY
+		}
+		err := b.sendDigest(settings, time.Now())
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error building digest: %v", err))
+		}
+		return
+	default:
+		b.SendMessage(chatID, "❌ Unknown option. Use: /subscribe daily|weekly|off|now")
+		return
+	}
+
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	if settings.Digest == "" {
+		b.SendMessage(chatID, "✅ Digest subscription cancelled")
+		return
+	}
+	b.SendMessage(chatID, fmt.Sprintf("✅ Subscribed to the <b>%s</b> FUD digest", settings.Digest))
+}
+
+// FormatDigest renders a daily or weekly summary of detections, new FUD users and top offenders.
+// Redacted chats get it within their profile, summary-only chats get the counts alone.
+func (nf *NotificationFormatter) FormatDigest(stats *FUDDigestStats, period string, timezone string, profile RedactionProfile) string {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	userLabel := func(userID string, username string) string {
+		if profile.HideUsernames {
+			return anonymizedUserLabel(userID)
+		}
+		return "@" + username
+	}
+
+	title := "Daily"
+	if period == DIGEST_WEEKLY {
+		title = "Weekly"
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("📰 <b>%s FUD Digest</b>\n📅 %s — %s\n\n", title,
+		stats.Since.In(location).Format("2006-01-02 15:04"), stats.Until.In(location).Format("2006-01-02 15:04")))
+
+	if stats.Detections == 0 && len(stats.NewFUDUsers) == 0 {
+		message.WriteString("✅ No FUD detected in this period.")
+		return message.String()
+	}
+
+	message.WriteString(fmt.Sprintf("🚨 <b>Detections:</b> %d\n", stats.Detections))
+	for _, severity := range []string{"critical", "high", "medium", "low"} {
+		if count := stats.BySeverity[severity]; count > 0 {
+			message.WriteString(fmt.Sprintf("  %s %s: %d\n", nf.getSeverityEmoji(severity), severity, count))
+		}
+	}
+
+	if len(stats.ByType) > 0 {
+		types := make([]string, 0, len(stats.ByType))
+		for fudType := range stats.ByType {
+			types = append(types, fudType)
+		}
+		sort.Slice(types, func(i, j int) bool {
+			if stats.ByType[types[i]] != stats.ByType[types[j]] {
+				return stats.ByType[types[i]] > stats.ByType[types[j]]
+			}
+			return types[i] < types[j]
+		})
+		message.WriteString("\n🎯 <b>Attack types:</b>\n")
+		for _, fudType := range types {
+			message.WriteString(fmt.Sprintf("  %s %s: %d\n", nf.getFUDTypeEmoji(fudType), nf.formatFUDType(fudType), stats.ByType[fudType]))
+		}
+	}
+
+	message.WriteString(fmt.Sprintf("\n🆕 <b>New FUD users:</b> %d\n", len(stats.NewFUDUsers)))
+	if profile.SummaryOnly {
+		return strings.TrimRight(message.String(), "\n")
+	}
+	for i, user := range stats.NewFUDUsers {
+		if i == DIGEST_MAX_NEW_FUD_IDS {
+			message.WriteString(fmt.Sprintf("  … and %d more\n", len(stats.NewFUDUsers)-DIGEST_MAX_NEW_FUD_IDS))
+			break
+		}
+		if profile.HideProbability {
+			message.WriteString(fmt.Sprintf("  • %s (%s)\n", userLabel(user.UserID, user.Username), nf.formatFUDType(user.FUDType)))
+			continue
+		}
+		message.WriteString(fmt.Sprintf("  • %s (%s, %.0f%%)\n", userLabel(user.UserID, user.Username), nf.formatFUDType(user.FUDType), user.FUDProbability*100))
+	}
+
+	if len(stats.TopOffenders) > 0 {
+		message.WriteString("\n🏆 <b>Top offenders:</b>\n")
+		for i, offender := range stats.TopOffenders {
+			message.WriteString(fmt.Sprintf("  %d. %s — %d alerts\n", i+1, userLabel(offender.UserID, offender.Username), offender.Alerts))
+		}
+	}
+
+	return strings.TrimRight(message.String(), "\n")
+}
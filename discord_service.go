@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DISCORD_API_BASE_URL       = "https://discord.com/api/v10"
+	DISCORD_MAX_MESSAGE_LENGTH = 2000
+	DISCORD_HISTORY_MESSAGES   = 10
+)
+
+// Discord interaction and response types
+const (
+	DISCORD_INTERACTION_PING    = 1
+	DISCORD_INTERACTION_COMMAND = 2
+	DISCORD_RESPONSE_PONG       = 1
+	DISCORD_RESPONSE_MESSAGE    = 4
+	DISCORD_OPTION_STRING       = 3
+)
+
+// analysisQueue starts manual analyses, implemented by BotController
+type analysisQueue interface {
+	queueAnalysisTask(task *AnalysisTaskModel) (string, error)
+}
+
+// DiscordService delivers FUD alerts to Discord channels and answers the /analyze and /history
+// slash commands, so teams on Discord don't need a Telegram bridge
+type DiscordService struct {
+	apiBaseURL    string
+	botToken      string
+	applicationID string
+	publicKey     ed25519.PublicKey
+	channelIDs    map[string]bool
+	httpClient    *http.Client
+	formatter     *NotificationFormatter
+	dbService     *DatabaseService
+	analysis      analysisQueue
+}
+
+type discordInteraction struct {
+	Type      int    `json:"type"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Member struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"member"`
+}
+
+type discordInteractionResponse struct {
+	Type int                     `json:"type"`
+	Data *discordResponseMessage `json:"data,omitempty"`
+}
+
+type discordResponseMessage struct {
+	Content         string                 `json:"content"`
+	AllowedMentions map[string]interface{} `json:"allowed_mentions"`
+}
+
+func NewDiscordService(botToken string, applicationID string, publicKeyHex string, channelIDs string, formatter *NotificationFormatter, dbService *DatabaseService, analysis analysisQueue) (*DiscordService, error) {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid %s", ENV_DISCORD_PUBLIC_KEY)
+	}
+
+	service := &DiscordService{
+		apiBaseURL:    DISCORD_API_BASE_URL,
+		botToken:      botToken,
+		applicationID: applicationID,
+		publicKey:     publicKey,
+		channelIDs:    make(map[string]bool),
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		formatter:     formatter,
+		dbService:     dbService,
+		analysis:      analysis,
+	}
+	for _, channelID := range strings.Split(channelIDs, ",") {
+		if channelID = strings.TrimSpace(channelID); channelID != "" {
+			service.channelIDs[channelID] = true
+		}
+	}
+	if len(service.channelIDs) == 0 {
+		return nil, fmt.Errorf("%s is empty", ENV_DISCORD_CHANNEL_IDS)
+	}
+	return service, nil
+}
+
+// StoreAndBroadcastNotification posts the alert to every configured channel. Alerts are stored
+// once by the Telegram controller, Discord has no /detail_ links of its own.
+func (d *DiscordService) StoreAndBroadcastNotification(alert FUDAlertNotification) error {
+	text := d.formatter.FormatForDiscord(alert)
+
+	failed := 0
+	for channelID := range d.channelIDs {
+		err := d.SendMessage(channelID, text)
+		if err != nil {
+			log.Printf("Failed to send alert to Discord channel %s: %v", channelID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to send to %d Discord channels", failed)
+	}
+	return nil
+}
+
+// SendAlertToChannel posts the result of an analysis requested from a Discord channel
+func (d *DiscordService) SendAlertToChannel(channelID string, alert FUDAlertNotification) error {
+	return d.SendMessage(channelID, d.formatter.FormatForDiscord(alert))
+}
+
+// SendMessage posts markdown text to a channel, retrying once after a 429
+func (d *DiscordService) SendMessage(channelID string, text string) error {
+	body, err := json.Marshal(discordResponseMessage{
+		Content:         truncateDiscordMessage(text),
+		AllowedMentions: map[string]interface{}{"parse": []string{}},
+	})
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = d.call(http.MethodPost, "/channels/"+channelID+"/messages", body)
+		var apiErr *discordAPIError
+		if err == nil || attempt > 0 || !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+			return err
+		}
+		log.Printf("⏳ Discord rate limited channel %s, retrying in %.1fs", channelID, apiErr.RetryAfter)
+		time.Sleep(time.Duration(apiErr.RetryAfter * float64(time.Second)))
+	}
+}
+
+// RegisterCommands creates or updates the /analyze and /history slash commands
+func (d *DiscordService) RegisterCommands() error {
+	usernameOption := []map[string]interface{}{{
+		"type":        DISCORD_OPTION_STRING,
+		"name":        "username",
+		"description": "Twitter username, profile or tweet link",
+		"required":    true,
+	}}
+	body, err := json.Marshal([]map[string]interface{}{
+		{"name": "analyze", "description": "Run a detailed FUD analysis of a user", "options": usernameOption},
+		{"name": "history", "description": "Show a user's recent messages", "options": usernameOption},
+	})
+	if err != nil {
+		return err
+	}
+	return d.call(http.MethodPut, "/applications/"+d.applicationID+"/commands", body)
+}
+
+type discordAPIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter float64
+}
+
+func (e *discordAPIError) Error() string {
+	return fmt.Sprintf("discord API error %d: %s", e.StatusCode, e.Body)
+}
+
+func (d *DiscordService) call(method string, path string, body []byte) error {
+	req, err := http.NewRequest(method, d.apiBaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.botToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	apiErr := &discordAPIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var rateLimit struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		if json.Unmarshal(respBody, &rateLimit) == nil {
+			apiErr.RetryAfter = rateLimit.RetryAfter
+		}
+	}
+	return apiErr
+}
+
+// StartInteractionsServer serves the interactions endpoint Discord calls for slash commands
+func (d *DiscordService) StartInteractionsServer(addr string) error {
+	if addr == "" {
+		return fmt.Errorf("%s is empty", ENV_DISCORD_INTERACTIONS_ADDR)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start Discord interactions listener: %w", err)
+	}
+
+	go func() {
+		err := http.Serve(listener, http.HandlerFunc(d.handleInteraction))
+		if err != nil {
+			log.Printf("Discord interactions server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("🎮 Discord interactions endpoint listening on %s", listener.Addr())
+	return nil
+}
+
+func (d *DiscordService) handleInteraction(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil || !d.verifySignature(r.Header.Get("X-Signature-Ed25519"), r.Header.Get("X-Signature-Timestamp"), body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	response := discordInteractionResponse{Type: DISCORD_RESPONSE_PONG}
+	if interaction.Type == DISCORD_INTERACTION_COMMAND {
+		response = discordInteractionResponse{
+			Type: DISCORD_RESPONSE_MESSAGE,
+			Data: &discordResponseMessage{
+				Content:         truncateDiscordMessage(d.handleCommand(interaction)),
+				AllowedMentions: map[string]interface{}{"parse": []string{}},
+			},
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// verifySignature checks the Ed25519 signature Discord puts on every interaction request
+func (d *DiscordService) verifySignature(signatureHex string, timestamp string, body []byte) bool {
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(d.publicKey, append([]byte(timestamp), body...), signature)
+}
+
+// handleCommand runs a slash command and returns the reply text
+func (d *DiscordService) handleCommand(interaction discordInteraction) string {
+	if !d.channelIDs[interaction.ChannelID] {
+		return "❌ Commands are not enabled in this channel."
+	}
+
+	var input string
+	for _, option := range interaction.Data.Options {
+		if option.Name == "username" {
+			input = option.Value
+		}
+	}
+	username, tweetID := parseTwitterReference(input)
+	if username == "" && tweetID != "" {
+		if tweet, err := d.dbService.GetTweet(tweetID); err == nil {
+			username = tweet.Username
+		}
+	}
+	if username == "" {
+		return "❌ Please provide a username"
+	}
+
+	log.Printf("Discord command /%s %s from %s in channel %s", interaction.Data.Name, username, interaction.Member.User.Username, interaction.ChannelID)
+	switch interaction.Data.Name {
+	case "analyze":
+		return d.handleAnalyzeCommand(interaction.ChannelID, username, tweetID)
+	case "history":
+		return d.handleHistoryCommand(username)
+	default:
+		return "❌ Unknown command"
+	}
+}
+
+func (d *DiscordService) handleAnalyzeCommand(channelID string, username string, tweetID string) string {
+	taskID, err := d.analysis.queueAnalysisTask(&AnalysisTaskModel{
+		Username:       username,
+		TweetID:        tweetID,
+		DiscordChannel: channelID,
+	})
+	if err != nil {
+		return fmt.Sprintf("❌ Failed to start analysis: %v", err)
+	}
+	return fmt.Sprintf("🔄 Analysis for **@%s** started (task `%s`). The result will be posted in this channel.", username, taskID)
+}
+
+func (d *DiscordService) handleHistoryCommand(username string) string {
+	tweets, err := d.dbService.GetUserMessagesByUsername(username, DISCORD_HISTORY_MESSAGES)
+	if err != nil {
+		return fmt.Sprintf("❌ Error retrieving messages for @%s: %v", username, err)
+	}
+	if len(tweets) == 0 {
+		return fmt.Sprintf("📭 No messages found for @%s", username)
+	}
+
+	var history strings.Builder
+	history.WriteString(fmt.Sprintf("📝 **Message History for @%s** (Last %d)\n\n", username, DISCORD_HISTORY_MESSAGES))
+	for i, tweet := range tweets {
+		history.WriteString(fmt.Sprintf("**%d.** %s <https://twitter.com/%s/status/%s>\n> %s\n",
+			i+1, tweet.CreatedAt.Format("2006-01-02 15:04"), username, tweet.ID,
+			strings.ReplaceAll(d.formatter.truncateText(tweet.Text, 150), "\n", " ")))
+	}
+	return history.String()
+}
+
+// FormatForDiscord renders an alert as Discord markdown
+func (nf *NotificationFormatter) FormatForDiscord(alert FUDAlertNotification) string {
+	isFUDAlert := !strings.Contains(alert.FUDType, "manual_analysis_clean") && alert.FUDType != "none"
+
+	var message strings.Builder
+	if isFUDAlert {
+		title := "FUD ALERT"
+		if alert.FUDType == HEURISTIC_FUD_TYPE {
+			title = "HEURISTIC FUD ALERT"
+		}
+		message.WriteString(fmt.Sprintf("%s **%s - %s SEVERITY**\n", nf.getSeverityEmoji(alert.AlertSeverity), title, strings.ToUpper(alert.AlertSeverity)))
+		message.WriteString(fmt.Sprintf("%s **Attack Type:** %s\n", nf.getFUDTypeEmoji(alert.FUDType), nf.formatFUDType(alert.FUDType)))
+	} else {
+		message.WriteString("✅ **ANALYSIS COMPLETE - USER CLEAN**\n")
+	}
+	if alert.UserSummary != "" {
+		message.WriteString(fmt.Sprintf("👤 **User Profile:** %s\n", alert.UserSummary))
+	}
+	message.WriteString(fmt.Sprintf("🎯 **User:** @%s\n", alert.FUDUsername))
+	message.WriteString(fmt.Sprintf("📊 **Confidence:** %.0f%%\n", alert.FUDProbability*100))
+	if alert.RecommendedAction != "" {
+		message.WriteString(fmt.Sprintf("⚡ **Action:** %s\n", alert.RecommendedAction))
+	}
+	if len(alert.PromotedCompetitors) > 0 {
+		message.WriteString(fmt.Sprintf("🏷 **Promotes:** %s\n", strings.Join(alert.PromotedCompetitors, ", ")))
+	}
+	if alert.RequestSource != "" {
+		message.WriteString(fmt.Sprintf("📨 **Submitted by:** %s\n", alert.RequestSource))
+	}
+	message.WriteString(fmt.Sprintf("\n💬 **Message:**\n> %s\n", strings.ReplaceAll(nf.truncateText(alert.MessagePreview, 500), "\n", "\n> ")))
+	message.WriteString(fmt.Sprintf("\n🔗 <https://twitter.com/%s/status/%s>\n", alert.FUDUsername, alert.FUDMessageID))
+	message.WriteString(fmt.Sprintf("⏰ **Detected:** %s", nf.formatTime(alert.DetectedAt)))
+	return message.String()
+}
+
+func truncateDiscordMessage(text string) string {
+	runes := []rune(text)
+	if len(runes) <= DISCORD_MAX_MESSAGE_LENGTH {
+		return text
+	}
+	return string(runes[:DISCORD_MAX_MESSAGE_LENGTH-1]) + "…"
+}
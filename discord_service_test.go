@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAnalysisQueue struct {
+	tasks []*AnalysisTaskModel
+}
+
+func (f *fakeAnalysisQueue) queueAnalysisTask(task *AnalysisTaskModel) (string, error) {
+	task.ID = "task-1"
+	f.tasks = append(f.tasks, task)
+	return task.ID, nil
+}
+
+func TestDiscordService(t *testing.T) {
+	var mu sync.Mutex
+	posted := make(map[string][]string)
+	discordAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bot secret", r.Header.Get("Authorization"))
+		var message discordResponseMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		posted[r.URL.Path] = append(posted[r.URL.Path], message.Content)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer discordAPI.Close()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	db := setupTestDB(t)
+	queue := &fakeAnalysisQueue{}
+	discord, err := NewDiscordService("secret", "app", hex.EncodeToString(publicKey), "100, 200", NewNotificationFormatter(), db, queue)
+	require.NoError(t, err)
+	discord.apiBaseURL = discordAPI.URL
+
+	interact := func(body string, sign bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		timestamp := "1700000000"
+		signature := make([]byte, ed25519.SignatureSize)
+		if sign {
+			signature = ed25519.Sign(privateKey, []byte(timestamp+body))
+		}
+		req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+		req.Header.Set("X-Signature-Timestamp", timestamp)
+		recorder := httptest.NewRecorder()
+		discord.handleInteraction(recorder, req)
+		return recorder
+	}
+	commandContent := func(recorder *httptest.ResponseRecorder) string {
+		var response discordInteractionResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Equal(t, DISCORD_RESPONSE_MESSAGE, response.Type)
+		return response.Data.Content
+	}
+
+	t.Run("Broadcast posts the alert to every channel", func(t *testing.T) {
+		err := discord.StoreAndBroadcastNotification(FUDAlertNotification{
+			FUDUsername:    "fudder",
+			FUDMessageID:   "555",
+			FUDType:        "fud_attack",
+			AlertSeverity:  "high",
+			FUDProbability: 0.9,
+			MessagePreview: "this project is a scam",
+			DetectedAt:     time.Now().Format(time.RFC3339),
+		})
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, channelID := range []string{"100", "200"} {
+			messages := posted["/channels/"+channelID+"/messages"]
+			require.Len(t, messages, 1)
+			assert.Contains(t, messages[0], "**FUD ALERT - HIGH SEVERITY**")
+			assert.Contains(t, messages[0], "@fudder")
+		}
+	})
+
+	t.Run("Broadcast follows maintenance and community routing", func(t *testing.T) {
+		bot := newTestBotController(&fakeTelegramTransport{}, db)
+		bot.chatIDs[1] = true
+		require.NoError(t, db.SaveCommunity(CommunityModel{ID: "1111111111", Ticker: "$GRUT", NotifyChatIDs: "1"}))
+		require.NoError(t, db.SaveCommunity(CommunityModel{ID: "2222222222", Ticker: "$PEPE"}))
+		deliver := func(alert FUDAlertNotification) int {
+			mu.Lock()
+			before := len(posted["/channels/100/messages"])
+			mu.Unlock()
+			notificationCh := make(chan FUDAlertNotification, 1)
+			notificationCh <- alert
+			close(notificationCh)
+			NotificationHandler(notificationCh, bot, discord)
+			mu.Lock()
+			defer mu.Unlock()
+			return len(posted["/channels/100/messages"]) - before
+		}
+
+		alert := FUDAlertNotification{FUDUserID: "u9", FUDUsername: "fudder", FUDType: "fud_attack", AlertSeverity: "high"}
+		alert.CommunityID = "1111111111"
+		assert.Equal(t, 0, deliver(alert), "the community is routed to configured chats")
+		alert.CommunityID = "2222222222"
+		assert.Equal(t, 1, deliver(alert), "the community is routed by ticker")
+
+		bot.handleMaintenanceCommand(1, []string{"1h"})
+		defer bot.endMaintenance()
+		assert.Equal(t, 0, deliver(alert), "held back during maintenance")
+		alert.AlertSeverity = "critical"
+		assert.Equal(t, 1, deliver(alert), "critical alerts are still delivered")
+	})
+
+	t.Run("Requests with a bad signature are rejected", func(t *testing.T) {
+		recorder := interact(`{"type":1}`, false)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Ping is answered with pong", func(t *testing.T) {
+		recorder := interact(`{"type":1}`, true)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		body, _ := io.ReadAll(recorder.Body)
+		assert.JSONEq(t, `{"type":1}`, string(body))
+	})
+
+	t.Run("Analyze queues a task for the requesting channel", func(t *testing.T) {
+		recorder := interact(`{"type":2,"channel_id":"200","data":{"name":"analyze","options":[{"name":"username","value":"@fudder"}]}}`, true)
+		assert.Contains(t, commandContent(recorder), "task `task-1`")
+		require.Len(t, queue.tasks, 1)
+		assert.Equal(t, "fudder", queue.tasks[0].Username)
+		assert.Equal(t, "200", queue.tasks[0].DiscordChannel)
+	})
+
+	t.Run("History lists stored messages", func(t *testing.T) {
+		require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "fudder"}))
+		require.NoError(t, db.SaveTweet(TweetModel{ID: "t1", UserID: "u1", Username: "fudder", Text: "sell everything", CreatedAt: time.Now()}))
+
+		recorder := interact(`{"type":2,"channel_id":"100","data":{"name":"history","options":[{"name":"username","value":"fudder"}]}}`, true)
+		content := commandContent(recorder)
+		assert.Contains(t, content, "Message History for @fudder")
+		assert.Contains(t, content, "sell everything")
+	})
+
+	t.Run("Commands from other channels are refused", func(t *testing.T) {
+		recorder := interact(`{"type":2,"channel_id":"999","data":{"name":"history","options":[{"name":"username","value":"fudder"}]}}`, true)
+		assert.Contains(t, commandContent(recorder), "not enabled in this channel")
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	EDIT_CHECK_INTERVAL  = 10 * time.Minute
+	EDIT_WATCH_WINDOW    = 75 * time.Minute // X allows edits for an hour after posting
+	EDIT_FETCH_BATCH     = 100
+	EDIT_HISTORY_PREVIEW = 300
+)
+
+// isTrackableTweetID filters out placeholder IDs used by manual and batch analysis
+func isTrackableTweetID(tweetID string) bool {
+	if tweetID == "" {
+		return false
+	}
+	for _, r := range tweetID {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// StartEditTracker periodically re-fetches recently flagged tweets and stores every edit
+func StartEditTracker(twitterApi TwitterAPI, dbService *DatabaseService, interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			checkFlaggedTweetEdits(twitterApi, dbService)
+		}
+	}()
+}
+
+func checkFlaggedTweetEdits(twitterApi TwitterAPI, dbService *DatabaseService) {
+	tweetIDs, err := dbService.GetTweetsFlaggedSince(time.Now().Add(-EDIT_WATCH_WINDOW))
+	if err != nil {
+		log.Printf("Failed to load flagged tweets for edit tracking: %v", err)
+		return
+	}
+
+	for start := 0; start < len(tweetIDs); start += EDIT_FETCH_BATCH {
+		batch := tweetIDs[start:min(start+EDIT_FETCH_BATCH, len(tweetIDs))]
+		resp, err := twitterApi.GetTweetsByIds(batch)
+		if err != nil {
+			log.Printf("Failed to re-fetch flagged tweets: %v", err)
+			return
+		}
+		for _, tweet := range resp.Tweets {
+			edited, err := dbService.SaveTweetRevision(tweet.Id, tweet.Text)
+			if err != nil {
+				log.Printf("Failed to save revision of tweet %s: %v", tweet.Id, err)
+			} else if edited {
+				log.Printf("✏️ Flagged tweet %s by @%s was edited", tweet.Id, tweet.Author.UserName)
+			}
+		}
+	}
+}
+
+// formatEditHistory notes a flagged tweet that was edited afterwards, for the alert details
+func (b *BotController) formatEditHistory(tweetID string) string {
+	revisions, err := b.dbService.GetTweetRevisions([]string{tweetID})
+	if err != nil || len(revisions[tweetID]) == 0 {
+		return ""
+	}
+	history := revisions[tweetID]
+	latest := history[len(history)-1]
+
+	return fmt.Sprintf("\n\n✏️ <b>EDITED AFTER BEING FLAGGED</b> (edits: %d, last %s)\n📝 <b>Current text:</b>\n<i>%s</i>",
+		len(history)-1,
+		latest.FetchedAt.UTC().Format("2006-01-02 15:04 UTC"),
+		b.formatter.escapeTruncated(latest.Text, EDIT_HISTORY_PREVIEW))
+}
+
+// writeEditHistory appends the revisions of an edited flagged tweet to a text export
+func writeEditHistory(fileContent *strings.Builder, revisions []TweetRevisionModel) {
+	if len(revisions) == 0 {
+		return
+	}
+	fileContent.WriteString(fmt.Sprintf("EDITED AFTER BEING FLAGGED (edits: %d)\n", len(revisions)-1))
+	for _, revision := range revisions {
+		label := fmt.Sprintf("Revision %d", revision.Revision)
+		if revision.Revision == 0 {
+			label = "Flagged text"
+		}
+		fileContent.WriteString(fmt.Sprintf("  %s (%s): %s\n", label, revision.FetchedAt.UTC().Format("2006-01-02 15:04:05 UTC"), revision.Text))
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditTracking(t *testing.T) {
+	currentText := "this project is a scam"
+	twitterApi := &mockTwitterAPI{tweetsByIds: func(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error) {
+		assert.Equal(t, []string{"1001"}, tweetIds)
+		resp := &twitterapi.TweetsByIdsResponse{Status: "success"}
+		resp.Tweets = append(resp.Tweets, twitterapi.Tweet{Id: "1001", Text: currentText})
+		return resp, nil
+	}}
+
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	require.NoError(t, bot.StoreAndBroadcastNotification(FUDAlertNotification{FUDMessageID: "1001", FUDUsername: "shady", FUDType: "direct_attack", AlertSeverity: "high", MessagePreview: currentText}))
+	// Placeholder IDs of manual analysis are not tracked
+	require.NoError(t, bot.StoreAndBroadcastNotification(FUDAlertNotification{FUDMessageID: "manual_shady", FUDUsername: "shady", FUDType: "direct_attack", AlertSeverity: "high"}))
+
+	checkFlaggedTweetEdits(twitterApi, db)
+	revisions, err := db.GetTweetRevisions([]string{"1001", "manual_shady"})
+	require.NoError(t, err)
+	assert.Empty(t, revisions, "unchanged text is not an edit")
+
+	currentText = "I have some concerns about this project"
+	checkFlaggedTweetEdits(twitterApi, db)
+	checkFlaggedTweetEdits(twitterApi, db)
+	revisions, err = db.GetTweetRevisions([]string{"1001"})
+	require.NoError(t, err)
+	require.Len(t, revisions["1001"], 2)
+	assert.Equal(t, "this project is a scam", revisions["1001"][0].Text)
+	assert.Equal(t, currentText, revisions["1001"][1].Text)
+
+	var notificationID string
+	notifications, err := db.GetNotificationsSince(time.Time{})
+	require.NoError(t, err)
+	for _, notification := range notifications {
+		if alert, err := db.GetNotification(notification.NotificationID); err == nil && alert.FUDMessageID == "1001" {
+			notificationID = notification.NotificationID
+		}
+	}
+	bot.handleDetailCommand(1, "/detail_"+notificationID)
+	sent := transport.sentMessages()
+	detail := sent[len(sent)-1].Text
+	assert.Contains(t, detail, "EDITED AFTER BEING FLAGGED</b> (edits: 1")
+	assert.Contains(t, detail, "I have some concerns")
+
+	var export strings.Builder
+	writeEditHistory(&export, revisions["1001"])
+	assert.Contains(t, export.String(), "Flagged text")
+	assert.Contains(t, export.String(), "Revision 1")
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	EVAL_FORMAT_VERSION = 1
+	EVAL_LOCAL_DATASET  = "local" // name this deployment's own verdicts are exported under
+	EVAL_ALL_DATASETS   = "all"
+)
+
+// evalExample is one line of a JSONL eval dataset: a human verdict on a user with the messages it
+// was based on and, when there was one, what the model had concluded
+type evalExample struct {
+	Version   int               `json:"version"`
+	Dataset   string            `json:"dataset"`
+	UserID    string            `json:"user_id,omitempty"`
+	Username  string            `json:"username"`
+	Label     string            `json:"label"` // "fud" or "clean"
+	FUDType   string            `json:"fud_type,omitempty"`
+	Note      string            `json:"note,omitempty"`
+	LabeledBy string            `json:"labeled_by,omitempty"`
+	LabeledAt time.Time         `json:"labeled_at"`
+	Model     *evalModelVerdict `json:"model,omitempty"`
+	Messages  []labeledMessage  `json:"messages"`
+}
+
+type evalModelVerdict struct {
+	IsFUD       bool    `json:"is_fud"`
+	FUDType     string  `json:"fud_type,omitempty"`
+	Probability float64 `json:"probability"`
+	Reason      string  `json:"reason,omitempty"`
+}
+
+func newEvalExample(verdict LabeledVerdictModel) evalExample {
+	example := evalExample{
+		Version:   EVAL_FORMAT_VERSION,
+		Dataset:   verdict.Dataset,
+		UserID:    verdict.UserID,
+		Username:  verdict.Username,
+		Label:     verdict.Label,
+		FUDType:   verdict.FUDType,
+		Note:      verdict.Note,
+		LabeledBy: verdict.LabeledBy,
+		LabeledAt: verdict.CreatedAt.UTC(),
+		Messages:  []labeledMessage{},
+	}
+	if example.Dataset == "" {
+		example.Dataset = EVAL_LOCAL_DATASET
+	}
+	if verdict.ModelAnalyzed {
+		example.Model = &evalModelVerdict{IsFUD: verdict.ModelIsFUD, FUDType: verdict.ModelFUDType, Probability: verdict.ModelProbability, Reason: verdict.ModelReason}
+	}
+	json.Unmarshal([]byte(verdict.Messages), &example.Messages)
+	return example
+}
+
+// toVerdict turns an imported example into a verdict of the dataset, nil when it is not a usable example
+func (example evalExample) toVerdict(dataset string) *LabeledVerdictModel {
+	username := strings.TrimPrefix(strings.TrimSpace(example.Username), "@")
+	if example.Label != LABEL_FUD && example.Label != LABEL_CLEAN {
+		return nil
+	}
+	if !twitterUsernameRegex.MatchString(username) && example.UserID == "" {
+		return nil
+	}
+	if example.FUDType != "" && !fudTypeRegex.MatchString(example.FUDType) {
+		return nil
+	}
+	messages, _ := json.Marshal(example.Messages)
+	verdict := &LabeledVerdictModel{
+		UserID:    example.UserID,
+		Username:  username,
+		Label:     example.Label,
+		FUDType:   example.FUDType,
+		Note:      example.Note,
+		LabeledBy: example.LabeledBy,
+		Messages:  string(messages),
+		Dataset:   dataset,
+	}
+	if example.Label == LABEL_FUD && verdict.FUDType == "" {
+		verdict.FUDType = LABEL_DEFAULT_FUD_TYPE
+	}
+	if !example.LabeledAt.IsZero() {
+		verdict.CreatedAt = example.LabeledAt
+	}
+	if example.Model != nil {
+		verdict.ModelAnalyzed = true
+		verdict.ModelIsFUD = example.Model.IsFUD
+		verdict.ModelFUDType = example.Model.FUDType
+		verdict.ModelProbability = example.Model.Probability
+		verdict.ModelReason = example.Model.Reason
+	}
+	return verdict
+}
+
+// parseEvalDataset reads a JSONL dataset and returns its usable examples with the number of skipped ones.
+// A line that is not JSON fails the whole import, a curated dataset is expected to be valid.
+func parseEvalDataset(data []byte, dataset string) ([]LabeledVerdictModel, int, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), IMPORT_MAX_SIZE)
+	var verdicts []LabeledVerdictModel
+	skipped := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var example evalExample
+		if err := json.Unmarshal(text, &example); err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if example.Version > EVAL_FORMAT_VERSION {
+			return nil, 0, fmt.Errorf("line %d: format version %d is newer than this deployment supports (%d)", line, example.Version, EVAL_FORMAT_VERSION)
+		}
+		verdict := example.toVerdict(dataset)
+		if verdict == nil {
+			skipped++
+			continue
+		}
+		verdicts = append(verdicts, *verdict)
+	}
+	return verdicts, skipped, scanner.Err()
+}
+
+// handleEvalCommand shares the labeled example bank between deployments as JSONL:
+// /eval [list|export [local|name|all]|import name https://url|remove name]
+func (b *BotController) handleEvalCommand(chatID int64, actor string, args []string) {
+	usage := "❌ Usage: /eval [list|export [local|name|all]|import name https://url|remove name]"
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleEvalList(chatID)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "export":
+		if len(args) > 2 {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		dataset := EVAL_LOCAL_DATASET
+		if len(args) == 2 {
+			dataset = strings.ToLower(args[1])
+		}
+		b.handleEvalExport(chatID, dataset)
+	case "import":
+		if len(args) != 3 || !importNameRegex.MatchString(args[1]) || !(strings.HasPrefix(args[2], "https://") || strings.HasPrefix(args[2], "http://")) {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		dataset := strings.ToLower(args[1])
+		if dataset == EVAL_LOCAL_DATASET || dataset == EVAL_ALL_DATASETS {
+			b.SendMessage(chatID, fmt.Sprintf("❌ %s is reserved, import under another name", dataset))
+			return
+		}
+		data, err := fetchImport(args[2])
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error downloading dataset: %s", html.EscapeString(err.Error())))
+			return
+		}
+		verdicts, skipped, err := parseEvalDataset(data, dataset)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Invalid dataset: %s", html.EscapeString(err.Error())))
+			return
+		}
+		if len(verdicts) == 0 {
+			b.SendMessage(chatID, "❌ No usable examples in the dataset")
+			return
+		}
+		if err := b.dbService.ReplaceLabeledDataset(dataset, verdicts); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error saving dataset: %v", err))
+			return
+		}
+		log.Printf("📚 %s imported %d examples as eval dataset %s", actor, len(verdicts), dataset)
+		message := fmt.Sprintf("✅ Imported %d examples as dataset %s, replacing its previous version\nThey stay separate from this deployment's verdicts, /eval export %s or all includes them", len(verdicts), dataset, dataset)
+		if skipped > 0 {
+			message += fmt.Sprintf("\n⚠️ %d examples without a fud or clean label and an account were skipped", skipped)
+		}
+		b.SendMessage(chatID, message)
+	case "remove":
+		if len(args) != 2 || strings.ToLower(args[1]) == EVAL_LOCAL_DATASET || !importNameRegex.MatchString(args[1]) {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		dataset := strings.ToLower(args[1])
+		removed, err := b.dbService.RemoveLabeledDataset(dataset)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error removing dataset: %v", err))
+			return
+		}
+		if removed == 0 {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Dataset %s not found", dataset))
+			return
+		}
+		log.Printf("📚 %s removed eval dataset %s", actor, dataset)
+		b.SendMessage(chatID, fmt.Sprintf("✅ Dataset %s removed (%d examples)", dataset, removed))
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+func (b *BotController) handleEvalList(chatID int64) {
+	counts, err := b.dbService.CountLabeledDatasets()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading datasets: %v", err))
+		return
+	}
+	if len(counts) == 0 {
+		b.SendMessage(chatID, "📚 No labeled examples yet, /mark_fud, /mark_clean and /poll add this deployment's verdicts.\n\nUsage: /eval import name https://url")
+		return
+	}
+
+	var names []string
+	labels := make(map[string]map[string]int64)
+	for _, count := range counts {
+		name := count.Dataset
+		if name == "" {
+			name = EVAL_LOCAL_DATASET
+		}
+		if labels[name] == nil {
+			names = append(names, name)
+			labels[name] = make(map[string]int64)
+		}
+		labels[name][count.Label] += count.Count
+	}
+	var message strings.Builder
+	message.WriteString("📚 <b>Labeled example datasets</b>\n\n")
+	for _, name := range names {
+		message.WriteString(fmt.Sprintf("• <b>%s</b>: %d FUD, %d clean\n", name, labels[name][LABEL_FUD], labels[name][LABEL_CLEAN]))
+	}
+	message.WriteString("\n/eval export [local|name|all] · /eval import name https://url · /eval remove name")
+	b.SendMessage(chatID, message.String())
+}
+
+// handleEvalExport sends a dataset as a JSONL file. This deployment's verdicts are exported with the
+// latest verdict per user, earlier ones were overruled.
+func (b *BotController) handleEvalExport(chatID int64, dataset string) {
+	var verdicts []LabeledVerdictModel
+	var err error
+	switch dataset {
+	case EVAL_ALL_DATASETS:
+		verdicts, err = b.dbService.GetLabeledVerdicts()
+	case EVAL_LOCAL_DATASET:
+		verdicts, err = b.dbService.GetLabeledVerdicts("")
+	default:
+		verdicts, err = b.dbService.GetLabeledVerdicts(dataset)
+	}
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading dataset: %v", err))
+		return
+	}
+
+	latest := make(map[string]int)
+	var examples []evalExample
+	for _, verdict := range verdicts {
+		key := verdict.Dataset + "|" + verdict.UserID + "|" + strings.ToLower(verdict.Username)
+		if i, ok := latest[key]; ok && verdict.Dataset == "" {
+			examples[i] = newEvalExample(verdict)
+			continue
+		}
+		latest[key] = len(examples)
+		examples = append(examples, newEvalExample(verdict))
+	}
+	if len(examples) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No labeled examples in %s", html.EscapeString(dataset)))
+		return
+	}
+
+	var content strings.Builder
+	counts := map[string]int{}
+	for _, example := range examples {
+		line, _ := json.Marshal(example)
+		content.Write(line)
+		content.WriteString("\n")
+		counts[example.Label]++
+	}
+	filename := fmt.Sprintf("eval_%s_%s.jsonl", dataset, time.Now().Format("20060102_150405"))
+	err = b.writeToFile(filename, content.String())
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error creating file: %v", err))
+		return
+	}
+	caption := fmt.Sprintf("📚 <b>Eval dataset %s</b>\n%d examples: %d FUD, %d clean\n📄 JSONL, format version %d, import elsewhere with /eval import name &lt;url&gt;", dataset, len(examples), counts[LABEL_FUD], counts[LABEL_CLEAN], EVAL_FORMAT_VERSION)
+	err = b.SendDocument(chatID, filename, caption)
+	os.Remove(filename)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
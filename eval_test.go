@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_EvalDatasets(t *testing.T) {
+	db := setupTestDB(t)
+	server := newFakeTelegramServer(t)
+	bot := newTestBotController(server.newClient(t), db)
+	reply := func(text string) string {
+		bot.handleEvalCommand(1, "@admin", strings.Fields(text)[1:])
+		messages := server.messagesFor(1)
+		return messages[len(messages)-1].Text
+	}
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "Flipper"}))
+	require.NoError(t, db.SaveUser(UserModel{ID: "u2", Username: "Builder"}))
+	bot.handleMarkCommand(1, "@mod", LABEL_FUD, []string{"flipper", "direct_attack"})
+	bot.handleMarkCommand(1, "@mod", LABEL_CLEAN, []string{"flipper", "note:", "was", "joking"})
+	bot.handleMarkCommand(1, "@mod", LABEL_CLEAN, []string{"builder"})
+
+	bot.handleEvalCommand(1, "@admin", []string{"export"})
+	documents := server.sentDocuments()
+	require.Len(t, documents, 1)
+	assert.Contains(t, documents[0].Caption, "2 examples: 0 FUD, 2 clean")
+	lines := strings.Split(strings.TrimSpace(documents[0].Content), "\n")
+	require.Len(t, lines, 2, "a user's latest verdict overrules the earlier ones")
+	var example evalExample
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &example))
+	assert.Equal(t, EVAL_LOCAL_DATASET, example.Dataset)
+	assert.Equal(t, "Flipper", example.Username)
+	assert.Equal(t, "was joking", example.Note)
+	assert.Equal(t, EVAL_FORMAT_VERSION, example.Version)
+
+	shared := documents[0].Content + `{"version":1,"username":"@Shill","label":"fud","fud_type":"paid_promotion","messages":[{"tweet_id":"9","text":"buy now"}]}` + "\n" + `{"version":1,"username":"nobody","label":"maybe"}` + "\n"
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(shared))
+	}))
+	defer partner.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"version\":1}\nnot json\n"))
+	}))
+	defer broken.Close()
+
+	assert.Contains(t, reply("/eval import local "+partner.URL), "reserved")
+	assert.Contains(t, reply("/eval import dao-a "+broken.URL), "line 2")
+	imported := reply("/eval import dao-a " + partner.URL)
+	assert.Contains(t, imported, "Imported 3 examples as dataset dao-a")
+	assert.Contains(t, imported, "1 examples without")
+	assert.Contains(t, reply("/eval import dao-a "+partner.URL), "Imported 3 examples", "a new version replaces the dataset")
+
+	list := reply("/eval list")
+	assert.Contains(t, list, "<b>local</b>: 1 FUD, 2 clean")
+	assert.Contains(t, list, "<b>dao-a</b>: 1 FUD, 2 clean")
+	counts, err := db.CountLabeledVerdicts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{LABEL_FUD: 1, LABEL_CLEAN: 2}, counts, "imported examples stay out of this deployment's verdicts")
+	verdict, err := db.GetLatestLabeledVerdict("u1")
+	require.NoError(t, err)
+	assert.Empty(t, verdict.Dataset)
+
+	bot.handleEvalCommand(1, "@admin", []string{"export", "all"})
+	documents = server.sentDocuments()
+	require.Len(t, documents, 2)
+	assert.Contains(t, documents[1].Caption, "5 examples: 1 FUD, 4 clean")
+	assert.Contains(t, documents[1].Content, `"dataset":"dao-a","username":"Shill","label":"fud","fud_type":"paid_promotion"`)
+
+	assert.Contains(t, reply("/eval remove local"), "Usage")
+	assert.Contains(t, reply("/eval remove dao-a"), "removed (3 examples)")
+	assert.Contains(t, reply("/eval remove dao-a"), "not found")
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types of the state change log
+const (
+	EVENT_FUD_USER_ADDED    = "fud_user.added"
+	EVENT_FUD_USER_REMOVED  = "fud_user.removed"
+	EVENT_FUD_USER_RESTORED = "fud_user.restored"
+	EVENT_USER_STATUS       = "user.status"
+	EVENT_VERDICT           = "verdict"
+	EVENT_CONFIG_CHANGED    = "config.changed"
+	EVENT_FOLLOW_UP         = "follow_up.completed"
+)
+
+const (
+	EVENTS_SHOWN          = 20
+	EVENTS_API_MAX        = 1000
+	EVENTS_STREAM_POLL    = time.Second
+	EVENTS_STREAM_BATCH   = 100
+	EVENTS_SUBJECT_CONFIG = "config"
+)
+
+// recordEvent appends a state change to the event log. A failure is logged, the change itself stands.
+func (s *DatabaseService) recordEvent(eventType string, subject string, actor string, data interface{}) {
+	event := EventModel{CreatedAt: time.Now(), Type: eventType, Subject: subject, Actor: actor}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("Failed to encode %s event of %s: %v", eventType, subject, err)
+			return
+		}
+		event.Data = string(encoded)
+	}
+	if err := s.db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record %s event of %s: %v", eventType, subject, err)
+	}
+}
+
+// RecordConfigChange logs a setting changed outside the database, such as the log level
+func (s *DatabaseService) RecordConfigChange(setting string, actor string, from string, to string) {
+	s.recordEvent(EVENT_CONFIG_CHANGED, setting, actor, map[string]string{"from": from, "to": to})
+}
+
+// changedFields lists the JSON fields that differ between two versions of a settings row,
+// leaving out bookkeeping fields that change without anyone configuring anything
+func changedFields(before interface{}, after interface{}, ignore ...string) map[string]interface{} {
+	decode := func(value interface{}) map[string]interface{} {
+		fields := make(map[string]interface{})
+		encoded, _ := json.Marshal(value)
+		json.Unmarshal(encoded, &fields)
+		return fields
+	}
+	old, current := decode(before), decode(after)
+	for _, field := range append(ignore, "ID", "CreatedAt", "UpdatedAt", "DeletedAt") {
+		delete(old, field)
+		delete(current, field)
+	}
+	changed := make(map[string]interface{})
+	for field, value := range current {
+		if !reflect.DeepEqual(old[field], value) {
+			changed[field] = value
+		}
+	}
+	for field := range old {
+		if _, ok := current[field]; !ok {
+			changed[field] = nil
+		}
+	}
+	return changed
+}
+
+// handleEventsCommand shows the latest state changes: /events [type] [N]
+func (b *BotController) handleEventsCommand(chatID int64, args []string) {
+	limit := EVENTS_SHOWN
+	eventType := ""
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			limit = min(n, EVENTS_API_MAX)
+		} else {
+			eventType = arg
+		}
+	}
+
+	events, err := b.dbService.GetLatestEvents(eventType, limit)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading events: %v", err))
+		return
+	}
+	if len(events) == 0 {
+		b.SendMessage(chatID, "📭 No events recorded")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("📜 <b>Latest %d events</b>\n\n", len(events)))
+	for _, event := range events {
+		message.WriteString(fmt.Sprintf("#%d %s <b>%s</b> %s", event.ID, event.CreatedAt.UTC().Format("01-02 15:04"), event.Type, html.EscapeString(event.Subject)))
+		if event.Actor != "" {
+			message.WriteString(" by " + html.EscapeString(event.Actor))
+		}
+		if event.Data != "" {
+			message.WriteString(fmt.Sprintf("\n<code>%s</code>", html.EscapeString(truncateEventData(event.Data))))
+		}
+		message.WriteString("\n")
+	}
+	message.WriteString("\nExternal systems can follow the log at /api/events/stream")
+	b.SendMessage(chatID, message.String())
+}
+
+func truncateEventData(data string) string {
+	if len(data) <= 200 {
+		return data
+	}
+	return strings.ToValidUTF8(data[:200], "") + "…"
+}
+
+// apiEvent is an event with its details as JSON instead of a string
+type apiEvent struct {
+	ID        uint            `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Type      string          `json:"type"`
+	Subject   string          `json:"subject"`
+	Actor     string          `json:"actor,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+func toAPIEvent(event EventModel) apiEvent {
+	converted := apiEvent{ID: event.ID, CreatedAt: event.CreatedAt, Type: event.Type, Subject: event.Subject, Actor: event.Actor}
+	if event.Data != "" {
+		converted.Data = json.RawMessage(event.Data)
+	}
+	return converted
+}
+
+// eventsQuery reads ?after=ID&type=... shared by the paged and the streaming endpoint
+func eventsQuery(r *http.Request) (uint, string, error) {
+	var after uint64
+	if value := r.URL.Query().Get("after"); value != "" {
+		var err error
+		after, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("after must be an event ID")
+		}
+	}
+	return uint(after), r.URL.Query().Get("type"), nil
+}
+
+// handleEventsRequest serves GET /api/events?after=ID&type=...&limit=N, the oldest events after ID first.
+// Consumers mirror the state by passing the next_after of the previous page.
+func handleEventsRequest(w http.ResponseWriter, r *http.Request, dbService *DatabaseService) {
+	after, eventType, err := eventsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := EVENTS_API_MAX
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, EVENTS_API_MAX)
+	}
+
+	events, err := dbService.GetEventsAfter(after, eventType, limit)
+	if err != nil {
+		log.Printf("Failed to load events: %v", err)
+		http.Error(w, "failed to load events", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Events    []apiEvent `json:"events"`
+		NextAfter uint       `json:"next_after"`
+	}{Events: make([]apiEvent, 0, len(events)), NextAfter: after}
+	for _, event := range events {
+		response.Events = append(response.Events, toAPIEvent(event))
+		response.NextAfter = event.ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleEventStream serves GET /api/events/stream?after=ID&type=..., newline-delimited JSON events
+// written as they are appended until the client disconnects
+func handleEventStream(w http.ResponseWriter, r *http.Request, dbService *DatabaseService, poll time.Duration) {
+	after, eventType, err := eventsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		events, err := dbService.GetEventsAfter(after, eventType, EVENTS_STREAM_BATCH)
+		if err != nil {
+			log.Printf("Event stream stopped: %v", err)
+			return
+		}
+		for _, event := range events {
+			if err := encoder.Encode(toAPIEvent(event)); err != nil {
+				return
+			}
+			after = event.ID
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+		if len(events) == EVENTS_STREAM_BATCH {
+			continue
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
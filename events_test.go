@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseService_EventLog(t *testing.T) {
+	db := setupTestDB(t)
+	types := func() []string {
+		events, err := db.GetEventsAfter(0, "", EVENTS_API_MAX)
+		require.NoError(t, err)
+		var types []string
+		for _, event := range events {
+			types = append(types, event.Type+" "+event.Subject)
+		}
+		return types
+	}
+
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "e1", Username: "eventful", FUDType: "trojan_horse"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "e1", Username: "eventful", FUDType: "trojan_horse"}))
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "e1", Username: "eventful", Label: LABEL_FUD, LabeledBy: "@mod"}))
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "e1", Label: LABEL_FUD, Dataset: "partner"}))
+	require.NoError(t, db.DeleteFUDUser("e1"))
+	require.NoError(t, db.DeleteFUDUser("e1"))
+
+	settings, err := db.GetChatSettings(9)
+	require.NoError(t, err)
+	now := time.Now()
+	settings.LastDigestAt = &now
+	require.NoError(t, db.SaveChatSettings(settings))
+	settings.Verbosity = VERBOSITY_COMPACT
+	require.NoError(t, db.SaveChatSettings(settings))
+
+	assert.Equal(t, []string{
+		"fud_user.added e1",
+		"verdict e1",
+		"fud_user.removed e1",
+		"config.changed chat:9",
+	}, types(), "one event per change, digest bookkeeping and imported datasets are left out")
+
+	latest, err := db.GetLatestEvents(EVENT_CONFIG_CHANGED, 1)
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.JSONEq(t, `{"verbosity":"compact"}`, latest[0].Data)
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.handleEventsCommand(1, []string{"verdict"})
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "<b>verdict</b> e1 by @mod")
+}
+
+func TestEventsAPI(t *testing.T) {
+	db := setupTestDB(t)
+	db.RecordConfigChange("log_level", "@admin", "info", "debug")
+	db.RecordConfigChange("log_level", "@admin", "debug", "warn")
+	handler := newAPIHandler(db)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/events?limit=1", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var page struct {
+		Events    []apiEvent `json:"events"`
+		NextAfter uint       `json:"next_after"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Events, 1)
+	assert.JSONEq(t, `{"from":"info","to":"debug"}`, string(page.Events[0].Data))
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/events?after=x", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleEventStream(w, r, db, 10*time.Millisecond)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, "GET", server.URL+"?after="+jsonNumber(page.NextAfter), nil)
+	require.NoError(t, err)
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, "application/x-ndjson", response.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(response.Body)
+	require.True(t, lines.Scan())
+	var event apiEvent
+	require.NoError(t, json.Unmarshal(lines.Bytes(), &event))
+	assert.JSONEq(t, `{"from":"debug","to":"warn"}`, string(event.Data), "the stream resumes after the given ID")
+
+	db.RecordConfigChange("log_level", "@admin", "warn", "info")
+	require.True(t, lines.Scan(), "events appended later are streamed")
+	require.NoError(t, json.Unmarshal(lines.Bytes(), &event))
+	assert.JSONEq(t, `{"from":"warn","to":"info"}`, string(event.Data))
+}
+
+func jsonNumber(n uint) string {
+	encoded, _ := json.Marshal(n)
+	return string(encoded)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// EVIDENCE_MARKER starts the chain of custody trailer; the hash covers every byte before it
+const EVIDENCE_MARKER = "\n===== CHAIN OF CUSTODY =====\n"
+
+// evidenceSeal is the SHA-256 of an export and, when a signing key is configured, its Ed25519 signature
+type evidenceSeal struct {
+	Digest    string // hex SHA-256 of the content
+	Signature string // hex Ed25519 signature of the raw digest, empty when unsigned
+	PublicKey string // hex public key the signature verifies with
+	SealedAt  time.Time
+}
+
+// evidenceSigningKey reads the hex Ed25519 seed from ENV_EVIDENCE_SIGNING_KEY, nil when not configured
+func evidenceSigningKey() (ed25519.PrivateKey, error) {
+	raw := strings.TrimSpace(os.Getenv(ENV_EVIDENCE_SIGNING_KEY))
+	if raw == "" {
+		return nil, nil
+	}
+	seed, err := hex.DecodeString(raw)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s must be a hex encoded %d byte Ed25519 seed", ENV_EVIDENCE_SIGNING_KEY, ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// sealEvidence hashes the content and signs the hash if a key is configured. A broken key
+// is logged and the export goes out hashed but unsigned rather than not at all.
+func sealEvidence(content []byte) evidenceSeal {
+	digest := sha256.Sum256(content)
+	seal := evidenceSeal{Digest: hex.EncodeToString(digest[:]), SealedAt: time.Now().UTC()}
+
+	key, err := evidenceSigningKey()
+	if err != nil {
+		log.Printf("Warning: exports are not signed: %v", err)
+	}
+	if key != nil {
+		seal.Signature = hex.EncodeToString(ed25519.Sign(key, digest[:]))
+		seal.PublicKey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	}
+	return seal
+}
+
+// sealEvidenceText appends the chain of custody trailer to a text export
+func sealEvidenceText(content string) (string, evidenceSeal) {
+	seal := sealEvidence([]byte(content))
+	var trailer strings.Builder
+	trailer.WriteString(EVIDENCE_MARKER)
+	trailer.WriteString(fmt.Sprintf("SHA-256: %s\n", seal.Digest))
+	if seal.Signature != "" {
+		trailer.WriteString(fmt.Sprintf("Signature (Ed25519 over the SHA-256 digest): %s\n", seal.Signature))
+		trailer.WriteString(fmt.Sprintf("Public key: %s\n", seal.PublicKey))
+	}
+	trailer.WriteString(fmt.Sprintf("Sealed: %s\n", seal.SealedAt.Format(time.RFC3339)))
+	trailer.WriteString("The hash covers every byte above the CHAIN OF CUSTODY line. Check it with: go run ./cmd/verify_export <file>\n")
+	return content + trailer.String(), seal
+}
+
+// verifyEvidenceText checks a sealed text export against its trailer. When publicKey is set
+// the signature is required and must verify with that key.
+func verifyEvidenceText(sealed string, publicKey ed25519.PublicKey) error {
+	i := strings.LastIndex(sealed, EVIDENCE_MARKER)
+	if i < 0 {
+		return fmt.Errorf("no chain of custody trailer found")
+	}
+	content, trailer := sealed[:i], sealed[i+len(EVIDENCE_MARKER):]
+
+	fields := map[string]string{}
+	for _, line := range strings.Split(trailer, "\n") {
+		if name, value, ok := strings.Cut(line, ": "); ok {
+			fields[name] = value
+		}
+	}
+	digest := sha256.Sum256([]byte(content))
+	if hex.EncodeToString(digest[:]) != fields["SHA-256"] {
+		return fmt.Errorf("content does not match its SHA-256, the export was modified")
+	}
+	if publicKey == nil {
+		return nil
+	}
+	signature, err := hex.DecodeString(fields["Signature (Ed25519 over the SHA-256 digest)"])
+	if err != nil || !ed25519.Verify(publicKey, digest[:], signature) {
+		return fmt.Errorf("signature does not verify with the given public key")
+	}
+	return nil
+}
+
+// caption renders the seal for a Telegram document caption
+func (s evidenceSeal) caption() string {
+	caption := fmt.Sprintf("\n🔐 SHA-256: <code>%s</code>", s.Digest)
+	if s.Signature != "" {
+		caption += fmt.Sprintf("\n✍️ Signed with key <code>%s…</code>", s.PublicKey[:16])
+	}
+	return caption
+}
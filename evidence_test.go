@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealEvidenceText(t *testing.T) {
+	content := "FULL MESSAGE HISTORY FOR @SHADY\n[1] this project is a rug\n"
+
+	t.Run("Hash only", func(t *testing.T) {
+		t.Setenv(ENV_EVIDENCE_SIGNING_KEY, "")
+		sealed, seal := sealEvidenceText(content)
+		assert.True(t, strings.HasPrefix(sealed, content))
+		assert.Contains(t, sealed, "SHA-256: "+seal.Digest)
+		assert.Empty(t, seal.Signature)
+		assert.NoError(t, verifyEvidenceText(sealed, nil))
+
+		tampered := strings.Replace(sealed, "rug", "gem", 1)
+		assert.Error(t, verifyEvidenceText(tampered, nil))
+		assert.Error(t, verifyEvidenceText(content, nil), "unsealed content has no trailer")
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		seed := make([]byte, ed25519.SeedSize)
+		seed[0] = 7
+		t.Setenv(ENV_EVIDENCE_SIGNING_KEY, hex.EncodeToString(seed))
+		publicKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+		sealed, seal := sealEvidenceText(content)
+		assert.Equal(t, hex.EncodeToString(publicKey), seal.PublicKey)
+		assert.Contains(t, seal.caption(), seal.Digest)
+		assert.NoError(t, verifyEvidenceText(sealed, publicKey))
+
+		otherKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+		assert.Error(t, verifyEvidenceText(sealed, otherKey))
+	})
+
+	t.Run("Broken key still hashes", func(t *testing.T) {
+		t.Setenv(ENV_EVIDENCE_SIGNING_KEY, "not-hex")
+		sealed, seal := sealEvidenceText(content)
+		assert.Empty(t, seal.Signature)
+		require.NoError(t, verifyEvidenceText(sealed, nil))
+	})
+}
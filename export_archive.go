@@ -0,0 +1,170 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	TELEGRAM_DOCUMENT_LIMIT  = 50 << 20 // Bot API upload limit of sendDocument
+	EXPORT_ARCHIVE_THRESHOLD = 20 << 20 // exports above this are sent as a ZIP archive
+	EXPORT_ARCHIVE_PART_SIZE = 45 << 20 // archive parts stay below the document limit with room for the upload overhead
+)
+
+// exportArchive is a ZIP archive of an export in its own temp dir, split into numbered parts
+// (name.zip.001, name.zip.002, ...) when it is larger than a part
+type exportArchive struct {
+	dir   string
+	parts []string
+}
+
+// archiveExport streams content into a ZIP archive in a temp dir, so large exports are never
+// compressed in memory. Archives larger than partSize are split into parts to be joined with cat.
+func archiveExport(filename string, content io.Reader, partSize int64) (*exportArchive, error) {
+	dir, err := os.MkdirTemp("", "export-*")
+	if err != nil {
+		return nil, err
+	}
+	archive := &exportArchive{dir: dir}
+	zipPath := filepath.Join(dir, strings.TrimSuffix(filename, filepath.Ext(filename))+".zip")
+	if err := writeZip(zipPath, filename, content); err != nil {
+		archive.remove()
+		return nil, err
+	}
+
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		archive.remove()
+		return nil, err
+	}
+	if info.Size() <= partSize {
+		archive.parts = []string{zipPath}
+		return archive, nil
+	}
+	if err := archive.split(zipPath, partSize); err != nil {
+		archive.remove()
+		return nil, err
+	}
+	return archive, nil
+}
+
+func writeZip(zipPath string, filename string, content io.Reader) error {
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	entry, err := writer.CreateHeader(&zip.FileHeader{Name: filename, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// split cuts the archive into parts of partSize bytes and removes the whole archive
+func (a *exportArchive) split(zipPath string, partSize int64) error {
+	source, err := os.Open(zipPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	for i := 1; ; i++ {
+		partPath := fmt.Sprintf("%s.%03d", zipPath, i)
+		part, err := os.Create(partPath)
+		if err != nil {
+			return err
+		}
+		written, err := io.CopyN(part, source, partSize)
+		part.Close()
+		if written > 0 {
+			a.parts = append(a.parts, partPath)
+		} else {
+			os.Remove(partPath)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	source.Close()
+	return os.Remove(zipPath)
+}
+
+func (a *exportArchive) remove() {
+	os.RemoveAll(a.dir)
+}
+
+// sendExportDocument sends an export as a document. Exports above EXPORT_ARCHIVE_THRESHOLD are
+// zipped, and archives above the Telegram document limit are sent in parts, each with its own caption.
+func (b *BotController) sendExportDocument(chatID int64, filename string, content string, caption string) error {
+	if len(content) <= EXPORT_ARCHIVE_THRESHOLD {
+		dir, err := os.MkdirTemp("", "export-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, filename)
+		if err := b.writeToFile(path, content); err != nil {
+			return err
+		}
+		return b.SendDocument(chatID, path, caption)
+	}
+
+	return b.sendExportArchive(chatID, filename, strings.NewReader(content), caption)
+}
+
+// sendExportFile sends an export streamed to a file, zipped and split like sendExportDocument
+func (b *BotController) sendExportFile(chatID int64, path string, caption string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() <= EXPORT_ARCHIVE_THRESHOLD {
+		return b.SendDocument(chatID, path, caption)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return b.sendExportArchive(chatID, filepath.Base(path), file, caption)
+}
+
+func (b *BotController) sendExportArchive(chatID int64, filename string, content io.Reader, caption string) error {
+	archive, err := archiveExport(filename, content, EXPORT_ARCHIVE_PART_SIZE)
+	if err != nil {
+		return fmt.Errorf("failed to archive the export: %w", err)
+	}
+	defer archive.remove()
+
+	if len(archive.parts) == 1 {
+		return b.SendDocument(chatID, archive.parts[0], caption+"\n🗜 Compressed as ZIP")
+	}
+	zipName := strings.TrimSuffix(filepath.Base(archive.parts[0]), filepath.Ext(archive.parts[0]))
+	for i, part := range archive.parts {
+		partCaption := fmt.Sprintf("🗜 <b>%s</b> part %d/%d", zipName, i+1, len(archive.parts))
+		if i == 0 {
+			partCaption = caption + "\n" + partCaption + fmt.Sprintf("\nJoin the parts with: <code>cat %s.* &gt; %s</code>", zipName, zipName)
+		}
+		if err := b.SendDocument(chatID, part, partCaption); err != nil {
+			return fmt.Errorf("failed to send part %d/%d: %w", i+1, len(archive.parts), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveExport_SplitsIntoJoinableParts(t *testing.T) {
+	// Random hex barely compresses, so the archive needs several parts
+	random := make([]byte, 64<<10)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	content := hex.EncodeToString(random)
+
+	archive, err := archiveExport("alice_messages.csv", strings.NewReader(content), 16<<10)
+	require.NoError(t, err)
+	defer archive.remove()
+	require.Greater(t, len(archive.parts), 1)
+	assert.Equal(t, "alice_messages.zip.001", filepath.Base(archive.parts[0]))
+
+	var joined bytes.Buffer
+	for _, part := range archive.parts {
+		data, err := os.ReadFile(part)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(data), 16<<10)
+		joined.Write(data)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(joined.Bytes()), int64(joined.Len()))
+	require.NoError(t, err)
+	require.Len(t, reader.File, 1)
+	assert.Equal(t, "alice_messages.csv", reader.File[0].Name)
+	entry, err := reader.File[0].Open()
+	require.NoError(t, err)
+	defer entry.Close()
+	unpacked, err := io.ReadAll(entry)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(unpacked))
+
+	archive.remove()
+	_, err = os.Stat(archive.dir)
+	assert.True(t, os.IsNotExist(err), "the temp dir is removed")
+}
+
+func TestArchiveExport_SmallArchiveIsOnePart(t *testing.T) {
+	archive, err := archiveExport("alice_messages.txt", strings.NewReader(strings.Repeat("gm ", 10000)), EXPORT_ARCHIVE_PART_SIZE)
+	require.NoError(t, err)
+	defer archive.remove()
+	require.Len(t, archive.parts, 1)
+	assert.Equal(t, "alice_messages.zip", filepath.Base(archive.parts[0]))
+}
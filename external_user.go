@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	EXTERNAL_TIMELINE_DEFAULT_TWEETS = 40
+	EXTERNAL_TIMELINE_MAX_PAGES      = 5 // the provider returns about 20 tweets a page
+)
+
+// externalTimelineLimit is how many public tweets /analyze fetches for a user without local data, 0 when disabled
+func externalTimelineLimit() int {
+	value := strings.TrimSpace(os.Getenv(ENV_EXTERNAL_TIMELINE_TWEETS))
+	if value == "" {
+		return EXTERNAL_TIMELINE_DEFAULT_TWEETS
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		logFor("analysis_task").Warn("invalid external timeline size, using the default", "value", value, "default", EXTERNAL_TIMELINE_DEFAULT_TWEETS)
+		return EXTERNAL_TIMELINE_DEFAULT_TWEETS
+	}
+	return limit
+}
+
+// fetchExternalTimeline stores the latest public tweets of a user the monitoring never saw, so a manual
+// analysis judges what they actually post. It reads at most limit tweets and EXTERNAL_TIMELINE_MAX_PAGES pages.
+func fetchExternalTimeline(twitterApi TwitterAPI, dbService *DatabaseService, username string, limit int) (int, error) {
+	stored := 0
+	cursor := ""
+	for page := 0; page < EXTERNAL_TIMELINE_MAX_PAGES && stored < limit; page++ {
+		resp, err := twitterApi.GetUserLastTweets(twitterapi.UserLastTweetsRequest{UserName: username, Cursor: cursor, IncludeReplies: true})
+		if err != nil {
+			if stored > 0 {
+				break
+			}
+			return 0, err
+		}
+		if resp.Status == "error" {
+			if stored > 0 {
+				break
+			}
+			if strings.Contains(strings.ToLower(resp.Msg), "not found") || strings.Contains(strings.ToLower(resp.Msg), "suspended") {
+				return 0, newTaskError(TASK_ERROR_USER_NOT_FOUND, fmt.Errorf("@%s: %s", username, resp.Msg))
+			}
+			return 0, fmt.Errorf("timeline of @%s: %s", username, resp.Msg)
+		}
+		for _, tweet := range resp.Data.Tweets {
+			if stored >= limit {
+				break
+			}
+			// Retweets and tweets of other authors are not the user's words
+			if tweet.RetweetedTweet != nil || !strings.EqualFold(tweet.Author.UserName, username) {
+				continue
+			}
+			storeTweetAndUserWithSource(dbService, tweet, TWEET_SOURCE_TIMELINE, "", "timeline of "+username)
+			stored++
+		}
+		if !resp.HasNextPage || resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	return stored, nil
+}
+
+// prepareExternalTimelineMessage gives the second step the public tweets of a user who is not active in the community
+func prepareExternalTimelineMessage(tweets []TweetModel) ClaudeMessage {
+	var message strings.Builder
+	message.WriteString("PUBLIC TIMELINE: the user has no activity in the monitored community, these are their latest public tweets, newest first:\n")
+	for _, tweet := range tweets {
+		message.WriteString(fmt.Sprintf("- [%s] %s\n", tweet.CreatedAt.UTC().Format("2006-01-02"), strings.ReplaceAll(tweet.Text, "\n", " ")))
+	}
+	return ClaudeMessage{ROLE_USER, message.String()}
+}
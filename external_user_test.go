@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_AnalyzeExternalUser(t *testing.T) {
+	db := setupTestDB(t)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	bot.analysisChannel = make(chan twitterapi.NewMessage, 2)
+	pages := 0
+	bot.twitterApi = &mockTwitterAPI{userLastTweets: func(req twitterapi.UserLastTweetsRequest) (*twitterapi.UserLastTweetsResponse, error) {
+		resp := &twitterapi.UserLastTweetsResponse{Status: "success"}
+		if req.UserName == "ghost" {
+			resp.Status, resp.Msg = "error", "User not found"
+			return resp, nil
+		}
+		pages++
+		for i := 0; i < 20; i++ {
+			tweet := twitterapi.Tweet{Id: fmt.Sprintf("t%d_%d", pages, i), Text: "rug incoming", CreatedAt: fmt.Sprintf("Mon Jan %02d 15:04:05 +0000 2024", 20-i)}
+			tweet.Author.Id, tweet.Author.UserName = "ext1", "outsider"
+			if i == 0 {
+				tweet.RetweetedTweet = &twitterapi.Tweet{Id: "original"}
+			}
+			resp.Data.Tweets = append(resp.Data.Tweets, tweet)
+		}
+		resp.HasNextPage, resp.NextCursor = true, fmt.Sprintf("page%d", pages+1)
+		return resp, nil
+	}}
+	t.Setenv(ENV_EXTERNAL_TIMELINE_TWEETS, "25")
+
+	_, _, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: "external", Username: "outsider", Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	bot.processAnalysisTask("external")
+
+	require.Len(t, bot.analysisChannel, 1)
+	message := <-bot.analysisChannel
+	assert.Equal(t, "ext1", message.Author.ID)
+	assert.Equal(t, "rug incoming", message.Text, "the latest public tweet replaces the placeholder")
+	assert.Equal(t, 2, pages, "the timeline stops at the configured size")
+	timeline, err := db.GetUserTweetsBySourceType("ext1", TWEET_SOURCE_TIMELINE, 100, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, timeline, 25, "retweets are not stored as the user's words")
+	task, err := db.GetAnalysisTask("external")
+	require.NoError(t, err)
+	assert.Equal(t, "ext1", task.UserID)
+
+	_, _, err = bot.createAnalysisTask(&AnalysisTaskModel{ID: "missing", Username: "ghost", Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	bot.processAnalysisTask("missing")
+	failed, err := db.GetAnalysisTask("missing")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_FAILED, failed.Status)
+	assert.Equal(t, TASK_ERROR_USER_NOT_FOUND, failed.ErrorCode)
+	assert.Empty(t, bot.analysisChannel)
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Setenv(ENV_EXTERNAL_TIMELINE_TWEETS, "0")
+		_, _, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: "offline", Username: "ghost", Status: ANALYSIS_STATUS_PENDING})
+		require.NoError(t, err)
+		bot.processAnalysisTask("offline")
+		require.Len(t, bot.analysisChannel, 1)
+		assert.Equal(t, "manual_analysis_ghost", (<-bot.analysisChannel).TweetID)
+	})
+}
+
+func TestPrepareExternalTimelineMessage(t *testing.T) {
+	message := prepareExternalTimelineMessage([]TweetModel{{Text: "line one\nline two"}})
+	assert.Contains(t, message.Content, "PUBLIC TIMELINE")
+	assert.Contains(t, message.Content, "line one line two")
+}
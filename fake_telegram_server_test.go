@@ -0,0 +1,569 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const FAKE_TELEGRAM_TOKEN = "test-token"
+
+type fakeTelegramMessage struct {
+	ChatID    int64
+	MessageID int64
+	Text      string
+	Silent    bool
+	ThreadID  int64
+}
+
+type fakeTelegramDocument struct {
+	ChatID   int64
+	Caption  string
+	FileName string
+	Content  string
+}
+
+// fakeTelegramPhoto is a sent photo, either fetched from Photo or uploaded with Content
+type fakeTelegramPhoto struct {
+	ChatID  int64
+	Caption string
+	Photo   string
+	Content string
+}
+
+type fakeTelegramAlbum struct {
+	ChatID  int64
+	Media   []TelegramInputMediaPhoto
+	Uploads map[string]string
+}
+
+// fakeTelegramServer mimics the subset of the Bot API used by TelegramClient:
+// getUpdates offsets, sequential message IDs, edit validation and 429 responses.
+type fakeTelegramServer struct {
+	*httptest.Server
+	mu            sync.Mutex
+	nextUpdateID  int64
+	nextMessageID int64
+	updates       []TelegramUpdate
+	messages      []fakeTelegramMessage
+	edits         []TelegramEditMessageRequest
+	documents     []fakeTelegramDocument
+	photos        []fakeTelegramPhoto
+	albums        []fakeTelegramAlbum
+	rejectPhotos  bool // answer sendPhoto like Telegram does for images it cannot resize
+	deletedTopics map[int64]bool
+	rateLimited   map[string]int
+	retryAfter    int
+}
+
+func newFakeTelegramServer(t testing.TB) *fakeTelegramServer {
+	server := &fakeTelegramServer{
+		nextUpdateID: 1,
+		rateLimited:  make(map[string]int),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newClient returns a real TelegramClient pointed at the fake server
+func (s *fakeTelegramServer) newClient(t testing.TB) *TelegramClient {
+	client, err := NewTelegramClient(FAKE_TELEGRAM_TOKEN, "")
+	require.NoError(t, err)
+	client.SetBaseURL(s.URL)
+	return client
+}
+
+// pushMessage queues an incoming user message for the next getUpdates call
+func (s *fakeTelegramServer) pushMessage(chatID int64, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var update TelegramUpdate
+	update.UpdateID = s.nextUpdateID
+	update.Message.MessageID = s.nextUpdateID
+	update.Message.Chat.ID = chatID
+	update.Message.Chat.Type = "private"
+	update.Message.From.ID = chatID
+	update.Message.From.FirstName = "Tester"
+	update.Message.From.Username = "tester"
+	update.Message.Date = time.Now().Unix()
+	update.Message.Text = text
+	s.nextUpdateID++
+	s.updates = append(s.updates, update)
+}
+
+// failWithTooManyRequests makes the next `times` calls of method answer with 429
+func (s *fakeTelegramServer) failWithTooManyRequests(method string, times int, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited[method] = times
+	s.retryAfter = retryAfter
+}
+
+func (s *fakeTelegramServer) messagesFor(chatID int64) []fakeTelegramMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []fakeTelegramMessage
+	for _, msg := range s.messages {
+		if msg.ChatID == chatID {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func (s *fakeTelegramServer) editsFor(messageID int64) []TelegramEditMessageRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []TelegramEditMessageRequest
+	for _, edit := range s.edits {
+		if edit.MessageID == messageID {
+			result = append(result, edit)
+		}
+	}
+	return result
+}
+
+func (s *fakeTelegramServer) sentDocuments() []fakeTelegramDocument {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeTelegramDocument(nil), s.documents...)
+}
+
+func (s *fakeTelegramServer) sentPhotos() []fakeTelegramPhoto {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeTelegramPhoto(nil), s.photos...)
+}
+
+func (s *fakeTelegramServer) sentAlbums() []fakeTelegramAlbum {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeTelegramAlbum(nil), s.albums...)
+}
+
+func (s *fakeTelegramServer) handle(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + FAKE_TELEGRAM_TOKEN + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		s.writeError(w, http.StatusUnauthorized, "Unauthorized", 0)
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, prefix)
+
+	s.mu.Lock()
+	if s.rateLimited[method] > 0 {
+		s.rateLimited[method]--
+		retryAfter := s.retryAfter
+		s.mu.Unlock()
+		s.writeError(w, http.StatusTooManyRequests, fmt.Sprintf("Too Many Requests: retry after %d", retryAfter), retryAfter)
+		return
+	}
+	s.mu.Unlock()
+
+	switch method {
+	case "getUpdates":
+		s.handleGetUpdates(w, r)
+	case "sendMessage":
+		s.handleSendMessage(w, r)
+	case "editMessageText":
+		s.handleEditMessage(w, r)
+	case "sendDocument":
+		s.handleSendDocument(w, r)
+	case "sendPhoto":
+		s.handleSendPhoto(w, r)
+	case "sendMediaGroup":
+		s.handleSendMediaGroup(w, r)
+	default:
+		s.writeError(w, http.StatusNotFound, "Not Found: method not found", 0)
+	}
+}
+
+func (s *fakeTelegramServer) handleGetUpdates(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+
+	s.mu.Lock()
+	// Like Telegram, requesting an offset confirms every earlier update
+	var pending []TelegramUpdate
+	for _, update := range s.updates {
+		if update.UpdateID >= offset {
+			pending = append(pending, update)
+		}
+	}
+	s.updates = pending
+	s.mu.Unlock()
+
+	s.writeResult(w, pending)
+}
+
+func (s *fakeTelegramServer) handleSendMessage(w http.ResponseWriter, r *http.Request) {
+	var req TelegramSendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: invalid json", 0)
+		return
+	}
+	if req.ChatID == 0 {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: chat not found", 0)
+		return
+	}
+	if req.Text == "" {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: message text is empty", 0)
+		return
+	}
+
+	s.mu.Lock()
+	if s.deletedTopics[req.MessageThreadID] {
+		s.mu.Unlock()
+		s.writeError(w, http.StatusBadRequest, "Bad Request: message thread not found", 0)
+		return
+	}
+	s.nextMessageID++
+	msg := fakeTelegramMessage{ChatID: req.ChatID, MessageID: s.nextMessageID, Text: req.Text, Silent: req.DisableNotification, ThreadID: req.MessageThreadID}
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	s.writeResult(w, map[string]interface{}{
+		"message_id": msg.MessageID,
+		"chat":       map[string]int64{"id": msg.ChatID},
+		"text":       msg.Text,
+	})
+}
+
+func (s *fakeTelegramServer) handleEditMessage(w http.ResponseWriter, r *http.Request) {
+	var req TelegramEditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: invalid json", 0)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, msg := range s.messages {
+		if msg.ChatID != req.ChatID || msg.MessageID != req.MessageID {
+			continue
+		}
+		if msg.Text == req.Text {
+			s.writeError(w, http.StatusBadRequest, "Bad Request: message is not modified", 0)
+			return
+		}
+		s.messages[i].Text = req.Text
+		s.edits = append(s.edits, req)
+		s.writeResult(w, map[string]interface{}{"message_id": msg.MessageID})
+		return
+	}
+
+	s.writeError(w, http.StatusBadRequest, "Bad Request: message to edit not found", 0)
+}
+
+func (s *fakeTelegramServer) handleSendDocument(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: invalid multipart form", 0)
+		return
+	}
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	file, header, err := r.FormFile("document")
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: there is no document in the request", 0)
+		return
+	}
+	defer file.Close()
+	content, _ := io.ReadAll(file)
+
+	s.mu.Lock()
+	s.nextMessageID++
+	messageID := s.nextMessageID
+	s.documents = append(s.documents, fakeTelegramDocument{
+		ChatID:   chatID,
+		Caption:  r.FormValue("caption"),
+		FileName: header.Filename,
+		Content:  string(content),
+	})
+	s.mu.Unlock()
+
+	s.writeResult(w, map[string]interface{}{"message_id": messageID})
+}
+
+func (s *fakeTelegramServer) handleSendPhoto(w http.ResponseWriter, r *http.Request) {
+	var photo fakeTelegramPhoto
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req TelegramSendPhotoRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Bad Request: invalid json", 0)
+			return
+		}
+		photo = fakeTelegramPhoto{ChatID: req.ChatID, Caption: req.Caption, Photo: req.Photo}
+	} else {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Bad Request: invalid multipart form", 0)
+			return
+		}
+		file, header, err := r.FormFile("photo")
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Bad Request: there is no photo in the request", 0)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+		photo = fakeTelegramPhoto{ChatID: chatID, Caption: r.FormValue("caption"), Photo: header.Filename, Content: string(content)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rejectPhotos {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: PHOTO_INVALID_DIMENSIONS", 0)
+		return
+	}
+	s.nextMessageID++
+	s.photos = append(s.photos, photo)
+	s.writeResult(w, map[string]interface{}{"message_id": s.nextMessageID})
+}
+
+func (s *fakeTelegramServer) handleSendMediaGroup(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: invalid multipart form", 0)
+		return
+	}
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	album := fakeTelegramAlbum{ChatID: chatID, Uploads: make(map[string]string)}
+	if err := json.Unmarshal([]byte(r.FormValue("media")), &album.Media); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: can't parse media JSON object", 0)
+		return
+	}
+	if len(album.Media) < 2 || len(album.Media) > 10 {
+		s.writeError(w, http.StatusBadRequest, "Bad Request: wrong number of messages in the media group", 0)
+		return
+	}
+	for _, media := range album.Media {
+		name, attached := strings.CutPrefix(media.Media, "attach://")
+		if !attached {
+			continue
+		}
+		file, _, err := r.FormFile(name)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Bad Request: file must be non-empty", 0)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		file.Close()
+		album.Uploads[name] = string(content)
+	}
+
+	s.mu.Lock()
+	s.albums = append(s.albums, album)
+	s.mu.Unlock()
+	s.writeResult(w, []interface{}{})
+}
+
+func (s *fakeTelegramServer) writeResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func (s *fakeTelegramServer) writeError(w http.ResponseWriter, code int, description string, retryAfter int) {
+	body := map[string]interface{}{"ok": false, "error_code": code, "description": description}
+	if retryAfter > 0 {
+		body["parameters"] = map[string]int{"retry_after": retryAfter}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestTelegramIntegration_CommandFlow(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	db := setupTestDB(t)
+	bot := newTestBotController(server.newClient(t), db)
+	bot.chatIDs[100] = true
+
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "alice", Name: "Alice"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "t1", Text: "wen moon", CreatedAt: time.Now(), UserID: "u1", Username: "alice"}))
+
+	t.Run("History command replies through the API", func(t *testing.T) {
+		server.pushMessage(100, "/history_alice")
+		require.NoError(t, bot.processUpdates())
+
+		assert.Eventually(t, func() bool {
+			return len(server.messagesFor(100)) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Contains(t, server.messagesFor(100)[0].Text, "wen moon")
+	})
+
+	t.Run("Processed updates are confirmed by offset", func(t *testing.T) {
+		require.NoError(t, bot.processUpdates())
+		time.Sleep(100 * time.Millisecond)
+		assert.Len(t, server.messagesFor(100), 1)
+	})
+
+	t.Run("Export command uploads a document", func(t *testing.T) {
+		server.pushMessage(100, "/export_alice")
+		require.NoError(t, bot.processUpdates())
+
+		assert.Eventually(t, func() bool {
+			return len(server.sentDocuments()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		doc := server.sentDocuments()[0]
+		// The handler removes its export file only after a delay
+		t.Cleanup(func() { os.Remove(doc.FileName) })
+		assert.Equal(t, int64(100), doc.ChatID)
+		assert.True(t, strings.HasPrefix(doc.FileName, "alice_messages_"))
+		assert.Contains(t, doc.Content, "wen moon")
+	})
+
+	t.Run("Rate limited getUpdates returns an error", func(t *testing.T) {
+		server.failWithTooManyRequests("getUpdates", 1, 3)
+		assert.Error(t, bot.processUpdates())
+		assert.NoError(t, bot.processUpdates())
+	})
+}
+
+func TestTelegramIntegration_Broadcast(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	bot := newTestBotController(server.newClient(t), setupTestDB(t))
+	for _, chatID := range []int64{1, 2, 3} {
+		bot.chatIDs[chatID] = true
+	}
+
+	t.Run("Every registered chat receives the message", func(t *testing.T) {
+		require.NoError(t, bot.BroadcastMessage("🚨 alert"))
+		for _, chatID := range []int64{1, 2, 3} {
+			msgs := server.messagesFor(chatID)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, "🚨 alert", msgs[0].Text)
+		}
+	})
+
+	t.Run("429 for one chat is reported without blocking others", func(t *testing.T) {
+		server.failWithTooManyRequests("sendMessage", 1, 5)
+		err := bot.BroadcastMessage("second")
+		assert.EqualError(t, err, "failed to send to 1 chats")
+
+		delivered := 0
+		for _, chatID := range []int64{1, 2, 3} {
+			delivered += len(server.messagesFor(chatID))
+		}
+		assert.Equal(t, 5, delivered)
+	})
+}
+
+func TestTelegramIntegration_SilentAlerts(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	db := setupTestDB(t)
+	bot := newTestBotController(server.newClient(t), db)
+	bot.chatIDs[1] = true
+	bot.chatIDs[2] = true
+
+	// Chat 1 wants every alert to ring, chat 2 keeps the default
+	bot.handleSilentCommand(1, []string{"none"})
+	settings, err := db.GetChatSettings(1)
+	require.NoError(t, err)
+	require.Equal(t, "none", settings.SilentUpTo)
+
+	alert := benchmarkAlert()
+	alert.AlertSeverity = "low"
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	alert.AlertSeverity = "critical"
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+
+	loud := server.messagesFor(1)
+	require.Len(t, loud, 3) // confirmation + two alerts
+	assert.False(t, loud[1].Silent)
+	assert.False(t, loud[2].Silent)
+
+	quiet := server.messagesFor(2)
+	require.Len(t, quiet, 2)
+	assert.True(t, quiet[0].Silent, "low alert should not ring")
+	assert.False(t, quiet[1].Silent, "critical alert should ring")
+}
+
+func TestTelegramIntegration_ProgressEdits(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	db := setupTestDB(t)
+	bot := newTestBotController(server.newClient(t), db)
+
+	messageID, err := bot.SendMessageWithID(200, "⏳ starting")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), messageID)
+
+	require.NoError(t, db.CreateAnalysisTask(&AnalysisTaskModel{
+		ID:             "task1",
+		Username:       "alice",
+		Status:         ANALYSIS_STATUS_RUNNING,
+		CurrentStep:    ANALYSIS_STEP_FOLLOWERS,
+		ProgressText:   "Loading followers",
+		TelegramChatID: 200,
+		MessageID:      messageID,
+		StartedAt:      time.Now(),
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		bot.monitorAnalysisProgress("task1")
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(server.editsFor(messageID)) >= 1
+	}, 3*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, db.CompleteAnalysisTask("task1", "{}"))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("progress monitor did not stop after task completion")
+	}
+
+	edits := server.editsFor(messageID)
+	assert.Contains(t, edits[len(edits)-1].Text, "Analysis Completed for @alice")
+	assert.Contains(t, server.messagesFor(200)[0].Text, "Analysis Completed")
+}
+
+func TestTelegramIntegration_RateLimitedTransport(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	transport := NewRateLimitedTransport(server.newClient(t))
+
+	t.Run("429 is retried after retry_after", func(t *testing.T) {
+		server.failWithTooManyRequests("sendMessage", 1, 1)
+		start := time.Now()
+		_, err := transport.SendMessage(TelegramSendMessageRequest{ChatID: 1, Text: "retried"})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		require.Len(t, server.messagesFor(1), 1)
+	})
+
+	t.Run("Long retry_after is returned to the caller", func(t *testing.T) {
+		server.failWithTooManyRequests("editMessageText", 1, 120)
+		err := transport.EditMessage(TelegramEditMessageRequest{ChatID: 1, MessageID: 1, Text: "edited"})
+		var apiErr *TelegramAPIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 120, apiErr.RetryAfter)
+		assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	})
+
+	t.Run("Messages to one chat are paced after the burst", func(t *testing.T) {
+		transport.privateRate = 10
+		start := time.Now()
+		for i := 0; i < TELEGRAM_CHAT_BURST+2; i++ {
+			_, err := transport.SendMessage(TelegramSendMessageRequest{ChatID: 2, Text: fmt.Sprintf("msg %d", i)})
+			require.NoError(t, err)
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+		assert.Len(t, server.messagesFor(2), TELEGRAM_CHAT_BURST+2)
+	})
+}
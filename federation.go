@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"math/bits"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	FEDERATION_TOKEN_HEADER         = "X-Federation-Token"
+	FEDERATION_INDICATORS_PATH      = "/api/federation/indicators"
+	FEDERATION_MAX_BODY             = 256 << 10
+	FEDERATION_MAX_INDICATORS       = 100
+	FEDERATION_ACCOUNT_HASH_LEN     = 32
+	FEDERATION_NARRATIVE_WINDOW     = 7 * 24 * time.Hour // how long a reported narrative fingerprint is matched
+	FEDERATION_FINGERPRINT_DISTANCE = 12                 // max differing bits of two fingerprints of the same narrative
+	FEDERATION_MIN_WORDS            = 6                  // shorter messages get no fingerprint, they match too easily
+	FEDERATION_SURGE_THRESHOLD      = 5                  // indicators from one peer within the window that warn the admins
+	FEDERATION_SURGE_WINDOW         = time.Hour
+	FEDERATION_PUSH_TIMEOUT         = 10 * time.Second
+	FEDERATION_STATUS_WINDOW        = 7 * 24 * time.Hour
+)
+
+var federationURLRegex = regexp.MustCompile(`https?://\S+`)
+
+// FederatedIndicator is a confirmed FUD detection shared between deployments. Both hashes are keyed
+// with the federation secret, so only deployments holding it can match them against their users.
+type FederatedIndicator struct {
+	AccountHash string    `json:"account_hash"`
+	Fingerprint string    `json:"fingerprint,omitempty"` // SimHash of the message narrative, missing for short messages
+	FUDType     string    `json:"fud_type"`
+	Severity    string    `json:"severity"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+type FederatedIndicatorBatch struct {
+	Indicators []FederatedIndicator `json:"indicators"`
+}
+
+// federationPeer is a deployment confirmed FUD accounts are pushed to
+type federationPeer struct {
+	Name  string
+	URL   string
+	Token string
+}
+
+// federationReceiver stores indicators sent by peers, implemented by BotController
+type federationReceiver interface {
+	receiveFederatedIndicators(peer string, indicators []FederatedIndicator) (int64, error)
+}
+
+// federationState remembers when the admins were last warned about a surge from each peer
+type federationState struct {
+	mu       sync.Mutex
+	warnedAt map[string]time.Time
+}
+
+// federationMatch is what peers reported about the author of a message or its narrative
+type federationMatch struct {
+	AccountPeers   []string
+	FUDTypes       []string
+	NarrativePeers []string
+}
+
+func (m federationMatch) empty() bool {
+	return len(m.AccountPeers) == 0 && len(m.NarrativePeers) == 0
+}
+
+// summary lists the matches for an alert, e.g. "account flagged by dao-a, dao-b as price_manipulation"
+func (m federationMatch) summary() []string {
+	var summary []string
+	if len(m.AccountPeers) > 0 {
+		line := "account flagged by " + strings.Join(m.AccountPeers, ", ")
+		if len(m.FUDTypes) > 0 {
+			line += " as " + strings.Join(m.FUDTypes, ", ")
+		}
+		summary = append(summary, line)
+	}
+	if len(m.NarrativePeers) > 0 {
+		summary = append(summary, "narrative seen by "+strings.Join(m.NarrativePeers, ", "))
+	}
+	return summary
+}
+
+// federationSecret is the secret shared by the federated deployments, federation is off without it
+func federationSecret() string {
+	return os.Getenv(ENV_FEDERATION_SECRET)
+}
+
+// loadFederationPeers reads ENV_FEDERATION_PEERS, e.g. "dao-a|https://fud.dao-a.xyz|t0ken,dao-b|https://...|..."
+func loadFederationPeers() ([]federationPeer, error) {
+	var peers []federationPeer
+	for _, entry := range strings.Split(os.Getenv(ENV_FEDERATION_PEERS), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "|")
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[2]) == "" ||
+			!(strings.HasPrefix(parts[1], "https://") || strings.HasPrefix(parts[1], "http://")) {
+			return nil, fmt.Errorf("invalid %s entry %q, expected name|https://host|token", ENV_FEDERATION_PEERS, entry)
+		}
+		peers = append(peers, federationPeer{Name: strings.TrimSpace(parts[0]), URL: strings.TrimSuffix(strings.TrimSpace(parts[1]), "/"), Token: strings.TrimSpace(parts[2])})
+	}
+	return peers, nil
+}
+
+// federationAccountHash is the keyed hash deployments know an account by
+func federationAccountHash(secret string, userID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("account:" + userID))
+	return hex.EncodeToString(mac.Sum(nil))[:FEDERATION_ACCOUNT_HASH_LEN]
+}
+
+// narrativeFingerprint is a 64-bit SimHash of the words and word pairs of a message, so reworded
+// copies of the same narrative differ in a few bits. Links, mentions and cashtags are left out, a brigade
+// pasting the same text at another community changes them first. Empty for short messages.
+func narrativeFingerprint(secret string, text string) string {
+	text = federationURLRegex.ReplaceAllString(text, " ")
+	text = mentionRegex.ReplaceAllString(text, " ")
+	text = cashtagRegex.ReplaceAllString(text, " ")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < FEDERATION_MIN_WORDS {
+		return ""
+	}
+
+	var weights [64]int
+	for size := 1; size <= 2; size++ {
+		for i := 0; i+size <= len(words); i++ {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte("shingle:" + strings.Join(words[i:i+size], " ")))
+			hash := binary.BigEndian.Uint64(mac.Sum(nil))
+			for bit := 0; bit < 64; bit++ {
+				if hash&(1<<bit) != 0 {
+					weights[bit]++
+				} else {
+					weights[bit]--
+				}
+			}
+		}
+	}
+	var fingerprint uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fmt.Sprintf("%016x", fingerprint)
+}
+
+// fingerprintDistance counts the differing bits of two fingerprints, -1 when one is not valid
+func fingerprintDistance(a string, b string) int {
+	first, errA := hex.DecodeString(a)
+	second, errB := hex.DecodeString(b)
+	if errA != nil || errB != nil || len(first) != 8 || len(second) != 8 {
+		return -1
+	}
+	return bits.OnesCount64(binary.BigEndian.Uint64(first) ^ binary.BigEndian.Uint64(second))
+}
+
+// federatedMatches looks up what peers reported about the author of a message and its narrative
+func federatedMatches(dbService *DatabaseService, newMessage twitterapi.NewMessage) (federationMatch, error) {
+	var match federationMatch
+	secret := federationSecret()
+	if secret == "" {
+		return match, nil
+	}
+
+	reports, err := dbService.GetFederatedIndicatorsByAccount(federationAccountHash(secret, newMessage.Author.ID))
+	if err != nil {
+		return match, err
+	}
+	for _, report := range reports {
+		if !slices.Contains(match.AccountPeers, report.Peer) {
+			match.AccountPeers = append(match.AccountPeers, report.Peer)
+		}
+		if report.FUDType != "" && !slices.Contains(match.FUDTypes, report.FUDType) {
+			match.FUDTypes = append(match.FUDTypes, report.FUDType)
+		}
+	}
+
+	sort.Strings(match.AccountPeers)
+
+	fingerprint := narrativeFingerprint(secret, newMessage.Text)
+	if fingerprint == "" {
+		return match, nil
+	}
+	narratives, err := dbService.GetFederatedFingerprintsSince(time.Now().Add(-FEDERATION_NARRATIVE_WINDOW))
+	if err != nil {
+		return match, err
+	}
+	for _, narrative := range narratives {
+		distance := fingerprintDistance(fingerprint, narrative.Fingerprint)
+		if distance >= 0 && distance <= FEDERATION_FINGERPRINT_DISTANCE && !slices.Contains(match.NarrativePeers, narrative.Peer) {
+			match.NarrativePeers = append(match.NarrativePeers, narrative.Peer)
+		}
+	}
+	sort.Strings(match.NarrativePeers)
+	return match, nil
+}
+
+// prepareFederationMessage tells the second step what other communities reported
+func prepareFederationMessage(match federationMatch) ClaudeMessage {
+	return ClaudeMessage{
+		Role:    ROLE_USER,
+		Content: fmt.Sprintf("FEDERATION SIGNAL: partner communities running this detector reported: %s. They confirmed these detections independently, weigh it like a prior FUD record, but judge the current message on its own content.", strings.Join(match.summary(), "; ")),
+	}
+}
+
+// newFederationHandler serves POST /api/federation/indicators for peers holding a federation token
+func newFederationHandler(tokens map[string]string, receiver federationReceiver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, ok := signalSource(tokens, r.Header.Get(FEDERATION_TOKEN_HEADER))
+		if !ok {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		var batch FederatedIndicatorBatch
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, FEDERATION_MAX_BODY)).Decode(&batch)
+		if err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if len(batch.Indicators) == 0 || len(batch.Indicators) > FEDERATION_MAX_INDICATORS {
+			http.Error(w, fmt.Sprintf("indicators must contain 1 to %d entries", FEDERATION_MAX_INDICATORS), http.StatusBadRequest)
+			return
+		}
+		for i := range batch.Indicators {
+			if err := validateFederatedIndicator(&batch.Indicators[i]); err != nil {
+				http.Error(w, fmt.Sprintf("indicator %d: %v", i, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		stored, err := receiver.receiveFederatedIndicators(peer, batch.Indicators)
+		if err != nil {
+			log.Printf("Failed to store federated indicators from %s: %v", peer, err)
+			http.Error(w, "failed to store indicators", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"peer": peer, "received": len(batch.Indicators), "stored": stored})
+	})
+}
+
+// validateFederatedIndicator checks the hashes of an indicator and normalizes its fields
+func validateFederatedIndicator(indicator *FederatedIndicator) error {
+	indicator.AccountHash = strings.ToLower(indicator.AccountHash)
+	if decoded, err := hex.DecodeString(indicator.AccountHash); err != nil || len(decoded)*2 != FEDERATION_ACCOUNT_HASH_LEN {
+		return fmt.Errorf("account_hash must be %d hex characters", FEDERATION_ACCOUNT_HASH_LEN)
+	}
+	indicator.Fingerprint = strings.ToLower(indicator.Fingerprint)
+	if decoded, err := hex.DecodeString(indicator.Fingerprint); indicator.Fingerprint != "" && (err != nil || len(decoded) != 8) {
+		return fmt.Errorf("fingerprint must be 16 hex characters")
+	}
+	if len(indicator.FUDType) > 64 || len(indicator.Severity) > 16 {
+		return fmt.Errorf("fud_type or severity too long")
+	}
+	if indicator.DetectedAt.IsZero() || indicator.DetectedAt.After(time.Now()) {
+		indicator.DetectedAt = time.Now()
+	}
+	return nil
+}
+
+// receiveFederatedIndicators stores what a peer sent and warns the admins when a peer reports a
+// burst of accounts, a brigade moving between communities usually shows up like that first
+func (b *BotController) receiveFederatedIndicators(peer string, indicators []FederatedIndicator) (int64, error) {
+	stored, err := b.dbService.SaveFederatedIndicators(peer, indicators)
+	if err != nil {
+		return stored, err
+	}
+	log.Printf("🛰 Received %d federated indicators from %s, %d new", len(indicators), peer, stored)
+	if stored == 0 {
+		return stored, nil
+	}
+
+	recent, err := b.dbService.GetFederatedIndicatorsSince(peer, time.Now().Add(-FEDERATION_SURGE_WINDOW))
+	if err != nil {
+		log.Printf("Failed to count federated indicators from %s: %v", peer, err)
+		return stored, nil
+	}
+	if len(recent) < FEDERATION_SURGE_THRESHOLD || !b.federation.shouldWarn(peer) {
+		return stored, nil
+	}
+
+	types := map[string]int{}
+	for _, indicator := range recent {
+		types[indicator.FUDType]++
+	}
+	var breakdown []string
+	for fudType, count := range types {
+		breakdown = append(breakdown, fmt.Sprintf("%s: %d", html.EscapeString(fudType), count))
+	}
+	sort.Strings(breakdown)
+	message := fmt.Sprintf("🛰 <b>Federation warning</b>\n\n<b>%s</b> confirmed %d FUD accounts in the last %s.\n📋 %s\n\nA brigade may be moving between communities. Accounts and narratives they reported are escalated to detailed analysis here.\n\n/federation - Federation status",
+		html.EscapeString(peer), len(recent), FEDERATION_SURGE_WINDOW, strings.Join(breakdown, ", "))
+	for _, chatID := range adminChatIDs() {
+		go b.SendMessage(chatID, message)
+	}
+	return stored, nil
+}
+
+// shouldWarn reports whether the admins were not warned about the peer during the surge window
+func (f *federationState) shouldWarn(peer string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.warnedAt == nil {
+		f.warnedAt = map[string]time.Time{}
+	}
+	if time.Since(f.warnedAt[peer]) < FEDERATION_SURGE_WINDOW {
+		return false
+	}
+	f.warnedAt[peer] = time.Now()
+	return true
+}
+
+// publishFederatedIndicator pushes a confirmed detection to every configured peer. Heuristic alerts
+// and accounts that did not end up on the FUD list are not shared.
+func publishFederatedIndicator(dbService *DatabaseService, alert FUDAlertNotification) {
+	secret := federationSecret()
+	if secret == "" || !isFUDDetection(alert) || alert.FUDType == HEURISTIC_FUD_TYPE || alert.BlocklistSource != "" || alert.FUDUserID == "" {
+		return
+	}
+	fudUser, err := dbService.GetFUDUser(alert.FUDUserID)
+	if err != nil {
+		return
+	}
+	peers, err := loadFederationPeers()
+	if err != nil {
+		log.Printf("Federation push disabled: %v", err)
+		return
+	}
+	if len(peers) == 0 {
+		return
+	}
+
+	batch := FederatedIndicatorBatch{Indicators: []FederatedIndicator{{
+		AccountHash: federationAccountHash(secret, alert.FUDUserID),
+		Fingerprint: narrativeFingerprint(secret, alert.MessagePreview),
+		FUDType:     fudUser.FUDType,
+		Severity:    alert.AlertSeverity,
+		DetectedAt:  fudUser.DetectedAt,
+	}}}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		log.Printf("Failed to encode federated indicator: %v", err)
+		return
+	}
+	client := &http.Client{Timeout: FEDERATION_PUSH_TIMEOUT}
+	for _, peer := range peers {
+		err := pushFederatedIndicators(client, peer, body)
+		if err != nil {
+			log.Printf("Failed to share FUD account %s with %s: %v", alert.FUDUsername, peer.Name, err)
+			continue
+		}
+		log.Printf("🛰 Shared FUD account %s with %s", alert.FUDUsername, peer.Name)
+	}
+}
+
+func pushFederatedIndicators(client *http.Client, peer federationPeer, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, peer.URL+FEDERATION_INDICATORS_PATH, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(FEDERATION_TOKEN_HEADER, peer.Token)
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// handleFederationCommand shows the federation configuration and what peers reported recently
+func (b *BotController) handleFederationCommand(chatID int64) {
+	var message strings.Builder
+	message.WriteString("🛰 <b>Federation</b>\n\n")
+	if federationSecret() == "" {
+		message.WriteString(fmt.Sprintf("⏸ Disabled, set %s to the secret shared with partner deployments.", ENV_FEDERATION_SECRET))
+		b.SendMessage(chatID, message.String())
+		return
+	}
+
+	peers, err := loadFederationPeers()
+	if err != nil {
+		message.WriteString(fmt.Sprintf("❌ Outbound: %s\n", html.EscapeString(err.Error())))
+	} else if len(peers) == 0 {
+		message.WriteString(fmt.Sprintf("📤 Outbound: no peers, set %s to share confirmed FUD accounts\n", ENV_FEDERATION_PEERS))
+	} else {
+		var names []string
+		for _, peer := range peers {
+			names = append(names, html.EscapeString(peer.Name))
+		}
+		message.WriteString(fmt.Sprintf("📤 Sharing with: %s\n", strings.Join(names, ", ")))
+	}
+	tokens, err := parseSourceTokens(ENV_FEDERATION_TOKENS, os.Getenv(ENV_FEDERATION_TOKENS))
+	if err != nil || len(tokens) == 0 {
+		message.WriteString(fmt.Sprintf("📥 Inbound: off, set %s and %s to receive\n", ENV_FEDERATION_TOKENS, ENV_API_ADDR))
+	} else {
+		message.WriteString(fmt.Sprintf("📥 Accepting indicators from %d peers\n", len(tokens)))
+	}
+
+	received, err := b.dbService.GetFederatedIndicatorsSince("", time.Now().Add(-FEDERATION_STATUS_WINDOW))
+	if err != nil {
+		message.WriteString(fmt.Sprintf("\n❌ Error loading indicators: %v", err))
+		b.SendMessage(chatID, message.String())
+		return
+	}
+	message.WriteString(fmt.Sprintf("\n📊 <b>Received in the last 7 days:</b> %d\n", len(received)))
+	perPeer := map[string]int{}
+	for _, indicator := range received {
+		perPeer[indicator.Peer]++
+	}
+	var peerNames []string
+	for peer := range perPeer {
+		peerNames = append(peerNames, peer)
+	}
+	sort.Slice(peerNames, func(i, j int) bool { return perPeer[peerNames[i]] > perPeer[peerNames[j]] })
+	for _, peer := range peerNames {
+		message.WriteString(fmt.Sprintf("• %s: %d\n", html.EscapeString(peer), perPeer[peer]))
+	}
+	b.SendMessage(chatID, message.String())
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNarrativeFingerprint(t *testing.T) {
+	original := "the team sold every token last night and the liquidity is gone, get out before it hits zero https://t.co/abc @victim $SCAM"
+	reworded := "the team sold every token last night and the liquidity is gone, get out now before it hits zero @someone_else"
+	unrelated := "great AMA today, the roadmap for the next quarter looks solid and the devs answered every question"
+
+	fingerprint := narrativeFingerprint("s3cret", original)
+	require.Len(t, fingerprint, 16)
+	assert.LessOrEqual(t, fingerprintDistance(fingerprint, narrativeFingerprint("s3cret", reworded)), FEDERATION_FINGERPRINT_DISTANCE)
+	assert.Greater(t, fingerprintDistance(fingerprint, narrativeFingerprint("s3cret", unrelated)), FEDERATION_FINGERPRINT_DISTANCE)
+	assert.NotEqual(t, fingerprint, narrativeFingerprint("other", original), "fingerprints are keyed with the secret")
+	assert.Empty(t, narrativeFingerprint("s3cret", "rug pull $SCAM"), "short messages get no fingerprint")
+	assert.Equal(t, -1, fingerprintDistance(fingerprint, "xyz"))
+
+	assert.Len(t, federationAccountHash("s3cret", "123"), FEDERATION_ACCOUNT_HASH_LEN)
+	assert.NotEqual(t, federationAccountHash("s3cret", "123"), federationAccountHash("other", "123"))
+}
+
+func TestFederationHandler(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	t.Setenv(ENV_FEDERATION_SECRET, "s3cret")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	handler := newFederationHandler(map[string]string{"t0ken": "dao-a"}, bot)
+	post := func(token string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, FEDERATION_INDICATORS_PATH, strings.NewReader(body))
+		request.Header.Set(FEDERATION_TOKEN_HEADER, token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	batch := func(indicators ...FederatedIndicator) string {
+		data, err := json.Marshal(FederatedIndicatorBatch{Indicators: indicators})
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	shady := FederatedIndicator{AccountHash: federationAccountHash("s3cret", "shady"), FUDType: "price_manipulation", Severity: "high"}
+	assert.Equal(t, http.StatusForbidden, post("wrong", batch(shady)).Code)
+	assert.Equal(t, http.StatusBadRequest, post("t0ken", `{"indicators":[]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("t0ken", batch(FederatedIndicator{AccountHash: "shady"})).Code, "raw IDs are refused")
+	assert.Equal(t, http.StatusBadRequest, post("t0ken", batch(FederatedIndicator{AccountHash: shady.AccountHash, Fingerprint: "abc"})).Code)
+
+	require.Equal(t, http.StatusAccepted, post("t0ken", batch(shady)).Code)
+	recorder := post("t0ken", batch(shady))
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"stored":0`, "the same indicator is stored once")
+
+	message := twitterapi.NewMessage{Text: "gm"}
+	message.Author.ID = "shady"
+	match, err := federatedMatches(db, message)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dao-a"}, match.AccountPeers)
+	assert.Equal(t, []string{"account flagged by dao-a as price_manipulation"}, match.summary())
+
+	t.Run("Narrative match", func(t *testing.T) {
+		text := "the team sold every token last night and the liquidity is gone, get out before it hits zero"
+		other := FederatedIndicator{AccountHash: federationAccountHash("s3cret", "someone"), Fingerprint: narrativeFingerprint("s3cret", text)}
+		require.Equal(t, http.StatusAccepted, post("t0ken", batch(other)).Code)
+
+		message := twitterapi.NewMessage{Text: "The team sold every token last night and the liquidity is gone!! get out before it hits zero $SCAM"}
+		message.Author.ID = "fresh"
+		match, err := federatedMatches(db, message)
+		require.NoError(t, err)
+		assert.Empty(t, match.AccountPeers)
+		assert.Equal(t, []string{"dao-a"}, match.NarrativePeers)
+	})
+
+	t.Run("Surge warns the admins once", func(t *testing.T) {
+		var indicators []FederatedIndicator
+		for i := 0; i < FEDERATION_SURGE_THRESHOLD; i++ {
+			indicators = append(indicators, FederatedIndicator{AccountHash: federationAccountHash("s3cret", fmt.Sprintf("brigade%d", i)), FUDType: "fake_news"})
+		}
+		require.Equal(t, http.StatusAccepted, post("t0ken", batch(indicators...)).Code)
+		require.Equal(t, http.StatusAccepted, post("t0ken", batch(FederatedIndicator{AccountHash: federationAccountHash("s3cret", "late")})).Code)
+
+		assert.Eventually(t, func() bool { return len(transport.sentMessages()) > 0 }, time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		sent := transport.sentMessages()
+		require.Len(t, sent, 1)
+		assert.Equal(t, int64(1), sent[0].ChatID)
+		assert.Contains(t, sent[0].Text, "Federation warning")
+		assert.Contains(t, sent[0].Text, "fake_news: 5")
+	})
+}
+
+func TestPublishFederatedIndicator(t *testing.T) {
+	received := make(chan FederatedIndicatorBatch, 1)
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, FEDERATION_INDICATORS_PATH, r.URL.Path)
+		assert.Equal(t, "t0ken", r.Header.Get(FEDERATION_TOKEN_HEADER))
+		var batch FederatedIndicatorBatch
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		received <- batch
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer peer.Close()
+	t.Setenv(ENV_FEDERATION_SECRET, "s3cret")
+	t.Setenv(ENV_FEDERATION_PEERS, "dao-b|"+peer.URL+"/|t0ken")
+
+	db := setupTestDB(t)
+	alert := FUDAlertNotification{FUDUserID: "shady", FUDUsername: "shady", FUDType: "price_manipulation", AlertSeverity: "high", MessagePreview: "the team sold every token last night and the liquidity is gone"}
+
+	publishFederatedIndicator(db, alert)
+	assert.Empty(t, received, "accounts not on the FUD list are not shared")
+
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "shady", Username: "shady", FUDType: "price_manipulation", DetectedAt: time.Now()}))
+	heuristic := alert
+	heuristic.FUDType = HEURISTIC_FUD_TYPE
+	publishFederatedIndicator(db, heuristic)
+	assert.Empty(t, received, "heuristic alerts are not shared")
+
+	publishFederatedIndicator(db, alert)
+	require.Len(t, received, 1)
+	batch := <-received
+	require.Len(t, batch.Indicators, 1)
+	assert.Equal(t, federationAccountHash("s3cret", "shady"), batch.Indicators[0].AccountHash)
+	assert.Equal(t, narrativeFingerprint("s3cret", alert.MessagePreview), batch.Indicators[0].Fingerprint)
+	assert.NotContains(t, fmt.Sprint(batch), "shady", "the username never leaves the deployment")
+}
+
+func TestFirstStepHandler_FederatedUser(t *testing.T) {
+	t.Setenv(ENV_FEDERATION_SECRET, "s3cret")
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "user_u1", IsDetailAnalyzed: true}))
+	_, err := db.SaveFederatedIndicators("dao-a", []FederatedIndicator{{AccountHash: federationAccountHash("s3cret", "u1"), FUDType: "fake_news", DetectedAt: time.Now()}})
+	require.NoError(t, err)
+
+	newMessageCh := make(chan twitterapi.NewMessage, 1)
+	message := twitterapi.NewMessage{TweetID: "t1", Text: "some text"}
+	message.Author.ID = "u1"
+	message.Author.UserName = "user_u1"
+	newMessageCh <- message
+	close(newMessageCh)
+	fudChannel := make(chan twitterapi.NewMessage, 1)
+	claudeApi := newMockClaudeAPI(`"is_fud":false,"fud_probability":0.1}`, nil)
+	FirstStepHandler(newMessageCh, fudChannel, claudeApi, nil, &mockUserStatusTracker{}, db, make(chan FUDAlertNotification, 1), &warRoomState{}, nil)
+
+	forwarded := <-fudChannel
+	assert.Equal(t, "t1", forwarded.TweetID)
+	assert.Empty(t, claudeApi.recordedCalls(), "the first step is skipped")
+}
+
+func TestFormatFederationMatches(t *testing.T) {
+	alert := FUDAlertNotification{FUDUsername: "shady", FUDType: "fake_news", FederationMatches: []string{"account flagged by dao-a as fake_news"}}
+	assert.Contains(t, NewNotificationFormatter().FormatForTelegram(alert), "🛰 <b>Flagged by peers:</b> account flagged by dao-a as fake_news")
+}
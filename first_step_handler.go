@@ -4,18 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/grutapig/hackaton/twitterapi"
-	"log"
-	"os"
+	"strings"
 	"time"
 )
 
 const FUD_TYPE = "known_fud_user_activity"
 
-func FirstStepHandler(newMessageCh chan twitterapi.NewMessage, fudChannel chan twitterapi.NewMessage, claudeApi *ClaudeApi, systemPromptFirstStep []byte, userStatusManager *UserStatusManager, dbService *DatabaseService, notificationCh chan FUDAlertNotification) {
+func FirstStepHandler(newMessageCh chan twitterapi.NewMessage, fudChannel chan twitterapi.NewMessage, claudeApi ClaudeAPI, prompts *PromptManager, userStatusManager UserStatusTracker, dbService *DatabaseService, notificationCh chan FUDAlertNotification, warRoom *warRoomState, tail *tailState) {
 	defer close(fudChannel)
 
 	for newMessage := range newMessageCh {
-		log.Println("Got a new message:", newMessage.Author.UserName, " - ", newMessage.Text, "parent to:", newMessage.ParentTweet.Text, " grandparent:", newMessage.GrandParentTweet.Text)
+		warRoom.recordMessage()
+		// Read for every message so /prompt changes apply right away
+		systemPromptFirstStep, _ := prompts.Prompt(PROMPT_STEP_FIRST)
+		logger := messageLogger("first_step", newMessage)
+		logger.Debug("got a new message", "text", newMessage.Text, "parent", newMessage.ParentTweet.Text, "grandparent", newMessage.GrandParentTweet.Text)
+
+		if isTrustedAuthor(newMessage, dbService) {
+			tail.record(newMessage, TAIL_VERDICT_SKIPPED, "trusted")
+			continue
+		}
+		if alertBlocklistedAuthor(newMessage, dbService, notificationCh) {
+			tail.record(newMessage, TAIL_VERDICT_ALERTED, "blocklisted")
+			continue
+		}
 
 		// Check if user has been through detailed analysis before
 		isDetailAnalyzed := dbService.IsUserDetailAnalyzed(newMessage.Author.ID)
@@ -23,9 +35,33 @@ func FirstStepHandler(newMessageCh chan twitterapi.NewMessage, fudChannel chan t
 		// Check if user is already known FUD user
 		isKnownFUDUser := dbService.IsFUDUser(newMessage.Author.ID)
 
+		// Without budget the heuristics decide. New users and flagged users wait for the detailed analysis.
+		if analysisPaused(dbService, newMessage) {
+			flagged := sendHeuristicAlert(newMessage, isKnownFUDUser, HEURISTIC_REASON_BUDGET, notificationCh)
+			if !isKnownFUDUser && (flagged || !isDetailAnalyzed) {
+				deferAnalysis(dbService, newMessage)
+			}
+			if flagged {
+				tail.record(newMessage, TAIL_VERDICT_ALERTED, "heuristic alert, budget spent")
+			} else {
+				tail.record(newMessage, TAIL_VERDICT_SKIPPED, "budget spent, heuristics clean")
+			}
+			continue
+		}
+
+		// Watched users skip the first step, each of their messages gets the detailed analysis
+		if dbService.IsWatchedUser(newMessage.Author.ID, newMessage.Author.UserName) {
+			logger.Info("👁 watched user, sending to detailed analysis")
+			newMessage.Watched = true
+			userStatusManager.SetUserAnalyzing(newMessage.Author.ID, newMessage.Author.UserName)
+			fudChannel <- newMessage
+			tail.record(newMessage, TAIL_VERDICT_DETAILED, "watchlist")
+			continue
+		}
+
 		if isKnownFUDUser {
 			// Known FUD user - ask Claude for quick analysis before sending notification
-			log.Printf("Known FUD user %s - performing quick analysis before notification", newMessage.Author.UserName)
+			logger.Info("known FUD user, performing quick analysis before notification")
 
 			messages := ClaudeMessages{}
 			// Add thread context in order: grandparent -> parent -> current
@@ -38,21 +74,32 @@ func FirstStepHandler(newMessageCh chan twitterapi.NewMessage, fudChannel chan t
 
 			messages = append(messages, ClaudeMessage{ROLE_USER, "user reply being analyzed: " + newMessage.Author.UserName + ":" + newMessage.Text})
 			messages = append(messages, ClaudeMessage{ROLE_ASSISTANT, "{"})
-			systemTicker := os.Getenv(ENV_TWITTER_COMMUNITY_TICKER)
-			resp, err := claudeApi.SendMessage(messages, fmt.Sprintf("%s\n<instruction>you must analyze %s user messages in the context of the full thread</instruction> \n this is a FUD user. be more attention for his message and his answers."+"\nthe system ticker is:"+systemTicker+", it cannot be used for any criteria or flag about decision FUD or not", string(systemPromptFirstStep), newMessage.Author.UserName))
+			resp, err := claudeApi.ForStep(USAGE_STEP_KNOWN_FUD).SendMessage(messages, fmt.Sprintf("%s\n<instruction>you must analyze %s user messages in the context of the full thread</instruction> \n this is a FUD user. be more attention for his message and his answers.", string(selectPrompt(systemPromptFirstStep, newMessage)), newMessage.Author.UserName)+communityPromptContext(newMessage))
 			if err != nil {
-				log.Printf("error claude quick analysis: %s", err)
+				logger.Error("claude quick analysis failed", "error", err)
+				if isLLMUnavailable(err) {
+					sendHeuristicAlert(newMessage, true, HEURISTIC_REASON_UNAVAILABLE, notificationCh)
+				}
+				tail.record(newMessage, TAIL_VERDICT_ERROR, "known FUD user, analysis failed")
 				continue
 			}
+			recordUserUsage(dbService, newMessage, USAGE_STEP_KNOWN_FUD, resp)
 
 			aiDecision := FirstStepClaudeResponse{}
 			err = json.Unmarshal([]byte("{"+resp.Content[0].Text), &aiDecision)
 			if err != nil {
-				log.Printf("error unmarshaling claude response: %s", err)
+				logger.Error("unmarshaling claude response failed", "error", err)
+				tail.record(newMessage, TAIL_VERDICT_ERROR, "known FUD user, unreadable response")
 				continue
 			}
 
 			if aiDecision.IsFud {
+				// Every confirmed message counts towards the auto-action thresholds
+				err = dbService.IncrementFUDUserMessageCount(newMessage.Author.ID, newMessage.TweetID)
+				if err != nil {
+					logger.Error("failed to increment FUD user message count", "error", err)
+				}
+
 				// Determine thread context from newMessage
 				originalPostText := ""
 				originalPostAuthor := ""
@@ -100,25 +147,41 @@ func FirstStepHandler(newMessageCh chan twitterapi.NewMessage, fudChannel chan t
 					GrandParentPostText:   grandParentPostText,
 					GrandParentPostAuthor: grandParentPostAuthor,
 					HasThreadContext:      hasThreadContext,
+					PromptVersion:         prompts.VersionLabel(PROMPT_STEP_FIRST),
 				}
-				log.Printf("Sending quick notification for known FUD user %s", newMessage.Author.UserName)
+				logger.Info("sending quick notification for known FUD user")
 				notificationCh <- alert
+				tail.record(newMessage, TAIL_VERDICT_ALERTED, fmt.Sprintf("known FUD user, FUD %.0f%%", aiDecision.FudProbability))
 			} else {
-				log.Printf("Known FUD user %s - message not FUD, ignoring", newMessage.Author.UserName)
+				logger.Info("known FUD user message not FUD, ignoring")
+				tail.record(newMessage, TAIL_VERDICT_CLEAN, fmt.Sprintf("known FUD user, clean %.0f%%", aiDecision.FudProbability))
 			}
 			continue
 		}
 
 		if !isDetailAnalyzed {
 			// New user - send to detailed analysis
-			log.Printf("New user %s - sending directly to detailed analysis", newMessage.Author.UserName)
+			logger.Info("new user, sending directly to detailed analysis")
 			userStatusManager.SetUserAnalyzing(newMessage.Author.ID, newMessage.Author.UserName)
 			fudChannel <- newMessage
+			tail.record(newMessage, TAIL_VERDICT_DETAILED, "new user")
+			continue
+		}
+
+		// Accounts and narratives partner deployments confirmed as FUD skip the first step
+		match, err := federatedMatches(dbService, newMessage)
+		if err != nil {
+			logger.Error("failed to check federated indicators", "error", err)
+		} else if !match.empty() {
+			logger.Info("🛰 user matches federated indicators, sending to detailed analysis", "indicators", strings.Join(match.summary(), "; "))
+			userStatusManager.SetUserAnalyzing(newMessage.Author.ID, newMessage.Author.UserName)
+			fudChannel <- newMessage
+			tail.record(newMessage, TAIL_VERDICT_DETAILED, "federated match")
 			continue
 		}
 
 		// Existing user (not FUD) - standard first step analysis
-		log.Printf("Existing user %s - performing first step analysis", newMessage.Author.UserName)
+		logger.Info("existing user, performing first step analysis")
 		messages := ClaudeMessages{}
 
 		// Add thread context in order: grandparent -> parent -> current
@@ -132,26 +195,40 @@ func FirstStepHandler(newMessageCh chan twitterapi.NewMessage, fudChannel chan t
 		messages = append(messages, ClaudeMessage{ROLE_USER, "user reply being analyzed: " + newMessage.Author.UserName + ":" + newMessage.Text})
 		messages = append(messages, ClaudeMessage{ROLE_ASSISTANT, "{"})
 
-		resp, err := claudeApi.SendMessage(messages, fmt.Sprintf("%s\n<instruction>you must analyze %s user messages in the context of the full thread</instruction>", string(systemPromptFirstStep), newMessage.Author.UserName))
+		resp, err := claudeApi.ForStep(USAGE_STEP_FIRST).SendMessage(messages, fmt.Sprintf("%s\n<instruction>you must analyze %s user messages in the context of the full thread</instruction>", string(selectPrompt(systemPromptFirstStep, newMessage)), newMessage.Author.UserName)+communityPromptContext(newMessage))
 		if err != nil {
-			log.Printf("error claude: %s", err)
+			logger.Error("claude first step failed", "error", err)
+			if isLLMUnavailable(err) {
+				sendHeuristicAlert(newMessage, false, HEURISTIC_REASON_UNAVAILABLE, notificationCh)
+			}
+			tail.record(newMessage, TAIL_VERDICT_ERROR, "first step failed")
 			continue
 		}
+		recordUserUsage(dbService, newMessage, USAGE_STEP_FIRST, resp)
 
 		aiDecision := FirstStepClaudeResponse{}
 		err = json.Unmarshal([]byte("{"+resp.Content[0].Text), &aiDecision)
 		if err != nil {
-			log.Printf("error unmarshaling claude response: %s", err)
+			logger.Error("unmarshaling claude response failed", "error", err)
+			tail.record(newMessage, TAIL_VERDICT_ERROR, "unreadable first step response")
 			continue
 		}
 
 		if aiDecision.IsFud {
 			// Send to detailed analysis
-			log.Printf("First step flagged user %s as FUD - sending to detailed analysis", newMessage.Author.UserName)
+			logger.Info("first step flagged user as FUD, sending to detailed analysis", "fud_probability", aiDecision.FudProbability)
+			userStatusManager.SetUserAnalyzing(newMessage.Author.ID, newMessage.Author.UserName)
+			fudChannel <- newMessage
+			tail.record(newMessage, TAIL_VERDICT_FUD, fmt.Sprintf("FUD %.0f%%, detailed analysis", aiDecision.FudProbability))
+		} else if warRoom.escalates(aiDecision.FudProbability) {
+			// The war room sends borderline messages to detailed analysis too
+			logger.Info("war room escalating user to detailed analysis", "fud_probability", aiDecision.FudProbability)
 			userStatusManager.SetUserAnalyzing(newMessage.Author.ID, newMessage.Author.UserName)
 			fudChannel <- newMessage
+			tail.record(newMessage, TAIL_VERDICT_FUD, fmt.Sprintf("war room escalation %.0f%%", aiDecision.FudProbability))
 		} else {
-			log.Printf("First step - user %s message not FUD, ignoring", newMessage.Author.UserName)
+			logger.Info("first step message not FUD, ignoring", "fud_probability", aiDecision.FudProbability)
+			tail.record(newMessage, TAIL_VERDICT_CLEAN, fmt.Sprintf("clean %.0f%%", aiDecision.FudProbability))
 		}
 	}
 }
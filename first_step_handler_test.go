@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstStepHandler_Routing(t *testing.T) {
+	db := setupTestDB(t)
+	for _, userID := range []string{"u1", "u2"} {
+		require.NoError(t, db.SaveUser(UserModel{ID: userID, Username: "user_" + userID, IsDetailAnalyzed: true}))
+	}
+
+	newMessage := func(userID, tweetID string) twitterapi.NewMessage {
+		message := twitterapi.NewMessage{TweetID: tweetID, Text: "some text"}
+		message.Author.ID = userID
+		message.Author.UserName = "user_" + userID
+		return message
+	}
+	run := func(claudeApi ClaudeAPI, messages ...twitterapi.NewMessage) ([]twitterapi.NewMessage, *mockUserStatusTracker) {
+		newMessageCh := make(chan twitterapi.NewMessage, len(messages))
+		for _, message := range messages {
+			newMessageCh <- message
+		}
+		close(newMessageCh)
+		fudChannel := make(chan twitterapi.NewMessage, len(messages))
+		userStatus := &mockUserStatusTracker{}
+		FirstStepHandler(newMessageCh, fudChannel, claudeApi, nil, userStatus, db, make(chan FUDAlertNotification, len(messages)), &warRoomState{}, nil)
+		var forwarded []twitterapi.NewMessage
+		for message := range fudChannel {
+			forwarded = append(forwarded, message)
+		}
+		return forwarded, userStatus
+	}
+
+	// Users never analyzed in detail skip the first step
+	claudeApi := newMockClaudeAPI(`"is_fud":false,"fud_probability":0.1}`, nil)
+	forwarded, userStatus := run(claudeApi, newMessage("new", "t0"))
+	require.Len(t, forwarded, 1)
+	assert.Equal(t, []string{"new"}, userStatus.analyzing)
+	assert.Empty(t, claudeApi.recordedCalls())
+
+	forwarded, userStatus = run(claudeApi, newMessage("u1", "t1"))
+	assert.Empty(t, forwarded)
+	assert.Empty(t, userStatus.analyzing)
+	require.Len(t, claudeApi.recordedCalls(), 1)
+	assert.Equal(t, USAGE_STEP_FIRST, claudeApi.recordedCalls()[0].step)
+
+	forwarded, userStatus = run(newMockClaudeAPI(`"is_fud":true,"fud_probability":0.9}`, nil), newMessage("u2", "t2"))
+	require.Len(t, forwarded, 1)
+	assert.Equal(t, "t2", forwarded[0].TweetID)
+	assert.Equal(t, []string{"u2"}, userStatus.analyzing)
+}
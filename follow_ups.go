@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	FOLLOW_UP_DEFAULT_DELAYS  = "24h,7d"
+	FOLLOW_UP_CHECK_INTERVAL  = 5 * time.Minute
+	FOLLOW_UP_BATCH           = 20
+	FOLLOW_UP_LIST_LIMIT      = 10
+	FOLLOW_UP_SAMPLE_MESSAGES = 2
+)
+
+const (
+	FOLLOW_UP_STATUS_PENDING = "pending"
+	FOLLOW_UP_STATUS_DONE    = "done"
+)
+
+// Outcomes of a follow-up check
+const (
+	FOLLOW_UP_ESCALATED = "escalated" // new alerts or hostile messages since the flag
+	FOLLOW_UP_ACTIVE    = "active"    // still posting, nothing hostile
+	FOLLOW_UP_QUIET     = "quiet"     // no messages since the flag
+	FOLLOW_UP_CLEARED   = "cleared"   // marked clean or whitelisted since the alert
+)
+
+// followUpDelay is one configured re-evaluation after an alert
+type followUpDelay struct {
+	Label    string
+	Duration time.Duration
+}
+
+// parseFollowUpDelays reads comma-separated delays such as "24h,7d", off disables the follow-ups
+func parseFollowUpDelays(spec string) ([]followUpDelay, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "off" {
+		return nil, nil
+	}
+	var delays []followUpDelay
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		var duration time.Duration
+		var err error
+		if days, ok := strings.CutSuffix(part, "d"); ok {
+			var n int
+			n, err = strconv.Atoi(days)
+			duration = time.Duration(n) * 24 * time.Hour
+		} else {
+			duration, err = time.ParseDuration(part)
+		}
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid follow-up delay %q, use e.g. 24h or 7d", part)
+		}
+		delays = append(delays, followUpDelay{Label: part, Duration: duration})
+	}
+	return delays, nil
+}
+
+// followUpDelays returns the configured delays, the default ones when the setting is missing or invalid
+func followUpDelays() []followUpDelay {
+	spec := os.Getenv(ENV_FOLLOW_UP_DELAYS)
+	if spec == "" {
+		spec = FOLLOW_UP_DEFAULT_DELAYS
+	}
+	delays, err := parseFollowUpDelays(spec)
+	if err != nil {
+		log.Printf("Warning: %v, using %s", err, FOLLOW_UP_DEFAULT_DELAYS)
+		delays, _ = parseFollowUpDelays(FOLLOW_UP_DEFAULT_DELAYS)
+	}
+	return delays
+}
+
+// scheduleFollowUpChecks stores the re-evaluations of a flagged user. A user with checks still pending
+// gets no new ones, those checks report the later alerts.
+func (b *BotController) scheduleFollowUpChecks(alert FUDAlertNotification, notificationID string) {
+	if alert.FUDUserID == "" || b.dbService.HasPendingFollowUpChecks(alert.FUDUserID) {
+		return
+	}
+	now := time.Now()
+	var checks []FollowUpCheckModel
+	for _, delay := range followUpDelays() {
+		checks = append(checks, FollowUpCheckModel{
+			NotificationID: notificationID,
+			UserID:         alert.FUDUserID,
+			Username:       alert.FUDUsername,
+			TweetID:        alert.FUDMessageID,
+			FUDType:        alert.FUDType,
+			Delay:          delay.Label,
+			FlaggedAt:      now,
+			DueAt:          now.Add(delay.Duration),
+			Status:         FOLLOW_UP_STATUS_PENDING,
+			CreatedAt:      now,
+		})
+	}
+	if err := b.dbService.ScheduleFollowUpChecks(checks); err != nil {
+		log.Printf("Failed to schedule follow-up checks of @%s: %v", alert.FUDUsername, err)
+	}
+}
+
+// StartFollowUpScheduler runs the due follow-up checks periodically. Checks are stored, so the ones
+// that came due while the bot was down run at the first tick.
+func (b *BotController) StartFollowUpScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			b.runDueFollowUpChecks(now)
+		}
+	}()
+}
+
+func (b *BotController) runDueFollowUpChecks(now time.Time) {
+	checks, err := b.dbService.GetDueFollowUpChecks(now, FOLLOW_UP_BATCH)
+	if err != nil {
+		log.Printf("Failed to load due follow-up checks: %v", err)
+		return
+	}
+	for i := range checks {
+		check := &checks[i]
+		outcome, summary := b.evaluateFollowUp(check)
+		if err := b.dbService.CompleteFollowUpCheck(check, outcome, summary); err != nil {
+			// Left pending, the next tick tries again
+			log.Printf("Failed to complete follow-up check %d of @%s: %v", check.ID, check.Username, err)
+			continue
+		}
+		log.Printf("🔁 Follow-up %s of @%s: %s", check.Delay, check.Username, outcome)
+		b.postFollowUp(check, formatFollowUp(check, outcome, summary))
+	}
+}
+
+// evaluateFollowUp re-evaluates a flagged user from what was stored since the alert: the whitelist,
+// human verdicts, new alerts and a heuristic pass over the new messages
+func (b *BotController) evaluateFollowUp(check *FollowUpCheckModel) (string, string) {
+	if b.dbService.IsTrustedUser(check.UserID, check.Username) {
+		return FOLLOW_UP_CLEARED, "Whitelisted since the alert."
+	}
+	var details []string
+	if verdict, err := b.dbService.GetLatestLabeledVerdict(check.UserID); err == nil && verdict.CreatedAt.After(check.FlaggedAt) {
+		if verdict.Label == LABEL_CLEAN {
+			return FOLLOW_UP_CLEARED, fmt.Sprintf("Marked clean by %s.", html.EscapeString(verdict.LabeledBy))
+		}
+		details = append(details, fmt.Sprintf("👤 Confirmed FUD by %s", html.EscapeString(verdict.LabeledBy)))
+	}
+
+	newAlerts, err := b.dbService.CountUserNotificationsSince(check.UserID, check.FlaggedAt)
+	if err != nil {
+		log.Printf("Failed to count alerts of @%s: %v", check.Username, err)
+	}
+	if newAlerts > 0 {
+		details = append(details, fmt.Sprintf("🚨 New alerts: %d", newAlerts))
+	}
+
+	messages, err := b.dbService.GetUserMessagesSince(check.UserID, check.FlaggedAt)
+	if err != nil {
+		log.Printf("Failed to load messages of @%s: %v", check.Username, err)
+	}
+	var hostile []TweetModel
+	for _, message := range messages {
+		newMessage := twitterapi.NewMessage{TweetID: message.ID, Text: message.Text}
+		newMessage.Author.UserName = check.Username
+		// Content only, being a known FUD user would flag every message
+		if assessMessageHeuristically(newMessage, false).IsFUD {
+			hostile = append(hostile, message)
+		}
+	}
+	if len(messages) > 0 {
+		details = append(details, fmt.Sprintf("💬 New messages: %d, hostile: %d", len(messages), len(hostile)))
+	}
+	for i := 0; i < len(hostile) && i < FOLLOW_UP_SAMPLE_MESSAGES; i++ {
+		details = append(details, fmt.Sprintf("  <i>%s</i>", b.formatter.escapeTruncated(hostile[len(hostile)-1-i].Text, 150)))
+	}
+
+	switch {
+	case newAlerts > 0 || len(hostile) > 0:
+		return FOLLOW_UP_ESCALATED, strings.Join(details, "\n")
+	case len(messages) > 0:
+		return FOLLOW_UP_ACTIVE, strings.Join(details, "\n")
+	default:
+		return FOLLOW_UP_QUIET, strings.Join(append(details, "No new messages since the alert."), "\n")
+	}
+}
+
+// formatFollowUp is the follow-up message replied to the alert
+func formatFollowUp(check *FollowUpCheckModel, outcome string, summary string) string {
+	emoji := map[string]string{
+		FOLLOW_UP_ESCALATED: "🔺",
+		FOLLOW_UP_ACTIVE:    "🟡",
+		FOLLOW_UP_QUIET:     "💤",
+		FOLLOW_UP_CLEARED:   "✅",
+	}[outcome]
+	return fmt.Sprintf("🔁 <b>Follow-up after %s: @%s</b>\n%s <b>%s</b>\n%s\n\n🔍 /history_%s | /analyze_%s",
+		check.Delay, check.Username, emoji, strings.ToUpper(outcome), summary, check.Username, check.Username)
+}
+
+// postFollowUp replies to the alert in every chat it was delivered to. Alerts held back during
+// maintenance have no messages, their follow-ups go to the admin chats.
+func (b *BotController) postFollowUp(check *FollowUpCheckModel, text string) {
+	alertMessages, err := b.dbService.GetAlertMessages(check.NotificationID)
+	if err != nil {
+		log.Printf("Failed to load the alert messages of %s: %v", check.NotificationID, err)
+	}
+	if len(alertMessages) == 0 {
+		for _, chatID := range adminChatIDs() {
+			alertMessages = append(alertMessages, AlertMessageModel{ChatID: chatID})
+		}
+	}
+	for _, alertMessage := range alertMessages {
+		if !b.isRegisteredChat(alertMessage.ChatID) && !b.isAdminChat(alertMessage.ChatID) {
+			continue
+		}
+		_, err := b.sendSplitMessage(TelegramSendMessageRequest{
+			ChatID:            alertMessage.ChatID,
+			Text:              text,
+			ParseMode:         "HTML",
+			DisablePreview:    true,
+			ReplyToMessageID:  alertMessage.MessageID,
+			AllowWithoutReply: true,
+		})
+		if err != nil {
+			log.Printf("Failed to send follow-up of @%s to chat %d: %v", check.Username, alertMessage.ChatID, err)
+		}
+	}
+}
+
+// handleFollowUpsCommand lists the scheduled follow-up checks and the latest outcomes
+func (b *BotController) handleFollowUpsCommand(chatID int64) {
+	pending, total, err := b.dbService.GetPendingFollowUpChecks(FOLLOW_UP_LIST_LIMIT)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading follow-up checks: %v", err))
+		return
+	}
+	completed, err := b.dbService.GetCompletedFollowUpChecks(FOLLOW_UP_LIST_LIMIT)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading follow-up checks: %v", err))
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🔁 <b>Follow-up checks</b> (%d scheduled)\n\n", total))
+	if len(pending) == 0 {
+		message.WriteString("📭 Nothing scheduled.\n")
+	}
+	for _, check := range pending {
+		message.WriteString(fmt.Sprintf("• @%s after %s, due %s\n", check.Username, check.Delay, check.DueAt.UTC().Format("2006-01-02 15:04 UTC")))
+	}
+	if len(completed) > 0 {
+		message.WriteString("\n<b>Latest outcomes:</b>\n")
+		for _, check := range completed {
+			message.WriteString(fmt.Sprintf("• @%s after %s: %s\n", check.Username, check.Delay, check.Outcome))
+		}
+	}
+	var delays []string
+	for _, delay := range followUpDelays() {
+		delays = append(delays, delay.Label)
+	}
+	if len(delays) == 0 {
+		message.WriteString(fmt.Sprintf("\nFollow-ups are off, enable them with %s.", ENV_FOLLOW_UP_DELAYS))
+	} else {
+		message.WriteString(fmt.Sprintf("\nFlagged users are re-evaluated after %s, outcomes are replied to the alert.", strings.Join(delays, " and ")))
+	}
+	b.SendMessage(chatID, message.String())
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFollowUpDelays(t *testing.T) {
+	delays, err := parseFollowUpDelays("24h, 7d")
+	require.NoError(t, err)
+	require.Len(t, delays, 2)
+	assert.Equal(t, followUpDelay{Label: "24h", Duration: 24 * time.Hour}, delays[0])
+	assert.Equal(t, followUpDelay{Label: "7d", Duration: 7 * 24 * time.Hour}, delays[1])
+
+	delays, err = parseFollowUpDelays("off")
+	require.NoError(t, err)
+	assert.Empty(t, delays)
+
+	_, err = parseFollowUpDelays("soon")
+	assert.Error(t, err)
+	_, err = parseFollowUpDelays("-2d")
+	assert.Error(t, err)
+}
+
+func TestFollowUpChecks_RepliedToAlertAcrossRestarts(t *testing.T) {
+	t.Setenv(ENV_FOLLOW_UP_DELAYS, "24h,7d")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	alert := benchmarkAlert()
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert), "a second alert does not schedule more checks")
+	pending, total, err := db.GetPendingFollowUpChecks(FOLLOW_UP_LIST_LIMIT)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	alertMessages, err := db.GetAlertMessages(pending[0].NotificationID)
+	require.NoError(t, err)
+	require.Len(t, alertMessages, 1)
+
+	now := time.Now()
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "f1", UserID: alert.FUDUserID, Text: "this project is a rug pull scam", CreatedAt: now.Add(time.Hour)}))
+
+	bot.runDueFollowUpChecks(now.Add(time.Hour))
+	sentBefore := len(transport.sentMessages())
+
+	// A new controller on the same database picks up the stored checks, as after a restart
+	restarted := newTestBotController(transport, db)
+	restarted.chatIDs[1] = true
+	restarted.runDueFollowUpChecks(now.Add(25 * time.Hour))
+	sent := transport.sentMessages()
+	require.Len(t, sent, sentBefore+1, "only the 24h check is due")
+	followUp := sent[len(sent)-1]
+	assert.Equal(t, alertMessages[0].MessageID, followUp.ReplyToMessageID)
+	assert.Contains(t, followUp.Text, "Follow-up after 24h")
+	assert.Contains(t, followUp.Text, "ESCALATED")
+	assert.Contains(t, followUp.Text, "New alerts: 1")
+	assert.Contains(t, followUp.Text, "rug pull scam")
+
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: alert.FUDUserID, Username: alert.FUDUsername, Label: LABEL_CLEAN, LabeledBy: "@mod"}))
+	restarted.runDueFollowUpChecks(now.Add(8 * 24 * time.Hour))
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "CLEARED")
+	assert.Contains(t, sent[len(sent)-1].Text, "Marked clean by @mod")
+
+	_, total, err = db.GetPendingFollowUpChecks(FOLLOW_UP_LIST_LIMIT)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	completed, err := db.GetCompletedFollowUpChecks(FOLLOW_UP_LIST_LIMIT)
+	require.NoError(t, err)
+	require.Len(t, completed, 2)
+	assert.Equal(t, FOLLOW_UP_CLEARED, completed[0].Outcome)
+	assert.Equal(t, FOLLOW_UP_ESCALATED, completed[1].Outcome)
+}
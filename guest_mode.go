@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chat roles set by admins with /chatrole
+const (
+	CHAT_ROLE_MEMBER = "member"
+	CHAT_ROLE_GUEST  = "guest" // read-only: digests and summaries, no raw alerts, investigations or exports
+)
+
+const SENTIMENT_DEFAULT_DAYS = 7
+
+// guestCommands are the read-only commands guest chats may run
+var guestCommands = map[string]bool{
+	"/fudlist":   true,
+	"/stats":     true,
+	"/health":    true,
+	"/sentiment": true,
+	"/subscribe": true,
+	"/topic":     true,
+	"/chatrole":  true,
+	"/help":      true,
+	"/start":     true,
+}
+
+func isGuestCommand(command string) bool {
+	return guestCommands[command] || strings.HasPrefix(command, "/fudlist_")
+}
+
+// isGuestChat reports whether the chat has read-only guest access
+func (b *BotController) isGuestChat(chatID int64) bool {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to load settings for chat %d, treating it as a guest: %v", chatID, err)
+		return true
+	}
+	return settings.Role == CHAT_ROLE_GUEST
+}
+
+// handleChatRoleCommand shows the chat role, or lets admins set it with /chatrole <chat_id> guest|member
+func (b *BotController) handleChatRoleCommand(chatID int64, args []string) {
+	if len(args) == 0 {
+		settings, err := b.dbService.GetChatSettings(chatID)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+			return
+		}
+		b.SendMessage(chatID, fmt.Sprintf("👥 <b>Chat role:</b> %s\n\nAdmins can change it with /chatrole &lt;chat_id&gt; %s|%s", settings.Role, CHAT_ROLE_GUEST, CHAT_ROLE_MEMBER))
+		return
+	}
+
+	if !b.isAdminChat(chatID) {
+		b.SendMessage(chatID, "❌ Access denied. Only administrators can change chat roles.")
+		return
+	}
+	if len(args) < 2 {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Usage: /chatrole &lt;chat_id&gt; %s|%s", CHAT_ROLE_GUEST, CHAT_ROLE_MEMBER))
+		return
+	}
+
+	targetChatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.SendMessage(chatID, "❌ Invalid chat ID")
+		return
+	}
+	role := strings.ToLower(args[1])
+	if role != CHAT_ROLE_GUEST && role != CHAT_ROLE_MEMBER {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Unknown role. Use %s or %s", CHAT_ROLE_GUEST, CHAT_ROLE_MEMBER))
+		return
+	}
+	if role == CHAT_ROLE_GUEST && b.isAdminChat(targetChatID) {
+		b.SendMessage(chatID, "❌ Admin chats cannot be guests")
+		return
+	}
+
+	settings, err := b.dbService.GetChatSettings(targetChatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+	settings.Role = role
+	err = b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	log.Printf("Role of chat %d set to %s by admin chat %d", targetChatID, role, chatID)
+	if role == CHAT_ROLE_GUEST {
+		b.SendMessage(chatID, fmt.Sprintf("✅ Chat %d is now a read-only guest: digests and summaries, no raw alerts", targetChatID))
+		return
+	}
+	b.SendMessage(chatID, fmt.Sprintf("✅ Chat %d is now a member and receives alerts", targetChatID))
+}
+
+// handleStatsCommand sends the FUD summary of the last day or week on demand: /stats [daily|weekly]
+func (b *BotController) handleStatsCommand(chatID int64, args []string) {
+	period := DIGEST_WEEKLY
+	if len(args) > 0 {
+		period = strings.ToLower(args[0])
+	}
+	if period != DIGEST_DAILY && period != DIGEST_WEEKLY {
+		b.SendMessage(chatID, "❌ Usage: /stats daily|weekly")
+		return
+	}
+
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+	now := time.Now()
+	stats, err := b.dbService.GetFUDDigestStats(now.Add(-digestWindow(period)), now, DIGEST_TOP_OFFENDERS)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error building stats: %v", err))
+		return
+	}
+	b.SendMessage(chatID, b.formatter.FormatDigest(stats, period, settings.Timezone, redactionProfiles[settings.Redaction]))
+}
+
+// handleHealthCommand tells whether monitoring is ingesting tweets and how busy the analysis is: /health
+func (b *BotController) handleHealthCommand(chatID int64) {
+	now := time.Now()
+	var message strings.Builder
+	message.WriteString("🩺 <b>Monitoring status</b>\n\n")
+
+	if last := b.watchdog.lastIngestion(); last.IsZero() {
+		message.WriteString("📥 <b>Last ingestion:</b> none yet\n")
+	} else {
+		message.WriteString(fmt.Sprintf("📥 <b>Last ingestion:</b> %s ago\n", now.Sub(last).Round(time.Minute)))
+	}
+	if tasks, err := b.dbService.GetAllRunningAnalysisTasks(); err == nil {
+		message.WriteString(fmt.Sprintf("⏳ <b>Running analyses:</b> %d\n", len(tasks)))
+	}
+
+	problems := b.watchdog.problems(now, watchdogStallThreshold())
+	if len(problems) == 0 {
+		message.WriteString("\n✅ Monitoring is running normally")
+	} else {
+		message.WriteString("\n⚠️ <b>Problems:</b>\n")
+		for _, problem := range problems {
+			message.WriteString(fmt.Sprintf("• %s\n", problem))
+		}
+	}
+	b.SendMessage(chatID, message.String())
+}
+
+// handleSentimentCommand sums up the ticker mentions and how much of them comes from FUD accounts: /sentiment [days]
+func (b *BotController) handleSentimentCommand(chatID int64, args []string) {
+	days := SENTIMENT_DEFAULT_DAYS
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(strings.TrimSuffix(args[0], "d"))
+		if err != nil || parsed <= 0 {
+			b.SendMessage(chatID, "❌ Usage: /sentiment [days]")
+			return
+		}
+		days = parsed
+	}
+	if b.ticker == "" {
+		b.SendMessage(chatID, "❌ No ticker is monitored")
+		return
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+	mentions, err := b.dbService.GetTickerMentionStats(b.ticker, since)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading ticker mentions: %v", err))
+		return
+	}
+	detections, err := b.dbService.GetFUDDigestStats(since, now, 0)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading detections: %v", err))
+		return
+	}
+	b.SendMessage(chatID, formatSentiment(b.ticker, days, mentions, detections.Detections))
+}
+
+// formatSentiment rates the FUD pressure by the share of mentions coming from flagged accounts
+func formatSentiment(ticker string, days int, mentions *TickerMentionStats, detections int) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🌡 <b>%s sentiment, last %d days</b>\n\n", ticker, days))
+	if mentions.Mentions == 0 {
+		message.WriteString(fmt.Sprintf("💬 No mentions found\n🔍 <b>FUD detections:</b> %d", detections))
+		return message.String()
+	}
+
+	share := float64(mentions.FUDMentions) / float64(mentions.Mentions) * 100
+	message.WriteString(fmt.Sprintf("💬 <b>Mentions:</b> %d by %d accounts\n", mentions.Mentions, mentions.Authors))
+	message.WriteString(fmt.Sprintf("🚨 <b>From FUD accounts:</b> %d (%.0f%%) by %d accounts\n", mentions.FUDMentions, share, mentions.FUDAuthors))
+	message.WriteString(fmt.Sprintf("🔍 <b>FUD detections:</b> %d\n\n", detections))
+	switch {
+	case share < 5:
+		message.WriteString("🟢 Calm")
+	case share < 20:
+		message.WriteString("🟡 Some FUD")
+	default:
+		message.WriteString("🔴 Under FUD pressure")
+	}
+	return message.String()
+}
+
+const guestHelpMessage = `🤖 <b>FUD Detection Bot - Guest Access</b>
+
+This chat has read-only access: summaries and digests, no raw alerts.
+
+📊 <b>Commands:</b>
+• /fudlist - Show all detected FUD users
+• /stats [daily|weekly] - FUD summary of the last day or week
+• /health - Monitoring status
+• /sentiment [days] - Ticker mentions and the share coming from FUD accounts
+• /subscribe daily|weekly|off|now - Scheduled FUD summary for this chat
+• /topic reports [off] - Post digests in the forum topic the command is sent in
+• /chatrole - Show the role of this chat
+
+👤 <b>Your Chat ID:</b> %d`
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_GuestChat(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.ticker = "$GRUT"
+	bot.chatIDs[1] = true
+	bot.chatIDs[7] = true
+	lastTo := func(chatID int64) string {
+		var text string
+		for _, msg := range transport.sentMessages() {
+			if msg.ChatID == chatID {
+				text = msg.Text
+			}
+		}
+		return text
+	}
+
+	bot.handleChatRoleCommand(7, []string{"7", CHAT_ROLE_GUEST})
+	assert.Contains(t, lastTo(7), "Access denied")
+	bot.handleChatRoleCommand(1, []string{"1", CHAT_ROLE_GUEST})
+	assert.Contains(t, lastTo(1), "Admin chats cannot be guests")
+	bot.handleChatRoleCommand(1, []string{"7", CHAT_ROLE_GUEST})
+	assert.Contains(t, lastTo(1), "read-only guest")
+
+	require.NoError(t, bot.StoreAndBroadcastNotification(benchmarkAlert()))
+	assert.Contains(t, lastTo(1), "@suspicious_user")
+	assert.Contains(t, lastTo(7), "Access denied", "guests get no raw alerts")
+	require.NoError(t, bot.SendAlertToChat(7, benchmarkAlert(), ""))
+	assert.Contains(t, lastTo(7), "Access denied")
+
+	bot.handleUpdate(newTestUpdate(7, "/exportfudlist"))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(lastTo(7), "read-only guest access")
+	}, time.Second, 10*time.Millisecond)
+	bot.handleUpdate(newTestUpdate(7, "/stats daily"))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(lastTo(7), "Daily FUD Digest")
+	}, time.Second, 10*time.Millisecond)
+	bot.handleHelpCommand(7)
+	assert.Contains(t, lastTo(7), "Guest Access")
+	assert.NotContains(t, lastTo(7), "/analyze")
+}
+
+func TestDatabaseService_TickerMentionStats(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "fudder", Username: "fudder", DetectedAt: time.Now()}))
+	for i, userID := range []string{"fudder", "fudder", "fan", "other"} {
+		require.NoError(t, db.SaveUserTickerOpinion(UserTickerOpinionModel{UserID: userID, Ticker: "$GRUT", TweetID: strings.Repeat("9", i+1)}))
+	}
+	require.NoError(t, db.SaveUserTickerOpinion(UserTickerOpinionModel{UserID: "fan", Ticker: "$ELSE", TweetID: "else"}))
+
+	stats, err := db.GetTickerMentionStats("$GRUT", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, TickerMentionStats{Mentions: 4, Authors: 3, FUDMentions: 2, FUDAuthors: 1}, *stats)
+	text := formatSentiment("$GRUT", 7, stats, 1)
+	assert.Contains(t, text, "2 (50%) by 1 accounts")
+	assert.Contains(t, text, "Under FUD pressure")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HANDOFF_DEFAULT_PERIOD = 8 * time.Hour // a moderation shift
+	HANDOFF_MAX_PERIOD     = 72 * time.Hour
+	HANDOFF_TOP_USERS      = 5
+)
+
+var handoffTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// handoffReport is what the outgoing shift hands over: the alerts of the period, what is still open
+// and what is still running
+type handoffReport struct {
+	Since          time.Time
+	Until          time.Time
+	Alerts         []NotificationModel
+	Unacknowledged []NotificationModel  // open critical alerts nobody acked, marked or whitelisted since, latest per user
+	Escalated      []FollowUpCheckModel // follow-ups of the period that found the user still hostile
+	Tasks          []AnalysisTaskModel  // pending and running analysis tasks
+	WarRoom        bool
+	WarRoomUntil   time.Time
+	Maintenance    time.Time // end of the maintenance window, zero when off
+	QueuedAlerts   int
+}
+
+// parseHandoffPeriod reads the optional period of /handoff: 8 or 8h for the last 8 hours
+func parseHandoffPeriod(args []string) (time.Duration, error) {
+	if len(args) == 0 {
+		return HANDOFF_DEFAULT_PERIOD, nil
+	}
+	hours, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(args[0]), "h"))
+	period := time.Duration(hours) * time.Hour
+	if err != nil || period <= 0 || period > HANDOFF_MAX_PERIOD {
+		return 0, fmt.Errorf("invalid period %s, use 1h to %dh", args[0], int(HANDOFF_MAX_PERIOD.Hours()))
+	}
+	return period, nil
+}
+
+// buildHandoffReport collects the report of the period ending now
+func (b *BotController) buildHandoffReport(now time.Time, period time.Duration) (*handoffReport, error) {
+	report := &handoffReport{Since: now.Add(-period), Until: now}
+	var err error
+	if report.Alerts, err = b.dbService.GetNotificationsSince(report.Since); err != nil {
+		return nil, fmt.Errorf("failed to load alerts: %w", err)
+	}
+	if report.Escalated, err = b.dbService.GetFollowUpChecksCompletedSince(FOLLOW_UP_ESCALATED, report.Since); err != nil {
+		return nil, fmt.Errorf("failed to load follow-ups: %w", err)
+	}
+	if report.Tasks, err = b.dbService.GetAllRunningAnalysisTasks(); err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, alert := range report.Alerts {
+		if alert.AlertSeverity != "critical" || seen[alert.FUDUserID] {
+			continue
+		}
+		seen[alert.FUDUserID] = true
+		if alert.State != ALERT_STATE_OPEN {
+			continue
+		}
+		if b.dbService.IsTrustedUser(alert.FUDUserID, alert.FUDUsername) {
+			continue
+		}
+		if verdict, err := b.dbService.GetLatestLabeledVerdict(alert.FUDUserID); err == nil && verdict.CreatedAt.After(alert.CreatedAt) {
+			continue
+		}
+		report.Unacknowledged = append(report.Unacknowledged, alert)
+	}
+
+	b.warRoom.mu.Lock()
+	report.WarRoom, report.WarRoomUntil = !b.warRoom.until.IsZero(), b.warRoom.until
+	b.warRoom.mu.Unlock()
+	b.maintenance.mu.Lock()
+	report.Maintenance, report.QueuedAlerts = b.maintenance.until, len(b.maintenance.queued)
+	b.maintenance.mu.Unlock()
+	return report, nil
+}
+
+// formatHandoffReport is the /handoff report
+func (b *BotController) formatHandoffReport(report *handoffReport) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🔄 <b>Shift hand-off</b>\n🕐 %s — %s UTC (%s)\n", report.Since.UTC().Format("2006-01-02 15:04"),
+		report.Until.UTC().Format("2006-01-02 15:04"), report.Until.Sub(report.Since).Round(time.Minute)))
+
+	text.WriteString("\n🛡 <b>Open incidents</b>\n")
+	if !report.WarRoom && report.Maintenance.IsZero() && len(report.Escalated) == 0 {
+		text.WriteString("✅ None\n")
+	}
+	if report.WarRoom {
+		text.WriteString(fmt.Sprintf("• War room active until %s UTC, /warroom for its status\n", report.WarRoomUntil.UTC().Format("15:04")))
+	}
+	if !report.Maintenance.IsZero() {
+		text.WriteString(fmt.Sprintf("• Maintenance until %s UTC, %d alerts queued\n", report.Maintenance.UTC().Format("15:04"), report.QueuedAlerts))
+	}
+	for _, check := range report.Escalated {
+		text.WriteString(fmt.Sprintf("• 🔺 @%s escalated at the %s follow-up, /history_%s\n", html.EscapeString(check.Username), check.Delay, check.Username))
+	}
+
+	text.WriteString(fmt.Sprintf("\n🚨 <b>Unacknowledged criticals:</b> %d\n", len(report.Unacknowledged)))
+	for _, alert := range report.Unacknowledged {
+		text.WriteString(fmt.Sprintf("• %s @%s%s /history_%s · /ack_%s\n", alert.CreatedAt.UTC().Format("15:04"), html.EscapeString(alert.FUDUsername),
+			b.handoffFUDType(alert), alert.FUDUsername, alert.NotificationID))
+	}
+
+	severities := make(map[string]int)
+	users := make(map[string]int)
+	for _, alert := range report.Alerts {
+		severities[alert.AlertSeverity]++
+		users[alert.FUDUsername]++
+	}
+	text.WriteString(fmt.Sprintf("\n📣 <b>Alerts:</b> %d%s\n", len(report.Alerts), b.formatSeverityCounts(severities)))
+	if len(users) > 0 {
+		usernames := make([]string, 0, len(users))
+		for username := range users {
+			usernames = append(usernames, username)
+		}
+		sort.Slice(usernames, func(i, j int) bool {
+			if users[usernames[i]] != users[usernames[j]] {
+				return users[usernames[i]] > users[usernames[j]]
+			}
+			return usernames[i] < usernames[j]
+		})
+		var top []string
+		for i, username := range usernames {
+			if i == HANDOFF_TOP_USERS {
+				break
+			}
+			top = append(top, fmt.Sprintf("@%s (%d)", html.EscapeString(username), users[username]))
+		}
+		text.WriteString("🏆 Most alerted: " + strings.Join(top, ", ") + "\n")
+	}
+	for _, alert := range report.Alerts {
+		text.WriteString(fmt.Sprintf("• %s %s @%s%s\n", alert.CreatedAt.UTC().Format("15:04"), b.formatter.getSeverityEmoji(alert.AlertSeverity),
+			html.EscapeString(alert.FUDUsername), b.handoffFUDType(alert)))
+	}
+
+	text.WriteString(fmt.Sprintf("\n🔄 <b>Running tasks:</b> %d\n", len(report.Tasks)))
+	for _, task := range report.Tasks {
+		text.WriteString(fmt.Sprintf("• @%s %s, %s\n", html.EscapeString(task.Username), task.Status, html.EscapeString(task.ProgressText)))
+	}
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// handoffFUDType is the FUD type of a stored alert, empty when its payload cannot be read
+func (b *BotController) handoffFUDType(notification NotificationModel) string {
+	var alert FUDAlertNotification
+	if err := json.Unmarshal([]byte(notification.Payload), &alert); err != nil || alert.FUDType == "" {
+		return ""
+	}
+	return " · " + b.formatter.formatFUDType(alert.FUDType)
+}
+
+// handleHandoffCommand processes /handoff [hours]: the report fits one message, or is sent in full as a document
+func (b *BotController) handleHandoffCommand(chatID int64, args []string) {
+	period, err := parseHandoffPeriod(args)
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	now := time.Now()
+	report, err := b.buildHandoffReport(now, period)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error building the hand-off report: %v", err))
+		return
+	}
+
+	full := b.formatHandoffReport(report)
+	if len(full) <= TELEGRAM_MAX_MESSAGE_LENGTH {
+		b.SendMessage(chatID, full)
+		return
+	}
+	content := html.UnescapeString(handoffTagRegex.ReplaceAllString(full, ""))
+	filename := fmt.Sprintf("handoff_%s.txt", now.Format("20060102_150405"))
+	caption := fmt.Sprintf("🔄 <b>Shift hand-off</b>, last %s\n🛡 Escalated follow-ups: %d\n🚨 Unacknowledged criticals: %d\n📣 Alerts: %d\n🔄 Running tasks: %d",
+		period, len(report.Escalated), len(report.Unacknowledged), len(report.Alerts), len(report.Tasks))
+	if err := b.sendExportDocument(chatID, filename, content, caption); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
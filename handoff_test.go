@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHandoffPeriod(t *testing.T) {
+	period, err := parseHandoffPeriod(nil)
+	require.NoError(t, err)
+	assert.Equal(t, HANDOFF_DEFAULT_PERIOD, period)
+
+	period, err = parseHandoffPeriod([]string{"12h"})
+	require.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, period)
+
+	for _, invalid := range []string{"0", "100h", "shift"} {
+		_, err = parseHandoffPeriod([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestHandoffReport(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveNotification("n1", FUDAlertNotification{FUDUserID: "u1", FUDUsername: "attacker", AlertSeverity: "critical", FUDType: "coordinated_attack"}, time.Hour))
+	require.NoError(t, db.SaveNotification("n2", FUDAlertNotification{FUDUserID: "u2", FUDUsername: "reviewed", AlertSeverity: "critical"}, time.Hour))
+	require.NoError(t, db.SaveNotification("n3", FUDAlertNotification{FUDUserID: "u3", FUDUsername: "grumbler", AlertSeverity: "low"}, time.Hour))
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "u2", Username: "reviewed", Label: LABEL_FUD, LabeledBy: "@mod"}))
+	require.NoError(t, db.CreateAnalysisTask(&AnalysisTaskModel{ID: "t1", Username: "suspect", Status: ANALYSIS_STATUS_RUNNING, ProgressText: "Fetching tweets"}))
+	check := FollowUpCheckModel{NotificationID: "n1", UserID: "u1", Username: "attacker", Delay: "24h", Status: FOLLOW_UP_STATUS_PENDING, DueAt: time.Now()}
+	require.NoError(t, db.ScheduleFollowUpChecks([]FollowUpCheckModel{check}))
+	pending, _, err := db.GetPendingFollowUpChecks(1)
+	require.NoError(t, err)
+	require.NoError(t, db.CompleteFollowUpCheck(&pending[0], FOLLOW_UP_ESCALATED, "New alerts: 1"))
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	report, err := bot.buildHandoffReport(time.Now(), HANDOFF_DEFAULT_PERIOD)
+	require.NoError(t, err)
+	assert.Len(t, report.Alerts, 3)
+	require.Len(t, report.Unacknowledged, 1, "the marked user is acknowledged, low alerts are not criticals")
+	assert.Equal(t, "attacker", report.Unacknowledged[0].FUDUsername)
+	assert.Len(t, report.Escalated, 1)
+	assert.Len(t, report.Tasks, 1)
+
+	bot.handleHandoffCommand(1, nil)
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	text := sent[0].Text
+	assert.Contains(t, text, "Unacknowledged criticals:</b> 1")
+	assert.Contains(t, text, "@attacker escalated at the 24h follow-up")
+	assert.Contains(t, text, "Alerts:</b> 3")
+	assert.Contains(t, text, "@suspect running, Fetching tweets")
+
+	bot.handleHandoffCommand(1, []string{"forever"})
+	assert.True(t, strings.HasPrefix(transport.sentMessages()[1].Text, "❌"))
+}
+
+func TestHandoffReportAsDocument(t *testing.T) {
+	db := setupTestDB(t)
+	for i := 0; i < 120; i++ {
+		alert := FUDAlertNotification{FUDUserID: fmt.Sprintf("u%d", i), FUDUsername: fmt.Sprintf("user_%d", i), AlertSeverity: "critical"}
+		require.NoError(t, db.SaveNotification(fmt.Sprintf("n%d", i), alert, time.Hour))
+	}
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.handleHandoffCommand(1, []string{"8"})
+	assert.Empty(t, transport.sentMessages())
+	transport.mu.Lock()
+	documents := transport.documents
+	transport.mu.Unlock()
+	require.Len(t, documents, 1)
+	assert.Contains(t, documents[0].Caption, "Alerts: 120")
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	HEALTH_STATUS_OK          = "ok"
+	HEALTH_STATUS_UNAVAILABLE = "unavailable"
+)
+
+// apiStatusReporter is implemented by the API clients that track their latest request
+type apiStatusReporter interface {
+	Status() twitterapi.APIStatus
+}
+
+// queueGauge reports the length and capacity of a pipeline channel
+type queueGauge func() (depth int, capacity int)
+
+// HealthChecker builds the reports of /healthz and /readyz
+type HealthChecker struct {
+	dbService *DatabaseService
+	telegram  apiStatusReporter
+	twitter   apiStatusReporter
+	watchdog  *ingestionWatchdog
+	queues    map[string]queueGauge
+	started   time.Time
+}
+
+type healthCheck struct {
+	OK          bool       `json:"ok"`
+	Error       string     `json:"error,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+type queueDepth struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+}
+
+type healthReport struct {
+	Status            string                 `json:"status"`
+	Uptime            string                 `json:"uptime"`
+	Checks            map[string]healthCheck `json:"checks"`
+	Queues            map[string]queueDepth  `json:"queues,omitempty"`
+	RunningTasks      *int                   `json:"running_tasks,omitempty"`
+	LastIngestion     *time.Time             `json:"last_ingestion,omitempty"`
+	IngestionProblems []string               `json:"ingestion_problems,omitempty"`
+}
+
+func NewHealthChecker(dbService *DatabaseService, bot *BotController, twitter apiStatusReporter) *HealthChecker {
+	return &HealthChecker{
+		dbService: dbService,
+		telegram:  &bot.telegram,
+		twitter:   twitter,
+		watchdog:  &bot.watchdog,
+		queues:    make(map[string]queueGauge),
+		started:   time.Now(),
+	}
+}
+
+// AddQueue reports the depth of a pipeline channel in /readyz, a full channel makes the bot not ready
+func (h *HealthChecker) AddQueue(name string, gauge queueGauge) {
+	h.queues[name] = gauge
+}
+
+// Liveness only checks what a restart can fix: the process answers and its database too
+func (h *HealthChecker) Liveness() healthReport {
+	report := h.newReport()
+	report.Checks["database"] = h.checkDatabase()
+	return report.finish()
+}
+
+// Readiness checks the database, the Telegram and Twitter APIs and the queues, and reports the last ingestion.
+// Quiet communities are normal so ingestion problems are reported without failing the check.
+func (h *HealthChecker) Readiness() healthReport {
+	report := h.newReport()
+	report.Checks["database"] = h.checkDatabase()
+
+	telegram := h.telegram.Status()
+	telegramCheck := apiCheck(telegram)
+	if telegram.LastSuccess.IsZero() && telegramCheck.OK {
+		telegramCheck = healthCheck{Error: "no successful poll yet"}
+	}
+	report.Checks["telegram"] = telegramCheck
+	if h.twitter != nil {
+		report.Checks["twitter"] = apiCheck(h.twitter.Status())
+	}
+
+	queues := healthCheck{OK: true}
+	for name, gauge := range h.queues {
+		depth, capacity := gauge()
+		report.Queues[name] = queueDepth{Depth: depth, Capacity: capacity}
+		if capacity > 0 && depth >= capacity {
+			queues = healthCheck{Error: fmt.Sprintf("%s queue is full", name)}
+		}
+	}
+	report.Checks["queues"] = queues
+	if tasks, err := h.dbService.GetAllRunningAnalysisTasks(); err == nil {
+		running := len(tasks)
+		report.RunningTasks = &running
+	}
+
+	if last := h.watchdog.lastIngestion(); !last.IsZero() {
+		report.LastIngestion = &last
+	}
+	report.IngestionProblems = h.watchdog.problems(time.Now(), watchdogStallThreshold())
+	return report.finish()
+}
+
+func (h *HealthChecker) newReport() healthReport {
+	return healthReport{
+		Uptime: time.Since(h.started).Round(time.Second).String(),
+		Checks: make(map[string]healthCheck),
+		Queues: make(map[string]queueDepth),
+	}
+}
+
+func (h *HealthChecker) checkDatabase() healthCheck {
+	if err := h.dbService.Ping(); err != nil {
+		return healthCheck{Error: err.Error()}
+	}
+	return healthCheck{OK: true}
+}
+
+// apiCheck fails while the latest request to the API failed
+func apiCheck(status twitterapi.APIStatus) healthCheck {
+	check := healthCheck{OK: !status.Failing()}
+	if !status.LastSuccess.IsZero() {
+		check.LastSuccess = &status.LastSuccess
+	}
+	if !check.OK {
+		check.Error = status.LastError
+	}
+	return check
+}
+
+// healthSafeError reduces a failed Telegram request to the method and the kind of failure for /readyz.
+// Transport errors quote the request URL, which carries the bot token.
+func healthSafeError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *TelegramAPIError
+	if errors.As(err, &apiErr) {
+		return fmt.Errorf("%s: HTTP %d", method, apiErr.StatusCode)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%s: timeout", method)
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return fmt.Errorf("%s: connection failed (%s)", method, opErr.Op)
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("%s: invalid response", method)
+	}
+	return fmt.Errorf("%s: request failed", method)
+}
+
+// finish sets the overall status, unavailable when any check failed
+func (r healthReport) finish() healthReport {
+	r.Status = HEALTH_STATUS_OK
+	for _, check := range r.Checks {
+		if !check.OK {
+			r.Status = HEALTH_STATUS_UNAVAILABLE
+		}
+	}
+	return r
+}
+
+func newHealthHandler(health *HealthChecker) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, health.Liveness())
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, health.Readiness())
+	})
+	return mux
+}
+
+// writeHealthReport answers 200 when healthy and 503 otherwise, as Kubernetes probes expect
+func writeHealthReport(w http.ResponseWriter, report healthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != HEALTH_STATUS_OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// StartHealthServer serves /healthz and /readyz on their own listener so probes need no API token.
+// It is disabled when addr is empty.
+func StartHealthServer(addr string, health *HealthChecker) error {
+	if addr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start health listener: %w", err)
+	}
+
+	go func() {
+		err := http.Serve(listener, newHealthHandler(health))
+		if err != nil {
+			log.Printf("Health server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("🩺 Health checks available at http://%s/healthz and /readyz", listener.Addr())
+	return nil
+}
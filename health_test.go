@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler(t *testing.T) {
+	db := setupTestDB(t)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	twitter := &twitterapi.StatusTracker{}
+	health := NewHealthChecker(db, bot, twitter)
+	queue := make(chan twitterapi.NewMessage, 2)
+	health.AddQueue("second_step", func() (int, int) { return len(queue), cap(queue) })
+	handler := newHealthHandler(health)
+	probe := func(path string) (int, healthReport) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		var report healthReport
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+		return recorder.Code, report
+	}
+
+	code, report := probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, report.Checks["database"].OK)
+
+	code, report = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code, "not ready before the first Telegram poll")
+	assert.Equal(t, "no successful poll yet", report.Checks["telegram"].Error)
+	assert.True(t, report.Checks["twitter"].OK, "no Twitter request yet is not a failure")
+
+	bot.telegram.Record(nil)
+	bot.watchdog.beat(CommunityModel{ID: "1", Ticker: "$GRUT"}, 4)
+	queue <- twitterapi.NewMessage{}
+	code, report = probe("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HEALTH_STATUS_OK, report.Status)
+	assert.Equal(t, queueDepth{Depth: 1, Capacity: 2}, report.Queues["second_step"])
+	require.NotNil(t, report.LastIngestion)
+	require.NotNil(t, report.RunningTasks)
+	assert.Equal(t, 0, *report.RunningTasks)
+
+	twitter.Record(errors.New("status 401"))
+	queue <- twitterapi.NewMessage{}
+	code, report = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "status 401", report.Checks["twitter"].Error)
+	assert.Equal(t, "second_step queue is full", report.Checks["queues"].Error)
+	code, _ = probe("/healthz")
+	assert.Equal(t, http.StatusOK, code, "liveness ignores external APIs so they do not restart the bot")
+}
+
+func TestHealthSafeError(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	client := server.newClient(t)
+	server.failWithTooManyRequests("getUpdates", 1, 3)
+	_, err := client.GetUpdates(0)
+	assert.EqualError(t, healthSafeError("getUpdates", err), "getUpdates: HTTP 429")
+
+	server.Close()
+	_, err = client.GetUpdates(0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), FAKE_TELEGRAM_TOKEN, "transport errors quote the URL")
+	safe := healthSafeError("getUpdates", err)
+	assert.NotContains(t, safe.Error(), FAKE_TELEGRAM_TOKEN)
+	assert.Equal(t, "getUpdates: connection failed (dial)", safe.Error())
+	assert.NoError(t, healthSafeError("getUpdates", nil))
+}
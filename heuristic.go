@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	HEURISTIC_FUD_TYPE      = "heuristic_fud"
+	HEURISTIC_FUD_THRESHOLD = 50
+	HEURISTIC_HIGH_SCORE    = 80
+)
+
+// Why an alert was assessed heuristically, shown on the alert
+const (
+	HEURISTIC_REASON_UNAVAILABLE = "AI analysis was unavailable"
+	HEURISTIC_REASON_BUDGET      = "the daily LLM budget is spent"
+)
+
+// Phrases that are hostile on their own, regardless of context
+var hostileKeywords = []string{
+	"scam", "rug", "rugpull", "rug pull", "ponzi", "exit scam", "honeypot", "fraud",
+	"dead project", "dead coin", "going to zero", "sell now", "dump it", "devs dumped",
+	"devs sold", "stay away", "worthless", "shitcoin",
+}
+
+var trailingDigitsRegex = regexp.MustCompile(`\d{4,}$`)
+
+// HeuristicAssessment is the degraded verdict used while the LLM provider is unavailable
+type HeuristicAssessment struct {
+	Score    int
+	IsFUD    bool
+	Evidence []string
+}
+
+// isLLMUnavailable reports whether a Claude error means the provider is down or overloaded,
+// as opposed to a bad request or an unparsable answer
+func isLLMUnavailable(err error) bool {
+	var statusErr *ClaudeStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// assessMessageHeuristically scores a message from keywords, shouting, competitor shilling and
+// account signals only
+func assessMessageHeuristically(newMessage twitterapi.NewMessage, isKnownFUDUser bool) HeuristicAssessment {
+	assessment := HeuristicAssessment{}
+	lower := strings.ToLower(newMessage.Text)
+
+	keywordScore := 0
+	for _, keyword := range hostileKeywords {
+		if strings.Contains(lower, keyword) {
+			keywordScore += 25
+			assessment.Evidence = append(assessment.Evidence, fmt.Sprintf("Hostile keyword: %q", keyword))
+		}
+	}
+	assessment.Score += min(keywordScore, 50)
+
+	letters, upper := 0, 0
+	for _, r := range newMessage.Text {
+		if r >= 'a' && r <= 'z' {
+			letters++
+		} else if r >= 'A' && r <= 'Z' {
+			letters++
+			upper++
+		}
+	}
+	if letters >= 20 && upper*100/letters >= 70 {
+		assessment.Score += 10
+		assessment.Evidence = append(assessment.Evidence, "Message is mostly in capitals")
+	}
+	if strings.Count(newMessage.Text, "!") >= 3 {
+		assessment.Score += 5
+		assessment.Evidence = append(assessment.Evidence, "Excessive exclamation marks")
+	}
+
+	if promotions := loadCompetitorList().findPromotions(newMessage.Text); len(promotions) > 0 {
+		assessment.Score += 15
+		assessment.Evidence = append(assessment.Evidence, "Promotes competitors: "+strings.Join(promotions, ", "))
+	}
+
+	if isKnownFUDUser {
+		assessment.Score += 30
+		assessment.Evidence = append(assessment.Evidence, "Known FUD user")
+	}
+	// Auto-generated handles like name12345678 are typical for bot accounts
+	if trailingDigitsRegex.MatchString(newMessage.Author.UserName) {
+		assessment.Score += 10
+		assessment.Evidence = append(assessment.Evidence, "Bot-like username")
+	}
+
+	assessment.Score = min(assessment.Score, 100)
+	assessment.IsFUD = assessment.Score >= HEURISTIC_FUD_THRESHOLD
+	return assessment
+}
+
+// sendHeuristicAlert runs the fallback assessment and sends a clearly labeled alert if it flags the message,
+// reporting whether it did. Nothing is stored, so the user gets a full analysis once the LLM is back.
+func sendHeuristicAlert(newMessage twitterapi.NewMessage, isKnownFUDUser bool, reason string, notificationCh chan FUDAlertNotification) bool {
+	assessment := assessMessageHeuristically(newMessage, isKnownFUDUser)
+	if !assessment.IsFUD {
+		log.Printf("🧮 Heuristics only (%s) - score @%s at %d, no alert", reason, newMessage.Author.UserName, assessment.Score)
+		return false
+	}
+
+	severity := "medium"
+	if assessment.Score >= HEURISTIC_HIGH_SCORE {
+		severity = "high"
+	}
+	log.Printf("🧮 Heuristics only (%s) - alert for @%s (score %d)", reason, newMessage.Author.UserName, assessment.Score)
+
+	alert := FUDAlertNotification{
+		FUDMessageID:      newMessage.TweetID,
+		FUDUserID:         newMessage.Author.ID,
+		FUDUsername:       newMessage.Author.UserName,
+		ThreadID:          newMessage.ReplyTweetID,
+		DetectedAt:        time.Now().Format(time.RFC3339),
+		AlertSeverity:     severity,
+		FUDType:           HEURISTIC_FUD_TYPE,
+		FUDProbability:    float64(assessment.Score) / 100.0,
+		MessagePreview:    newMessage.Text,
+		RecommendedAction: "VERIFY_MANUALLY",
+		KeyEvidence:       assessment.Evidence,
+		DecisionReason:    "Heuristic assessment only, " + reason,
+		UserSummary:       "Not analyzed, " + reason,
+	}
+	setAlertThreadContext(&alert, newMessage)
+	routeAlert(&alert, newMessage)
+	notificationCh <- alert
+	return true
+}
+
+// setAlertThreadContext copies the thread a message replied in into an alert sent without an analysis
+func setAlertThreadContext(alert *FUDAlertNotification, newMessage twitterapi.NewMessage) {
+	if newMessage.ParentTweet.ID != "" {
+		alert.ParentPostText = newMessage.ParentTweet.Text
+		alert.ParentPostAuthor = newMessage.ParentTweet.Author
+		alert.OriginalPostText = newMessage.ParentTweet.Text
+		alert.OriginalPostAuthor = newMessage.ParentTweet.Author
+		alert.HasThreadContext = true
+	}
+	if newMessage.GrandParentTweet.ID != "" {
+		alert.GrandParentPostText = newMessage.GrandParentTweet.Text
+		alert.GrandParentPostAuthor = newMessage.GrandParentTweet.Author
+		alert.OriginalPostText = newMessage.GrandParentTweet.Text
+		alert.OriginalPostAuthor = newMessage.GrandParentTweet.Author
+	}
+}
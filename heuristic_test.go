@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAssessMessageHeuristically(t *testing.T) {
+	hostile := twitterapi.NewMessage{Text: "This is a SCAM, devs dumped, sell now!!!"}
+	hostile.Author.UserName = "crypto84729301"
+	assessment := assessMessageHeuristically(hostile, false)
+	assert.True(t, assessment.IsFUD)
+	assert.Contains(t, assessment.Evidence, "Bot-like username")
+	assert.Contains(t, assessment.Evidence, "Excessive exclamation marks")
+
+	benign := twitterapi.NewMessage{Text: "gm, great AMA today"}
+	benign.Author.UserName = "holder"
+	assert.False(t, assessMessageHeuristically(benign, false).IsFUD)
+	assert.Equal(t, 30, assessMessageHeuristically(benign, true).Score)
+}
+
+func TestIsLLMUnavailable(t *testing.T) {
+	assert.True(t, isLLMUnavailable(&ClaudeStatusError{StatusCode: 529}))
+	assert.True(t, isLLMUnavailable(&ClaudeStatusError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isLLMUnavailable(&ClaudeStatusError{StatusCode: http.StatusBadRequest}))
+	assert.False(t, isLLMUnavailable(fmt.Errorf("claude SendMessage unmarshall err")))
+}
+
+func TestFirstStepHandler_HeuristicFallback(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u1", Username: "shady", FUDType: "direct_attack", DetectedAt: time.Now()}))
+
+	claudeApi := newMockClaudeAPI("", &ClaudeStatusError{StatusCode: 529})
+
+	newMessageCh := make(chan twitterapi.NewMessage, 1)
+	notificationCh := make(chan FUDAlertNotification, 1)
+	message := twitterapi.NewMessage{TweetID: "t1", Text: "total rug pull, stay away"}
+	message.Author.ID = "u1"
+	message.Author.UserName = "shady"
+	newMessageCh <- message
+	close(newMessageCh)
+
+	FirstStepHandler(newMessageCh, make(chan twitterapi.NewMessage, 1), claudeApi, nil, &mockUserStatusTracker{}, db, notificationCh, &warRoomState{}, nil)
+
+	require.Len(t, claudeApi.recordedCalls(), 1)
+	assert.Equal(t, USAGE_STEP_KNOWN_FUD, claudeApi.recordedCalls()[0].step)
+	require.Len(t, notificationCh, 1)
+	alert := <-notificationCh
+	assert.Equal(t, HEURISTIC_FUD_TYPE, alert.FUDType)
+	assert.Contains(t, alert.KeyEvidence, "Known FUD user")
+	assert.Contains(t, NewNotificationFormatter().FormatForTelegramWithDetail(alert, "n1"), "HEURISTIC FUD ALERT")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of /export_<username>
+const (
+	EXPORT_FORMAT_TXT  = "txt"
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_JSON = "json"
+)
+
+// exportedTweet is one message of a structured history export, with the edits recorded after it was flagged
+type exportedTweet struct {
+	ID            string             `json:"id"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UserID        string             `json:"user_id"`
+	Username      string             `json:"username"`
+	Text          string             `json:"text"`
+	InReplyToID   string             `json:"in_reply_to_id"`
+	ReplyCount    int                `json:"reply_count"`
+	SourceType    string             `json:"source_type"`
+	TickerMention string             `json:"ticker_mention"`
+	SearchQuery   string             `json:"search_query"`
+	CommunityID   string             `json:"community_id"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Edits         []exportedRevision `json:"edits,omitempty"`
+}
+
+type exportedRevision struct {
+	Revision  int       `json:"revision"`
+	Text      string    `json:"text"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// historyExport is the JSON document of /export_<username> json
+type historyExport struct {
+	Username    string          `json:"username"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Total       int             `json:"total"`
+	Messages    []exportedTweet `json:"messages"`
+}
+
+var historyCSVHeader = []string{"id", "created_at", "user_id", "username", "text", "in_reply_to_id", "reply_count", "source_type", "ticker_mention", "search_query", "community_id", "updated_at", "edits", "current_text"}
+
+// parseExportFormat reads the optional format argument of /export_<username>, txt by default
+func parseExportFormat(args []string) (string, bool) {
+	if len(args) == 0 {
+		return EXPORT_FORMAT_TXT, true
+	}
+	switch format := strings.ToLower(args[0]); format {
+	case EXPORT_FORMAT_TXT, EXPORT_FORMAT_CSV, EXPORT_FORMAT_JSON:
+		return format, true
+	}
+	return "", false
+}
+
+func exportedTweets(tweets []TweetModel, revisions map[string][]TweetRevisionModel) []exportedTweet {
+	exported := make([]exportedTweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		item := exportedTweet{
+			ID:            tweet.ID,
+			CreatedAt:     tweet.CreatedAt.UTC(),
+			UserID:        tweet.UserID,
+			Username:      tweet.Username,
+			Text:          tweet.Text,
+			InReplyToID:   tweet.InReplyToID,
+			ReplyCount:    tweet.ReplyCount,
+			SourceType:    tweet.SourceType,
+			TickerMention: tweet.TickerMention,
+			SearchQuery:   tweet.SearchQuery,
+			CommunityID:   tweet.CommunityID,
+			UpdatedAt:     tweet.UpdatedAt.UTC(),
+		}
+		for _, revision := range revisions[tweet.ID] {
+			item.Edits = append(item.Edits, exportedRevision{Revision: revision.Revision, Text: revision.Text, FetchedAt: revision.FetchedAt.UTC()})
+		}
+		exported = append(exported, item)
+	}
+	return exported
+}
+
+// renderHistoryJSON renders a user's messages as one indented JSON document
+func renderHistoryJSON(username string, tweets []TweetModel, revisions map[string][]TweetRevisionModel, generatedAt time.Time) (string, error) {
+	data, err := json.MarshalIndent(historyExport{
+		Username:    username,
+		GeneratedAt: generatedAt.UTC(),
+		Total:       len(tweets),
+		Messages:    exportedTweets(tweets, revisions),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// renderHistoryCSV renders a user's messages one per row. Edited messages carry their edit count
+// and the latest text next to the text they were stored with.
+func renderHistoryCSV(tweets []TweetModel, revisions map[string][]TweetRevisionModel) (string, error) {
+	var out strings.Builder
+	writer := csv.NewWriter(&out)
+	if err := writer.Write(historyCSVHeader); err != nil {
+		return "", err
+	}
+	for _, tweet := range exportedTweets(tweets, revisions) {
+		edits, currentText := 0, ""
+		if len(tweet.Edits) > 0 {
+			edits = len(tweet.Edits) - 1
+			currentText = tweet.Edits[len(tweet.Edits)-1].Text
+		}
+		err := writer.Write([]string{
+			tweet.ID,
+			tweet.CreatedAt.Format(time.RFC3339),
+			tweet.UserID,
+			tweet.Username,
+			tweet.Text,
+			tweet.InReplyToID,
+			strconv.Itoa(tweet.ReplyCount),
+			tweet.SourceType,
+			tweet.TickerMention,
+			tweet.SearchQuery,
+			tweet.CommunityID,
+			tweet.UpdatedAt.Format(time.RFC3339),
+			strconv.Itoa(edits),
+			currentText,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return out.String(), writer.Error()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryExport_Formats(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tweets := []TweetModel{
+		{ID: "2", UserID: "u1", Username: "alice", Text: "devs are \"dumping\", sell, now", InReplyToID: "1", SourceType: TWEET_SOURCE_COMMUNITY, CommunityID: "c1", CreatedAt: createdAt},
+		{ID: "1", UserID: "u1", Username: "alice", Text: "gm", SourceType: TWEET_SOURCE_TICKER_SEARCH, TickerMention: "$GRUT", CreatedAt: createdAt.Add(-time.Hour)},
+	}
+	revisions := map[string][]TweetRevisionModel{"2": {
+		{TweetID: "2", Revision: 0, Text: tweets[0].Text, FetchedAt: createdAt},
+		{TweetID: "2", Revision: 1, Text: "never mind", FetchedAt: createdAt.Add(time.Minute)},
+	}}
+
+	rendered, err := renderHistoryCSV(tweets, revisions)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(strings.NewReader(rendered)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, historyCSVHeader, rows[0])
+	assert.Equal(t, []string{"2", "2026-03-01T12:00:00Z", "u1", "alice", tweets[0].Text, "1", "0", TWEET_SOURCE_COMMUNITY, "", "", "c1", "0001-01-01T00:00:00Z", "1", "never mind"}, rows[1])
+	assert.Equal(t, "$GRUT", rows[2][8])
+	assert.Equal(t, "0", rows[2][12])
+
+	rendered, err = renderHistoryJSON("alice", tweets, revisions, createdAt)
+	require.NoError(t, err)
+	var export historyExport
+	require.NoError(t, json.Unmarshal([]byte(rendered), &export))
+	assert.Equal(t, 2, export.Total)
+	require.Len(t, export.Messages, 2)
+	assert.Equal(t, tweets[0].Text, export.Messages[0].Text)
+	require.Len(t, export.Messages[0].Edits, 2)
+	assert.Equal(t, "never mind", export.Messages[0].Edits[1].Text)
+	assert.Empty(t, export.Messages[1].Edits)
+}
+
+func TestHistoryExport_Command(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "alice"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "1", UserID: "u1", Text: "gm"}))
+
+	bot.handleExportCommand(1, "/export_alice", []string{"CSV"})
+	bot.handleExportCommand(1, "/export_alice", []string{"json"})
+	bot.handleExportCommand(1, "/export_alice", []string{"xlsx"})
+
+	transport.mu.Lock()
+	documents := transport.documents
+	transport.mu.Unlock()
+	require.Len(t, documents, 2)
+	assert.Contains(t, documents[0].Caption, "Format: CSV")
+	assert.Contains(t, documents[0].Caption, "SHA-256")
+	assert.Contains(t, documents[1].Caption, "Format: JSON")
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "Unknown format")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HISTORY_WINDOW_ALL    = "all"
+	HISTORY_WINDOW_TICKER = "ticker" // ticker mentions only, the community activity is left out
+	HISTORY_OPTION_PREFIX = "history:"
+)
+
+// historyWindow limits the user history the second step sees. The zero value sends everything.
+type historyWindow struct {
+	Messages   int  // newest messages of each history source, 0 is unlimited
+	Days       int  // messages of the last days, 0 is unlimited
+	TickerOnly bool // only the ticker mentions, without the community activity
+}
+
+// parseHistoryWindow reads comma-separated limits: N messages, Nd days and ticker, or all.
+// For example "50", "30d", "ticker" or "ticker,20,7d".
+func parseHistoryWindow(spec string) (historyWindow, error) {
+	var window historyWindow
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" || spec == HISTORY_WINDOW_ALL {
+		return window, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == HISTORY_WINDOW_TICKER:
+			window.TickerOnly = true
+		case strings.HasSuffix(part, "d"):
+			days, err := strconv.Atoi(strings.TrimSuffix(part, "d"))
+			if err != nil || days <= 0 {
+				return window, fmt.Errorf("invalid history window %s, use N messages, Nd days, ticker or all", part)
+			}
+			window.Days = days
+		default:
+			messages, err := strconv.Atoi(part)
+			if err != nil || messages <= 0 {
+				return window, fmt.Errorf("invalid history window %s, use N messages, Nd days, ticker or all", part)
+			}
+			window.Messages = messages
+		}
+	}
+	return window, nil
+}
+
+// String is the canonical form stored on analysis tasks, parseHistoryWindow reads it back
+func (w historyWindow) String() string {
+	var parts []string
+	if w.TickerOnly {
+		parts = append(parts, HISTORY_WINDOW_TICKER)
+	}
+	if w.Messages > 0 {
+		parts = append(parts, strconv.Itoa(w.Messages))
+	}
+	if w.Days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", w.Days))
+	}
+	if len(parts) == 0 {
+		return HISTORY_WINDOW_ALL
+	}
+	return strings.Join(parts, ",")
+}
+
+// describe is the human readable window shown in progress messages
+func (w historyWindow) describe() string {
+	var parts []string
+	if w.Messages > 0 {
+		parts = append(parts, fmt.Sprintf("last %d messages", w.Messages))
+	}
+	if w.Days > 0 {
+		parts = append(parts, fmt.Sprintf("last %d days", w.Days))
+	}
+	if w.TickerOnly {
+		parts = append(parts, "ticker mentions only")
+	}
+	if len(parts) == 0 {
+		return "full history"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// since is the oldest time inside the window, zero without a day limit
+func (w historyWindow) since(now time.Time) time.Time {
+	if w.Days == 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -w.Days)
+}
+
+// defaultHistoryWindow reads ENV_HISTORY_WINDOW, used by monitoring and by analyses without a history: option
+func defaultHistoryWindow() historyWindow {
+	window, err := parseHistoryWindow(os.Getenv(ENV_HISTORY_WINDOW))
+	if err != nil {
+		log.Printf("Warning: %s: %v, sending the full history", ENV_HISTORY_WINDOW, err)
+		return historyWindow{}
+	}
+	return window
+}
+
+// messageHistoryWindow is the window recorded on the message's task, or the configured default
+func messageHistoryWindow(spec string) historyWindow {
+	if spec == "" {
+		return defaultHistoryWindow()
+	}
+	window, err := parseHistoryWindow(spec)
+	if err != nil {
+		log.Printf("Warning: stored history window %q: %v, using the default", spec, err)
+		return defaultHistoryWindow()
+	}
+	return window
+}
+
+// filterTickerMentions keeps the newest mentions inside the window. Mentions without a readable date are kept.
+func (w historyWindow) filterTickerMentions(data *UserTickerMentionsData, now time.Time) {
+	if data == nil {
+		return
+	}
+	since := w.since(now)
+	kept := make([]UserMessageWithReplies, 0, len(data.UserMessages))
+	for _, message := range data.UserMessages {
+		if w.Messages > 0 && len(kept) == w.Messages {
+			break
+		}
+		if createdAt, err := parseTweetTime(message.CreatedAt); err == nil && createdAt.Before(since) {
+			continue
+		}
+		kept = append(kept, message)
+	}
+	data.UserMessages = kept
+	data.TotalMessages = len(kept)
+}
+
+// parseTweetTime reads the created_at of the Twitter API
+func parseTweetTime(value string) (time.Time, error) {
+	createdAt, err := time.Parse(time.RubyDate, value)
+	if err != nil {
+		createdAt, err = time.Parse(time.RFC3339, value)
+	}
+	return createdAt, err
+}
+
+// applyAnalysisOption applies a to: or history: option of an analysis command to the task
+func (b *BotController) applyAnalysisOption(task *AnalysisTaskModel, option string) error {
+	if strings.HasPrefix(strings.ToLower(option), HISTORY_OPTION_PREFIX) {
+		window, err := parseHistoryWindow(option[len(HISTORY_OPTION_PREFIX):])
+		if err != nil {
+			return err
+		}
+		task.HistoryWindow = window.String()
+		return nil
+	}
+	return b.setNotifyRoute(task, option)
+}
+
+// isAnalysisOption tells the options of analysis commands apart from their targets
+func isAnalysisOption(arg string) bool {
+	lower := strings.ToLower(arg)
+	return strings.HasPrefix(lower, "to:") || strings.HasPrefix(lower, HISTORY_OPTION_PREFIX)
+}
+
+// formatTaskHistoryWindow shows the history the task was analyzed with when it was limited
+func formatTaskHistoryWindow(task *AnalysisTaskModel) string {
+	if task.HistoryWindow == "" || task.HistoryWindow == HISTORY_WINDOW_ALL {
+		return ""
+	}
+	return fmt.Sprintf("\n📚 <b>History:</b> %s", messageHistoryWindow(task.HistoryWindow).describe())
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHistoryWindow(t *testing.T) {
+	tests := []struct {
+		spec     string
+		window   historyWindow
+		str      string
+		describe string
+	}{
+		{"", historyWindow{}, "all", "full history"},
+		{"ALL", historyWindow{}, "all", "full history"},
+		{"50", historyWindow{Messages: 50}, "50", "last 50 messages"},
+		{"30d", historyWindow{Days: 30}, "30d", "last 30 days"},
+		{"ticker", historyWindow{TickerOnly: true}, "ticker", "ticker mentions only"},
+		{"7d, ticker,20", historyWindow{Messages: 20, Days: 7, TickerOnly: true}, "ticker,20,7d", "last 20 messages, last 7 days, ticker mentions only"},
+	}
+	for _, tt := range tests {
+		window, err := parseHistoryWindow(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.window, window, tt.spec)
+		assert.Equal(t, tt.str, window.String(), tt.spec)
+		assert.Equal(t, tt.describe, window.describe(), tt.spec)
+		again, err := parseHistoryWindow(window.String())
+		require.NoError(t, err)
+		assert.Equal(t, window, again, "the stored form reads back")
+	}
+	for _, spec := range []string{"0", "-5", "d", "0d", "week", "50,soon"} {
+		_, err := parseHistoryWindow(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestHistoryWindow_FilterTickerMentions(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	mentions := func() *UserTickerMentionsData {
+		return &UserTickerMentionsData{UserMessages: []UserMessageWithReplies{
+			{TweetID: "3", CreatedAt: "Sun Mar 09 10:00:00 +0000 2025"},
+			{TweetID: "2", CreatedAt: "garbled"},
+			{TweetID: "1", CreatedAt: "Sat Feb 01 10:00:00 +0000 2025"},
+		}, TotalMessages: 3}
+	}
+	ids := func(data *UserTickerMentionsData) string {
+		var ids []string
+		for _, message := range data.UserMessages {
+			ids = append(ids, message.TweetID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	data := mentions()
+	historyWindow{Messages: 2}.filterTickerMentions(data, now)
+	assert.Equal(t, "3,2", ids(data))
+	assert.Equal(t, 2, data.TotalMessages)
+
+	data = mentions()
+	historyWindow{Days: 7}.filterTickerMentions(data, now)
+	assert.Equal(t, "3,2", ids(data), "undated mentions are kept")
+
+	data = mentions()
+	historyWindow{}.filterTickerMentions(data, now)
+	assert.Equal(t, "3,2,1", ids(data))
+	historyWindow{Days: 7}.filterTickerMentions(nil, now)
+}
+
+func TestSecondStepHandler_HistoryWindow(t *testing.T) {
+	db := setupTestDB(t)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u-hist", Username: "historian"}))
+	old := TweetModel{ID: "h1", Text: "old community rant", UserID: "u-hist", SourceType: TWEET_SOURCE_COMMUNITY, CreatedAt: time.Now().AddDate(0, 0, -60)}
+	recent := TweetModel{ID: "h2", Text: "recent community post", UserID: "u-hist", SourceType: TWEET_SOURCE_COMMUNITY, CreatedAt: time.Now().Add(-time.Hour)}
+	require.NoError(t, db.SaveTweet(old))
+	require.NoError(t, db.SaveTweet(recent))
+
+	activity, err := db.GetUserCommunityActivityWindow("u-hist", 0, time.Now().AddDate(0, 0, -30))
+	require.NoError(t, err)
+	require.Len(t, activity.ThreadGroups, 1)
+	assert.Equal(t, "h2", activity.ThreadGroups[0].MainPost.ID)
+	activity, err = db.GetUserCommunityActivityWindow("u-hist", 1, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, activity.ThreadGroups, 1, "the newest tweet only")
+
+	t.Setenv(ENV_HISTORY_WINDOW, "30d")
+	_, _, err = bot.createAnalysisTask(&AnalysisTaskModel{ID: "hist-default", Username: "historian", Status: ANALYSIS_STATUS_RUNNING})
+	require.NoError(t, err)
+	task := &AnalysisTaskModel{ID: "hist-ticker", Username: "historian", Status: ANALYSIS_STATUS_RUNNING}
+	require.NoError(t, bot.applyAnalysisOption(task, "history:ticker"))
+	assert.Error(t, bot.applyAnalysisOption(task, "history:soon"))
+	_, _, err = bot.createAnalysisTask(task)
+	require.NoError(t, err)
+	stored, err := db.GetAnalysisTask("hist-default")
+	require.NoError(t, err)
+	assert.Equal(t, "30d", stored.HistoryWindow, "the default in effect is recorded on the task")
+	stored, err = db.GetAnalysisTask("hist-ticker")
+	require.NoError(t, err)
+	assert.Equal(t, "ticker", stored.HistoryWindow)
+
+	claudeApi := newMockClaudeAPI(`"is_fud_user": false}`, nil)
+	message := twitterapi.NewMessage{TaskID: "hist-ticker", IsManualAnalysis: true, TweetID: "h2", HistoryWindow: stored.HistoryWindow}
+	message.Author.ID, message.Author.UserName = "u-hist", "historian"
+	SecondStepHandler(message, make(chan FUDAlertNotification, 1), &mockTwitterAPI{}, claudeApi, nil, &mockUserStatusTracker{}, "GRUT", db)
+
+	calls := claudeApi.recordedCalls()
+	require.Len(t, calls, 1)
+	var prompt strings.Builder
+	for _, claudeMessage := range calls[0].messages {
+		prompt.WriteString(claudeMessage.Content + "\n")
+	}
+	assert.NotContains(t, prompt.String(), "recent community post", "community activity is left out")
+	assert.Contains(t, prompt.String(), "HISTORY WINDOW: the user history above is limited to ticker mentions only")
+}
@@ -0,0 +1,151 @@
+package httpretry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	RETRY_MAX_ATTEMPTS = 4
+	RETRY_BASE_DELAY   = 500 * time.Millisecond
+	RETRY_MAX_DELAY    = 20 * time.Second
+)
+
+// RetryPolicy retries transient HTTP failures (network errors, 408, 429 and 5xx) with exponential
+// backoff and full jitter, until MaxAttempts requests were made
+type RetryPolicy struct {
+	Name        string // provider name used in log lines
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Retryable decides which statuses are retried, RetryableStatus when nil
+	Retryable func(statusCode int) bool
+	// RetryableError decides which network errors are retried, every one when nil. Requests that must
+	// not be sent twice use IsConnectError: after a timeout the server may have acted on the request.
+	RetryableError func(err error) bool
+	sleep          func(time.Duration)
+}
+
+func NewRetryPolicy(name string) RetryPolicy {
+	return RetryPolicy{Name: name, MaxAttempts: RETRY_MAX_ATTEMPTS, BaseDelay: RETRY_BASE_DELAY, MaxDelay: RETRY_MAX_DELAY}
+}
+
+// RetryError is returned when every attempt failed, it wraps the last failure
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s (gave up after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Retries returns the number of retries behind an error, 0 when it was not retried
+func Retries(err error) int {
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		return retryErr.Attempts - 1
+	}
+	return 0
+}
+
+// RetryableStatus reports whether a status is a transient failure worth retrying
+func RetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// IsConnectError reports whether the connection to the server could not be established, so the request
+// was never sent
+func IsConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// Do sends the request built by newRequest until it gets an answer that is not a transient failure.
+// It returns the last response with its body read, and the number of retries made. A request that
+// still fails on the last attempt is returned as it is, a network error as a *RetryError.
+func (p RetryPolicy) Do(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, []byte, int, error) {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = RetryableStatus
+	}
+	attempts := max(p.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, nil, attempt - 1, err
+		}
+		resp, err := client.Do(req)
+		var body []byte
+		if err == nil {
+			body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+
+		var netErr net.Error
+		switch {
+		case err != nil && (!errors.As(err, &netErr) || p.RetryableError != nil && !p.RetryableError(err)):
+			return nil, nil, attempt - 1, err
+		case err == nil && !retryable(resp.StatusCode):
+			return resp, body, attempt - 1, nil
+		case attempt >= attempts:
+			if err != nil {
+				return nil, nil, attempt - 1, &RetryError{Attempts: attempt, Err: err}
+			}
+			return resp, body, attempt - 1, nil
+		}
+
+		delay := p.backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+			if retryAfter := retryAfter(resp.Header); retryAfter > 0 {
+				delay = min(retryAfter, p.MaxDelay)
+			}
+		}
+		log.Printf("🔁 %s request failed (%s), retrying in %s (attempt %d/%d)", p.Name, reason, delay.Round(time.Millisecond), attempt+1, attempts)
+		if p.sleep != nil {
+			p.sleep(delay)
+		} else {
+			time.Sleep(delay)
+		}
+	}
+}
+
+// backoff returns a random delay up to BaseDelay doubled per attempt made, capped at MaxDelay
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.MaxDelay
+	if shift := attempt - 1; shift < 30 && p.BaseDelay<<shift < ceiling {
+		ceiling = p.BaseDelay << shift
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
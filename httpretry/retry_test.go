@@ -0,0 +1,118 @@
+package httpretry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	var calls atomic.Int32
+	statuses := map[int32]int{1: http.StatusServiceUnavailable, 2: http.StatusTooManyRequests}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		call := calls.Add(1)
+		if status, ok := statuses[call]; ok {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "7")
+			}
+			w.WriteHeader(status)
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	policy := NewRetryPolicy("test")
+	policy.sleep = func(delay time.Duration) { delays = append(delays, delay) }
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest("POST", server.URL, strings.NewReader("payload"))
+	}
+
+	resp, body, retries, err := policy.Do(server.Client(), newRequest)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "payload", string(body), "the body is sent again on every attempt")
+	assert.Equal(t, 2, retries)
+	require.Len(t, delays, 2)
+	assert.LessOrEqual(t, delays[0], RETRY_BASE_DELAY, "the 503 backs off with jitter")
+	assert.Equal(t, 7*time.Second, delays[1], "Retry-After is honored")
+
+	calls.Store(0)
+	statuses = map[int32]int{1: http.StatusBadRequest}
+	resp, _, retries, err = policy.Do(server.Client(), newRequest)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, 0, retries, "client errors are not retried")
+
+	calls.Store(0)
+	statuses = map[int32]int{1: 500, 2: 502, 3: 503, 4: 504, 5: 500}
+	resp, _, retries, err = policy.Do(server.Client(), newRequest)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode, "the last failure is returned once the attempts run out")
+	assert.Equal(t, RETRY_MAX_ATTEMPTS-1, retries)
+	assert.Equal(t, int32(RETRY_MAX_ATTEMPTS), calls.Load())
+
+	server.Close()
+	_, _, retries, err = policy.Do(server.Client(), newRequest)
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr), "network errors are retried too")
+	assert.Equal(t, RETRY_MAX_ATTEMPTS, retryErr.Attempts)
+	assert.Equal(t, RETRY_MAX_ATTEMPTS-1, Retries(err))
+	assert.Equal(t, RETRY_MAX_ATTEMPTS-1, retries)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := NewRetryPolicy("test")
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := min(RETRY_BASE_DELAY<<(attempt-1), RETRY_MAX_DELAY)
+		for i := 0; i < 20; i++ {
+			delay := policy.backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling)
+		}
+	}
+	assert.LessOrEqual(t, policy.backoff(100), RETRY_MAX_DELAY, "no overflow on long runs")
+}
+
+func TestRetryPolicy_ConnectErrorsOnly(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	policy := NewRetryPolicy("test")
+	policy.RetryableError = IsConnectError
+	policy.sleep = func(time.Duration) {}
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+	newRequest := func() (*http.Request, error) {
+		return http.NewRequest("POST", server.URL, strings.NewReader("message"))
+	}
+
+	_, _, retries, err := policy.Do(client, newRequest)
+	require.Error(t, err)
+	assert.False(t, IsConnectError(err))
+	assert.Equal(t, 0, retries, "a timeout may have reached the server and is not retried")
+	assert.Equal(t, int32(1), calls.Load())
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, _, retries, err = policy.Do(client, func() (*http.Request, error) {
+		return http.NewRequest("POST", closed.URL, strings.NewReader("message"))
+	})
+	assert.True(t, IsConnectError(err))
+	assert.Equal(t, RETRY_MAX_ATTEMPTS-1, retries, "refused connections never reached the server and are retried")
+}
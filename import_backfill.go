@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	IMPORT_BACKFILL_DEFAULT_LIMIT = 500 // missing parents fetched per import at most, each costs provider quota
+	IMPORT_BACKFILL_BATCH         = 100 // tweet IDs per GetTweetsByIds request
+	IMPORT_STEP_BACKFILL          = "Fetching missing parent tweets"
+)
+
+// EnableParentBackfill makes imports fetch up to limit missing parents of orphaned replies from the
+// provider, so the replies can be linked instead of skipped
+func (c *CSVImporter) EnableParentBackfill(twitterApi TwitterAPI, limit int) {
+	c.backfillApi = twitterApi
+	c.backfillLimit = limit
+}
+
+// backfillParents fetches the parents the orphans are missing, stores them as context tweets and
+// imports the orphans that can be linked now. It returns the orphans that are still missing a parent.
+func (c *CSVImporter) backfillParents(orphans []CSVTweetData, result *ImportResult, settled *int, total int) []CSVTweetData {
+	inFile := make(map[string]bool, len(orphans))
+	for _, orphan := range orphans {
+		inFile[orphan.TweetID] = true
+	}
+	// Parents that are orphans themselves are linked once their own parent is fetched
+	var missing []string
+	seen := make(map[string]bool)
+	for _, orphan := range orphans {
+		parentID := orphan.ReplyToID
+		if seen[parentID] || inFile[parentID] || !isTrackableTweetID(parentID) {
+			continue
+		}
+		seen[parentID] = true
+		missing = append(missing, parentID)
+	}
+	if len(missing) > c.backfillLimit {
+		result.BackfillError = fmt.Sprintf("%d parents missing, fetched the first %d", len(missing), c.backfillLimit)
+		missing = missing[:c.backfillLimit]
+	}
+
+	fmt.Printf("Step 4: Fetching %d missing parent tweets...\n", len(missing))
+	c.reportProgress(IMPORT_STEP_BACKFILL, *settled, total)
+	for start := 0; start < len(missing); start += IMPORT_BACKFILL_BATCH {
+		batch := missing[start:min(start+IMPORT_BACKFILL_BATCH, len(missing))]
+		resp, err := c.backfillApi.GetTweetsByIds(batch)
+		if err != nil {
+			// Usually the rate limit, what was fetched so far is still linked
+			result.BackfillError = err.Error()
+			break
+		}
+		for _, tweet := range resp.Tweets {
+			storeTweetAndUserWithSource(c.dbService, tweet, TWEET_SOURCE_CONTEXT, "", "parent of an imported reply")
+			result.BackfilledParents++
+		}
+		c.reportProgress(IMPORT_STEP_BACKFILL, *settled, total)
+	}
+
+	resolved, orphans := c.importLinkable(orphans, IMPORT_STEP_BACKFILL, settled, total)
+	result.ResolvedOrphans += resolved
+	return orphans
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVImporter_ParentBackfill(t *testing.T) {
+	db := setupTestDB(t)
+	// 12 answers the missing 100, 13 answers 12, 14 answers 200 which the provider does not return
+	path := writeImportFile(t, "orphans.json", `[
+{"id":"12","text":"reply","inReplyToId":"100","author":{"id":"u1","userName":"alice"}},
+{"id":"13","text":"reply to reply","inReplyToId":"12","author":{"id":"u2","userName":"bob"}},
+{"id":"14","text":"lost","inReplyToId":"200","author":{"id":"u2","userName":"bob"}}]`)
+	var requested [][]string
+	twitterApi := &mockTwitterAPI{tweetsByIds: func(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error) {
+		requested = append(requested, tweetIds)
+		parent := twitterapi.Tweet{Id: "100", Text: "announcement", CreatedAt: "Mon Jan 02 10:00:00 +0000 2023"}
+		parent.Author.Id, parent.Author.UserName = "u9", "project"
+		return &twitterapi.TweetsByIdsResponse{Tweets: []twitterapi.Tweet{parent}}, nil
+	}}
+
+	importer := NewCSVImporter(db)
+	importer.EnableParentBackfill(twitterApi, 10)
+	result, err := importer.ImportFile(path, ImportOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"100", "200"}}, requested, "parents in the file are not fetched")
+	assert.Equal(t, 1, result.BackfilledParents)
+	assert.Equal(t, 2, result.ResolvedOrphans)
+	assert.Equal(t, 1, result.SkippedTweets)
+	assert.Equal(t, 2, result.TotalProcessed)
+	parent, err := db.GetTweet("100")
+	require.NoError(t, err)
+	assert.Equal(t, TWEET_SOURCE_CONTEXT, parent.SourceType)
+	assert.True(t, db.TweetExists("13"))
+
+	t.Run("Stops at the limit and on provider errors", func(t *testing.T) {
+		db := setupTestDB(t)
+		calls := 0
+		importer := NewCSVImporter(db)
+		importer.EnableParentBackfill(&mockTwitterAPI{tweetsByIds: func(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error) {
+			calls++
+			return nil, errors.New("status 429: too many requests")
+		}}, 1)
+		result, err := importer.ImportFile(path, ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 3, result.SkippedTweets)
+		assert.Contains(t, result.BackfillError, "too many requests")
+		assert.Contains(t, result.String(), "Backfill stopped")
+	})
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const IMPORT_PROGRESS_INTERVAL = 5 * time.Second // import status is edited at most this often within a step
+
+// importState allows one /import at a time, imports write to the same tables
+type importState struct {
+	mu      sync.Mutex
+	running bool
+}
+
+func (s *importState) start() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+func (s *importState) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+// parseImportArgs reads the options of /import: format:csv|twitterapi|archive, author:username[:id],
+// backfill[:N] and --dry-run
+func parseImportArgs(args []string) (ImportOptions, error) {
+	var options ImportOptions
+	for _, arg := range args {
+		if strings.EqualFold(arg, "--dry-run") || strings.EqualFold(arg, "dry-run") {
+			options.DryRun = true
+			continue
+		}
+		key, value, _ := strings.Cut(arg, ":")
+		switch strings.ToLower(key) {
+		case "format":
+			options.Format = strings.ToLower(value)
+			if !isImportFormat(options.Format) {
+				return options, fmt.Errorf("unknown format %q, use %s", value, strings.Join(importFormats, ", "))
+			}
+		case "author":
+			options.Author = value
+		case "backfill":
+			options.Backfill = IMPORT_BACKFILL_DEFAULT_LIMIT
+			if value != "" {
+				limit, err := strconv.Atoi(value)
+				if err != nil || limit <= 0 {
+					return options, fmt.Errorf("invalid backfill limit %q", value)
+				}
+				options.Backfill = limit
+			}
+		default:
+			return options, fmt.Errorf("unknown option %q", arg)
+		}
+	}
+	return options, nil
+}
+
+// handleImportCommand imports a file uploaded with the caption /import [format:...] [author:...]: a CSV
+// export, a twitterapi JSON dump or tweets.js of an X archive. The file is downloaded to a temp dir with
+// getFile and fed to the importer of its format, the status message is edited as it goes.
+func (b *BotController) handleImportCommand(chatID int64, document *TelegramDocument, args []string) {
+	if document == nil {
+		b.SendMessage(chatID, "📥 Send a CSV, twitterapi JSON or X archive tweets.js file with the caption /import to import its tweets.\n\nOptions: <code>format:csv|twitterapi|archive</code> when the file type is ambiguous, <code>author:username[:id]</code> for archives, <code>backfill[:N]</code> to fetch missing parents of replies, <code>--dry-run</code> to validate the file without importing it.")
+		return
+	}
+	options, err := parseImportArgs(args)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+	if options.Backfill > 0 && options.DryRun {
+		b.SendMessage(chatID, "❌ A dry run writes nothing, backfill cannot be combined with --dry-run.")
+		return
+	}
+	if options.Backfill > 0 && b.twitterApi == nil {
+		b.SendMessage(chatID, "❌ backfill needs the Twitter API, which is not configured.")
+		return
+	}
+	if options.Format == "" && !isImportFile(document.FileName) {
+		b.SendMessage(chatID, fmt.Sprintf("❌ %s is not a CSV, JSON or tweets.js file, pass format:csv|twitterapi|archive to import it anyway.", document.FileName))
+		return
+	}
+	if document.FileSize > TELEGRAM_DOWNLOAD_LIMIT {
+		b.SendMessage(chatID, fmt.Sprintf("❌ %s is larger than %dMB, bots cannot download it. Split the file or set %s on the server.", document.FileName, TELEGRAM_DOWNLOAD_LIMIT>>20, ENV_IMPORT_CSV_PATH))
+		return
+	}
+	if !b.imports.start() {
+		b.SendMessage(chatID, "⏳ An import is already running, try again when it has finished.")
+		return
+	}
+	defer b.imports.finish()
+
+	messageID, err := b.SendMessageWithID(chatID, fmt.Sprintf("📥 <b>Import</b>\n\n📄 %s\n⬇️ Downloading...", document.FileName))
+	if err != nil {
+		log.Printf("Failed to send import status to chat %d: %v", chatID, err)
+	}
+	status := func(text string) {
+		text = fmt.Sprintf("📥 <b>Import</b>\n\n📄 %s\n%s", document.FileName, text)
+		if messageID == 0 {
+			b.SendMessage(chatID, text)
+			return
+		}
+		if err := b.progress.editNow(b, chatID, messageID, text); err != nil {
+			log.Printf("Failed to update import status in chat %d: %v", chatID, err)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "import-*")
+	if err != nil {
+		status(fmt.Sprintf("❌ Error creating temp dir: %v", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, filepath.Base(document.FileName))
+	if err := b.transport.DownloadFile(document.FileID, path); err != nil {
+		status(fmt.Sprintf("❌ Download failed: %v", err))
+		return
+	}
+
+	if options.DryRun {
+		status("🧪 Validating...")
+		validation, err := NewCSVImporter(b.dbService).ValidateFile(path, options)
+		if err != nil {
+			status(fmt.Sprintf("❌ Validation failed: %v", err))
+			return
+		}
+		log.Printf("🧪 Validated %s for chat %d: %d tweets, %d row errors", document.FileName, chatID, validation.Rows, len(validation.RowErrors))
+		status(formatImportValidation(validation))
+		return
+	}
+
+	status("⏳ Importing...")
+	log.Printf("📥 Importing %s uploaded to chat %d", document.FileName, chatID)
+	importer := NewCSVImporter(b.dbService)
+	if options.Backfill > 0 {
+		importer.EnableParentBackfill(b.twitterApi, options.Backfill)
+	}
+	if messageID != 0 {
+		lastStep := ""
+		var lastEdit time.Time
+		importer.OnProgress(func(progress ImportProgress) {
+			if progress.Step == lastStep && time.Since(lastEdit) < IMPORT_PROGRESS_INTERVAL {
+				return
+			}
+			lastStep, lastEdit = progress.Step, time.Now()
+			b.progress.queue(b, chatID, messageID, fmt.Sprintf("📥 <b>Import</b>\n\n📄 %s\n%s", document.FileName, formatImportProgress(progress)))
+		})
+	}
+	result, err := importer.ImportFile(path, options)
+	if err != nil {
+		status(fmt.Sprintf("❌ Import failed: %v", err))
+		return
+	}
+	log.Printf("📥 Imported %s: %s", document.FileName, result.String())
+	status(fmt.Sprintf("✅ Import finished\n\n🧵 Original tweets: %d\n💬 Replies: %d\n🔗 Replies imported later: %d\n⏭ Skipped, parent missing: %d\n📊 Total imported: %d%s",
+		result.OriginalTweets, result.ReplyTweets, result.RemainingTweets, result.SkippedTweets, result.TotalProcessed, formatImportBackfill(result, options)))
+}
+
+// formatImportProgress shows the step, settled rows and the time left of a running import
+func formatImportProgress(progress ImportProgress) string {
+	text := fmt.Sprintf("⏳ %s...\n📊 %d/%d rows (%.0f%%)\n⏱ Elapsed: %s", progress.Step, progress.Processed, progress.Total, progress.Percent(), progress.Elapsed.Round(time.Second))
+	if eta := progress.ETA(); eta > 0 {
+		text += fmt.Sprintf(", ETA: %s", eta)
+	}
+	return text
+}
+
+// formatImportBackfill reports the parents fetched for orphaned replies when the backfill ran
+func formatImportBackfill(result *ImportResult, options ImportOptions) string {
+	if options.Backfill == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("\n\n🪝 Parents fetched: %d\n🧩 Orphans resolved: %d", result.BackfilledParents, result.ResolvedOrphans)
+	if result.BackfillError != "" {
+		text += fmt.Sprintf("\n⚠️ Backfill stopped: %s", html.EscapeString(result.BackfillError))
+	}
+	return text
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCommand_UploadedCSV(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	content, err := os.ReadFile(writeBenchmarkCSV(t, t.TempDir(), "upload", 2, 3))
+	require.NoError(t, err)
+	transport := &fakeTelegramTransport{files: map[string]string{"file1": string(content)}}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+	bot.chatIDs[2] = true
+
+	update := newTestUpdate(1, "")
+	update.Message.Caption = "/import"
+	update.Message.Document = &TelegramDocument{FileID: "file1", FileName: "upload.csv", FileSize: int64(len(content))}
+	assert.Equal(t, "/import", commandText(update))
+	bot.handleImportCommand(1, update.Message.Document, nil)
+
+	require.NotEmpty(t, transport.edited)
+	final := transport.edited[len(transport.edited)-1].Text
+	assert.Contains(t, final, "Import finished")
+	assert.Contains(t, final, "Total imported: 8")
+	assert.True(t, db.TweetExists("upload_reply_1_2"))
+
+	t.Run("Dry run", func(t *testing.T) {
+		transport.files["file2"] = "tweet_id,author_id,author_username,date,message_text\nnew1,u1,alice,2023-01-02,gm\n"
+		bot.handleImportCommand(1, &TelegramDocument{FileID: "file2", FileName: "check.csv"}, []string{"--dry-run"})
+		report := transport.edited[len(transport.edited)-1].Text
+		assert.Contains(t, report, "Dry run, nothing was written")
+		assert.Contains(t, report, "Would import: 1 originals, 0 replies")
+		assert.False(t, db.TweetExists("new1"))
+	})
+
+	t.Run("Rejects other files", func(t *testing.T) {
+		bot.handleImportCommand(1, &TelegramDocument{FileID: "file1", FileName: "upload.txt"}, nil)
+		sent := transport.sentMessages()
+		assert.Contains(t, sent[len(sent)-1].Text, "not a CSV, JSON or tweets.js file")
+	})
+
+	t.Run("Reports download failures", func(t *testing.T) {
+		bot.handleImportCommand(1, &TelegramDocument{FileID: "missing", FileName: "other.csv"}, nil)
+		assert.Contains(t, transport.edited[len(transport.edited)-1].Text, "Download failed")
+	})
+
+	t.Run("Admin only", func(t *testing.T) {
+		other := newTestUpdate(2, "")
+		other.Message.Caption = "/import"
+		other.Message.Document = update.Message.Document
+		bot.routeCommand(other, commandText(other), false)
+		assert.Eventually(t, func() bool {
+			sent := transport.sentMessages()
+			return sent[len(sent)-1].Text == "❌ Access denied. This command is restricted to administrators only."
+		}, time.Second, 10*time.Millisecond)
+	})
+}
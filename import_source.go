@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	IMPORT_FETCH_TIMEOUT = 30 * time.Second
+	IMPORT_MAX_SIZE      = 10 << 20
+)
+
+// importNameRegex checks the names blocklists and eval datasets are imported under
+var importNameRegex = regexp.MustCompile(`^[\w.-]{1,40}$`)
+
+// fetchImport reads a blocklist or dataset from an http(s) URL or a local file
+func fetchImport(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+		return os.ReadFile(location)
+	}
+	client := &http.Client{Timeout: IMPORT_FETCH_TIMEOUT}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", location, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, IMPORT_MAX_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > IMPORT_MAX_SIZE {
+		return nil, fmt.Errorf("%s is larger than %d MB", location, IMPORT_MAX_SIZE>>20)
+	}
+	return data, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const IMPORT_VALIDATION_SHOWN_ERRORS = 10 // row errors listed in the dry-run report, the rest are counted
+
+// ImportValidation is what an import would do with a file, worked out without writing to the database
+type ImportValidation struct {
+	Format           string
+	Rows             int      // tweets parsed from the file
+	RowErrors        []string // rows that cannot be imported or lose data, e.g. an unparsable date
+	DuplicatesInFile int      // tweets listed more than once
+	AlreadyStored    int      // tweets the database already has
+	NewOriginals     int
+	NewReplies       int // replies whose parent is stored or imported from the file
+	MissingParents   int // replies skipped because their parent is neither stored nor in the file
+	MissingParentIDs int // distinct parents missing, what a backfill would fetch
+}
+
+// ValidateFile parses a whole file and reports row errors, duplicates and missing parent links the
+// import would run into. Nothing is written.
+func (c *CSVImporter) ValidateFile(path string, options ImportOptions) (*ImportValidation, error) {
+	format, tweetsData, rowErrors, err := c.parseFile(path, options)
+	if err != nil {
+		return nil, err
+	}
+	validation := &ImportValidation{Format: format, Rows: len(tweetsData), RowErrors: rowErrors}
+
+	byID := make(map[string]CSVTweetData, len(tweetsData))
+	var unique []CSVTweetData
+	for _, tweetData := range tweetsData {
+		if tweetData.TweetID == "" || tweetData.AuthorID == "" {
+			validation.RowErrors = append(validation.RowErrors, fmt.Sprintf("tweet %q by %q: missing tweet or author ID", tweetData.TweetID, tweetData.AuthorUsername))
+			continue
+		}
+		if _, err := c.parseDate(tweetData.Date); err != nil {
+			validation.RowErrors = append(validation.RowErrors, fmt.Sprintf("tweet %s: unparsable date %q, the import time would be used", tweetData.TweetID, tweetData.Date))
+		}
+		if _, ok := byID[tweetData.TweetID]; ok {
+			validation.DuplicatesInFile++
+			continue
+		}
+		byID[tweetData.TweetID] = tweetData
+		unique = append(unique, tweetData)
+	}
+
+	// A reply is linked when its parent is stored, or is in the file and linked itself
+	linked := make(map[string]bool)
+	var isLinked func(tweetID string, depth int) bool
+	isLinked = func(tweetID string, depth int) bool {
+		if done, ok := linked[tweetID]; ok {
+			return done
+		}
+		tweetData, inFile := byID[tweetID]
+		switch {
+		case !inFile:
+			linked[tweetID] = c.dbService.TweetExists(tweetID)
+		case tweetData.ReplyToID == "":
+			linked[tweetID] = true
+		case depth > len(byID):
+			// A cycle of replies never links
+			linked[tweetID] = false
+		default:
+			linked[tweetID] = isLinked(tweetData.ReplyToID, depth+1)
+		}
+		return linked[tweetID]
+	}
+
+	missing := make(map[string]bool)
+	for _, tweetData := range unique {
+		switch {
+		case c.dbService.TweetExists(tweetData.TweetID):
+			validation.AlreadyStored++
+		case tweetData.ReplyToID == "":
+			validation.NewOriginals++
+		case isLinked(tweetData.ReplyToID, 0):
+			validation.NewReplies++
+		default:
+			validation.MissingParents++
+			if _, inFile := byID[tweetData.ReplyToID]; !inFile {
+				missing[tweetData.ReplyToID] = true
+			}
+		}
+	}
+	validation.MissingParentIDs = len(missing)
+	return validation, nil
+}
+
+// formatImportValidation is the dry-run report of /import
+func formatImportValidation(validation *ImportValidation) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🧪 Dry run, nothing was written\n\n📋 Format: %s\n📄 Tweets parsed: %d\n", validation.Format, validation.Rows))
+	text.WriteString(fmt.Sprintf("🆕 Would import: %d originals, %d replies\n", validation.NewOriginals, validation.NewReplies))
+	text.WriteString(fmt.Sprintf("♻️ Already stored: %d\n👯 Duplicates in file: %d\n", validation.AlreadyStored, validation.DuplicatesInFile))
+	text.WriteString(fmt.Sprintf("⏭ Skipped, parent missing: %d (%d distinct parents, import with backfill to fetch them)\n", validation.MissingParents, validation.MissingParentIDs))
+	if len(validation.RowErrors) == 0 {
+		text.WriteString("✅ No row errors")
+		return text.String()
+	}
+	text.WriteString(fmt.Sprintf("\n⚠️ <b>Row errors: %d</b>\n", len(validation.RowErrors)))
+	for i, rowError := range validation.RowErrors {
+		if i == IMPORT_VALIDATION_SHOWN_ERRORS {
+			text.WriteString(fmt.Sprintf("... and %d more\n", len(validation.RowErrors)-IMPORT_VALIDATION_SHOWN_ERRORS))
+			break
+		}
+		text.WriteString("• " + html.EscapeString(rowError) + "\n")
+	}
+	return text.String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVImporter_ValidateFile(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "1", UserID: "u1", Text: "stored post"}))
+	path := writeImportFile(t, "validate.csv", `tweet_id,author_id,author_username,date,reply_to_tweet,message_text
+1,u1,alice,2023-01-02,,stored post
+2,u2,bob,2023-01-02,1,reply to stored
+3,u3,carol,yesterday,,new post
+4,u2,bob,2023-01-02,3,reply to new post
+4,u2,bob,2023-01-02,3,duplicate row
+5,u2,bob,2023-01-02,6,reply to an orphan
+6,u3,carol,2023-01-02,404,orphan
+7,u3,carol
+`)
+
+	validation, err := NewCSVImporter(db).ValidateFile(path, ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, IMPORT_FORMAT_CSV, validation.Format)
+	assert.Equal(t, 7, validation.Rows)
+	assert.Equal(t, 1, validation.AlreadyStored)
+	assert.Equal(t, 1, validation.DuplicatesInFile)
+	assert.Equal(t, 1, validation.NewOriginals)
+	assert.Equal(t, 2, validation.NewReplies)
+	assert.Equal(t, 2, validation.MissingParents, "a reply to an orphan is skipped too")
+	assert.Equal(t, 1, validation.MissingParentIDs)
+	require.Len(t, validation.RowErrors, 2)
+	assert.Contains(t, validation.RowErrors[0], "row 9: 3 columns")
+	assert.Contains(t, validation.RowErrors[1], `unparsable date "yesterday"`)
+
+	count, err := db.GetTweetCount()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "a dry run writes nothing")
+
+	report := formatImportValidation(validation)
+	assert.Contains(t, report, "Would import: 1 originals, 2 replies")
+	assert.Contains(t, report, "Row errors: 2")
+
+	// The import skips what the dry run predicted
+	result, err := NewCSVImporter(db).ImportFile(path, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, validation.NewOriginals+validation.NewReplies, result.TotalProcessed)
+	assert.Equal(t, validation.MissingParents, result.SkippedTweets)
+}
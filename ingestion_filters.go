@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// Ingestion filter settings, stored by name and changed at runtime with /filters
+const (
+	FILTER_MIN_ACCOUNT_AGE_DAYS = "min_account_age_days" // 0 disables
+	FILTER_EXCLUDE_RETWEETS     = "exclude_retweets"     // "true" or "false"
+	FILTER_LANGUAGES            = "languages"            // comma separated allow-list, empty allows all
+	FILTER_MUTED                = "muted"                // comma separated usernames of known bots
+)
+
+// Why a tweet was filtered, counted in /filters
+const (
+	FILTER_REASON_MUTED       = "muted account"
+	FILTER_REASON_RETWEET     = "retweet"
+	FILTER_REASON_LANGUAGE    = "language"
+	FILTER_REASON_ACCOUNT_AGE = "new account"
+)
+
+const INGESTION_FILTERS_RELOAD = 30 * time.Second
+
+// undeterminedLanguages pass the language allow-list, X uses them for links, emoji and short replies
+var undeterminedLanguages = map[string]bool{"": true, "und": true, "zxx": true, "qme": true, "qst": true}
+
+// ingestionFilters decide which community tweets are stored and analyzed. The zero value lets everything through.
+type ingestionFilters struct {
+	MinAccountAge   time.Duration
+	ExcludeRetweets bool
+	Languages       map[string]bool
+	Muted           map[string]bool // lower case without @
+}
+
+// ingestionFilterCache keeps the filters of every monitor in memory, reloaded periodically and after /filters
+var ingestionFilterCache struct {
+	mu       sync.Mutex
+	filters  ingestionFilters
+	loadedAt time.Time
+	filtered map[string]int // tweets filtered since the start by reason
+}
+
+// currentIngestionFilters returns the cached filters, reloading them when they are stale
+func currentIngestionFilters(dbService *DatabaseService) ingestionFilters {
+	ingestionFilterCache.mu.Lock()
+	defer ingestionFilterCache.mu.Unlock()
+	if time.Since(ingestionFilterCache.loadedAt) < INGESTION_FILTERS_RELOAD {
+		return ingestionFilterCache.filters
+	}
+	settings, err := dbService.GetIngestionFilterSettings()
+	if err != nil {
+		log.Printf("Failed to load ingestion filters, keeping the previous ones: %v", err)
+		return ingestionFilterCache.filters
+	}
+	ingestionFilterCache.filters = parseIngestionFilters(settings)
+	ingestionFilterCache.loadedAt = time.Now()
+	return ingestionFilterCache.filters
+}
+
+// reloadIngestionFilters makes the next tweet read the filters from the database
+func reloadIngestionFilters() {
+	ingestionFilterCache.mu.Lock()
+	defer ingestionFilterCache.mu.Unlock()
+	ingestionFilterCache.loadedAt = time.Time{}
+}
+
+func countFilteredTweet(reason string) {
+	ingestionFilterCache.mu.Lock()
+	defer ingestionFilterCache.mu.Unlock()
+	if ingestionFilterCache.filtered == nil {
+		ingestionFilterCache.filtered = make(map[string]int)
+	}
+	ingestionFilterCache.filtered[reason]++
+}
+
+func filteredTweetCounts() map[string]int {
+	ingestionFilterCache.mu.Lock()
+	defer ingestionFilterCache.mu.Unlock()
+	counts := make(map[string]int, len(ingestionFilterCache.filtered))
+	for reason, count := range ingestionFilterCache.filtered {
+		counts[reason] = count
+	}
+	return counts
+}
+
+// parseIngestionFilters reads the stored settings, unknown or invalid values are ignored
+func parseIngestionFilters(settings map[string]string) ingestionFilters {
+	filters := ingestionFilters{Languages: make(map[string]bool), Muted: make(map[string]bool)}
+	if days, err := strconv.Atoi(settings[FILTER_MIN_ACCOUNT_AGE_DAYS]); err == nil && days > 0 {
+		filters.MinAccountAge = time.Duration(days) * 24 * time.Hour
+	}
+	filters.ExcludeRetweets = settings[FILTER_EXCLUDE_RETWEETS] == "true"
+	for _, lang := range splitFilterList(settings[FILTER_LANGUAGES]) {
+		filters.Languages[strings.ToLower(lang)] = true
+	}
+	for _, username := range splitFilterList(settings[FILTER_MUTED]) {
+		filters.Muted[strings.ToLower(strings.TrimPrefix(username, "@"))] = true
+	}
+	return filters
+}
+
+func splitFilterList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// reject tells why a tweet is filtered, empty when it passes. Unknown account ages pass.
+func (f ingestionFilters) reject(tweet twitterapi.Tweet, now time.Time) string {
+	if f.Muted[strings.ToLower(tweet.Author.UserName)] {
+		return FILTER_REASON_MUTED
+	}
+	if f.ExcludeRetweets && (tweet.RetweetedTweet != nil || strings.HasPrefix(tweet.Text, "RT @")) {
+		return FILTER_REASON_RETWEET
+	}
+	if len(f.Languages) > 0 && !undeterminedLanguages[tweet.Lang] && !f.Languages[strings.ToLower(tweet.Lang)] {
+		return FILTER_REASON_LANGUAGE
+	}
+	if f.MinAccountAge > 0 {
+		if createdAt, err := parseTweetTime(tweet.Author.CreatedAt); err == nil && now.Sub(createdAt) < f.MinAccountAge {
+			return FILTER_REASON_ACCOUNT_AGE
+		}
+	}
+	return ""
+}
+
+// ingestTweet tells whether a community tweet is stored and analyzed, counting the ones filtered out
+func ingestTweet(dbService *DatabaseService, tweet twitterapi.Tweet, known bool) bool {
+	if dbService == nil {
+		return true
+	}
+	reason := currentIngestionFilters(dbService).reject(tweet, time.Now())
+	if reason == "" {
+		return true
+	}
+	// Known tweets come back on every poll and were counted the first time
+	if !known {
+		countFilteredTweet(reason)
+		logFor("ingestion").Debug("tweet filtered", "tweet_id", tweet.Id, "author", tweet.Author.UserName, "reason", reason)
+	}
+	return false
+}
+
+// handleFiltersCommand shows or changes the ingestion filters:
+// /filters [min_age days|off] [retweets exclude|include] [lang en,es|all] [mute add|remove user1,user2]
+func (b *BotController) handleFiltersCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 {
+		b.sendIngestionFilters(chatID)
+		return
+	}
+
+	usage := "❌ Usage: /filters min_age 30|off, /filters retweets exclude|include, /filters lang en,es|all, /filters mute add|remove user1,user2"
+	settings, err := b.dbService.GetIngestionFilterSettings()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading ingestion filters: %v", err))
+		return
+	}
+
+	var name, value string
+	switch strings.ToLower(args[0]) {
+	case "min_age":
+		if len(args) != 2 {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		name, value = FILTER_MIN_ACCOUNT_AGE_DAYS, "0"
+		if strings.ToLower(args[1]) != "off" {
+			days, err := strconv.Atoi(strings.TrimSuffix(args[1], "d"))
+			if err != nil || days < 0 {
+				b.SendMessage(chatID, "❌ The minimum account age is a number of days, or off")
+				return
+			}
+			value = strconv.Itoa(days)
+		}
+	case "retweets":
+		if len(args) != 2 || (args[1] != "exclude" && args[1] != "include") {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		name, value = FILTER_EXCLUDE_RETWEETS, strconv.FormatBool(args[1] == "exclude")
+	case "lang":
+		if len(args) != 2 {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		name = FILTER_LANGUAGES
+		if strings.ToLower(args[1]) != "all" {
+			value = strings.ToLower(strings.Join(splitFilterList(args[1]), ","))
+		}
+	case "mute":
+		if len(args) != 3 || (args[1] != "add" && args[1] != "remove") {
+			b.SendMessage(chatID, usage)
+			return
+		}
+		muted := parseIngestionFilters(settings).Muted
+		for _, username := range splitFilterList(args[2]) {
+			username = strings.ToLower(strings.TrimPrefix(username, "@"))
+			if args[1] == "add" {
+				muted[username] = true
+			} else {
+				delete(muted, username)
+			}
+		}
+		usernames := make([]string, 0, len(muted))
+		for username := range muted {
+			usernames = append(usernames, username)
+		}
+		sort.Strings(usernames)
+		name, value = FILTER_MUTED, strings.Join(usernames, ",")
+	default:
+		b.SendMessage(chatID, usage)
+		return
+	}
+
+	err = b.dbService.SaveIngestionFilterSetting(IngestionFilterModel{Name: name, Value: value, UpdatedBy: actor})
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving ingestion filters: %v", err))
+		return
+	}
+	b.dbService.RecordConfigChange("ingestion_filter:"+name, actor, settings[name], value)
+	reloadIngestionFilters()
+	log.Printf("🧹 Ingestion filter %s set to %q by %s", name, value, actor)
+	b.sendIngestionFilters(chatID)
+}
+
+// sendIngestionFilters shows the filters in effect and how many tweets they dropped since the start
+func (b *BotController) sendIngestionFilters(chatID int64) {
+	settings, err := b.dbService.GetIngestionFilterSettings()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading ingestion filters: %v", err))
+		return
+	}
+	filters := parseIngestionFilters(settings)
+
+	var message strings.Builder
+	message.WriteString("🧹 <b>Ingestion filters</b>\n\n")
+	if filters.MinAccountAge > 0 {
+		message.WriteString(fmt.Sprintf("• Minimum account age: %d days\n", int(filters.MinAccountAge.Hours()/24)))
+	} else {
+		message.WriteString("• Minimum account age: off\n")
+	}
+	if filters.ExcludeRetweets {
+		message.WriteString("• Retweets: excluded\n")
+	} else {
+		message.WriteString("• Retweets: included\n")
+	}
+	if len(filters.Languages) > 0 {
+		message.WriteString(fmt.Sprintf("• Languages: %s\n", html.EscapeString(settings[FILTER_LANGUAGES])))
+	} else {
+		message.WriteString("• Languages: all\n")
+	}
+	if len(filters.Muted) > 0 {
+		message.WriteString(fmt.Sprintf("• Muted accounts (%d): %s\n", len(filters.Muted), html.EscapeString(settings[FILTER_MUTED])))
+	} else {
+		message.WriteString("• Muted accounts: none\n")
+	}
+
+	counts := filteredTweetCounts()
+	if len(counts) > 0 {
+		reasons := make([]string, 0, len(counts))
+		for reason := range counts {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		message.WriteString("\n📉 <b>Filtered since the start:</b>")
+		for _, reason := range reasons {
+			message.WriteString(fmt.Sprintf(" %s %d ·", reason, counts[reason]))
+		}
+		message.WriteString("\n")
+	}
+	message.WriteString("\nFiltered tweets are neither stored nor analyzed. Change with /filters min_age|retweets|lang|mute")
+	b.SendMessage(chatID, strings.TrimSuffix(message.String(), " ·"))
+}
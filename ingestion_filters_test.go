@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestionFilters_Reject(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	filters := parseIngestionFilters(map[string]string{
+		FILTER_MIN_ACCOUNT_AGE_DAYS: "30",
+		FILTER_EXCLUDE_RETWEETS:     "true",
+		FILTER_LANGUAGES:            "en, ES",
+		FILTER_MUTED:                "@SpamBot,other_bot",
+	})
+	tweet := func(username, text, lang, createdAt string) twitterapi.Tweet {
+		return twitterapi.Tweet{Id: "1", Text: text, Lang: lang, Author: twitterapi.Author{UserName: username, CreatedAt: createdAt}}
+	}
+	old := "Mon Jan 01 00:00:00 +0000 2024"
+
+	assert.Equal(t, "", filters.reject(tweet("holder", "gm", "en", old), now))
+	assert.Equal(t, "", filters.reject(tweet("holder", "🚀🚀", "und", old), now))
+	assert.Equal(t, "", filters.reject(tweet("holder", "hola", "es", "not a date"), now), "unknown account ages pass")
+	assert.Equal(t, FILTER_REASON_MUTED, filters.reject(tweet("spambot", "gm", "en", old), now))
+	assert.Equal(t, FILTER_REASON_RETWEET, filters.reject(tweet("holder", "RT @dev: soon", "en", old), now))
+	retweet := tweet("holder", "soon", "en", old)
+	retweet.RetweetedTweet = &twitterapi.Tweet{Id: "2"}
+	assert.Equal(t, FILTER_REASON_RETWEET, filters.reject(retweet, now))
+	assert.Equal(t, FILTER_REASON_LANGUAGE, filters.reject(tweet("holder", "привет", "ru", old), now))
+	assert.Equal(t, FILTER_REASON_ACCOUNT_AGE, filters.reject(tweet("holder", "gm", "en", "Sun Feb 15 00:00:00 +0000 2026"), now))
+
+	assert.Equal(t, "", ingestionFilters{}.reject(retweet, now), "no filters let everything through")
+}
+
+func TestIngestionFilters_Monitoring(t *testing.T) {
+	db := setupTestDB(t)
+	t.Cleanup(reloadIngestionFilters)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+
+	bot.handleFiltersCommand(1, "@mod", []string{"mute", "add", "@SpamBot,noisy"})
+	bot.handleFiltersCommand(1, "@mod", []string{"mute", "remove", "noisy"})
+	bot.handleFiltersCommand(1, "@mod", []string{"retweets", "exclude"})
+	settings, err := db.GetIngestionFilterSettings()
+	require.NoError(t, err)
+	assert.Equal(t, "spambot", settings[FILTER_MUTED])
+	assert.Equal(t, "true", settings[FILTER_EXCLUDE_RETWEETS])
+
+	events, err := db.GetEventsAfter(0, EVENT_CONFIG_CHANGED, EVENTS_API_MAX)
+	require.NoError(t, err)
+	var changes int
+	for _, event := range events {
+		if event.Actor == "@mod" && strings.HasPrefix(event.Subject, "ingestion_filter:") {
+			changes++
+		}
+	}
+	assert.Equal(t, 3, changes)
+
+	post := twitterapi.Tweet{Id: "filtered_post", Text: "RT @dev: soon", ReplyCount: 2, Author: twitterapi.Author{Id: "u1", UserName: "holder"}}
+	twitterApi := &mockTwitterAPI{tweetReplies: func(req twitterapi.TweetRepliesRequest) (*twitterapi.TweetRepliesResponse, error) {
+		return &twitterapi.TweetRepliesResponse{Tweets: []twitterapi.Tweet{
+			{Id: "muted_reply", InReplyToId: "filtered_post", Text: "buy now", Author: twitterapi.Author{Id: "u2", UserName: "SpamBot"}},
+			{Id: "kept_reply", InReplyToId: "filtered_post", Text: "this is a rug", Author: twitterapi.Author{Id: "u3", UserName: "critic"}},
+		}}, nil
+	}}
+	newMessageCh := make(chan twitterapi.NewMessage, 10)
+	storage := map[string]int{}
+	processCommunityTweet(twitterApi, db, CommunityModel{ID: "c1", Ticker: "$GRUT"}, post, newMessageCh, storage)
+
+	require.Len(t, newMessageCh, 1)
+	assert.Equal(t, "kept_reply", (<-newMessageCh).TweetID)
+	_, err = db.GetTweet("filtered_post")
+	assert.Error(t, err, "filtered posts are not stored")
+	_, err = db.GetTweet("muted_reply")
+	assert.Error(t, err)
+	_, err = db.GetTweet("kept_reply")
+	assert.NoError(t, err)
+	assert.Contains(t, storage, "muted_reply", "filtered replies are not fetched again")
+
+	counts := filteredTweetCounts()
+	assert.GreaterOrEqual(t, counts[FILTER_REASON_RETWEET], 1)
+	assert.GreaterOrEqual(t, counts[FILTER_REASON_MUTED], 1)
+}
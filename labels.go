@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	LABEL_CLEAN            = "clean"
+	LABEL_FUD              = "fud"
+	LABEL_DEFAULT_FUD_TYPE = "human_verdict"
+	LABEL_MESSAGES_SAMPLE  = 20 // latest stored messages kept with a labeled example
+)
+
+var fudTypeRegex = regexp.MustCompile(`^[a-z_]{1,64}$`)
+
+// labeledMessage is a stored message kept with a labeled example
+type labeledMessage struct {
+	TweetID   string `json:"tweet_id"`
+	CreatedAt string `json:"created_at"`
+	Text      string `json:"text"`
+}
+
+// handleMarkCommand records a human verdict on a user and makes it the user's current analysis:
+// /mark_clean username [note: why], /mark_fud username [fud_type] [note: why]
+func (b *BotController) handleMarkCommand(chatID int64, actor string, label string, args []string) {
+	usage := "❌ Usage: /mark_clean username [note: why] or /mark_fud username [fud_type] [note: why]"
+	rest, note := splitNotifyNote(args)
+	if len(rest) == 0 || (label == LABEL_CLEAN && len(rest) > 1) || len(rest) > 2 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	fudType := ""
+	if len(rest) == 2 {
+		fudType = strings.ToLower(rest[1])
+		if !fudTypeRegex.MatchString(fudType) {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Invalid FUD type: %s", html.EscapeString(rest[1])))
+			return
+		}
+	}
+
+	username, _ := b.resolveTwitterReference(rest[0])
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ User @%s not found in database", html.EscapeString(username)))
+		return
+	}
+
+	verdict, kept, err := b.recordLabeledVerdict(user, label, fudType, note, actor, chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving verdict: %v", err))
+		return
+	}
+	b.SendMessage(chatID, b.formatLabeledVerdict(verdict, kept))
+}
+
+// recordLabeledVerdict stores a human verdict with what the model had concluded and the user's latest
+// messages, then makes it the user's current analysis. Returns the verdict and how many messages it kept.
+func (b *BotController) recordLabeledVerdict(user *UserModel, label string, fudType string, note string, actor string, chatID int64) (*LabeledVerdictModel, int, error) {
+	verdict := &LabeledVerdictModel{
+		UserID:          user.ID,
+		Username:        user.Username,
+		Label:           label,
+		Note:            note,
+		LabeledBy:       actor,
+		LabeledFromChat: chatID,
+	}
+	previous, err := b.dbService.GetCachedAnalysis(user.ID)
+	if err == nil {
+		verdict.ModelAnalyzed = true
+		verdict.ModelIsFUD = previous.IsFUDUser
+		verdict.ModelFUDType = previous.FUDType
+		verdict.ModelProbability = previous.FUDProbability
+		verdict.ModelReason = previous.DecisionReason
+	}
+	// Without a type a FUD verdict keeps the one the analysis found
+	if label == LABEL_FUD {
+		verdict.FUDType = fudType
+		if verdict.FUDType == "" && verdict.ModelIsFUD && verdict.ModelFUDType != "" {
+			verdict.FUDType = verdict.ModelFUDType
+		}
+		if verdict.FUDType == "" {
+			verdict.FUDType = LABEL_DEFAULT_FUD_TYPE
+		}
+	}
+	tweets, err := b.dbService.GetUserMessagesWithContext(user.ID, LABEL_MESSAGES_SAMPLE)
+	if err != nil {
+		log.Printf("Failed to load messages of %s for labeled example: %v", user.Username, err)
+	}
+	messages := make([]labeledMessage, 0, len(tweets))
+	for _, tweet := range tweets {
+		messages = append(messages, labeledMessage{TweetID: tweet.ID, CreatedAt: tweet.CreatedAt.UTC().Format(time.RFC3339), Text: tweet.Text})
+	}
+	data, _ := json.Marshal(messages)
+	verdict.Messages = string(data)
+
+	err = b.dbService.SaveLabeledVerdict(verdict)
+	if err != nil {
+		return nil, 0, err
+	}
+	b.applyLabeledVerdict(user, verdict)
+	log.Printf("🏷 %s marked @%s as %s", actor, user.Username, label)
+	return verdict, len(messages), nil
+}
+
+// formatLabeledVerdict confirms a recorded verdict and how it compares with the analysis
+func (b *BotController) formatLabeledVerdict(verdict *LabeledVerdictModel, kept int) string {
+	var message strings.Builder
+	if verdict.Label == LABEL_FUD {
+		message.WriteString(fmt.Sprintf("🚨 @%s marked as FUD (%s)\n", verdict.Username, html.EscapeString(verdict.FUDType)))
+	} else {
+		message.WriteString(fmt.Sprintf("✅ @%s marked as clean\n", verdict.Username))
+	}
+	switch {
+	case !verdict.ModelAnalyzed:
+		message.WriteString("🤖 No analysis to compare with\n")
+	case verdict.ModelIsFUD == (verdict.Label == LABEL_FUD):
+		message.WriteString(fmt.Sprintf("🤖 Confirms the analysis: %s\n", modelVerdictText(verdict)))
+	default:
+		message.WriteString(fmt.Sprintf("🤖 Corrects the analysis: %s\n", modelVerdictText(verdict)))
+	}
+	message.WriteString(fmt.Sprintf("💾 Cached analysis overridden, %d messages kept as a labeled example", kept))
+	if counts, err := b.dbService.CountLabeledVerdicts(); err == nil {
+		message.WriteString(fmt.Sprintf("\n📚 Labeled examples: %d FUD, %d clean", counts[LABEL_FUD], counts[LABEL_CLEAN]))
+	}
+	return message.String()
+}
+
+// applyLabeledVerdict replaces the cached analysis with the human verdict and updates the FUD list
+func (b *BotController) applyLabeledVerdict(user *UserModel, verdict *LabeledVerdictModel) {
+	isFUD := verdict.Label == LABEL_FUD
+	reason := fmt.Sprintf("Marked %s by %s", verdict.Label, verdict.LabeledBy)
+	if verdict.Note != "" {
+		reason += ": " + verdict.Note
+	}
+	analysis := SecondStepClaudeResponse{
+		IsFUDUser:      isFUD,
+		FUDType:        verdict.FUDType,
+		UserRiskLevel:  "low",
+		DecisionReason: reason,
+		UserSummary:    "Human verdict: " + verdict.Label,
+	}
+	if isFUD {
+		analysis.FUDProbability = 1
+		analysis.UserRiskLevel = "high"
+	} else {
+		analysis.FUDType = "none"
+	}
+	err := b.dbService.SaveCachedAnalysis(user.ID, user.Username, analysis, "")
+	if err != nil {
+		log.Printf("Failed to override cached analysis of %s: %v", user.Username, err)
+	}
+
+	if isFUD {
+		// An account already on the FUD list keeps its detection history
+		fudUser, getErr := b.dbService.GetFUDUser(user.ID)
+		if getErr != nil {
+			fudUser = &FUDUserModel{UserID: user.ID, Username: user.Username, DetectedAt: time.Now(), MessageCount: 1}
+		}
+		fudUser.FUDType = verdict.FUDType
+		fudUser.FUDProbability = 1
+		err = b.dbService.SaveFUDUser(*fudUser)
+	} else if b.dbService.IsFUDUser(user.ID) {
+		err = b.dbService.DeleteFUDUser(user.ID)
+	}
+	if err != nil {
+		log.Printf("Failed to update FUD list for %s: %v", user.Username, err)
+	}
+	err = b.dbService.UpdateUserFUDStatus(user.ID, isFUD, verdict.FUDType)
+	if err != nil {
+		log.Printf("Failed to update FUD status for %s: %v", user.Username, err)
+	}
+}
+
+func modelVerdictText(verdict *LabeledVerdictModel) string {
+	if !verdict.ModelIsFUD {
+		return fmt.Sprintf("clean (%.0f%%)", verdict.ModelProbability*100)
+	}
+	return fmt.Sprintf("FUD, %s (%.0f%%)", html.EscapeString(verdict.ModelFUDType), verdict.ModelProbability*100)
+}
+
+// prepareLabeledVerdictMessage tells the second step how a moderator judged the user before
+func prepareLabeledVerdictMessage(verdict *LabeledVerdictModel) ClaudeMessage {
+	content := fmt.Sprintf("HUMAN VERDICT: on %s a moderator reviewed this user and marked them %s", verdict.CreatedAt.UTC().Format(time.DateOnly), strings.ToUpper(verdict.Label))
+	if verdict.Note != "" {
+		content += ", note: " + verdict.Note
+	}
+	content += ". Trust this verdict for their past behaviour and decide differently only when the new messages clearly show a change."
+	return ClaudeMessage{Role: ROLE_USER, Content: content}
+}
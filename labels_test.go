@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_MarkVerdicts(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "Shady"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "t1", Text: "devs dumped, get out", UserID: "u1", Username: "Shady", CreatedAt: time.Now()}))
+	require.NoError(t, db.SaveCachedAnalysis("u1", "Shady", SecondStepClaudeResponse{IsFUDUser: false, FUDType: "none", FUDProbability: 0.2, DecisionReason: "just venting"}, ""))
+
+	reply := func(label string, args ...string) string {
+		bot.handleMarkCommand(1, "@mod", label, args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply(LABEL_FUD), "Usage")
+	assert.Contains(t, reply(LABEL_CLEAN, "shady", "extra"), "Usage")
+	assert.Contains(t, reply(LABEL_FUD, "shady", "Bad-Type!"), "Invalid FUD type")
+	assert.Contains(t, reply(LABEL_FUD, "nobody"), "not found")
+
+	marked := reply(LABEL_FUD, "https://x.com/Shady", "coordinated_attack", "note:", "same", "script", "as", "last", "week")
+	assert.Contains(t, marked, "@Shady marked as FUD (coordinated_attack)")
+	assert.Contains(t, marked, "Corrects the analysis: clean (20%)")
+	assert.Contains(t, marked, "Labeled examples: 1 FUD, 0 clean")
+
+	verdict, err := db.GetLatestLabeledVerdict("u1")
+	require.NoError(t, err)
+	assert.Equal(t, "@mod", verdict.LabeledBy)
+	assert.Equal(t, "same script as last week", verdict.Note)
+	assert.True(t, verdict.ModelAnalyzed)
+	assert.Equal(t, "just venting", verdict.ModelReason)
+	var messages []labeledMessage
+	require.NoError(t, json.Unmarshal([]byte(verdict.Messages), &messages))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "devs dumped, get out", messages[0].Text)
+
+	cached, err := db.GetCachedAnalysis("u1")
+	require.NoError(t, err)
+	assert.True(t, cached.IsFUDUser, "the cached analysis is overridden")
+	assert.Contains(t, cached.DecisionReason, "Marked fud by @mod")
+	fudUser, err := db.GetFUDUser("u1")
+	require.NoError(t, err)
+	assert.Equal(t, "coordinated_attack", fudUser.FUDType)
+
+	cleared := reply(LABEL_CLEAN, "shady")
+	assert.Contains(t, cleared, "@Shady marked as clean")
+	assert.Contains(t, cleared, "Corrects the analysis: FUD, coordinated_attack (100%)")
+	assert.False(t, db.IsFUDUser("u1"))
+	cached, err = db.GetCachedAnalysis("u1")
+	require.NoError(t, err)
+	assert.False(t, cached.IsFUDUser)
+
+	counts, err := db.CountLabeledVerdicts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{LABEL_FUD: 1, LABEL_CLEAN: 1}, counts)
+
+	verdict, err = db.GetLatestLabeledVerdict("u1")
+	require.NoError(t, err)
+	assert.Contains(t, prepareLabeledVerdictMessage(verdict).Content, "marked them CLEAN")
+}
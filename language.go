@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const PROMPT_LANGUAGE_DEFAULT = "en"
+
+// Language names used in the preamble for languages without a translated prompt variant
+var promptLanguageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"el": "Greek",
+	"es": "Spanish",
+	"fa": "Persian",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"th": "Thai",
+	"tl": "Tagalog",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"vi": "Vietnamese",
+	"zh": "Chinese",
+}
+
+// Legacy codes X still returns for some languages
+var twitterLanguageAliases = map[string]string{
+	"in":  "id",
+	"iw":  "he",
+	"fil": "tl",
+}
+
+// PromptSet is a system prompt with optional translated variants. Variants live next to the
+// base file as <name>.<lang>.txt, e.g. prompt1.es.txt for prompt1.txt.
+type PromptSet struct {
+	base     []byte
+	variants map[string][]byte
+}
+
+func NewPromptSet(base []byte, variants map[string][]byte) *PromptSet {
+	if variants == nil {
+		variants = map[string][]byte{}
+	}
+	return &PromptSet{base: base, variants: variants}
+}
+
+// LoadPromptSet reads the base prompt file and every language variant found next to it
+func LoadPromptSet(path string) (*PromptSet, error) {
+	return LoadPromptSetFrom(dirPromptSource{dir: filepath.Dir(path)}, filepath.Base(path))
+}
+
+// Default returns the base (English) prompt
+func (p *PromptSet) Default() []byte {
+	if p == nil {
+		return nil
+	}
+	return p.base
+}
+
+// Languages lists the languages with a translated variant
+func (p *PromptSet) Languages() []string {
+	if p == nil {
+		return nil
+	}
+	var languages []string
+	for lang := range p.variants {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// ForLanguage returns the prompt to use for a message in lang: the translated variant when there
+// is one, otherwise the base prompt, prefixed with a language preamble for known non-English languages
+func (p *PromptSet) ForLanguage(lang string) []byte {
+	if p == nil {
+		return nil
+	}
+	if variant, ok := p.variants[lang]; ok {
+		return variant
+	}
+	name, ok := promptLanguageNames[lang]
+	if !ok {
+		return p.base
+	}
+	preamble := fmt.Sprintf("<language>The message being analyzed is written in %s. Read it in %s: judge insults, slang, sarcasm and scam accusations by what they mean in %s, and do not treat the language itself as a FUD signal. Keep the JSON keys and the reason text in English.</language>\n\n", name, name, name)
+	return append([]byte(preamble), p.base...)
+}
+
+// detectMessageLanguage returns the ISO 639-1 code of the message language, or "" when it cannot
+// be told. The language X reports for the tweet wins; otherwise the dominant script of the text decides.
+func detectMessageLanguage(message twitterapi.NewMessage) string {
+	lang := strings.ToLower(message.Lang)
+	if i := strings.Index(lang, "-"); i > 0 {
+		lang = lang[:i]
+	}
+	if alias, ok := twitterLanguageAliases[lang]; ok {
+		lang = alias
+	}
+	switch lang {
+	case "", "und", "zxx", "art", "qam", "qct", "qht", "qme", "qst":
+		return detectScriptLanguage(message.Text)
+	}
+	return lang
+}
+
+// detectScriptLanguage guesses the language from its script, which is only possible for scripts
+// used mostly by one language. Latin text returns "".
+func detectScriptLanguage(text string) string {
+	scripts := []struct {
+		lang  string
+		table *unicode.RangeTable
+	}{
+		{"ja", unicode.Hiragana},
+		{"ja", unicode.Katakana},
+		{"ko", unicode.Hangul},
+		{"zh", unicode.Han},
+		{"ru", unicode.Cyrillic},
+		{"ar", unicode.Arabic},
+		{"he", unicode.Hebrew},
+		{"el", unicode.Greek},
+		{"hi", unicode.Devanagari},
+		{"th", unicode.Thai},
+	}
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.lang]++
+				break
+			}
+		}
+	}
+	// Japanese mixes kana with Han characters, any kana at all marks it as Japanese
+	if counts["ja"] > 0 && counts["ja"]+counts["zh"] > letters/2 {
+		return "ja"
+	}
+	for _, script := range scripts {
+		if counts[script.lang] > letters/2 {
+			return script.lang
+		}
+	}
+	return ""
+}
+
+// selectPrompt picks the prompt variant for a message and logs the choice when it is not the default
+func selectPrompt(prompts *PromptSet, message twitterapi.NewMessage) []byte {
+	if prompts == nil {
+		return nil
+	}
+	lang := detectMessageLanguage(message)
+	if _, ok := prompts.variants[lang]; ok {
+		log.Printf("🌐 Using %s prompt variant for @%s", lang, message.Author.UserName)
+	} else if _, ok := promptLanguageNames[lang]; ok {
+		log.Printf("🌐 Adding %s language preamble for @%s", lang, message.Author.UserName)
+	}
+	return prompts.ForLanguage(lang)
+}
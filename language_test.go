@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMessageLanguage(t *testing.T) {
+	cases := []struct {
+		lang, text, expected string
+	}{
+		{"es", "esto es una estafa", "es"},
+		{"in", "ini penipuan", "id"},
+		{"zh-cn", "骗局", "zh"},
+		{"und", "это скам, выходите", "ru"},
+		{"", "これは詐欺です", "ja"},
+		{"", "사기입니다", "ko"},
+		{"", "this is a scam", ""},
+		{"zxx", "🚀🚀🚀", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, detectMessageLanguage(twitterapi.NewMessage{Lang: c.lang, Text: c.text}), c.text)
+	}
+}
+
+func TestPromptSet(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompt1.txt"), []byte("base prompt"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompt1.es.txt"), []byte("prompt en español"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompt2.txt"), []byte("other prompt"), 0o644))
+
+	prompts, err := LoadPromptSet(filepath.Join(dir, "prompt1.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"es"}, prompts.Languages())
+	assert.Equal(t, "base prompt", string(prompts.ForLanguage("")))
+	assert.Equal(t, "base prompt", string(prompts.ForLanguage(PROMPT_LANGUAGE_DEFAULT)))
+	assert.Equal(t, "base prompt", string(prompts.ForLanguage("xx")))
+	assert.Equal(t, "prompt en español", string(prompts.ForLanguage("es")))
+
+	withPreamble := string(prompts.ForLanguage("ru"))
+	assert.Contains(t, withPreamble, "written in Russian")
+	assert.Contains(t, withPreamble, "base prompt")
+	assert.Equal(t, "base prompt", string(prompts.Default()), "the preamble must not leak into the base prompt")
+}
+
+func TestFirstStepHandler_LanguagePrompt(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "user_u1", IsDetailAnalyzed: true}))
+	prompts := NewPromptSet([]byte("base prompt"), map[string][]byte{"es": []byte("prompt en español")})
+
+	newMessageCh := make(chan twitterapi.NewMessage, 2)
+	for _, message := range []twitterapi.NewMessage{{TweetID: "t1", Lang: "es", Text: "esto es una estafa"}, {TweetID: "t2", Lang: "tr", Text: "bu bir dolandırıcılık"}} {
+		message.Author.ID = "u1"
+		message.Author.UserName = "user_u1"
+		newMessageCh <- message
+	}
+	close(newMessageCh)
+	claudeApi := newMockClaudeAPI(`"is_fud":false,"fud_probability":0.1}`, nil)
+
+	FirstStepHandler(newMessageCh, make(chan twitterapi.NewMessage, 2), claudeApi, NewStaticPromptManager(prompts, nil), &mockUserStatusTracker{}, db, make(chan FUDAlertNotification, 2), &warRoomState{}, nil)
+
+	calls := claudeApi.recordedCalls()
+	require.Len(t, calls, 2)
+	assert.Contains(t, calls[0].systemMessage, "prompt en español")
+	assert.NotContains(t, calls[0].systemMessage, "base prompt")
+	assert.Contains(t, calls[1].systemMessage, "written in Turkish")
+	assert.Contains(t, calls[1].systemMessage, "base prompt")
+}
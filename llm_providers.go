@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// LLM providers, listed in ENV_LLM_PROVIDERS in the order they are tried
+const (
+	LLM_PROVIDER_CLAUDE   = "claude"
+	LLM_PROVIDER_OPENAI   = "openai"
+	LLM_PROVIDER_GEMINI   = "gemini"
+	LLM_PROVIDER_LOCAL    = "local" // any OpenAI compatible server, e.g. Ollama, vLLM or llama.cpp
+	LLM_PROVIDER_COOLDOWN = time.Minute
+)
+
+const (
+	OPENAI_API_URL       = "https://api.openai.com/v1"
+	OPENAI_DEFAULT_MODEL = "gpt-4o-mini"
+	GEMINI_API_URL       = "https://generativelanguage.googleapis.com/v1beta"
+	GEMINI_DEFAULT_MODEL = "gemini-1.5-flash"
+)
+
+// LLMProvider is a model backend the analysis can run on
+type LLMProvider interface {
+	ClaudeAPI
+	Name() string
+	SetUsageHook(hook func(step string, usage Usage))
+	LogRequests(logger *twitterapi.RequestLogger)
+}
+
+// FallbackLLM sends every call to the first provider of a priority list and moves on to the next one when
+// a provider fails. A provider that is down or rate limited goes to the end of the list for a minute.
+type FallbackLLM struct {
+	providers []LLMProvider
+	cooldowns *llmCooldowns // shared by the step clients
+}
+
+type llmCooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// NewFallbackLLM tries the providers in the given order
+func NewFallbackLLM(providers []LLMProvider) *FallbackLLM {
+	return &FallbackLLM{providers: providers, cooldowns: &llmCooldowns{until: map[string]time.Time{}}}
+}
+
+// ForStep returns a client whose calls are counted under the given step on every provider
+func (f *FallbackLLM) ForStep(step string) ClaudeAPI {
+	stepClient := &FallbackLLM{providers: make([]LLMProvider, 0, len(f.providers)), cooldowns: f.cooldowns}
+	for _, provider := range f.providers {
+		stepClient.providers = append(stepClient.providers, provider.ForStep(step).(LLMProvider))
+	}
+	return stepClient
+}
+
+// SetUsageHook registers a function called with the token usage of every call on every provider
+func (f *FallbackLLM) SetUsageHook(hook func(step string, usage Usage)) {
+	for _, provider := range f.providers {
+		provider.SetUsageHook(hook)
+	}
+}
+
+// LogRequests records the requests of every provider in the request log
+func (f *FallbackLLM) LogRequests(logger *twitterapi.RequestLogger) {
+	for _, provider := range f.providers {
+		provider.LogRequests(logger)
+	}
+}
+
+// Names lists the providers in priority order
+func (f *FallbackLLM) Names() []string {
+	names := make([]string, 0, len(f.providers))
+	for _, provider := range f.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+func (f *FallbackLLM) SendMessage(claudeMessages ClaudeMessages, systemMessage string) (*ClaudeMessageResponse, error) {
+	if len(f.providers) == 1 {
+		return f.providers[0].SendMessage(claudeMessages, systemMessage)
+	}
+	var errs []error
+	for i, provider := range f.ordered() {
+		resp, err := provider.SendMessage(claudeMessages, systemMessage)
+		if err == nil {
+			if i > 0 {
+				log.Printf("🔀 LLM call answered by fallback provider %s", provider.Name())
+			}
+			return resp, nil
+		}
+		log.Printf("⚠️ LLM provider %s failed: %v", provider.Name(), err)
+		if isLLMUnavailable(err) {
+			f.cooldowns.mu.Lock()
+			f.cooldowns.until[provider.Name()] = time.Now().Add(LLM_PROVIDER_COOLDOWN)
+			f.cooldowns.mu.Unlock()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ordered puts the providers cooling down after the others, they are still tried when all else fails
+func (f *FallbackLLM) ordered() []LLMProvider {
+	f.cooldowns.mu.Lock()
+	defer f.cooldowns.mu.Unlock()
+	var ready, cooling []LLMProvider
+	for _, provider := range f.providers {
+		if time.Now().Before(f.cooldowns.until[provider.Name()]) {
+			cooling = append(cooling, provider)
+		} else {
+			ready = append(ready, provider)
+		}
+	}
+	return append(ready, cooling...)
+}
+
+// loadLLMProviders builds the providers listed in ENV_LLM_PROVIDERS, Claude alone when none is usable
+func loadLLMProviders(claude *ClaudeApi) []LLMProvider {
+	var providers []LLMProvider
+	for _, name := range strings.Split(os.Getenv(ENV_LLM_PROVIDERS), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case LLM_PROVIDER_CLAUDE:
+			providers = append(providers, claude)
+		case LLM_PROVIDER_OPENAI:
+			if os.Getenv(ENV_OPENAI_API_KEY) == "" {
+				log.Printf("Warning: LLM provider openai skipped, %s is not set", ENV_OPENAI_API_KEY)
+				continue
+			}
+			providers = append(providers, NewOpenAIClient(LLM_PROVIDER_OPENAI, os.Getenv(ENV_OPENAI_API_KEY), OPENAI_API_URL, envOrDefault(ENV_OPENAI_MODEL, OPENAI_DEFAULT_MODEL)))
+		case LLM_PROVIDER_GEMINI:
+			if os.Getenv(ENV_GEMINI_API_KEY) == "" {
+				log.Printf("Warning: LLM provider gemini skipped, %s is not set", ENV_GEMINI_API_KEY)
+				continue
+			}
+			providers = append(providers, NewGeminiClient(os.Getenv(ENV_GEMINI_API_KEY), GEMINI_API_URL, envOrDefault(ENV_GEMINI_MODEL, GEMINI_DEFAULT_MODEL)))
+		case LLM_PROVIDER_LOCAL:
+			if os.Getenv(ENV_LOCAL_LLM_URL) == "" || os.Getenv(ENV_LOCAL_LLM_MODEL) == "" {
+				log.Printf("Warning: LLM provider local skipped, %s and %s are required", ENV_LOCAL_LLM_URL, ENV_LOCAL_LLM_MODEL)
+				continue
+			}
+			providers = append(providers, NewOpenAIClient(LLM_PROVIDER_LOCAL, "", strings.TrimRight(os.Getenv(ENV_LOCAL_LLM_URL), "/"), os.Getenv(ENV_LOCAL_LLM_MODEL)))
+		default:
+			log.Printf("Warning: unknown LLM provider %q in %s", name, ENV_LLM_PROVIDERS)
+		}
+	}
+	if len(providers) == 0 {
+		providers = append(providers, claude)
+	}
+	return providers
+}
+
+func envOrDefault(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// splitPrefill takes off the trailing assistant message the pipeline prefills Claude's answer with ("{"),
+// the other providers get a JSON only instruction instead
+func splitPrefill(claudeMessages ClaudeMessages) (ClaudeMessages, string) {
+	if len(claudeMessages) == 0 || claudeMessages[len(claudeMessages)-1].Role != ROLE_ASSISTANT {
+		return claudeMessages, ""
+	}
+	return claudeMessages[:len(claudeMessages)-1], claudeMessages[len(claudeMessages)-1].Content
+}
+
+func prefillInstruction(systemMessage string, prefill string) string {
+	if !strings.HasPrefix(prefill, "{") {
+		return systemMessage
+	}
+	return systemMessage + "\n\nAnswer with a single JSON object only, without markdown or any text around it."
+}
+
+// completePrefill makes an answer read as the continuation of the prefill, as Claude's answers do
+func completePrefill(text string, prefill string) string {
+	if prefill == "" {
+		return text
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	return strings.TrimPrefix(text, prefill)
+}
+
+// postLLMRequest sends a JSON request and decodes the answer, a non 200 status becomes a *ClaudeStatusError
+// so that rate limits and outages are recognized whatever the provider
+func postLLMRequest(client *http.Client, provider string, url string, headers map[string]string, payload interface{}, result interface{}) error {
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		httpReq.Header.Set(name, value)
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &errorResponse) != nil || errorResponse.Error.Message == "" {
+			errorResponse.Error.Message = string(body)
+		}
+		return &ClaudeStatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("%s SendMessage status not 200(%d) error: %s", provider, resp.StatusCode, errorResponse.Error.Message)}
+	}
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return fmt.Errorf("%s SendMessage unmarshall err: %s, body: %s", provider, err, string(body))
+	}
+	return nil
+}
+
+// OpenAIClient talks to the OpenAI chat completions API or a local server compatible with it
+type OpenAIClient struct {
+	name        string
+	apiKey      string // empty for local servers
+	baseURL     string
+	model       string
+	client      *http.Client
+	maxTokens   int
+	temperature float32
+	step        string
+	usageHook   func(step string, usage Usage)
+}
+
+func NewOpenAIClient(name string, apiKey string, baseURL string, model string) *OpenAIClient {
+	return &OpenAIClient{
+		name:        name,
+		apiKey:      apiKey,
+		baseURL:     baseURL,
+		model:       model,
+		client:      &http.Client{Transport: &http.Transport{}},
+		maxTokens:   DEFAULT_MAX_TOKENS,
+		temperature: DEFAULT_TEMPERATURE,
+	}
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model          string              `json:"model"`
+	Messages       []openAIChatMessage `json:"messages"`
+	MaxTokens      int                 `json:"max_tokens"`
+	Temperature    float32             `json:"temperature"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIChatMessage `json:"message"`
+		FinishReason string            `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (c *OpenAIClient) Name() string {
+	return c.name
+}
+
+func (c *OpenAIClient) ForStep(step string) ClaudeAPI {
+	stepClient := *c
+	stepClient.step = step
+	return &stepClient
+}
+
+func (c *OpenAIClient) SetUsageHook(hook func(step string, usage Usage)) {
+	c.usageHook = hook
+}
+
+func (c *OpenAIClient) LogRequests(logger *twitterapi.RequestLogger) {
+	c.client.Transport = logger.Wrap(c.name, c.client.Transport)
+}
+
+func (c *OpenAIClient) SendMessage(claudeMessages ClaudeMessages, systemMessage string) (*ClaudeMessageResponse, error) {
+	claudeMessages, prefill := splitPrefill(claudeMessages)
+	request := openAIChatRequest{
+		Model:       c.model,
+		Messages:    []openAIChatMessage{{Role: "system", Content: prefillInstruction(systemMessage, prefill)}},
+		MaxTokens:   min(c.maxTokens, MAX_TOKENS),
+		Temperature: c.temperature,
+	}
+	for _, message := range claudeMessages {
+		request.Messages = append(request.Messages, openAIChatMessage{Role: message.Role, Content: message.Content})
+	}
+	// Local servers do not all support the JSON mode, the instruction has to do there
+	if strings.HasPrefix(prefill, "{") && c.name == LLM_PROVIDER_OPENAI {
+		request.ResponseFormat = &struct {
+			Type string `json:"type"`
+		}{Type: "json_object"}
+	}
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+
+	var respData openAIChatResponse
+	err := postLLMRequest(c.client, c.name, c.baseURL+"/chat/completions", headers, request, &respData)
+	if err != nil {
+		c.recordUsage(Usage{})
+		return nil, err
+	}
+	usage := Usage{InputTokens: respData.Usage.PromptTokens, OutputTokens: respData.Usage.CompletionTokens}
+	c.recordUsage(usage)
+	if len(respData.Choices) == 0 {
+		return nil, fmt.Errorf("%s SendMessage returned no choices", c.name)
+	}
+	return &ClaudeMessageResponse{
+		Role:       ROLE_ASSISTANT,
+		Content:    []Content{{Type: "text", Text: completePrefill(respData.Choices[0].Message.Content, prefill)}},
+		Model:      respData.Model,
+		StopReason: respData.Choices[0].FinishReason,
+		Usage:      usage,
+		Provider:   c.name,
+	}, nil
+}
+
+func (c *OpenAIClient) recordUsage(usage Usage) {
+	if c.usageHook != nil {
+		c.usageHook(c.step, usage)
+	}
+}
+
+// GeminiClient talks to the Google Gemini generateContent API
+type GeminiClient struct {
+	apiKey      string
+	baseURL     string
+	model       string
+	client      *http.Client
+	maxTokens   int
+	temperature float32
+	step        string
+	usageHook   func(step string, usage Usage)
+}
+
+func NewGeminiClient(apiKey string, baseURL string, model string) *GeminiClient {
+	return &GeminiClient{
+		apiKey:      apiKey,
+		baseURL:     baseURL,
+		model:       model,
+		client:      &http.Client{Transport: &http.Transport{}},
+		maxTokens:   DEFAULT_MAX_TOKENS,
+		temperature: DEFAULT_TEMPERATURE,
+	}
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction geminiContent   `json:"systemInstruction"`
+	Contents          []geminiContent `json:"contents"`
+	GenerationConfig  struct {
+		Temperature      float32 `json:"temperature"`
+		MaxOutputTokens  int     `json:"maxOutputTokens"`
+		ResponseMimeType string  `json:"responseMimeType,omitempty"`
+	} `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+func (c *GeminiClient) Name() string {
+	return LLM_PROVIDER_GEMINI
+}
+
+func (c *GeminiClient) ForStep(step string) ClaudeAPI {
+	stepClient := *c
+	stepClient.step = step
+	return &stepClient
+}
+
+func (c *GeminiClient) SetUsageHook(hook func(step string, usage Usage)) {
+	c.usageHook = hook
+}
+
+func (c *GeminiClient) LogRequests(logger *twitterapi.RequestLogger) {
+	c.client.Transport = logger.Wrap(LLM_PROVIDER_GEMINI, c.client.Transport)
+}
+
+func (c *GeminiClient) SendMessage(claudeMessages ClaudeMessages, systemMessage string) (*ClaudeMessageResponse, error) {
+	claudeMessages, prefill := splitPrefill(claudeMessages)
+	request := geminiRequest{SystemInstruction: geminiContent{Parts: []geminiPart{{Text: prefillInstruction(systemMessage, prefill)}}}}
+	request.GenerationConfig.Temperature = c.temperature
+	request.GenerationConfig.MaxOutputTokens = min(c.maxTokens, MAX_TOKENS)
+	if strings.HasPrefix(prefill, "{") {
+		request.GenerationConfig.ResponseMimeType = "application/json"
+	}
+	// Gemini wants the turns to alternate, consecutive messages of one role are merged
+	for _, message := range claudeMessages {
+		role := "user"
+		if message.Role == ROLE_ASSISTANT {
+			role = "model"
+		}
+		if last := len(request.Contents) - 1; last >= 0 && request.Contents[last].Role == role {
+			request.Contents[last].Parts = append(request.Contents[last].Parts, geminiPart{Text: message.Content})
+			continue
+		}
+		request.Contents = append(request.Contents, geminiContent{Role: role, Parts: []geminiPart{{Text: message.Content}}})
+	}
+
+	var respData geminiResponse
+	err := postLLMRequest(c.client, LLM_PROVIDER_GEMINI, fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, c.model), map[string]string{"x-goog-api-key": c.apiKey}, request, &respData)
+	if err != nil {
+		c.recordUsage(Usage{})
+		return nil, err
+	}
+	usage := Usage{InputTokens: respData.UsageMetadata.PromptTokenCount, OutputTokens: respData.UsageMetadata.CandidatesTokenCount}
+	c.recordUsage(usage)
+	if len(respData.Candidates) == 0 {
+		return nil, fmt.Errorf("gemini SendMessage returned no candidates")
+	}
+	var text strings.Builder
+	for _, part := range respData.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	model := respData.ModelVersion
+	if model == "" {
+		model = c.model
+	}
+	return &ClaudeMessageResponse{
+		Role:       ROLE_ASSISTANT,
+		Content:    []Content{{Type: "text", Text: completePrefill(text.String(), prefill)}},
+		Model:      model,
+		StopReason: respData.Candidates[0].FinishReason,
+		Usage:      usage,
+		Provider:   LLM_PROVIDER_GEMINI,
+	}, nil
+}
+
+func (c *GeminiClient) recordUsage(usage Usage) {
+	if c.usageHook != nil {
+		c.usageHook(c.step, usage)
+	}
+}
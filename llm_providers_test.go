@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var prefilledConversation = ClaudeMessages{
+	{Role: ROLE_USER, Content: "the main post is: dev: launch day"},
+	{Role: ROLE_USER, Content: "user reply being analyzed: shady: rug incoming"},
+	{Role: ROLE_ASSISTANT, Content: "{"},
+}
+
+func TestOpenAIClient_PromptAdapter(t *testing.T) {
+	var request openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Write([]byte(`{"model":"gpt-4o-mini-2024","choices":[{"message":{"role":"assistant","content":"` + "```json\\n{\\\"is_fud\\\": true}\\n```" + `"},"finish_reason":"stop"}],"usage":{"prompt_tokens":120,"completion_tokens":8}}`))
+	}))
+	defer server.Close()
+
+	var hooked []string
+	client := NewOpenAIClient(LLM_PROVIDER_OPENAI, "sk-test", server.URL, OPENAI_DEFAULT_MODEL)
+	client.SetUsageHook(func(step string, usage Usage) { hooked = append(hooked, step) })
+	resp, err := client.ForStep(USAGE_STEP_FIRST).SendMessage(prefilledConversation, "find FUD")
+	require.NoError(t, err)
+
+	require.Len(t, request.Messages, 3, "the prefill is dropped")
+	assert.Equal(t, "system", request.Messages[0].Role)
+	assert.Contains(t, request.Messages[0].Content, "single JSON object")
+	assert.Equal(t, "user reply being analyzed: shady: rug incoming", request.Messages[2].Content)
+	require.NotNil(t, request.ResponseFormat)
+	assert.Equal(t, "json_object", request.ResponseFormat.Type)
+
+	assert.Equal(t, `"is_fud": true}`, resp.Content[0].Text, "the answer continues the prefill like Claude's")
+	assert.Equal(t, LLM_PROVIDER_OPENAI, resp.Provider)
+	assert.Equal(t, Usage{InputTokens: 120, OutputTokens: 8}, resp.Usage)
+	assert.Equal(t, []string{USAGE_STEP_FIRST}, hooked)
+}
+
+func TestGeminiClient_PromptAdapter(t *testing.T) {
+	var request geminiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/gemini-1.5-flash:generateContent", r.URL.Path)
+		assert.Equal(t, "g-test", r.Header.Get("x-goog-api-key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"{\"is_fud\":"},{"text":" false}"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":90,"candidatesTokenCount":5}}`))
+	}))
+	defer server.Close()
+
+	resp, err := NewGeminiClient("g-test", server.URL, GEMINI_DEFAULT_MODEL).SendMessage(prefilledConversation, "find FUD")
+	require.NoError(t, err)
+
+	require.Len(t, request.Contents, 1, "consecutive user messages are merged into one turn")
+	assert.Equal(t, "user", request.Contents[0].Role)
+	assert.Len(t, request.Contents[0].Parts, 2)
+	assert.Contains(t, request.SystemInstruction.Parts[0].Text, "single JSON object")
+	assert.Equal(t, "application/json", request.GenerationConfig.ResponseMimeType)
+
+	assert.Equal(t, `"is_fud": false}`, resp.Content[0].Text)
+	assert.Equal(t, GEMINI_DEFAULT_MODEL, resp.Model)
+	assert.Equal(t, LLM_PROVIDER_GEMINI, resp.Provider)
+}
+
+func TestFallbackLLM(t *testing.T) {
+	var primaryCalls, backupCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"slow down"}}`))
+	}))
+	defer primary.Close()
+	var backupStatus atomic.Int32
+	backupStatus.Store(http.StatusOK)
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backupCalls.Add(1)
+		w.WriteHeader(int(backupStatus.Load()))
+		w.Write([]byte(`{"model":"llama3.1","choices":[{"message":{"role":"assistant","content":"{\"ok\": true}"}}]}`))
+	}))
+	defer backup.Close()
+
+	llm := NewFallbackLLM([]LLMProvider{
+		NewOpenAIClient(LLM_PROVIDER_OPENAI, "sk-test", primary.URL, OPENAI_DEFAULT_MODEL),
+		NewOpenAIClient(LLM_PROVIDER_LOCAL, "", backup.URL, "llama3.1"),
+	})
+	resp, err := llm.ForStep(USAGE_STEP_SECOND).SendMessage(prefilledConversation, "find FUD")
+	require.NoError(t, err)
+	assert.Equal(t, LLM_PROVIDER_LOCAL, resp.Provider)
+	assert.Equal(t, `"ok": true}`, resp.Content[0].Text)
+
+	_, err = llm.SendMessage(prefilledConversation, "find FUD")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), primaryCalls.Load(), "a rate limited provider is skipped for a while")
+	assert.Equal(t, int32(2), backupCalls.Load())
+
+	backupStatus.Store(http.StatusServiceUnavailable)
+	_, err = llm.SendMessage(prefilledConversation, "find FUD")
+	require.Error(t, err)
+	assert.Equal(t, int32(2), primaryCalls.Load(), "cooling providers are still tried when all else fails")
+	assert.True(t, isLLMUnavailable(err), "heuristic alerts still take over when every provider is down")
+	assert.Contains(t, err.Error(), "openai: ")
+	assert.Contains(t, err.Error(), "local: ")
+}
+
+func TestLoadLLMProviders(t *testing.T) {
+	claude, err := NewClaudeClient("key", "", CLAUDE_MODEL)
+	require.NoError(t, err)
+
+	t.Setenv(ENV_LLM_PROVIDERS, "")
+	assert.Equal(t, []string{LLM_PROVIDER_CLAUDE}, NewFallbackLLM(loadLLMProviders(claude)).Names())
+
+	t.Setenv(ENV_LLM_PROVIDERS, "gemini, Claude,openai,local,mistral")
+	t.Setenv(ENV_OPENAI_API_KEY, "sk-test")
+	t.Setenv(ENV_LOCAL_LLM_URL, "http://localhost:11434/v1/")
+	t.Setenv(ENV_LOCAL_LLM_MODEL, "llama3.1:8b")
+	assert.Equal(t, []string{LLM_PROVIDER_CLAUDE, LLM_PROVIDER_OPENAI, LLM_PROVIDER_LOCAL}, NewFallbackLLM(loadLLMProviders(claude)).Names(), "providers without credentials and unknown ones are skipped")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+)
+
+// logLevel is shared by every handler so /loglevel applies at once
+var logLevel = new(slog.LevelVar)
+
+// setupLogging installs the structured logger configured by ENV_LOG_FORMAT and ENV_LOG_LEVEL.
+// Lines still written through the log package go through it too, tagged with their source file
+// as module and leveled by their wording.
+func setupLogging(output io.Writer) error {
+	level, err := parseLogLevel(os.Getenv(ENV_LOG_LEVEL))
+	if err != nil {
+		return err
+	}
+	logLevel.Set(level)
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	var handler slog.Handler
+	switch format := strings.ToLower(strings.TrimSpace(os.Getenv(ENV_LOG_FORMAT))); format {
+	case "", LOG_FORMAT_TEXT:
+		handler = slog.NewTextHandler(output, options)
+	case LOG_FORMAT_JSON:
+		handler = slog.NewJSONHandler(output, options)
+	default:
+		return fmt.Errorf("unknown %s %q, use text or json", ENV_LOG_FORMAT, format)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	log.SetFlags(log.Lshortfile)
+	log.SetOutput(legacyLogWriter{})
+	return nil
+}
+
+// parseLogLevel reads debug, info, warn or error, empty is info
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(name) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, use debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// logFor returns the logger of a module
+func logFor(module string) *slog.Logger {
+	return slog.With("module", module)
+}
+
+// messageLogger tags the lines about a message in the pipeline with its task, chat, author and tweet
+func messageLogger(module string, newMessage twitterapi.NewMessage) *slog.Logger {
+	attrs := []any{"module", module, "username", newMessage.Author.UserName, "tweet_id", newMessage.TweetID}
+	if newMessage.TaskID != "" {
+		attrs = append(attrs, "task_id", newMessage.TaskID)
+	}
+	if newMessage.TelegramChatID != 0 {
+		attrs = append(attrs, "chat_id", newMessage.TelegramChatID)
+	}
+	return slog.With(attrs...)
+}
+
+// taskLogger tags the lines about an analysis task with its ID, chat and target
+func taskLogger(module string, task *AnalysisTaskModel) *slog.Logger {
+	attrs := []any{"module", module, "task_id", task.ID, "username", task.Username}
+	if task.TelegramChatID != 0 {
+		attrs = append(attrs, "chat_id", task.TelegramChatID)
+	}
+	return slog.With(attrs...)
+}
+
+// legacyLogWriter hands lines of the log package to the structured logger
+type legacyLogWriter struct{}
+
+func (legacyLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	module := "main"
+	// log.Lshortfile prefixes "file.go:123: "
+	if location, message, ok := strings.Cut(line, ": "); ok {
+		if file, _, ok := strings.Cut(location, ":"); ok && strings.HasSuffix(file, ".go") {
+			module = strings.TrimSuffix(filepath.Base(file), ".go")
+			line = message
+		}
+	}
+	slog.Default().Log(context.Background(), legacyLogLevel(line), line, "module", module)
+	return len(p), nil
+}
+
+// legacyLogLevel guesses the level of an unstructured line from its wording
+func legacyLogLevel(line string) slog.Level {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.Contains(lower, "error") || strings.Contains(lower, "failed") || strings.Contains(lower, "panic") || strings.Contains(line, "❌"):
+		return slog.LevelError
+	case strings.Contains(lower, "warning") || strings.Contains(line, "⚠️"):
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// handleLogLevelCommand shows or changes the log level until the next restart: /loglevel [debug|info|warn|error]
+func (b *BotController) handleLogLevelCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📝 Log level: <b>%s</b>\n\nUsage: /loglevel debug|info|warn|error, %s sets it at startup", strings.ToLower(logLevel.Level().String()), ENV_LOG_LEVEL))
+		return
+	}
+	level, err := parseLogLevel(args[0])
+	if len(args) > 1 || err != nil {
+		b.SendMessage(chatID, "❌ Usage: /loglevel debug|info|warn|error")
+		return
+	}
+	previous := logLevel.Level()
+	logLevel.Set(level)
+	if b.dbService != nil {
+		b.dbService.RecordConfigChange("log_level", actor, strings.ToLower(previous.String()), strings.ToLower(level.String()))
+	}
+	logFor("logging").Warn("log level changed", "from", previous.String(), "to", level.String(), "by", actor)
+	b.SendMessage(chatID, fmt.Sprintf("✅ Log level %s → <b>%s</b> until the next restart", strings.ToLower(previous.String()), strings.ToLower(level.String())))
+}
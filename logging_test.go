@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestLogger sends the logs of a test to a buffer as JSON and restores the loggers afterwards
+func useTestLogger(t *testing.T) *bytes.Buffer {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+		logLevel.Set(slog.LevelInfo)
+	})
+	t.Setenv(ENV_LOG_FORMAT, "json")
+	t.Setenv(ENV_LOG_LEVEL, "")
+	var output bytes.Buffer
+	require.NoError(t, setupLogging(&output))
+	return &output
+}
+
+func logLines(t *testing.T, output *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		lines = append(lines, entry)
+	}
+	output.Reset()
+	return lines
+}
+
+func TestSetupLogging(t *testing.T) {
+	output := useTestLogger(t)
+
+	log.Printf("Failed to save tweet %s: %v", "42", "disk full")
+	log.Printf("Loaded %d prompts", 2)
+	lines := logLines(t, output)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "ERROR", lines[0]["level"])
+	assert.Equal(t, "Failed to save tweet 42: disk full", lines[0]["msg"])
+	assert.Equal(t, "logging_test", lines[0]["module"], "legacy lines are tagged with their source file")
+	assert.Equal(t, "INFO", lines[1]["level"])
+
+	message := twitterapi.NewMessage{TweetID: "t1", TaskID: "task-7", TelegramChatID: -100}
+	message.Author.UserName = "shouter"
+	messageLogger("second_step", message).Info("analyzed")
+	messageLogger("second_step", message).Debug("prompt dump")
+	lines = logLines(t, output)
+	require.Len(t, lines, 1, "debug is off by default")
+	assert.Equal(t, "task-7", lines[0]["task_id"])
+	assert.Equal(t, float64(-100), lines[0]["chat_id"])
+	assert.Equal(t, "shouter", lines[0]["username"])
+	assert.Equal(t, "second_step", lines[0]["module"])
+
+	t.Setenv(ENV_LOG_FORMAT, "xml")
+	assert.Error(t, setupLogging(output))
+	t.Setenv(ENV_LOG_FORMAT, "")
+	t.Setenv(ENV_LOG_LEVEL, "loud")
+	assert.Error(t, setupLogging(output))
+}
+
+func TestBotController_LogLevelCommand(t *testing.T) {
+	output := useTestLogger(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, nil)
+	reply := func(args ...string) string {
+		bot.handleLogLevelCommand(1, "@admin", args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply(), "Log level: <b>info</b>")
+	assert.Contains(t, reply("verbose"), "Usage")
+	assert.Contains(t, reply("DEBUG"), "info → <b>debug</b>")
+	logFor("test").Debug("now visible")
+	lines := logLines(t, output)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "@admin", lines[0]["by"])
+	assert.Equal(t, "now visible", lines[1]["msg"])
+
+	assert.Contains(t, reply("error"), "debug → <b>error</b>")
+	log.Printf("⚠️ Warning: something odd")
+	logLines(t, output)
+	log.Printf("just chatter")
+	assert.Empty(t, logLines(t, output), "legacy lines honor the level too")
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,6 +24,7 @@ func main() {
 	configFile := flag.String("config", "", "Configuration file to load (e.g., .env, .dev.env, .prod.env)")
 	showHelp := flag.Bool("help", false, "Show help information")
 	flag.BoolVar(showHelp, "h", false, "Show help information (shorthand)")
+	migrateDown := flag.Int("migrate-down", -1, "Revert the database schema to this version and exit")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "FUD Detection System - Twitter/X Community Monitoring\n\n")
@@ -30,6 +33,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  -config string\n")
 		fmt.Fprintf(os.Stderr, "        Configuration file to load (default: none)\n")
 		fmt.Fprintf(os.Stderr, "        Examples: .env, .dev.env, .prod.env\n")
+		fmt.Fprintf(os.Stderr, "  -migrate-down int\n")
+		fmt.Fprintf(os.Stderr, "        Revert the database schema to this version and exit, see /dbversion\n")
 		fmt.Fprintf(os.Stderr, "  -help, -h\n")
 		fmt.Fprintf(os.Stderr, "        Show this help information\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
@@ -61,19 +66,47 @@ func main() {
 	} else {
 		log.Println("No config file specified, using environment variables only")
 	}
-	claudeApi, err := NewClaudeClient(os.Getenv(ENV_CLAUDE_API_KEY), os.Getenv(ENV_PROXY_CLAUDE_DSN), CLAUDE_MODEL)
+	if err := setupLogging(os.Stderr); err != nil {
+		log.Printf("Warning: %v, keeping plain text logs at info level", err)
+	}
+	// Start profiling endpoints if configured
+	err := StartProfilingServer(os.Getenv(ENV_PPROF_ADDR), os.Getenv(ENV_PPROF_TOKEN))
+	if err != nil {
+		log.Printf("Warning: profiling disabled: %v", err)
+	}
+	claudeClient, err := NewClaudeClient(os.Getenv(ENV_CLAUDE_API_KEY), os.Getenv(ENV_PROXY_CLAUDE_DSN), CLAUDE_MODEL)
 	if err != nil {
 		panic(err)
 	}
+	// Other providers take over when Claude errors or is rate limited
+	claudeApi := NewFallbackLLM(loadLLMProviders(claudeClient))
+	log.Printf("🤖 LLM providers in order: %s", strings.Join(claudeApi.Names(), ", "))
 	ticker := os.Getenv(ENV_TWITTER_COMMUNITY_TICKER)
 	if ticker == "" {
 		panic("ticker should be set .env: " + ENV_TWITTER_COMMUNITY_TICKER)
 	}
 	twitterApi := twitterapi.NewTwitterAPIService(os.Getenv(ENV_TWITTER_API_KEY), os.Getenv(ENV_TWITTER_API_BASE_URL), os.Getenv(ENV_PROXY_DSN))
+
+	// Outbound request log for debugging and fixtures (see cmd/fixtures)
+	if requestLogDir := os.Getenv(ENV_REQUEST_LOG_DIR); requestLogDir != "" {
+		maxFiles, _ := strconv.Atoi(os.Getenv(ENV_REQUEST_LOG_MAX_FILES))
+		requestLogger, err := twitterapi.NewRequestLogger(requestLogDir, twitterapi.REQUEST_LOG_MAX_FILE_BYTES, maxFiles)
+		if err != nil {
+			log.Printf("Warning: request log disabled: %v", err)
+		} else {
+			defer requestLogger.Close()
+			twitterApi.LogRequests(requestLogger)
+			claudeApi.LogRequests(requestLogger)
+			log.Printf("📼 Logging Twitter and LLM requests to %s", requestLogDir)
+		}
+	}
 	notificationFormatter := NewNotificationFormatter()
 
 	// Initialize database service
-	dbName := os.Getenv(ENV_DATABASE_NAME)
+	dbName := os.Getenv(ENV_DATABASE_DSN)
+	if dbName == "" {
+		dbName = os.Getenv(ENV_DATABASE_NAME)
+	}
 	if dbName == "" {
 		dbName = "hackathon.db"
 	}
@@ -82,7 +115,22 @@ func main() {
 		panic(fmt.Sprintf("Failed to initialize database: %v", err))
 	}
 	defer dbService.Close()
-	log.Println("Database service initialized successfully")
+	log.Printf("Database service initialized successfully (%s)", dbService.Backend())
+	if *migrateDown >= 0 {
+		if err := dbService.MigrateDown(*migrateDown); err != nil {
+			panic(fmt.Sprintf("Failed to revert migrations: %v", err))
+		}
+		log.Printf("🗄 Database schema reverted to v%d", *migrateDown)
+		return
+	}
+
+	// Count API calls and tokens for /usage
+	claudeApi.SetUsageHook(func(step string, usage Usage) {
+		dbService.RecordUsage(USAGE_LLM, step, 1, int64(usage.InputTokens), int64(usage.OutputTokens))
+	})
+	twitterApi.SetRequestHook(func(endpoint string) {
+		dbService.RecordUsage(USAGE_TWITTER, endpoint, 1, 0, 0)
+	})
 
 	// Check if we need to clear analysis flags on startup
 	if os.Getenv(ENV_CLEAR_ANALYSIS_ON_START) == "true" {
@@ -91,21 +139,83 @@ func main() {
 		if err != nil {
 			log.Printf("Warning: Failed to clear analysis flags: %v", err)
 		} else {
-			log.Println("Successfully cleared all analysis flags")
+			log.Printf("Successfully cleared all analysis flags, FUD records stay restorable with /restore_user for %s", softDeleteGrace())
 		}
 	}
 
 	fudChannel := make(chan twitterapi.NewMessage, 30)
+	// User reports skip the queue of regular FUD messages
+	priorityChannel := make(chan twitterapi.NewMessage, 10)
 
-	telegramService, err := NewTelegramService(os.Getenv(ENV_TELEGRAM_API_KEY), os.Getenv(ENV_PROXY_DSN), os.Getenv(ENV_TELEGRAM_ADMIN_CHAT_ID), notificationFormatter, dbService, fudChannel)
+	telegramClient, err := NewTelegramClient(os.Getenv(ENV_TELEGRAM_API_KEY), os.Getenv(ENV_PROXY_DSN))
 	if err != nil {
-		panic(fmt.Sprintf("Failed to initialize telegram service: %v", err))
+		panic(fmt.Sprintf("Failed to initialize telegram client: %v", err))
 	}
+	telegramService := NewBotController(NewRateLimitedTransport(telegramClient), os.Getenv(ENV_TELEGRAM_ADMIN_CHAT_ID), notificationFormatter, dbService, fudChannel)
+	telegramService.SetPriorityChannel(priorityChannel)
 
 	// Initialize user status manager
 	userStatusManager := NewUserStatusManager()
 	userStatusManager.StartPeriodicSave()
 
+	// Drop expired /detail_ notifications
+	dbService.StartNotificationCleanup(time.Hour)
+
+	// Permanently remove user data deleted longer ago than the restore grace period
+	dbService.StartSoftDeletePurge(softDeleteGrace(), SOFT_DELETE_PURGE_EVERY)
+
+	// Re-fetch flagged tweets to catch edits made after they were flagged
+	StartEditTracker(twitterApi, dbService, EDIT_CHECK_INTERVAL)
+
+	// Daily and weekly summaries for chats that ran /subscribe
+	telegramService.StartDigestScheduler(DIGEST_CHECK_INTERVAL)
+	// Alerts held during the quiet hours of a chat, see /quiet
+	telegramService.StartQuietHoursDigests(QUIET_HOURS_CHECK_INTERVAL)
+
+	// Analyst REST endpoints (graph export), the signals webhook and federation if configured
+	signalTokens, err := parseSignalTokens(os.Getenv(ENV_SIGNAL_TOKENS))
+	if err != nil {
+		log.Printf("Warning: signals webhook disabled: %v", err)
+	}
+	federationTokens, err := parseSourceTokens(ENV_FEDERATION_TOKENS, os.Getenv(ENV_FEDERATION_TOKENS))
+	if err != nil {
+		log.Printf("Warning: federation inbound disabled: %v", err)
+	}
+	err = StartAPIServer(os.Getenv(ENV_API_ADDR), os.Getenv(ENV_API_TOKEN), dbService, signalTokens, telegramService, federationTokens, telegramService)
+	if err != nil {
+		log.Printf("Warning: API disabled: %v", err)
+	}
+
+	// Discord alerts and slash commands if configured
+	var notificationSinks []NotificationSink
+	if os.Getenv(ENV_DISCORD_BOT_TOKEN) != "" {
+		discordService, err := NewDiscordService(os.Getenv(ENV_DISCORD_BOT_TOKEN), os.Getenv(ENV_DISCORD_APPLICATION_ID), os.Getenv(ENV_DISCORD_PUBLIC_KEY), os.Getenv(ENV_DISCORD_CHANNEL_IDS), notificationFormatter, dbService, telegramService)
+		if err != nil {
+			log.Printf("Warning: Discord disabled: %v", err)
+		} else {
+			notificationSinks = append(notificationSinks, discordService)
+			if err := discordService.RegisterCommands(); err != nil {
+				log.Printf("Warning: failed to register Discord commands: %v", err)
+			}
+			if err := discordService.StartInteractionsServer(os.Getenv(ENV_DISCORD_INTERACTIONS_ADDR)); err != nil {
+				log.Printf("Warning: Discord commands disabled: %v", err)
+			}
+		}
+	}
+
+	// The configured community is the first monitored one, more are added with /community add
+	if communityID := os.Getenv(ENV_DEMO_COMMUNITY_ID); communityID != "" {
+		seeded, err := dbService.SeedCommunity(communityID, ticker)
+		if err != nil {
+			log.Printf("Failed to register community %s: %v", communityID, err)
+		} else if seeded {
+			log.Printf("🏘 Registered community %s (%s) from configuration", communityID, ticker)
+		}
+	}
+
+	// Community blocklists, their accounts alert on their first message
+	seedBlocklists(dbService)
+
 	// Initialize data (CSV import or community loading)
 	log.Println("Initializing data...")
 	initializeData(dbService, twitterApi)
@@ -113,53 +223,90 @@ func main() {
 	// Start Telegram service
 	telegramService.StartListening()
 
-	systemPromptFirstStep, err := os.ReadFile(PROMPT_FILE_STEP1)
-	if err != nil {
-		panic(err)
-	}
-	systemPromptSecondStep, err := os.ReadFile(PROMPT_FILE_STEP2)
+	// Prompts come from the working directory by default, a central server or the binary itself
+	promptSource := NewPromptSource(os.Getenv(ENV_PROMPT_SOURCE), os.Getenv(ENV_PROMPT_CACHE_DIR))
+	// Stored as versions, /prompt changes them without a restart
+	prompts, err := NewPromptManager(dbService, promptSource)
 	if err != nil {
 		panic(err)
 	}
+	systemPromptFirstStep, firstVersion := prompts.Prompt(PROMPT_STEP_FIRST)
+	systemPromptSecondStep, secondVersion := prompts.Prompt(PROMPT_STEP_SECOND)
+	log.Printf("📝 Active prompts: first step v%d, second step v%d", firstVersion, secondVersion)
+	log.Printf("🌐 Prompt variants: first step %v, second step %v", systemPromptFirstStep.Languages(), systemPromptSecondStep.Languages())
+	telegramService.SetAnalysisServices(twitterApi, claudeApi, userStatusManager, prompts, ticker)
 	//init channels
 	newMessageCh := make(chan twitterapi.NewMessage, 10)
 	//notification channel
 	notificationCh := make(chan FUDAlertNotification, 30)
 
-	//start monitoring for new messages in community
+	//start monitoring for new messages, one monitor per community
+	monitors := newCommunityMonitors(func(community CommunityModel, stop <-chan struct{}) {
+		MonitoringHandler(twitterApi, newMessageCh, dbService, &telegramService.warRoom, &telegramService.watchdog, community, stop)
+	})
+	telegramService.SetCommunityMonitors(monitors)
+	communities, err := dbService.GetActiveCommunities()
+	if err != nil {
+		log.Printf("Failed to load communities: %v", err)
+	}
+	for _, community := range communities {
+		log.Printf("🏘 Monitoring community %s (%s)", community.ID, community.Ticker)
+		monitors.Start(community)
+	}
 	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		MonitoringHandler(twitterApi, newMessageCh, dbService)
-	}()
 	//handle new message first step
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		FirstStepHandler(newMessageCh, fudChannel, claudeApi, systemPromptFirstStep, userStatusManager, dbService, notificationCh)
+		FirstStepHandler(newMessageCh, fudChannel, claudeApi, prompts, userStatusManager, dbService, notificationCh, &telegramService.warRoom, &telegramService.tail)
 	}()
 	//handle fud messages with dynamic routing
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		for newMessage := range fudChannel {
+		for {
+			newMessage, ok := nextSecondStepMessage(priorityChannel, fudChannel)
+			if !ok {
+				return
+			}
 			log.Printf("Second step processing for user %s", newMessage.Author.UserName)
-			SecondStepHandler(newMessage, notificationCh, twitterApi, claudeApi, systemPromptSecondStep, userStatusManager, ticker, dbService)
+			SecondStepHandler(newMessage, notificationCh, twitterApi, claudeApi, prompts, userStatusManager, ticker, dbService)
 		}
 	}()
 	//notification handler
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		NotificationHandler(notificationCh, telegramService)
+		NotificationHandler(notificationCh, telegramService, notificationSinks...)
 	}()
+	// resume analysis tasks interrupted by the previous shutdown now that the pipeline is running
+	taskTimeout := analysisTaskTimeout()
+	telegramService.ResumeAnalysisTasks(taskTimeout)
+	telegramService.ResumeAnalysisBatches()
+	telegramService.StartOrphanedTaskSweeper(taskTimeout, ANALYSIS_TASK_SWEEP_EVERY)
+	telegramService.StartIngestionWatchdog(watchdogStallThreshold(), WATCHDOG_CHECK_EVERY)
+	telegramService.StartBudgetScheduler(fudChannel, BUDGET_CHECK_INTERVAL)
+	telegramService.StartFollowUpScheduler(FOLLOW_UP_CHECK_INTERVAL)
+	telegramService.StartReanalysisScheduler(REANALYSIS_CHECK_INTERVAL)
+	telegramService.StartModeratorSync(MODERATOR_SYNC_INTERVAL)
+	if err := telegramService.StartRetention(RETENTION_RUN_EVERY); err != nil {
+		panic(fmt.Sprintf("Failed to start data retention: %v", err))
+	}
+	// Kubernetes liveness and readiness probes
+	health := NewHealthChecker(dbService, telegramService, twitterApi)
+	health.AddQueue("first_step", func() (int, int) { return len(newMessageCh), cap(newMessageCh) })
+	health.AddQueue("second_step", func() (int, int) { return len(fudChannel), cap(fudChannel) })
+	health.AddQueue("priority", func() (int, int) { return len(priorityChannel), cap(priorityChannel) })
+	health.AddQueue("notifications", func() (int, int) { return len(notificationCh), cap(notificationCh) })
+	if err := StartHealthServer(os.Getenv(ENV_HEALTH_ADDR), health); err != nil {
+		log.Printf("Warning: health checks disabled: %v", err)
+	}
 	// Cleanup
 	defer userStatusManager.StopPeriodicSave()
 	wg.Wait()
 }
-func initializeData(dbService *DatabaseService, twitterApi *twitterapi.TwitterAPIService) {
+func initializeData(dbService *DatabaseService, twitterApi TwitterAPI) {
 	// Check if CSV import is requested
 	csvPath := os.Getenv(ENV_IMPORT_CSV_PATH)
 	if csvPath != "" {
@@ -167,7 +314,10 @@ func initializeData(dbService *DatabaseService, twitterApi *twitterapi.TwitterAP
 
 		// Import from CSV instead of full community load
 		importer := NewCSVImporter(dbService)
-		result, err := importer.ImportCSV(csvPath)
+		if backfill, _ := strconv.Atoi(os.Getenv(ENV_IMPORT_BACKFILL_PARENTS)); backfill > 0 && twitterApi != nil {
+			importer.EnableParentBackfill(twitterApi, backfill)
+		}
+		result, err := importer.ImportFile(csvPath, ImportOptions{})
 		if err != nil {
 			log.Printf("CSV import failed: %v", err)
 			log.Println("Falling back to community data loading...")
@@ -186,13 +336,19 @@ func initializeData(dbService *DatabaseService, twitterApi *twitterapi.TwitterAP
 
 	if tweetCount < 10 {
 		log.Printf("Tweet count (%d) is less than 10, performing full community load...", tweetCount)
-		FullCommunityLoad(twitterApi, dbService)
+		communities, err := dbService.GetActiveCommunities()
+		if err != nil {
+			log.Printf("Failed to load communities: %v", err)
+		}
+		for _, community := range communities {
+			FullCommunityLoad(twitterApi, dbService, community.ID)
+		}
 	} else {
 		log.Printf("Tweet count (%d) is >= 10, skipping full database initialization", tweetCount)
 	}
 }
 
-func PrepareClaudeSecondStepRequest(userTickerData *UserTickerMentionsData, followers *twitterapi.UserFollowersResponse, followings *twitterapi.UserFollowingsResponse, userStatusManager *UserStatusManager, communityActivity *UserCommunityActivity) ClaudeMessages {
+func PrepareClaudeSecondStepRequest(userTickerData *UserTickerMentionsData, followers *twitterapi.UserFollowersResponse, followings *twitterapi.UserFollowingsResponse, userStatusManager UserStatusTracker, communityActivity *UserCommunityActivity) ClaudeMessages {
 	claudeMessages := ClaudeMessages{}
 
 	// 1. User's ticker mentions with replied messages
@@ -299,7 +455,7 @@ type ReplyTweet struct {
 	Author    string `json:"author"`
 }
 
-func getUserTickerMentions(twitterApi *twitterapi.TwitterAPIService, username string, ticker string, dbService *DatabaseService) *UserTickerMentionsData {
+func getUserTickerMentions(twitterApi TwitterAPI, username string, ticker string, dbService *DatabaseService) *UserTickerMentionsData {
 	const MAX_PAGES = 3
 	const TOKEN_LIMIT = 50000 // Half of typical Claude token limit
 
@@ -433,7 +589,7 @@ func getUserTickerMentions(twitterApi *twitterapi.TwitterAPIService, username st
 	return result
 }
 
-func SendIfNotExistsTweetToChannel(tweet twitterapi.Tweet, newMessageCh chan twitterapi.NewMessage, tweetsExistsStorage map[string]int, parentTweet twitterapi.Tweet, grandParentTweet twitterapi.Tweet) {
+func SendIfNotExistsTweetToChannel(tweet twitterapi.Tweet, newMessageCh chan twitterapi.NewMessage, tweetsExistsStorage map[string]int, parentTweet twitterapi.Tweet, grandParentTweet twitterapi.Tweet, community CommunityModel) {
 	if _, ok := tweetsExistsStorage[tweet.Id]; !ok {
 		newMessageCh <- twitterapi.NewMessage{
 			TweetID:      tweet.Id,
@@ -453,11 +609,15 @@ func SendIfNotExistsTweetToChannel(tweet twitterapi.Tweet, newMessageCh chan twi
 				Author string
 				Text   string
 			}{ID: grandParentTweet.Id, Author: grandParentTweet.Author.UserName, Text: grandParentTweet.Text},
-			Text:         tweet.Text,
-			CreatedAt:    tweet.CreatedAt,
-			ReplyCount:   tweet.ReplyCount,
-			LikeCount:    tweet.LikeCount,
-			RetweetCount: tweet.RetweetCount,
+			Text:             tweet.Text,
+			Lang:             tweet.Lang,
+			CreatedAt:        tweet.CreatedAt,
+			ReplyCount:       tweet.ReplyCount,
+			LikeCount:        tweet.LikeCount,
+			RetweetCount:     tweet.RetweetCount,
+			CommunityID:      community.ID,
+			Ticker:           community.Ticker,
+			CommunityContext: community.PromptContext,
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// BenchmarkPrepareClaudeSecondStepRequest measures second step context building for a user with 50 ticker mentions and 400 friends
+func BenchmarkPrepareClaudeSecondStepRequest(b *testing.B) {
+	userStatusManager := &UserStatusManager{users: make(map[string]*UserInfo)}
+	for i := 0; i < 100; i++ {
+		userStatusManager.users[fmt.Sprintf("id_%d", i)] = &UserInfo{
+			UserID:   fmt.Sprintf("id_%d", i),
+			Username: fmt.Sprintf("friend_%d", i*4),
+			Status:   STATUS_FUD_CONFIRMED,
+		}
+	}
+
+	tickerData := &UserTickerMentionsData{}
+	for i := 0; i < 50; i++ {
+		tickerData.UserMessages = append(tickerData.UserMessages, UserMessageWithReplies{
+			TweetID:   fmt.Sprintf("tweet_%d", i),
+			CreatedAt: "Mon Jan 02 15:04:05 -0700 2006",
+			Text:      "What do you think about $RODF liquidity?",
+			RepliedTo: &ReplyTweet{TweetID: fmt.Sprintf("parent_%d", i), Text: "Parent text", Author: "someone"},
+		})
+	}
+
+	followers := &twitterapi.UserFollowersResponse{}
+	followings := &twitterapi.UserFollowingsResponse{}
+	for i := 0; i < 200; i++ {
+		followers.Followers = append(followers.Followers, twitterapi.User{UserName: fmt.Sprintf("friend_%d", i)})
+		followings.Followings = append(followings.Followings, twitterapi.User{UserName: fmt.Sprintf("friend_%d", i+200)})
+	}
+
+	activity := &UserCommunityActivity{UserID: "user"}
+	for i := 0; i < 20; i++ {
+		activity.ThreadGroups = append(activity.ThreadGroups, ThreadGroup{
+			MainPost:    ThreadPost{ID: fmt.Sprintf("root_%d", i), Text: "Root post", Author: "project"},
+			UserReplies: []UserReply{{TweetID: fmt.Sprintf("reply_%d", i), Text: "Reply"}},
+		})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PrepareClaudeSecondStepRequest(tickerData, followers, followings, userStatusManager, activity)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maintenanceState holds non-critical alerts queued during a maintenance window
+type maintenanceState struct {
+	mu     sync.Mutex
+	until  time.Time
+	timer  *time.Timer
+	queued []queuedAlert
+}
+
+type queuedAlert struct {
+	NotificationID string
+	Alert          FUDAlertNotification
+}
+
+// holds reports whether an active maintenance window holds back the alert, the caller holds mu
+func (m *maintenanceState) holds(alert FUDAlertNotification) bool {
+	return !m.until.IsZero() && alert.AlertSeverity != "critical"
+}
+
+// heldByMaintenance reports whether the alert is held back from broadcasts right now
+func (b *BotController) heldByMaintenance(alert FUDAlertNotification) bool {
+	b.maintenance.mu.Lock()
+	defer b.maintenance.mu.Unlock()
+	return b.maintenance.holds(alert)
+}
+
+// queueIfInMaintenance holds back non-critical alerts while maintenance is active
+func (b *BotController) queueIfInMaintenance(alert FUDAlertNotification, notificationID string) bool {
+	b.maintenance.mu.Lock()
+	defer b.maintenance.mu.Unlock()
+
+	if !b.maintenance.holds(alert) {
+		return false
+	}
+	b.maintenance.queued = append(b.maintenance.queued, queuedAlert{NotificationID: notificationID, Alert: alert})
+	log.Printf("Maintenance active, queued %s alert for @%s", alert.AlertSeverity, alert.FUDUsername)
+	return true
+}
+
+// handleMaintenanceCommand processes /maintenance <duration>|off
+func (b *BotController) handleMaintenanceCommand(chatID int64, args []string) {
+	if len(args) == 0 {
+		b.maintenance.mu.Lock()
+		until, queued := b.maintenance.until, len(b.maintenance.queued)
+		b.maintenance.mu.Unlock()
+
+		if until.IsZero() {
+			b.SendMessage(chatID, "🛠 No maintenance running.\n\nUsage: /maintenance 30m|2h|off")
+			return
+		}
+		b.SendMessage(chatID, fmt.Sprintf("🛠 Maintenance until %s UTC, %d alerts queued.\n\nUse /maintenance off to end it now.", until.UTC().Format("15:04"), queued))
+		return
+	}
+
+	if strings.ToLower(args[0]) == "off" {
+		if !b.endMaintenance() {
+			b.SendMessage(chatID, "🛠 No maintenance running.")
+		}
+		return
+	}
+
+	duration, err := time.ParseDuration(args[0])
+	if err != nil || duration <= 0 || duration > 24*time.Hour {
+		b.SendMessage(chatID, "❌ Invalid duration. Use something like 30m or 2h (max 24h)")
+		return
+	}
+
+	until := time.Now().Add(duration)
+	b.maintenance.mu.Lock()
+	if b.maintenance.timer != nil {
+		b.maintenance.timer.Stop()
+	}
+	b.maintenance.until = until
+	b.maintenance.timer = time.AfterFunc(duration, func() { b.endMaintenance() })
+	b.maintenance.mu.Unlock()
+
+	log.Printf("Maintenance started by chat %d for %s", chatID, duration)
+	b.BroadcastMessage(fmt.Sprintf("🛠 <b>Scheduled maintenance</b>\n\nNon-critical alerts are paused until %s UTC and will be summarized afterwards. Critical alerts are still delivered.", until.UTC().Format("15:04")))
+}
+
+// endMaintenance resumes broadcasts and posts a summary of the queued alerts
+func (b *BotController) endMaintenance() bool {
+	b.maintenance.mu.Lock()
+	if b.maintenance.until.IsZero() {
+		b.maintenance.mu.Unlock()
+		return false
+	}
+	if b.maintenance.timer != nil {
+		b.maintenance.timer.Stop()
+	}
+	queued := b.maintenance.queued
+	b.maintenance.until = time.Time{}
+	b.maintenance.timer = nil
+	b.maintenance.queued = nil
+	b.maintenance.mu.Unlock()
+
+	log.Printf("Maintenance finished, %d alerts were queued", len(queued))
+	b.broadcastMaintenanceSummary(queued)
+	return true
+}
+
+// broadcastMaintenanceSummary tells each chat which of its alerts were held back
+func (b *BotController) broadcastMaintenanceSummary(queued []queuedAlert) {
+	settings, err := b.dbService.GetAllChatSettings()
+	if err != nil {
+		log.Printf("Failed to load chat settings, using defaults: %v", err)
+	}
+
+	for _, chatID := range b.GetRegisteredChats() {
+		chatSettings, ok := settings[chatID]
+		if !ok {
+			chatSettings = *defaultChatSettings(chatID)
+		}
+
+		var relevant []queuedAlert
+		counts := make(map[string]int)
+		for _, item := range queued {
+			// Same routing as broadcastAlert, so the summary names nobody the chat would not have been alerted about
+			if severityRank(item.Alert.AlertSeverity) < severityRank(chatSettings.MinSeverity) {
+				continue
+			}
+			if !communityReceivesAlert(b.alertCommunity(item.Alert), chatID, &chatSettings) {
+				continue
+			}
+			if chatID < 0 && b.alertDelivery(item.Alert.AlertSeverity) == ALERT_DELIVERY_USERS {
+				continue
+			}
+			relevant = append(relevant, item)
+			counts[item.Alert.AlertSeverity]++
+		}
+
+		var message strings.Builder
+		message.WriteString("✅ <b>Maintenance finished</b>, alerts are delivered again.\n")
+		if len(relevant) == 0 {
+			message.WriteString("\nNo alerts were held back.")
+		} else {
+			message.WriteString(fmt.Sprintf("\n📥 <b>Held back:</b> %d alerts", len(relevant)))
+			for _, severity := range []string{"high", "medium", "low"} {
+				if counts[severity] > 0 {
+					message.WriteString(fmt.Sprintf(" · %s %s %d", b.formatter.getSeverityEmoji(severity), severity, counts[severity]))
+				}
+			}
+			message.WriteString("\n")
+
+			// Redacted and guest chats only get the counts
+			if !isRedactedProfile(chatSettings.Redaction) && chatSettings.Role != CHAT_ROLE_GUEST {
+				for i, item := range relevant {
+					if i == 20 {
+						message.WriteString(fmt.Sprintf("… and %d more\n", len(relevant)-20))
+						break
+					}
+					message.WriteString(fmt.Sprintf("%s @%s — %s · /detail_%s\n",
+						b.formatter.getSeverityEmoji(item.Alert.AlertSeverity), item.Alert.FUDUsername,
+						b.formatter.formatFUDType(item.Alert.FUDType), item.NotificationID))
+				}
+			}
+		}
+
+		err := b.SendMessageToTopic(chatID, chatSettings.AlertsTopicID, message.String())
+		if err != nil {
+			log.Printf("Failed to send maintenance summary to chat %d: %v", chatID, err)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migration is one versioned schema change. Versions only grow, a released migration is never edited:
+// a model change ships as a new migration, for example AutoMigrate of the changed model with a Down
+// dropping the added column.
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// migrations are applied in order at startup, databases created before versioning start at the baseline
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "baseline schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(databaseModels...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(databaseModels...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "chat roles",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ChatSettingsModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&ChatSettingsModel{}, "Role")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "deferred analyses",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&DeferredAnalysisModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&DeferredAnalysisModel{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "event log",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&EventModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&EventModel{})
+		},
+	},
+	{
+		Version: 5,
+		Name:    "ingestion filters",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&IngestionFilterModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&IngestionFilterModel{})
+		},
+	},
+	{
+		Version: 6,
+		Name:    "follow-up checks",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&AlertMessageModel{}, &FollowUpCheckModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&FollowUpCheckModel{}, &AlertMessageModel{})
+		},
+	},
+	{
+		Version: 7,
+		Name:    "moderators",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ModeratorModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&ModeratorModel{})
+		},
+	},
+	{
+		Version: 8,
+		Name:    "alert format versions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&NotificationModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&NotificationModel{}, "FormatVersion")
+		},
+	},
+	{
+		Version: 9,
+		Name:    "task error codes",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&AnalysisTaskModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&AnalysisTaskModel{}, "ErrorCode")
+		},
+	},
+	{
+		Version: 10,
+		Name:    "fud types",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&FUDTypeModel{}); err != nil {
+				return err
+			}
+			return seedFUDTypes(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&FUDTypeModel{})
+		},
+	},
+	{
+		Version: 11,
+		Name:    "tweet merges",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&TweetMergeModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&TweetMergeModel{})
+		},
+	},
+	{
+		Version: 12,
+		Name:    "alert thresholds",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&AlertThresholdModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&AlertThresholdModel{})
+		},
+	},
+	{
+		Version: 13,
+		Name:    "quiet hours",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ChatSettingsModel{}, &QuietAlertModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&QuietAlertModel{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&ChatSettingsModel{}, "QuietHours")
+		},
+	},
+	{
+		Version: 14,
+		Name:    "alert states",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&NotificationModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"State", "AssignedTo", "AckedBy", "AckedAt", "ResolvedBy", "ResolvedAt"} {
+				if err := tx.Migrator().DropColumn(&NotificationModel{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 15,
+		Name:    "watchlist",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&WatchlistModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&WatchlistModel{})
+		},
+	},
+	{
+		Version: 16,
+		Name:    "reanalyses",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ReanalysisModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&ReanalysisModel{})
+		},
+	},
+	{
+		Version: 17,
+		Name:    "risk scores",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&RiskScoreModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&RiskScoreModel{})
+		},
+	},
+	{
+		Version: 18,
+		Name:    "forum topics",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ChatSettingsModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"AlertsTopicID", "ReportsTopicID"} {
+				if err := tx.Migrator().DropColumn(&ChatSettingsModel{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 19,
+		Name:    "alert message lookup",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&AlertMessageModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropIndex(&AlertMessageModel{}, "idx_alert_messages_chat_message")
+		},
+	},
+	{
+		Version: 20,
+		Name:    "removed chats",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ChatSettingsModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"RemovedAt", "RemovedBy"} {
+				if err := tx.Migrator().DropColumn(&ChatSettingsModel{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 21,
+		Name:    "alert log",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&AlertLogModel{}); err != nil {
+				return err
+			}
+			// Alerts stored so far start the log
+			var notifications []NotificationModel
+			if err := tx.Order("id").Find(&notifications).Error; err != nil {
+				return err
+			}
+			for _, notification := range notifications {
+				if err := tx.Create(newAlertLogEntry(notification)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&AlertLogModel{})
+		},
+	},
+	{
+		Version: 22,
+		Name:    "re-analysis backoff",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ReanalysisModel{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&ReanalysisModel{}, "RetryAfter")
+		},
+	},
+}
+
+// latestSchemaVersion is the version this build migrates to
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// runMigrations applies the pending migrations. On PostgreSQL instances starting together take turns.
+func (s *DatabaseService) runMigrations() error {
+	return s.withMigrationLock(func(db *gorm.DB) error {
+		if err := db.AutoMigrate(&SchemaVersionModel{}); err != nil {
+			return fmt.Errorf("failed to create the schema_version table: %w", err)
+		}
+		current, err := currentSchemaVersion(db)
+		if err != nil {
+			return err
+		}
+		if current > latestSchemaVersion() {
+			log.Printf("⚠️ Database schema v%d is newer than this build (v%d), skipping migrations", current, latestSchemaVersion())
+			return nil
+		}
+		for _, m := range migrations {
+			if m.Version <= current {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaVersionModel{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+			log.Printf("🗄 Applied migration %d: %s", m.Version, m.Name)
+		}
+		return nil
+	})
+}
+
+// MigrateDown reverts the applied migrations above the target version, newest first
+func (s *DatabaseService) MigrateDown(target int) error {
+	if target < 0 {
+		return fmt.Errorf("invalid target version %d", target)
+	}
+	return s.withMigrationLock(func(db *gorm.DB) error {
+		var applied []SchemaVersionModel
+		if err := db.Where("version > ?", target).Order("version DESC").Find(&applied).Error; err != nil {
+			return err
+		}
+		for _, version := range applied {
+			m, ok := findMigration(version.Version)
+			if !ok {
+				return fmt.Errorf("migration %d (%s) is unknown to this build, revert it with the build that applied it", version.Version, version.Name)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaVersionModel{}, "version = ?", m.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+			log.Printf("🗄 Reverted migration %d: %s", m.Version, m.Name)
+		}
+		return nil
+	})
+}
+
+// SchemaVersions lists the applied migrations, oldest first
+func (s *DatabaseService) SchemaVersions() ([]SchemaVersionModel, error) {
+	var versions []SchemaVersionModel
+	err := s.db.Order("version ASC").Find(&versions).Error
+	return versions, err
+}
+
+// withMigrationLock runs fn holding the PostgreSQL advisory lock, SQLite locks the file itself
+func (s *DatabaseService) withMigrationLock(fn func(db *gorm.DB) error) error {
+	if s.Backend() != "postgres" {
+		return fn(s.db)
+	}
+	return s.db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", MIGRATION_LOCK_ID).Error; err != nil {
+			return fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", MIGRATION_LOCK_ID)
+		return fn(conn)
+	})
+}
+
+func currentSchemaVersion(db *gorm.DB) (int, error) {
+	var current int
+	err := db.Model(&SchemaVersionModel{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error
+	return current, err
+}
+
+func findMigration(version int) (migration, bool) {
+	index := sort.Search(len(migrations), func(i int) bool { return migrations[i].Version >= version })
+	if index < len(migrations) && migrations[index].Version == version {
+		return migrations[index], true
+	}
+	return migration{}, false
+}
+
+// handleDBVersionCommand shows the schema version and the applied migrations: /dbversion
+func (b *BotController) handleDBVersionCommand(chatID int64) {
+	versions, err := b.dbService.SchemaVersions()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Failed to read the schema version: %v", err))
+		return
+	}
+	current := 0
+	if len(versions) > 0 {
+		current = versions[len(versions)-1].Version
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🗄 <b>Schema version:</b> %d of %d (%s)\n", current, latestSchemaVersion(), b.dbService.Backend()))
+	switch {
+	case current > latestSchemaVersion():
+		message.WriteString("⚠️ The database is newer than this build\n")
+	case current < latestSchemaVersion():
+		message.WriteString(fmt.Sprintf("⏳ %d migrations pending until the next restart\n", latestSchemaVersion()-current))
+	}
+	message.WriteString("\n<b>Applied:</b>\n")
+	for _, version := range versions {
+		message.WriteString(fmt.Sprintf("• %d %s, %s\n", version.Version, version.Name, version.AppliedAt.Format("2006-01-02 15:04")))
+	}
+	message.WriteString("\nRevert with the -migrate-down version flag at startup")
+	b.SendMessage(chatID, message.String())
+}
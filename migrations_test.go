@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseService_Migrations(t *testing.T) {
+	db := setupTestDB(t)
+	versions, err := db.SchemaVersions()
+	require.NoError(t, err)
+	require.Len(t, versions, len(migrations))
+	assert.Equal(t, latestSchemaVersion(), versions[len(versions)-1].Version)
+
+	require.NoError(t, db.runMigrations(), "applied migrations are not run again")
+	versions, err = db.SchemaVersions()
+	require.NoError(t, err)
+	assert.Len(t, versions, len(migrations))
+
+	require.NoError(t, db.MigrateDown(0))
+	assert.False(t, db.db.Migrator().HasTable(&TweetModel{}))
+	versions, err = db.SchemaVersions()
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	require.NoError(t, db.runMigrations())
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "m1", Text: "after the rollback"}))
+	assert.True(t, db.TweetExists("m1"))
+
+	require.NoError(t, db.db.Create(&SchemaVersionModel{Version: latestSchemaVersion() + 1, Name: "from a newer build"}).Error)
+	assert.Error(t, db.MigrateDown(0), "unknown migrations are not reverted")
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.handleDBVersionCommand(1)
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "newer than this build")
+	assert.Contains(t, sent[0].Text, "1 baseline schema")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// mockTwitterAPI answers each endpoint with its func field; endpoints left nil return an error
+type mockTwitterAPI struct {
+	communityTweets func(req twitterapi.CommunityTweetsRequest) (*twitterapi.CommunityTweetsResponse, error)
+	tweetReplies    func(req twitterapi.TweetRepliesRequest) (*twitterapi.TweetRepliesResponse, error)
+	userLastTweets  func(req twitterapi.UserLastTweetsRequest) (*twitterapi.UserLastTweetsResponse, error)
+	userInfo        func(req twitterapi.UserInfoRequest) (*twitterapi.UserInfoResponse, error)
+	userFollowers   func(req twitterapi.UserFollowersRequest) (*twitterapi.UserFollowersResponse, error)
+	userFollowings  func(req twitterapi.UserFollowingsRequest) (*twitterapi.UserFollowingsResponse, error)
+	tweetRetweeters func(req twitterapi.TweetRetweetersRequest) (*twitterapi.TweetRetweetersResponse, error)
+	tweetsByIds     func(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error)
+	advancedSearch  func(request twitterapi.AdvancedSearchRequest) (*twitterapi.AdvancedSearchResponse, error)
+}
+
+func errNotMocked(endpoint string) error {
+	return fmt.Errorf("mockTwitterAPI: %s not mocked", endpoint)
+}
+
+func (m *mockTwitterAPI) GetCommunityTweets(req twitterapi.CommunityTweetsRequest) (*twitterapi.CommunityTweetsResponse, error) {
+	if m.communityTweets == nil {
+		return nil, errNotMocked("GetCommunityTweets")
+	}
+	return m.communityTweets(req)
+}
+
+func (m *mockTwitterAPI) GetTweetReplies(req twitterapi.TweetRepliesRequest) (*twitterapi.TweetRepliesResponse, error) {
+	if m.tweetReplies == nil {
+		return nil, errNotMocked("GetTweetReplies")
+	}
+	return m.tweetReplies(req)
+}
+
+func (m *mockTwitterAPI) GetUserLastTweets(req twitterapi.UserLastTweetsRequest) (*twitterapi.UserLastTweetsResponse, error) {
+	if m.userLastTweets == nil {
+		return nil, errNotMocked("GetUserLastTweets")
+	}
+	return m.userLastTweets(req)
+}
+
+func (m *mockTwitterAPI) GetUserInfo(req twitterapi.UserInfoRequest) (*twitterapi.UserInfoResponse, error) {
+	if m.userInfo == nil {
+		return nil, errNotMocked("GetUserInfo")
+	}
+	return m.userInfo(req)
+}
+
+func (m *mockTwitterAPI) GetUserFollowers(req twitterapi.UserFollowersRequest) (*twitterapi.UserFollowersResponse, error) {
+	if m.userFollowers == nil {
+		return nil, errNotMocked("GetUserFollowers")
+	}
+	return m.userFollowers(req)
+}
+
+func (m *mockTwitterAPI) GetUserFollowings(req twitterapi.UserFollowingsRequest) (*twitterapi.UserFollowingsResponse, error) {
+	if m.userFollowings == nil {
+		return nil, errNotMocked("GetUserFollowings")
+	}
+	return m.userFollowings(req)
+}
+
+func (m *mockTwitterAPI) GetTweetRetweeters(req twitterapi.TweetRetweetersRequest) (*twitterapi.TweetRetweetersResponse, error) {
+	if m.tweetRetweeters == nil {
+		return nil, errNotMocked("GetTweetRetweeters")
+	}
+	return m.tweetRetweeters(req)
+}
+
+func (m *mockTwitterAPI) GetTweetsByIds(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error) {
+	if m.tweetsByIds == nil {
+		return nil, errNotMocked("GetTweetsByIds")
+	}
+	return m.tweetsByIds(tweetIds)
+}
+
+func (m *mockTwitterAPI) AdvancedSearch(request twitterapi.AdvancedSearchRequest) (*twitterapi.AdvancedSearchResponse, error) {
+	if m.advancedSearch == nil {
+		return nil, errNotMocked("AdvancedSearch")
+	}
+	return m.advancedSearch(request)
+}
+
+type mockClaudeCall struct {
+	step          string
+	messages      ClaudeMessages
+	systemMessage string
+}
+
+// mockClaudeAPI replies to every call with text (the part after the prefilled "{") or err,
+// and records the calls with the step they were made for
+type mockClaudeAPI struct {
+	text  string
+	err   error
+	mu    *sync.Mutex
+	calls *[]mockClaudeCall
+	step  string
+}
+
+func newMockClaudeAPI(text string, err error) *mockClaudeAPI {
+	return &mockClaudeAPI{text: text, err: err, mu: &sync.Mutex{}, calls: &[]mockClaudeCall{}}
+}
+
+func (m *mockClaudeAPI) ForStep(step string) ClaudeAPI {
+	stepClient := *m
+	stepClient.step = step
+	return &stepClient
+}
+
+func (m *mockClaudeAPI) SendMessage(claudeMessages ClaudeMessages, systemMessage string) (*ClaudeMessageResponse, error) {
+	m.mu.Lock()
+	*m.calls = append(*m.calls, mockClaudeCall{step: m.step, messages: claudeMessages, systemMessage: systemMessage})
+	m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ClaudeMessageResponse{Role: ROLE_ASSISTANT, Content: []Content{{Type: "text", Text: m.text}}}, nil
+}
+
+func (m *mockClaudeAPI) recordedCalls() []mockClaudeCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mockClaudeCall(nil), *m.calls...)
+}
+
+// mockUserStatusTracker records which users were marked as being analyzed and their decisions
+type mockUserStatusTracker struct {
+	mu         sync.Mutex
+	analyzing  []string
+	decisions  map[string]SecondStepClaudeResponse
+	fudFriends map[string]bool
+}
+
+func (m *mockUserStatusTracker) SetUserAnalyzing(userID, username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.analyzing = append(m.analyzing, userID)
+}
+
+func (m *mockUserStatusTracker) UpdateUserAfterAnalysis(userID, username string, aiDecision SecondStepClaudeResponse, messageID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.decisions == nil {
+		m.decisions = map[string]SecondStepClaudeResponse{}
+	}
+	m.decisions[userID] = aiDecision
+}
+
+func (m *mockUserStatusTracker) GetFUDFriendsAnalysis(usernames []string) (int, int, []string) {
+	var fudFriends []string
+	for _, username := range usernames {
+		if m.fudFriends[username] {
+			fudFriends = append(fudFriends, username)
+		}
+	}
+	return len(usernames), len(fudFriends), fudFriends
+}
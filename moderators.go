@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MODERATOR_VERIFY_TTL    = 10 * time.Minute // how long a getChatMember answer is trusted for commands
+	MODERATOR_SYNC_INTERVAL = time.Hour
+)
+
+const MODERATOR_ACCESS_DENIED = "❌ Access denied. Admin commands are restricted to administrators of the moderator group."
+
+const (
+	MODERATOR_STATUS_ADMIN   = "admin"
+	MODERATOR_STATUS_REVOKED = "revoked"
+)
+
+// Member statuses of getChatMember
+const (
+	TELEGRAM_MEMBER_CREATOR       = "creator"
+	TELEGRAM_MEMBER_ADMINISTRATOR = "administrator"
+	TELEGRAM_MEMBER_LEFT          = "left"
+	TELEGRAM_MEMBER_KICKED        = "kicked"
+)
+
+// moderatorGroupID returns the configured moderator group, false when admin chats trust all their members
+func moderatorGroupID() (int64, bool) {
+	value := strings.TrimSpace(os.Getenv(ENV_MODERATOR_GROUP_ID))
+	if value == "" {
+		return 0, false
+	}
+	groupID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, admin commands are denied", ENV_MODERATOR_GROUP_ID, value)
+		return 0, true
+	}
+	return groupID, true
+}
+
+func isModeratorMemberStatus(status string) bool {
+	return status == TELEGRAM_MEMBER_CREATOR || status == TELEGRAM_MEMBER_ADMINISTRATOR
+}
+
+// revocationReason describes why a member status ends the bot privileges
+func revocationReason(status string) string {
+	switch status {
+	case TELEGRAM_MEMBER_LEFT:
+		return "left the moderator group"
+	case TELEGRAM_MEMBER_KICKED:
+		return "removed from the moderator group"
+	}
+	return "no longer an administrator of the moderator group"
+}
+
+// isModeratorSender tells whether the sender of a command in an admin chat is an administrator of the
+// moderator group. Answers are cached for MODERATOR_VERIFY_TTL. When Telegram cannot be asked, a
+// stored administrator keeps access and anybody else is denied.
+func (b *BotController) isModeratorSender(update TelegramUpdate) bool {
+	if allowed, known := b.cachedModeratorDecision(update); known {
+		return allowed
+	}
+	groupID, _ := moderatorGroupID()
+	userID := update.Message.From.ID
+	stored, _ := b.dbService.GetModerator(userID)
+
+	member, err := b.transport.GetChatMember(groupID, userID)
+	if err != nil {
+		log.Printf("Failed to verify moderator @%s (id %d): %v", update.Message.From.Username, userID, err)
+		return stored != nil && stored.Status == MODERATOR_STATUS_ADMIN
+	}
+	username := member.User.Username
+	if username == "" {
+		username = update.Message.From.Username
+	}
+	return b.syncModerator(stored, userID, username, member.Status)
+}
+
+// cachedModeratorDecision answers isModeratorSender without asking Telegram. known is false when the
+// sender has to be verified with GetChatMember first.
+func (b *BotController) cachedModeratorDecision(update TelegramUpdate) (allowed bool, known bool) {
+	groupID, enabled := moderatorGroupID()
+	if !enabled {
+		return true, true
+	}
+	if groupID == 0 {
+		return false, true
+	}
+	stored, _ := b.dbService.GetModerator(update.Message.From.ID)
+	if stored != nil && time.Since(stored.VerifiedAt) < MODERATOR_VERIFY_TTL {
+		return stored.Status == MODERATOR_STATUS_ADMIN, true
+	}
+	return false, false
+}
+
+// syncModerator stores a verified member status and tells whether it grants the bot privileges.
+// Admins losing their status are revoked and the admin chats are told.
+func (b *BotController) syncModerator(stored *ModeratorModel, userID int64, username string, memberStatus string) bool {
+	now := time.Now()
+	moderator := ModeratorModel{UserID: userID, Username: username, CreatedAt: now}
+	if stored != nil {
+		moderator = *stored
+		if username != "" {
+			moderator.Username = username
+		}
+	}
+	wasAdmin := stored != nil && stored.Status == MODERATOR_STATUS_ADMIN
+	moderator.MemberStatus = memberStatus
+	moderator.VerifiedAt = now
+	if isModeratorMemberStatus(memberStatus) {
+		moderator.Status = MODERATOR_STATUS_ADMIN
+		moderator.RevokedAt = nil
+		moderator.RevokedReason = ""
+	} else {
+		moderator.Status = MODERATOR_STATUS_REVOKED
+		if wasAdmin || stored == nil {
+			moderator.RevokedAt = &now
+			moderator.RevokedReason = revocationReason(memberStatus)
+		}
+	}
+	if err := b.dbService.SaveModerator(&moderator); err != nil {
+		log.Printf("Failed to save moderator @%s (id %d): %v", moderator.Username, userID, err)
+	}
+	if wasAdmin && moderator.Status == MODERATOR_STATUS_REVOKED {
+		log.Printf("🔒 Revoked bot privileges of @%s (id %d): %s", moderator.Username, userID, moderator.RevokedReason)
+		for _, adminChatID := range adminChatIDs() {
+			message := fmt.Sprintf("🔒 <b>Bot privileges revoked</b>\n\n👤 @%s (<code>%d</code>) %s.", moderator.Username, userID, moderator.RevokedReason)
+			if err := b.SendMessage(adminChatID, message); err != nil {
+				log.Printf("Failed to notify admin chat %d about revoked moderator %d: %v", adminChatID, userID, err)
+			}
+		}
+	}
+	return moderator.Status == MODERATOR_STATUS_ADMIN
+}
+
+// StartModeratorSync re-verifies the stored administrators periodically, so users who left the
+// moderator group lose the bot privileges before their next command
+func (b *BotController) StartModeratorSync(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			b.syncModerators()
+		}
+	}()
+}
+
+func (b *BotController) syncModerators() {
+	groupID, enabled := moderatorGroupID()
+	if !enabled || groupID == 0 {
+		return
+	}
+	moderators, err := b.dbService.GetModerators()
+	if err != nil {
+		log.Printf("Failed to load moderators: %v", err)
+		return
+	}
+	for i := range moderators {
+		moderator := &moderators[i]
+		if moderator.Status != MODERATOR_STATUS_ADMIN {
+			continue
+		}
+		member, err := b.transport.GetChatMember(groupID, moderator.UserID)
+		if err != nil {
+			// Kept until Telegram answers, a failed request proves nothing
+			log.Printf("Failed to verify moderator @%s (id %d): %v", moderator.Username, moderator.UserID, err)
+			continue
+		}
+		b.syncModerator(moderator, moderator.UserID, member.User.Username, member.Status)
+	}
+}
+
+// handleModeratorsCommand lists the verified moderators and the revoked ones
+func (b *BotController) handleModeratorsCommand(chatID int64) {
+	groupID, enabled := moderatorGroupID()
+	if !enabled {
+		b.SendMessage(chatID, fmt.Sprintf("👮 Moderator sync is off, every member of the admin chats may use admin commands. Set %s to the moderator group to enable it.", ENV_MODERATOR_GROUP_ID))
+		return
+	}
+	moderators, err := b.dbService.GetModerators()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading moderators: %v", err))
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("👮 <b>Moderators</b> of group <code>%d</code>\n\n", groupID))
+	if len(moderators) == 0 {
+		message.WriteString("📭 Nobody verified yet, moderators are verified at their first admin command.\n")
+	}
+	for _, moderator := range moderators {
+		if moderator.Status == MODERATOR_STATUS_ADMIN {
+			message.WriteString(fmt.Sprintf("✅ @%s (%s), verified %s\n", moderator.Username, moderator.MemberStatus, moderator.VerifiedAt.UTC().Format("2006-01-02 15:04 UTC")))
+			continue
+		}
+		revoked := ""
+		if moderator.RevokedAt != nil {
+			revoked = ", " + moderator.RevokedAt.UTC().Format("2006-01-02 15:04 UTC")
+		}
+		message.WriteString(fmt.Sprintf("🔒 @%s: %s%s\n", moderator.Username, moderator.RevokedReason, revoked))
+	}
+	message.WriteString(fmt.Sprintf("\nAdministrators are re-verified every %s, users who leave the group lose access.", MODERATOR_SYNC_INTERVAL))
+	b.SendMessage(chatID, message.String())
+}
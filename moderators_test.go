@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModerators_AdminCommandsFollowGroupRoster(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	t.Setenv(ENV_MODERATOR_GROUP_ID, "-500")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{members: map[int64]string{7: TELEGRAM_MEMBER_ADMINISTRATOR}}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	moderator := newTestUpdate(1, "/followups")
+	moderator.Message.From.ID = 7
+	assert.True(t, bot.isModeratorSender(moderator))
+	stranger := newTestUpdate(1, "/followups")
+	stranger.Message.From.ID = 8
+	assert.False(t, bot.isModeratorSender(stranger))
+
+	stored, err := db.GetModerator(7)
+	require.NoError(t, err)
+	assert.Equal(t, MODERATOR_STATUS_ADMIN, stored.Status)
+
+	// The moderator leaves the group, the sync revokes the privileges and tells the admin chats
+	transport.members[7] = TELEGRAM_MEMBER_LEFT
+	bot.syncModerators()
+	stored, err = db.GetModerator(7)
+	require.NoError(t, err)
+	assert.Equal(t, MODERATOR_STATUS_REVOKED, stored.Status)
+	assert.Equal(t, "left the moderator group", stored.RevokedReason)
+	sent := transport.sentMessages()
+	require.NotEmpty(t, sent)
+	assert.Contains(t, sent[len(sent)-1].Text, "Bot privileges revoked")
+	assert.False(t, bot.isModeratorSender(moderator))
+
+	events, err := db.GetEventsAfter(0, EVENT_CONFIG_CHANGED, 10)
+	require.NoError(t, err)
+	var changes int
+	for _, event := range events {
+		if event.Subject == "moderator:7" {
+			changes++
+		}
+	}
+	assert.Equal(t, 2, changes, "granted and revoked")
+
+	// Promoted again, access comes back once the cached answer expires
+	transport.members[7] = TELEGRAM_MEMBER_CREATOR
+	stored.VerifiedAt = time.Now().Add(-MODERATOR_VERIFY_TTL)
+	require.NoError(t, db.SaveModerator(stored))
+	assert.True(t, bot.isModeratorSender(moderator))
+}
+
+func TestModerators_DisabledTrustsAdminChats(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	bot := newTestBotController(&fakeTelegramTransport{}, setupTestDB(t))
+	assert.True(t, bot.isModeratorSender(newTestUpdate(1, "/moderators")))
+}
+
+// slowMemberTransport holds getChatMember answers until released
+type slowMemberTransport struct {
+	*fakeTelegramTransport
+	release chan struct{}
+}
+
+func (t slowMemberTransport) GetChatMember(chatID int64, userID int64) (TelegramChatMember, error) {
+	<-t.release
+	return t.fakeTelegramTransport.GetChatMember(chatID, userID)
+}
+
+func TestModerators_VerificationDoesNotBlockPolling(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	t.Setenv(ENV_MODERATOR_GROUP_ID, "-500")
+	fake := &fakeTelegramTransport{members: map[int64]string{7: TELEGRAM_MEMBER_ADMINISTRATOR}}
+	transport := slowMemberTransport{fakeTelegramTransport: fake, release: make(chan struct{})}
+	bot := newTestBotController(transport, setupTestDB(t))
+	bot.chatIDs[1] = true
+
+	moderator := newTestUpdate(1, "/help")
+	moderator.Message.From.ID = 7
+	stranger := newTestUpdate(1, "/help")
+	stranger.Message.From.ID = 8
+	handled := make(chan struct{})
+	go func() {
+		bot.handleUpdate(moderator)
+		bot.handleUpdate(stranger)
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("the update loop waited for getChatMember")
+	}
+	assert.Empty(t, fake.sentMessages())
+
+	close(transport.release)
+	require.Eventually(t, func() bool {
+		var denied, help int
+		for _, msg := range fake.sentMessages() {
+			switch {
+			case msg.Text == MODERATOR_ACCESS_DENIED:
+				denied++
+			case strings.Contains(msg.Text, "Available Commands"):
+				help++
+			}
+		}
+		return denied == 1 && help == 1
+	}, 2*time.Second, 10*time.Millisecond, "the stranger is denied, the moderator gets the help")
+}
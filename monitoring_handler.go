@@ -7,24 +7,32 @@ import (
 	"time"
 )
 
-// MonitoringHandler handles monitoring for new messages in community
-func MonitoringHandler(twitterApi *twitterapi.TwitterAPIService, newMessageCh chan twitterapi.NewMessage, dbService *DatabaseService) {
-	defer close(newMessageCh)
-
-	MonitoringIncremental(twitterApi, newMessageCh, dbService)
+// MonitoringHandler handles monitoring for new messages in one community until stop is closed.
+// Every community runs its own handler, they all feed the same newMessageCh.
+func MonitoringHandler(twitterApi TwitterAPI, newMessageCh chan twitterapi.NewMessage, dbService *DatabaseService, warRoom *warRoomState, watchdog *ingestionWatchdog, community CommunityModel, stop <-chan struct{}) {
+	watchdog.beat(community, 0)
+	if os.Getenv(ENV_MONITORING_METHOD) == MONITORING_METHOD_STREAM {
+		MonitoringStream(twitterApi, newMessageCh, dbService, warRoom, watchdog, community, stop)
+		return
+	}
+	MonitoringIncremental(twitterApi, newMessageCh, dbService, warRoom, watchdog, community, stop)
 }
 
-func MonitoringIncremental(twitterApi *twitterapi.TwitterAPIService, newMessageCh chan twitterapi.NewMessage, dbService *DatabaseService) {
+func MonitoringIncremental(twitterApi TwitterAPI, newMessageCh chan twitterapi.NewMessage, dbService *DatabaseService, warRoom *warRoomState, watchdog *ingestionWatchdog, community CommunityModel, stop <-chan struct{}) {
 	// Local storage exists messages, with reply counts
 	tweetsExistsStorage := map[string]int{}
 
 	for {
-		time.Sleep(60 * time.Second)
+		// Polls faster while the war room is active
+		if !sleepOrStop(warRoom.pollInterval(), stop) {
+			return
+		}
 		tweetsResponse, err := twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-			CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+			CommunityID: community.ID,
 		})
 		if err != nil {
 			log.Println(err)
+			watchdog.beat(community, 0)
 			continue
 		}
 
@@ -34,78 +42,139 @@ func MonitoringIncremental(twitterApi *twitterapi.TwitterAPIService, newMessageC
 
 			// Initialize mapping from 3 pages for monitoring
 			log.Println("Initializing monitoring mapping from 3 pages...")
-			InitializeMonitoringMapping(twitterApi, tweetsExistsStorage)
+			InitializeMonitoringMapping(twitterApi, community.ID, tweetsExistsStorage)
 
 			log.Printf("Monitoring initialization completed with %d tweets in storage", len(tweetsExistsStorage))
+			watchdog.beat(community, 0)
 			continue
 		}
 
 		// Start monitoring
+		newTweets := 0
 		for _, tweet := range tweetsResponse.Tweets {
-			// Store tweet and user data
-			storeTweetAndUser(dbService, tweet)
-
-			SendIfNotExistsTweetToChannel(tweet, newMessageCh, tweetsExistsStorage, twitterapi.Tweet{}, twitterapi.Tweet{})
-			if tweet.ReplyCount > tweetsExistsStorage[tweet.Id] {
-				tweetsExistsStorage[tweet.Id] = tweet.ReplyCount
-				// Last page is enough for monitoring
-				tweetRepliesResponse, err := twitterApi.GetTweetReplies(twitterapi.TweetRepliesRequest{
-					TweetID: tweet.Id,
-				})
-				if err != nil {
-					// First step we don't handle any errors, debug is enough
-					log.Printf("error on gettings replies for tweet, ERR: %s, TWEET ID: %s, TEXT: %s, AUTHOR: %s", err, tweet.Id, tweet.Text, tweet.Author.Name)
-					continue
-				}
+			_, known := tweetsExistsStorage[tweet.Id]
+			processCommunityTweet(twitterApi, dbService, community, tweet, newMessageCh, tweetsExistsStorage)
+			if !known {
+				newTweets++
+			}
+		}
+		watchdog.beat(community, newTweets)
+	}
+}
+
+// MonitoringStream delivers new community posts within seconds: it polls incrementally from the
+// newest tweet seen and adapts the wait between polls, short while the community is active and
+// backing off to the regular poll interval when it is quiet.
+func MonitoringStream(twitterApi TwitterAPI, newMessageCh chan twitterapi.NewMessage, dbService *DatabaseService, warRoom *warRoomState, watchdog *ingestionWatchdog, community CommunityModel, stop <-chan struct{}) {
+	tweetsExistsStorage := map[string]int{}
+	log.Println("Initializing monitoring mapping from 3 pages...")
+	InitializeMonitoringMapping(twitterApi, community.ID, tweetsExistsStorage)
+	log.Printf("📡 Stream monitoring of %s started with %d tweets in storage", community.Ticker, len(tweetsExistsStorage))
 
-				for _, tweetReply := range tweetRepliesResponse.Tweets {
-					// Store reply tweet and user data
-					storeTweetAndUser(dbService, tweetReply)
-
-					// Check if this reply is responding to another reply (not the main post)
-					var parentTweet, grandParentTweet twitterapi.Tweet
-					if tweetReply.InReplyToId != tweet.Id {
-						// This is a reply to another reply, not to the main post
-						log.Printf("Reply %s is responding to another reply %s, not main post %s", tweetReply.Id, tweetReply.InReplyToId, tweet.Id)
-
-						// Try to find the immediate parent in database
-						if dbTweet, err := dbService.GetTweet(tweetReply.InReplyToId); err == nil {
-							if dbUser, err := dbService.GetUser(dbTweet.UserID); err == nil {
-								parentTweet = twitterapi.Tweet{
-									Id:   dbTweet.ID,
-									Text: dbTweet.Text,
-									Author: twitterapi.Author{
-										Id:       dbUser.ID,
-										UserName: dbUser.Username,
-										Name:     dbUser.Name,
-									},
-								}
-								log.Printf("'%s', Found parent reply in database: %s by %s", tweetReply.Text, parentTweet.Text, parentTweet.Author.UserName)
-
-								// Set the main post as grandparent
-								grandParentTweet = tweet
-							}
-						} else {
-							log.Printf("Parent reply %s not found in database", tweetReply.InReplyToId)
-							// Fallback: use main post as parent
-							parentTweet = tweet
+	stream := twitterapi.NewCommunityStream(twitterApi, community.ID, STREAM_MAX_PAGES)
+	interval := twitterapi.NewAdaptiveInterval(STREAM_MIN_INTERVAL, MONITORING_POLL_INTERVAL)
+	wait := interval.Min
+	for {
+		// The war room interval caps the wait, quiet periods never poll slower than it
+		if !sleepOrStop(min(wait, warRoom.pollInterval()), stop) {
+			return
+		}
+		tweets, newTweets, err := stream.Poll()
+		watchdog.beat(community, newTweets)
+		if err != nil {
+			log.Println(err)
+			wait = interval.Next(false)
+			continue
+		}
+
+		changed := 0
+		for _, tweet := range tweets {
+			if processCommunityTweet(twitterApi, dbService, community, tweet, newMessageCh, tweetsExistsStorage) {
+				changed++
+			}
+		}
+		wait = interval.Next(changed > 0)
+		if changed > 0 {
+			log.Printf("📡 %d %s community posts new or with new replies, next poll in %s", changed, community.Ticker, wait)
+		}
+	}
+}
+
+// processCommunityTweet stores a community post, sends it to analysis if it is new and fetches its
+// replies when the reply count grew. It reports whether the post or its replies changed.
+func processCommunityTweet(twitterApi TwitterAPI, dbService *DatabaseService, community CommunityModel, tweet twitterapi.Tweet, newMessageCh chan twitterapi.NewMessage, tweetsExistsStorage map[string]int) bool {
+	_, known := tweetsExistsStorage[tweet.Id]
+	// Filtered posts are neither stored nor analyzed, their replies still are
+	if ingestTweet(dbService, tweet, known) {
+		storeTweetAndUser(dbService, tweet, community.ID)
+		SendIfNotExistsTweetToChannel(tweet, newMessageCh, tweetsExistsStorage, twitterapi.Tweet{}, twitterapi.Tweet{}, community)
+	}
+	activity := !known || tweet.ReplyCount > tweetsExistsStorage[tweet.Id]
+	if tweet.ReplyCount > tweetsExistsStorage[tweet.Id] {
+		tweetsExistsStorage[tweet.Id] = tweet.ReplyCount
+		// Last page is enough for monitoring
+		tweetRepliesResponse, err := twitterApi.GetTweetReplies(twitterapi.TweetRepliesRequest{
+			TweetID: tweet.Id,
+		})
+		if err != nil {
+			// First step we don't handle any errors, debug is enough
+			log.Printf("error on gettings replies for tweet, ERR: %s, TWEET ID: %s, TEXT: %s, AUTHOR: %s", err, tweet.Id, tweet.Text, tweet.Author.Name)
+			return activity
+		}
+
+		for _, tweetReply := range tweetRepliesResponse.Tweets {
+			_, replyKnown := tweetsExistsStorage[tweetReply.Id]
+			if !ingestTweet(dbService, tweetReply, replyKnown) {
+				tweetsExistsStorage[tweetReply.Id] = tweetReply.ReplyCount
+				continue
+			}
+			// Store reply tweet and user data
+			storeTweetAndUser(dbService, tweetReply, community.ID)
+
+			// Check if this reply is responding to another reply (not the main post)
+			var parentTweet, grandParentTweet twitterapi.Tweet
+			if tweetReply.InReplyToId != tweet.Id {
+				// This is a reply to another reply, not to the main post
+				log.Printf("Reply %s is responding to another reply %s, not main post %s", tweetReply.Id, tweetReply.InReplyToId, tweet.Id)
+
+				// Try to find the immediate parent in database
+				if dbTweet, err := dbService.GetTweet(tweetReply.InReplyToId); err == nil {
+					if dbUser, err := dbService.GetUser(dbTweet.UserID); err == nil {
+						parentTweet = twitterapi.Tweet{
+							Id:   dbTweet.ID,
+							Text: dbTweet.Text,
+							Author: twitterapi.Author{
+								Id:       dbUser.ID,
+								UserName: dbUser.Username,
+								Name:     dbUser.Name,
+							},
 						}
-					} else {
-						log.Printf("Reply %s is responding to main post %s", tweetReply.Id, tweet.Id)
-						// This is a direct reply to the main post
-						parentTweet = tweet
-					}
+						log.Printf("'%s', Found parent reply in database: %s by %s", tweetReply.Text, parentTweet.Text, parentTweet.Author.UserName)
 
-					SendIfNotExistsTweetToChannel(tweetReply, newMessageCh, tweetsExistsStorage, parentTweet, grandParentTweet)
-					tweetsExistsStorage[tweetReply.Id] = tweetReply.ReplyCount
+						// Set the main post as grandparent
+						grandParentTweet = tweet
+					}
+				} else {
+					log.Printf("Parent reply %s not found in database", tweetReply.InReplyToId)
+					// Fallback: use main post as parent
+					parentTweet = tweet
 				}
+			} else {
+				log.Printf("Reply %s is responding to main post %s", tweetReply.Id, tweet.Id)
+				// This is a direct reply to the main post
+				parentTweet = tweet
 			}
-			tweetsExistsStorage[tweet.Id] = tweet.ReplyCount
+
+			SendIfNotExistsTweetToChannel(tweetReply, newMessageCh, tweetsExistsStorage, parentTweet, grandParentTweet, community)
+			tweetsExistsStorage[tweetReply.Id] = tweetReply.ReplyCount
 		}
 	}
+	tweetsExistsStorage[tweet.Id] = tweet.ReplyCount
+	return activity
 }
 
-func storeTweetAndUser(dbService *DatabaseService, tweet twitterapi.Tweet) {
+// storeTweetAndUser saves a post or reply of the given community
+func storeTweetAndUser(dbService *DatabaseService, tweet twitterapi.Tweet, communityID string) {
 	// Parse created_at time
 	createdAt, err := time.Parse(time.RFC1123, tweet.CreatedAt)
 	if err != nil {
@@ -143,6 +212,7 @@ func storeTweetAndUser(dbService *DatabaseService, tweet twitterapi.Tweet) {
 		SourceType:    TWEET_SOURCE_COMMUNITY,
 		TickerMention: "",
 		SearchQuery:   "",
+		CommunityID:   communityID,
 	}
 
 	err = dbService.SaveTweet(tweetModel)
@@ -197,7 +267,7 @@ func storeTweetAndUserWithSource(dbService *DatabaseService, tweet twitterapi.Tw
 	}
 }
 
-func InitialCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *DatabaseService) {
+func InitialCommunityLoad(twitterApi TwitterAPI, dbService *DatabaseService, communityID string) {
 	const MAX_PAGES = 3
 	cursor := ""
 	totalPosts := 0
@@ -211,11 +281,11 @@ func InitialCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *D
 
 		if cursor == "" {
 			tweetsResponse, err = twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-				CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+				CommunityID: communityID,
 			})
 		} else {
 			tweetsResponse, err = twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-				CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+				CommunityID: communityID,
 				Cursor:      cursor,
 			})
 		}
@@ -235,11 +305,11 @@ func InitialCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *D
 		// Process each main post
 		for _, mainTweet := range tweetsResponse.Tweets {
 			// Save main post
-			storeTweetAndUserWithSource(dbService, mainTweet, TWEET_SOURCE_COMMUNITY, "", "")
+			storeTweetAndUser(dbService, mainTweet, communityID)
 			totalPosts++
 
 			// Get all replies for this post recursively
-			repliesCount := LoadAllRepliesRecursive(twitterApi, dbService, mainTweet.Id, 0)
+			repliesCount := LoadAllRepliesRecursive(twitterApi, dbService, communityID, mainTweet.Id, 0)
 			totalReplies += repliesCount
 
 			log.Printf("Loaded post %s with %d replies", mainTweet.Id, repliesCount)
@@ -256,7 +326,7 @@ func InitialCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *D
 	log.Printf("Initial community load completed: %d posts, %d replies loaded", totalPosts, totalReplies)
 }
 
-func LoadAllRepliesRecursive(twitterApi *twitterapi.TwitterAPIService, dbService *DatabaseService, tweetID string, depth int) int {
+func LoadAllRepliesRecursive(twitterApi TwitterAPI, dbService *DatabaseService, communityID string, tweetID string, depth int) int {
 	if depth > 10 { // Prevent infinite recursion
 		log.Printf("Max depth reached for tweet %s", tweetID)
 		return 0
@@ -274,17 +344,17 @@ func LoadAllRepliesRecursive(twitterApi *twitterapi.TwitterAPIService, dbService
 
 	for _, reply := range repliesResponse.Tweets {
 		// Save reply
-		storeTweetAndUserWithSource(dbService, reply, TWEET_SOURCE_COMMUNITY, "", "")
+		storeTweetAndUser(dbService, reply, communityID)
 
 		// Recursively load replies to this reply
-		nestedReplies := LoadAllRepliesRecursive(twitterApi, dbService, reply.Id, depth+1)
+		nestedReplies := LoadAllRepliesRecursive(twitterApi, dbService, communityID, reply.Id, depth+1)
 		totalReplies += nestedReplies
 	}
 
 	return totalReplies
 }
 
-func FullCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *DatabaseService) {
+func FullCommunityLoad(twitterApi TwitterAPI, dbService *DatabaseService, communityID string) {
 	cursor := ""
 	totalPosts := 0
 	totalReplies := 0
@@ -302,11 +372,11 @@ func FullCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *Data
 
 		if cursor == "" {
 			tweetsResponse, err = twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-				CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+				CommunityID: communityID,
 			})
 		} else {
 			tweetsResponse, err = twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-				CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+				CommunityID: communityID,
 				Cursor:      cursor,
 			})
 		}
@@ -326,11 +396,11 @@ func FullCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *Data
 		// Process each main post
 		for _, mainTweet := range tweetsResponse.Tweets {
 			// Save main post
-			storeTweetAndUserWithSource(dbService, mainTweet, TWEET_SOURCE_COMMUNITY, "", "")
+			storeTweetAndUser(dbService, mainTweet, communityID)
 			totalPosts++
 
 			// Get all replies for this post recursively
-			repliesCount := LoadAllRepliesRecursive(twitterApi, dbService, mainTweet.Id, 0)
+			repliesCount := LoadAllRepliesRecursive(twitterApi, dbService, communityID, mainTweet.Id, 0)
 			totalReplies += repliesCount
 
 			log.Printf("FULL load: saved post %s with %d replies", mainTweet.Id, repliesCount)
@@ -348,7 +418,7 @@ func FullCommunityLoad(twitterApi *twitterapi.TwitterAPIService, dbService *Data
 }
 
 // InitializeMonitoringMapping initializes the monitoring storage with tweets from 3 pages (for tracking new messages)
-func InitializeMonitoringMapping(twitterApi *twitterapi.TwitterAPIService, tweetsExistsStorage map[string]int) {
+func InitializeMonitoringMapping(twitterApi TwitterAPI, communityID string, tweetsExistsStorage map[string]int) {
 	cursor := ""
 	pageCount := 0
 	maxPages := 3
@@ -359,11 +429,11 @@ func InitializeMonitoringMapping(twitterApi *twitterapi.TwitterAPIService, tweet
 
 		if cursor == "" {
 			tweetsResponse, err = twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-				CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+				CommunityID: communityID,
 			})
 		} else {
 			tweetsResponse, err = twitterApi.GetCommunityTweets(twitterapi.CommunityTweetsRequest{
-				CommunityID: os.Getenv(ENV_DEMO_COMMUNITY_ID),
+				CommunityID: communityID,
 				Cursor:      cursor,
 			})
 		}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+const (
+	FUD_NETWORK_ALERT_TOP   = 3  // connected FUD accounts named in an alert
+	FUD_NETWORK_COMMAND_TOP = 15 // connected FUD accounts listed by /network_
+)
+
+// FUDConnection is how a suspect connects to one known FUD account in the stored follower graph
+type FUDConnection struct {
+	UserID            string
+	Username          string
+	FUDType           string
+	FollowsSuspect    bool  // the FUD account follows the suspect
+	FollowedBySuspect bool  // the suspect follows the FUD account
+	SharedFollowers   int64 // accounts following both
+	SharedFollowings  int64 // accounts both follow
+}
+
+func (c FUDConnection) direct() bool {
+	return c.FollowsSuspect || c.FollowedBySuspect
+}
+
+func (c FUDConnection) shared() int64 {
+	return c.SharedFollowers + c.SharedFollowings
+}
+
+// sortFUDConnections puts direct links first, then the largest overlaps
+func sortFUDConnections(connections []FUDConnection) {
+	sort.Slice(connections, func(i, j int) bool {
+		if connections[i].direct() != connections[j].direct() {
+			return connections[i].direct()
+		}
+		if connections[i].shared() != connections[j].shared() {
+			return connections[i].shared() > connections[j].shared()
+		}
+		return connections[i].Username < connections[j].Username
+	})
+}
+
+// describe lists the links of a connection, e.g. "mutual follow, 12 shared followers"
+func (c FUDConnection) describe() string {
+	var links []string
+	switch {
+	case c.FollowsSuspect && c.FollowedBySuspect:
+		links = append(links, "mutual follow")
+	case c.FollowsSuspect:
+		links = append(links, "follows them")
+	case c.FollowedBySuspect:
+		links = append(links, "followed by them")
+	}
+	if c.SharedFollowers > 0 {
+		links = append(links, fmt.Sprintf("%d shared followers", c.SharedFollowers))
+	}
+	if c.SharedFollowings > 0 {
+		links = append(links, fmt.Sprintf("%d shared followings", c.SharedFollowings))
+	}
+	return strings.Join(links, ", ")
+}
+
+// summarizeFUDConnections names the strongest connections for an alert: "@a (mutual follow)"
+func summarizeFUDConnections(connections []FUDConnection, limit int) []string {
+	var summary []string
+	for _, connection := range connections[:min(limit, len(connections))] {
+		summary = append(summary, fmt.Sprintf("@%s (%s)", connection.Username, connection.describe()))
+	}
+	if len(connections) > limit {
+		summary = append(summary, fmt.Sprintf("+%d more", len(connections)-limit))
+	}
+	return summary
+}
+
+// prepareFUDNetworkMessage tells the second step how the user connects to known FUD accounts
+func prepareFUDNetworkMessage(connections []FUDConnection) ClaudeMessage {
+	return ClaudeMessage{
+		Role:    ROLE_USER,
+		Content: fmt.Sprintf("FUD NETWORK SIGNAL: the user is connected to %d accounts already detected as FUD: %s. Follow links and a large overlap of followers with FUD accounts point to a coordinated group, a few shared followers alone are common in a small community.", len(connections), strings.Join(summarizeFUDConnections(connections, FUD_NETWORK_COMMAND_TOP), "; ")),
+	}
+}
+
+// handleNetworkCommand shows how a user connects to known FUD accounts: /network_username
+func (b *BotController) handleNetworkCommand(chatID int64, command string) {
+	username, _ := b.resolveTwitterReference(strings.TrimPrefix(command, "/network_"))
+
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ User @%s not found in database", html.EscapeString(username)))
+		return
+	}
+
+	followers, err := b.dbService.GetUserFollowers(user.ID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading network of @%s: %v", user.Username, err))
+		return
+	}
+	followings, err := b.dbService.GetUserFollowings(user.ID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading network of @%s: %v", user.Username, err))
+		return
+	}
+	connections, err := b.dbService.GetFUDConnections(user.ID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading network of @%s: %v", user.Username, err))
+		return
+	}
+	if len(followers) == 0 && len(followings) == 0 && len(connections) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No stored followers or followings for @%s. Run /analyze_%s first.", user.Username, user.Username))
+		return
+	}
+
+	direct := 0
+	for _, connection := range connections {
+		if connection.direct() {
+			direct++
+		}
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🕸 <b>Network of @%s</b>\n\n", user.Username))
+	message.WriteString(fmt.Sprintf("👥 Stored: %d followers, %d followings\n", len(followers), len(followings)))
+	if len(connections) == 0 {
+		message.WriteString("✅ No links to known FUD accounts\n")
+	} else {
+		message.WriteString(fmt.Sprintf("🚨 Connected to <b>%d</b> known FUD accounts, %d directly\n\n", len(connections), direct))
+		for _, connection := range connections[:min(FUD_NETWORK_COMMAND_TOP, len(connections))] {
+			message.WriteString(fmt.Sprintf("• @%s <i>%s</i> — %s\n", connection.Username, html.EscapeString(connection.FUDType), connection.describe()))
+		}
+		if len(connections) > FUD_NETWORK_COMMAND_TOP {
+			message.WriteString(fmt.Sprintf("… and %d more\n", len(connections)-FUD_NETWORK_COMMAND_TOP))
+		}
+	}
+	message.WriteString(fmt.Sprintf("\n📎 /graph_%s - Export the full graph", user.Username))
+	b.SendMessage(chatID, message.String())
+}
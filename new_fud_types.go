@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// normalizeFUDType is the form FUD types are tracked in, the model is not consistent about case
+func normalizeFUDType(fudType string) string {
+	return strings.ToLower(strings.TrimSpace(fudType))
+}
+
+// isTrackedFUDType tells whether a type names an attack category, rather than no FUD or a placeholder of the bot
+func isTrackedFUDType(fudType string) bool {
+	switch normalizeFUDType(fudType) {
+	case "", "none", "unknown", "n/a", HEURISTIC_FUD_TYPE:
+		return false
+	}
+	return !strings.HasPrefix(normalizeFUDType(fudType), "manual_analysis")
+}
+
+// seedFUDTypes records the types of past verdicts as seen, so only types new to the deployment are announced
+func seedFUDTypes(tx *gorm.DB) error {
+	var fudTypes []string
+	if err := tx.Model(&FUDUserModel{}).Unscoped().Distinct("fud_type").Pluck("fud_type", &fudTypes).Error; err != nil {
+		return err
+	}
+	var cachedTypes []string
+	if err := tx.Model(&CachedAnalysisModel{}).Unscoped().Where("is_fud_user = ?", true).Distinct("fud_type").Pluck("fud_type", &cachedTypes).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	seen := make(map[string]bool)
+	for _, fudType := range append(fudTypes, cachedTypes...) {
+		fudType = normalizeFUDType(fudType)
+		if !isTrackedFUDType(fudType) || seen[fudType] {
+			continue
+		}
+		seen[fudType] = true
+		if err := tx.Create(&FUDTypeModel{Type: fudType, FirstSeenAt: now, Sightings: 1, LastSeenAt: now}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatNewFUDTypeAlert is the admin notice about the first verdict of a FUD type
+func formatNewFUDTypeAlert(alert FUDAlertNotification) string {
+	nf := NewNotificationFormatter()
+	var message strings.Builder
+	message.WriteString("🆕 <b>New attack category observed</b>\n\n")
+	message.WriteString(fmt.Sprintf("%s <b>Type:</b> %s (<code>%s</code>)\n", nf.getFUDTypeEmoji(alert.NewFUDType), nf.formatFUDType(alert.NewFUDType), html.EscapeString(alert.NewFUDType)))
+	message.WriteString(fmt.Sprintf("👤 <b>First seen from:</b> @%s\n", html.EscapeString(alert.FUDUsername)))
+	if alert.FUDMessageID != "" {
+		message.WriteString(fmt.Sprintf("🔗 <a href=\"https://twitter.com/%s/status/%s\">Tweet</a>\n", alert.FUDUsername, alert.FUDMessageID))
+	}
+	if alert.DecisionReason != "" {
+		message.WriteString(fmt.Sprintf("💭 %s\n", nf.escapeTruncated(alert.DecisionReason, 300)))
+	}
+	message.WriteString("\n📋 The model has not reported this type before. Review the FUD taxonomy in the prompts and the response playbooks for it.")
+	return message.String()
+}
+
+// notifyNewFUDType sends the admin chats the notice about a FUD type seen for the first time
+func (b *BotController) notifyNewFUDType(alert FUDAlertNotification) {
+	message := formatNewFUDTypeAlert(alert)
+	for _, chatID := range adminChatIDs() {
+		err := b.SendMessage(chatID, message)
+		if err != nil {
+			logFor("notifications").Error("failed to send new FUD type notice", "chat_id", chatID, "fud_type", alert.NewFUDType, "error", err)
+		}
+	}
+}
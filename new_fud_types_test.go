@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordFUDTypeSighting(t *testing.T) {
+	db := setupTestDB(t)
+
+	added, err := db.RecordFUDTypeSighting("Fake_Partnership", "u1", "first", "t1")
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = db.RecordFUDTypeSighting("fake_partnership", "u2", "second", "t2")
+	require.NoError(t, err)
+	assert.False(t, added, "types are compared case-insensitively")
+
+	var stored FUDTypeModel
+	require.NoError(t, db.db.First(&stored, "type = ?", "fake_partnership").Error)
+	assert.Equal(t, "first", stored.FirstUsername)
+	assert.Equal(t, 2, stored.Sightings)
+}
+
+func TestSeedFUDTypes(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u1", Username: "old", FUDType: "direct_attack"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u2", Username: "guess", FUDType: HEURISTIC_FUD_TYPE}))
+
+	require.NoError(t, seedFUDTypes(db.db))
+
+	added, err := db.RecordFUDTypeSighting("direct_attack", "u3", "new", "t3")
+	require.NoError(t, err)
+	assert.False(t, added, "types of past verdicts are not announced")
+	var count int64
+	db.db.Model(&FUDTypeModel{}).Where("type = ?", HEURISTIC_FUD_TYPE).Count(&count)
+	assert.Zero(t, count, "placeholders are not attack categories")
+}
+
+func TestNewFUDTypeAlert(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	claudeApi := newMockClaudeAPI(`"is_fud_user":true,"fud_type":"Deepfake_Endorsement","fud_probability":0.9,"user_risk_level":"high","decision_reason":"fake video of the founder"}`, nil)
+
+	analyze := func(userID, username, tweetID string) FUDAlertNotification {
+		message := twitterapi.NewMessage{TweetID: tweetID, Text: "the founder admitted it on video"}
+		message.Author.ID, message.Author.UserName = userID, username
+		notificationCh := make(chan FUDAlertNotification, 1)
+		SecondStepHandler(message, notificationCh, &mockTwitterAPI{}, claudeApi, nil, &mockUserStatusTracker{}, "GRUT", db)
+		require.Len(t, notificationCh, 1)
+		return <-notificationCh
+	}
+
+	first := analyze("u1", "faker", "t1")
+	assert.Equal(t, "deepfake_endorsement", first.NewFUDType)
+	second := analyze("u2", "copycat", "t2")
+	assert.Empty(t, second.NewFUDType, "only the first sighting is announced")
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	notificationCh := make(chan FUDAlertNotification, 2)
+	notificationCh <- first
+	notificationCh <- second
+	close(notificationCh)
+	NotificationHandler(notificationCh, bot)
+
+	var notices []string
+	for _, sent := range transport.sentMessages() {
+		if sent.ChatID == 1 {
+			notices = append(notices, sent.Text)
+		}
+	}
+	require.Len(t, notices, 1)
+	assert.Contains(t, notices[0], "New attack category observed")
+	assert.Contains(t, notices[0], "Deepfake Endorsement")
+	assert.Contains(t, notices[0], "@faker")
+	assert.Contains(t, notices[0], "https://twitter.com/faker/status/t1")
+	assert.Contains(t, notices[0], "Review the FUD taxonomy")
+}
@@ -2,12 +2,24 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type NotificationFormatter struct{}
 
+// ALERT_FORMAT_VERSION is bumped when the alert templates change, /reformat_alerts re-renders the stored alerts
+const ALERT_FORMAT_VERSION = 1
+
+// Alert verbosity profiles selectable per chat with /verbosity
+const (
+	VERBOSITY_COMPACT  = "compact"
+	VERBOSITY_NORMAL   = "normal"
+	VERBOSITY_DETAILED = "detailed"
+)
+
 type FUDAlertNotification struct {
 	FUDMessageID      string   `json:"fud_message_id"`
 	FUDUserID         string   `json:"fud_user_id"`
@@ -30,8 +42,32 @@ type FUDAlertNotification struct {
 	GrandParentPostText   string `json:"grandparent_post_text"`
 	GrandParentPostAuthor string `json:"grandparent_post_author"`
 	HasThreadContext      bool   `json:"has_thread_context"`
+	// Configured competitor cashtags and accounts the user promotes
+	PromotedCompetitors []string `json:"promoted_competitors,omitempty"`
+	// Strongest links to known FUD accounts in the follower graph
+	FUDConnections []string `json:"fud_connections,omitempty"`
+	// What partner deployments reported about the account or the narrative
+	FederationMatches []string `json:"federation_matches,omitempty"`
+	// Community blocklist the account is listed on, the alert was sent without an AI analysis
+	BlocklistSource string `json:"blocklist_source,omitempty"`
+	// Earlier alerts about the user and their human verdicts, nil when not looked up
+	PriorAlerts *PriorAlertHistory `json:"prior_alerts,omitempty"`
+	// System prompt version that produced the analysis, e.g. "second v3"
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// FUD type the model reported for the first time, admins are notified about it
+	NewFUDType string `json:"new_fud_type,omitempty"`
 	// Target chat for notification (optional)
-	TargetChatID int64 `json:"target_chat_id,omitempty"` // If set, send only to this chat
+	TargetChatID     int64  `json:"target_chat_id,omitempty"`     // If set, send only to this chat
+	DiscordChannelID string `json:"discord_channel_id,omitempty"` // If set, send only to this Discord channel
+	// External tool that submitted the analysis through the signals webhook
+	RequestSource string `json:"request_source,omitempty"`
+	RequestReason string `json:"request_reason,omitempty"`
+	CommunityID   string `json:"community_id,omitempty"`
+	Ticker        string `json:"ticker,omitempty"`
+	// The author is on a watchlist, the watching chats get a summary of the message
+	Watched bool `json:"watched,omitempty"`
+	// A watched message found clean, only the watching chats are told
+	WatchOnly bool `json:"watch_only,omitempty"`
 }
 
 func NewNotificationFormatter() *NotificationFormatter {
@@ -43,35 +79,7 @@ func (nf *NotificationFormatter) FormatForTelegram(alert FUDAlertNotification) s
 	typeEmoji := nf.getFUDTypeEmoji(alert.FUDType)
 
 	// Build context section if available
-	contextSection := ""
-	if alert.HasThreadContext {
-		if alert.GrandParentPostText != "" {
-			// Show grandparent -> parent -> current structure
-			contextSection = fmt.Sprintf(`
-
-📄 <b>Thread Context:</b>
-<b>Root:</b> <i>%s</i> - @%s
-<b>Reply:</b> <i>%s</i> - @%s`,
-				nf.truncateText(alert.GrandParentPostText, 150),
-				alert.GrandParentPostAuthor,
-				nf.truncateText(alert.ParentPostText, 150),
-				alert.ParentPostAuthor)
-		} else if alert.OriginalPostText != "" || alert.ParentPostText != "" {
-			// Show parent -> current structure
-			postText := alert.OriginalPostText
-			postAuthor := alert.OriginalPostAuthor
-			if postText == "" {
-				postText = alert.ParentPostText
-				postAuthor = alert.ParentPostAuthor
-			}
-			contextSection = fmt.Sprintf(`
-
-📄 <b>Original Post Context:</b>
-<i>%s</i> - @%s`,
-				nf.truncateText(postText, 200),
-				postAuthor)
-		}
-	}
+	contextSection := nf.formatThreadContext(alert)
 
 	// Determine if this is a FUD alert or clean analysis
 	isFUDAlert := !strings.Contains(alert.FUDType, "manual_analysis_clean") && alert.FUDType != "none"
@@ -79,11 +87,24 @@ func (nf *NotificationFormatter) FormatForTelegram(alert FUDAlertNotification) s
 	var alertTitle, typeSection string
 	if isFUDAlert {
 		alertTitle = fmt.Sprintf("%s <b>FUD ALERT - %s SEVERITY</b>", severityEmoji, strings.ToUpper(alert.AlertSeverity))
-		typeSection = fmt.Sprintf("%s <b>Attack Type:</b> %s\n👤 <b>User Profile:</b> %s", typeEmoji, nf.formatFUDType(alert.FUDType), alert.UserSummary)
+		if alert.FUDType == HEURISTIC_FUD_TYPE {
+			alertTitle = fmt.Sprintf("%s <b>HEURISTIC FUD ALERT - %s SEVERITY</b>\n<i>AI analysis unavailable, keyword and account signals only</i>", severityEmoji, strings.ToUpper(alert.AlertSeverity))
+		}
+		if alert.BlocklistSource != "" {
+			alertTitle = fmt.Sprintf("%s <b>BLOCKLISTED ACCOUNT - %s SEVERITY</b>\n<i>First contact of an account on a community blocklist, not analyzed yet</i>", severityEmoji, strings.ToUpper(alert.AlertSeverity))
+		}
+		typeSection = fmt.Sprintf("%s <b>Attack Type:</b> %s\n👤 <b>User Profile:</b> %s", typeEmoji, nf.formatFUDType(alert.FUDType), nf.escape(alert.UserSummary))
+		typeSection += nf.formatPromotions(alert)
+		typeSection += nf.formatFUDConnections(alert)
+		typeSection += nf.formatFederationMatches(alert)
+		typeSection += nf.formatBlocklist(alert)
 	} else {
 		alertTitle = fmt.Sprintf("✅ <b>ANALYSIS COMPLETE - USER CLEAN</b>")
-		typeSection = fmt.Sprintf("👤 <b>User Type:</b> %s", alert.UserSummary)
+		typeSection = fmt.Sprintf("👤 <b>User Type:</b> %s", nf.escape(alert.UserSummary))
 	}
+	typeSection += nf.formatRequestSource(alert)
+	typeSection += nf.formatCommunity(alert)
+	typeSection += nf.formatPriorAlerts(alert)
 
 	message := fmt.Sprintf(`%s
 
@@ -110,8 +131,8 @@ func (nf *NotificationFormatter) FormatForTelegram(alert FUDAlertNotification) s
 		typeSection,
 		alert.FUDUsername,
 		alert.FUDProbability*100,
-		alert.RecommendedAction,
-		nf.truncateText(alert.MessagePreview, 500),
+		nf.escape(alert.RecommendedAction),
+		nf.escapeTruncated(alert.MessagePreview, 500),
 		contextSection,
 		alert.FUDUsername, alert.FUDMessageID,
 		alert.ThreadID,
@@ -122,6 +143,93 @@ func (nf *NotificationFormatter) FormatForTelegram(alert FUDAlertNotification) s
 	return message
 }
 
+// formatPromotions renders the competitor promotion signal as an extra line, if any
+func (nf *NotificationFormatter) formatPromotions(alert FUDAlertNotification) string {
+	if len(alert.PromotedCompetitors) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n🏷 <b>Promotes:</b> %s", nf.escape(strings.Join(alert.PromotedCompetitors, ", ")))
+}
+
+// formatFUDConnections renders the links to known FUD accounts as an extra line, if any
+func (nf *NotificationFormatter) formatFUDConnections(alert FUDAlertNotification) string {
+	if len(alert.FUDConnections) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n🕸 <b>Linked FUD:</b> %s", nf.escape(strings.Join(alert.FUDConnections, ", ")))
+}
+
+// formatBlocklist renders the community blocklist that listed the account as an extra line, if any
+func (nf *NotificationFormatter) formatBlocklist(alert FUDAlertNotification) string {
+	if alert.BlocklistSource == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n🚫 <b>Blocklisted by:</b> %s", nf.escape(alert.BlocklistSource))
+}
+
+// formatFederationMatches renders what partner deployments reported as an extra line, if any
+func (nf *NotificationFormatter) formatFederationMatches(alert FUDAlertNotification) string {
+	if len(alert.FederationMatches) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n🛰 <b>Flagged by peers:</b> %s", nf.escape(strings.Join(alert.FederationMatches, "; ")))
+}
+
+// formatRequestSource credits the external tool that submitted the analysis, if any
+func (nf *NotificationFormatter) formatRequestSource(alert FUDAlertNotification) string {
+	if alert.RequestSource == "" {
+		return ""
+	}
+	line := fmt.Sprintf("\n📨 <b>Submitted by:</b> %s", nf.escape(alert.RequestSource))
+	if alert.RequestReason != "" {
+		line += fmt.Sprintf(" — <i>%s</i>", nf.escapeTruncated(alert.RequestReason, 200))
+	}
+	return line
+}
+
+// formatCommunity names the monitored community the alert came from, if any
+func (nf *NotificationFormatter) formatCommunity(alert FUDAlertNotification) string {
+	if alert.Ticker == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n🏘 <b>Community:</b> %s", nf.escape(alert.Ticker))
+}
+
+// formatThreadContext renders the parent/root posts of the alerted message, if known
+func (nf *NotificationFormatter) formatThreadContext(alert FUDAlertNotification) string {
+	contextSection := ""
+	if alert.HasThreadContext {
+		if alert.GrandParentPostText != "" {
+			// Show grandparent -> parent -> current structure
+			contextSection = fmt.Sprintf(`
+
+📄 <b>Thread Context:</b>
+<b>Root:</b> <i>%s</i> - @%s
+<b>Reply:</b> <i>%s</i> - @%s`,
+				nf.escapeTruncated(alert.GrandParentPostText, 150),
+				alert.GrandParentPostAuthor,
+				nf.escapeTruncated(alert.ParentPostText, 150),
+				alert.ParentPostAuthor)
+		} else if alert.OriginalPostText != "" || alert.ParentPostText != "" {
+			// Show parent -> current structure
+			postText := alert.OriginalPostText
+			postAuthor := alert.OriginalPostAuthor
+			if postText == "" {
+				postText = alert.ParentPostText
+				postAuthor = alert.ParentPostAuthor
+			}
+			contextSection = fmt.Sprintf(`
+
+📄 <b>Original Post Context:</b>
+<i>%s</i> - @%s`,
+				nf.escapeTruncated(postText, 200),
+				postAuthor)
+		}
+	}
+
+	return contextSection
+}
+
 func (nf *NotificationFormatter) FormatForTelegramWithDetail(alert FUDAlertNotification, notificationID string) string {
 	severityEmoji := nf.getSeverityEmoji(alert.AlertSeverity)
 	typeEmoji := nf.getFUDTypeEmoji(alert.FUDType)
@@ -132,11 +240,24 @@ func (nf *NotificationFormatter) FormatForTelegramWithDetail(alert FUDAlertNotif
 	var alertTitle, typeSection string
 	if isFUDAlert {
 		alertTitle = fmt.Sprintf("%s <b>FUD ALERT - %s SEVERITY</b>", severityEmoji, strings.ToUpper(alert.AlertSeverity))
-		typeSection = fmt.Sprintf("%s <b>Attack Type:</b> %s\n👤 <b>User Profile:</b> %s", typeEmoji, nf.formatFUDType(alert.FUDType), alert.UserSummary)
+		if alert.FUDType == HEURISTIC_FUD_TYPE {
+			alertTitle = fmt.Sprintf("%s <b>HEURISTIC FUD ALERT - %s SEVERITY</b>\n<i>AI analysis unavailable, keyword and account signals only</i>", severityEmoji, strings.ToUpper(alert.AlertSeverity))
+		}
+		if alert.BlocklistSource != "" {
+			alertTitle = fmt.Sprintf("%s <b>BLOCKLISTED ACCOUNT - %s SEVERITY</b>\n<i>First contact of an account on a community blocklist, not analyzed yet</i>", severityEmoji, strings.ToUpper(alert.AlertSeverity))
+		}
+		typeSection = fmt.Sprintf("%s <b>Attack Type:</b> %s\n👤 <b>User Profile:</b> %s", typeEmoji, nf.formatFUDType(alert.FUDType), nf.escape(alert.UserSummary))
+		typeSection += nf.formatPromotions(alert)
+		typeSection += nf.formatFUDConnections(alert)
+		typeSection += nf.formatFederationMatches(alert)
+		typeSection += nf.formatBlocklist(alert)
 	} else {
 		alertTitle = fmt.Sprintf("✅ <b>ANALYSIS COMPLETE - USER CLEAN</b>")
-		typeSection = fmt.Sprintf("👤 <b>User Type:</b> %s", alert.UserSummary)
+		typeSection = fmt.Sprintf("👤 <b>User Type:</b> %s", nf.escape(alert.UserSummary))
 	}
+	typeSection += nf.formatRequestSource(alert)
+	typeSection += nf.formatCommunity(alert)
+	typeSection += nf.formatPriorAlerts(alert)
 
 	message := fmt.Sprintf(`%s
 
@@ -163,8 +284,8 @@ func (nf *NotificationFormatter) FormatForTelegramWithDetail(alert FUDAlertNotif
 		typeSection,
 		alert.FUDUsername,
 		alert.FUDProbability*100,
-		alert.RecommendedAction,
-		nf.truncateText(alert.MessagePreview, 500),
+		nf.escape(alert.RecommendedAction),
+		nf.escapeTruncated(alert.MessagePreview, 500),
 		alert.FUDUsername, alert.FUDMessageID,
 		alert.ThreadID,
 		notificationID, alert.FUDUsername, alert.FUDUsername, alert.FUDUsername,
@@ -172,12 +293,89 @@ func (nf *NotificationFormatter) FormatForTelegramWithDetail(alert FUDAlertNotif
 	if alert.FUDType == FUD_TYPE {
 		message = fmt.Sprintf("Known FUD user:\n🎯 <b>User:</b> @%s\n💬 <i>%s</i>\n• /cache_%s - details",
 			alert.FUDUsername,
-			nf.truncateText(alert.MessagePreview, 2000),
+			nf.escapeTruncated(alert.MessagePreview, 2000),
 			alert.FUDUsername)
 	}
 	return message
 }
 
+// FormatAlert renders an alert using the given verbosity profile
+func (nf *NotificationFormatter) FormatAlert(alert FUDAlertNotification, notificationID string, verbosity string) string {
+	switch verbosity {
+	case VERBOSITY_COMPACT:
+		return nf.FormatCompact(alert, notificationID)
+	case VERBOSITY_DETAILED:
+		return nf.FormatForTelegramDetailed(alert, notificationID)
+	default:
+		return nf.FormatForTelegramWithDetail(alert, notificationID)
+	}
+}
+
+// FormatCompact renders a single-line alert for busy channels
+func (nf *NotificationFormatter) FormatCompact(alert FUDAlertNotification, notificationID string) string {
+	preview := nf.escapeTruncated(strings.Join(strings.Fields(alert.MessagePreview), " "), 120)
+
+	if alert.FUDType == FUD_TYPE {
+		return fmt.Sprintf("🔁 Known FUD @%s: <i>%s</i> · /cache_%s", alert.FUDUsername, preview, alert.FUDUsername)
+	}
+
+	isFUDAlert := !strings.Contains(alert.FUDType, "manual_analysis_clean") && alert.FUDType != "none"
+	var head string
+	if isFUDAlert {
+		head = fmt.Sprintf("%s <b>%s</b> @%s · %s %.0f%%", nf.getSeverityEmoji(alert.AlertSeverity), strings.ToUpper(alert.AlertSeverity), alert.FUDUsername, nf.formatFUDType(alert.FUDType), alert.FUDProbability*100)
+	} else {
+		head = fmt.Sprintf("✅ <b>CLEAN</b> @%s · %.0f%%", alert.FUDUsername, alert.FUDProbability*100)
+	}
+
+	if isFUDAlert && len(alert.PromotedCompetitors) > 0 {
+		head += " · promotes " + nf.escape(strings.Join(alert.PromotedCompetitors, " "))
+	}
+	if alert.FUDType == HEURISTIC_FUD_TYPE || alert.BlocklistSource != "" {
+		head += " · <i>no AI analysis</i>"
+	}
+
+	head += nf.formatPriorAlertsCompact(alert)
+	message := fmt.Sprintf(`%s · <i>%s</i> · <a href="https://twitter.com/%s/status/%s">tweet</a>`, head, preview, alert.FUDUsername, alert.FUDMessageID)
+	if notificationID != "" {
+		message += fmt.Sprintf(" · /detail_%s", notificationID)
+	}
+	return message
+}
+
+// FormatForTelegramDetailed extends the normal alert with evidence excerpts and the linked thread tweets
+func (nf *NotificationFormatter) FormatForTelegramDetailed(alert FUDAlertNotification, notificationID string) string {
+	message := nf.FormatForTelegramWithDetail(alert, notificationID)
+	if alert.FUDType == FUD_TYPE {
+		return message
+	}
+
+	var evidence strings.Builder
+	for i, item := range alert.KeyEvidence {
+		if i == 5 {
+			evidence.WriteString(fmt.Sprintf("  … and %d more\n", len(alert.KeyEvidence)-5))
+			break
+		}
+		evidence.WriteString(fmt.Sprintf("  %d. <i>%s</i>\n", i+1, nf.escapeTruncated(item, 200)))
+	}
+	if evidence.Len() > 0 {
+		message += "\n\n🔍 <b>Key Evidence:</b>\n" + strings.TrimRight(evidence.String(), "\n")
+	}
+
+	if alert.DecisionReason != "" {
+		message += fmt.Sprintf("\n\n🧠 <b>Reasoning:</b>\n<i>%s</i>", nf.escapeTruncated(alert.DecisionReason, 400))
+	}
+
+	message += nf.formatThreadContext(alert)
+	message += fmt.Sprintf("\n\n🧵 <b>Linked Tweets:</b>\n• <a href=\"https://twitter.com/%s/status/%s\">Alerted message</a>", alert.FUDUsername, alert.FUDMessageID)
+	// Top-level posts have no thread
+	if alert.ThreadID != "" {
+		message += fmt.Sprintf("\n• <a href=\"https://twitter.com/user/status/%s\">Thread root</a>", alert.ThreadID)
+	}
+	message += fmt.Sprintf("\n• <a href=\"https://twitter.com/%s\">@%s profile</a>", alert.FUDUsername, alert.FUDUsername)
+
+	return message
+}
+
 func (nf *NotificationFormatter) FormatDetailedView(alert FUDAlertNotification) string {
 	severityEmoji := nf.getSeverityEmoji(alert.AlertSeverity)
 	typeEmoji := nf.getFUDTypeEmoji(alert.FUDType)
@@ -185,7 +383,7 @@ func (nf *NotificationFormatter) FormatDetailedView(alert FUDAlertNotification)
 	// Format key evidence
 	var evidenceList string
 	for i, evidence := range alert.KeyEvidence {
-		evidenceList += fmt.Sprintf("  %d. %s\n", i+1, evidence)
+		evidenceList += fmt.Sprintf("  %d. %s\n", i+1, nf.escape(evidence))
 	}
 	if evidenceList == "" {
 		evidenceList = "  No specific evidence provided\n"
@@ -205,9 +403,9 @@ func (nf *NotificationFormatter) FormatDetailedView(alert FUDAlertNotification)
 💬 <b>Parent Reply:</b> @%s
 📝 <i>%s</i>`,
 				alert.GrandParentPostAuthor,
-				alert.GrandParentPostText,
+				nf.escape(alert.GrandParentPostText),
 				alert.ParentPostAuthor,
-				alert.ParentPostText)
+				nf.escape(alert.ParentPostText))
 		} else if alert.OriginalPostText != "" || alert.ParentPostText != "" {
 			// Show single parent context
 			postText := alert.OriginalPostText
@@ -222,7 +420,7 @@ func (nf *NotificationFormatter) FormatDetailedView(alert FUDAlertNotification)
 👤 Author: @%s
 📝 Content: <i>%s</i>`,
 				postAuthor,
-				postText)
+				nf.escape(postText))
 		}
 	}
 
@@ -237,7 +435,11 @@ func (nf *NotificationFormatter) FormatDetailedView(alert FUDAlertNotification)
 🎯 Target User: @%s (ID: %s)
 📊 Confidence Level: %.1f%%
 🚨 Risk Level: %s
-⚡ Recommended Action: %s`, typeEmoji, nf.formatFUDType(alert.FUDType), alert.FUDUsername, alert.FUDUserID, alert.FUDProbability*100, strings.ToUpper(alert.AlertSeverity), alert.RecommendedAction)
+⚡ Recommended Action: %s`, typeEmoji, nf.formatFUDType(alert.FUDType), alert.FUDUsername, alert.FUDUserID, alert.FUDProbability*100, strings.ToUpper(alert.AlertSeverity), nf.escape(alert.RecommendedAction))
+		classificationSection += nf.formatPromotions(alert)
+		classificationSection += nf.formatFUDConnections(alert)
+		classificationSection += nf.formatFederationMatches(alert)
+		classificationSection += nf.formatBlocklist(alert)
 	} else {
 		analysisTitle = fmt.Sprintf("✅ <b>DETAILED USER ANALYSIS - CLEAN</b>")
 		classificationSection = fmt.Sprintf(`👤 <b>USER CLASSIFICATION</b>
@@ -245,8 +447,9 @@ func (nf *NotificationFormatter) FormatDetailedView(alert FUDAlertNotification)
 👤 User Type: %s
 🎯 Analyzed User: @%s (ID: %s)
 📊 Confidence Level: %.1f%%
-⚡ Recommended Action: %s`, alert.UserSummary, alert.FUDUsername, alert.FUDUserID, alert.FUDProbability*100, alert.RecommendedAction)
+⚡ Recommended Action: %s`, nf.escape(alert.UserSummary), alert.FUDUsername, alert.FUDUserID, alert.FUDProbability*100, nf.escape(alert.RecommendedAction))
 	}
+	classificationSection += nf.formatPriorAlerts(alert)
 
 	var messageTitle string
 	if isFUDAlert {
@@ -286,10 +489,10 @@ User ID: %s`,
 		analysisTitle,
 		classificationSection,
 		messageTitle,
-		alert.MessagePreview,
+		nf.escape(alert.MessagePreview),
 		threadContextSection,
 		evidenceList,
-		alert.DecisionReason,
+		nf.escape(alert.DecisionReason),
 		alert.FUDUsername, alert.FUDMessageID,
 		alert.ThreadID,
 		alert.FUDUsername,
@@ -298,6 +501,9 @@ User ID: %s`,
 		alert.FUDMessageID,
 		alert.ThreadID,
 		alert.FUDUserID)
+	if alert.PromptVersion != "" {
+		message += "\nPrompt: " + alert.PromptVersion
+	}
 
 	return message
 }
@@ -359,12 +565,19 @@ func (nf *NotificationFormatter) getFUDTypeEmoji(fudType string) string {
 		return "🎭"
 	case strings.Contains(fudType, "casual"):
 		return "💭"
+	case fudType == HEURISTIC_FUD_TYPE:
+		return "🧮"
+	case fudType == BLOCKLIST_FUD_TYPE:
+		return "🚫"
 	default:
 		return "🎯"
 	}
 }
 
 func (nf *NotificationFormatter) formatFUDType(fudType string) string {
+	if fudType == HEURISTIC_FUD_TYPE {
+		return "Heuristic (AI unavailable)"
+	}
 	// Convert snake_case to Title Case
 	words := strings.Split(fudType, "_")
 	for i, word := range words {
@@ -373,16 +586,29 @@ func (nf *NotificationFormatter) formatFUDType(fudType string) string {
 	return strings.Join(words, " ")
 }
 
+// truncateText cuts text to maxLength characters ending in "...", never inside a multi-byte character
 func (nf *NotificationFormatter) truncateText(text string, maxLength int) string {
-	if len(text) <= maxLength {
+	if utf8.RuneCountInString(text) <= maxLength {
 		return text
 	}
-	return text[:maxLength-3] + "..."
+	runes := []rune(text)
+	return string(runes[:max(maxLength-3, 0)]) + "..."
+}
+
+// escape makes user or model text safe to interpolate into messages sent in HTML parse mode
+func (nf *NotificationFormatter) escape(text string) string {
+	return html.EscapeString(text)
+}
+
+// escapeTruncated truncates and then escapes text, so entities are never cut in half and the
+// escaping does not count against the length
+func (nf *NotificationFormatter) escapeTruncated(text string, maxLength int) string {
+	return nf.escape(nf.truncateText(text, maxLength))
 }
 
 func (nf *NotificationFormatter) formatTime(timeStr string) string {
 	if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
-		return t.Format("2006-01-02 15:04:05 UTC")
+		return t.Format("2006-01-02 15:04:05 MST")
 	}
 	return timeStr
 }
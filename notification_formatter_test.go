@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func benchmarkAlert() FUDAlertNotification {
+	return FUDAlertNotification{
+		FUDMessageID:          "1234567890",
+		FUDUserID:             "987654321",
+		FUDUsername:           "suspicious_user",
+		ThreadID:              "1234567000",
+		DetectedAt:            time.Now().Format(time.RFC3339),
+		AlertSeverity:         "high",
+		FUDType:               "professional_trojan_horse",
+		FUDProbability:        0.87,
+		MessagePreview:        strings.Repeat("Is the team dumping on us again? ", 20),
+		RecommendedAction:     "MONITOR_CLOSELY",
+		KeyEvidence:           []string{"Repeated liquidity concerns", "Coordinated timing with other accounts", "Account created recently"},
+		DecisionReason:        "User consistently spreads doubts disguised as questions.",
+		UserSummary:           "Professional FUDder",
+		OriginalPostText:      "Big announcement coming this week!",
+		OriginalPostAuthor:    "project_team",
+		ParentPostText:        "Can't wait",
+		ParentPostAuthor:      "holder",
+		GrandParentPostText:   "Big announcement coming this week!",
+		GrandParentPostAuthor: "project_team",
+		HasThreadContext:      true,
+	}
+}
+
+func BenchmarkNotificationFormatter_FormatForTelegramWithDetail(b *testing.B) {
+	formatter := NewNotificationFormatter()
+	alert := benchmarkAlert()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		formatter.FormatForTelegramWithDetail(alert, "abcdef0123456789")
+	}
+}
+
+func BenchmarkNotificationFormatter_FormatDetailedView(b *testing.B) {
+	formatter := NewNotificationFormatter()
+	alert := benchmarkAlert()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		formatter.FormatDetailedView(alert)
+	}
+}
+
+func TestNotificationFormatter_FormatAlert(t *testing.T) {
+	formatter := NewNotificationFormatter()
+	alert := benchmarkAlert()
+
+	t.Run("Compact is a single line", func(t *testing.T) {
+		message := formatter.FormatAlert(alert, "abc", VERBOSITY_COMPACT)
+		assert.NotContains(t, message, "\n")
+		assert.Contains(t, message, "@suspicious_user")
+		assert.Contains(t, message, "87%")
+		assert.Contains(t, message, "/detail_abc")
+	})
+
+	t.Run("Normal matches the standard alert", func(t *testing.T) {
+		assert.Equal(t, formatter.FormatForTelegramWithDetail(alert, "abc"), formatter.FormatAlert(alert, "abc", VERBOSITY_NORMAL))
+		assert.Equal(t, formatter.FormatForTelegramWithDetail(alert, "abc"), formatter.FormatAlert(alert, "abc", ""))
+	})
+
+	t.Run("Detailed adds evidence and linked tweets", func(t *testing.T) {
+		message := formatter.FormatAlert(alert, "abc", VERBOSITY_DETAILED)
+		assert.Contains(t, message, "Coordinated timing with other accounts")
+		assert.Contains(t, message, "Linked Tweets")
+		assert.Contains(t, message, "https://twitter.com/user/status/1234567000")
+		assert.Contains(t, message, "Big announcement coming this week!")
+
+		topLevel := benchmarkAlert()
+		topLevel.ThreadID = ""
+		message = formatter.FormatAlert(topLevel, "abc", VERBOSITY_DETAILED)
+		assert.NotContains(t, message, "Thread root")
+		assert.Contains(t, message, "Alerted message")
+		assert.Contains(t, message, "@suspicious_user profile")
+	})
+
+	t.Run("Competitor promotions are shown in every profile", func(t *testing.T) {
+		alert := benchmarkAlert()
+		alert.PromotedCompetitors = []string{"$XYZ", "@xyzproject"}
+		for _, verbosity := range []string{VERBOSITY_COMPACT, VERBOSITY_NORMAL, VERBOSITY_DETAILED} {
+			assert.Contains(t, formatter.FormatAlert(alert, "abc", verbosity), "$XYZ", verbosity)
+		}
+		assert.Contains(t, formatter.FormatForTelegram(alert), "Promotes:</b> $XYZ, @xyzproject")
+		assert.NotContains(t, formatter.FormatAlert(benchmarkAlert(), "abc", VERBOSITY_NORMAL), "Promotes")
+	})
+}
+
+func TestNotificationFormatter_FormatRedacted(t *testing.T) {
+	formatter := NewNotificationFormatter()
+	alert := benchmarkAlert()
+	alert.MessagePreview = "ask @project_team where the liquidity went"
+
+	t.Run("Ambassador profile hides identity and probability", func(t *testing.T) {
+		message := formatter.FormatRedacted(alert, REDACTION_AMBASSADOR)
+		assert.NotContains(t, message, "suspicious_user")
+		assert.NotContains(t, message, "project_team")
+		assert.NotContains(t, message, "87%")
+		assert.NotContains(t, message, "twitter.com")
+		assert.Contains(t, message, anonymizedUserLabel(alert.FUDUserID))
+		assert.Contains(t, message, "where the liquidity went")
+	})
+
+	t.Run("No text profile keeps username but drops message", func(t *testing.T) {
+		message := formatter.FormatRedacted(alert, REDACTION_NO_TEXT)
+		assert.Contains(t, message, "@suspicious_user")
+		assert.NotContains(t, message, "liquidity")
+	})
+
+	t.Run("Anonymized profile is a summary only", func(t *testing.T) {
+		message := formatter.FormatRedacted(alert, REDACTION_ANONYMIZED)
+		assert.NotContains(t, message, "\n")
+		assert.NotContains(t, message, "suspicious_user")
+		assert.Contains(t, message, "High severity FUD detected")
+	})
+}
+
+func TestNotificationFormatter_TruncateText(t *testing.T) {
+	formatter := NewNotificationFormatter()
+	assert.Equal(t, "short", formatter.truncateText("short", 10))
+	assert.Equal(t, "Привет ми...", formatter.truncateText("Привет мир, как дела", 12))
+	truncated := formatter.truncateText(strings.Repeat("🚀", 50), 10)
+	assert.True(t, utf8.ValidString(truncated))
+	assert.Equal(t, strings.Repeat("🚀", 7)+"...", truncated)
+	assert.Equal(t, "a &lt; b...", formatter.escapeTruncated("a < b < c < d", 8), "truncated before escaping")
+}
+
+func TestNotificationFormatter_EscapesText(t *testing.T) {
+	formatter := NewNotificationFormatter()
+	alert := benchmarkAlert()
+	alert.MessagePreview = "buy <b>now</b> & sell at 2<3"
+	alert.DecisionReason = "Uses <script> tags & rants"
+	alert.KeyEvidence = []string{"price < 0.01"}
+
+	for _, verbosity := range []string{VERBOSITY_COMPACT, VERBOSITY_NORMAL, VERBOSITY_DETAILED} {
+		message := formatter.FormatAlert(alert, "n1", verbosity)
+		assert.Contains(t, message, "buy &lt;b&gt;now&lt;/b&gt; &amp; sell at 2&lt;3", verbosity)
+		assert.NotContains(t, message, "<b>now</b>", verbosity)
+	}
+	detailed := formatter.FormatDetailedView(alert)
+	assert.Contains(t, detailed, "Uses &lt;script&gt; tags &amp; rants")
+	assert.Contains(t, detailed, "price &lt; 0.01")
+	assert.Contains(t, formatter.FormatRedacted(alert, REDACTION_NO_USERNAMES), "&amp; sell")
+}
@@ -1,29 +1,68 @@
 package main
 
-import (
-	"log"
-)
+import "fmt"
+
+// NotificationSink is a destination that FUD alerts are broadcast to besides Telegram
+type NotificationSink interface {
+	StoreAndBroadcastNotification(alert FUDAlertNotification) error
+}
 
 // NotificationHandler handles FUD alert notifications
-func NotificationHandler(notificationCh chan FUDAlertNotification, telegramService *TelegramService) {
+func NotificationHandler(notificationCh chan FUDAlertNotification, telegramService *BotController, sinks ...NotificationSink) {
 	for alert := range notificationCh {
-		log.Printf("FUD Alert: %s (@%s) - %s", alert.FUDType, alert.FUDUsername, alert.AlertSeverity)
+		logger := logFor("notifications").With("username", alert.FUDUsername, "tweet_id", alert.FUDMessageID)
+		logger.Info("FUD alert", "fud_type", alert.FUDType, "severity", alert.AlertSeverity)
+		if alert.NewFUDType != "" {
+			telegramService.notifyNewFUDType(alert)
+		}
+		if alert.Watched {
+			telegramService.notifyWatchers(alert)
+			if alert.WatchOnly {
+				continue
+			}
+		}
 
 		// Check if this notification should be sent to a specific chat
-		if alert.TargetChatID != 0 {
+		if alert.DiscordChannelID != "" {
+			// Analysis requested from Discord goes back to the requesting channel only
+			for _, sink := range sinks {
+				discord, ok := sink.(*DiscordService)
+				if !ok {
+					continue
+				}
+				err := discord.SendAlertToChannel(alert.DiscordChannelID, alert)
+				if err != nil {
+					logger.Error("failed to send targeted Discord notification", "discord_channel", alert.DiscordChannelID, "error", err)
+				} else {
+					logger.Info("sent targeted Discord notification", "discord_channel", alert.DiscordChannelID)
+				}
+			}
+		} else if alert.TargetChatID != 0 {
 			// Send to specific chat only
-			telegramMessage := telegramService.formatter.FormatForTelegramWithDetail(alert, "")
-			err := telegramService.SendMessage(alert.TargetChatID, telegramMessage)
+			err := telegramService.SendAlertToChat(alert.TargetChatID, alert, "")
 			if err != nil {
-				log.Printf("Failed to send targeted Telegram notification to chat %d: %v", alert.TargetChatID, err)
+				logger.Error("failed to send targeted Telegram notification", "chat_id", alert.TargetChatID, "error", err)
 			} else {
-				log.Printf("Sent targeted notification for @%s to chat %d", alert.FUDUsername, alert.TargetChatID)
+				logger.Info("sent targeted notification", "chat_id", alert.TargetChatID)
 			}
 		} else {
 			// Store and broadcast notification to all registered chats
+			delivery := telegramService.alertDelivery(alert.AlertSeverity)
+			held := telegramService.heldByMaintenance(alert)
 			err := telegramService.StoreAndBroadcastNotification(alert)
 			if err != nil {
-				log.Printf("Failed to send Telegram notification: %v", err)
+				logger.Error("failed to send Telegram notification", "error", err)
+			}
+			// The other sinks get no maintenance summary, held alerts are listed in Telegram only
+			if delivery != ALERT_DELIVERY_BROADCAST || held || !telegramService.sinksReceiveAlert(alert) {
+				logger.Info("alert not sent to other sinks", "delivery", delivery, "maintenance", held)
+				continue
+			}
+			for _, sink := range sinks {
+				err := sink.StoreAndBroadcastNotification(alert)
+				if err != nil {
+					logger.Error("failed to send notification", "sink", fmt.Sprintf("%T", sink), "error", err)
+				}
 			}
 		}
 	}
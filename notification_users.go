@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const NOTIFICATION_USERS_AUDIT_PATH = "notification_users_audit.log"
+const NOTIFY_CONFIRMATION_TIMEOUT = 2 * time.Minute
+const NOTIFY_NOTE_PREFIX = "note:"
+
+// How a chat got on the notification list
+const (
+	NOTIFY_SOURCE_COMMAND    = "notify"
+	NOTIFY_SOURCE_ONBOARDING = "onboarding"
+	NOTIFY_SOURCE_APPROVAL   = "approval"
+	NOTIFY_SOURCE_CONFIG     = "config"
+	NOTIFY_SOURCE_MIGRATION  = "users.txt"
+)
+
+// notificationUsersAuditPath is a variable so tests can write the audit log elsewhere
+var notificationUsersAuditPath = NOTIFICATION_USERS_AUDIT_PATH
+
+// notifyConfirmations holds destructive /notify actions until the admin confirms them
+type notifyConfirmations struct {
+	mu      sync.Mutex
+	pending map[int64]pendingNotifyAction
+}
+
+type pendingNotifyAction struct {
+	Action       string // remove or clear
+	TargetChatID int64
+	ExpiresAt    time.Time
+}
+
+// handleNotifyCommand manages the list of chats that receive notifications (users.txt):
+// /notify [list|add ids [note: why]|remove id|clear|confirm|cancel|audit]
+func (b *BotController) handleNotifyCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 {
+		b.handleNotifyList(chatID)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		b.handleNotifyList(chatID)
+	case "add":
+		b.handleNotifyAdd(chatID, actor, args[1:])
+	case "remove":
+		if len(args) < 2 {
+			b.SendMessage(chatID, "❌ Usage: /notify remove &lt;chat_id&gt;")
+			return
+		}
+		targetChatID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			b.SendMessage(chatID, "❌ Invalid chat ID")
+			return
+		}
+		if !b.isRegisteredChat(targetChatID) {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Chat %d is not in the notification list", targetChatID))
+			return
+		}
+		b.requestNotifyConfirmation(chatID, pendingNotifyAction{Action: "remove", TargetChatID: targetChatID},
+			fmt.Sprintf("⚠️ Remove chat <code>%d</code> from notifications?", targetChatID))
+	case "clear":
+		b.requestNotifyConfirmation(chatID, pendingNotifyAction{Action: "clear"},
+			fmt.Sprintf("⚠️ Remove all %d chats from notifications? Admin chats are kept.", len(b.GetRegisteredChats())))
+	case "confirm":
+		b.handleNotifyConfirm(chatID, actor)
+	case "cancel":
+		b.confirmations.mu.Lock()
+		delete(b.confirmations.pending, chatID)
+		b.confirmations.mu.Unlock()
+		b.SendMessage(chatID, "👌 Cancelled.")
+	case "audit":
+		b.handleNotifyAudit(chatID)
+	default:
+		b.SendMessage(chatID, "❌ Usage: /notify [list|add id1,id2 [note: why]|remove id|clear|audit]")
+	}
+}
+
+func (b *BotController) handleNotifyList(chatID int64) {
+	chats := b.GetRegisteredChats()
+	sort.Slice(chats, func(i, j int) bool { return chats[i] < chats[j] })
+
+	settings, err := b.dbService.GetAllChatSettings()
+	if err != nil {
+		log.Printf("Failed to load chat settings for /notify: %v", err)
+	}
+	provenance, err := b.dbService.GetNotificationChats()
+	if err != nil {
+		log.Printf("Failed to load notification chat records for /notify: %v", err)
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🔔 <b>Notification list</b> (%d chats)\n\n", len(chats)))
+	for _, id := range chats {
+		title := settings[id].ChatTitle
+		if title == "" {
+			title = "unknown"
+		}
+		marker := ""
+		if b.isAdminChat(id) {
+			marker = " 👑"
+		}
+		message.WriteString(fmt.Sprintf("• <code>%d</code> — %s%s\n", id, html.EscapeString(title), marker))
+		if record, ok := provenance[id]; ok && record.Active {
+			message.WriteString("   ↳ " + formatNotifyProvenance(record) + "\n")
+		}
+	}
+	message.WriteString("\n/notify add id1,id2 note: why · /notify remove id · /notify clear · /notify audit")
+
+	b.SendMessage(chatID, message.String())
+}
+
+// formatNotifyProvenance describes who added a chat to the notification list, when and why
+func formatNotifyProvenance(record NotificationChatModel) string {
+	var via string
+	switch record.Source {
+	case NOTIFY_SOURCE_COMMAND:
+		via = "via /notify"
+	case NOTIFY_SOURCE_ONBOARDING:
+		via = "after onboarding"
+	case NOTIFY_SOURCE_APPROVAL:
+		via = "by approving it"
+	case NOTIFY_SOURCE_CONFIG:
+		via = "from " + ENV_TELEGRAM_ADMIN_CHAT_ID
+	case NOTIFY_SOURCE_MIGRATION:
+		via = "migrated from users.txt"
+	default:
+		via = record.Source
+	}
+	text := fmt.Sprintf("added by %s %s on %s", html.EscapeString(record.AddedBy), via, record.AddedAt.UTC().Format("2006-01-02"))
+	if record.Note != "" {
+		text += " — <i>" + html.EscapeString(record.Note) + "</i>"
+	}
+	return text
+}
+
+// splitNotifyNote separates the optional "note: why" suffix of /notify add from the chat IDs
+func splitNotifyNote(args []string) ([]string, string) {
+	for i, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), NOTIFY_NOTE_PREFIX) {
+			note := strings.TrimSpace(arg[len(NOTIFY_NOTE_PREFIX):] + " " + strings.Join(args[i+1:], " "))
+			return args[:i], note
+		}
+	}
+	return args, ""
+}
+
+func (b *BotController) handleNotifyAdd(chatID int64, actor string, args []string) {
+	args, note := splitNotifyNote(args)
+	var added []string
+	var invalid []string
+	for _, idStr := range strings.Split(strings.Join(args, ","), ",") {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			invalid = append(invalid, idStr)
+			continue
+		}
+		b.chatMutex.Lock()
+		b.chatIDs[id] = true
+		b.chatMutex.Unlock()
+		b.recordNotificationChat(NotificationChatModel{ChatID: id, Source: NOTIFY_SOURCE_COMMAND, AddedBy: actor, AddedFromChat: chatID, Note: note})
+		b.unmarkChatRemoved(id)
+		added = append(added, idStr)
+	}
+
+	if len(added) == 0 {
+		b.SendMessage(chatID, "❌ Usage: /notify add id1,id2,id3 [note: why]")
+		return
+	}
+	change := "added " + strings.Join(added, ",")
+	if note != "" {
+		change += fmt.Sprintf(" (note: %s)", note)
+	}
+	b.auditNotificationUsers(chatID, actor, change)
+
+	message := fmt.Sprintf("✅ Added %d chats to notifications", len(added))
+	if len(invalid) > 0 {
+		message += fmt.Sprintf("\n⚠️ Skipped invalid IDs: %s", strings.Join(invalid, ", "))
+	}
+	b.SendMessage(chatID, message)
+}
+
+func (b *BotController) requestNotifyConfirmation(chatID int64, action pendingNotifyAction, prompt string) {
+	action.ExpiresAt = time.Now().Add(NOTIFY_CONFIRMATION_TIMEOUT)
+
+	b.confirmations.mu.Lock()
+	if b.confirmations.pending == nil {
+		b.confirmations.pending = make(map[int64]pendingNotifyAction)
+	}
+	b.confirmations.pending[chatID] = action
+	b.confirmations.mu.Unlock()
+
+	b.SendMessage(chatID, prompt+"\n\nSend /notify confirm within 2 minutes or /notify cancel.")
+}
+
+func (b *BotController) handleNotifyConfirm(chatID int64, actor string) {
+	b.confirmations.mu.Lock()
+	action, ok := b.confirmations.pending[chatID]
+	delete(b.confirmations.pending, chatID)
+	b.confirmations.mu.Unlock()
+
+	if !ok || time.Now().After(action.ExpiresAt) {
+		b.SendMessage(chatID, "❌ Nothing to confirm. Run /notify remove or /notify clear again.")
+		return
+	}
+
+	switch action.Action {
+	case "remove":
+		b.chatMutex.Lock()
+		delete(b.chatIDs, action.TargetChatID)
+		b.chatMutex.Unlock()
+		b.forgetNotificationChats([]int64{action.TargetChatID}, actor)
+		b.markChatsRemoved([]int64{action.TargetChatID}, actor)
+		b.auditNotificationUsers(chatID, actor, fmt.Sprintf("removed %d", action.TargetChatID))
+		b.SendMessage(chatID, fmt.Sprintf("✅ Chat %d no longer receives notifications", action.TargetChatID))
+
+	case "clear":
+		var removed []string
+		var removedIDs []int64
+		b.chatMutex.Lock()
+		for id := range b.chatIDs {
+			if !b.isAdminChat(id) {
+				delete(b.chatIDs, id)
+				removed = append(removed, strconv.FormatInt(id, 10))
+				removedIDs = append(removedIDs, id)
+			}
+		}
+		b.chatMutex.Unlock()
+		b.forgetNotificationChats(removedIDs, actor)
+		b.markChatsRemoved(removedIDs, actor)
+		b.auditNotificationUsers(chatID, actor, "cleared "+strings.Join(removed, ","))
+		b.SendMessage(chatID, fmt.Sprintf("✅ Removed %d chats from notifications", len(removed)))
+	}
+}
+
+func (b *BotController) handleNotifyAudit(chatID int64) {
+	data, err := os.ReadFile(notificationUsersAuditPath)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		b.SendMessage(chatID, "📭 No changes to the notification list recorded yet.")
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) > 20 {
+		lines = lines[len(lines)-20:]
+	}
+	b.SendMessage(chatID, "📜 <b>Notification list changes</b>\n\n<code>"+html.EscapeString(strings.Join(lines, "\n"))+"</code>")
+}
+
+// auditNotificationUsers records who changed the notification list
+func (b *BotController) auditNotificationUsers(chatID int64, actor string, change string) {
+	entry := fmt.Sprintf("%s %s (chat %d) %s", time.Now().UTC().Format(time.RFC3339), actor, chatID, change)
+	log.Printf("Notification list: %s", entry)
+
+	file, err := os.OpenFile(notificationUsersAuditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Failed to open notification audit log: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.WriteString(entry + "\n"); err != nil {
+		log.Printf("Failed to write notification audit log: %v", err)
+	}
+}
+
+// recordNotificationChat stores who added a chat to the notification list
+func (b *BotController) recordNotificationChat(chat NotificationChatModel) {
+	if b.dbService == nil {
+		return
+	}
+	if err := b.dbService.AddNotificationChat(chat); err != nil {
+		log.Printf("Failed to record notification chat %d: %v", chat.ChatID, err)
+	}
+}
+
+func (b *BotController) forgetNotificationChats(chatIDs []int64, actor string) {
+	if b.dbService == nil {
+		return
+	}
+	if err := b.dbService.RemoveNotificationChats(chatIDs, actor); err != nil {
+		log.Printf("Failed to record removal of notification chats %v: %v", chatIDs, err)
+	}
+}
+
+// markChatsRemoved keeps chats removed by an admin from registering themselves again through onboarding
+func (b *BotController) markChatsRemoved(chatIDs []int64, actor string) {
+	if b.dbService == nil {
+		return
+	}
+	now := time.Now()
+	for _, id := range chatIDs {
+		settings, err := b.dbService.GetChatSettings(id)
+		if err != nil {
+			log.Printf("Failed to load settings of removed chat %d: %v", id, err)
+			continue
+		}
+		settings.RemovedAt = &now
+		settings.RemovedBy = actor
+		settings.OnboardingStep = ""
+		if err := b.dbService.SaveChatSettings(settings); err != nil {
+			log.Printf("Failed to record removal of chat %d: %v", id, err)
+		}
+	}
+}
+
+// unmarkChatRemoved lets a chat added back by an admin receive notifications and onboard again
+func (b *BotController) unmarkChatRemoved(chatID int64) {
+	if b.dbService == nil {
+		return
+	}
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil || settings.RemovedAt == nil {
+		return
+	}
+	settings.RemovedAt = nil
+	settings.RemovedBy = ""
+	if err := b.dbService.SaveChatSettings(settings); err != nil {
+		log.Printf("Failed to clear removal of chat %d: %v", chatID, err)
+	}
+}
+
+// migrateNotificationChats gives every chat loaded from users.txt or the admin config a database
+// record, then reconciles the in-memory list with the database: chats removed there stay removed
+// and chats recorded there but missing from users.txt are restored
+func (b *BotController) migrateNotificationChats(fileChatIDs []int64, configChatIDs []int64) {
+	var migrated []string
+	for _, id := range configChatIDs {
+		if _, err := b.dbService.MigrateNotificationChat(NotificationChatModel{ChatID: id, Source: NOTIFY_SOURCE_CONFIG, AddedBy: "config"}); err != nil {
+			log.Printf("Failed to migrate notification chat %d: %v", id, err)
+		}
+	}
+	for _, id := range fileChatIDs {
+		created, err := b.dbService.MigrateNotificationChat(NotificationChatModel{ChatID: id, Source: NOTIFY_SOURCE_MIGRATION, AddedBy: "migration"})
+		if err != nil {
+			log.Printf("Failed to migrate notification chat %d: %v", id, err)
+			continue
+		}
+		if created {
+			migrated = append(migrated, strconv.FormatInt(id, 10))
+		}
+	}
+	if len(migrated) > 0 {
+		log.Printf("📦 Migrated %d notification chats from %s to the database", len(migrated), CHAT_IDS_STORAGE_PATH)
+		b.auditNotificationUsers(0, "migration", "migrated "+strings.Join(migrated, ","))
+	}
+
+	records, err := b.dbService.GetNotificationChats()
+	if err != nil {
+		log.Printf("Failed to load notification chats from the database: %v", err)
+		return
+	}
+	b.chatMutex.Lock()
+	defer b.chatMutex.Unlock()
+	for id, record := range records {
+		if record.Active {
+			b.chatIDs[id] = true
+		} else if !b.isAdminChat(id) {
+			delete(b.chatIDs, id)
+		}
+	}
+}
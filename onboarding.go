@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var onboardingTickerRegex = regexp.MustCompile(`^[A-Z0-9]{1,15}$`)
+
+// handleOnboarding walks a not yet registered chat through ticker, severity threshold
+// and timezone selection. The chat only receives broadcasts after the user who started
+// onboarding confirms the summary and, in approval mode, an admin approves the chat.
+func (b *BotController) handleOnboarding(update TelegramUpdate) {
+	chatID := update.Message.Chat.ID
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to load settings for onboarding chat %d: %v", chatID, err)
+		return
+	}
+
+	// Chats removed by an admin stay removed until an admin adds them back
+	if settings.RemovedAt != nil {
+		if strings.HasPrefix(update.Message.Text, "/") {
+			b.SendMessage(chatID, "🚫 This chat was removed from notifications by an admin. Ask an admin to add it back with /notify add.")
+		}
+		return
+	}
+
+	switch settings.ApprovalStatus {
+	case CHAT_APPROVAL_PENDING:
+		if strings.HasPrefix(update.Message.Text, "/") {
+			b.SendMessage(chatID, "⏳ This chat is waiting for admin approval.")
+		}
+		return
+	case CHAT_APPROVAL_REJECTED:
+		return
+	}
+
+	text := strings.TrimSpace(update.Message.Text)
+	command := ""
+	if fields := strings.Fields(text); len(fields) > 0 {
+		// Commands in groups may come as /skip@BotName
+		command = strings.SplitN(fields[0], "@", 2)[0]
+	}
+
+	if settings.OnboardingStep == "" || command == "/restart" {
+		log.Printf("Starting onboarding for chat %d (from: %s)", chatID, update.Message.From.FirstName)
+		settings.OnboardingStep = ONBOARDING_STEP_TICKER
+		settings.OnboardingUserID = update.Message.From.ID
+		settings.ChatTitle = update.Message.Chat.Title
+		if settings.ChatTitle == "" {
+			settings.ChatTitle = strings.TrimSpace(update.Message.From.FirstName + " " + update.Message.From.LastName)
+		}
+		b.saveOnboardingStep(settings, fmt.Sprintf(`👋 <b>Welcome to the FUD Detection Bot!</b>
+
+This chat is not receiving alerts yet. Let's set it up in a few steps.
+Chat ID: <code>%d</code>
+
+%s`, chatID, b.onboardingPrompt(settings)))
+		return
+	}
+
+	if text == "" {
+		return
+	}
+
+	switch settings.OnboardingStep {
+	case ONBOARDING_STEP_TICKER:
+		ticker := b.defaultOnboardingTicker()
+		if command != "/skip" {
+			ticker = strings.ToUpper(strings.TrimPrefix(text, "$"))
+		}
+		if !onboardingTickerRegex.MatchString(ticker) {
+			b.SendMessage(chatID, "❌ Invalid ticker. Send something like <code>$TICKER</code>")
+			return
+		}
+		settings.Ticker = ticker
+		settings.OnboardingStep = ONBOARDING_STEP_SEVERITY
+
+	case ONBOARDING_STEP_SEVERITY:
+		severity := "low"
+		if command != "/skip" {
+			severity = strings.ToLower(text)
+		}
+		switch severity {
+		case "low", "medium", "high", "critical":
+		default:
+			b.SendMessage(chatID, "❌ Unknown severity. Use: low, medium, high or critical")
+			return
+		}
+		settings.MinSeverity = severity
+		settings.OnboardingStep = ONBOARDING_STEP_TIMEZONE
+
+	case ONBOARDING_STEP_TIMEZONE:
+		timezone := "UTC"
+		if command != "/skip" {
+			timezone = text
+		}
+		if _, err := time.LoadLocation(timezone); err != nil {
+			b.SendMessage(chatID, "❌ Unknown timezone. Use an IANA name like <code>Europe/Berlin</code> or <code>UTC</code>")
+			return
+		}
+		settings.Timezone = timezone
+		settings.OnboardingStep = ONBOARDING_STEP_CONFIRM
+
+	case ONBOARDING_STEP_CONFIRM:
+		if command != "/confirm" {
+			b.SendMessage(chatID, b.onboardingPrompt(settings))
+			return
+		}
+		if update.Message.From.ID != settings.OnboardingUserID {
+			b.SendMessage(chatID, "❌ Only the user who started the setup can confirm it. Use /restart to start over.")
+			return
+		}
+		b.completeOnboarding(settings)
+		return
+	}
+
+	b.saveOnboardingStep(settings, b.onboardingPrompt(settings))
+}
+
+func (b *BotController) saveOnboardingStep(settings *ChatSettingsModel, message string) {
+	err := b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		log.Printf("Failed to save onboarding state for chat %d: %v", settings.ChatID, err)
+		b.SendMessage(settings.ChatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+	b.SendMessage(settings.ChatID, message)
+}
+
+func (b *BotController) onboardingPrompt(settings *ChatSettingsModel) string {
+	switch settings.OnboardingStep {
+	case ONBOARDING_STEP_TICKER:
+		return fmt.Sprintf("1️⃣ <b>Project</b>\nReply with the ticker this chat should follow, e.g. <code>$%s</code>, or /skip to use it.", b.defaultOnboardingTicker())
+	case ONBOARDING_STEP_SEVERITY:
+		return "2️⃣ <b>Severity threshold</b>\nReply with the minimum alert severity: <code>low</code>, <code>medium</code>, <code>high</code> or <code>critical</code>. /skip keeps all alerts."
+	case ONBOARDING_STEP_TIMEZONE:
+		return "3️⃣ <b>Timezone</b>\nReply with your timezone, e.g. <code>Europe/Berlin</code>. /skip keeps UTC."
+	case ONBOARDING_STEP_CONFIRM:
+		return fmt.Sprintf(`4️⃣ <b>Confirm setup</b>
+🪙 Ticker: $%s
+🚨 Minimum severity: %s
+🕐 Timezone: %s
+
+Send /confirm to start receiving alerts or /restart to change the answers.`, settings.Ticker, settings.MinSeverity, settings.Timezone)
+	}
+	return ""
+}
+
+func (b *BotController) completeOnboarding(settings *ChatSettingsModel) {
+	now := time.Now()
+	settings.OnboardingStep = ""
+	settings.OnboardedAt = &now
+	if isChatApprovalMode() {
+		settings.ApprovalStatus = CHAT_APPROVAL_PENDING
+	}
+	err := b.dbService.SaveChatSettings(settings)
+	if err != nil {
+		b.SendMessage(settings.ChatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+
+	if settings.ApprovalStatus == CHAT_APPROVAL_PENDING {
+		log.Printf("Chat %d finished onboarding and waits for approval", settings.ChatID)
+		b.SendMessage(settings.ChatID, "⏳ Setup saved! An administrator has to approve this chat before alerts are posted here.")
+		b.notifyAdminsAboutPendingChat(settings)
+		return
+	}
+
+	b.registerChat(settings, NOTIFY_SOURCE_ONBOARDING, fmt.Sprintf("user %d", settings.OnboardingUserID), settings.ChatID)
+}
+
+// registerChat adds the chat to the broadcast list, records who added it and tells it what it will receive
+func (b *BotController) registerChat(settings *ChatSettingsModel, source string, addedBy string, fromChatID int64) {
+	b.chatMutex.Lock()
+	b.chatIDs[settings.ChatID] = true
+	b.chatMutex.Unlock()
+	b.recordNotificationChat(NotificationChatModel{ChatID: settings.ChatID, Source: source, AddedBy: addedBy, AddedFromChat: fromChatID})
+	b.auditNotificationUsers(fromChatID, addedBy, fmt.Sprintf("added %d (%s)", settings.ChatID, source))
+	log.Printf("New Telegram chat registered after onboarding: %d", settings.ChatID)
+
+	b.SendMessage(settings.ChatID, fmt.Sprintf("✅ Chat registered!\nChat ID: %d\nAlerts for $%s from <b>%s</b> severity will be posted here.\n\nSend /help to see available commands.",
+		settings.ChatID, settings.Ticker, settings.MinSeverity))
+}
+
+func (b *BotController) defaultOnboardingTicker() string {
+	ticker := b.ticker
+	if ticker == "" {
+		ticker = os.Getenv(ENV_TWITTER_COMMUNITY_TICKER)
+	}
+	return strings.ToUpper(strings.TrimPrefix(ticker, "$"))
+}
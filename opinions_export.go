@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var opinionsCSVHeader = []string{"tweet_id", "tweet_created_at", "user_id", "username", "ticker", "text", "search_query", "found_at", "in_reply_to_id", "replied_to_author", "replied_to_text"}
+
+// parseOpinionsPeriod reads the optional period of /export_opinions: all by default, or today, 7d or 7
+// like /usage. It returns the start of the period, zero for all opinions.
+func parseOpinionsPeriod(args []string, now time.Time) (time.Time, string, error) {
+	if len(args) == 0 || strings.EqualFold(args[0], "all") {
+		return time.Time{}, "all time", nil
+	}
+	days, err := parseUsagePeriod(args)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid period %s, use all, today or 1d to %dd", args[0], USAGE_MAX_PERIOD_DAYS)
+	}
+	start := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	return start, fmt.Sprintf("since %s", start.Format(time.DateOnly)), nil
+}
+
+// writeOpinionsCSV streams the ticker opinions since a time into a CSV file and returns how many it wrote
+func writeOpinionsCSV(dbService *DatabaseService, path string, since time.Time) (int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(opinionsCSVHeader); err != nil {
+		return 0, err
+	}
+	count, err := dbService.StreamTickerOpinions(since, func(opinion UserTickerOpinionModel) error {
+		return writer.Write([]string{
+			opinion.TweetID,
+			opinion.TweetCreatedAt.UTC().Format(time.RFC3339),
+			opinion.UserID,
+			opinion.Username,
+			opinion.Ticker,
+			opinion.Text,
+			opinion.SearchQuery,
+			opinion.FoundAt.UTC().Format(time.RFC3339),
+			opinion.InReplyToID,
+			opinion.RepliedToAuthor,
+			opinion.RepliedToText,
+		})
+	})
+	if err != nil {
+		return count, err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, err
+	}
+	return count, file.Close()
+}
+
+// handleExportOpinionsCommand processes /export_opinions [period]: every stored ticker opinion as CSV for analytics
+func (b *BotController) handleExportOpinionsCommand(chatID int64, args []string) {
+	now := time.Now()
+	since, label, err := parseOpinionsPeriod(args, now)
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "export-*")
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error creating the export: %v", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, fmt.Sprintf("ticker_opinions_%s.csv", now.Format("20060102_150405")))
+
+	count, err := writeOpinionsCSV(b.dbService, path, since)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error exporting ticker opinions: %v", err))
+		return
+	}
+	if count == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No ticker opinions stored (%s)", label))
+		return
+	}
+
+	caption := fmt.Sprintf("📊 <b>Ticker Opinions Export</b>\n\n📅 Period: %s\n💬 Opinions: %d\n🕒 Generated: %s", label, count, now.UTC().Format("2006-01-02 15:04:05 UTC"))
+	if err := b.sendExportFile(chatID, path, caption); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
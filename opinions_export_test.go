@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOpinionsPeriod(t *testing.T) {
+	now := time.Date(2026, 5, 10, 15, 0, 0, 0, time.UTC)
+	since, label, err := parseOpinionsPeriod(nil, now)
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+	assert.Equal(t, "all time", label)
+
+	since, _, err = parseOpinionsPeriod([]string{"7d"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC), since)
+
+	_, _, err = parseOpinionsPeriod([]string{"forever"}, now)
+	assert.Error(t, err)
+}
+
+func TestExportOpinions(t *testing.T) {
+	db := setupTestDB(t)
+	old := time.Now().AddDate(0, 0, -30)
+	require.NoError(t, db.SaveUserTickerOpinion(UserTickerOpinionModel{UserID: "u1", Username: "alice", Ticker: "GRUT", TweetID: "1", Text: "old, \"quoted\" take", TweetCreatedAt: old, SearchQuery: "$GRUT"}))
+	require.NoError(t, db.SaveUserTickerOpinion(UserTickerOpinionModel{UserID: "u2", Username: "bob", Ticker: "GRUT", TweetID: "2", Text: "line one\nline two", TweetCreatedAt: time.Now(),
+		SearchQuery: "$GRUT", InReplyToID: "1", RepliedToAuthor: "alice", RepliedToText: "old take"}))
+
+	path := filepath.Join(t.TempDir(), "opinions.csv")
+	count, err := writeOpinionsCSV(db, path, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, opinionsCSVHeader, records[0])
+	assert.Equal(t, "old, \"quoted\" take", records[1][5], "oldest first, text survives quoting")
+	assert.Equal(t, []string{"1", "alice", "old take"}, records[2][8:])
+	assert.Equal(t, "line one\nline two", records[2][5])
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.handleExportOpinionsCommand(1, []string{"7d"})
+	transport.mu.Lock()
+	documents := transport.documents
+	transport.mu.Unlock()
+	require.Len(t, documents, 1)
+	assert.Contains(t, documents[0].Caption, "Opinions: 1")
+
+	bot.handleExportOpinionsCommand(1, []string{"yesterday"})
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "invalid period yesterday")
+}
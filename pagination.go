@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	HISTORY_PAGE_SIZE = 20
+	SEARCH_PAGE_SIZE  = 20
+)
+
+// pageSuffixPattern matches the page token of paginated commands, "/history_alice_p2" is page 2 of /history_alice
+var pageSuffixPattern = regexp.MustCompile(`_p(\d+)$`)
+
+// splitPageSuffix separates the page token from a command argument, page 1 when there is none
+func splitPageSuffix(value string) (string, int) {
+	match := pageSuffixPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, 1
+	}
+	page, err := strconv.Atoi(match[1])
+	if err != nil || page < 1 {
+		return value, 1
+	}
+	return strings.TrimSuffix(value, match[0]), page
+}
+
+// pageNavigation lists the previous and next page commands, empty when there is a single page
+func pageNavigation(command string, page int, hasNext bool) string {
+	if page <= 1 && !hasNext {
+		return ""
+	}
+	var navigation strings.Builder
+	navigation.WriteString("📄 <b>Navigation:</b>\n")
+	if page > 1 {
+		navigation.WriteString(fmt.Sprintf("  ⬅️ %s_p%d (Previous)\n", command, page-1))
+	}
+	if hasNext {
+		navigation.WriteString(fmt.Sprintf("  ➡️ %s_p%d (Next)\n", command, page+1))
+	}
+	return navigation.String() + "\n"
+}
+
+// searchQueries remembers the last /search query of every chat, so /search_p2 pages through it
+type searchQueries struct {
+	mu      sync.Mutex
+	byChats map[int64]string
+}
+
+func (s *searchQueries) set(chatID int64, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byChats == nil {
+		s.byChats = make(map[int64]string)
+	}
+	s.byChats[chatID] = query
+}
+
+func (s *searchQueries) get(chatID int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.byChats[chatID]
+}
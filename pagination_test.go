@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPageSuffix(t *testing.T) {
+	reference, page := splitPageSuffix("alice_p3")
+	assert.Equal(t, "alice", reference)
+	assert.Equal(t, 3, page)
+
+	reference, page = splitPageSuffix("bob_the_builder")
+	assert.Equal(t, "bob_the_builder", reference)
+	assert.Equal(t, 1, page)
+
+	reference, page = splitPageSuffix("carol_p0")
+	assert.Equal(t, "carol_p0", reference)
+	assert.Equal(t, 1, page)
+}
+
+func TestHistoryAndSearch_Pagination(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "alice", Name: "Alice"}))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < HISTORY_PAGE_SIZE+5; i++ {
+		require.NoError(t, db.SaveTweet(TweetModel{ID: fmt.Sprintf("t%02d", i), UserID: "u1", Text: fmt.Sprintf("message %d", i), CreatedAt: start.Add(time.Duration(i) * time.Hour)}))
+	}
+	for i := 0; i < SEARCH_PAGE_SIZE+1; i++ {
+		require.NoError(t, db.SaveUser(UserModel{ID: fmt.Sprintf("h%02d", i), Username: fmt.Sprintf("holder%02d", i)}))
+	}
+
+	lastSent := func() string {
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+		return transport.sent[len(transport.sent)-1].Text
+	}
+
+	bot.handleHistoryCommand(1, "/history_alice")
+	first := lastSent()
+	assert.Contains(t, first, "<code>t24</code>")
+	assert.NotContains(t, first, "<code>t04</code>")
+	assert.Contains(t, first, "/history_alice_p2 (Next)")
+	assert.NotContains(t, first, "(Previous)")
+
+	bot.handleHistoryCommand(1, "/history_alice_p2")
+	second := lastSent()
+	assert.Contains(t, second, "<code>t04</code>")
+	assert.Contains(t, second, "<b>21.</b>")
+	assert.Contains(t, second, "/history_alice_p1 (Previous)")
+	assert.NotContains(t, second, "(Next)")
+
+	bot.handleSearchCommand(1, "/search", []string{"holder"})
+	assert.Contains(t, lastSent(), "@holder19")
+	assert.NotContains(t, lastSent(), "@holder20")
+	assert.Contains(t, lastSent(), "/search_p2 (Next)")
+
+	// The next page reuses the chat's last query
+	bot.handleSearchCommand(1, "/search_p2", nil)
+	assert.Contains(t, lastSent(), "@holder20")
+	assert.NotContains(t, lastSent(), "@alice")
+	assert.Contains(t, lastSent(), "/search_p1 (Previous)")
+}
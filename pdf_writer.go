@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A4 in points
+const (
+	PDF_PAGE_WIDTH  = 595.0
+	PDF_PAGE_HEIGHT = 842.0
+	PDF_MARGIN      = 50.0
+)
+
+const (
+	PDF_FONT_REGULAR = "F1" // Helvetica
+	PDF_FONT_BOLD    = "F2" // Helvetica-Bold
+)
+
+// pdfDocument lays out text on A4 pages with the standard Helvetica fonts, enough for reports without
+// a PDF dependency. Characters outside Latin-1 (emoji, CJK) are replaced, the standard fonts lack them.
+type pdfDocument struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func newPDFDocument(title string) *pdfDocument {
+	doc := &pdfDocument{title: title}
+	doc.newPage()
+	return doc
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PDF_PAGE_HEIGHT - PDF_MARGIN
+}
+
+// line writes one line of text, starting a new page when the current one is full
+func (d *pdfDocument) line(font string, size float64, indent float64, text string) {
+	leading := size * 1.4
+	if d.y-leading < PDF_MARGIN {
+		d.newPage()
+	}
+	d.y -= leading
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, PDF_MARGIN+indent, d.y, pdfEscape(text))
+}
+
+// Title writes the document title
+func (d *pdfDocument) Title(text string) {
+	d.line(PDF_FONT_BOLD, 18, 0, text)
+	d.Space()
+}
+
+// Heading starts a section
+func (d *pdfDocument) Heading(text string) {
+	d.Space()
+	d.line(PDF_FONT_BOLD, 13, 0, text)
+	d.y -= 4
+}
+
+// Paragraph writes wrapped text
+func (d *pdfDocument) Paragraph(text string) {
+	d.wrapped(PDF_FONT_REGULAR, 10, 0, text)
+}
+
+// Field writes a "label: value" line, the value wrapped under the label
+func (d *pdfDocument) Field(label string, value string) {
+	if value == "" {
+		value = "-"
+	}
+	d.wrapped(PDF_FONT_REGULAR, 10, 0, label+": "+value)
+}
+
+// Bullet writes a wrapped list item
+func (d *pdfDocument) Bullet(text string) {
+	for i, line := range wrapPDFText(text, pdfLineChars(10, 12)) {
+		if i == 0 {
+			d.line(PDF_FONT_REGULAR, 10, 0, "- "+line)
+			continue
+		}
+		d.line(PDF_FONT_REGULAR, 10, 12, line)
+	}
+}
+
+// Space leaves an empty line
+func (d *pdfDocument) Space() {
+	d.y -= 8
+}
+
+func (d *pdfDocument) wrapped(font string, size float64, indent float64, text string) {
+	for _, line := range wrapPDFText(text, pdfLineChars(size, indent)) {
+		d.line(font, size, indent, line)
+	}
+}
+
+// pdfLineChars estimates how many Helvetica characters fit on a line, erring on the short side
+func pdfLineChars(size float64, indent float64) int {
+	return int((PDF_PAGE_WIDTH - 2*PDF_MARGIN - indent) / (size * 0.55))
+}
+
+// wrapPDFText breaks text into lines of at most width characters, splitting words longer than a line
+func wrapPDFText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			for len(runes) > width {
+				if len(line) > 0 {
+					lines = append(lines, string(line))
+					line = nil
+				}
+				lines = append(lines, string(runes[:width]))
+				runes = runes[width:]
+			}
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// pdfEscape encodes text as the body of a PDF string literal in WinAnsiEncoding
+func pdfEscape(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r == '\t':
+			out.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7F && r < 0xA0):
+			continue
+		case r < 0x7F:
+			out.WriteByte(byte(r))
+		case r <= 0xFF:
+			fmt.Fprintf(&out, "\\%03o", r)
+		case unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r):
+			// Emoji are dropped rather than shown as question marks
+			continue
+		default:
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
+
+// Bytes renders the document
+func (d *pdfDocument) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1 catalog, 2 page tree, 3 and 4 fonts, 5 info, then a page and its content per page
+	pageIDs := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIDs, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (FUD monitoring bot) >>", pdfEscape(d.title)))
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("BT /%s 8.0 Tf %.1f %.1f Td (Page %d of %d) Tj ET\n", PDF_FONT_REGULAR, PDF_MARGIN, PDF_MARGIN/2, i+1, len(d.pages))
+		content := page.String() + footer
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			PDF_PAGE_WIDTH, PDF_PAGE_HEIGHT, PDF_FONT_REGULAR, PDF_FONT_BOLD, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Verdict poll options, in the order they are shown
+const (
+	VERDICT_POLL_OPTION_FUD = iota
+	VERDICT_POLL_OPTION_CLEAN
+	VERDICT_POLL_OPTION_MORE_INFO
+)
+
+const (
+	VERDICT_POLL_DEFAULT_QUORUM = 3
+	VERDICT_POLL_MAX_QUORUM     = 50
+	VERDICT_POLL_MORE_INFO      = "needs_more_info"
+)
+
+var verdictPollOptions = []string{"Yes, FUD", "No, clean", "Needs more info"}
+
+// verdictPollQuorum returns the votes one answer needs to close a poll, ENV_VERDICT_POLL_QUORUM or 3
+func verdictPollQuorum() int {
+	quorum, err := strconv.Atoi(os.Getenv(ENV_VERDICT_POLL_QUORUM))
+	if err != nil || quorum < 1 {
+		return VERDICT_POLL_DEFAULT_QUORUM
+	}
+	return min(quorum, VERDICT_POLL_MAX_QUORUM)
+}
+
+// verdictPollChat returns the moderator chat polls are posted to, the requesting chat when none is set
+func verdictPollChat(chatID int64) int64 {
+	if moderatorChatID, err := strconv.ParseInt(os.Getenv(ENV_VERDICT_POLL_CHAT_ID), 10, 64); err == nil {
+		return moderatorChatID
+	}
+	return chatID
+}
+
+// handlePollCommand asks the moderators for a team verdict on an ambiguous user:
+// /poll username [quorum], /poll lists the open polls
+func (b *BotController) handlePollCommand(chatID int64, actor string, args []string) {
+	if len(args) == 0 {
+		b.handlePollList(chatID)
+		return
+	}
+	quorum := verdictPollQuorum()
+	if len(args) > 2 {
+		b.SendMessage(chatID, "❌ Usage: /poll username [quorum]")
+		return
+	}
+	if len(args) == 2 {
+		value, err := strconv.Atoi(args[1])
+		if err != nil || value < 1 || value > VERDICT_POLL_MAX_QUORUM {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Invalid quorum %s, use 1 to %d votes", html.EscapeString(args[1]), VERDICT_POLL_MAX_QUORUM))
+			return
+		}
+		quorum = value
+	}
+
+	username, _ := b.resolveTwitterReference(args[0])
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ User @%s not found in database", html.EscapeString(username)))
+		return
+	}
+	open, err := b.dbService.GetOpenVerdictPolls()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading polls: %v", err))
+		return
+	}
+	for _, poll := range open {
+		if poll.UserID == user.ID {
+			b.SendMessage(chatID, fmt.Sprintf("❌ A poll on @%s is already open, requested by %s", user.Username, html.EscapeString(poll.CreatedBy)))
+			return
+		}
+	}
+
+	pollChatID := verdictPollChat(chatID)
+	context := fmt.Sprintf("🗳 <b>Team verdict on @%s</b>\nRequested by %s · the first answer with %d votes decides\n", user.Username, html.EscapeString(actor), quorum)
+	if analysis, err := b.dbService.GetCachedAnalysis(user.ID); err == nil {
+		verdict := "clean"
+		if analysis.IsFUDUser {
+			verdict = "FUD, " + analysis.FUDType
+		}
+		context += fmt.Sprintf("🤖 Analysis: %s (%.0f%%) · <i>%s</i>\n", html.EscapeString(verdict), analysis.FUDProbability*100, b.formatter.escapeTruncated(analysis.DecisionReason, 300))
+	} else {
+		context += "🤖 Not analyzed yet\n"
+	}
+	context += fmt.Sprintf("🔍 /history_%s · /cache_%s", user.Username, user.Username)
+	if err := b.SendMessage(pollChatID, context); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error posting to chat %d: %v", pollChatID, err))
+		return
+	}
+
+	options := make([]TelegramPollOption, 0, len(verdictPollOptions))
+	for _, option := range verdictPollOptions {
+		options = append(options, TelegramPollOption{Text: option})
+	}
+	sent, err := b.transport.SendPoll(TelegramSendPollRequest{ChatID: pollChatID, Question: fmt.Sprintf("Is @%s FUD?", user.Username), Options: options})
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending poll: %v", err))
+		return
+	}
+	poll := &VerdictPollModel{PollID: sent.PollID, ChatID: pollChatID, MessageID: sent.MessageID, UserID: user.ID, Username: user.Username, Quorum: quorum, CreatedBy: actor}
+	if err := b.dbService.SaveVerdictPoll(poll); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving poll: %v", err))
+		return
+	}
+	log.Printf("🗳 %s opened a verdict poll on @%s in chat %d", actor, user.Username, pollChatID)
+	if pollChatID != chatID {
+		b.SendMessage(chatID, fmt.Sprintf("✅ Poll on @%s posted to the moderator chat, %d votes decide", user.Username, quorum))
+	}
+}
+
+func (b *BotController) handlePollList(chatID int64) {
+	polls, err := b.dbService.GetOpenVerdictPolls()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading polls: %v", err))
+		return
+	}
+	if len(polls) == 0 {
+		b.SendMessage(chatID, "🗳 No open verdict polls.\n\nUsage: /poll username [quorum]")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🗳 <b>Open verdict polls</b> (%d)\n\n", len(polls)))
+	for _, poll := range polls {
+		counts := make([]int, len(verdictPollOptions))
+		if votes, err := b.dbService.GetVerdictPollVotes(poll.PollID); err == nil {
+			for _, vote := range votes {
+				counts[vote.Choice]++
+			}
+		}
+		message.WriteString(fmt.Sprintf("• @%s: %d FUD · %d clean · %d more info, %d decide\n   ↳ requested by %s on %s\n", poll.Username, counts[VERDICT_POLL_OPTION_FUD], counts[VERDICT_POLL_OPTION_CLEAN], counts[VERDICT_POLL_OPTION_MORE_INFO], poll.Quorum, html.EscapeString(poll.CreatedBy), poll.CreatedAt.UTC().Format("2006-01-02 15:04")))
+	}
+	b.SendMessage(chatID, strings.TrimRight(message.String(), "\n"))
+}
+
+// handlePollAnswer records a moderator's vote and closes the poll once an answer reaches the quorum.
+// A FUD or clean majority is recorded like /mark_fud or /mark_clean, by the voters who gave it.
+func (b *BotController) handlePollAnswer(answer TelegramPollAnswer) {
+	poll, err := b.dbService.GetVerdictPoll(answer.PollID)
+	if err != nil || poll.ClosedAt != nil {
+		return
+	}
+	voter := answer.User.FirstName
+	if answer.User.Username != "" {
+		voter = "@" + answer.User.Username
+	}
+	vote := VerdictPollVoteModel{PollID: poll.PollID, VoterID: answer.User.ID, VoterName: voter}
+	if len(answer.OptionIDs) > 0 {
+		vote.Choice = answer.OptionIDs[0]
+	}
+	if vote.Choice < 0 || vote.Choice >= len(verdictPollOptions) {
+		return
+	}
+	if err := b.dbService.SetVerdictPollVote(vote, len(answer.OptionIDs) == 0); err != nil {
+		log.Printf("Failed to record vote of %s in poll on %s: %v", voter, poll.Username, err)
+		return
+	}
+
+	votes, err := b.dbService.GetVerdictPollVotes(poll.PollID)
+	if err != nil {
+		log.Printf("Failed to count votes of poll on %s: %v", poll.Username, err)
+		return
+	}
+	counts := make([]int, len(verdictPollOptions))
+	voters := make([][]string, len(verdictPollOptions))
+	for _, vote := range votes {
+		counts[vote.Choice]++
+		voters[vote.Choice] = append(voters[vote.Choice], vote.VoterName)
+	}
+	decided := -1
+	for option, count := range counts {
+		if count >= poll.Quorum {
+			decided = option
+		}
+	}
+	if decided < 0 {
+		return
+	}
+
+	result := VERDICT_POLL_MORE_INFO
+	switch decided {
+	case VERDICT_POLL_OPTION_FUD:
+		result = LABEL_FUD
+	case VERDICT_POLL_OPTION_CLEAN:
+		result = LABEL_CLEAN
+	}
+	closed, err := b.dbService.CloseVerdictPoll(poll.PollID, result)
+	if err != nil || !closed {
+		return
+	}
+	if err := b.transport.StopPoll(poll.ChatID, poll.MessageID); err != nil {
+		log.Printf("Failed to stop poll on %s: %v", poll.Username, err)
+	}
+	tally := fmt.Sprintf("%d FUD, %d clean, %d more info", counts[VERDICT_POLL_OPTION_FUD], counts[VERDICT_POLL_OPTION_CLEAN], counts[VERDICT_POLL_OPTION_MORE_INFO])
+	log.Printf("🗳 Poll on @%s closed: %s (%s)", poll.Username, result, tally)
+
+	if result == VERDICT_POLL_MORE_INFO {
+		b.SendMessage(poll.ChatID, fmt.Sprintf("🔍 The team needs more information on @%s (%s), no verdict recorded.\n/history_%s · /poll %s to ask again", poll.Username, tally, poll.Username, poll.Username))
+		return
+	}
+	user, err := b.dbService.GetUser(poll.UserID)
+	if err != nil {
+		b.SendMessage(poll.ChatID, fmt.Sprintf("❌ Poll on @%s decided %s, but the user is gone: %v", poll.Username, result, err))
+		return
+	}
+	verdict, kept, err := b.recordLabeledVerdict(user, result, "", "team poll: "+tally, "poll: "+strings.Join(voters[decided], ", "), poll.ChatID)
+	if err != nil {
+		b.SendMessage(poll.ChatID, fmt.Sprintf("❌ Error saving verdict: %v", err))
+		return
+	}
+	b.SendMessage(poll.ChatID, "🗳 Poll closed\n"+b.formatLabeledVerdict(verdict, kept))
+}
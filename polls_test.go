@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_VerdictPolls(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	t.Setenv(ENV_VERDICT_POLL_CHAT_ID, "-500")
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "Ambiguous"}))
+	require.NoError(t, db.SaveUser(UserModel{ID: "u2", Username: "Skeptic"}))
+	reply := func(text string) string {
+		bot.handlePollCommand(1, "@lead", strings.Fields(text)[1:])
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+	vote := func(pollID string, voterID int64, username string, options ...int) {
+		answer := TelegramPollAnswer{PollID: pollID, OptionIDs: options}
+		answer.User.ID, answer.User.Username = voterID, username
+		bot.handlePollAnswer(answer)
+	}
+
+	assert.Contains(t, reply("/poll"), "No open verdict polls")
+	assert.Contains(t, reply("/poll nobody"), "not found")
+	assert.Contains(t, reply("/poll Ambiguous 0"), "Invalid quorum")
+	assert.Contains(t, reply("/poll @Ambiguous 2"), "posted to the moderator chat, 2 votes decide")
+	require.Len(t, transport.polls, 1)
+	assert.Equal(t, int64(-500), transport.polls[0].ChatID)
+	assert.Equal(t, "Is @Ambiguous FUD?", transport.polls[0].Question)
+	assert.False(t, transport.polls[0].IsAnonymous, "answers name the voters")
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-2].Text, "Not analyzed yet")
+	assert.Contains(t, reply("/poll ambiguous"), "already open")
+	assert.Contains(t, reply("/poll Skeptic"), "3 votes decide")
+
+	vote("poll1", 10, "mod1", VERDICT_POLL_OPTION_FUD)
+	vote("poll1", 11, "mod2", VERDICT_POLL_OPTION_MORE_INFO)
+	list := reply("/poll")
+	assert.Contains(t, list, "@Ambiguous: 1 FUD · 0 clean · 1 more info, 2 decide")
+	assert.Contains(t, list, "@Skeptic")
+
+	vote("poll1", 11, "mod2", VERDICT_POLL_OPTION_FUD)
+	assert.Len(t, transport.stoppedPolls, 1)
+	sent = transport.sentMessages()
+	closing := sent[len(sent)-1]
+	assert.Equal(t, int64(-500), closing.ChatID)
+	assert.Contains(t, closing.Text, "Poll closed")
+	assert.Contains(t, closing.Text, "@Ambiguous marked as FUD")
+	verdict, err := db.GetLatestLabeledVerdict("u1")
+	require.NoError(t, err)
+	assert.Equal(t, LABEL_FUD, verdict.Label)
+	assert.Equal(t, "poll: @mod1, @mod2", verdict.LabeledBy)
+	assert.Equal(t, "team poll: 2 FUD, 0 clean, 0 more info", verdict.Note)
+	assert.True(t, db.IsFUDUser("u1"))
+
+	vote("poll1", 12, "late", VERDICT_POLL_OPTION_CLEAN)
+	assert.Len(t, transport.sentMessages(), len(sent), "closed polls ignore votes")
+
+	vote("poll2", 10, "mod1", VERDICT_POLL_OPTION_MORE_INFO)
+	vote("poll2", 11, "mod2", VERDICT_POLL_OPTION_MORE_INFO)
+	vote("poll2", 11, "mod2")
+	vote("poll2", 12, "mod3", VERDICT_POLL_OPTION_MORE_INFO)
+	assert.Len(t, transport.stoppedPolls, 1, "a retracted vote does not count")
+	vote("poll2", 11, "mod2", VERDICT_POLL_OPTION_MORE_INFO)
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "needs more information on @Skeptic")
+	_, err = db.GetLatestLabeledVerdict("u2")
+	assert.Error(t, err, "no verdict without a FUD or clean majority")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+)
+
+const PRIOR_ALERTS_SHOWN = 3 // earlier alerts listed on a new alert, the rest are counted
+
+// PriorAlertHistory is what moderators already saw and decided about the user of an alert
+type PriorAlertHistory struct {
+	Total  int          `json:"total"`
+	Alerts []PriorAlert `json:"alerts,omitempty"` // newest first
+}
+
+// PriorAlert is an earlier stored alert with the first human verdict given after it
+type PriorAlert struct {
+	NotificationID string    `json:"notification_id"`
+	DetectedAt     time.Time `json:"detected_at"`
+	Severity       string    `json:"severity"`
+	FUDType        string    `json:"fud_type"`
+	Verdict        string    `json:"verdict,omitempty"` // "fud" or "clean"
+	VerdictBy      string    `json:"verdict_by,omitempty"`
+}
+
+// attachPriorAlerts looks up the earlier alerts about the user before the new one is stored
+func (b *BotController) attachPriorAlerts(alert *FUDAlertNotification) {
+	if alert.PriorAlerts != nil || alert.FUDUserID == "" || b.dbService == nil {
+		return
+	}
+	history, err := priorAlertHistory(b.dbService, alert.FUDUserID)
+	if err != nil {
+		log.Printf("Failed to load prior alerts for %s: %v", alert.FUDUsername, err)
+		return
+	}
+	alert.PriorAlerts = history
+}
+
+// priorAlertHistory lists the newest logged alerts about a user with their human verdicts, including
+// alerts whose notification expired
+func priorAlertHistory(dbService *DatabaseService, userID string) (*PriorAlertHistory, error) {
+	entries, total, err := dbService.GetUserAlertLog(userID, PRIOR_ALERTS_SHOWN)
+	if err != nil {
+		return nil, err
+	}
+	verdicts, err := dbService.GetUserLabeledVerdicts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &PriorAlertHistory{Total: int(total)}
+	for _, entry := range entries {
+		prior := PriorAlert{
+			NotificationID: entry.NotificationID,
+			DetectedAt:     entry.CreatedAt,
+			Severity:       entry.AlertSeverity,
+			FUDType:        entry.FUDType,
+		}
+		for _, verdict := range verdicts {
+			if !verdict.CreatedAt.Before(entry.CreatedAt) {
+				prior.Verdict, prior.VerdictBy = verdict.Label, verdict.LabeledBy
+				break
+			}
+		}
+		history.Alerts = append(history.Alerts, prior)
+	}
+	return history, nil
+}
+
+// formatPriorAlerts tells moderators whether the user is a repeat offender, with links to the earlier alerts
+func (nf *NotificationFormatter) formatPriorAlerts(alert FUDAlertNotification) string {
+	history := alert.PriorAlerts
+	if history == nil {
+		return ""
+	}
+	if history.Total == 0 {
+		return "\n🆕 <b>Prior alerts:</b> none, first flag for this user"
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("\n🔁 <b>Prior alerts:</b> %d", history.Total))
+	for _, prior := range history.Alerts {
+		verdict := "no human verdict"
+		if prior.Verdict != "" {
+			verdict = fmt.Sprintf("👮 %s by %s", html.EscapeString(prior.Verdict), html.EscapeString(prior.VerdictBy))
+		}
+		message.WriteString(fmt.Sprintf("\n  • %s %s, %s · /detail_%s · %s",
+			prior.DetectedAt.Format("2006-01-02"),
+			strings.ToUpper(prior.Severity),
+			html.EscapeString(nf.formatFUDType(prior.FUDType)),
+			prior.NotificationID,
+			verdict))
+	}
+	if hidden := history.Total - len(history.Alerts); hidden > 0 {
+		message.WriteString(fmt.Sprintf("\n  … and %d older", hidden))
+	}
+	return message.String()
+}
+
+// formatPriorAlertsCompact is the prior alerts part of a compact alert line
+func (nf *NotificationFormatter) formatPriorAlertsCompact(alert FUDAlertNotification) string {
+	switch {
+	case alert.PriorAlerts == nil:
+		return ""
+	case alert.PriorAlerts.Total == 0:
+		return " · 🆕 first flag"
+	default:
+		return fmt.Sprintf(" · 🔁 %d prior", alert.PriorAlerts.Total)
+	}
+}
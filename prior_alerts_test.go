@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_PriorAlerts(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+	lastAlert := func() string {
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	first := benchmarkAlert()
+	require.NoError(t, bot.StoreAndBroadcastNotification(first))
+	assert.Contains(t, lastAlert(), "Prior alerts:</b> none, first flag for this user")
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: first.FUDUserID, Username: first.FUDUsername, Label: LABEL_FUD, LabeledBy: "@mod"}))
+
+	second := benchmarkAlert()
+	second.AlertSeverity = "medium"
+	require.NoError(t, bot.StoreAndBroadcastNotification(second))
+	require.NoError(t, bot.StoreAndBroadcastNotification(benchmarkAlert()))
+	text := lastAlert()
+	assert.Contains(t, text, "Prior alerts:</b> 2")
+	assert.Contains(t, text, "MEDIUM, Professional Trojan Horse · /detail_")
+	assert.Contains(t, text, "no human verdict", "the verdict came before the second alert")
+	assert.Contains(t, text, "👮 fud by @mod", "the first alert got the moderator's verdict")
+
+	stored, total, err := db.GetUserNotifications(first.FUDUserID, PRIOR_ALERTS_SHOWN)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	detail, err := db.GetNotification(stored[0].NotificationID)
+	require.NoError(t, err)
+	require.NotNil(t, detail.PriorAlerts, "/detail_ keeps the history the alert was sent with")
+	assert.Equal(t, 2, detail.PriorAlerts.Total)
+
+	compact := bot.formatter.FormatCompact(*detail, stored[0].NotificationID)
+	assert.Contains(t, compact, "🔁 2 prior")
+	other := benchmarkAlert()
+	other.FUDUserID = "someone_else"
+	bot.attachPriorAlerts(&other)
+	assert.Contains(t, bot.formatter.FormatCompact(other, ""), "🆕 first flag")
+
+	t.Run("History outlives the notifications", func(t *testing.T) {
+		require.NoError(t, db.db.Model(&NotificationModel{}).Where("fud_user_id = ?", first.FUDUserID).
+			Updates(map[string]interface{}{"expires_at": time.Now().Add(-time.Minute), "state": ALERT_STATE_RESOLVED}).Error)
+		deleted, err := db.DeleteExpiredNotifications()
+		require.NoError(t, err)
+		require.Equal(t, int64(3), deleted)
+
+		again := benchmarkAlert()
+		bot.attachPriorAlerts(&again)
+		require.NotNil(t, again.PriorAlerts)
+		assert.Equal(t, 3, again.PriorAlerts.Total)
+		require.Len(t, again.PriorAlerts.Alerts, PRIOR_ALERTS_SHOWN)
+		assert.Equal(t, LABEL_FUD, again.PriorAlerts.Alerts[2].Verdict)
+		assert.Equal(t, "@mod", again.PriorAlerts.Alerts[2].VerdictBy)
+		assert.Equal(t, first.FUDType, again.PriorAlerts.Alerts[2].FUDType)
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+const PPROF_TOKEN_HEADER = "X-Pprof-Token"
+
+// StartProfilingServer exposes net/http/pprof handlers on a dedicated listener.
+// Profiling is disabled when addr is empty. Without a token the server refuses
+// to bind anything but a loopback address so profiles are never exposed publicly.
+func StartProfilingServer(addr string, token string) error {
+	if addr == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid pprof address %s: %w", addr, err)
+	}
+	if token == "" && !isLoopbackHost(host) {
+		return fmt.Errorf("pprof on non-loopback address %s requires %s to be set", addr, ENV_PPROF_TOKEN)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start pprof listener: %w", err)
+	}
+
+	go func() {
+		err := http.Serve(listener, tokenGuard(PPROF_TOKEN_HEADER, token, mux))
+		if err != nil {
+			log.Printf("pprof server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Profiling endpoints available at http://%s/debug/pprof/", listener.Addr())
+	return nil
+}
+
+// tokenGuard rejects requests without the configured token (header or ?token=)
+func tokenGuard(header string, token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			provided := r.Header.Get(header)
+			if provided == "" {
+				provided = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	PROGRESS_REFRESH_INTERVAL = 12 * time.Second // progress messages are edited on step changes and at most this often otherwise
+	PROGRESS_EDITS_PER_SEC    = 5                // share of Telegram's global limit left to progress edits, alerts keep the rest
+)
+
+// progressEditor is the shared queue all progress messages are edited through. It keeps only the
+// latest text per message, skips edits that would not change the message and sends them one by one
+// at PROGRESS_EDITS_PER_SEC, so many concurrent tasks cannot flood Telegram.
+type progressEditor struct {
+	mu      sync.Mutex
+	pending map[progressKey]*progressEdit
+	order   []progressKey
+	last    map[progressKey]string // text currently shown by each message
+	running bool
+}
+
+type progressKey struct {
+	ChatID    int64
+	MessageID int64
+}
+
+type progressEdit struct {
+	text  string
+	final bool
+	done  chan error // set for final edits, receives the result
+}
+
+// queue schedules an edit of a progress message, replacing an edit of the same message still waiting
+func (p *progressEditor) queue(b *BotController, chatID int64, messageID int64, text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := progressKey{chatID, messageID}
+	if p.last[key] == text {
+		return
+	}
+	if edit, ok := p.pending[key]; ok {
+		// The final state of a message is never replaced by a progress update
+		if !edit.final {
+			edit.text = text
+		}
+		return
+	}
+	p.enqueue(b, key, &progressEdit{text: text}, false)
+}
+
+// editNow puts the final state of a progress message in front of the queue, dropping any waiting
+// update of it, and returns once it has been sent
+func (p *progressEditor) editNow(b *BotController, chatID int64, messageID int64, text string) error {
+	edit := &progressEdit{text: text, final: true, done: make(chan error, 1)}
+	p.mu.Lock()
+	key := progressKey{chatID, messageID}
+	if _, ok := p.pending[key]; ok {
+		for i, queued := range p.order {
+			if queued == key {
+				p.order = append(p.order[:i], p.order[i+1:]...)
+				break
+			}
+		}
+	}
+	p.enqueue(b, key, edit, true)
+	p.mu.Unlock()
+	return <-edit.done
+}
+
+// enqueue adds an edit and starts the sender if it is idle, p.mu must be held
+func (p *progressEditor) enqueue(b *BotController, key progressKey, edit *progressEdit, first bool) {
+	if p.pending == nil {
+		p.pending = make(map[progressKey]*progressEdit)
+		p.last = make(map[progressKey]string)
+	}
+	p.pending[key] = edit
+	if first {
+		p.order = append([]progressKey{key}, p.order...)
+	} else {
+		p.order = append(p.order, key)
+	}
+	if !p.running {
+		p.running = true
+		go p.run(b)
+	}
+}
+
+// run sends the queued edits until the queue is empty
+func (p *progressEditor) run(b *BotController) {
+	limiter := time.NewTicker(time.Second / PROGRESS_EDITS_PER_SEC)
+	defer limiter.Stop()
+	for {
+		p.mu.Lock()
+		if len(p.order) == 0 {
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+		key := p.order[0]
+		p.order = p.order[1:]
+		edit := p.pending[key]
+		delete(p.pending, key)
+		p.mu.Unlock()
+
+		err := b.EditMessage(key.ChatID, key.MessageID, edit.text)
+		p.mu.Lock()
+		switch {
+		case edit.final:
+			// Nothing edits the message after its final state
+			delete(p.last, key)
+		case err == nil:
+			p.last[key] = edit.text
+		}
+		p.mu.Unlock()
+		if edit.done != nil {
+			edit.done <- err
+		} else if err != nil {
+			log.Printf("Failed to update progress message %d in chat %d: %v", key.MessageID, key.ChatID, err)
+		}
+		<-limiter.C
+	}
+}
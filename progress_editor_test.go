@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressEditor_CoalescesAndFinalWins(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	edits := func() []string {
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+		var texts []string
+		for _, edit := range transport.edited {
+			texts = append(texts, edit.Text)
+		}
+		return texts
+	}
+
+	bot.progress.queue(bot, 1, 10, "step 1")
+	bot.progress.queue(bot, 1, 10, "step 2")
+	bot.progress.queue(bot, 1, 10, "step 3")
+	bot.progress.queue(bot, 2, 20, "other")
+	assert.Eventually(t, func() bool { return len(edits()) >= 2 }, 2*time.Second, 10*time.Millisecond)
+	texts := edits()
+	assert.NotContains(t, texts, "step 2", "waiting updates of a message are replaced by the latest one")
+	assert.Contains(t, texts, "other")
+
+	time.Sleep(500 * time.Millisecond)
+	before := len(edits())
+	bot.progress.queue(bot, 2, 20, "other")
+	time.Sleep(500 * time.Millisecond)
+	assert.Len(t, edits(), before, "unchanged text is not sent again")
+
+	bot.progress.queue(bot, 1, 10, "step 4")
+	require.NoError(t, bot.progress.editNow(bot, 1, 10, "done"))
+	texts = edits()
+	assert.Equal(t, "done", texts[len(texts)-1], "the final edit is sent before editNow returns")
+	assert.NotContains(t, texts, "step 4", "the final edit drops the waiting update")
+}
+
+func TestProgressEditor_RateLimited(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	start := time.Now()
+	for i := int64(0); i < PROGRESS_EDITS_PER_SEC+1; i++ {
+		bot.progress.queue(bot, i, 1, "progress")
+	}
+	require.NoError(t, bot.progress.editNow(bot, 100, 1, "done"))
+	assert.Eventually(t, func() bool {
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+		return len(transport.edited) == PROGRESS_EDITS_PER_SEC+2
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "edits are spread over time")
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PROMPT_STEP_FIRST     = "first"
+	PROMPT_STEP_SECOND    = "second"
+	PROMPT_ORIGIN_FILE    = "file"
+	PROMPT_ORIGIN_CHAT    = "telegram"
+	PROMPT_MIN_LENGTH     = 50 // a shorter prompt is almost certainly a mistake
+	PROMPT_VERSIONS_SHOWN = 10
+)
+
+// promptStepFiles maps the analysis steps to the prompt files they are seeded from
+var promptStepFiles = map[string]string{
+	PROMPT_STEP_FIRST:  PROMPT_FILE_STEP1,
+	PROMPT_STEP_SECOND: PROMPT_FILE_STEP2,
+}
+
+// PromptManager serves the active system prompt of each analysis step. Versions are stored in the
+// database: a prompt file that changed since the last start becomes a new version, /prompt set adds
+// one from Telegram and /prompt rollback activates an older one, all without a restart. Language
+// variants only apply while the active version is the current prompt file.
+type PromptManager struct {
+	mu       sync.RWMutex
+	db       *DatabaseService
+	source   PromptSource
+	prompts  map[string]*PromptSet
+	versions map[string]int
+}
+
+// NewPromptManager loads the prompt files from source and syncs them with the stored versions
+func NewPromptManager(dbService *DatabaseService, source PromptSource) (*PromptManager, error) {
+	manager := &PromptManager{db: dbService, source: source, prompts: map[string]*PromptSet{}, versions: map[string]int{}}
+	_, err := manager.Reload("startup")
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// NewStaticPromptManager serves fixed prompts without versions
+func NewStaticPromptManager(first *PromptSet, second *PromptSet) *PromptManager {
+	return &PromptManager{
+		prompts:  map[string]*PromptSet{PROMPT_STEP_FIRST: first, PROMPT_STEP_SECOND: second},
+		versions: map[string]int{},
+	}
+}
+
+// Prompt returns the active prompt of a step and its version, 0 when it is not versioned
+func (m *PromptManager) Prompt(step string) (*PromptSet, int) {
+	if m == nil {
+		return nil, 0
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.prompts[step], m.versions[step]
+}
+
+// VersionLabel names the prompt version that produced an analysis, e.g. "second v3"
+func (m *PromptManager) VersionLabel(step string) string {
+	_, version := m.Prompt(step)
+	return promptVersionLabel(step, version)
+}
+
+func promptVersionLabel(step string, version int) string {
+	if version == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s v%d", step, version)
+}
+
+// Reload re-reads the prompt files. A file that changed since it was last stored becomes the new
+// active version; otherwise the active version, possibly set from Telegram, stays.
+func (m *PromptManager) Reload(actor string) ([]string, error) {
+	var changes []string
+	for _, step := range []string{PROMPT_STEP_FIRST, PROMPT_STEP_SECOND} {
+		files, err := loadSystemPrompt(m.source, promptStepFiles[step])
+		if err != nil {
+			return changes, err
+		}
+		content := string(files.Default())
+		stored, err := m.db.GetLatestPromptVersionByOrigin(step, PROMPT_ORIGIN_FILE)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return changes, err
+		}
+		if stored == nil || stored.Content != content {
+			version := &PromptVersionModel{Step: step, Content: content, Checksum: sha256Hex([]byte(content))[:12], Origin: PROMPT_ORIGIN_FILE, CreatedBy: actor, Note: fmt.Sprintf("%s from %s", promptStepFiles[step], m.source)}
+			err = m.db.CreatePromptVersion(version)
+			if err != nil {
+				return changes, err
+			}
+			log.Printf("📝 Stored %s as %s prompt v%d", promptStepFiles[step], step, version.Version)
+			changes = append(changes, fmt.Sprintf("%s prompt: new version v%d from %s", step, version.Version, promptStepFiles[step]))
+		}
+		err = m.activate(step, files)
+		if err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+// activate loads the active stored version of a step into memory
+func (m *PromptManager) activate(step string, files *PromptSet) error {
+	active, err := m.db.GetActivePromptVersion(step)
+	if err != nil {
+		return fmt.Errorf("no active %s prompt: %w", step, err)
+	}
+	prompts := NewPromptSet([]byte(active.Content), nil)
+	if files != nil && active.Origin == PROMPT_ORIGIN_FILE && string(files.Default()) == active.Content {
+		prompts = files
+	}
+	m.mu.Lock()
+	m.prompts[step] = prompts
+	m.versions[step] = active.Version
+	m.mu.Unlock()
+	return nil
+}
+
+// Set stores a prompt written in Telegram as the new active version of a step
+func (m *PromptManager) Set(step string, content string, actor string) (*PromptVersionModel, error) {
+	version := &PromptVersionModel{Step: step, Content: content, Checksum: sha256Hex([]byte(content))[:12], Origin: PROMPT_ORIGIN_CHAT, CreatedBy: actor}
+	err := m.db.CreatePromptVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return version, m.activate(step, nil)
+}
+
+// Rollback activates an older version of a step's prompt, the one before the active version when number is 0
+func (m *PromptManager) Rollback(step string, number int) (*PromptVersionModel, error) {
+	if number == 0 {
+		_, active := m.Prompt(step)
+		number = active - 1
+	}
+	if number < 1 {
+		return nil, fmt.Errorf("there is no version before v1")
+	}
+	version, err := m.db.ActivatePromptVersion(step, number)
+	if err != nil {
+		return nil, err
+	}
+	// The files are read again so that rolling back to the current file brings its language variants back
+	files, err := loadSystemPrompt(m.source, promptStepFiles[step])
+	if err != nil {
+		files = nil
+	}
+	return version, m.activate(step, files)
+}
+
+// handlePromptCommand manages the versioned system prompts:
+// /prompt [list|show step [version]|set step <text>|rollback step [version]|reload]
+func (b *BotController) handlePromptCommand(chatID int64, actor string, text string, args []string) {
+	usage := "❌ Usage: /prompt [list|show first|second [version]|set first|second &lt;prompt text&gt;|rollback first|second [version]|reload]"
+	if b.prompts == nil {
+		b.SendMessage(chatID, "❌ Prompt versions are not available")
+		return
+	}
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handlePromptList(chatID)
+		return
+	}
+	action := strings.ToLower(args[0])
+	if action == "reload" {
+		changes, err := b.prompts.Reload(actor)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error reloading prompts: %v", err))
+			return
+		}
+		if len(changes) == 0 {
+			changes = []string{"prompt files unchanged"}
+		}
+		log.Printf("📝 %s reloaded the prompts: %s", actor, strings.Join(changes, ", "))
+		b.SendMessage(chatID, "🔄 Prompts reloaded\n• "+html.EscapeString(strings.Join(changes, "\n• ")))
+		return
+	}
+
+	if len(args) < 2 || promptStepFiles[strings.ToLower(args[1])] == "" {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	step := strings.ToLower(args[1])
+	number := 0
+	if len(args) > 2 && action != "set" {
+		var err error
+		number, err = strconv.Atoi(strings.TrimPrefix(strings.ToLower(args[2]), "v"))
+		if err != nil || number < 1 {
+			b.SendMessage(chatID, usage)
+			return
+		}
+	}
+
+	switch action {
+	case "show":
+		b.handlePromptShow(chatID, step, number)
+	case "set":
+		content := commandRemainder(text, 3)
+		if len(content) < PROMPT_MIN_LENGTH {
+			b.SendMessage(chatID, fmt.Sprintf("❌ The prompt must be at least %d characters. Prompts longer than a Telegram message go in %s, then /prompt reload.", PROMPT_MIN_LENGTH, promptStepFiles[step]))
+			return
+		}
+		version, err := b.prompts.Set(step, content, actor)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error saving prompt: %v", err))
+			return
+		}
+		log.Printf("📝 %s set %s prompt v%d", actor, step, version.Version)
+		b.SendMessage(chatID, fmt.Sprintf("✅ %s prompt v%d is active (%d characters, sha256 %s)\n↩️ /prompt rollback %s - Back to v%d", step, version.Version, len(content), version.Checksum, step, version.Version-1))
+	case "rollback":
+		version, err := b.prompts.Rollback(step, number)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error rolling back %s prompt: %v", step, err))
+			return
+		}
+		log.Printf("📝 %s rolled %s prompt back to v%d", actor, step, version.Version)
+		b.SendMessage(chatID, fmt.Sprintf("↩️ %s prompt v%d is active again (%s, by %s)", step, version.Version, version.Origin, html.EscapeString(version.CreatedBy)))
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+func (b *BotController) handlePromptList(chatID int64) {
+	var message strings.Builder
+	message.WriteString("📝 <b>System prompts</b>\n")
+	for _, step := range []string{PROMPT_STEP_FIRST, PROMPT_STEP_SECOND} {
+		versions, err := b.dbService.GetPromptVersions(step, PROMPT_VERSIONS_SHOWN)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading prompt versions: %v", err))
+			return
+		}
+		message.WriteString(fmt.Sprintf("\n<b>%s step</b>\n", step))
+		for _, version := range versions {
+			marker := "▫️"
+			if version.Active {
+				marker = "▶️"
+			}
+			message.WriteString(fmt.Sprintf("%s v%d %s — %s by %s, %s\n", marker, version.Version, version.Checksum, version.Origin, html.EscapeString(version.CreatedBy), version.CreatedAt.UTC().Format("2006-01-02 15:04")))
+		}
+	}
+	message.WriteString("\n/prompt show second · /prompt set second &lt;text&gt; · /prompt rollback second [version] · /prompt reload")
+	b.SendMessage(chatID, message.String())
+}
+
+// handlePromptShow sends a prompt version as a file, prompts rarely fit in a message
+func (b *BotController) handlePromptShow(chatID int64, step string, number int) {
+	if number == 0 {
+		_, number = b.prompts.Prompt(step)
+	}
+	version, err := b.dbService.GetPromptVersion(step, number)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ %s prompt v%d not found", step, number))
+		return
+	}
+	filename := fmt.Sprintf("prompt_%s_v%d_%s.txt", step, version.Version, time.Now().Format("20060102_150405"))
+	err = b.writeToFile(filename, version.Content)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error creating file: %v", err))
+		return
+	}
+	status := "inactive"
+	if version.Active {
+		status = "active"
+	}
+	caption := fmt.Sprintf("📝 <b>%s prompt v%d</b> (%s)\n🔑 sha256 %s\n👤 %s by %s on %s", step, version.Version, status, version.Checksum, version.Origin, html.EscapeString(version.CreatedBy), version.CreatedAt.UTC().Format("2006-01-02 15:04"))
+	err = b.SendDocument(chatID, filename, caption)
+	os.Remove(filename)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
+
+// commandRemainder returns the text after the first n words of a command, keeping its line breaks
+func commandRemainder(text string, n int) string {
+	text = strings.TrimSpace(text)
+	for i := 0; i < n && text != ""; i++ {
+		end := strings.IndexFunc(text, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' })
+		if end < 0 {
+			return ""
+		}
+		text = strings.TrimSpace(text[end:])
+	}
+	return text
+}
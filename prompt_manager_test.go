@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptManager_Versions(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+	writePrompt := func(name string, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	writePrompt(PROMPT_FILE_STEP1, "first step prompt")
+	writePrompt(PROMPT_FILE_STEP2, "second step prompt")
+	writePrompt("prompt2.es.txt", "segundo paso")
+
+	manager, err := NewPromptManager(db, dirPromptSource{dir: dir})
+	require.NoError(t, err)
+	prompts, version := manager.Prompt(PROMPT_STEP_SECOND)
+	assert.Equal(t, 1, version)
+	assert.Equal(t, "second step prompt", string(prompts.Default()))
+	assert.Equal(t, []string{"es"}, prompts.Languages(), "the file version keeps its language variants")
+	assert.Equal(t, "second v1", manager.VersionLabel(PROMPT_STEP_SECOND))
+
+	set, err := manager.Set(PROMPT_STEP_SECOND, "edited in telegram", "@admin")
+	require.NoError(t, err)
+	assert.Equal(t, 2, set.Version)
+	prompts, version = manager.Prompt(PROMPT_STEP_SECOND)
+	assert.Equal(t, 2, version)
+	assert.Equal(t, "edited in telegram", string(prompts.Default()))
+	assert.Empty(t, prompts.Languages())
+
+	restarted, err := NewPromptManager(db, dirPromptSource{dir: dir})
+	require.NoError(t, err)
+	_, version = restarted.Prompt(PROMPT_STEP_SECOND)
+	assert.Equal(t, 2, version, "a restart keeps the version set from Telegram")
+
+	rolledBack, err := manager.Rollback(PROMPT_STEP_SECOND, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rolledBack.Version)
+	prompts, _ = manager.Prompt(PROMPT_STEP_SECOND)
+	assert.Equal(t, []string{"es"}, prompts.Languages())
+	_, err = manager.Rollback(PROMPT_STEP_SECOND, 0)
+	assert.Error(t, err)
+
+	writePrompt(PROMPT_FILE_STEP2, "second step prompt, improved")
+	changes, err := manager.Reload("@admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second prompt: new version v3 from prompt2.txt"}, changes)
+	prompts, version = manager.Prompt(PROMPT_STEP_SECOND)
+	assert.Equal(t, 3, version)
+	assert.Equal(t, "second step prompt, improved", string(prompts.Default()))
+	_, version = manager.Prompt(PROMPT_STEP_FIRST)
+	assert.Equal(t, 1, version)
+
+	var nilManager *PromptManager
+	prompts, version = nilManager.Prompt(PROMPT_STEP_FIRST)
+	assert.Nil(t, prompts)
+	assert.Zero(t, version)
+}
+
+func TestBotController_PromptCommand(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PROMPT_FILE_STEP1), []byte("first"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PROMPT_FILE_STEP2), []byte("second"), 0o644))
+	manager, err := NewPromptManager(db, dirPromptSource{dir: dir})
+	require.NoError(t, err)
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.prompts = manager
+	reply := func(text string) string {
+		bot.handlePromptCommand(1, "@admin", text, strings.Fields(text)[1:])
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply("/prompt set third something"), "Usage")
+	assert.Contains(t, reply("/prompt set second too short"), "at least")
+
+	prompt := "You analyze crypto community replies.\n\n<rules>\n- flag coordinated FUD\n</rules>"
+	assert.Contains(t, reply("/prompt set second\n"+prompt), "second prompt v2 is active")
+	prompts, _ := manager.Prompt(PROMPT_STEP_SECOND)
+	assert.Equal(t, prompt, string(prompts.Default()), "line breaks are kept")
+
+	list := reply("/prompt")
+	assert.Contains(t, list, "▶️ v2")
+	assert.Contains(t, list, "telegram by @admin")
+
+	bot.handlePromptCommand(1, "@admin", "/prompt show second 1", []string{"show", "second", "1"})
+	transport.mu.Lock()
+	documents := append([]TelegramSendDocumentRequest(nil), transport.documents...)
+	transport.mu.Unlock()
+	require.Len(t, documents, 1)
+	assert.Contains(t, documents[0].Caption, "second prompt v1</b> (inactive)")
+
+	assert.Contains(t, reply("/prompt rollback second v1"), "second prompt v1 is active again")
+	assert.Contains(t, reply("/prompt reload"), "prompt files unchanged")
+}
+
+func TestCommandRemainder(t *testing.T) {
+	assert.Equal(t, "line one\nline two", commandRemainder("/prompt set second  line one\nline two", 3))
+	assert.Equal(t, "text", commandRemainder("/prompt set\nsecond\ntext", 3))
+	assert.Empty(t, commandRemainder("/prompt set second", 3))
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed prompts
+var embeddedPrompts embed.FS
+
+const (
+	PROMPT_SOURCE_EMBED      = "embed"
+	PROMPT_CHECKSUM_MANIFEST = "SHA256SUMS"
+	PROMPT_CACHE_DIR_DEFAULT = "prompt_cache"
+	PROMPT_FETCH_TIMEOUT     = 15 * time.Second
+	PROMPT_MAX_REMOTE_SIZE   = 1 << 20
+)
+
+// PromptSource is where system prompts and their language variants are read from. Missing
+// prompts are reported as fs.ErrNotExist.
+type PromptSource interface {
+	ReadPrompt(name string) ([]byte, error)
+	ListPrompts() ([]string, error)
+	String() string
+}
+
+// NewPromptSource picks the source for ENV_PROMPT_SOURCE: "embed", an http(s) base URL or a
+// directory, the working directory when empty
+func NewPromptSource(spec string, cacheDir string) PromptSource {
+	switch {
+	case spec == PROMPT_SOURCE_EMBED:
+		return newEmbeddedPromptSource()
+	case strings.HasPrefix(spec, "https://") || strings.HasPrefix(spec, "http://"):
+		if cacheDir == "" {
+			cacheDir = PROMPT_CACHE_DIR_DEFAULT
+		}
+		return &remotePromptSource{baseURL: strings.TrimSuffix(spec, "/"), cacheDir: cacheDir, client: &http.Client{Timeout: PROMPT_FETCH_TIMEOUT}}
+	case spec == "":
+		return dirPromptSource{dir: "."}
+	default:
+		return dirPromptSource{dir: spec}
+	}
+}
+
+// LoadPromptSetFrom reads a base prompt and every language variant of it, <stem>.<lang><ext>
+func LoadPromptSetFrom(source PromptSource, name string) (*PromptSet, error) {
+	base, err := source.ReadPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	names, err := source.ListPrompts()
+	if err != nil {
+		return nil, err
+	}
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	variants := map[string][]byte{}
+	for _, file := range names {
+		if file == name || !strings.HasPrefix(file, stem+".") || !strings.HasSuffix(file, ext) {
+			continue
+		}
+		lang := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(file, stem+"."), ext))
+		if lang == "" || strings.Contains(lang, ".") {
+			continue
+		}
+		data, err := source.ReadPrompt(file)
+		if err != nil {
+			return nil, fmt.Errorf("error read prompt variant %s: %w", file, err)
+		}
+		variants[lang] = data
+	}
+	return NewPromptSet(base, variants), nil
+}
+
+// loadSystemPrompt loads a prompt set from the configured source, falling back to the prompts
+// compiled into the binary when the source does not have it
+func loadSystemPrompt(source PromptSource, name string) (*PromptSet, error) {
+	prompts, err := LoadPromptSetFrom(source, name)
+	if !errors.Is(err, fs.ErrNotExist) || source.String() == PROMPT_SOURCE_EMBED {
+		if err == nil {
+			log.Printf("📝 Loaded %s from %s", name, source)
+		}
+		return prompts, err
+	}
+	prompts, embedErr := LoadPromptSetFrom(newEmbeddedPromptSource(), name)
+	if embedErr != nil {
+		return nil, fmt.Errorf("prompt %s not found in %s and not embedded: %w", name, source, err)
+	}
+	log.Printf("📦 %s not found in %s, using the embedded default", name, source)
+	return prompts, nil
+}
+
+// dirPromptSource reads prompts from a local directory
+type dirPromptSource struct {
+	dir string
+}
+
+func (s dirPromptSource) ReadPrompt(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, filepath.Base(name)))
+}
+
+func (s dirPromptSource) ListPrompts() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (s dirPromptSource) String() string {
+	return s.dir
+}
+
+// embeddedPromptSource reads the prompts compiled into the binary from the prompts directory
+type embeddedPromptSource struct {
+	files fs.FS
+}
+
+func newEmbeddedPromptSource() embeddedPromptSource {
+	files, err := fs.Sub(embeddedPrompts, "prompts")
+	if err != nil {
+		panic(err)
+	}
+	return embeddedPromptSource{files: files}
+}
+
+func (s embeddedPromptSource) ReadPrompt(name string) ([]byte, error) {
+	return fs.ReadFile(s.files, path.Base(name))
+}
+
+func (s embeddedPromptSource) ListPrompts() ([]string, error) {
+	entries, err := fs.ReadDir(s.files, ".")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func (s embeddedPromptSource) String() string {
+	return PROMPT_SOURCE_EMBED
+}
+
+// remotePromptSource fetches prompts from a central server that publishes a SHA256SUMS manifest
+// next to them. Every file is checked against the manifest and the last valid copies are cached
+// on disk, so a restart during a server outage keeps the prompts it had.
+type remotePromptSource struct {
+	baseURL  string
+	cacheDir string
+	client   *http.Client
+
+	once      sync.Once
+	checksums map[string]string
+	err       error
+}
+
+func (s *remotePromptSource) String() string {
+	return s.baseURL
+}
+
+func (s *remotePromptSource) ListPrompts() ([]string, error) {
+	checksums, err := s.manifest()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *remotePromptSource) ReadPrompt(name string) ([]byte, error) {
+	checksums, err := s.manifest()
+	if err != nil {
+		return nil, err
+	}
+	name = path.Base(name)
+	expected, ok := checksums[name]
+	if !ok {
+		return nil, fmt.Errorf("prompt %s is not listed in %s/%s: %w", name, s.baseURL, PROMPT_CHECKSUM_MANIFEST, fs.ErrNotExist)
+	}
+
+	data, fetchErr := s.fetch(name)
+	if fetchErr == nil {
+		if sum := sha256Hex(data); sum != expected {
+			fetchErr = fmt.Errorf("checksum mismatch: got %s, manifest has %s", sum, expected)
+		} else {
+			s.cache(name, data)
+			return data, nil
+		}
+	}
+
+	cached, err := os.ReadFile(filepath.Join(s.cacheDir, name))
+	if err != nil || sha256Hex(cached) != expected {
+		return nil, fmt.Errorf("error fetch prompt %s from %s: %w", name, s.baseURL, fetchErr)
+	}
+	log.Printf("⚠️ Using cached prompt %s, fetching it from %s failed: %v", name, s.baseURL, fetchErr)
+	return cached, nil
+}
+
+// manifest loads SHA256SUMS once, from the server or from the cache when the server is unreachable
+func (s *remotePromptSource) manifest() (map[string]string, error) {
+	s.once.Do(func() {
+		data, err := s.fetch(PROMPT_CHECKSUM_MANIFEST)
+		if err == nil {
+			s.checksums, err = parsePromptChecksums(data)
+			if err == nil {
+				s.cache(PROMPT_CHECKSUM_MANIFEST, data)
+				return
+			}
+		}
+		cached, cacheErr := os.ReadFile(filepath.Join(s.cacheDir, PROMPT_CHECKSUM_MANIFEST))
+		if cacheErr != nil {
+			s.err = fmt.Errorf("error fetch %s from %s: %w", PROMPT_CHECKSUM_MANIFEST, s.baseURL, err)
+			return
+		}
+		log.Printf("⚠️ Using cached %s, fetching it from %s failed: %v", PROMPT_CHECKSUM_MANIFEST, s.baseURL, err)
+		s.checksums, s.err = parsePromptChecksums(cached)
+	})
+	return s.checksums, s.err
+}
+
+func (s *remotePromptSource) fetch(name string) ([]byte, error) {
+	resp, err := s.client.Get(s.baseURL + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, PROMPT_MAX_REMOTE_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > PROMPT_MAX_REMOTE_SIZE {
+		return nil, fmt.Errorf("larger than %d bytes", PROMPT_MAX_REMOTE_SIZE)
+	}
+	return data, nil
+}
+
+func (s *remotePromptSource) cache(name string, data []byte) {
+	err := os.MkdirAll(s.cacheDir, 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(s.cacheDir, name), data, 0o644)
+	}
+	if err != nil {
+		log.Printf("Failed to cache prompt %s: %v", name, err)
+	}
+}
+
+// parsePromptChecksums reads sha256sum output: "<hex>  <name>" per line, "*" marks binary mode
+func parsePromptChecksums(data []byte) (map[string]string, error) {
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s line %d: expected \"<sha256> <file>\"", PROMPT_CHECKSUM_MANIFEST, line)
+		}
+		sum := strings.ToLower(fields[0])
+		if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("%s line %d: invalid sha256 %q", PROMPT_CHECKSUM_MANIFEST, line, fields[0])
+		}
+		checksums[path.Base(strings.TrimPrefix(fields[1], "*"))] = sum
+	}
+	if len(checksums) == 0 {
+		return nil, fmt.Errorf("%s is empty", PROMPT_CHECKSUM_MANIFEST)
+	}
+	return checksums, scanner.Err()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
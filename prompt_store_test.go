@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemotePromptSource(t *testing.T) {
+	files := map[string]string{
+		"prompt1.txt":    "base prompt",
+		"prompt1.es.txt": "prompt en español",
+	}
+	manifest := ""
+	for name, content := range files {
+		manifest += fmt.Sprintf("%s  %s\n", sha256Hex([]byte(content)), name)
+	}
+	files[PROMPT_CHECKSUM_MANIFEST] = manifest
+	files["prompt2.txt"] = "not in the manifest"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[filepath.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	cacheDir := t.TempDir()
+
+	source := NewPromptSource(server.URL+"/", cacheDir)
+	prompts, err := LoadPromptSetFrom(source, "prompt1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "base prompt", string(prompts.Default()))
+	assert.Equal(t, "prompt en español", string(prompts.ForLanguage("es")))
+	cached, err := os.ReadFile(filepath.Join(cacheDir, "prompt1.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "base prompt", string(cached))
+
+	_, err = source.ReadPrompt("prompt2.txt")
+	assert.True(t, errors.Is(err, fs.ErrNotExist), "files missing from the manifest are rejected")
+
+	files["prompt1.txt"] = "tampered prompt"
+	data, err := NewPromptSource(server.URL, cacheDir).ReadPrompt("prompt1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "base prompt", string(data), "a file not matching the manifest falls back to the valid cached copy")
+
+	server.Close()
+	data, err = NewPromptSource(server.URL, cacheDir).ReadPrompt("prompt1.es.txt")
+	require.NoError(t, err, "the cached manifest and prompts are used while the server is down")
+	assert.Equal(t, "prompt en español", string(data))
+
+	_, err = NewPromptSource(server.URL, t.TempDir()).ReadPrompt("prompt1.txt")
+	assert.Error(t, err)
+}
+
+func TestLoadSystemPrompt(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompt1.txt"), []byte("local prompt"), 0o644))
+
+	prompts, err := loadSystemPrompt(NewPromptSource(dir, ""), "prompt1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "local prompt", string(prompts.Default()))
+
+	readme, err := loadSystemPrompt(NewPromptSource(dir, ""), "README.md")
+	require.NoError(t, err, "files missing locally come from the embedded defaults")
+	assert.Contains(t, string(readme.Default()), "Embedded prompts")
+
+	_, err = loadSystemPrompt(NewPromptSource(PROMPT_SOURCE_EMBED, ""), "prompt_missing.txt")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	_, err = parsePromptChecksums([]byte("nothex  prompt1.txt\n"))
+	assert.Error(t, err)
+}
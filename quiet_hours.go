@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	QUIET_HOURS_CHECK_INTERVAL = time.Minute
+	QUIET_DIGEST_SHOWN         = 20 // alerts listed in the digest, the rest are counted
+)
+
+// quietWindow is a daily period in minutes after midnight. A window ending before it starts runs past midnight.
+type quietWindow struct {
+	Start int
+	End   int
+}
+
+// parseQuietHours reads a window such as 23:00-07:00
+func parseQuietHours(spec string) (quietWindow, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return quietWindow{}, fmt.Errorf("use a window such as 23:00-07:00")
+	}
+	var window quietWindow
+	for _, bound := range []struct {
+		text    string
+		minutes *int
+	}{{from, &window.Start}, {to, &window.End}} {
+		clock, err := time.Parse("15:04", strings.TrimSpace(bound.text))
+		if err != nil {
+			return quietWindow{}, fmt.Errorf("invalid time %s, use HH:MM", strings.TrimSpace(bound.text))
+		}
+		*bound.minutes = clock.Hour()*60 + clock.Minute()
+	}
+	if window.Start == window.End {
+		return quietWindow{}, fmt.Errorf("the quiet hours start and end at the same time")
+	}
+	return window, nil
+}
+
+func (w quietWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// contains reports whether a local time falls in the window
+func (w quietWindow) contains(local time.Time) bool {
+	minute := local.Hour()*60 + local.Minute()
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// chatInQuietHours reports whether the quiet hours of a chat are running, in the chat timezone
+func chatInQuietHours(settings *ChatSettingsModel, now time.Time) bool {
+	if settings.QuietHours == "" {
+		return false
+	}
+	window, err := parseQuietHours(settings.QuietHours)
+	if err != nil {
+		return false
+	}
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return window.contains(now.In(location))
+}
+
+// holdForQuietHours queues a non-critical alert for a chat in its quiet hours and reports whether it did
+func (b *BotController) holdForQuietHours(alert FUDAlertNotification, notificationID string, settings *ChatSettingsModel, now time.Time) bool {
+	if alert.AlertSeverity == "critical" || !chatInQuietHours(settings, now) {
+		return false
+	}
+	err := b.dbService.QueueQuietAlert(QuietAlertModel{
+		ChatID:         settings.ChatID,
+		NotificationID: notificationID,
+		Severity:       alert.AlertSeverity,
+		Username:       alert.FUDUsername,
+		FUDType:        alert.FUDType,
+	})
+	if err != nil {
+		log.Printf("Failed to hold alert for chat %d during quiet hours, delivering it: %v", settings.ChatID, err)
+		return false
+	}
+	return true
+}
+
+// StartQuietHoursDigests delivers the held alerts of every chat whose quiet hours ended
+func (b *BotController) StartQuietHoursDigests(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			b.sendDueQuietDigests(now)
+		}
+	}()
+}
+
+func (b *BotController) sendDueQuietDigests(now time.Time) {
+	chatIDs, err := b.dbService.GetQuietAlertChats()
+	if err != nil {
+		log.Printf("Failed to load chats with held alerts: %v", err)
+		return
+	}
+	for _, chatID := range chatIDs {
+		settings, err := b.dbService.GetChatSettings(chatID)
+		if err != nil {
+			log.Printf("Failed to load settings for chat %d: %v", chatID, err)
+			continue
+		}
+		if chatInQuietHours(settings, now) {
+			continue
+		}
+		b.sendQuietDigest(settings)
+	}
+}
+
+// sendQuietDigest delivers the alerts held for a chat as one message
+func (b *BotController) sendQuietDigest(settings *ChatSettingsModel) {
+	alerts, err := b.dbService.GetQuietAlerts(settings.ChatID)
+	if err != nil || len(alerts) == 0 {
+		if err != nil {
+			log.Printf("Failed to load held alerts of chat %d: %v", settings.ChatID, err)
+		}
+		return
+	}
+	// Chats removed from the notification list while quiet get nothing
+	if b.isRegisteredChat(settings.ChatID) {
+		if err := b.SendMessageToTopic(settings.ChatID, settings.AlertsTopicID, b.formatQuietDigest(alerts, settings)); err != nil {
+			log.Printf("Failed to send quiet hours digest to chat %d: %v", settings.ChatID, err)
+			return
+		}
+	}
+	if err := b.dbService.DeleteQuietAlerts(settings.ChatID, alerts[len(alerts)-1].ID); err != nil {
+		log.Printf("Failed to clear held alerts of chat %d: %v", settings.ChatID, err)
+	}
+}
+
+// formatQuietDigest lists the held alerts like the maintenance summary, redacted chats only get the counts
+func (b *BotController) formatQuietDigest(alerts []QuietAlertModel, settings *ChatSettingsModel) string {
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	counts := make(map[string]int)
+	for _, alert := range alerts {
+		counts[alert.Severity]++
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🌅 <b>Quiet hours over</b>, %d alerts held back since %s%s\n",
+		len(alerts), alerts[0].QueuedAt.In(location).Format("15:04"), b.formatSeverityCounts(counts)))
+	if isRedactedProfile(settings.Redaction) {
+		return message.String()
+	}
+	message.WriteString("\n")
+	for i, alert := range alerts {
+		if i == QUIET_DIGEST_SHOWN {
+			message.WriteString(fmt.Sprintf("… and %d more\n", len(alerts)-QUIET_DIGEST_SHOWN))
+			break
+		}
+		message.WriteString(fmt.Sprintf("%s %s @%s — %s · /detail_%s\n", alert.QueuedAt.In(location).Format("15:04"),
+			b.formatter.getSeverityEmoji(alert.Severity), alert.Username, b.formatter.formatFUDType(alert.FUDType), alert.NotificationID))
+	}
+	return message.String()
+}
+
+// handleQuietCommand shows or changes the quiet hours of the chat: /quiet [23:00-07:00|off]
+func (b *BotController) handleQuietCommand(chatID int64, args []string) {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+
+	if len(args) == 0 {
+		state := "off"
+		if settings.QuietHours != "" {
+			state = fmt.Sprintf("%s (%s)", settings.QuietHours, settings.Timezone)
+		}
+		b.SendMessage(chatID, fmt.Sprintf("🌙 <b>Quiet hours:</b> %s\n\nUsage: /quiet 23:00-07:00|off\nNon-critical alerts are held during the quiet hours and delivered as one digest when they end. Critical alerts still pass through.", state))
+		return
+	}
+
+	if strings.ToLower(args[0]) == "off" {
+		settings.QuietHours = ""
+		if err := b.dbService.SaveChatSettings(settings); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+			return
+		}
+		b.SendMessage(chatID, "🔔 Quiet hours off, alerts are delivered as they come")
+		b.sendQuietDigest(settings)
+		return
+	}
+
+	window, err := parseQuietHours(strings.Join(args, ""))
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	settings.QuietHours = window.String()
+	if err := b.dbService.SaveChatSettings(settings); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+	b.SendMessage(chatID, fmt.Sprintf("🌙 Quiet hours set to <b>%s</b> (%s). Non-critical alerts are held until they end and delivered as a digest, critical alerts still pass through.", settings.QuietHours, settings.Timezone))
+}
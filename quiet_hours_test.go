@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	window, err := parseQuietHours("23:00-7:30")
+	require.NoError(t, err)
+	assert.Equal(t, "23:00-07:30", window.String())
+	assert.True(t, window.contains(time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, window.contains(time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)))
+	assert.False(t, window.contains(time.Date(2026, 1, 1, 7, 30, 0, 0, time.UTC)))
+
+	day, err := parseQuietHours("12:00-14:00")
+	require.NoError(t, err)
+	assert.True(t, day.contains(time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)))
+	assert.False(t, day.contains(time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)))
+
+	for _, invalid := range []string{"23:00", "25:00-07:00", "07:00-07:00"} {
+		_, err := parseQuietHours(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	t.Setenv(ENV_FOLLOW_UP_DELAYS, "off")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	// The window covers now, the digest runs as if it had ended
+	now := time.Now().UTC()
+	start := now.Add(-time.Hour).Format("15:04")
+	end := now.Add(time.Hour).Format("15:04")
+	bot.handleQuietCommand(1, []string{start + "-" + end})
+	require.Contains(t, transport.sentMessages()[0].Text, "Quiet hours set to")
+
+	alert := benchmarkAlert()
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	alert.AlertSeverity = "critical"
+	require.NoError(t, bot.StoreAndBroadcastNotification(alert))
+	sent := transport.sentMessages()
+	require.Len(t, sent, 2, "only the critical alert passes")
+	assert.Contains(t, sent[1].Text, "CRITICAL")
+
+	bot.sendDueQuietDigests(now)
+	assert.Len(t, transport.sentMessages(), 2, "still quiet")
+
+	bot.sendDueQuietDigests(now.Add(2 * time.Hour))
+	sent = transport.sentMessages()
+	require.Len(t, sent, 3)
+	assert.Contains(t, sent[2].Text, "Quiet hours over</b>, 1 alerts held back")
+	assert.Contains(t, sent[2].Text, "@suspicious_user — Professional Trojan Horse · /detail_")
+
+	bot.sendDueQuietDigests(now.Add(2 * time.Hour))
+	assert.Len(t, transport.sentMessages(), 3, "held alerts are delivered once")
+}
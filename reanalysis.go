@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	REANALYSIS_CHECK_INTERVAL       = 10 * time.Minute
+	REANALYSIS_DEFAULT_AFTER        = 72 * time.Hour
+	REANALYSIS_DEFAULT_CONCURRENCY  = 2
+	REANALYSIS_PROBABILITY_CHANGE   = 0.2 // smaller moves of the FUD probability count as unchanged
+	REANALYSIS_MAX_BACKOFF          = 30 * 24 * time.Hour
+	REANALYSIS_MAX_FAILURES_COUNTED = 10 // enough to reach the longest backoff
+)
+
+const (
+	REANALYSIS_STATUS_PENDING = "pending"
+	REANALYSIS_STATUS_DONE    = "done"
+)
+
+// Outcomes of a re-analysis compared with the previous verdict
+const (
+	REANALYSIS_UNCHANGED = "unchanged"
+	REANALYSIS_ESCALATED = "escalated" // higher risk level or FUD probability
+	REANALYSIS_EASED     = "eased"     // lower risk level or FUD probability, still FUD
+	REANALYSIS_RETYPED   = "retyped"   // same risk, different kind of FUD
+	REANALYSIS_CLEARED   = "cleared"   // no longer FUD, the second step removed them from the FUD list
+	REANALYSIS_FAILED    = "failed"
+)
+
+var reanalysisOutcomeEmojis = map[string]string{
+	REANALYSIS_ESCALATED: "🔺",
+	REANALYSIS_EASED:     "🔻",
+	REANALYSIS_RETYPED:   "🔀",
+	REANALYSIS_CLEARED:   "✅",
+	REANALYSIS_FAILED:    "⚠️",
+}
+
+// reanalysisSchedule reads how old the last analysis of a known FUD user may get, 0 when the re-analysis
+// is disabled, and how many re-analyses run at once
+func reanalysisSchedule() (time.Duration, int) {
+	after := REANALYSIS_DEFAULT_AFTER
+	if value := strings.TrimSpace(os.Getenv(ENV_REANALYSIS_AFTER_HOURS)); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 0 {
+			log.Printf("Warning: invalid %s %q, using %s", ENV_REANALYSIS_AFTER_HOURS, value, REANALYSIS_DEFAULT_AFTER)
+		} else {
+			after = time.Duration(hours) * time.Hour
+		}
+	}
+	concurrency := REANALYSIS_DEFAULT_CONCURRENCY
+	if value := strings.TrimSpace(os.Getenv(ENV_REANALYSIS_CONCURRENCY)); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			log.Printf("Warning: invalid %s %q, using %d", ENV_REANALYSIS_CONCURRENCY, value, REANALYSIS_DEFAULT_CONCURRENCY)
+		} else {
+			concurrency = n
+		}
+	}
+	return after, concurrency
+}
+
+// StartReanalysisScheduler periodically analyzes known FUD users again once their last analysis is too old
+func (b *BotController) StartReanalysisScheduler(interval time.Duration) {
+	after, concurrency := reanalysisSchedule()
+	if after == 0 {
+		log.Printf("♻️ Scheduled re-analysis of known FUD users is off")
+		return
+	}
+	log.Printf("♻️ Re-analyzing known FUD users after %s, %d at a time", after, concurrency)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			b.runReanalyses(now, after, concurrency)
+		}
+	}()
+}
+
+// runReanalyses compares the finished re-analyses with their previous verdicts, reports the changes
+// and starts new ones while fewer than concurrency are running
+func (b *BotController) runReanalyses(now time.Time, after time.Duration, concurrency int) {
+	pending, err := b.dbService.GetPendingReanalyses()
+	if err != nil {
+		log.Printf("Failed to load pending re-analyses: %v", err)
+		return
+	}
+	running := 0
+	var changed []ReanalysisModel
+	for i := range pending {
+		reanalysis := &pending[i]
+		task, err := b.dbService.GetAnalysisTask(reanalysis.TaskID)
+		if err == nil && !isFinishedTaskStatus(task.Status) {
+			running++
+			continue
+		}
+		outcome, summary := REANALYSIS_FAILED, "The analysis task is gone"
+		if err == nil {
+			outcome, summary = compareReanalysis(reanalysis, task)
+		}
+		if outcome == REANALYSIS_FAILED {
+			failures, err := b.dbService.CountReanalysisFailures(reanalysis.UserID)
+			if err != nil {
+				log.Printf("Failed to count failed re-analyses of @%s: %v", reanalysis.Username, err)
+			}
+			retryAfter := now.Add(reanalysisBackoff(after, failures+1))
+			reanalysis.RetryAfter = &retryAfter
+		}
+		if err := b.dbService.CompleteReanalysis(reanalysis, outcome, summary); err != nil {
+			// Left pending, the next tick compares it again
+			log.Printf("Failed to complete re-analysis of @%s: %v", reanalysis.Username, err)
+			continue
+		}
+		log.Printf("♻️ Re-analysis of @%s: %s, %s", reanalysis.Username, outcome, summary)
+		if outcome != REANALYSIS_UNCHANGED {
+			changed = append(changed, *reanalysis)
+		}
+	}
+	if len(changed) > 0 {
+		message := formatReanalysisChanges(changed)
+		for _, chatID := range adminChatIDs() {
+			if err := b.SendMessage(chatID, message); err != nil {
+				log.Printf("Failed to send re-analysis changes to chat %d: %v", chatID, err)
+			}
+		}
+	}
+
+	if running >= concurrency {
+		return
+	}
+	users, err := b.dbService.GetReanalysisCandidates(now.Add(-after), concurrency-running)
+	if err != nil {
+		log.Printf("Failed to load FUD users due for re-analysis: %v", err)
+		return
+	}
+	for _, user := range users {
+		if err := b.startReanalysis(user, now); err != nil {
+			log.Printf("Failed to start re-analysis of @%s: %v", user.Username, err)
+		}
+	}
+}
+
+// reanalysisBackoff is how long a user waits after a failed re-analysis: the re-analysis interval,
+// doubled with every failure in a row up to REANALYSIS_MAX_BACKOFF
+func reanalysisBackoff(after time.Duration, failures int) time.Duration {
+	backoff := after
+	for i := 1; i < failures && backoff < REANALYSIS_MAX_BACKOFF; i++ {
+		backoff *= 2
+	}
+	return min(backoff, REANALYSIS_MAX_BACKOFF)
+}
+
+// startReanalysis records the current verdict of a FUD user and queues the analysis that is compared with it
+func (b *BotController) startReanalysis(user FUDUserModel, now time.Time) error {
+	reanalysis := &ReanalysisModel{
+		UserID:              user.UserID,
+		Username:            user.Username,
+		PreviousIsFUD:       true,
+		PreviousFUDType:     user.FUDType,
+		PreviousProbability: user.FUDProbability,
+		PreviousAnalyzedAt:  user.DetectedAt,
+	}
+	if cached, err := b.dbService.GetCachedAnalysisRecord(user.UserID); err == nil {
+		reanalysis.PreviousIsFUD = cached.IsFUDUser
+		reanalysis.PreviousFUDType = cached.FUDType
+		reanalysis.PreviousProbability = cached.FUDProbability
+		reanalysis.PreviousRiskLevel = cached.UserRiskLevel
+		reanalysis.PreviousAnalyzedAt = cached.AnalyzedAt
+	}
+
+	taskID, err := b.queueAnalysisTask(&AnalysisTaskModel{
+		Username: user.Username,
+		UserID:   user.UserID,
+		Kind:     ANALYSIS_KIND_REANALYSIS,
+		Priority: ANALYSIS_PRIORITY_NORMAL,
+	})
+	if err != nil {
+		return err
+	}
+	reanalysis.TaskID = taskID
+	if err := b.dbService.CreateReanalysis(reanalysis); err != nil {
+		return err
+	}
+	log.Printf("♻️ Re-analyzing @%s, last analyzed %s ago (task %s)", user.Username, now.Sub(reanalysis.PreviousAnalyzedAt).Round(time.Hour), taskID)
+	return nil
+}
+
+// compareReanalysis diffs the verdict of a finished re-analysis task against the previous one
+func compareReanalysis(reanalysis *ReanalysisModel, task *AnalysisTaskModel) (string, string) {
+	if task.Status != ANALYSIS_STATUS_COMPLETED {
+		return REANALYSIS_FAILED, fmt.Sprintf("Task %s %s: %s", task.ID, task.Status, task.ErrorMessage)
+	}
+	var result AnalysisTaskResult
+	if err := json.Unmarshal([]byte(task.ResultData), &result); err != nil {
+		return REANALYSIS_FAILED, fmt.Sprintf("Task %s result is unreadable", task.ID)
+	}
+
+	summary := formatReanalysisVerdict(reanalysis.PreviousIsFUD, reanalysis.PreviousRiskLevel, reanalysis.PreviousProbability, reanalysis.PreviousFUDType) +
+		" → " + formatReanalysisVerdict(result.IsFUD, result.UserRiskLevel, result.FUDProbability, result.FUDType)
+	probabilityChange := result.FUDProbability - reanalysis.PreviousProbability
+	switch {
+	case !result.IsFUD && reanalysis.PreviousIsFUD:
+		return REANALYSIS_CLEARED, summary
+	case !result.IsFUD:
+		return REANALYSIS_UNCHANGED, summary
+	case !reanalysis.PreviousIsFUD:
+		return REANALYSIS_ESCALATED, summary
+	case severityRank(result.UserRiskLevel) > severityRank(reanalysis.PreviousRiskLevel):
+		return REANALYSIS_ESCALATED, summary
+	case severityRank(result.UserRiskLevel) < severityRank(reanalysis.PreviousRiskLevel):
+		return REANALYSIS_EASED, summary
+	case probabilityChange >= REANALYSIS_PROBABILITY_CHANGE:
+		return REANALYSIS_ESCALATED, summary
+	case probabilityChange <= -REANALYSIS_PROBABILITY_CHANGE:
+		return REANALYSIS_EASED, summary
+	case normalizeFUDType(result.FUDType) != normalizeFUDType(reanalysis.PreviousFUDType):
+		return REANALYSIS_RETYPED, summary
+	default:
+		return REANALYSIS_UNCHANGED, summary
+	}
+}
+
+// formatReanalysisVerdict is a verdict in one line, e.g. "FUD high 85% (casual_fud)"
+func formatReanalysisVerdict(isFUD bool, riskLevel string, probability float64, fudType string) string {
+	if !isFUD {
+		return fmt.Sprintf("clean %.0f%%", probability*100)
+	}
+	verdict := "FUD"
+	if riskLevel != "" {
+		verdict += " " + riskLevel
+	}
+	verdict += fmt.Sprintf(" %.0f%%", probability*100)
+	if fudType != "" {
+		verdict += " (" + fudType + ")"
+	}
+	return verdict
+}
+
+// formatReanalysisChanges is the admin notice of the re-analyses whose verdict changed
+func formatReanalysisChanges(changed []ReanalysisModel) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("♻️ <b>Scheduled re-analysis</b>: %d verdicts changed\n\n", len(changed)))
+	for _, reanalysis := range changed {
+		message.WriteString(fmt.Sprintf("%s @%s <b>%s</b>: %s · /history_%s\n", reanalysisOutcomeEmojis[reanalysis.Outcome],
+			reanalysis.Username, reanalysis.Outcome, html.EscapeString(reanalysis.Summary), reanalysis.Username))
+	}
+	return strings.TrimRight(message.String(), "\n")
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReanalysisCandidates(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now()
+	for _, user := range []FUDUserModel{
+		{UserID: "u1", Username: "stale", DetectedAt: now.Add(-300 * time.Hour)},
+		{UserID: "u2", Username: "fresh", DetectedAt: now.Add(-300 * time.Hour)},
+		{UserID: "u3", Username: "never_analyzed", DetectedAt: now.Add(-200 * time.Hour)},
+		{UserID: "u4", Username: "in_progress", DetectedAt: now.Add(-400 * time.Hour)},
+		{UserID: "u5", Username: "new", DetectedAt: now.Add(-time.Hour)},
+		{UserID: "u6", Username: "failed_lately", DetectedAt: now.Add(-500 * time.Hour)},
+		{UserID: "u7", Username: "backing_off", DetectedAt: now.Add(-500 * time.Hour)},
+	} {
+		require.NoError(t, db.SaveFUDUser(user))
+	}
+	for _, userID := range []string{"u1", "u2"} {
+		require.NoError(t, db.SaveCachedAnalysis(userID, userID, SecondStepClaudeResponse{IsFUDUser: true}, ""))
+	}
+	require.NoError(t, db.db.Model(&CachedAnalysisModel{}).Where("user_id = ?", "u1").Update("analyzed_at", now.Add(-100*time.Hour)).Error)
+	require.NoError(t, db.CreateReanalysis(&ReanalysisModel{UserID: "u4", Username: "in_progress", TaskID: "t4"}))
+	for _, userID := range []string{"u6", "u7"} {
+		failed := &ReanalysisModel{UserID: userID, TaskID: "t-" + userID}
+		require.NoError(t, db.CreateReanalysis(failed))
+		require.NoError(t, db.CompleteReanalysis(failed, REANALYSIS_FAILED, "The analysis task is gone"))
+	}
+	retryAfter := now.Add(time.Hour)
+	require.NoError(t, db.db.Model(&ReanalysisModel{}).Where("user_id = ?", "u7").
+		Updates(map[string]interface{}{"created_at": now.Add(-200 * time.Hour), "retry_after": retryAfter}).Error)
+
+	users, err := db.GetReanalysisCandidates(now.Add(-72*time.Hour), 10)
+	require.NoError(t, err)
+	var usernames []string
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+	}
+	assert.Equal(t, []string{"never_analyzed", "stale"}, usernames, "oldest analysis first, fresh, pending and failed users are skipped")
+
+	failures, err := db.CountReanalysisFailures("u6")
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	users, err = db.GetReanalysisCandidates(now.Add(-72*time.Hour), 1)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestReanalysisBackoff(t *testing.T) {
+	assert.Equal(t, 72*time.Hour, reanalysisBackoff(72*time.Hour, 1))
+	assert.Equal(t, 144*time.Hour, reanalysisBackoff(72*time.Hour, 2))
+	assert.Equal(t, 288*time.Hour, reanalysisBackoff(72*time.Hour, 3))
+	assert.Equal(t, REANALYSIS_MAX_BACKOFF, reanalysisBackoff(72*time.Hour, REANALYSIS_MAX_FAILURES_COUNTED))
+
+	t.Run("Failures in a row back off longer", func(t *testing.T) {
+		t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+		db := setupTestDB(t)
+		bot := newTestBotController(&fakeTelegramTransport{}, db)
+		now := time.Now()
+		for _, expected := range []time.Duration{72 * time.Hour, 144 * time.Hour} {
+			require.NoError(t, db.CreateReanalysis(&ReanalysisModel{UserID: "u1", Username: "grumbler", TaskID: "gone"}))
+			bot.runReanalyses(now, 72*time.Hour, 0)
+
+			var latest ReanalysisModel
+			require.NoError(t, db.db.Order("id DESC").First(&latest).Error)
+			assert.Equal(t, REANALYSIS_FAILED, latest.Outcome)
+			require.NotNil(t, latest.RetryAfter)
+			assert.WithinDuration(t, now.Add(expected), *latest.RetryAfter, time.Second)
+		}
+	})
+}
+
+func TestCompareReanalysis(t *testing.T) {
+	previous := &ReanalysisModel{PreviousIsFUD: true, PreviousFUDType: "casual_fud", PreviousProbability: 0.6, PreviousRiskLevel: "medium"}
+	completed := func(result AnalysisTaskResult) *AnalysisTaskModel {
+		data, _ := json.Marshal(result)
+		return &AnalysisTaskModel{ID: "t1", Status: ANALYSIS_STATUS_COMPLETED, ResultData: string(data)}
+	}
+
+	tests := []struct {
+		name    string
+		task    *AnalysisTaskModel
+		outcome string
+	}{
+		{"same verdict", completed(AnalysisTaskResult{IsFUD: true, FUDType: "casual_fud", FUDProbability: 0.65, UserRiskLevel: "medium"}), REANALYSIS_UNCHANGED},
+		{"higher risk", completed(AnalysisTaskResult{IsFUD: true, FUDType: "casual_fud", FUDProbability: 0.6, UserRiskLevel: "high"}), REANALYSIS_ESCALATED},
+		{"much lower probability", completed(AnalysisTaskResult{IsFUD: true, FUDType: "casual_fud", FUDProbability: 0.3, UserRiskLevel: "medium"}), REANALYSIS_EASED},
+		{"other FUD type", completed(AnalysisTaskResult{IsFUD: true, FUDType: "coordinated_attack", FUDProbability: 0.6, UserRiskLevel: "medium"}), REANALYSIS_RETYPED},
+		{"clean now", completed(AnalysisTaskResult{IsFUD: false, FUDProbability: 0.1}), REANALYSIS_CLEARED},
+		{"failed task", &AnalysisTaskModel{ID: "t1", Status: ANALYSIS_STATUS_FAILED, ErrorMessage: "rate limited"}, REANALYSIS_FAILED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, _ := compareReanalysis(previous, tt.task)
+			assert.Equal(t, tt.outcome, outcome)
+		})
+	}
+
+	_, summary := compareReanalysis(previous, completed(AnalysisTaskResult{IsFUD: false, FUDProbability: 0.1}))
+	assert.Equal(t, "FUD medium 60% (casual_fud) → clean 10%", summary)
+}
+
+func TestRunReanalyses(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	now := time.Now()
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u1", Username: "grumbler", DetectedAt: now.Add(-300 * time.Hour)}))
+	require.NoError(t, db.SaveCachedAnalysis("u1", "grumbler", SecondStepClaudeResponse{IsFUDUser: true, FUDType: "casual_fud", FUDProbability: 0.8, UserRiskLevel: "high"}, ""))
+	require.NoError(t, db.db.Model(&CachedAnalysisModel{}).Where("user_id = ?", "u1").Update("analyzed_at", now.Add(-100*time.Hour)).Error)
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.analysisChannel = make(chan twitterapi.NewMessage, 1)
+	bot.runReanalyses(now, 72*time.Hour, 1)
+
+	var queued twitterapi.NewMessage
+	select {
+	case queued = <-bot.analysisChannel:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the re-analysis was not sent to the second step")
+	}
+	assert.True(t, queued.Reanalysis)
+	assert.True(t, queued.IsManualAnalysis, "the cached verdict is not reused")
+	assert.False(t, queued.ForceNotification, "no alert for a re-analysis")
+
+	pending, err := db.GetPendingReanalyses()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "high", pending[0].PreviousRiskLevel)
+	require.Eventually(t, func() bool {
+		task, err := db.GetAnalysisTask(pending[0].TaskID)
+		return err == nil && task.ProgressText == "Processing with neural network..."
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Still running: nothing is compared and the concurrency limit holds
+	bot.runReanalyses(now, 72*time.Hour, 1)
+	assert.Empty(t, transport.sentMessages())
+	assert.Empty(t, bot.analysisChannel)
+
+	notificationCh := make(chan FUDAlertNotification, 1)
+	claudeApi := newMockClaudeAPI(`"is_fud_user":false,"fud_probability":0.1,"user_risk_level":"low"}`, nil)
+	SecondStepHandler(queued, notificationCh, &mockTwitterAPI{}, claudeApi, nil, &mockUserStatusTracker{}, "GRUT", db)
+	assert.Empty(t, notificationCh)
+	assert.False(t, db.IsFUDUser("u1"))
+	bot.runReanalyses(now, 72*time.Hour, 1)
+
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, int64(1), sent[0].ChatID)
+	assert.Contains(t, sent[0].Text, "@grumbler <b>cleared</b>: FUD high 80% (casual_fud) → clean 10%")
+	pending, err = db.GetPendingReanalyses()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.Empty(t, bot.analysisChannel, "re-analyzed users wait for their next turn")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+)
+
+var reportUsernameRegex = regexp.MustCompile(`^\w{1,15}$`)
+
+// handleReportCommand processes /report <tweet_url_or_username> <reason> and queues a high priority analysis
+func (b *BotController) handleReportCommand(chatID int64, reporterID int64, reporterName string, args []string) {
+	if len(args) < 2 {
+		b.SendMessage(chatID, "❌ Usage: /report &lt;tweet_url_or_username&gt; &lt;reason&gt;\n\nExample: /report https://x.com/someone/status/123 spreading fake rug pull claims")
+		return
+	}
+
+	username, tweetID := b.resolveTwitterReference(args[0])
+	if !reportUsernameRegex.MatchString(username) {
+		b.SendMessage(chatID, "❌ Invalid target. Use a tweet link or a username like @someone")
+		return
+	}
+
+	reason := strings.Join(args[1:], " ")
+	report := &UserReportModel{
+		ReporterChatID: chatID,
+		ReporterUserID: reporterID,
+		ReporterName:   reporterName,
+		TargetUsername: username,
+		TweetID:        tweetID,
+		Reason:         reason,
+	}
+
+	b.SendMessage(chatID, fmt.Sprintf("📨 Report about @%s received, thank you! It skips the regular queue and the verdict will show up in /reports.", username))
+
+	taskID, err := b.startAnalysisTask(chatID, &AnalysisTaskModel{Username: username, TweetID: tweetID, Priority: ANALYSIS_PRIORITY_HIGH})
+	if err != nil {
+		log.Printf("Failed to start analysis for report about @%s: %v", username, err)
+		return
+	}
+
+	report.TaskID = taskID
+	err = b.dbService.CreateUserReport(report)
+	if err != nil {
+		log.Printf("Failed to save report about @%s: %v", username, err)
+		return
+	}
+	log.Printf("Chat %d (%s) reported @%s, task %s", chatID, reporterName, username, taskID)
+}
+
+// handleReportsCommand lists recent reports with their verdicts. Admins see reports from every chat.
+func (b *BotController) handleReportsCommand(chatID int64) {
+	scope := chatID
+	if b.isAdminChat(chatID) {
+		scope = 0
+	}
+
+	reports, err := b.dbService.GetRecentUserReports(scope, 20)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading reports: %v", err))
+		return
+	}
+	if len(reports) == 0 {
+		b.SendMessage(chatID, "📭 No reports yet.\n\nUse /report &lt;tweet_url_or_username&gt; &lt;reason&gt; to flag suspicious content.")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("📨 <b>Recent Reports</b> (%d)\n\n", len(reports)))
+	for _, report := range reports {
+		message.WriteString(fmt.Sprintf("%s <b>@%s</b> — %s\n", report.CreatedAt.UTC().Format("01-02 15:04"), report.TargetUsername, b.reportVerdict(report)))
+		message.WriteString(fmt.Sprintf("   💬 <i>%s</i>\n", b.formatter.escapeTruncated(report.Reason, 100)))
+		if scope == 0 {
+			message.WriteString(fmt.Sprintf("   👤 %s (chat %d)\n", html.EscapeString(report.ReporterName), report.ReporterChatID))
+		}
+		message.WriteString("\n")
+	}
+
+	b.SendMessage(chatID, message.String())
+}
+
+// reportVerdict describes the outcome of the analysis started by a report
+func (b *BotController) reportVerdict(report UserReportModel) string {
+	task, err := b.dbService.GetAnalysisTask(report.TaskID)
+	if err != nil {
+		return "❔ unknown"
+	}
+
+	switch task.Status {
+	case ANALYSIS_STATUS_FAILED:
+		return "❌ analysis failed"
+	case ANALYSIS_STATUS_COMPLETED:
+	default:
+		return "⏳ analyzing"
+	}
+
+	var result struct {
+		IsFUD   bool   `json:"is_fud"`
+		FUDType string `json:"fud_type"`
+	}
+	if err := json.Unmarshal([]byte(task.ResultData), &result); err != nil {
+		// Result data is not always valid JSON, fall back to the cached analysis
+		cached, cacheErr := b.dbService.GetCachedAnalysis(task.UserID)
+		if cacheErr != nil {
+			return "✅ analyzed"
+		}
+		result.IsFUD, result.FUDType = cached.IsFUDUser, cached.FUDType
+	}
+
+	if !result.IsFUD {
+		return "✅ clean"
+	}
+	return fmt.Sprintf("🚨 FUD (%s)", b.formatter.formatFUDType(result.FUDType))
+}
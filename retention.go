@@ -0,0 +1,373 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RETENTION_RUN_EVERY   = 24 * time.Hour
+	RETENTION_START_DELAY = 10 * time.Minute // the first purge waits for the startup imports and loads
+	RETENTION_BATCH       = 1000             // rows read and deleted per query
+	RETENTION_DEFAULT_DIR = "archive"
+)
+
+// Data classes the retention policy applies to
+const (
+	RETENTION_TWEETS   = "tweets"
+	RETENTION_OPINIONS = "opinions"
+	RETENTION_EVENTS   = "events"
+	RETENTION_TASKS    = "tasks"
+)
+
+// retentionClass is a table whose old rows are archived and purged
+type retentionClass struct {
+	Name       string
+	Model      interface{}
+	Table      string
+	TimeColumn string
+	Key        string
+	Keep       string // rows matching this are never purged
+	KeepNote   string
+}
+
+// retentionClasses lists what the policy can purge. Detections are never purged: FUD users, analyses,
+// alerts and verdicts, and the tweets of flagged users that are their evidence.
+var retentionClasses = []retentionClass{
+	{Name: RETENTION_TWEETS, Model: &TweetModel{}, Table: "tweets", TimeColumn: "created_at", Key: "id",
+		Keep: "user_id IN (SELECT user_id FROM fud_users)", KeepNote: "tweets of flagged users are kept"},
+	{Name: RETENTION_OPINIONS, Model: &UserTickerOpinionModel{}, Table: "user_ticker_opinions", TimeColumn: "tweet_created_at", Key: "id"},
+	{Name: RETENTION_EVENTS, Model: &EventModel{}, Table: "events", TimeColumn: "created_at", Key: "id"},
+	{Name: RETENTION_TASKS, Model: &AnalysisTaskModel{}, Table: "analysis_tasks", TimeColumn: "created_at", Key: "id",
+		Keep: fmt.Sprintf("status IN ('%s', '%s')", ANALYSIS_STATUS_PENDING, ANALYSIS_STATUS_RUNNING), KeepNote: "unfinished tasks are kept"},
+}
+
+// parseRetentionPolicy reads ENV_RETENTION: comma-separated class:days, forever (or 0) keeps a class.
+// Classes not listed are kept forever, so nothing is purged until a policy is configured.
+func parseRetentionPolicy(value string) (map[string]int, error) {
+	policy := make(map[string]int)
+	if strings.TrimSpace(value) == "" {
+		return policy, nil
+	}
+	known := make(map[string]bool)
+	for _, class := range retentionClasses {
+		known[class.Name] = true
+	}
+	for _, item := range strings.Split(value, ",") {
+		name, daysStr, ok := strings.Cut(strings.TrimSpace(item), ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !known[name] {
+			return nil, fmt.Errorf("invalid retention %q, use class:days with tweets, opinions, events or tasks", item)
+		}
+		daysStr = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(daysStr), "d"))
+		if daysStr == "forever" {
+			continue
+		}
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid retention %q, days must be a number or forever", item)
+		}
+		if days > 0 {
+			policy[name] = days
+		}
+	}
+	return policy, nil
+}
+
+// retentionState is the policy of the background purge and the outcome of its last run, see /archive_status
+type retentionState struct {
+	mu      sync.Mutex
+	policy  map[string]int // days by class, classes not listed are kept forever
+	dir     string
+	lastRun time.Time
+	nextRun time.Time
+	results []retentionResult
+	err     error
+}
+
+type retentionResult struct {
+	Class    string
+	Archived int
+	File     string
+}
+
+// StartRetention archives and purges the rows older than the policy allows, first after
+// RETENTION_START_DELAY and then every interval
+func (b *BotController) StartRetention(interval time.Duration) error {
+	policy, err := parseRetentionPolicy(os.Getenv(ENV_RETENTION))
+	if err != nil {
+		return err
+	}
+	dir := os.Getenv(ENV_RETENTION_ARCHIVE_DIR)
+	if dir == "" {
+		dir = RETENTION_DEFAULT_DIR
+	}
+	b.retention.mu.Lock()
+	b.retention.policy = policy
+	b.retention.dir = dir
+	b.retention.nextRun = time.Now().Add(RETENTION_START_DELAY)
+	b.retention.mu.Unlock()
+
+	go func() {
+		time.Sleep(RETENTION_START_DELAY)
+		for {
+			b.runRetention(time.Now(), interval)
+			time.Sleep(interval)
+		}
+	}()
+	return nil
+}
+
+// runRetention archives and purges every class with a retention period
+func (b *BotController) runRetention(now time.Time, interval time.Duration) {
+	b.retention.mu.Lock()
+	policy, dir := b.retention.policy, b.retention.dir
+	b.retention.mu.Unlock()
+
+	var results []retentionResult
+	var runErr error
+	for _, class := range retentionClasses {
+		days, ok := policy[class.Name]
+		if !ok {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -days)
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.jsonl.gz", class.Name, now.UTC().Format("20060102_150405")))
+		archived, err := b.dbService.ArchiveRows(class, cutoff, path)
+		if err != nil {
+			log.Printf("Failed to archive %s older than %d days: %v", class.Name, days, err)
+			runErr = fmt.Errorf("%s: %w", class.Name, err)
+			continue
+		}
+		if archived > 0 {
+			log.Printf("🗄 Archived and purged %d %s older than %d days to %s", archived, class.Name, days, path)
+			results = append(results, retentionResult{Class: class.Name, Archived: archived, File: path})
+		}
+	}
+
+	b.retention.mu.Lock()
+	b.retention.lastRun = now
+	b.retention.nextRun = now.Add(interval)
+	b.retention.results = results
+	b.retention.err = runErr
+	b.retention.mu.Unlock()
+}
+
+// ArchiveRows writes the rows of a class older than the cutoff to a gzipped JSONL file and deletes them
+// once the file is complete. Nothing is deleted when writing fails. It returns the number of rows archived.
+func (s *DatabaseService) ArchiveRows(class retentionClass, cutoff time.Time, path string) (int, error) {
+	due := func() *gorm.DB {
+		query := s.db.Unscoped().Table(class.Table).Where(class.TimeColumn+" < ?", cutoff)
+		if class.Keep != "" {
+			query = query.Where("NOT (" + class.Keep + ")")
+		}
+		return query
+	}
+	var count int64
+	if err := due().Count(&count).Error; err != nil || count == 0 {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	compressed := gzip.NewWriter(file)
+	encoder := json.NewEncoder(compressed)
+
+	var keys []interface{}
+	var last interface{}
+	for {
+		query := due().Order(class.Key).Limit(RETENTION_BATCH)
+		if last != nil {
+			query = query.Where(class.Key+" > ?", last)
+		}
+		var rows []map[string]interface{}
+		if err := query.Find(&rows).Error; err != nil {
+			os.Remove(path)
+			return 0, err
+		}
+		for _, row := range rows {
+			if err := encoder.Encode(row); err != nil {
+				os.Remove(path)
+				return 0, err
+			}
+			keys = append(keys, row[class.Key])
+			last = row[class.Key]
+		}
+		if len(rows) < RETENTION_BATCH {
+			break
+		}
+	}
+	if err := compressed.Close(); err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+
+	for start := 0; start < len(keys); start += RETENTION_BATCH {
+		batch := keys[start:min(start+RETENTION_BATCH, len(keys))]
+		if err := s.db.Unscoped().Where(class.Key+" IN ?", batch).Delete(class.Model).Error; err != nil {
+			// The archive holds every row, rows still stored are archived again next time
+			return start, err
+		}
+	}
+	return len(keys), nil
+}
+
+// RetentionTableStats counts the rows of a class and those older than the cutoff the policy would purge
+func (s *DatabaseService) RetentionTableStats(class retentionClass, cutoff time.Time) (int64, int64, error) {
+	var total, due int64
+	if err := s.db.Unscoped().Table(class.Table).Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	if cutoff.IsZero() {
+		return total, 0, nil
+	}
+	query := s.db.Unscoped().Table(class.Table).Where(class.TimeColumn+" < ?", cutoff)
+	if class.Keep != "" {
+		query = query.Where("NOT (" + class.Keep + ")")
+	}
+	err := query.Count(&due).Error
+	return total, due, err
+}
+
+// DatabaseSize is the size of the database in bytes
+func (s *DatabaseService) DatabaseSize() (int64, error) {
+	var size int64
+	if s.Backend() == "postgres" {
+		err := s.db.Raw("SELECT pg_database_size(current_database())").Scan(&size).Error
+		return size, err
+	}
+	var pageCount, pageSize int64
+	if err := s.db.Raw("PRAGMA page_count").Scan(&pageCount).Error; err != nil {
+		return 0, err
+	}
+	if err := s.db.Raw("PRAGMA page_size").Scan(&pageSize).Error; err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
+
+// archiveDirStats counts the archive files and their size
+func archiveDirStats(dir string) (int, int64) {
+	files, size := 0, int64(0)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl.gz") {
+			continue
+		}
+		files++
+		size += info.Size()
+	}
+	return files, size
+}
+
+// formatBytes prints a size with a binary unit, e.g. 1.5 MB
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size), 0
+	for value >= unit*unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value/unit, "KMGT"[exp])
+}
+
+// handleArchiveStatusCommand processes /archive_status: database size, the retention policy and the next purge
+func (b *BotController) handleArchiveStatusCommand(chatID int64) {
+	b.retention.mu.Lock()
+	policy, dir := b.retention.policy, b.retention.dir
+	lastRun, nextRun, results, runErr := b.retention.lastRun, b.retention.nextRun, b.retention.results, b.retention.err
+	b.retention.mu.Unlock()
+
+	var text strings.Builder
+	text.WriteString("🗄 <b>Data retention</b>\n\n")
+	if size, err := b.dbService.DatabaseSize(); err != nil {
+		text.WriteString(fmt.Sprintf("💾 Database: size unknown (%v)\n", err))
+	} else {
+		text.WriteString(fmt.Sprintf("💾 Database: %s (%s)\n", formatBytes(size), b.dbService.Backend()))
+	}
+	if dir != "" {
+		files, size := archiveDirStats(dir)
+		text.WriteString(fmt.Sprintf("📦 Archive: %d files, %s in %s\n", files, formatBytes(size), dir))
+	}
+
+	text.WriteString("\n<b>Policy:</b>\n")
+	now := time.Now()
+	for _, class := range retentionClasses {
+		days, limited := policy[class.Name]
+		var cutoff time.Time
+		period := "forever"
+		if limited {
+			cutoff = now.AddDate(0, 0, -days)
+			period = fmt.Sprintf("%d days", days)
+		}
+		total, due, err := b.dbService.RetentionTableStats(class, cutoff)
+		if err != nil {
+			text.WriteString(fmt.Sprintf("• %s: %s, %v\n", class.Name, period, err))
+			continue
+		}
+		line := fmt.Sprintf("• %s: %s, %d rows", class.Name, period, total)
+		if limited {
+			line += fmt.Sprintf(", %d due", due)
+		}
+		if class.KeepNote != "" && limited {
+			line += " (" + class.KeepNote + ")"
+		}
+		text.WriteString(line + "\n")
+	}
+	text.WriteString("• detections: forever (FUD users, analyses, alerts and verdicts)\n")
+
+	text.WriteString("\n")
+	switch {
+	case lastRun.IsZero():
+		text.WriteString("⏱ Last purge: not run yet\n")
+	case len(results) == 0 && runErr == nil:
+		text.WriteString(fmt.Sprintf("⏱ Last purge: %s UTC, nothing due\n", lastRun.UTC().Format("2006-01-02 15:04")))
+	default:
+		sort.Slice(results, func(i, j int) bool { return results[i].Class < results[j].Class })
+		var archived []string
+		for _, result := range results {
+			archived = append(archived, fmt.Sprintf("%d %s", result.Archived, result.Class))
+		}
+		if len(archived) == 0 {
+			archived = []string{"nothing"}
+		}
+		text.WriteString(fmt.Sprintf("⏱ Last purge: %s UTC, archived %s\n", lastRun.UTC().Format("2006-01-02 15:04"), strings.Join(archived, ", ")))
+		if runErr != nil {
+			text.WriteString(fmt.Sprintf("⚠️ %v\n", runErr))
+		}
+	}
+	if nextRun.IsZero() {
+		text.WriteString("⏭ Next purge: retention is not running")
+	} else {
+		text.WriteString(fmt.Sprintf("⏭ Next purge: %s UTC", nextRun.UTC().Format("2006-01-02 15:04")))
+	}
+	b.SendMessage(chatID, text.String())
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	policy, err := parseRetentionPolicy("")
+	require.NoError(t, err)
+	assert.Empty(t, policy, "nothing is purged by default")
+
+	policy, err = parseRetentionPolicy("tweets:90d, events:forever,tasks:0, opinions:365")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{RETENTION_TWEETS: 90, RETENTION_OPINIONS: 365}, policy)
+
+	_, err = parseRetentionPolicy("fud_users:30")
+	assert.Error(t, err, "detections cannot be purged")
+	_, err = parseRetentionPolicy("tweets:soon")
+	assert.Error(t, err)
+}
+
+func TestRetention_ArchivesBeforePurging(t *testing.T) {
+	db := setupTestDB(t)
+	old := time.Now().AddDate(0, 0, -100)
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "1", UserID: "u1", Text: "old chatter", CreatedAt: old}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "2", UserID: "fud", Text: "old evidence", CreatedAt: old}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "3", UserID: "u1", Text: "recent", CreatedAt: time.Now()}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "fud", Username: "fudder", FUDType: "direct_attack"}))
+	for id, status := range map[string]string{"done": ANALYSIS_STATUS_COMPLETED, "queued": ANALYSIS_STATUS_PENDING} {
+		require.NoError(t, db.db.Create(&AnalysisTaskModel{ID: id, Status: status}).Error)
+		require.NoError(t, db.db.Model(&AnalysisTaskModel{}).Where("id = ?", id).Update("created_at", old).Error)
+	}
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	dir := t.TempDir()
+	bot.retention.policy = map[string]int{RETENTION_TWEETS: 90, RETENTION_TASKS: 30}
+	bot.retention.dir = dir
+	bot.runRetention(time.Now(), RETENTION_RUN_EVERY)
+
+	assert.False(t, db.TweetExists("1"))
+	assert.True(t, db.TweetExists("2"), "tweets of flagged users are evidence")
+	assert.True(t, db.TweetExists("3"))
+	_, err := db.GetAnalysisTask("done")
+	assert.Error(t, err)
+	_, err = db.GetAnalysisTask("queued")
+	assert.NoError(t, err, "unfinished tasks are kept")
+
+	require.Len(t, bot.retention.results, 2)
+	file, err := os.Open(bot.retention.results[0].File)
+	require.NoError(t, err)
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(reader)
+	require.True(t, scanner.Scan())
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+	assert.Equal(t, "1", row["id"])
+	assert.Equal(t, "old chatter", row["text"])
+	assert.False(t, scanner.Scan(), "one tweet archived")
+
+	bot.handleArchiveStatusCommand(1)
+	sent := transport.sentMessages()
+	status := sent[len(sent)-1].Text
+	assert.Contains(t, status, "📦 Archive: 2 files")
+	assert.Contains(t, status, "• tweets: 90 days, 2 rows, 0 due (tweets of flagged users are kept)")
+	assert.Contains(t, status, "• events: forever")
+	assert.Contains(t, status, "archived 1 tasks, 1 tweets")
+	assert.Contains(t, status, "⏭ Next purge: ")
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	RISK_CHART_WIDTH      = 900
+	RISK_CHART_HEIGHT     = 420
+	RISK_CHART_MARGIN     = 30
+	RISK_CHART_DOT        = 4   // half the size of the dot marking each verdict
+	RISK_CHART_MAX_SCORES = 200 // latest verdicts drawn, older ones are left out
+)
+
+var (
+	riskChartBackground = color.RGBA{255, 255, 255, 255}
+	riskChartGrid       = color.RGBA{225, 225, 225, 255}
+	riskChartAxis       = color.RGBA{120, 120, 120, 255}
+	riskChartLine       = color.RGBA{60, 90, 160, 255}
+)
+
+// riskChartColors colour the dot of each verdict by risk level, clean verdicts are green whatever their level
+var riskChartColors = map[string]color.RGBA{
+	"clean":    {40, 170, 80, 255},
+	"low":      {150, 200, 60, 255},
+	"medium":   {240, 190, 30, 255},
+	"high":     {240, 120, 30, 255},
+	"critical": {210, 30, 30, 255},
+}
+
+// handleRiskChartCommand sends the FUD probability of every analysis of a user as a chart: /riskchart_username
+func (b *BotController) handleRiskChartCommand(chatID int64, command string) {
+	username, _ := b.resolveTwitterReference(strings.TrimPrefix(command, "/riskchart_"))
+	if username == "" {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /riskchart_username")
+		return
+	}
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ User @%s not found in database", username))
+		return
+	}
+
+	scores, err := b.dbService.GetRiskScores(user.ID, RISK_CHART_MAX_SCORES)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading the risk scores of @%s: %v", user.Username, err))
+		return
+	}
+	if len(scores) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No analysis of @%s recorded yet. Run /analyze_%s first.", user.Username, user.Username))
+		return
+	}
+
+	chart, err := renderRiskChart(scores)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error drawing the chart: %v", err))
+		return
+	}
+	filename := fmt.Sprintf("%s_risk_%s.png", user.Username, time.Now().Format("20060102_150405"))
+	if err := b.SendChart(chatID, filename, chart, formatRiskChartCaption(user.Username, scores)); err != nil {
+		log.Printf("Failed to send the risk chart of @%s to chat %d: %v", user.Username, chatID, err)
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending the chart: %v", err))
+	}
+}
+
+// formatRiskChartCaption explains the chart, which has no text of its own
+func formatRiskChartCaption(username string, scores []RiskScoreModel) string {
+	first, latest := scores[0], scores[len(scores)-1]
+	low, high := latest.FUDProbability, latest.FUDProbability
+	for _, score := range scores {
+		low = min(low, score.FUDProbability)
+		high = max(high, score.FUDProbability)
+	}
+
+	var caption strings.Builder
+	caption.WriteString(fmt.Sprintf("📈 <b>Risk score of @%s</b>\n\n", username))
+	caption.WriteString(fmt.Sprintf("🗓 %s → %s, %d verdicts\n", first.RecordedAt.UTC().Format("2006-01-02 15:04"), latest.RecordedAt.UTC().Format("2006-01-02 15:04"), len(scores)))
+	caption.WriteString(fmt.Sprintf("📍 Latest: %s\n", formatReanalysisVerdict(latest.IsFUD, latest.RiskLevel, latest.FUDProbability, latest.FUDType)))
+	caption.WriteString(fmt.Sprintf("↕️ Range: %.0f%% – %.0f%%\n\n", low*100, high*100))
+	caption.WriteString("<i>FUD probability 0–100%, gridlines every 25%. Dots: 🟢 clean or low 🟡 medium 🟠 high 🔴 critical</i>")
+	return caption.String()
+}
+
+// renderRiskChart draws the FUD probability of the verdicts over time as a PNG line chart
+func renderRiskChart(scores []RiskScoreModel) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, RISK_CHART_WIDTH, RISK_CHART_HEIGHT))
+	draw.Draw(img, img.Bounds(), &image.Uniform{riskChartBackground}, image.Point{}, draw.Src)
+
+	left, right := RISK_CHART_MARGIN, RISK_CHART_WIDTH-RISK_CHART_MARGIN
+	top, bottom := RISK_CHART_MARGIN, RISK_CHART_HEIGHT-RISK_CHART_MARGIN
+	for quarter := 1; quarter <= 3; quarter++ {
+		y := bottom - (bottom-top)*quarter/4
+		drawChartLine(img, left, y, right, y, riskChartGrid)
+	}
+	drawChartLine(img, left, top, right, top, riskChartGrid)
+	drawChartLine(img, left, top, left, bottom, riskChartAxis)
+	drawChartLine(img, left, bottom, right, bottom, riskChartAxis)
+
+	// Verdicts are placed by time, a single one or several at the same moment sit in the middle
+	start, end := scores[0].RecordedAt, scores[len(scores)-1].RecordedAt
+	span := end.Sub(start)
+	points := make([]image.Point, len(scores))
+	for i, score := range scores {
+		x := (left + right) / 2
+		if span > 0 {
+			x = left + int(float64(right-left)*float64(score.RecordedAt.Sub(start))/float64(span))
+		}
+		probability := min(max(score.FUDProbability, 0), 1)
+		points[i] = image.Pt(x, bottom-int(float64(bottom-top)*probability))
+	}
+
+	for i := 1; i < len(points); i++ {
+		// Two pixels wide so the line survives Telegram's compression
+		drawChartLine(img, points[i-1].X, points[i-1].Y, points[i].X, points[i].Y, riskChartLine)
+		drawChartLine(img, points[i-1].X, points[i-1].Y+1, points[i].X, points[i].Y+1, riskChartLine)
+	}
+	for i, score := range scores {
+		level := strings.ToLower(score.RiskLevel)
+		if !score.IsFUD {
+			level = "clean"
+		}
+		dotColor, ok := riskChartColors[level]
+		if !ok {
+			dotColor = riskChartColors["medium"]
+		}
+		dot := image.Rect(points[i].X-RISK_CHART_DOT, points[i].Y-RISK_CHART_DOT, points[i].X+RISK_CHART_DOT+1, points[i].Y+RISK_CHART_DOT+1)
+		draw.Draw(img, dot, &image.Uniform{dotColor}, image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawChartLine draws a one pixel line between two points (Bresenham)
+func drawChartLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := absInt(x1-x0), -absInt(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
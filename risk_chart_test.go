@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskScoresRecorded(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveCachedAnalysis("u1", "grumbler", SecondStepClaudeResponse{IsFUDUser: true, FUDType: "casual_fud", FUDProbability: 0.7, UserRiskLevel: "medium"}, "v1"))
+	require.NoError(t, db.SaveCachedAnalysis("u1", "grumbler", SecondStepClaudeResponse{IsFUDUser: false, FUDProbability: 0.1}, ""))
+	require.NoError(t, db.SaveCachedAnalysis("u2", "other", SecondStepClaudeResponse{IsFUDUser: true, FUDProbability: 0.9}, "v1"))
+
+	scores, err := db.GetRiskScores("u1", 10)
+	require.NoError(t, err)
+	require.Len(t, scores, 2, "the cached analysis is replaced, the time series is not")
+	assert.Equal(t, 0.7, scores[0].FUDProbability)
+	assert.Equal(t, "v1", scores[0].PromptVersion)
+	assert.False(t, scores[1].IsFUD)
+
+	scores, err = db.GetRiskScores("u1", 1)
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	assert.Equal(t, 0.1, scores[0].FUDProbability, "the latest verdicts are kept")
+}
+
+func TestRenderRiskChart(t *testing.T) {
+	now := time.Now()
+	for _, scores := range [][]RiskScoreModel{
+		{{IsFUD: true, FUDProbability: 0.8, RiskLevel: "high", RecordedAt: now}},
+		{
+			{IsFUD: true, FUDProbability: 0.6, RiskLevel: "medium", RecordedAt: now.Add(-48 * time.Hour)},
+			{IsFUD: true, FUDProbability: 1.2, RiskLevel: "critical", RecordedAt: now.Add(-24 * time.Hour)},
+			{IsFUD: false, FUDProbability: -0.1, RecordedAt: now},
+		},
+	} {
+		chart, err := renderRiskChart(scores)
+		require.NoError(t, err)
+		img, err := png.Decode(bytes.NewReader(chart))
+		require.NoError(t, err)
+		assert.Equal(t, RISK_CHART_WIDTH, img.Bounds().Dx())
+		assert.Equal(t, RISK_CHART_HEIGHT, img.Bounds().Dy())
+	}
+}
+
+func TestBotController_RiskChart(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "Grumbler"}))
+
+	bot.handleRiskChartCommand(1, "/riskchart_grumbler")
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "No analysis of @Grumbler recorded yet")
+
+	require.NoError(t, db.SaveCachedAnalysis("u1", "Grumbler", SecondStepClaudeResponse{IsFUDUser: true, FUDType: "casual_fud", FUDProbability: 0.6, UserRiskLevel: "medium"}, "v1"))
+	require.NoError(t, db.SaveCachedAnalysis("u1", "Grumbler", SecondStepClaudeResponse{IsFUDUser: true, FUDType: "casual_fud", FUDProbability: 0.85, UserRiskLevel: "high"}, "v1"))
+	bot.handleRiskChartCommand(1, "/riskchart_grumbler")
+
+	require.Len(t, transport.photos, 1)
+	assert.Equal(t, int64(1), transport.photos[0].ChatID)
+	assert.Contains(t, transport.photos[0].Caption, "Risk score of @Grumbler")
+	assert.Contains(t, transport.photos[0].Caption, "2 verdicts")
+	assert.Contains(t, transport.photos[0].Caption, "Latest: FUD high 85% (casual_fud)")
+	assert.Contains(t, transport.photos[0].Caption, "Range: 60% – 85%")
+	_, err := png.Decode(bytes.NewReader(transport.photoData[0]))
+	assert.NoError(t, err)
+
+	// The chart names the user, so redacted chats don't get it, and drawing it counts as expensive
+	assert.True(t, isInvestigationCommand("/riskchart_grumbler"))
+	assert.True(t, isExpensiveCommand("/riskchart_grumbler"))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// Where the result of an analysis task is delivered
+const (
+	NOTIFY_ROUTE_ORIGIN    = "origin"    // chat or Discord channel that requested the analysis (default)
+	NOTIFY_ROUTE_BROADCAST = "broadcast" // every registered chat and notification sink
+	NOTIFY_ROUTE_CHAT      = "chat"      // one specific Telegram group
+)
+
+// parseNotifyRoute reads a to:origin, to:broadcast or to:<chat_id> command option
+func parseNotifyRoute(option string) (route string, chatID int64, err error) {
+	value, ok := strings.CutPrefix(strings.ToLower(option), "to:")
+	if !ok {
+		return "", 0, fmt.Errorf("unknown option %s", option)
+	}
+	switch value {
+	case NOTIFY_ROUTE_ORIGIN, NOTIFY_ROUTE_BROADCAST:
+		return value, 0, nil
+	}
+	chatID, err = strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid destination %s, use to:origin, to:broadcast or to:<chat_id>", option)
+	}
+	return NOTIFY_ROUTE_CHAT, chatID, nil
+}
+
+// setNotifyRoute applies a to: option to a task, only chats that receive notifications can be targeted
+func (b *BotController) setNotifyRoute(task *AnalysisTaskModel, option string) error {
+	route, chatID, err := parseNotifyRoute(option)
+	if err != nil {
+		return err
+	}
+	if route == NOTIFY_ROUTE_CHAT {
+		b.chatMutex.RLock()
+		registered := b.chatIDs[chatID]
+		b.chatMutex.RUnlock()
+		if !registered {
+			return fmt.Errorf("chat %d is not registered for notifications", chatID)
+		}
+	}
+	task.NotifyRoute = route
+	task.NotifyChatID = chatID
+	return nil
+}
+
+// describeNotifyRoute is the "results go to ..." text shown when a task starts
+func describeNotifyRoute(task *AnalysisTaskModel) string {
+	switch task.NotifyRoute {
+	case NOTIFY_ROUTE_BROADCAST:
+		return "all notification chats"
+	case NOTIFY_ROUTE_CHAT:
+		return fmt.Sprintf("chat %d", task.NotifyChatID)
+	default:
+		return "this chat only"
+	}
+}
+
+// notificationChatID is the Telegram chat the task result goes to, 0 when it is broadcast
+func (task *AnalysisTaskModel) notificationChatID() int64 {
+	switch task.NotifyRoute {
+	case NOTIFY_ROUTE_BROADCAST:
+		return 0
+	case NOTIFY_ROUTE_CHAT:
+		return task.NotifyChatID
+	default:
+		return task.TelegramChatID
+	}
+}
+
+// routeAlert addresses an alert according to the message's routing metadata. Monitoring detections
+// carry none and are broadcast.
+func routeAlert(alert *FUDAlertNotification, newMessage twitterapi.NewMessage) {
+	if newMessage.NotificationRoute == NOTIFY_ROUTE_BROADCAST {
+		alert.TargetChatID = 0
+		alert.DiscordChannelID = ""
+		return
+	}
+	alert.TargetChatID = newMessage.TelegramChatID
+	alert.DiscordChannelID = newMessage.DiscordChannelID
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyRoute(t *testing.T) {
+	bot := newTestBotController(&fakeTelegramTransport{}, setupTestDB(t))
+	bot.chatIDs[-100] = true
+
+	task := &AnalysisTaskModel{TelegramChatID: 7}
+	assert.Equal(t, int64(7), task.notificationChatID())
+
+	require.NoError(t, bot.setNotifyRoute(task, "to:broadcast"))
+	assert.Equal(t, int64(0), task.notificationChatID())
+
+	require.NoError(t, bot.setNotifyRoute(task, "to:-100"))
+	assert.Equal(t, NOTIFY_ROUTE_CHAT, task.NotifyRoute)
+	assert.Equal(t, int64(-100), task.notificationChatID())
+
+	assert.ErrorContains(t, bot.setNotifyRoute(task, "to:-200"), "not registered")
+	assert.Error(t, bot.setNotifyRoute(task, "to:somewhere"))
+	assert.Error(t, bot.setNotifyRoute(task, "broadcast"))
+
+	t.Run("Broadcast route clears targets", func(t *testing.T) {
+		message := twitterapi.NewMessage{TelegramChatID: 7, DiscordChannelID: "100", NotificationRoute: NOTIFY_ROUTE_BROADCAST}
+		alert := FUDAlertNotification{}
+		routeAlert(&alert, message)
+		assert.Zero(t, alert.TargetChatID)
+		assert.Empty(t, alert.DiscordChannelID)
+
+		message.NotificationRoute = NOTIFY_ROUTE_ORIGIN
+		routeAlert(&alert, message)
+		assert.Equal(t, int64(7), alert.TargetChatID)
+		assert.Equal(t, "100", alert.DiscordChannelID)
+	})
+}
@@ -1,19 +1,38 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/grutapig/hackaton/httpretry"
 	"github.com/grutapig/hackaton/twitterapi"
-	"log"
-	"os"
+	"log/slog"
+	"strings"
 	"time"
 )
 
-func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUDAlertNotification, twitterApi *twitterapi.TwitterAPIService, claudeApi *ClaudeApi, systemPromptSecondStep []byte, userStatusManager *UserStatusManager, ticker string, dbService *DatabaseService) {
-	// Check if we have cached analysis first (for non-manual analysis)
-	if !newMessage.IsManualAnalysis {
-		if cachedResult, err := dbService.GetCachedAnalysis(newMessage.Author.ID); err == nil {
-			log.Printf("Using cached analysis for user %s", newMessage.Author.UserName)
+func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUDAlertNotification, twitterApi TwitterAPI, claudeApi ClaudeAPI, prompts *PromptManager, userStatusManager UserStatusTracker, ticker string, dbService *DatabaseService) {
+	if isCancelledTask(newMessage, dbService) || isTrustedAuthor(newMessage, dbService) {
+		return
+	}
+	logger := messageLogger("second_step", newMessage)
+	// Messages from a monitored community are searched for that community's ticker
+	if newMessage.Ticker != "" {
+		ticker = newMessage.Ticker
+	}
+	// A report from a partner deployment is newer than any cached verdict
+	federation, err := federatedMatches(dbService, newMessage)
+	if err != nil {
+		logger.Error("failed to check federated indicators", "error", err)
+	}
+	// Check if we have cached analysis first (for non-manual analysis), watched users are analyzed every time
+	if !newMessage.IsManualAnalysis && !newMessage.Watched && federation.empty() {
+		cachedResult, err := dbService.GetCachedAnalysis(newMessage.Author.ID)
+		if err != nil {
+			dbService.RecordUsage(USAGE_CACHE, USAGE_CACHE_MISS, 1, 0, 0)
+		} else {
+			logger.Info("using cached analysis")
+			dbService.RecordUsage(USAGE_CACHE, USAGE_CACHE_HIT, 1, 0, 0)
 
 			// Use cached result instead of running full analysis
 			aiDecision2 := *cachedResult
@@ -26,15 +45,15 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 				if dbService.IsFUDUser(newMessage.Author.ID) {
 					err := dbService.DeleteFUDUser(newMessage.Author.ID)
 					if err != nil {
-						log.Printf("Failed to remove user %s from FUD list: %v", newMessage.Author.UserName, err)
+						logger.Error("failed to remove user from FUD list", "error", err)
 					} else {
-						log.Printf("Removed user %s from FUD list - cached analysis shows user is clean", newMessage.Author.UserName)
+						logger.Info("removed user from FUD list, cached analysis shows user is clean")
 					}
 
 					// Also update user model to mark as not FUD
 					err = dbService.UpdateUserFUDStatus(newMessage.Author.ID, false, "")
 					if err != nil {
-						log.Printf("Failed to update FUD status for user %s: %v", newMessage.Author.UserName, err)
+						logger.Error("failed to update FUD status", "error", err)
 					}
 				}
 			}
@@ -55,17 +74,30 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 			return
 		}
 	}
+	// The budget may have run out while the message waited for the second step
+	if analysisPaused(dbService, newMessage) {
+		deferAnalysis(dbService, newMessage)
+		return
+	}
+
+	// The task records how much history to look at, monitoring uses the configured default
+	window := messageHistoryWindow(newMessage.HistoryWindow)
+	now := time.Now()
 
 	// Get user's ticker mentions using advanced search (max 3 pages)
 	userTickerMentions := getUserTickerMentions(twitterApi, newMessage.Author.UserName, ticker, dbService)
+	window.filterTickerMentions(userTickerMentions, now)
 
 	// Get user's community activity from database
-	userCommunityActivity, err := dbService.GetUserCommunityActivity(newMessage.Author.ID)
-	if err != nil {
-		log.Printf("Error getting user community activity for %s: %v", newMessage.Author.UserName, err)
-		userCommunityActivity = &UserCommunityActivity{
-			UserID:       newMessage.Author.ID,
-			ThreadGroups: []ThreadGroup{},
+	userCommunityActivity := &UserCommunityActivity{UserID: newMessage.Author.ID, ThreadGroups: []ThreadGroup{}}
+	if !window.TickerOnly {
+		userCommunityActivity, err = dbService.GetUserCommunityActivityWindow(newMessage.Author.ID, window.Messages, window.since(now))
+		if err != nil {
+			logger.Error("failed to get user community activity", "error", err)
+			userCommunityActivity = &UserCommunityActivity{
+				UserID:       newMessage.Author.ID,
+				ThreadGroups: []ThreadGroup{},
+			}
 		}
 	}
 
@@ -89,9 +121,9 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 		}
 		err = dbService.SaveUserRelations(newMessage.Author.ID, followerIDs, RELATION_TYPE_FOLLOWER)
 		if err != nil {
-			log.Printf("Failed to save followers for user %s: %v", newMessage.Author.UserName, err)
+			logger.Error("failed to save followers", "error", err)
 		} else {
-			log.Printf("Saved %d followers for user %s", len(followerIDs), newMessage.Author.UserName)
+			logger.Debug("saved followers", "count", len(followerIDs))
 		}
 	}
 
@@ -111,14 +143,56 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 		}
 		err = dbService.SaveUserRelations(newMessage.Author.ID, followingIDs, RELATION_TYPE_FOLLOWING)
 		if err != nil {
-			log.Printf("Failed to save followings for user %s: %v", newMessage.Author.UserName, err)
+			logger.Error("failed to save followings", "error", err)
 		} else {
-			log.Printf("Saved %d followings for user %s", len(followingIDs), newMessage.Author.UserName)
+			logger.Debug("saved followings", "count", len(followingIDs))
 		}
 	}
 
 	// Prepare claude request with community activity
 	claudeMessages := PrepareClaudeSecondStepRequest(userTickerMentions, followers, followings, userStatusManager, userCommunityActivity)
+	if len(userCommunityActivity.ThreadGroups) == 0 && !window.TickerOnly {
+		// Users outside the community have their public timeline stored by /analyze instead
+		timeline, err := dbService.GetUserTweetsBySourceType(newMessage.Author.ID, TWEET_SOURCE_TIMELINE, externalTimelineLimit(), window.since(now))
+		if err != nil {
+			logger.Error("failed to get user public timeline", "error", err)
+		} else if len(timeline) > 0 {
+			claudeMessages = append(claudeMessages, prepareExternalTimelineMessage(timeline))
+		}
+	}
+	if window != (historyWindow{}) {
+		claudeMessages = append(claudeMessages, ClaudeMessage{ROLE_USER, "HISTORY WINDOW: the user history above is limited to " + window.describe() + " on purpose, missing older activity is not evidence either way"})
+	}
+
+	// Bios and pinned tweets often reveal affiliation with rival projects
+	userProfile := getUserProfile(twitterApi, newMessage.Author.ID, newMessage.Author.UserName, ticker, dbService)
+	claudeMessages = append(claudeMessages, prepareUserProfileMessage(userProfile))
+
+	promotedCompetitors := findCompetitorPromotions(newMessage.Text, userTickerMentions, userProfile)
+	if len(promotedCompetitors) > 0 {
+		logger.Info("🏷 user promotes competitors", "competitors", strings.Join(promotedCompetitors, ", "))
+		claudeMessages = append(claudeMessages, prepareCompetitorPromotionMessage(promotedCompetitors))
+	}
+
+	// Accounts sharing followers or follow links with known FUD users often belong to the same group
+	var fudConnections []string
+	connections, err := dbService.GetFUDConnections(newMessage.Author.ID)
+	if err != nil {
+		logger.Error("failed to measure FUD network", "error", err)
+	} else if len(connections) > 0 {
+		logger.Info("🕸 user is connected to known FUD accounts", "connections", len(connections))
+		claudeMessages = append(claudeMessages, prepareFUDNetworkMessage(connections))
+		fudConnections = summarizeFUDConnections(connections, FUD_NETWORK_ALERT_TOP)
+	}
+
+	if !federation.empty() {
+		claudeMessages = append(claudeMessages, prepareFederationMessage(federation))
+	}
+
+	// Moderators' verdicts from /mark_clean and /mark_fud outlive the analysis cache
+	if verdict, err := dbService.GetLatestLabeledVerdict(newMessage.Author.ID); err == nil {
+		claudeMessages = append(claudeMessages, prepareLabeledVerdictMessage(verdict))
+	}
 
 	// Add thread context in order: grandparent -> parent -> current
 	if newMessage.GrandParentTweet.ID != "" {
@@ -130,32 +204,43 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 
 	claudeMessages = append(claudeMessages, ClaudeMessage{ROLE_USER, "user reply being analyzed: " + newMessage.Author.UserName + ":" + newMessage.Text})
 	claudeMessages = append(claudeMessages, ClaudeMessage{Role: ROLE_ASSISTANT, Content: "{"})
-	pretty, _ := json.MarshalIndent(claudeMessages, "", "\t")
-	fmt.Println("send to analyze:", string(pretty))
-	//fmt.Println("send to analyze:")
-	systemPromptModified := string(systemPromptSecondStep)
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		pretty, _ := json.MarshalIndent(claudeMessages, "", "\t")
+		logger.Debug("sending for second step analysis", "messages", string(pretty))
+	}
+	systemPromptSecondStep, promptVersion := prompts.Prompt(PROMPT_STEP_SECOND)
+	systemPromptModified := string(selectPrompt(systemPromptSecondStep, newMessage))
 	if newMessage.IsManualAnalysis {
 		systemPromptModified += "\n\nIMPORTANT: This is a MANUAL ANALYSIS REQUEST initiated by an administrator. Please provide a thorough analysis regardless of normal filtering criteria."
 	}
+	if newMessage.FocusTweet {
+		systemPromptModified += "\n\nThe administrator asks about the exact reply being analyzed: judge that tweet in its thread, and use the user's other activity only as context for it."
+	}
 	systemPromptModified += " analyzed user is " + newMessage.Author.UserName
-	systemTicker := os.Getenv(ENV_TWITTER_COMMUNITY_TICKER)
-	resp, err := claudeApi.SendMessage(claudeMessages, systemPromptModified+"\nthe system ticker is:"+systemTicker+", it cannot be used for any criteria or flag about decision FUD or not")
+	if isCancelledTask(newMessage, dbService) {
+		return
+	}
+	resp, err := claudeApi.ForStep(USAGE_STEP_SECOND).SendMessage(claudeMessages, systemPromptModified+communityPromptContext(newMessage))
 	aiDecision2 := SecondStepClaudeResponse{}
-	fmt.Println("claude make a decision for this user:", resp, err)
-
 	if err != nil {
+		recordTaskRetries(newMessage, httpretry.Retries(err), dbService)
 		failManualAnalysisTask(newMessage, err, dbService)
-		log.Printf("error claude second step: %s", err)
+		logger.Error("claude second step failed", "error", err)
+		if isLLMUnavailable(err) {
+			sendHeuristicAlert(newMessage, dbService.IsFUDUser(newMessage.Author.ID), HEURISTIC_REASON_UNAVAILABLE, notificationCh)
+		}
 		return
 	}
+	recordUserUsage(dbService, newMessage, USAGE_STEP_SECOND, resp)
+	recordTaskRetries(newMessage, resp.Retries, dbService)
 
 	err = json.Unmarshal([]byte("{"+resp.Content[0].Text), &aiDecision2)
 	if err != nil {
-		log.Printf("error unmarshaling claude response: %s", err)
+		logger.Error("unmarshaling claude response failed", "error", err)
+		failManualAnalysisTask(newMessage, newTaskError(TASK_ERROR_INTERNAL, fmt.Errorf("unparsable answer of the neural network: %w", err)), dbService)
 		return
 	}
-	pretty, _ = json.MarshalIndent(aiDecision2, "", "\t")
-	fmt.Println(string(pretty))
+	logger.Info("second step decision", "is_fud", aiDecision2.IsFUDUser, "fud_type", aiDecision2.FUDType, "fud_probability", aiDecision2.FUDProbability, "risk_level", aiDecision2.UserRiskLevel, "provider", resp.Provider)
 
 	// Update user status after analysis
 	userStatusManager.UpdateUserAfterAnalysis(newMessage.Author.ID, newMessage.Author.UserName, aiDecision2, newMessage.TweetID)
@@ -165,30 +250,42 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 		if dbService.IsFUDUser(newMessage.Author.ID) {
 			err := dbService.DeleteFUDUser(newMessage.Author.ID)
 			if err != nil {
-				log.Printf("Failed to remove user %s from FUD list: %v", newMessage.Author.UserName, err)
+				logger.Error("failed to remove user from FUD list", "error", err)
 			} else {
-				log.Printf("Removed user %s from FUD list - analysis shows user is clean", newMessage.Author.UserName)
+				logger.Info("removed user from FUD list, analysis shows user is clean")
 			}
 
 			// Also update user model to mark as not FUD
 			err = dbService.UpdateUserFUDStatus(newMessage.Author.ID, false, "")
 			if err != nil {
-				log.Printf("Failed to update FUD status for user %s: %v", newMessage.Author.UserName, err)
+				logger.Error("failed to update FUD status", "error", err)
 			}
 		}
 	}
 
-	if aiDecision2.IsFUDUser || newMessage.ForceNotification {
+	if (aiDecision2.IsFUDUser || newMessage.ForceNotification || newMessage.Watched) && !newMessage.Reanalysis {
+		newFUDType := ""
 		// Store FUD user in database only if actually detected as FUD
 		if aiDecision2.IsFUDUser {
 			fudUser := FUDUserModel{
-				UserID:         newMessage.Author.ID,
-				Username:       newMessage.Author.UserName,
-				FUDType:        aiDecision2.FUDType,
-				FUDProbability: aiDecision2.FUDProbability,
-				DetectedAt:     time.Now(),
-				MessageCount:   1,
-				LastMessageID:  newMessage.TweetID,
+				UserID:              newMessage.Author.ID,
+				Username:            newMessage.Author.UserName,
+				FUDType:             aiDecision2.FUDType,
+				FUDProbability:      aiDecision2.FUDProbability,
+				DetectedAt:          time.Now(),
+				MessageCount:        1,
+				LastMessageID:       newMessage.TweetID,
+				PromotedCompetitors: strings.Join(promotedCompetitors, ","),
+			}
+
+			if isTrackedFUDType(aiDecision2.FUDType) {
+				added, err := dbService.RecordFUDTypeSighting(aiDecision2.FUDType, newMessage.Author.ID, newMessage.Author.UserName, newMessage.TweetID)
+				if err != nil {
+					logger.Error("failed to record FUD type", "fud_type", aiDecision2.FUDType, "error", err)
+				} else if added {
+					logger.Info("new FUD type observed", "fud_type", aiDecision2.FUDType)
+					newFUDType = normalizeFUDType(aiDecision2.FUDType)
+				}
 			}
 
 			// Check if FUD user already exists
@@ -196,17 +293,24 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 				// Increment message count for existing FUD user
 				err = dbService.IncrementFUDUserMessageCount(newMessage.Author.ID, newMessage.TweetID)
 				if err != nil {
-					log.Printf("Failed to increment FUD user message count: %v", err)
+					logger.Error("failed to increment FUD user message count", "error", err)
+				}
+				err = dbService.UpdateFUDUserPromotions(newMessage.Author.ID, fudUser.PromotedCompetitors)
+				if err != nil {
+					logger.Error("failed to update competitor promotions", "error", err)
 				}
 			} else {
 				// Save new FUD user
 				err = dbService.SaveFUDUser(fudUser)
 				if err != nil {
-					log.Printf("Failed to save FUD user: %v", err)
+					logger.Error("failed to save FUD user", "error", err)
 				} else {
-					log.Printf("Stored new FUD user: %s", newMessage.Author.UserName)
+					logger.Info("stored new FUD user")
 				}
 			}
+
+			// Track who retweets the flagged post
+			TrackAmplifiers(twitterApi, dbService, newMessage.TweetID, newMessage.Author.ID)
 		}
 
 		// Determine thread context from newMessage
@@ -269,25 +373,36 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 			GrandParentPostText:   grandParentPostText,
 			GrandParentPostAuthor: grandParentPostAuthor,
 			HasThreadContext:      hasThreadContext,
-			TargetChatID:          newMessage.TelegramChatID, // Set target chat if specified
+			PromotedCompetitors:   promotedCompetitors,
+			FUDConnections:        fudConnections,
+			FederationMatches:     federation.summary(),
+			PromptVersion:         promptVersionLabel(PROMPT_STEP_SECOND, promptVersion),
+			NewFUDType:            newFUDType,
+			Watched:               newMessage.Watched,
+			WatchOnly:             newMessage.Watched && !aiDecision2.IsFUDUser && !newMessage.ForceNotification,
+			CommunityID:           newMessage.CommunityID,
+			Ticker:                newMessage.Ticker,
+			RequestSource:         newMessage.RequestSource,
+			RequestReason:         newMessage.RequestReason,
 		}
+		routeAlert(&alert, newMessage)
 		notificationCh <- alert
 	}
 
 	// Save analysis result to cache (24-hour expiration)
-	err = dbService.SaveCachedAnalysis(newMessage.Author.ID, newMessage.Author.UserName, aiDecision2)
+	err = dbService.SaveCachedAnalysis(newMessage.Author.ID, newMessage.Author.UserName, aiDecision2, promptVersionLabel(PROMPT_STEP_SECOND, promptVersion))
 	if err != nil {
-		log.Printf("Failed to save cached analysis for user %s: %v", newMessage.Author.UserName, err)
+		logger.Error("failed to save cached analysis", "error", err)
 	} else {
-		log.Printf("Saved cached analysis for user %s", newMessage.Author.UserName)
+		logger.Debug("saved cached analysis")
 	}
 
 	// Mark user as having been through detailed analysis
 	err = dbService.MarkUserAsDetailAnalyzed(newMessage.Author.ID)
 	if err != nil {
-		log.Printf("Failed to mark user %s as detail analyzed: %v", newMessage.Author.UserName, err)
+		logger.Error("failed to mark user as detail analyzed", "error", err)
 	} else {
-		log.Printf("Marked user %s as detail analyzed", newMessage.Author.UserName)
+		logger.Debug("marked user as detail analyzed")
 	}
 
 	// Complete manual analysis task if this was a manual analysis
@@ -296,6 +411,23 @@ func SecondStepHandler(newMessage twitterapi.NewMessage, notificationCh chan FUD
 	}
 }
 
+// nextSecondStepMessage returns the next message for the second step, preferring high priority ones.
+// It reports false once the regular FUD channel is closed.
+func nextSecondStepMessage(priorityCh <-chan twitterapi.NewMessage, fudCh <-chan twitterapi.NewMessage) (twitterapi.NewMessage, bool) {
+	select {
+	case newMessage := <-priorityCh:
+		return newMessage, true
+	default:
+	}
+
+	select {
+	case newMessage := <-priorityCh:
+		return newMessage, true
+	case newMessage, ok := <-fudCh:
+		return newMessage, ok
+	}
+}
+
 func mapRiskLevelToSeverity(riskLevel string) string {
 	switch riskLevel {
 	case "critical":
@@ -311,6 +443,22 @@ func mapRiskLevelToSeverity(riskLevel string) string {
 	}
 }
 
+// severityRank orders alert severities; "none" ranks below everything, unknown values count as medium
+func severityRank(severity string) int {
+	switch severity {
+	case "none":
+		return 0
+	case "low":
+		return 1
+	case "high":
+		return 3
+	case "critical":
+		return 4
+	default:
+		return 2
+	}
+}
+
 func getRecommendedAction(decision SecondStepClaudeResponse) string {
 	if decision.UserRiskLevel == "critical" {
 		return "IMMEDIATE_ACTION_REQUIRED"
@@ -323,6 +471,7 @@ func getRecommendedAction(decision SecondStepClaudeResponse) string {
 
 // sendCachedNotification sends notification using cached analysis result
 func sendCachedNotification(newMessage twitterapi.NewMessage, aiDecision2 SecondStepClaudeResponse, notificationCh chan FUDAlertNotification, dbService *DatabaseService) {
+	logger := messageLogger("second_step", newMessage)
 	// Determine thread context from newMessage
 	originalPostText := ""
 	originalPostAuthor := ""
@@ -366,15 +515,15 @@ func sendCachedNotification(newMessage twitterapi.NewMessage, aiDecision2 Second
 			// Increment message count for existing FUD user
 			err := dbService.IncrementFUDUserMessageCount(newMessage.Author.ID, newMessage.TweetID)
 			if err != nil {
-				log.Printf("Failed to increment FUD user message count: %v", err)
+				logger.Error("failed to increment FUD user message count", "error", err)
 			}
 		} else {
 			// Save new FUD user
 			err := dbService.SaveFUDUser(fudUser)
 			if err != nil {
-				log.Printf("Failed to save FUD user: %v", err)
+				logger.Error("failed to save FUD user", "error", err)
 			} else {
-				log.Printf("Stored new FUD user: %s", newMessage.Author.UserName)
+				logger.Info("stored new FUD user")
 			}
 		}
 	}
@@ -392,6 +541,12 @@ func sendCachedNotification(newMessage twitterapi.NewMessage, aiDecision2 Second
 		}
 	}
 
+	// Competitors found during the last full analysis
+	var promotedCompetitors []string
+	if fudUser, err := dbService.GetFUDUser(newMessage.Author.ID); err == nil && fudUser.PromotedCompetitors != "" {
+		promotedCompetitors = strings.Split(fudUser.PromotedCompetitors, ",")
+	}
+
 	alert := FUDAlertNotification{
 		FUDMessageID:          newMessage.TweetID,
 		FUDUserID:             newMessage.Author.ID,
@@ -413,27 +568,60 @@ func sendCachedNotification(newMessage twitterapi.NewMessage, aiDecision2 Second
 		GrandParentPostText:   grandParentPostText,
 		GrandParentPostAuthor: grandParentPostAuthor,
 		HasThreadContext:      hasThreadContext,
-		TargetChatID:          newMessage.TelegramChatID, // Set target chat if specified
+		PromotedCompetitors:   promotedCompetitors,
+		RequestSource:         newMessage.RequestSource,
+		RequestReason:         newMessage.RequestReason,
+		CommunityID:           newMessage.CommunityID,
+		Ticker:                newMessage.Ticker,
 	}
+	routeAlert(&alert, newMessage)
 	notificationCh <- alert
 }
 
+// isCancelledTask checks if the manual analysis the message belongs to was cancelled with /cancel_<taskID>
+func isCancelledTask(newMessage twitterapi.NewMessage, dbService *DatabaseService) bool {
+	if newMessage.TaskID == "" || !dbService.IsAnalysisTaskCancelled(newMessage.TaskID) {
+		return false
+	}
+	messageLogger("second_step", newMessage).Info("🛑 skipping cancelled analysis task")
+	return true
+}
+
 // completeManualAnalysisTask completes manual analysis task with results
 func completeManualAnalysisTask(newMessage twitterapi.NewMessage, aiDecision2 SecondStepClaudeResponse, dbService *DatabaseService) {
 	// Update progress to saving results
 	dbService.UpdateAnalysisTaskProgress(newMessage.TaskID, ANALYSIS_STEP_SAVING_RESULTS, "Analysis completed, saving results...")
 
 	// Complete the task with analysis results
-	resultData := fmt.Sprintf(`{"analysis_complete": true, "is_fud": %t, "fud_type": "%s", "user_summary": "%s", "timestamp": "%s"}`,
-		aiDecision2.IsFUDUser, aiDecision2.FUDType, aiDecision2.UserSummary, time.Now().Format(time.RFC3339))
-
-	err := dbService.CompleteAnalysisTask(newMessage.TaskID, resultData)
+	resultData, _ := json.Marshal(AnalysisTaskResult{
+		AnalysisComplete: true,
+		IsFUD:            aiDecision2.IsFUDUser,
+		FUDType:          aiDecision2.FUDType,
+		FUDProbability:   aiDecision2.FUDProbability,
+		UserRiskLevel:    aiDecision2.UserRiskLevel,
+		UserSummary:      aiDecision2.UserSummary,
+		Timestamp:        time.Now().Format(time.RFC3339),
+	})
+
+	err := dbService.CompleteAnalysisTask(newMessage.TaskID, string(resultData))
 	if err != nil {
-		log.Printf("Failed to complete analysis task %s: %v", newMessage.TaskID, err)
+		messageLogger("second_step", newMessage).Error("failed to complete analysis task", "error", err)
+		dbService.SetAnalysisTaskError(newMessage.TaskID, TASK_ERROR_DB_ERROR, fmt.Sprintf("Failed to save the results: %v", err))
 	} else {
-		log.Printf("Completed manual analysis task %s for user %s", newMessage.TaskID, newMessage.Author.UserName)
+		messageLogger("second_step", newMessage).Info("completed manual analysis task")
+	}
+}
+
+// recordTaskRetries counts the retries an API call of a manual analysis needed on its task
+func recordTaskRetries(newMessage twitterapi.NewMessage, retries int, dbService *DatabaseService) {
+	if newMessage.TaskID == "" || retries == 0 {
+		return
+	}
+	if err := dbService.AddAnalysisTaskRetries(newMessage.TaskID, retries); err != nil {
+		messageLogger("second_step", newMessage).Error("failed to record retries on task", "retries", retries, "error", err)
 	}
 }
+
 func failManualAnalysisTask(newMessage twitterapi.NewMessage, err error, dbService *DatabaseService) {
-	dbService.SetAnalysisTaskError(newMessage.TaskID, err.Error())
+	dbService.SetAnalysisTaskError(newMessage.TaskID, classifyTaskError(err), err.Error())
 }
@@ -0,0 +1,37 @@
+package main
+
+import "github.com/grutapig/hackaton/twitterapi"
+
+// TwitterAPI is the part of the twitter provider the monitoring and analysis handlers rely on
+type TwitterAPI interface {
+	GetCommunityTweets(req twitterapi.CommunityTweetsRequest) (*twitterapi.CommunityTweetsResponse, error)
+	GetTweetReplies(req twitterapi.TweetRepliesRequest) (*twitterapi.TweetRepliesResponse, error)
+	GetUserLastTweets(req twitterapi.UserLastTweetsRequest) (*twitterapi.UserLastTweetsResponse, error)
+	GetUserInfo(req twitterapi.UserInfoRequest) (*twitterapi.UserInfoResponse, error)
+	GetUserFollowers(req twitterapi.UserFollowersRequest) (*twitterapi.UserFollowersResponse, error)
+	GetUserFollowings(req twitterapi.UserFollowingsRequest) (*twitterapi.UserFollowingsResponse, error)
+	GetTweetRetweeters(req twitterapi.TweetRetweetersRequest) (*twitterapi.TweetRetweetersResponse, error)
+	GetTweetsByIds(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error)
+	AdvancedSearch(request twitterapi.AdvancedSearchRequest) (*twitterapi.AdvancedSearchResponse, error)
+}
+
+// ClaudeAPI sends analysis prompts to the LLM
+type ClaudeAPI interface {
+	SendMessage(claudeMessages ClaudeMessages, systemMessage string) (*ClaudeMessageResponse, error)
+	// ForStep returns a client whose calls are counted under the given pipeline step
+	ForStep(step string) ClaudeAPI
+}
+
+// UserStatusTracker keeps the per-user analysis state shared by the first and second step.
+// It is implemented by *UserStatusManager.
+type UserStatusTracker interface {
+	SetUserAnalyzing(userID, username string)
+	UpdateUserAfterAnalysis(userID, username string, aiDecision SecondStepClaudeResponse, messageID string)
+	GetFUDFriendsAnalysis(usernames []string) (int, int, []string)
+}
+
+var (
+	_ TwitterAPI        = (*twitterapi.TwitterAPIService)(nil)
+	_ ClaudeAPI         = (*ClaudeApi)(nil)
+	_ UserStatusTracker = (*UserStatusManager)(nil)
+)
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	SIGNAL_TOKEN_HEADER = "X-Signal-Token"
+	SIGNAL_MAX_TARGETS  = 20
+	SIGNAL_MAX_BODY     = 64 << 10
+)
+
+// signalQueue starts analyses for submitted signals, implemented by BotController
+type signalQueue interface {
+	analysisQueue
+	resolveTwitterReference(input string) (username string, tweetID string)
+}
+
+// SignalRequest is what external moderation tools POST to /api/signals
+type SignalRequest struct {
+	Targets   []string `json:"targets"`   // usernames, profile links or tweet URLs
+	Submitter string   `json:"submitter"` // optional, the moderator or form user behind the submission
+	Reason    string   `json:"reason"`    // optional, shown next to the verdict
+}
+
+type SignalResult struct {
+	Target   string `json:"target"`
+	Username string `json:"username,omitempty"`
+	TweetID  string `json:"tweet_id,omitempty"`
+	TaskID   string `json:"task_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// parseSignalTokens reads ENV_SIGNAL_TOKENS, e.g. "discord-modbot:s3cret,webform:an0ther", into token -> source
+func parseSignalTokens(raw string) (map[string]string, error) {
+	return parseSourceTokens(ENV_SIGNAL_TOKENS, raw)
+}
+
+// parseSourceTokens reads comma-separated source:token pairs from the named variable into token -> source
+func parseSourceTokens(envName string, raw string) (map[string]string, error) {
+	tokens := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		source, token, ok := strings.Cut(pair, ":")
+		source, token = strings.TrimSpace(source), strings.TrimSpace(token)
+		if !ok || source == "" || token == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected source:token", envName, pair)
+		}
+		tokens[token] = source
+	}
+	return tokens, nil
+}
+
+// signalSource returns the source the provided token belongs to, comparing in constant time
+func signalSource(tokens map[string]string, provided string) (string, bool) {
+	source, found := "", false
+	for token, name := range tokens {
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+			source, found = name, true
+		}
+	}
+	return source, found
+}
+
+// newSignalHandler serves POST /api/signals. Every target becomes a high priority analysis task
+// credited to the source of the token; the verdict is broadcast like other manual analyses.
+func newSignalHandler(tokens map[string]string, queue signalQueue) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source, ok := signalSource(tokens, r.Header.Get(SIGNAL_TOKEN_HEADER))
+		if !ok {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		var request SignalRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, SIGNAL_MAX_BODY)).Decode(&request)
+		if err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if len(request.Targets) == 0 || len(request.Targets) > SIGNAL_MAX_TARGETS {
+			http.Error(w, fmt.Sprintf("targets must contain 1 to %d usernames or tweet URLs", SIGNAL_MAX_TARGETS), http.StatusBadRequest)
+			return
+		}
+
+		requestSource := source
+		if submitter := strings.TrimSpace(request.Submitter); submitter != "" {
+			requestSource = fmt.Sprintf("%s (%s)", source, submitter)
+		}
+
+		results := make([]SignalResult, 0, len(request.Targets))
+		for _, target := range request.Targets {
+			result := SignalResult{Target: target}
+			result.Username, result.TweetID = queue.resolveTwitterReference(strings.TrimSpace(target))
+			if !reportUsernameRegex.MatchString(result.Username) {
+				result.Error = "not a username or tweet URL"
+				results = append(results, result)
+				continue
+			}
+			result.TaskID, err = queue.queueAnalysisTask(&AnalysisTaskModel{
+				Username:      result.Username,
+				TweetID:       result.TweetID,
+				Priority:      ANALYSIS_PRIORITY_HIGH,
+				RequestSource: requestSource,
+				RequestReason: strings.TrimSpace(request.Reason),
+			})
+			if err != nil {
+				result.Error = "failed to start analysis"
+				log.Printf("Failed to queue signal from %s about @%s: %v", requestSource, result.Username, err)
+			} else {
+				log.Printf("📨 Signal from %s: analyzing @%s as task %s", requestSource, result.Username, result.TaskID)
+			}
+			results = append(results, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"source": source, "results": results})
+	})
+}
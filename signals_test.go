@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSignalQueue struct {
+	fakeAnalysisQueue
+}
+
+func (f *fakeSignalQueue) resolveTwitterReference(input string) (string, string) {
+	return parseTwitterReference(input)
+}
+
+func TestParseSignalTokens(t *testing.T) {
+	tokens, err := parseSignalTokens(" modbot:s3cret , webform:an0ther,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"s3cret": "modbot", "an0ther": "webform"}, tokens)
+
+	_, err = parseSignalTokens("modbot")
+	assert.Error(t, err)
+}
+
+func TestSignalHandler(t *testing.T) {
+	queue := &fakeSignalQueue{}
+	handler := newSignalHandler(map[string]string{"s3cret": "modbot"}, queue)
+	post := func(token string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/signals", strings.NewReader(body))
+		request.Header.Set(SIGNAL_TOKEN_HEADER, token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusForbidden, post("wrong", `{"targets":["shady"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("s3cret", `{"targets":[]}`).Code)
+	assert.Empty(t, queue.tasks)
+
+	recorder := post("s3cret", `{"targets":["https://x.com/shady/status/123","@other","not a user!"],"submitter":"mod_alice","reason":"scam DMs"}`)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	var response struct {
+		Source  string         `json:"source"`
+		Results []SignalResult `json:"results"`
+	}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+	assert.Equal(t, "modbot", response.Source)
+	require.Len(t, response.Results, 3)
+	assert.Equal(t, SignalResult{Target: "https://x.com/shady/status/123", Username: "shady", TweetID: "123", TaskID: "task-1"}, response.Results[0])
+	assert.NotEmpty(t, response.Results[2].Error)
+
+	require.Len(t, queue.tasks, 2)
+	assert.Equal(t, ANALYSIS_PRIORITY_HIGH, queue.tasks[0].Priority)
+	assert.Equal(t, "modbot (mod_alice)", queue.tasks[0].RequestSource)
+	assert.Equal(t, "scam DMs", queue.tasks[0].RequestReason)
+	assert.Equal(t, "other", queue.tasks[1].Username)
+
+	t.Run("Source is credited in the result", func(t *testing.T) {
+		alert := FUDAlertNotification{FUDUsername: "shady", FUDType: "manual_analysis_clean", RequestSource: "modbot (mod_alice)", RequestReason: "scam <DMs>"}
+		assert.Contains(t, NewNotificationFormatter().FormatForTelegram(alert), "📨 <b>Submitted by:</b> modbot (mod_alice) — <i>scam &lt;DMs&gt;</i>")
+		assert.Contains(t, NewNotificationFormatter().FormatForDiscord(alert), "📨 **Submitted by:** modbot (mod_alice)")
+	})
+
+	t.Run("Fresh analysis keeps the submitter", func(t *testing.T) {
+		message := twitterapi.NewMessage{TweetID: "123", Text: "dump it", IsManualAnalysis: true, ForceNotification: true,
+			RequestSource: queue.tasks[0].RequestSource, RequestReason: queue.tasks[0].RequestReason}
+		message.Author.ID, message.Author.UserName = "shady-id", "shady"
+		alert := detectFresh(t, setupTestDB(t), message)
+		assert.Equal(t, "modbot (mod_alice)", alert.RequestSource)
+		assert.Equal(t, "scam DMs", alert.RequestReason)
+		assert.Contains(t, NewNotificationFormatter().FormatForTelegram(alert), "📨 <b>Submitted by:</b> modbot (mod_alice) — <i>scam DMs</i>")
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SOFT_DELETE_GRACE       = 30 * 24 * time.Hour // deleted user data can be restored for this long, then it is purged
+	SOFT_DELETE_PURGE_EVERY = 6 * time.Hour
+	RESTORE_LIST_LIMIT      = 20
+)
+
+// softDeleteGrace reads ENV_SOFT_DELETE_GRACE_DAYS, falling back to SOFT_DELETE_GRACE
+func softDeleteGrace() time.Duration {
+	days, err := strconv.Atoi(os.Getenv(ENV_SOFT_DELETE_GRACE_DAYS))
+	if err != nil || days <= 0 {
+		return SOFT_DELETE_GRACE
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// handleRestoreUserCommand processes /restore_user (list restorable users) and /restore_user_<username>
+func (b *BotController) handleRestoreUserCommand(chatID int64, actor string, command string) {
+	grace := softDeleteGrace()
+	since := time.Now().Add(-grace)
+	username := strings.TrimPrefix(strings.TrimPrefix(command, "/restore_user"), "_")
+
+	if username == "" {
+		deleted, err := b.dbService.GetDeletedUsers(since)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading deleted users: %v", err))
+			return
+		}
+		if len(deleted) == 0 {
+			b.SendMessage(chatID, fmt.Sprintf("🗑 No user data deleted in the last %d days", int(grace.Hours()/24)))
+			return
+		}
+
+		var message strings.Builder
+		message.WriteString(fmt.Sprintf("🗑 <b>Deleted user data</b> (restorable for %d days after deletion)\n\n", int(grace.Hours()/24)))
+		for i, user := range deleted {
+			if i == RESTORE_LIST_LIMIT {
+				message.WriteString(fmt.Sprintf("\n… and %d more", len(deleted)-RESTORE_LIST_LIMIT))
+				break
+			}
+			what := "profile"
+			if user.FUDType != "" {
+				what = "FUD record (" + b.formatter.formatFUDType(user.FUDType) + ")"
+			}
+			message.WriteString(fmt.Sprintf("• @%s — %s, deleted %s\n   /restore_user_%s\n",
+				html.EscapeString(user.Username), what, user.DeletedAt.Format("2006-01-02 15:04"), user.Username))
+		}
+		b.SendMessage(chatID, message.String())
+		return
+	}
+
+	username, _ = b.resolveTwitterReference(username)
+	restored, err := b.dbService.RestoreUser(username, since)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Cannot restore @%s: %s", html.EscapeString(username), html.EscapeString(err.Error())))
+		return
+	}
+
+	var parts []string
+	if restored.Profile {
+		parts = append(parts, "user profile")
+	}
+	if restored.Tweets > 0 {
+		parts = append(parts, fmt.Sprintf("%d tweets", restored.Tweets))
+	}
+	if restored.FUDRecord != nil {
+		parts = append(parts, fmt.Sprintf("FUD record (%s)", b.formatter.formatFUDType(restored.FUDRecord.FUDType)))
+	}
+	log.Printf("♻️ %s restored @%s: %s", actor, username, strings.Join(parts, ", "))
+	b.SendMessage(chatID, fmt.Sprintf("♻️ <b>Restored @%s</b>\n\n%s", html.EscapeString(username), strings.Join(parts, "\n")))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DEFAULT_COMMAND_RATE_PER_MINUTE         = 20
+	DEFAULT_EXPENSIVE_COMMAND_RATE_PER_HOUR = 10
+	COMMAND_BURST                           = 10
+	EXPENSIVE_COMMAND_BURST                 = 3
+
+	EXPENSIVE_LIMIT_WARNING = "⏳ You reached the limit for analysis and export commands. Please try again later."
+)
+
+// senderLimiter keeps per-sender command budgets so one user can't flood the bot
+type senderLimiter struct {
+	mu      sync.Mutex
+	senders map[int64]*senderLimit
+}
+
+type senderLimit struct {
+	commands  *tokenBucket
+	expensive *tokenBucket
+	// Each budget warns once, so a sender limited on one is still warned about the other
+	warnedCommands  bool
+	warnedExpensive bool
+}
+
+func (l *senderLimiter) get(senderID int64) *senderLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.senders == nil {
+		l.senders = make(map[int64]*senderLimit)
+	}
+	limit, ok := l.senders[senderID]
+	if !ok {
+		limit = &senderLimit{
+			commands:  newTokenBucket(float64(envRate(ENV_COMMAND_RATE_PER_MINUTE, DEFAULT_COMMAND_RATE_PER_MINUTE))/60, COMMAND_BURST),
+			expensive: newTokenBucket(float64(envRate(ENV_EXPENSIVE_COMMAND_RATE_PER_HOUR, DEFAULT_EXPENSIVE_COMMAND_RATE_PER_HOUR))/3600, EXPENSIVE_COMMAND_BURST),
+		}
+		l.senders[senderID] = limit
+	}
+	return limit
+}
+
+func envRate(name string, fallback int) int {
+	rate, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || rate <= 0 {
+		return fallback
+	}
+	return rate
+}
+
+// isExpensiveCommand reports commands that start Claude analysis or large exports
+func isExpensiveCommand(command string) bool {
+	return strings.HasPrefix(command, "/analyze") || strings.HasPrefix(command, "/export") ||
+		strings.HasPrefix(command, "/graph") || strings.HasPrefix(command, "/riskchart_") ||
+		command == "/batch_analyze" || command == "/report" || strings.HasPrefix(command, "/report_")
+}
+
+// commandName returns the command word of a message without the @botname suffix
+func commandName(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.SplitN(fields[0], "@", 2)[0]
+}
+
+// isAllowedPrivateSender checks the private chat allow-list. Group chats and an empty list allow everyone.
+func isAllowedPrivateSender(update TelegramUpdate) bool {
+	allowlist := strings.TrimSpace(os.Getenv(ENV_PRIVATE_CHAT_ALLOWLIST))
+	if update.Message.Chat.Type != "private" || allowlist == "" {
+		return true
+	}
+
+	senderID := strconv.FormatInt(update.Message.From.ID, 10)
+	for _, entry := range strings.Split(allowlist, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == senderID {
+			return true
+		}
+		if update.Message.From.Username != "" && strings.EqualFold(strings.TrimPrefix(entry, "@"), update.Message.From.Username) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowSender applies the allow-list and per-sender rate limits. Admin chats are never limited.
+// A limited sender is warned once and then ignored until their budget refills.
+func (b *BotController) allowSender(update TelegramUpdate) bool {
+	chatID := update.Message.Chat.ID
+	if b.isAdminChat(chatID) {
+		return true
+	}
+
+	if !isAllowedPrivateSender(update) {
+		log.Printf("Ignoring private chat %d from @%s (id %d): not on the allow-list", chatID, update.Message.From.Username, update.Message.From.ID)
+		return false
+	}
+
+	limit := b.senders.get(update.Message.From.ID)
+	if !b.allowBudget(update, limit.commands, &limit.warnedCommands, "⏳ Too many commands, please slow down.") {
+		return false
+	}
+	if isExpensiveCommand(commandName(commandText(update))) {
+		return b.allowBudget(update, limit.expensive, &limit.warnedExpensive, EXPENSIVE_LIMIT_WARNING)
+	}
+	return true
+}
+
+// allowExpandedCommand charges the expensive budget for a command that a shortcut expanded into.
+// allowSender only sees the word the sender typed, so it has not charged it unless that word was expensive too.
+func (b *BotController) allowExpandedCommand(update TelegramUpdate, expanded string) bool {
+	if b.isAdminChat(update.Message.Chat.ID) || !isExpensiveCommand(commandName(expanded)) || isExpensiveCommand(commandName(commandText(update))) {
+		return true
+	}
+
+	limit := b.senders.get(update.Message.From.ID)
+	return b.allowBudget(update, limit.expensive, &limit.warnedExpensive, EXPENSIVE_LIMIT_WARNING)
+}
+
+// allowBudget takes a token from the budget, warning the sender once until the budget lets a command through again
+func (b *BotController) allowBudget(update TelegramUpdate, budget *tokenBucket, warned *bool, warning string) bool {
+	chatID := update.Message.Chat.ID
+	allowed := budget.allow()
+	b.senders.mu.Lock()
+	defer b.senders.mu.Unlock()
+	if allowed {
+		*warned = false
+		return true
+	}
+	if !*warned {
+		*warned = true
+		log.Printf("Rate limited sender %d in chat %d", update.Message.From.ID, chatID)
+		go b.SendMessage(chatID, warning)
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ANON_STATS_DEFAULT_DAYS = 30
+	ANON_STATS_MAX_DAYS     = 365
+	ANON_STATS_MIN_COUNT    = 3 // breakdown counts below this are left out, a rare category can point at one account
+	ANON_STATS_REMOVED      = "removed"
+	ANON_STATS_HASHED       = "hashed"
+)
+
+// AnonymizedStats is an aggregate of detections safe to share outside the team: no usernames,
+// user IDs, tweet IDs or texts, and small counts suppressed
+type AnonymizedStats struct {
+	GeneratedAt     string              `json:"generated_at"`
+	Since           string              `json:"since"`
+	Until           string              `json:"until"`
+	Accounts        string              `json:"accounts"` // "removed" or "hashed"
+	SuppressedBelow int                 `json:"suppressed_below"`
+	Detections      int                 `json:"detections"`
+	FlaggedAccounts int                 `json:"flagged_accounts"`
+	NewFUDAccounts  int64               `json:"new_fud_accounts"`
+	RepeatOffenders int                 `json:"repeat_offenders"` // accounts with more than one detection
+	BySeverity      map[string]int      `json:"by_severity"`
+	ByNarrative     map[string]int      `json:"by_narrative"`
+	ByTicker        map[string]int      `json:"by_ticker,omitempty"`
+	Daily           []AnonymizedDay     `json:"daily"`
+	NarrativeTrends []NarrativeTrend    `json:"narrative_trends"`
+	HashedAccounts  []AnonymizedAccount `json:"hashed_accounts,omitempty"`
+}
+
+type AnonymizedDay struct {
+	Date        string         `json:"date"`
+	Detections  int            `json:"detections"`
+	ByNarrative map[string]int `json:"by_narrative,omitempty"`
+}
+
+// NarrativeTrend compares a FUD type with the window of the same length before the export window
+type NarrativeTrend struct {
+	Narrative     string   `json:"narrative"`
+	Current       int      `json:"current"`
+	Previous      int      `json:"previous"`
+	ChangePercent *float64 `json:"change_percent,omitempty"` // missing when the narrative is new
+}
+
+// AnonymizedAccount is a flagged account under a pseudonym that only holds within one export
+type AnonymizedAccount struct {
+	Account    string   `json:"account"`
+	Detections int      `json:"detections"`
+	Narratives []string `json:"narratives"`
+}
+
+// buildAnonymizedStats aggregates the detections of [since, until). previous holds the detections
+// of the window before it for the trends. Hashed accounts use a random key per export, so the
+// pseudonyms can't be matched against user IDs or linked between exports.
+func buildAnonymizedStats(current []StoredAlert, previous []StoredAlert, since time.Time, until time.Time, newFUDAccounts int64, hashed bool) *AnonymizedStats {
+	stats := &AnonymizedStats{
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
+		Since:           since.UTC().Format(time.RFC3339),
+		Until:           until.UTC().Format(time.RFC3339),
+		Accounts:        ANON_STATS_REMOVED,
+		SuppressedBelow: ANON_STATS_MIN_COUNT,
+		NewFUDAccounts:  newFUDAccounts,
+		BySeverity:      map[string]int{},
+		ByNarrative:     map[string]int{},
+		ByTicker:        map[string]int{},
+	}
+
+	accounts := map[string]*AnonymizedAccount{}
+	days := map[string]*AnonymizedDay{}
+	for _, stored := range current {
+		alert := stored.Alert
+		if !isFUDDetection(alert) {
+			continue
+		}
+		stats.Detections++
+		stats.BySeverity[alert.AlertSeverity]++
+		stats.ByNarrative[alert.FUDType]++
+		if alert.Ticker != "" {
+			stats.ByTicker[alert.Ticker]++
+		}
+
+		date := stored.CreatedAt.UTC().Format(time.DateOnly)
+		if days[date] == nil {
+			days[date] = &AnonymizedDay{Date: date, ByNarrative: map[string]int{}}
+		}
+		days[date].Detections++
+		days[date].ByNarrative[alert.FUDType]++
+
+		account := accounts[alert.FUDUserID]
+		if account == nil {
+			account = &AnonymizedAccount{}
+			accounts[alert.FUDUserID] = account
+		}
+		account.Detections++
+		if !slices.Contains(account.Narratives, alert.FUDType) {
+			account.Narratives = append(account.Narratives, alert.FUDType)
+		}
+	}
+	stats.FlaggedAccounts = len(accounts)
+	for _, account := range accounts {
+		if account.Detections > 1 {
+			stats.RepeatOffenders++
+		}
+	}
+
+	suppressSmallCounts(stats.BySeverity)
+	suppressSmallCounts(stats.ByNarrative)
+	suppressSmallCounts(stats.ByTicker)
+	for _, day := range days {
+		suppressSmallCounts(day.ByNarrative)
+		stats.Daily = append(stats.Daily, *day)
+	}
+	sort.Slice(stats.Daily, func(i, j int) bool { return stats.Daily[i].Date < stats.Daily[j].Date })
+
+	previousByNarrative := map[string]int{}
+	for _, stored := range previous {
+		if isFUDDetection(stored.Alert) {
+			previousByNarrative[stored.Alert.FUDType]++
+		}
+	}
+	stats.NarrativeTrends = narrativeTrends(stats.ByNarrative, previousByNarrative)
+
+	if hashed {
+		stats.Accounts = ANON_STATS_HASHED
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Printf("Failed to generate pseudonym key, leaving accounts out: %v", err)
+			return stats
+		}
+		for userID, account := range accounts {
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(userID))
+			account.Account = hex.EncodeToString(mac.Sum(nil))[:12]
+			sort.Strings(account.Narratives)
+			stats.HashedAccounts = append(stats.HashedAccounts, *account)
+		}
+		sort.Slice(stats.HashedAccounts, func(i, j int) bool {
+			if stats.HashedAccounts[i].Detections != stats.HashedAccounts[j].Detections {
+				return stats.HashedAccounts[i].Detections > stats.HashedAccounts[j].Detections
+			}
+			return stats.HashedAccounts[i].Account < stats.HashedAccounts[j].Account
+		})
+	}
+	return stats
+}
+
+// suppressSmallCounts drops breakdown entries below ANON_STATS_MIN_COUNT
+func suppressSmallCounts(counts map[string]int) {
+	for key, count := range counts {
+		if count < ANON_STATS_MIN_COUNT {
+			delete(counts, key)
+		}
+	}
+}
+
+// narrativeTrends lists the narratives of the current window, largest first, with their change
+func narrativeTrends(current map[string]int, previous map[string]int) []NarrativeTrend {
+	var trends []NarrativeTrend
+	for narrative, count := range current {
+		trend := NarrativeTrend{Narrative: narrative, Current: count, Previous: previous[narrative]}
+		if trend.Previous >= ANON_STATS_MIN_COUNT {
+			change := float64(count-trend.Previous) * 100 / float64(trend.Previous)
+			trend.ChangePercent = &change
+		} else {
+			trend.Previous = 0
+		}
+		trends = append(trends, trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Current != trends[j].Current {
+			return trends[i].Current > trends[j].Current
+		}
+		return trends[i].Narrative < trends[j].Narrative
+	})
+	return trends
+}
+
+// handleAnonStatsCommand exports anonymized aggregate statistics as JSON: /anonstats [days] [hashed]
+func (b *BotController) handleAnonStatsCommand(chatID int64, args []string) {
+	days := ANON_STATS_DEFAULT_DAYS
+	hashed := false
+	for _, arg := range args {
+		if strings.ToLower(arg) == ANON_STATS_HASHED {
+			hashed = true
+			continue
+		}
+		value, err := strconv.Atoi(arg)
+		if err != nil || value < 1 || value > ANON_STATS_MAX_DAYS {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Usage: /anonstats [days 1-%d] [hashed]", ANON_STATS_MAX_DAYS))
+			return
+		}
+		days = value
+	}
+
+	until := time.Now()
+	since := until.AddDate(0, 0, -days)
+	current, err := b.dbService.GetAlertsBetween(since, until)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading alerts: %v", err))
+		return
+	}
+	previous, err := b.dbService.GetAlertsBetween(since.AddDate(0, 0, -days), since)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading alerts: %v", err))
+		return
+	}
+	newFUDAccounts, err := b.dbService.CountFUDUsersDetectedBetween(since, until)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error counting FUD accounts: %v", err))
+		return
+	}
+
+	stats := buildAnonymizedStats(current, previous, since, until, newFUDAccounts, hashed)
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error encoding statistics: %v", err))
+		return
+	}
+
+	filename := fmt.Sprintf("fud_stats_%dd_%s.json", days, time.Now().Format("20060102_150405"))
+	err = b.writeToFile(filename, string(data))
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error creating file: %v", err))
+		return
+	}
+	caption := fmt.Sprintf("📊 <b>Anonymized FUD Statistics</b>\n\n📅 Last %d days\n🚨 Detections: %d\n👥 Flagged accounts: %d\n🔒 Accounts: %s, counts below %d suppressed\n\nAlerts older than the notification TTL are not included.",
+		days, stats.Detections, stats.FlaggedAccounts, stats.Accounts, ANON_STATS_MIN_COUNT)
+	seal := sealEvidence(data)
+	caption += seal.caption()
+	err = b.SendDocument(chatID, filename, caption)
+	os.Remove(filename)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
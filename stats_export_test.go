@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAnonymizedStats(t *testing.T) {
+	until := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -7)
+	stored := func(userID string, fudType string, day int) StoredAlert {
+		return StoredAlert{
+			Alert:     FUDAlertNotification{FUDUserID: userID, FUDUsername: "name_" + userID, FUDType: fudType, AlertSeverity: "high", Ticker: "$GRUT", MessagePreview: "@someone rug"},
+			CreatedAt: since.AddDate(0, 0, day),
+		}
+	}
+	current := []StoredAlert{
+		stored("u1", "coordinated_attack", 0),
+		stored("u1", "coordinated_attack", 1),
+		stored("u2", "coordinated_attack", 1),
+		stored("u3", "coordinated_attack", 2),
+		stored("u4", "scam_accusation", 2),
+		stored("u5", "manual_analysis_clean", 2),
+	}
+	previous := []StoredAlert{
+		stored("u9", "coordinated_attack", -1),
+		stored("u9", "coordinated_attack", -2),
+		stored("u8", "coordinated_attack", -3),
+	}
+
+	stats := buildAnonymizedStats(current, previous, since, until, 2, false)
+	assert.Equal(t, 5, stats.Detections, "clean analyses are not detections")
+	assert.Equal(t, 4, stats.FlaggedAccounts)
+	assert.Equal(t, 1, stats.RepeatOffenders)
+	assert.Equal(t, map[string]int{"coordinated_attack": 4}, stats.ByNarrative, "narratives below the threshold are suppressed")
+	assert.Equal(t, map[string]int{"high": 5}, stats.BySeverity)
+	require.Len(t, stats.Daily, 3)
+	assert.Equal(t, "2026-03-04", stats.Daily[1].Date)
+	assert.Equal(t, 2, stats.Daily[1].Detections)
+	require.Len(t, stats.NarrativeTrends, 1)
+	assert.Equal(t, 3, stats.NarrativeTrends[0].Previous)
+	assert.InDelta(t, 33.3, *stats.NarrativeTrends[0].ChangePercent, 0.1)
+	assert.Empty(t, stats.HashedAccounts)
+
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	for _, secret := range []string{"u1", "name_u1", "@someone", "rug"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	hashed := buildAnonymizedStats(current, previous, since, until, 2, true)
+	require.Len(t, hashed.HashedAccounts, 4)
+	assert.Equal(t, 2, hashed.HashedAccounts[0].Detections)
+	assert.Len(t, hashed.HashedAccounts[0].Account, 12)
+	again := buildAnonymizedStats(current, previous, since, until, 2, true)
+	assert.NotEqual(t, hashed.HashedAccounts[0].Account, again.HashedAccounts[0].Account, "pseudonyms do not link between exports")
+}
+
+func TestBotController_AnonStats(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	for i := 0; i < 3; i++ {
+		alert := benchmarkAlert()
+		alert.FUDUserID = fmt.Sprintf("u%d", i)
+		require.NoError(t, db.SaveNotification(fmt.Sprintf("n%d", i), alert, time.Hour))
+	}
+
+	bot.handleAnonStatsCommand(1, []string{"7", "hashed"})
+	transport.mu.Lock()
+	documents := append([]TelegramSendDocumentRequest(nil), transport.documents...)
+	transport.mu.Unlock()
+	require.Len(t, documents, 1)
+	assert.Contains(t, documents[0].Caption, "Detections: 3")
+	assert.Contains(t, documents[0].Caption, "Accounts: hashed")
+
+	bot.handleAnonStatsCommand(1, []string{"9999"})
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "Usage: /anonstats")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+const (
+	TAIL_DEFAULT_DURATION = 10 * time.Minute
+	TAIL_MAX_DURATION     = time.Hour
+	TAIL_FLUSH_INTERVAL   = 5 * time.Second // lines are batched into one message this often, Telegram limits bot messages per chat
+	TAIL_MAX_PENDING      = 40              // lines held between flushes, older ones are dropped and counted
+	TAIL_PREVIEW_RUNES    = 80
+)
+
+// First step verdicts shown by /tail
+const (
+	TAIL_VERDICT_SKIPPED  = "⚪"
+	TAIL_VERDICT_CLEAN    = "🟢"
+	TAIL_VERDICT_DETAILED = "🔵" // sent to the detailed analysis without a first step verdict
+	TAIL_VERDICT_FUD      = "🟠"
+	TAIL_VERDICT_ALERTED  = "🔴"
+	TAIL_VERDICT_ERROR    = "⚠️"
+)
+
+// tailState streams the first step verdicts of ingested messages to the admin chats that ran /tail on.
+// The first step loop records into it, so all methods are safe for concurrent use.
+type tailState struct {
+	mu       sync.Mutex
+	sessions map[int64]*tailSession
+}
+
+type tailSession struct {
+	until   time.Time
+	lines   []string
+	dropped int
+	shown   int
+	done    chan struct{}
+}
+
+// active reports whether any chat is tailing
+func (t *tailState) active() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sessions) > 0
+}
+
+// record adds the line of a message to every tailing chat
+func (t *tailState) record(newMessage twitterapi.NewMessage, verdictEmoji string, verdict string) {
+	if !t.active() {
+		return
+	}
+	line := formatTailLine(newMessage, verdictEmoji, verdict, time.Now())
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, session := range t.sessions {
+		session.lines = append(session.lines, line)
+		if len(session.lines) > TAIL_MAX_PENDING {
+			session.dropped += len(session.lines) - TAIL_MAX_PENDING
+			session.lines = session.lines[len(session.lines)-TAIL_MAX_PENDING:]
+		}
+	}
+}
+
+// formatTailLine is the compact line of one message: time, author, start of the text and the verdict
+func formatTailLine(newMessage twitterapi.NewMessage, verdictEmoji string, verdict string, now time.Time) string {
+	preview := []rune(strings.Join(strings.Fields(newMessage.Text), " "))
+	if len(preview) > TAIL_PREVIEW_RUNES {
+		preview = append(preview[:TAIL_PREVIEW_RUNES], '…')
+	}
+	return fmt.Sprintf("%s <code>%s</code> @%s: %s → <b>%s</b>", verdictEmoji, now.UTC().Format("15:04:05"),
+		html.EscapeString(newMessage.Author.UserName), html.EscapeString(string(preview)), html.EscapeString(verdict))
+}
+
+// handleTailCommand processes /tail on [duration]|off
+func (b *BotController) handleTailCommand(chatID int64, args []string) {
+	if len(args) == 0 {
+		b.tail.mu.Lock()
+		session := b.tail.sessions[chatID]
+		b.tail.mu.Unlock()
+		if session == nil {
+			b.SendMessage(chatID, "📡 Live tail is off.\n\nUsage: /tail on [10m]|off\nPosts a line with the author, text and first step verdict of every ingested message, for up to 1h.")
+			return
+		}
+		b.SendMessage(chatID, fmt.Sprintf("📡 Live tail running until %s UTC. Use /tail off to stop it.", session.until.UTC().Format("15:04")))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "off":
+		if !b.endTail(chatID) {
+			b.SendMessage(chatID, "📡 Live tail is off.")
+		}
+		return
+	case "on":
+	default:
+		b.SendMessage(chatID, "❌ Usage: /tail on [10m]|off")
+		return
+	}
+
+	duration := TAIL_DEFAULT_DURATION
+	if len(args) > 1 {
+		parsed, err := time.ParseDuration(args[1])
+		if err != nil || parsed <= 0 || parsed > TAIL_MAX_DURATION {
+			b.SendMessage(chatID, "❌ Invalid duration. Use something like 5m or 30m (max 1h)")
+			return
+		}
+		duration = parsed
+	}
+
+	until := time.Now().Add(duration)
+	b.tail.mu.Lock()
+	if b.tail.sessions == nil {
+		b.tail.sessions = make(map[int64]*tailSession)
+	}
+	if session, ok := b.tail.sessions[chatID]; ok {
+		session.until = until
+		b.tail.mu.Unlock()
+		b.SendMessage(chatID, fmt.Sprintf("📡 Live tail extended until %s UTC", until.UTC().Format("15:04")))
+		return
+	}
+	session := &tailSession{until: until, done: make(chan struct{})}
+	b.tail.sessions[chatID] = session
+	b.tail.mu.Unlock()
+
+	log.Printf("📡 Live tail started by chat %d for %s", chatID, duration)
+	b.SendMessage(chatID, fmt.Sprintf("📡 <b>Live tail on</b> until %s UTC\n\n%s skipped · %s clean · %s detailed analysis · %s flagged by the first step · %s alerted · %s failed",
+		until.UTC().Format("15:04"), TAIL_VERDICT_SKIPPED, TAIL_VERDICT_CLEAN, TAIL_VERDICT_DETAILED, TAIL_VERDICT_FUD, TAIL_VERDICT_ALERTED, TAIL_VERDICT_ERROR))
+	go b.runTail(chatID, session.done)
+}
+
+// runTail posts the pending lines of a chat until its tail ends
+func (b *BotController) runTail(chatID int64, done chan struct{}) {
+	ticker := time.NewTicker(TAIL_FLUSH_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			b.flushTail(chatID)
+			b.tail.mu.Lock()
+			session := b.tail.sessions[chatID]
+			expired := session != nil && now.After(session.until)
+			b.tail.mu.Unlock()
+			if expired {
+				b.endTail(chatID)
+				return
+			}
+		}
+	}
+}
+
+// flushTail posts the lines recorded for a chat since the last flush as one message
+func (b *BotController) flushTail(chatID int64) {
+	b.tail.mu.Lock()
+	session := b.tail.sessions[chatID]
+	if session == nil || (len(session.lines) == 0 && session.dropped == 0) {
+		b.tail.mu.Unlock()
+		return
+	}
+	lines, dropped := session.lines, session.dropped
+	session.lines, session.dropped = nil, 0
+	session.shown += len(lines)
+	b.tail.mu.Unlock()
+
+	text := strings.Join(lines, "\n")
+	if dropped > 0 {
+		text = fmt.Sprintf("… %d lines skipped, too many messages\n%s", dropped, text)
+	}
+	if err := b.SendMessage(chatID, text); err != nil {
+		log.Printf("Failed to post live tail to chat %d: %v", chatID, err)
+	}
+}
+
+// endTail stops the tail of a chat after posting its last lines
+func (b *BotController) endTail(chatID int64) bool {
+	b.flushTail(chatID)
+	b.tail.mu.Lock()
+	session := b.tail.sessions[chatID]
+	if session == nil {
+		b.tail.mu.Unlock()
+		return false
+	}
+	delete(b.tail.sessions, chatID)
+	close(session.done)
+	shown := session.shown
+	b.tail.mu.Unlock()
+
+	log.Printf("📡 Live tail of chat %d finished", chatID)
+	b.SendMessage(chatID, fmt.Sprintf("📡 <b>Live tail off</b>, %d messages shown.", shown))
+	return true
+}
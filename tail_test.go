@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailCommand(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "regular", IsDetailAnalyzed: true}))
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	lastMessage := func() string {
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	bot.handleTailCommand(1, []string{"on", "2h"})
+	assert.Contains(t, lastMessage(), "Invalid duration")
+	bot.handleTailCommand(1, []string{"on", "5m"})
+	assert.Contains(t, lastMessage(), "Live tail on")
+	assert.True(t, bot.tail.active())
+
+	message := func(userID, username, text string) twitterapi.NewMessage {
+		newMessage := twitterapi.NewMessage{TweetID: "t-" + userID, Text: text}
+		newMessage.Author.ID, newMessage.Author.UserName = userID, username
+		return newMessage
+	}
+	newMessageCh := make(chan twitterapi.NewMessage, 2)
+	newMessageCh <- message("u1", "regular", "gm <everyone>\nhave a nice day "+strings.Repeat("é", 100))
+	newMessageCh <- message("u2", "newcomer", "first post")
+	close(newMessageCh)
+	claudeApi := newMockClaudeAPI(`"is_fud":false,"fud_probability":12}`, nil)
+	FirstStepHandler(newMessageCh, make(chan twitterapi.NewMessage, 2), claudeApi, nil, &mockUserStatusTracker{}, db, make(chan FUDAlertNotification, 2), &warRoomState{}, &bot.tail)
+
+	bot.flushTail(1)
+	lines := strings.Split(lastMessage(), "\n")
+	require.Len(t, lines, 2, "one message per flush, one line per ingested message")
+	assert.Contains(t, lines[0], TAIL_VERDICT_CLEAN+" <code>")
+	assert.Contains(t, lines[0], "@regular: gm &lt;everyone&gt; have a nice day ")
+	assert.Contains(t, lines[0], "é… → <b>clean 12%</b>", "the preview is cut at 80 characters")
+	assert.Contains(t, lines[1], TAIL_VERDICT_DETAILED)
+	assert.Contains(t, lines[1], "@newcomer: first post → <b>new user</b>")
+
+	bot.handleTailCommand(1, []string{"off"})
+	assert.Contains(t, lastMessage(), "Live tail off</b>, 2 messages shown")
+	assert.False(t, bot.tail.active())
+	bot.handleTailCommand(1, []string{"off"})
+	assert.Equal(t, "📡 Live tail is off.", lastMessage())
+}
+
+func TestTailState_DropsOldLines(t *testing.T) {
+	tail := &tailState{sessions: map[int64]*tailSession{1: {}}}
+	for i := 0; i < TAIL_MAX_PENDING+5; i++ {
+		tail.record(twitterapi.NewMessage{Text: "spam"}, TAIL_VERDICT_CLEAN, "clean")
+	}
+	assert.Len(t, tail.sessions[1].lines, TAIL_MAX_PENDING)
+	assert.Equal(t, 5, tail.sessions[1].dropped)
+
+	var idle *tailState
+	idle.record(twitterapi.NewMessage{}, TAIL_VERDICT_CLEAN, "clean")
+}
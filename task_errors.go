@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error codes of failed analysis tasks, shown in /tasks and the progress message
+const (
+	TASK_ERROR_TWITTER_RATE_LIMIT = "TWITTER_RATE_LIMIT"
+	TASK_ERROR_CLAUDE_TIMEOUT     = "CLAUDE_TIMEOUT"
+	TASK_ERROR_CLAUDE_UNAVAILABLE = "CLAUDE_UNAVAILABLE"
+	TASK_ERROR_USER_NOT_FOUND     = "USER_NOT_FOUND"
+	TASK_ERROR_TWEET_NOT_FOUND    = "TWEET_NOT_FOUND"
+	TASK_ERROR_CONTEXT_TOO_LARGE  = "CONTEXT_TOO_LARGE"
+	TASK_ERROR_DB_ERROR           = "DB_ERROR"
+	TASK_ERROR_QUEUE_FULL         = "QUEUE_FULL"
+	TASK_ERROR_STALLED            = "STALLED"
+	TASK_ERROR_WHITELISTED        = "WHITELISTED"
+	TASK_ERROR_INTERNAL           = "INTERNAL"
+)
+
+const (
+	TASK_FAILURES_WINDOW = 24 * time.Hour
+	TASK_FAILURES_LIMIT  = 5
+)
+
+// taskErrorGuidance tells users what to do about a failure: retry, wait, check the input or report a bug
+type taskErrorGuidance struct {
+	Action string
+	Hint   string
+}
+
+var taskErrorGuidances = map[string]taskErrorGuidance{
+	TASK_ERROR_TWITTER_RATE_LIMIT: {"⏳ Wait", "The Twitter API rate limit was hit, run the analysis again in a few minutes."},
+	TASK_ERROR_CLAUDE_TIMEOUT:     {"🔄 Retry", "The neural network did not answer in time, run the analysis again."},
+	TASK_ERROR_CLAUDE_UNAVAILABLE: {"⏳ Wait", "The neural network is overloaded or down, run the analysis again later."},
+	TASK_ERROR_USER_NOT_FOUND:     {"🔎 Check input", "The user does not exist or is suspended, check the username."},
+	TASK_ERROR_TWEET_NOT_FOUND:    {"🔎 Check input", "The tweet was deleted or is not visible, check the link."},
+	TASK_ERROR_CONTEXT_TOO_LARGE:  {"✂️ Shorten", "The user history is too large to analyze, run the analysis again with a shorter window, e.g. history:30d."},
+	TASK_ERROR_DB_ERROR:           {"🐞 Report", "The database failed, report it to the bot maintainers if it happens again."},
+	TASK_ERROR_QUEUE_FULL:         {"⏳ Wait", "Too many analyses are queued, run the analysis again in a minute."},
+	TASK_ERROR_STALLED:            {"🔄 Retry", "The task stopped making progress, run the analysis again."},
+	TASK_ERROR_WHITELISTED:        {"🤝 Whitelisted", "Whitelisted users are never analyzed."},
+	TASK_ERROR_INTERNAL:           {"🐞 Report", "Unexpected error, report it to the bot maintainers with the task ID."},
+}
+
+// taskError attaches an error code to the error a task failed with
+type taskError struct {
+	Code string
+	Err  error
+}
+
+func (e *taskError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *taskError) Unwrap() error {
+	return e.Err
+}
+
+func newTaskError(code string, err error) error {
+	return &taskError{Code: code, Err: err}
+}
+
+// classifyTaskError picks the error code of a task failure. Codes attached with newTaskError win,
+// Claude errors are told apart by status and the Twitter API, which only returns text, by message.
+func classifyTaskError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var tagged *taskError
+	if errors.As(err, &tagged) {
+		return tagged.Code
+	}
+	var statusErr *ClaudeStatusError
+	if errors.As(err, &statusErr) {
+		message := strings.ToLower(statusErr.Message)
+		switch {
+		case statusErr.StatusCode == http.StatusRequestEntityTooLarge,
+			statusErr.StatusCode == http.StatusBadRequest && (strings.Contains(message, "too long") || strings.Contains(message, "context") || strings.Contains(message, "maximum")):
+			return TASK_ERROR_CONTEXT_TOO_LARGE
+		case statusErr.StatusCode == http.StatusRequestTimeout || statusErr.StatusCode == http.StatusGatewayTimeout:
+			return TASK_ERROR_CLAUDE_TIMEOUT
+		case statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500:
+			return TASK_ERROR_CLAUDE_UNAVAILABLE
+		}
+		return TASK_ERROR_INTERNAL
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return TASK_ERROR_CLAUDE_TIMEOUT
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests"):
+		return TASK_ERROR_TWITTER_RATE_LIMIT
+	case strings.Contains(message, "user not found") || strings.Contains(message, "suspended"):
+		return TASK_ERROR_USER_NOT_FOUND
+	case strings.Contains(message, "database") || strings.Contains(message, "sqlite"):
+		return TASK_ERROR_DB_ERROR
+	}
+	return TASK_ERROR_INTERNAL
+}
+
+// formatTaskError shows the error of a failed task with its code and what to do about it
+func formatTaskError(task *AnalysisTaskModel) string {
+	guidance, ok := taskErrorGuidances[task.ErrorCode]
+	if !ok {
+		return fmt.Sprintf("⚠️ <b>Error:</b> %s", task.ErrorMessage)
+	}
+	return fmt.Sprintf("⚠️ <b>Error:</b> <code>%s</code> %s\n%s: %s", task.ErrorCode, task.ErrorMessage, guidance.Action, guidance.Hint)
+}
+
+// formatRecentTaskFailures lists the tasks that failed lately with their codes, for /tasks
+func formatRecentTaskFailures(tasks []AnalysisTaskModel) string {
+	if len(tasks) == 0 {
+		return ""
+	}
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("\n❌ <b>Failed in the last %.0fh:</b>\n", TASK_FAILURES_WINDOW.Hours()))
+	for _, task := range tasks {
+		code := task.ErrorCode
+		if code == "" {
+			code = "UNKNOWN"
+		}
+		action := ""
+		if guidance, ok := taskErrorGuidances[task.ErrorCode]; ok {
+			action = " - " + guidance.Action
+		}
+		message.WriteString(fmt.Sprintf("• @%s <code>%s</code>%s\n", html.EscapeString(task.Username), code, action))
+	}
+	return message.String()
+}
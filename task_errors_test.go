@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyTaskError(t *testing.T) {
+	cases := []struct {
+		err  error
+		code string
+	}{
+		{newTaskError(TASK_ERROR_WHITELISTED, errors.New("@friend is whitelisted")), TASK_ERROR_WHITELISTED},
+		{fmt.Errorf("second step: %w", &ClaudeStatusError{StatusCode: http.StatusBadRequest, Message: "prompt is too long: 210000 tokens > 200000 maximum"}), TASK_ERROR_CONTEXT_TOO_LARGE},
+		{&ClaudeStatusError{StatusCode: http.StatusRequestEntityTooLarge}, TASK_ERROR_CONTEXT_TOO_LARGE},
+		{&ClaudeStatusError{StatusCode: http.StatusGatewayTimeout}, TASK_ERROR_CLAUDE_TIMEOUT},
+		{&ClaudeStatusError{StatusCode: 529, Message: "overloaded"}, TASK_ERROR_CLAUDE_UNAVAILABLE},
+		{&ClaudeStatusError{StatusCode: http.StatusBadRequest, Message: "invalid request"}, TASK_ERROR_INTERNAL},
+		{fmt.Errorf("claude request: %w", context.DeadlineExceeded), TASK_ERROR_CLAUDE_TIMEOUT},
+		{errors.New(`error tweets by ids, status non 200: {"error":"Too Many Requests"}`), TASK_ERROR_TWITTER_RATE_LIMIT},
+		{errors.New("user not found"), TASK_ERROR_USER_NOT_FOUND},
+		{errors.New("sqlite: database is locked"), TASK_ERROR_DB_ERROR},
+		{errors.New("something else"), TASK_ERROR_INTERNAL},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.code, classifyTaskError(c.err), c.err.Error())
+	}
+	assert.Empty(t, classifyTaskError(nil))
+}
+
+func TestBotController_TaskErrorCodes(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	for _, username := range []string{"huge", "legacy"} {
+		_, _, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: username, Username: username, TelegramChatID: 5, Status: ANALYSIS_STATUS_PENDING})
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.SetAnalysisTaskError("huge", TASK_ERROR_CONTEXT_TOO_LARGE, "prompt is too long"))
+	require.NoError(t, db.SetAnalysisTaskError("legacy", "", "boom"))
+
+	task, err := db.GetAnalysisTask("huge")
+	require.NoError(t, err)
+	assert.Equal(t, TASK_ERROR_CONTEXT_TOO_LARGE, task.ErrorCode)
+	progress := bot.formatAnalysisProgress(task)
+	assert.Contains(t, progress, "<code>CONTEXT_TOO_LARGE</code> prompt is too long")
+	assert.Contains(t, progress, "history:30d")
+
+	// Tasks failed before error codes keep the generic advice
+	legacy, err := db.GetAnalysisTask("legacy")
+	require.NoError(t, err)
+	assert.Contains(t, bot.formatAnalysisProgress(legacy), "You can try running the analysis again")
+
+	bot.handleTasksCommand(5)
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "No Running Analysis Tasks")
+	assert.Contains(t, sent[0].Text, "@huge <code>CONTEXT_TOO_LARGE</code> - ✂️ Shorten")
+	assert.Contains(t, sent[0].Text, "@legacy <code>UNKNOWN</code>")
+}
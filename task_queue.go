@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ANALYSIS_TASK_TIMEOUT      = 60 * time.Minute // pending or running tasks without progress for this long are failed
+	ANALYSIS_TASK_MAX_ATTEMPTS = 3                // tasks are not resumed again after crashing this many times
+	ANALYSIS_TASK_SWEEP_EVERY  = 5 * time.Minute
+	ANALYSIS_TASK_RESUME_DELAY = 200 * time.Millisecond
+)
+
+// analysisTaskTimeout reads ENV_ANALYSIS_TASK_TIMEOUT_MINUTES, falling back to ANALYSIS_TASK_TIMEOUT
+func analysisTaskTimeout() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv(ENV_ANALYSIS_TASK_TIMEOUT_MINUTES))
+	if err != nil || minutes <= 0 {
+		return ANALYSIS_TASK_TIMEOUT
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// analysisTaskIdempotencyKey identifies a request: the same analysis for the same destination
+func analysisTaskIdempotencyKey(task *AnalysisTaskModel) string {
+	return strings.Join([]string{
+		task.Kind,
+		strings.ToLower(task.Username),
+		task.TweetID,
+		strconv.FormatInt(task.TelegramChatID, 10),
+		task.DiscordChannel,
+		task.NotifyRoute,
+		strconv.FormatInt(task.NotifyChatID, 10),
+		task.HistoryWindow,
+	}, "|")
+}
+
+// createAnalysisTask stores the task unless an identical one is already pending or running.
+// It returns the ID of the task that will do the work and whether the task was created now.
+func (b *BotController) createAnalysisTask(task *AnalysisTaskModel) (string, bool, error) {
+	if task.Kind == "" {
+		task.Kind = ANALYSIS_KIND_SINGLE
+	}
+	// Recorded so the analysis can be reproduced after the default changes
+	if task.HistoryWindow == "" {
+		task.HistoryWindow = defaultHistoryWindow().String()
+	}
+	task.IdempotencyKey = analysisTaskIdempotencyKey(task)
+	stored, created, err := b.dbService.CreateAnalysisTaskOnce(task)
+	if err != nil {
+		return "", false, err
+	}
+	if !created {
+		taskLogger("task_queue", stored).Info("analysis is already queued")
+	}
+	return stored.ID, created, nil
+}
+
+// runAnalysisTask counts an attempt and hands the task to the processor for its kind
+func (b *BotController) runAnalysisTask(taskID string, kind string) {
+	if err := b.dbService.StartAnalysisTaskAttempt(taskID); err != nil {
+		logFor("task_queue").Error("failed to count attempt of analysis task", "task_id", taskID, "error", err)
+	}
+	switch kind {
+	case ANALYSIS_KIND_BATCH:
+		b.processBatchAnalysisTask(taskID)
+	case ANALYSIS_KIND_TWEET:
+		b.processTweetAnalysisTask(taskID)
+	default:
+		b.processAnalysisTask(taskID)
+	}
+}
+
+// ResumeAnalysisTasks re-enqueues the tasks left pending or running by the previous run. Tasks
+// without progress for longer than timeout, or that already crashed too often, are failed instead.
+func (b *BotController) ResumeAnalysisTasks(timeout time.Duration) (resumed int, failed int) {
+	tasks, err := b.dbService.GetAllRunningAnalysisTasks()
+	if err != nil {
+		logFor("task_queue").Error("failed to load unfinished analysis tasks", "error", err)
+		return 0, 0
+	}
+
+	var toResume []AnalysisTaskModel
+	for _, task := range tasks {
+		switch {
+		case time.Since(task.UpdatedAt) > timeout:
+			b.dbService.SetAnalysisTaskError(task.ID, TASK_ERROR_STALLED, fmt.Sprintf("Orphaned: no progress for %s before the bot restarted", timeout))
+			failed++
+		case task.Attempts >= ANALYSIS_TASK_MAX_ATTEMPTS:
+			b.dbService.SetAnalysisTaskError(task.ID, TASK_ERROR_STALLED, fmt.Sprintf("Gave up after %d attempts", task.Attempts))
+			failed++
+		default:
+			b.dbService.UpdateAnalysisTaskProgress(task.ID, ANALYSIS_STEP_INIT, "Resumed after restart...")
+			toResume = append(toResume, task)
+		}
+	}
+	if len(tasks) > 0 {
+		logFor("task_queue").Info("🔁 resuming analysis tasks", "resumed", len(toResume), "orphaned", failed)
+	}
+
+	// Oldest first, spaced out so the analysis channel is not flooded at startup
+	go func() {
+		for i := len(toResume) - 1; i >= 0; i-- {
+			task := toResume[i]
+			if task.MessageID != 0 && task.TelegramChatID != 0 {
+				go b.monitorAnalysisProgress(task.ID)
+			}
+			go b.runAnalysisTask(task.ID, task.Kind)
+			time.Sleep(ANALYSIS_TASK_RESUME_DELAY)
+		}
+	}()
+	return len(toResume), failed
+}
+
+// failOrphanedAnalysisTasks fails pending or running tasks that made no progress within timeout,
+// e.g. because their message was dropped somewhere in the pipeline
+func (b *BotController) failOrphanedAnalysisTasks(timeout time.Duration) int {
+	tasks, err := b.dbService.GetAllRunningAnalysisTasks()
+	if err != nil {
+		logFor("task_queue").Error("failed to load unfinished analysis tasks", "error", err)
+		return 0
+	}
+	failed := 0
+	for _, task := range tasks {
+		if time.Since(task.UpdatedAt) > timeout {
+			b.dbService.SetAnalysisTaskError(task.ID, TASK_ERROR_STALLED, fmt.Sprintf("Timed out: no progress for %s", timeout))
+			failed++
+		}
+	}
+	if failed > 0 {
+		logFor("task_queue").Warn("⌛ failed orphaned analysis tasks", "count", failed)
+	}
+	return failed
+}
+
+// StartOrphanedTaskSweeper periodically fails tasks that stopped making progress
+func (b *BotController) StartOrphanedTaskSweeper(timeout time.Duration, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			b.failOrphanedAnalysisTasks(timeout)
+		}
+	}()
+}
+
+// analysisTaskContexts holds the cancel functions of the tasks this process is working on
+type analysisTaskContexts struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// analysisTaskContext returns the context a worker processes the task under, cancelled by /cancel_<taskID>,
+// and the function the worker calls when it is done with the task
+func (b *BotController) analysisTaskContext(taskID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.taskContexts.mu.Lock()
+	if b.taskContexts.cancels == nil {
+		b.taskContexts.cancels = make(map[string]context.CancelFunc)
+	}
+	b.taskContexts.cancels[taskID] = cancel
+	b.taskContexts.mu.Unlock()
+
+	return ctx, func() {
+		b.taskContexts.mu.Lock()
+		delete(b.taskContexts.cancels, taskID)
+		b.taskContexts.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancelAnalysisTask marks the task as cancelled and stops its worker. Stages further down the
+// pipeline, such as the second step, check the task status before doing expensive work.
+func (b *BotController) cancelAnalysisTask(taskID string, reason string) (bool, error) {
+	cancelled, err := b.dbService.CancelAnalysisTask(taskID, reason)
+	if err != nil || !cancelled {
+		return false, err
+	}
+	b.taskContexts.mu.Lock()
+	cancel, ok := b.taskContexts.cancels[taskID]
+	b.taskContexts.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return true, nil
+}
+
+// handleCancelCommand processes /cancel_<taskID>. Tasks can be cancelled from the chat that
+// started them or from an admin chat.
+func (b *BotController) handleCancelCommand(chatID int64, actor string, command string) {
+	taskID := strings.TrimPrefix(command, "/cancel_")
+	if taskID == "" {
+		b.SendMessage(chatID, "❌ Usage: /cancel_&lt;task_id&gt;, see /tasks")
+		return
+	}
+
+	task, err := b.dbService.GetAnalysisTask(taskID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Task <code>%s</code> not found", html.EscapeString(taskID)))
+		return
+	}
+	if task.TelegramChatID != chatID && !b.isAdminChat(chatID) {
+		b.SendMessage(chatID, "❌ Only the chat that started this analysis or an administrator can cancel it.")
+		return
+	}
+
+	cancelled, err := b.cancelAnalysisTask(taskID, "Cancelled by "+actor)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error cancelling task: %v", err))
+		return
+	}
+	if !cancelled {
+		b.SendMessage(chatID, fmt.Sprintf("ℹ️ Task <code>%s</code> for @%s is already %s", taskID, task.Username, task.Status))
+		return
+	}
+	taskLogger("task_queue", task).Info("🛑 analysis task cancelled", "by", actor)
+
+	if task.MessageID != 0 && task.TelegramChatID != 0 {
+		if updated, err := b.dbService.GetAnalysisTask(taskID); err == nil {
+			b.EditMessage(task.TelegramChatID, task.MessageID, b.formatAnalysisProgress(updated))
+		}
+	}
+	b.SendMessage(chatID, fmt.Sprintf("🛑 Analysis of @%s cancelled (task <code>%s</code>)", task.Username, taskID))
+}
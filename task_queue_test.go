@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grutapig/hackaton/httpretry"
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_CreateAnalysisTaskIsIdempotent(t *testing.T) {
+	db := setupTestDB(t)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+
+	firstID, created, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: "a", Username: "Shady", TelegramChatID: 1, Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	secondID, created, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: "b", Username: "shady", TelegramChatID: 1, Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, firstID, secondID)
+
+	// A different destination is a different request
+	_, created, err = bot.createAnalysisTask(&AnalysisTaskModel{ID: "c", Username: "shady", TelegramChatID: 2, Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// Once the first task finished the same request runs again
+	require.NoError(t, db.CompleteAnalysisTask(firstID, "{}"))
+	_, created, err = bot.createAnalysisTask(&AnalysisTaskModel{ID: "d", Username: "shady", TelegramChatID: 1, Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	assert.True(t, created)
+}
+
+func TestBotController_ResumeAnalysisTasks(t *testing.T) {
+	db := setupTestDB(t)
+	analysisChannel := make(chan twitterapi.NewMessage, 5)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	bot.analysisChannel = analysisChannel
+
+	for _, task := range []*AnalysisTaskModel{
+		{ID: "fresh", Username: "fresh_user", Status: ANALYSIS_STATUS_RUNNING, CurrentStep: ANALYSIS_STEP_CLAUDE_ANALYSIS},
+		{ID: "stale", Username: "stale_user", Status: ANALYSIS_STATUS_RUNNING},
+		{ID: "crashy", Username: "crashy_user", Status: ANALYSIS_STATUS_PENDING, Attempts: ANALYSIS_TASK_MAX_ATTEMPTS},
+		{ID: "done", Username: "done_user", Status: ANALYSIS_STATUS_COMPLETED},
+	} {
+		_, _, err := bot.createAnalysisTask(task)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.db.Model(&AnalysisTaskModel{}).Where("id = ?", "stale").UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error)
+
+	resumed, failed := bot.ResumeAnalysisTasks(time.Hour)
+	assert.Equal(t, 1, resumed)
+	assert.Equal(t, 2, failed)
+
+	select {
+	case message := <-analysisChannel:
+		assert.Equal(t, "fresh", message.TaskID)
+		assert.Equal(t, "fresh_user", message.Author.UserName)
+	case <-time.After(2 * time.Second):
+		t.Fatal("resumed task was not sent to the analysis channel")
+	}
+	require.Eventually(t, func() bool {
+		task, err := db.GetAnalysisTask("fresh")
+		return err == nil && task.ProgressText == "Processing with neural network..."
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stale, err := db.GetAnalysisTask("stale")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_FAILED, stale.Status)
+	assert.Contains(t, stale.ErrorMessage, "Orphaned")
+	crashy, err := db.GetAnalysisTask("crashy")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_FAILED, crashy.Status)
+	fresh, err := db.GetAnalysisTask("fresh")
+	require.NoError(t, err)
+	assert.Equal(t, 1, fresh.Attempts)
+
+	t.Run("Sweeper fails tasks that stopped making progress", func(t *testing.T) {
+		require.NoError(t, db.db.Model(&AnalysisTaskModel{}).Where("id = ?", "fresh").UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error)
+		assert.Equal(t, 1, bot.failOrphanedAnalysisTasks(time.Hour))
+		fresh, err := db.GetAnalysisTask("fresh")
+		require.NoError(t, err)
+		assert.Equal(t, ANALYSIS_STATUS_FAILED, fresh.Status)
+	})
+}
+
+func TestBotController_CancelCommand(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	lastMessage := func() string {
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	_, _, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: "t1", Username: "shady", TelegramChatID: 5, MessageID: 77, Status: ANALYSIS_STATUS_RUNNING})
+	require.NoError(t, err)
+	ctx, done := bot.analysisTaskContext("t1")
+	defer done()
+
+	bot.handleCancelCommand(6, "@stranger", "/cancel_t1")
+	assert.Contains(t, lastMessage(), "Only the chat that started this analysis")
+	assert.NoError(t, ctx.Err())
+
+	bot.handleCancelCommand(5, "@requester", "/cancel_t1")
+	assert.Contains(t, lastMessage(), "Analysis of @shady cancelled")
+	assert.Error(t, ctx.Err(), "the worker context is cancelled")
+	require.Len(t, transport.edited, 1)
+	assert.Equal(t, int64(77), transport.edited[0].MessageID)
+	assert.Contains(t, transport.edited[0].Text, "Cancelled by @requester")
+
+	// Late progress from the pipeline does not revive the task
+	require.NoError(t, db.UpdateAnalysisTaskProgress("t1", ANALYSIS_STEP_CLAUDE_ANALYSIS, "Processing..."))
+	require.NoError(t, db.CompleteAnalysisTask("t1", "{}"))
+	task, err := db.GetAnalysisTask("t1")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_CANCELLED, task.Status)
+
+	bot.handleCancelCommand(1, "@admin", "/cancel_t1")
+	assert.Contains(t, lastMessage(), "already cancelled")
+
+	t.Run("Second step skips cancelled tasks", func(t *testing.T) {
+		claudeApi := newMockClaudeAPI(`"is_fud_user":true}`, nil)
+		message := twitterapi.NewMessage{TaskID: "t1", IsManualAnalysis: true}
+		message.Author.UserName = "shady"
+		notificationCh := make(chan FUDAlertNotification, 1)
+
+		SecondStepHandler(message, notificationCh, &mockTwitterAPI{}, claudeApi, nil, &mockUserStatusTracker{}, "GRUT", db)
+
+		assert.Empty(t, claudeApi.recordedCalls())
+		assert.Empty(t, notificationCh)
+	})
+}
+
+func TestSecondStepHandler_RecordsRetriesOnTask(t *testing.T) {
+	db := setupTestDB(t)
+	bot := newTestBotController(&fakeTelegramTransport{}, db)
+	_, _, err := bot.createAnalysisTask(&AnalysisTaskModel{ID: "flaky", Username: "overloaded", Status: ANALYSIS_STATUS_RUNNING})
+	require.NoError(t, err)
+
+	overloaded := &httpretry.RetryError{Attempts: 4, Err: &ClaudeStatusError{StatusCode: 529, Message: "claude SendMessage status not 200(529)"}}
+	message := twitterapi.NewMessage{TaskID: "flaky", IsManualAnalysis: true, TweetID: "t-flaky"}
+	message.Author.ID, message.Author.UserName = "u-flaky", "overloaded"
+	notificationCh := make(chan FUDAlertNotification, 1)
+	SecondStepHandler(message, notificationCh, &mockTwitterAPI{}, newMockClaudeAPI("", overloaded), nil, &mockUserStatusTracker{}, "GRUT", db)
+
+	task, err := db.GetAnalysisTask("flaky")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_FAILED, task.Status)
+	assert.Equal(t, 3, task.Retries)
+	assert.Contains(t, task.ErrorMessage, "gave up after 4 attempts")
+	assert.Contains(t, bot.formatAnalysisProgress(task), "Retries:</b> 3 transient API failures")
+	assert.True(t, isLLMUnavailable(overloaded), "an outage outlasting the retries still falls back to heuristics")
+}
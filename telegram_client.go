@@ -0,0 +1,706 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grutapig/hackaton/httpretry"
+)
+
+const TELEGRAM_API_BASE_URL = "https://api.telegram.org"
+
+const (
+	TELEGRAM_DOWNLOAD_LIMIT   = 20 << 20 // getFile serves files up to 20MB
+	TELEGRAM_DOWNLOAD_TIMEOUT = 2 * time.Minute
+)
+
+// TelegramTransport is the minimal set of Bot API calls the bot controller needs.
+// Keeping it small lets command handlers be tested against a fake transport.
+type TelegramTransport interface {
+	GetUpdates(offset int64) ([]TelegramUpdate, error)
+	SendMessage(req TelegramSendMessageRequest) (int64, error)
+	EditMessage(req TelegramEditMessageRequest) error
+	SendDocument(req TelegramSendDocumentRequest, filePath string) error
+	SendPhoto(req TelegramSendPhotoRequest, filename string, photo []byte) error
+	SendMediaGroup(req TelegramSendMediaGroupRequest, uploads map[string][]byte) error
+	SendPoll(req TelegramSendPollRequest) (TelegramSentPoll, error)
+	StopPoll(chatID int64, messageID int64) error
+	GetChatMember(chatID int64, userID int64) (TelegramChatMember, error)
+	DownloadFile(fileID string, destPath string) error
+}
+
+type TelegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  struct {
+		MessageID int64 `json:"message_id"`
+		From      struct {
+			ID        int64  `json:"id"`
+			IsBot     bool   `json:"is_bot"`
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name,omitempty"`
+			Username  string `json:"username,omitempty"`
+		} `json:"from"`
+		Chat struct {
+			ID    int64  `json:"id"`
+			Type  string `json:"type"`
+			Title string `json:"title,omitempty"`
+		} `json:"chat"`
+		Date     int64             `json:"date"`
+		Text     string            `json:"text"`
+		Caption  string            `json:"caption,omitempty"`  // text sent with a document
+		Document *TelegramDocument `json:"document,omitempty"` // uploaded file, see /import
+		// Forum topic of the message in supergroups with topics, 0 for the General topic
+		MessageThreadID   int64               `json:"message_thread_id,omitempty"`
+		IsTopicMessage    bool                `json:"is_topic_message,omitempty"`
+		ForumTopicCreated *TelegramForumTopic `json:"forum_topic_created,omitempty"` // service message of a new topic, see /topic
+		// The message this one replies to, replies to alerts can name just the command, see alert_replies.go
+		ReplyToMessage *TelegramReplyMessage `json:"reply_to_message,omitempty"`
+	} `json:"message"`
+	// Votes in non-anonymous polls the bot sent, the update has no message
+	PollAnswer *TelegramPollAnswer `json:"poll_answer,omitempty"`
+}
+
+type TelegramDocument struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
+// TelegramReplyMessage is the replied-to message. Telegram nests only one level, so it has no reply of its own.
+type TelegramReplyMessage struct {
+	MessageID int64 `json:"message_id"`
+	From      struct {
+		ID       int64  `json:"id"`
+		IsBot    bool   `json:"is_bot"`
+		Username string `json:"username,omitempty"`
+	} `json:"from"`
+	Text string `json:"text,omitempty"`
+}
+
+type TelegramForumTopic struct {
+	Name string `json:"name"`
+}
+
+type TelegramPollAnswer struct {
+	PollID string `json:"poll_id"`
+	User   struct {
+		ID        int64  `json:"id"`
+		FirstName string `json:"first_name"`
+		Username  string `json:"username,omitempty"`
+	} `json:"user"`
+	OptionIDs []int `json:"option_ids"` // empty when the vote was retracted
+}
+
+type TelegramResponse struct {
+	OK     bool             `json:"ok"`
+	Result []TelegramUpdate `json:"result"`
+	Error  *TelegramError   `json:"error,omitempty"`
+}
+
+type TelegramError struct {
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+// TelegramAPIError is returned for non-200 Bot API responses
+type TelegramAPIError struct {
+	Action     string
+	StatusCode int
+	Body       string
+	RetryAfter int // Seconds to wait, set on 429 responses
+}
+
+func (e *TelegramAPIError) Error() string {
+	return fmt.Sprintf("telegram %s failed: %s", e.Action, e.Body)
+}
+
+func newTelegramAPIError(action string, statusCode int, body []byte) *TelegramAPIError {
+	var parsed struct {
+		Parameters struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	json.Unmarshal(body, &parsed)
+
+	return &TelegramAPIError{
+		Action:     action,
+		StatusCode: statusCode,
+		Body:       string(body),
+		RetryAfter: parsed.Parameters.RetryAfter,
+	}
+}
+
+type TelegramSendMessageRequest struct {
+	ChatID              int64  `json:"chat_id"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode,omitempty"`
+	DisablePreview      bool   `json:"disable_web_page_preview,omitempty"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+	ReplyToMessageID    int64  `json:"reply_to_message_id,omitempty"`
+	AllowWithoutReply   bool   `json:"allow_sending_without_reply,omitempty"` // still send when the replied message was deleted
+	MessageThreadID     int64  `json:"message_thread_id,omitempty"`           // forum topic, 0 for the General topic
+}
+
+type TelegramSendDocumentRequest struct {
+	ChatID          int64  `json:"chat_id"`
+	MessageThreadID int64  `json:"message_thread_id,omitempty"`
+	Caption         string `json:"caption,omitempty"`
+	ParseMode       string `json:"parse_mode,omitempty"`
+}
+
+type TelegramSendPhotoRequest struct {
+	ChatID          int64  `json:"chat_id"`
+	MessageThreadID int64  `json:"message_thread_id,omitempty"`
+	Photo           string `json:"photo,omitempty"` // URL or file ID Telegram fetches itself, used when nothing is uploaded
+	Caption         string `json:"caption,omitempty"`
+	ParseMode       string `json:"parse_mode,omitempty"`
+}
+
+// TelegramInputMediaPhoto is one photo of an album
+type TelegramInputMediaPhoto struct {
+	Type      string `json:"type"`  // always "photo"
+	Media     string `json:"media"` // URL, file ID or attach://<name> of an upload
+	Caption   string `json:"caption,omitempty"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+type TelegramSendMediaGroupRequest struct {
+	ChatID          int64                     `json:"chat_id"`
+	MessageThreadID int64                     `json:"message_thread_id,omitempty"`
+	Media           []TelegramInputMediaPhoto `json:"media"`
+}
+
+type TelegramSendPollRequest struct {
+	ChatID          int64                `json:"chat_id"`
+	MessageThreadID int64                `json:"message_thread_id,omitempty"`
+	Question        string               `json:"question"`
+	Options         []TelegramPollOption `json:"options"`
+	IsAnonymous     bool                 `json:"is_anonymous"`
+}
+
+type TelegramPollOption struct {
+	Text string `json:"text"`
+}
+
+// TelegramSentPoll identifies a sent poll, answers refer to the poll ID and stopPoll to the message
+type TelegramSentPoll struct {
+	MessageID int64
+	PollID    string
+}
+
+// TelegramChatMember is a user's membership of a chat, Status is creator, administrator, member,
+// restricted, left or kicked
+type TelegramChatMember struct {
+	Status string `json:"status"`
+	User   struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+type TelegramEditMessageRequest struct {
+	ChatID         int64  `json:"chat_id"`
+	MessageID      int64  `json:"message_id"`
+	Text           string `json:"text"`
+	ParseMode      string `json:"parse_mode,omitempty"`
+	DisablePreview bool   `json:"disable_web_page_preview,omitempty"`
+}
+
+type TelegramSendMessageResponse struct {
+	OK     bool `json:"ok"`
+	Result struct {
+		MessageID int64 `json:"message_id"`
+		Chat      struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"result"`
+}
+
+// TelegramClient is the HTTP implementation of TelegramTransport
+type TelegramClient struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+	retry   httpretry.RetryPolicy
+	// sendRetry is used for methods that post something, retrying them after a timeout could post twice
+	sendRetry httpretry.RetryPolicy
+}
+
+func NewTelegramClient(apiKey string, proxyDSN string) (*TelegramClient, error) {
+	transport := &http.Transport{}
+	if proxyDSN != "" {
+		proxyURL, err := url.Parse(proxyDSN)
+		if err != nil {
+			return nil, fmt.Errorf("telegram client proxy dsn error: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &TelegramClient{
+		apiKey:  apiKey,
+		baseURL: TELEGRAM_API_BASE_URL,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+		},
+		retry:     newTelegramRetryPolicy(),
+		sendRetry: newTelegramSendRetryPolicy(),
+	}, nil
+}
+
+// telegramSendMethods post messages, they are not retried once the request may have reached Telegram
+var telegramSendMethods = map[string]bool{
+	"sendMessage":    true,
+	"sendPoll":       true,
+	"sendDocument":   true,
+	"sendPhoto":      true,
+	"sendMediaGroup": true,
+}
+
+// newTelegramRetryPolicy retries outages only, 429 answers carry retry_after and are left to RateLimitedTransport
+func newTelegramRetryPolicy() httpretry.RetryPolicy {
+	policy := httpretry.NewRetryPolicy("telegram")
+	policy.Retryable = func(statusCode int) bool { return statusCode >= 500 }
+	return policy
+}
+
+// newTelegramSendRetryPolicy retries 5xx answers and connections that failed before the request was sent
+func newTelegramSendRetryPolicy() httpretry.RetryPolicy {
+	policy := newTelegramRetryPolicy()
+	policy.RetryableError = httpretry.IsConnectError
+	return policy
+}
+
+// SetBaseURL points the client at another Bot API server (local bot api, test server)
+func (c *TelegramClient) SetBaseURL(baseURL string) {
+	c.baseURL = baseURL
+}
+
+func (c *TelegramClient) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.apiKey, method)
+}
+
+// post calls a Bot API method, retrying network errors and 5xx answers. Methods that send messages are
+// retried after connection failures only.
+func (c *TelegramClient) post(method string, contentType string, body []byte) (*http.Response, []byte, error) {
+	policy := c.retry
+	if telegramSendMethods[method] {
+		policy = c.sendRetry
+	}
+	resp, respBody, _, err := policy.Do(c.client, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.methodURL(method), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	})
+	return resp, respBody, err
+}
+
+func (c *TelegramClient) GetUpdates(offset int64) ([]TelegramUpdate, error) {
+	uri := fmt.Sprintf("%s?offset=%d&timeout=1", c.methodURL("getUpdates"), offset)
+
+	resp, err := c.client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var telegramResp TelegramResponse
+	err = json.Unmarshal(body, &telegramResp)
+	if err != nil {
+		return nil, err
+	}
+
+	if !telegramResp.OK {
+		return nil, newTelegramAPIError("get updates", resp.StatusCode, body)
+	}
+
+	return telegramResp.Result, nil
+}
+
+func (c *TelegramClient) SendMessage(req TelegramSendMessageRequest) (int64, error) {
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, body, err := c.post("sendMessage", "application/json", jsonBody)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != 200 {
+		return 0, newTelegramAPIError("send message", resp.StatusCode, body)
+	}
+
+	var response TelegramSendMessageResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return 0, err
+	}
+
+	return response.Result.MessageID, nil
+}
+
+func (c *TelegramClient) EditMessage(req TelegramEditMessageRequest) error {
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := c.post("editMessageText", "application/json", jsonBody)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newTelegramAPIError("edit message", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+func (c *TelegramClient) SendPoll(req TelegramSendPollRequest) (TelegramSentPoll, error) {
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return TelegramSentPoll{}, err
+	}
+
+	resp, body, err := c.post("sendPoll", "application/json", jsonBody)
+	if err != nil {
+		return TelegramSentPoll{}, err
+	}
+
+	if resp.StatusCode != 200 {
+		return TelegramSentPoll{}, newTelegramAPIError("send poll", resp.StatusCode, body)
+	}
+
+	var response struct {
+		Result struct {
+			MessageID int64 `json:"message_id"`
+			Poll      struct {
+				ID string `json:"id"`
+			} `json:"poll"`
+		} `json:"result"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return TelegramSentPoll{}, err
+	}
+
+	return TelegramSentPoll{MessageID: response.Result.MessageID, PollID: response.Result.Poll.ID}, nil
+}
+
+func (c *TelegramClient) StopPoll(chatID int64, messageID int64) error {
+	jsonBody, err := json.Marshal(map[string]int64{"chat_id": chatID, "message_id": messageID})
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := c.post("stopPoll", "application/json", jsonBody)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newTelegramAPIError("stop poll", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+func (c *TelegramClient) GetChatMember(chatID int64, userID int64) (TelegramChatMember, error) {
+	jsonBody, err := json.Marshal(map[string]int64{"chat_id": chatID, "user_id": userID})
+	if err != nil {
+		return TelegramChatMember{}, err
+	}
+
+	resp, body, err := c.post("getChatMember", "application/json", jsonBody)
+	if err != nil {
+		return TelegramChatMember{}, err
+	}
+
+	if resp.StatusCode != 200 {
+		return TelegramChatMember{}, newTelegramAPIError("get chat member", resp.StatusCode, body)
+	}
+
+	var response struct {
+		Result TelegramChatMember `json:"result"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return TelegramChatMember{}, err
+	}
+
+	return response.Result, nil
+}
+
+// DownloadFile resolves an uploaded file with getFile and streams it to destPath
+func (c *TelegramClient) DownloadFile(fileID string, destPath string) error {
+	jsonBody, err := json.Marshal(map[string]string{"file_id": fileID})
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := c.post("getFile", "application/json", jsonBody)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newTelegramAPIError("get file", resp.StatusCode, body)
+	}
+
+	var response struct {
+		Result struct {
+			FilePath string `json:"file_path"`
+		} `json:"result"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return err
+	}
+	if response.Result.FilePath == "" {
+		return fmt.Errorf("telegram get file: no file path, the file may be larger than %dMB", TELEGRAM_DOWNLOAD_LIMIT>>20)
+	}
+
+	// Downloads take longer than API calls
+	client := &http.Client{Transport: c.client.Transport, Timeout: TELEGRAM_DOWNLOAD_TIMEOUT}
+	download, err := client.Get(fmt.Sprintf("%s/file/bot%s/%s", c.baseURL, c.apiKey, response.Result.FilePath))
+	if err != nil {
+		return err
+	}
+	defer download.Body.Close()
+
+	if download.StatusCode != 200 {
+		errorBody, _ := io.ReadAll(io.LimitReader(download.Body, 1024))
+		return newTelegramAPIError("download file", download.StatusCode, errorBody)
+	}
+
+	file, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, download.Body)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (c *TelegramClient) SendDocument(req TelegramSendDocumentRequest, filePath string) error {
+	// Open the file
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// Create multipart form
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	err = writer.WriteField("chat_id", strconv.FormatInt(req.ChatID, 10))
+	if err != nil {
+		return err
+	}
+
+	if req.MessageThreadID != 0 {
+		err = writer.WriteField("message_thread_id", strconv.FormatInt(req.MessageThreadID, 10))
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.Caption != "" {
+		err = writer.WriteField("caption", req.Caption)
+		if err != nil {
+			return err
+		}
+		if req.ParseMode != "" {
+			err = writer.WriteField("parse_mode", req.ParseMode)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	part, err := writer.CreateFormFile("document", filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := c.post("sendDocument", writer.FormDataContentType(), requestBody.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newTelegramAPIError("send document", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// SendPhoto uploads an image generated in memory, e.g. a chart, shown inline in the chat.
+// Without photo data Telegram fetches req.Photo itself.
+func (c *TelegramClient) SendPhoto(req TelegramSendPhotoRequest, filename string, photo []byte) error {
+	if photo == nil {
+		jsonBody, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		resp, body, err := c.post("sendPhoto", "application/json", jsonBody)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != 200 {
+			return newTelegramAPIError("send photo", resp.StatusCode, body)
+		}
+		return nil
+	}
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	err := writer.WriteField("chat_id", strconv.FormatInt(req.ChatID, 10))
+	if err != nil {
+		return err
+	}
+
+	if req.MessageThreadID != 0 {
+		err = writer.WriteField("message_thread_id", strconv.FormatInt(req.MessageThreadID, 10))
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.Caption != "" {
+		err = writer.WriteField("caption", req.Caption)
+		if err != nil {
+			return err
+		}
+		if req.ParseMode != "" {
+			err = writer.WriteField("parse_mode", req.ParseMode)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	part, err := writer.CreateFormFile("photo", filename)
+	if err != nil {
+		return err
+	}
+
+	_, err = part.Write(photo)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := c.post("sendPhoto", writer.FormDataContentType(), requestBody.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newTelegramAPIError("send photo", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// SendMediaGroup sends 2-10 photos as one album, uploads are referenced as attach://<name> in req.Media
+func (c *TelegramClient) SendMediaGroup(req TelegramSendMediaGroupRequest, uploads map[string][]byte) error {
+	media, err := json.Marshal(req.Media)
+	if err != nil {
+		return err
+	}
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	err = writer.WriteField("chat_id", strconv.FormatInt(req.ChatID, 10))
+	if err != nil {
+		return err
+	}
+
+	if req.MessageThreadID != 0 {
+		err = writer.WriteField("message_thread_id", strconv.FormatInt(req.MessageThreadID, 10))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writer.WriteField("media", string(media))
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(uploads))
+	for name := range uploads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		part, err := writer.CreateFormFile(name, name)
+		if err != nil {
+			return err
+		}
+		_, err = part.Write(uploads[name])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := c.post("sendMediaGroup", writer.FormDataContentType(), requestBody.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newTelegramAPIError("send media group", resp.StatusCode, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+const TELEGRAM_MEDIA_GROUP_LIMIT = 10 // photos per album, larger sets are split
+
+// MediaPhoto is one photo to send: a URL Telegram fetches itself, e.g. an avatar or a tweet image,
+// or Data generated here and uploaded as Filename, e.g. a chart
+type MediaPhoto struct {
+	URL      string
+	Filename string
+	Data     []byte
+	Caption  string
+}
+
+// SendPhotoURL sends a photo Telegram downloads from url
+func (b *BotController) SendPhotoURL(chatID int64, url string, caption string) error {
+	return b.transport.SendPhoto(TelegramSendPhotoRequest{
+		ChatID:    chatID,
+		Photo:     url,
+		Caption:   caption,
+		ParseMode: "HTML",
+	}, "", nil)
+}
+
+// SendMediaGroup sends photos as albums of up to TELEGRAM_MEDIA_GROUP_LIMIT, a photo left alone is sent on its own.
+// Telegram shows the caption of the first photo under the album.
+func (b *BotController) SendMediaGroup(chatID int64, photos []MediaPhoto) error {
+	for start := 0; start < len(photos); start += TELEGRAM_MEDIA_GROUP_LIMIT {
+		album := photos[start:min(start+TELEGRAM_MEDIA_GROUP_LIMIT, len(photos))]
+		if len(album) == 1 {
+			if err := b.sendMediaPhoto(chatID, album[0]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		req := TelegramSendMediaGroupRequest{ChatID: chatID}
+		uploads := make(map[string][]byte)
+		for i, photo := range album {
+			media := TelegramInputMediaPhoto{Type: "photo", Media: photo.URL, Caption: photo.Caption}
+			if photo.Data != nil {
+				// Form field names must be unique, the file name shown in Telegram is irrelevant for photos
+				name := fmt.Sprintf("photo%d", i)
+				uploads[name] = photo.Data
+				media.Media = "attach://" + name
+			}
+			if media.Caption != "" {
+				media.ParseMode = "HTML"
+			}
+			req.Media = append(req.Media, media)
+		}
+		if err := b.transport.SendMediaGroup(req, uploads); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BotController) sendMediaPhoto(chatID int64, photo MediaPhoto) error {
+	if photo.Data == nil {
+		return b.SendPhotoURL(chatID, photo.URL, photo.Caption)
+	}
+	return b.SendPhoto(chatID, photo.Filename, photo.Data, photo.Caption)
+}
+
+// SendChart sends a rendered PNG chart as a photo. Telegram rejects photos it cannot resize (too large,
+// extreme aspect ratio), those charts are sent as a document instead.
+func (b *BotController) SendChart(chatID int64, filename string, chart []byte, caption string) error {
+	err := b.SendPhoto(chatID, filename, chart, caption)
+	var apiErr *TelegramAPIError
+	if err == nil || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return err
+	}
+	log.Printf("Chart %s rejected as a photo for chat %d, sending it as a document: %v", filename, chatID, err)
+	return b.sendExportDocument(chatID, filename, string(chart), caption)
+}
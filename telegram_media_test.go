@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramClient_SendPhoto(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	bot := newTestBotController(server.newClient(t), setupTestDB(t))
+
+	require.NoError(t, bot.SendPhoto(1, "chart.png", []byte("png data"), "📈 <b>chart</b>"))
+	require.NoError(t, bot.SendPhotoURL(1, "https://pbs.twimg.com/profile_images/1/avatar.jpg", "@alice"))
+
+	photos := server.sentPhotos()
+	require.Len(t, photos, 2)
+	assert.Equal(t, fakeTelegramPhoto{ChatID: 1, Caption: "📈 <b>chart</b>", Photo: "chart.png", Content: "png data"}, photos[0])
+	assert.Equal(t, "https://pbs.twimg.com/profile_images/1/avatar.jpg", photos[1].Photo)
+	assert.Empty(t, photos[1].Content)
+}
+
+func TestBotController_SendMediaGroup(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	bot := newTestBotController(server.newClient(t), setupTestDB(t))
+
+	photos := []MediaPhoto{
+		{URL: "https://pbs.twimg.com/profile_images/1/avatar.jpg", Caption: "@alice"},
+		{Filename: "risk.png", Data: []byte("chart")},
+	}
+	for i := 0; i < TELEGRAM_MEDIA_GROUP_LIMIT-1; i++ {
+		photos = append(photos, MediaPhoto{URL: fmt.Sprintf("https://pbs.twimg.com/media/%d.jpg", i)})
+	}
+	require.NoError(t, bot.SendMediaGroup(1, photos))
+
+	albums := server.sentAlbums()
+	require.Len(t, albums, 1)
+	require.Len(t, albums[0].Media, TELEGRAM_MEDIA_GROUP_LIMIT)
+	assert.Equal(t, TelegramInputMediaPhoto{Type: "photo", Media: "https://pbs.twimg.com/profile_images/1/avatar.jpg", Caption: "@alice", ParseMode: "HTML"}, albums[0].Media[0])
+	assert.Equal(t, "attach://photo1", albums[0].Media[1].Media)
+	assert.Equal(t, map[string]string{"photo1": "chart"}, albums[0].Uploads)
+
+	sent := server.sentPhotos()
+	require.Len(t, sent, 1, "the photo left over after a full album is sent alone")
+	assert.Equal(t, "https://pbs.twimg.com/media/8.jpg", sent[0].Photo)
+}
+
+func TestBotController_SendChart(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	bot := newTestBotController(server.newClient(t), setupTestDB(t))
+
+	require.NoError(t, bot.SendChart(1, "risk.png", []byte("chart"), "📈 risk"))
+	assert.Len(t, server.sentPhotos(), 1)
+
+	server.rejectPhotos = true
+	require.NoError(t, bot.SendChart(1, "wide.png", []byte("wide chart"), "📈 wide"))
+	documents := server.sentDocuments()
+	require.Len(t, documents, 1, "charts Telegram cannot show as a photo arrive as a document")
+	assert.Equal(t, "wide.png", documents[0].FileName)
+	assert.Equal(t, "wide chart", documents[0].Content)
+	assert.Equal(t, "📈 wide", documents[0].Caption)
+	_, err := os.Stat("wide.png")
+	assert.True(t, os.IsNotExist(err), "no file is left behind")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	TELEGRAM_MAX_MESSAGE_LENGTH = 4096
+	// Room left in every part for the "📄 1/3" page indicator
+	TELEGRAM_PART_INDICATOR_RESERVE = 16
+)
+
+var (
+	htmlAtomRegex = regexp.MustCompile(`<[^>]*>|&#?\w+;|(?s:.)`)
+	htmlTagRegex  = regexp.MustCompile(`^<(/?)(\w+)`)
+)
+
+// telegramTextLength counts UTF-16 code units, the unit Telegram's limits are measured in
+func telegramTextLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// splitTelegramMessage splits an HTML message into parts of at most limit characters.
+// It prefers line breaks, never cuts inside a tag or entity, and closes tags that are
+// still open at the end of a part and reopens them at the start of the next one.
+// Parts get a page indicator when the message had to be split.
+func splitTelegramMessage(text string, limit int) []string {
+	if telegramTextLength(text) <= limit {
+		return []string{text}
+	}
+	limit -= TELEGRAM_PART_INDICATOR_RESERVE
+
+	var parts []string
+	var openTags []string
+	var current strings.Builder
+	currentLength, hasContent := 0, false
+
+	flush := func() {
+		parts = append(parts, current.String()+closingTags(openTags))
+		current.Reset()
+		current.WriteString(strings.Join(openTags, ""))
+		currentLength, hasContent = telegramTextLength(current.String()), false
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		// Half the limit leaves room for tags reopened in front of a long line
+		for _, piece := range splitLongLine(line, limit/2) {
+			pieceLength := telegramTextLength(piece)
+			if hasContent && currentLength+pieceLength+telegramTextLength(closingTags(openTags)) > limit {
+				flush()
+			}
+			current.WriteString(piece)
+			currentLength += pieceLength
+			hasContent = true
+			openTags = updateOpenTags(openTags, piece)
+		}
+	}
+	if hasContent {
+		parts = append(parts, current.String()+closingTags(openTags))
+	}
+
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s\n\n📄 %d/%d", strings.TrimRight(parts[i], "\n"), i+1, len(parts))
+	}
+	return parts
+}
+
+// splitLongLine cuts a line into pieces of at most max characters, preferring spaces
+func splitLongLine(line string, max int) []string {
+	if telegramTextLength(line) <= max {
+		return []string{line}
+	}
+
+	var pieces []string
+	var current []string
+	currentLength, lastSpace := 0, -1
+	for _, atom := range htmlAtomRegex.FindAllString(line, -1) {
+		atomLength := telegramTextLength(atom)
+		if currentLength+atomLength > max && len(current) > 0 {
+			cut := len(current)
+			if lastSpace > 0 {
+				cut = lastSpace + 1
+			}
+			pieces = append(pieces, strings.Join(current[:cut], ""))
+			current = append([]string(nil), current[cut:]...)
+			currentLength = telegramTextLength(strings.Join(current, ""))
+			lastSpace = -1
+			for i, a := range current {
+				if a == " " {
+					lastSpace = i
+				}
+			}
+		}
+		if atom == " " {
+			lastSpace = len(current)
+		}
+		current = append(current, atom)
+		currentLength += atomLength
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, ""))
+	}
+	return pieces
+}
+
+// updateOpenTags tracks which opening tags are still unclosed after text
+func updateOpenTags(openTags []string, text string) []string {
+	for _, tag := range htmlAtomRegex.FindAllString(text, -1) {
+		match := htmlTagRegex.FindStringSubmatch(tag)
+		if match == nil {
+			continue
+		}
+		if match[1] == "" {
+			openTags = append(openTags, tag)
+			continue
+		}
+		for i := len(openTags) - 1; i >= 0; i-- {
+			if htmlTagRegex.FindStringSubmatch(openTags[i])[2] == match[2] {
+				openTags = append(openTags[:i], openTags[i+1:]...)
+				break
+			}
+		}
+	}
+	return openTags
+}
+
+func closingTags(openTags []string) string {
+	var closing strings.Builder
+	for i := len(openTags) - 1; i >= 0; i-- {
+		closing.WriteString("</" + htmlTagRegex.FindStringSubmatch(openTags[i])[2] + ">")
+	}
+	return closing.String()
+}
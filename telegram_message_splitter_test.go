@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTelegramMessage(t *testing.T) {
+	t.Run("Short message is unchanged", func(t *testing.T) {
+		assert.Equal(t, []string{"<b>hi</b>"}, splitTelegramMessage("<b>hi</b>", TELEGRAM_MAX_MESSAGE_LENGTH))
+	})
+
+	t.Run("Long list splits on lines and keeps tags balanced", func(t *testing.T) {
+		var message strings.Builder
+		message.WriteString("<b>FUD users</b>\n<i>")
+		for i := 0; i < 300; i++ {
+			message.WriteString(fmt.Sprintf("%d. @user_%d &amp; friends 🚨\n", i, i))
+		}
+		message.WriteString("</i>")
+
+		parts := splitTelegramMessage(message.String(), 1000)
+		require.Greater(t, len(parts), 1)
+		for i, part := range parts {
+			assert.LessOrEqual(t, telegramTextLength(part), 1000)
+			assert.Equal(t, strings.Count(part, "<i>"), strings.Count(part, "</i>"), "part %d", i)
+			assert.True(t, strings.HasSuffix(part, fmt.Sprintf("📄 %d/%d", i+1, len(parts))))
+		}
+		assert.True(t, strings.HasPrefix(parts[1], "<i>"), "open tag is reopened")
+		assert.Contains(t, strings.Join(parts, ""), "299. @user_299 &amp; friends")
+	})
+
+	t.Run("Single long line is cut between words", func(t *testing.T) {
+		line := strings.Repeat("word ", 500)
+		parts := splitTelegramMessage(line, 1000)
+		require.Greater(t, len(parts), 2)
+		for _, part := range parts {
+			assert.LessOrEqual(t, telegramTextLength(part), 1000)
+			assert.True(t, strings.HasPrefix(part, "word"))
+		}
+	})
+}
+
+func TestBotController_SendMessageSplitsLongText(t *testing.T) {
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, setupTestDB(t))
+
+	messageID, err := bot.SendMessageWithID(1, strings.Repeat("line of history\n", 600))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), messageID, "the first part's ID is returned")
+
+	sent := transport.sentMessages()
+	require.Len(t, sent, 3)
+	assert.Contains(t, sent[2].Text, "📄 3/3")
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Telegram flood limits: about 30 messages per second overall, one per second in a
+// private chat and 20 per minute in a group
+const (
+	TELEGRAM_GLOBAL_RATE_PER_SEC  = 30
+	TELEGRAM_PRIVATE_RATE_PER_SEC = 1.0
+	TELEGRAM_GROUP_RATE_PER_SEC   = 20.0 / 60
+	TELEGRAM_CHAT_BURST           = 3
+	TELEGRAM_MAX_RETRIES          = 3
+	TELEGRAM_MAX_RETRY_AFTER      = 60 * time.Second
+)
+
+// tokenBucket allows `capacity` calls at once and refills at `rate` tokens per second
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, capacity float64) *tokenBucket {
+	return &tokenBucket{
+		capacity: capacity,
+		rate:     rate,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it
+func (tb *tokenBucket) reserve() time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+
+	// Tokens may go negative, later callers queue up behind earlier reservations
+	tb.tokens--
+	if tb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+}
+
+// allow takes a token if one is available right now, without queueing
+func (tb *tokenBucket) allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	if tb.tokens < 1 {
+		return false
+	}
+	tb.tokens--
+	return true
+}
+
+func (tb *tokenBucket) refill() {
+	now := time.Now()
+	tb.tokens = math.Min(tb.capacity, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	tb.last = now
+}
+
+// RateLimitedTransport paces outgoing Bot API calls to stay under Telegram's flood limits
+// and retries calls that were answered with 429 after the requested retry_after.
+type RateLimitedTransport struct {
+	next        TelegramTransport
+	global      *tokenBucket
+	chatMutex   sync.Mutex
+	chats       map[int64]*tokenBucket
+	privateRate float64
+	groupRate   float64
+	maxRetries  int
+}
+
+func NewRateLimitedTransport(next TelegramTransport) *RateLimitedTransport {
+	return &RateLimitedTransport{
+		next:        next,
+		global:      newTokenBucket(TELEGRAM_GLOBAL_RATE_PER_SEC, TELEGRAM_GLOBAL_RATE_PER_SEC),
+		chats:       make(map[int64]*tokenBucket),
+		privateRate: TELEGRAM_PRIVATE_RATE_PER_SEC,
+		groupRate:   TELEGRAM_GROUP_RATE_PER_SEC,
+		maxRetries:  TELEGRAM_MAX_RETRIES,
+	}
+}
+
+func (r *RateLimitedTransport) GetUpdates(offset int64) ([]TelegramUpdate, error) {
+	return r.next.GetUpdates(offset)
+}
+
+func (r *RateLimitedTransport) SendMessage(req TelegramSendMessageRequest) (int64, error) {
+	var messageID int64
+	err := r.do(req.ChatID, func() error {
+		var err error
+		messageID, err = r.next.SendMessage(req)
+		return err
+	})
+	return messageID, err
+}
+
+func (r *RateLimitedTransport) EditMessage(req TelegramEditMessageRequest) error {
+	return r.do(req.ChatID, func() error {
+		return r.next.EditMessage(req)
+	})
+}
+
+func (r *RateLimitedTransport) SendDocument(req TelegramSendDocumentRequest, filePath string) error {
+	return r.do(req.ChatID, func() error {
+		return r.next.SendDocument(req, filePath)
+	})
+}
+
+func (r *RateLimitedTransport) SendPhoto(req TelegramSendPhotoRequest, filename string, photo []byte) error {
+	return r.do(req.ChatID, func() error {
+		return r.next.SendPhoto(req, filename, photo)
+	})
+}
+
+func (r *RateLimitedTransport) SendMediaGroup(req TelegramSendMediaGroupRequest, uploads map[string][]byte) error {
+	return r.do(req.ChatID, func() error {
+		return r.next.SendMediaGroup(req, uploads)
+	})
+}
+
+func (r *RateLimitedTransport) SendPoll(req TelegramSendPollRequest) (TelegramSentPoll, error) {
+	var poll TelegramSentPoll
+	err := r.do(req.ChatID, func() error {
+		var err error
+		poll, err = r.next.SendPoll(req)
+		return err
+	})
+	return poll, err
+}
+
+func (r *RateLimitedTransport) StopPoll(chatID int64, messageID int64) error {
+	return r.do(chatID, func() error {
+		return r.next.StopPoll(chatID, messageID)
+	})
+}
+
+func (r *RateLimitedTransport) GetChatMember(chatID int64, userID int64) (TelegramChatMember, error) {
+	var member TelegramChatMember
+	err := r.do(chatID, func() error {
+		var err error
+		member, err = r.next.GetChatMember(chatID, userID)
+		return err
+	})
+	return member, err
+}
+
+// DownloadFile is not rate limited, file downloads do not count against the Bot API limits
+func (r *RateLimitedTransport) DownloadFile(fileID string, destPath string) error {
+	return r.next.DownloadFile(fileID, destPath)
+}
+
+// do waits for both the chat and the global bucket, then runs call with retry_after handling
+func (r *RateLimitedTransport) do(chatID int64, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		time.Sleep(r.chatBucket(chatID).reserve())
+		time.Sleep(r.global.reserve())
+
+		err := call()
+		var apiErr *TelegramAPIError
+		if err == nil || !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+			return err
+		}
+
+		retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+		if attempt >= r.maxRetries || retryAfter > TELEGRAM_MAX_RETRY_AFTER {
+			return err
+		}
+		log.Printf("⏳ Telegram rate limited chat %d, retrying in %s (attempt %d/%d)", chatID, retryAfter, attempt+1, r.maxRetries)
+		time.Sleep(retryAfter)
+	}
+}
+
+func (r *RateLimitedTransport) chatBucket(chatID int64) *tokenBucket {
+	r.chatMutex.Lock()
+	defer r.chatMutex.Unlock()
+
+	bucket, ok := r.chats[chatID]
+	if !ok {
+		// Group and channel IDs are negative
+		rate := r.privateRate
+		if chatID < 0 {
+			rate = r.groupRate
+		}
+		bucket = newTokenBucket(rate, TELEGRAM_CHAT_BURST)
+		r.chats[chatID] = bucket
+	}
+	return bucket
+}
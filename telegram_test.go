@@ -7,11 +7,12 @@ import (
 	"testing"
 )
 
-func TestNewTelegramService(t *testing.T) {
+func TestNewBotController(t *testing.T) {
 	t.Skip()
 	godotenv.Load()
-	telegramService, err := NewTelegramService(os.Getenv(ENV_TELEGRAM_API_KEY), os.Getenv(ENV_PROXY_DSN), os.Getenv(ENV_TELEGRAM_ADMIN_CHAT_ID), nil, nil, nil)
+	telegramClient, err := NewTelegramClient(os.Getenv(ENV_TELEGRAM_API_KEY), os.Getenv(ENV_PROXY_DSN))
 	assert.NoError(t, err)
+	telegramService := NewBotController(telegramClient, os.Getenv(ENV_TELEGRAM_ADMIN_CHAT_ID), nil, nil, nil)
 	telegramService.StartListening()
 	err = telegramService.BroadcastMessage("hello")
 	assert.NoError(t, err)
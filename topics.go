@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Kinds of messages that can be routed to a forum topic
+const (
+	TOPIC_ALERTS  = "alerts"
+	TOPIC_REPORTS = "reports"
+)
+
+const (
+	TOPIC_DEFAULT_ALERTS_NAME  = "FUD Alerts"
+	TOPIC_DEFAULT_REPORTS_NAME = "Reports"
+)
+
+// topicNames are the names of the topics picked up for alerts and reports when they are created
+func topicNames() (alerts string, reports string) {
+	alerts, reports = TOPIC_DEFAULT_ALERTS_NAME, TOPIC_DEFAULT_REPORTS_NAME
+	if name := strings.TrimSpace(os.Getenv(ENV_ALERTS_TOPIC_NAME)); name != "" {
+		alerts = name
+	}
+	if name := strings.TrimSpace(os.Getenv(ENV_REPORTS_TOPIC_NAME)); name != "" {
+		reports = name
+	}
+	return alerts, reports
+}
+
+// topicThreadID is the forum topic a message was posted in, 0 for the General topic and for chats
+// without topics, where replies carry a thread ID as well
+func topicThreadID(update TelegramUpdate) int64 {
+	if !update.Message.IsTopicMessage {
+		return 0
+	}
+	return update.Message.MessageThreadID
+}
+
+// isTopicGoneError reports whether Telegram refused a message because its forum topic was deleted or closed
+func isTopicGoneError(err error) bool {
+	var apiErr *TelegramAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	return strings.Contains(apiErr.Body, "message thread not found") ||
+		strings.Contains(apiErr.Body, "TOPIC_CLOSED") || strings.Contains(apiErr.Body, "TOPIC_DELETED")
+}
+
+// describeTopic is the /topic status of one kind
+func describeTopic(threadID int64) string {
+	if threadID == 0 {
+		return "General topic"
+	}
+	return fmt.Sprintf("topic %d", threadID)
+}
+
+// handleTopicCommand routes alerts or reports of the chat to the forum topic the command is sent in:
+// /topic [alerts|reports] [off]
+func (b *BotController) handleTopicCommand(chatID int64, threadID int64, args []string) {
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("❌ Error loading chat settings: %v", err))
+		return
+	}
+
+	if len(args) == 0 {
+		alertsName, reportsName := topicNames()
+		b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("🧵 <b>Forum topics</b>\n• Alerts: %s\n• Reports: %s\n\n"+
+			"Usage: send /topic alerts or /topic reports inside a topic to post there, /topic alerts off for the General topic.\n"+
+			"Topics named <i>%s</i> and <i>%s</i> are picked up when they are created.",
+			describeTopic(settings.AlertsTopicID), describeTopic(settings.ReportsTopicID), html.EscapeString(alertsName), html.EscapeString(reportsName)))
+		return
+	}
+
+	kind := strings.ToLower(args[0])
+	if kind != TOPIC_ALERTS && kind != TOPIC_REPORTS {
+		b.SendMessageToTopic(chatID, threadID, "❌ Unknown option. Use: /topic alerts|reports [off]")
+		return
+	}
+	target := threadID
+	if len(args) > 1 && strings.ToLower(args[1]) == "off" {
+		target = 0
+	} else if threadID == 0 {
+		b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("❌ Send /topic %s inside the forum topic that should get the %s.", kind, kind))
+		return
+	}
+
+	if kind == TOPIC_ALERTS {
+		settings.AlertsTopicID = target
+	} else {
+		settings.ReportsTopicID = target
+	}
+	if err := b.dbService.SaveChatSettings(settings); err != nil {
+		b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("❌ Error saving chat settings: %v", err))
+		return
+	}
+	log.Printf("🧵 Chat %d posts %s to the %s", chatID, kind, describeTopic(target))
+	if target == 0 {
+		b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("✅ The %s of this chat go to the General topic", kind))
+		return
+	}
+	b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("✅ The %s of this chat go to this topic", kind))
+}
+
+// handleForumTopicCreated routes alerts or reports to a new topic carrying their configured name
+func (b *BotController) handleForumTopicCreated(chatID int64, threadID int64, name string) {
+	alertsName, reportsName := topicNames()
+	var kind string
+	switch {
+	case strings.EqualFold(strings.TrimSpace(name), alertsName):
+		kind = TOPIC_ALERTS
+	case strings.EqualFold(strings.TrimSpace(name), reportsName):
+		kind = TOPIC_REPORTS
+	default:
+		return
+	}
+
+	settings, err := b.dbService.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to load settings of chat %d for topic %q: %v", chatID, name, err)
+		return
+	}
+	if kind == TOPIC_ALERTS {
+		settings.AlertsTopicID = threadID
+	} else {
+		settings.ReportsTopicID = threadID
+	}
+	if err := b.dbService.SaveChatSettings(settings); err != nil {
+		log.Printf("Failed to route %s of chat %d to topic %q: %v", kind, chatID, name, err)
+		return
+	}
+	log.Printf("🧵 Chat %d posts %s to the new topic %q (%d)", chatID, kind, name, threadID)
+	b.SendMessageToTopic(chatID, threadID, fmt.Sprintf("🧵 The %s of this chat go to this topic from now on. /topic shows the routing.", kind))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicThreadID(t *testing.T) {
+	var update TelegramUpdate
+	require.NoError(t, json.Unmarshal([]byte(`{"update_id":1,"message":{"message_id":5,"message_thread_id":12,"is_topic_message":true,
+		"chat":{"id":-100,"type":"supergroup"},"forum_topic_created":{"name":"FUD Alerts","icon_color":7322096}}}`), &update))
+	assert.Equal(t, int64(12), topicThreadID(update))
+	require.NotNil(t, update.Message.ForumTopicCreated)
+	assert.Equal(t, "FUD Alerts", update.Message.ForumTopicCreated.Name)
+
+	// Replies in groups without topics carry a thread ID too
+	update = TelegramUpdate{}
+	require.NoError(t, json.Unmarshal([]byte(`{"update_id":2,"message":{"message_id":6,"message_thread_id":3,"chat":{"id":-100},"text":"/topic"}}`), &update))
+	assert.Zero(t, topicThreadID(update))
+}
+
+func TestBotController_Topic(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[-100] = true
+
+	last := func() TelegramSendMessageRequest {
+		sent := transport.sentMessages()
+		return sent[len(sent)-1]
+	}
+
+	bot.handleTopicCommand(-100, 0, []string{"alerts"})
+	assert.Contains(t, last().Text, "inside the forum topic")
+	bot.handleTopicCommand(-100, 12, []string{"alerts"})
+	assert.Contains(t, last().Text, "alerts of this chat go to this topic")
+	assert.Equal(t, int64(12), last().MessageThreadID, "the reply goes to the topic of the command")
+
+	require.NoError(t, bot.SendAlertToChat(-100, benchmarkAlert(), "n1"))
+	assert.Contains(t, last().Text, "@suspicious_user")
+	assert.Equal(t, int64(12), last().MessageThreadID)
+
+	// A new topic with the reports name takes the digests
+	update := newTestUpdate(-100, "")
+	update.Message.MessageThreadID = 20
+	update.Message.IsTopicMessage = true
+	update.Message.ForumTopicCreated = &TelegramForumTopic{Name: "reports"}
+	bot.handleUpdate(update)
+	require.Eventually(t, func() bool {
+		settings, err := db.GetChatSettings(-100)
+		return err == nil && settings.ReportsTopicID == 20
+	}, time.Second, 10*time.Millisecond)
+	settings, err := db.GetChatSettings(-100)
+	require.NoError(t, err)
+	settings.Digest = DIGEST_DAILY
+	require.NoError(t, bot.sendDigest(settings, time.Now()))
+	assert.Equal(t, int64(20), last().MessageThreadID)
+
+	bot.handleTopicCommand(-100, 20, []string{"alerts", "off"})
+	assert.Contains(t, last().Text, "General topic")
+	bot.handleTopicCommand(-100, 0, nil)
+	assert.Contains(t, last().Text, "Alerts: General topic")
+	assert.Contains(t, last().Text, "Reports: topic 20")
+}
+
+func TestSendMessageToDeletedTopic(t *testing.T) {
+	server := newFakeTelegramServer(t)
+	bot := newTestBotController(server.newClient(t), setupTestDB(t))
+	server.deletedTopics = map[int64]bool{9: true}
+
+	require.NoError(t, bot.SendMessageToTopic(1, 12, "in topic"))
+	require.NoError(t, bot.SendMessageToTopic(1, 9, "topic is gone"))
+
+	messages := server.messagesFor(1)
+	require.Len(t, messages, 2)
+	assert.Equal(t, int64(12), messages[0].ThreadID)
+	assert.Equal(t, "topic is gone", messages[1].Text)
+	assert.Zero(t, messages[1].ThreadID, "sent to the General topic instead")
+}
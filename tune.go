@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TUNE_DEFAULT_DAYS = 30
+	TUNE_MAX_DAYS     = 90
+)
+
+// tuneDefaultCutoffs are the FUD probability cutoffs /tune compares with the model's own verdict
+var tuneDefaultCutoffs = []float64{0.5, 0.6, 0.7, 0.8, 0.9}
+
+// tuneSample is one stored analysis replayed by /tune
+type tuneSample struct {
+	IsFUD       bool    // the model's verdict, what decides alerts today
+	Probability float64 // the model's FUD probability
+	Messages    int64   // messages of the user in the window, each one alerts while the user is flagged
+	Label       string  // moderator verdict, empty when nobody labeled the user
+}
+
+// tuneOutcome is what a setting would have produced over the window
+type tuneOutcome struct {
+	Users       int
+	Alerts      int64
+	Caught      int // confirmed FUD users it flags
+	FalseAlarms int // confirmed clean users it flags
+}
+
+func replayTuneSetting(samples []tuneSample, flags func(tuneSample) bool) tuneOutcome {
+	outcome := tuneOutcome{}
+	for _, sample := range samples {
+		if !flags(sample) {
+			continue
+		}
+		outcome.Users++
+		outcome.Alerts += max(sample.Messages, 1)
+		switch sample.Label {
+		case LABEL_FUD:
+			outcome.Caught++
+		case LABEL_CLEAN:
+			outcome.FalseAlarms++
+		}
+	}
+	return outcome
+}
+
+// loadTuneSamples pairs the analyses of the window with the moderators' verdicts. A verdict given after the
+// analysis replaced it in the cache, the model's side is then read from the verdict.
+func (b *BotController) loadTuneSamples(since time.Time) ([]tuneSample, error) {
+	analyses, err := b.dbService.GetCachedAnalysesSince(since)
+	if err != nil {
+		return nil, err
+	}
+	verdicts, err := b.dbService.GetLatestLabeledVerdicts()
+	if err != nil {
+		return nil, err
+	}
+	messages, err := b.dbService.GetUserTweetCountsSince(since)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]tuneSample, 0, len(analyses))
+	for _, analysis := range analyses {
+		sample := tuneSample{IsFUD: analysis.IsFUDUser, Probability: analysis.FUDProbability, Messages: messages[analysis.UserID]}
+		if verdict, ok := verdicts[analysis.UserID]; ok {
+			sample.Label = verdict.Label
+			if !verdict.CreatedAt.Before(analysis.AnalyzedAt) {
+				if !verdict.ModelAnalyzed {
+					continue
+				}
+				sample.IsFUD, sample.Probability = verdict.ModelIsFUD, verdict.ModelProbability
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// parseTuneArgs reads /tune [days] [cutoffs], cutoffs as 0.7 or 70%, comma separated
+func parseTuneArgs(args []string) (int, []float64, error) {
+	days := TUNE_DEFAULT_DAYS
+	candidates := tuneDefaultCutoffs
+	for _, arg := range args {
+		if strings.Contains(arg, ",") || strings.Contains(arg, "%") || strings.Contains(arg, ".") {
+			candidates = nil
+			for _, part := range strings.Split(arg, ",") {
+				part = strings.TrimSpace(part)
+				value, err := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+				if err == nil && (strings.HasSuffix(part, "%") || value > 1) {
+					value /= 100
+				}
+				if err != nil || value <= 0 || value > 1 {
+					return 0, nil, fmt.Errorf("invalid cutoff %s, use 0.7 or 70%%", part)
+				}
+				candidates = append(candidates, value)
+			}
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(arg), "d"))
+		if err != nil || value < 1 || value > TUNE_MAX_DAYS {
+			return 0, nil, fmt.Errorf("invalid period %s, use 1d to %dd", arg, TUNE_MAX_DAYS)
+		}
+		days = value
+	}
+	return days, candidates, nil
+}
+
+// handleTuneCommand replays the stored analyses against candidate FUD probability cutoffs:
+// /tune [days] [0.6,0.7,0.8]
+func (b *BotController) handleTuneCommand(chatID int64, args []string) {
+	days, candidates, err := parseTuneArgs(args)
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error()+"\nUsage: /tune [30d] [0.6,0.7,0.8]")
+		return
+	}
+	samples, err := b.loadTuneSamples(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading analyses: %v", err))
+		return
+	}
+	b.SendMessage(chatID, formatTuneReport(samples, candidates, days))
+}
+
+func formatTuneReport(samples []tuneSample, candidates []float64, days int) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🎛 <b>Threshold replay, last %d days</b>\n", days))
+	if len(samples) == 0 {
+		message.WriteString("\n📭 No analyses in this period.")
+		return message.String()
+	}
+
+	current := replayTuneSetting(samples, func(sample tuneSample) bool { return sample.IsFUD })
+	confirmedFUD, confirmedClean := 0, 0
+	for _, sample := range samples {
+		switch sample.Label {
+		case LABEL_FUD:
+			confirmedFUD++
+		case LABEL_CLEAN:
+			confirmedClean++
+		}
+	}
+	message.WriteString(fmt.Sprintf("📊 %d analyses · %d users flagged by the model · moderators confirmed %d FUD and %d clean\n\n", len(samples), current.Users, confirmedFUD, confirmedClean))
+
+	writeOutcome := func(label string, outcome tuneOutcome, withDelta bool) {
+		line := fmt.Sprintf("<b>%s</b>: %d users", label, outcome.Users)
+		if withDelta {
+			line += fmt.Sprintf(" (%+d)", outcome.Users-current.Users)
+		}
+		line += fmt.Sprintf(" · %d alerts", outcome.Alerts)
+		if withDelta {
+			line += fmt.Sprintf(" (%+d)", outcome.Alerts-current.Alerts)
+		}
+		if confirmedFUD+confirmedClean > 0 {
+			line += fmt.Sprintf(" · %d/%d confirmed FUD · %d false alarms", outcome.Caught, confirmedFUD, outcome.FalseAlarms)
+		}
+		message.WriteString(line + "\n")
+	}
+	writeOutcome("Model verdict (current)", current, false)
+
+	var best *tuneOutcome
+	bestCutoff := 0.0
+	for _, cutoff := range candidates {
+		outcome := replayTuneSetting(samples, func(sample tuneSample) bool { return sample.Probability >= cutoff })
+		writeOutcome(fmt.Sprintf("≥ %.0f%%", cutoff*100), outcome, true)
+		if outcome.Caught >= current.Caught && (best == nil || outcome.Alerts < best.Alerts) {
+			best, bestCutoff = &outcome, cutoff
+		}
+	}
+
+	message.WriteString("\n")
+	switch {
+	case confirmedFUD == 0:
+		message.WriteString("🏷 No confirmed FUD in this period. /mark_fud and /mark_clean make the capture measurable.")
+	case best != nil && best.Alerts < current.Alerts:
+		message.WriteString(fmt.Sprintf("💡 ≥ %.0f%% keeps %d/%d confirmed FUD with %d fewer alerts (%.0f%%)", bestCutoff*100, best.Caught, confirmedFUD, current.Alerts-best.Alerts, float64(best.Alerts-current.Alerts)*100/float64(current.Alerts)))
+	default:
+		message.WriteString("💡 No cutoff keeps the confirmed FUD caught today with fewer alerts than the model verdict.")
+	}
+	return message.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_Tune(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	reply := func(args ...string) string {
+		bot.handleTuneCommand(1, args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+	assert.Contains(t, reply(), "No analyses in this period")
+
+	analyze := func(userID string, isFUD bool, probability float64, messages int) {
+		require.NoError(t, db.SaveCachedAnalysis(userID, userID, SecondStepClaudeResponse{IsFUDUser: isFUD, FUDProbability: probability}, "second v1"))
+		for i := 0; i < messages; i++ {
+			require.NoError(t, db.SaveTweet(TweetModel{ID: fmt.Sprintf("%s_%d", userID, i), UserID: userID, Text: "gm", CreatedAt: time.Now()}))
+		}
+	}
+	analyze("loud", true, 0.95, 10)
+	analyze("borderline", true, 0.65, 20)
+	analyze("sneaky", false, 0.75, 3)
+	analyze("calm", false, 0.2, 5)
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "loud", Label: LABEL_FUD}))
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "borderline", Label: LABEL_CLEAN}))
+	assert.Contains(t, reply("7d", "0.6,70%,0.9"), "No confirmed FUD", "verdicts given after the analysis without a model side are skipped")
+
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "loud", Label: LABEL_FUD, ModelAnalyzed: true, ModelIsFUD: true, ModelProbability: 0.95}))
+	require.NoError(t, db.SaveLabeledVerdict(&LabeledVerdictModel{UserID: "borderline", Label: LABEL_CLEAN, ModelAnalyzed: true, ModelIsFUD: true, ModelProbability: 0.65}))
+	report := reply("7d", "0.6,70%,0.9")
+	assert.Contains(t, report, "last 7 days")
+	assert.Contains(t, report, "4 analyses · 2 users flagged by the model · moderators confirmed 1 FUD and 1 clean")
+	assert.Contains(t, report, "<b>Model verdict (current)</b>: 2 users · 30 alerts · 1/1 confirmed FUD · 1 false alarms")
+	assert.Contains(t, report, "<b>≥ 60%</b>: 3 users (+1) · 33 alerts (+3) · 1/1 confirmed FUD · 1 false alarms")
+	assert.Contains(t, report, "<b>≥ 70%</b>: 2 users (+0) · 13 alerts (-17) · 1/1 confirmed FUD · 0 false alarms")
+	assert.Contains(t, report, "💡 ≥ 90% keeps 1/1 confirmed FUD with 20 fewer alerts (-67%)")
+
+	assert.Contains(t, reply("0.6,150%"), "invalid cutoff")
+	assert.Contains(t, reply("365d"), "invalid period")
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+var tweetIDRegex = regexp.MustCompile(`^\d{1,25}$`)
+
+// handleAnalyzeTweetCommand analyzes one specific tweet with its thread instead of the author's
+// latest one: /analyze_tweet id_or_url [to:broadcast|to:chat_id] [history:window]
+func (b *BotController) handleAnalyzeTweetCommand(chatID int64, args []string) {
+	usage := "❌ Usage: /analyze_tweet tweet_id_or_link [to:broadcast|to:chat_id] [history:50|30d|ticker]"
+	if len(args) == 0 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	_, tweetID := parseTwitterReference(args[0])
+	if tweetID == "" && tweetIDRegex.MatchString(args[0]) {
+		tweetID = args[0]
+	}
+	if tweetID == "" {
+		b.SendMessage(chatID, usage)
+		return
+	}
+
+	tweet, author, err := b.lookupTweet(tweetID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Tweet %s not found: %s", tweetID, html.EscapeString(err.Error())))
+		return
+	}
+	task := &AnalysisTaskModel{Kind: ANALYSIS_KIND_TWEET, Username: author, UserID: tweet.UserID, TweetID: tweet.ID, Priority: ANALYSIS_PRIORITY_NORMAL}
+	for _, option := range args[1:] {
+		if err := b.applyAnalysisOption(task, option); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+	}
+	b.startAnalysisTask(chatID, task)
+}
+
+// lookupTweet returns a tweet and its author's username from the database, fetching tweets the
+// bot has not seen from the API
+func (b *BotController) lookupTweet(tweetID string) (*TweetModel, string, error) {
+	tweet, err := b.dbService.GetTweet(tweetID)
+	if err != nil {
+		if b.twitterApi == nil {
+			return nil, "", fmt.Errorf("not stored and the Twitter API is not available")
+		}
+		resp, err := b.twitterApi.GetTweetsByIds([]string{tweetID})
+		if err != nil {
+			return nil, "", err
+		}
+		for _, fetched := range resp.Tweets {
+			if fetched.Id == tweetID {
+				storeTweetAndUserWithSource(b.dbService, fetched, TWEET_SOURCE_CONTEXT, "", "context for /analyze_tweet")
+			}
+		}
+		if tweet, err = b.dbService.GetTweet(tweetID); err != nil {
+			return nil, "", fmt.Errorf("deleted or not visible to the API")
+		}
+	}
+
+	author := tweet.Username
+	if author == "" {
+		if user, err := b.dbService.GetUser(tweet.UserID); err == nil {
+			author = user.Username
+		}
+	}
+	if author == "" {
+		return nil, "", fmt.Errorf("author %s unknown", tweet.UserID)
+	}
+	return tweet, author, nil
+}
+
+// tweetAnalysisMessage builds the second step message for an exact tweet with the two tweets above it in the thread
+func (b *BotController) tweetAnalysisMessage(task *AnalysisTaskModel) (twitterapi.NewMessage, error) {
+	tweet, author, err := b.lookupTweet(task.TweetID)
+	if err != nil {
+		return twitterapi.NewMessage{}, fmt.Errorf("tweet %s not found: %w", task.TweetID, err)
+	}
+	newMessage := twitterapi.NewMessage{
+		TweetID:           tweet.ID,
+		ReplyTweetID:      tweet.InReplyToID,
+		Text:              tweet.Text,
+		CreatedAt:         tweet.CreatedAt.Format(time.RFC3339),
+		IsManualAnalysis:  true,
+		ForceNotification: true,
+		FocusTweet:        true,
+		TaskID:            task.ID,
+		HistoryWindow:     task.HistoryWindow,
+		TelegramChatID:    task.notificationChatID(),
+		DiscordChannelID:  task.DiscordChannel,
+		NotificationRoute: task.NotifyRoute,
+		RequestSource:     task.RequestSource,
+		RequestReason:     task.RequestReason,
+	}
+	newMessage.Author.UserName, newMessage.Author.Name, newMessage.Author.ID = author, author, tweet.UserID
+
+	if tweet.InReplyToID == "" {
+		newMessage.ParentTweet.Author = "system"
+		newMessage.ParentTweet.Text = "The analyzed tweet is a top-level post, not a reply"
+		return newMessage, nil
+	}
+	parent, parentAuthor, err := b.lookupTweet(tweet.InReplyToID)
+	if err != nil {
+		taskLogger("tweet_analysis", task).Warn("parent of analyzed tweet not found", "tweet_id", tweet.ID, "parent_id", tweet.InReplyToID, "error", err)
+		newMessage.ParentTweet.Author = "system"
+		newMessage.ParentTweet.Text = "The analyzed tweet is a reply, the post it answers is not available"
+		return newMessage, nil
+	}
+	newMessage.ParentTweet.ID, newMessage.ParentTweet.Author, newMessage.ParentTweet.Text = parent.ID, parentAuthor, parent.Text
+	if parent.InReplyToID == "" {
+		return newMessage, nil
+	}
+	if grandParent, grandParentAuthor, err := b.lookupTweet(parent.InReplyToID); err == nil {
+		newMessage.GrandParentTweet.ID, newMessage.GrandParentTweet.Author, newMessage.GrandParentTweet.Text = grandParent.ID, grandParentAuthor, grandParent.Text
+	}
+	return newMessage, nil
+}
+
+// processTweetAnalysisTask sends the exact tweet of the task to the second step. Unlike the user
+// analysis it fails when the tweet is gone rather than judging another one.
+func (b *BotController) processTweetAnalysisTask(taskID string) {
+	defer func() {
+		if r := recover(); r != nil {
+			logFor("tweet_analysis").Error("tweet analysis task panicked", "task_id", taskID, "panic", r)
+			b.dbService.SetAnalysisTaskError(taskID, TASK_ERROR_INTERNAL, fmt.Sprintf("Internal error: %v", r))
+		}
+	}()
+	ctx, done := b.analysisTaskContext(taskID)
+	defer done()
+
+	task, err := b.dbService.GetAnalysisTask(taskID)
+	if err != nil {
+		logFor("tweet_analysis").Error("failed to get tweet analysis task", "task_id", taskID, "error", err)
+		return
+	}
+	logger := taskLogger("tweet_analysis", task).With("tweet_id", task.TweetID)
+
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_USER_LOOKUP, "Loading the tweet and its thread...")
+	newMessage, err := b.tweetAnalysisMessage(task)
+	if err != nil {
+		code := classifyTaskError(err)
+		if code == TASK_ERROR_INTERNAL {
+			code = TASK_ERROR_TWEET_NOT_FOUND
+		}
+		b.dbService.SetAnalysisTaskError(taskID, code, html.EscapeString(err.Error()))
+		return
+	}
+
+	b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_CLAUDE_ANALYSIS, "Sending for FUD analysis...")
+	if ctx.Err() != nil {
+		logger.Info("tweet analysis task cancelled before it was sent for analysis")
+		return
+	}
+	select {
+	case b.analysisChannel <- newMessage:
+		b.dbService.UpdateAnalysisTaskProgress(taskID, ANALYSIS_STEP_CLAUDE_ANALYSIS, "Processing with neural network...")
+		logger.Info("tweet analysis task sent to Claude processing pipeline")
+	default:
+		b.dbService.SetAnalysisTaskError(taskID, TASK_ERROR_QUEUE_FULL, "Analysis channel is full, please try again later")
+	}
+}
+
+// formatTweetVerdict reports the verdict of a completed tweet analysis on that tweet
+func formatTweetVerdict(task *AnalysisTaskModel) string {
+	var result AnalysisTaskResult
+	if task.Kind != ANALYSIS_KIND_TWEET || json.Unmarshal([]byte(task.ResultData), &result) != nil || !result.AnalysisComplete {
+		return ""
+	}
+	verdict := fmt.Sprintf("✅ clean (%.0f%%)", result.FUDProbability*100)
+	if result.IsFUD {
+		verdict = fmt.Sprintf("🚨 FUD, %s (%.0f%%)", html.EscapeString(result.FUDType), result.FUDProbability*100)
+	}
+	return fmt.Sprintf("\n🎯 <b>Tweet verdict:</b> %s\n🔗 https://x.com/%s/status/%s", verdict, task.Username, task.TweetID)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_AnalyzeTweet(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.analysisChannel = make(chan twitterapi.NewMessage, 1)
+	var fetched [][]string
+	bot.twitterApi = &mockTwitterAPI{tweetsByIds: func(tweetIds []string) (*twitterapi.TweetsByIdsResponse, error) {
+		fetched = append(fetched, tweetIds)
+		if tweetIds[0] != "103" {
+			return &twitterapi.TweetsByIdsResponse{}, nil
+		}
+		tweet := twitterapi.Tweet{Id: "103", Text: "team is dumping on you", InReplyToId: "101", CreatedAt: "Mon Jan 02 15:04:05 +0000 2006"}
+		tweet.Author.Id, tweet.Author.UserName = "u7", "replier"
+		return &twitterapi.TweetsByIdsResponse{Tweets: []twitterapi.Tweet{tweet}}, nil
+	}}
+	require.NoError(t, db.SaveUser(UserModel{ID: "u8", Username: "founder"}))
+	require.NoError(t, db.SaveUser(UserModel{ID: "u9", Username: "holder"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "100", Text: "v2 ships today", UserID: "u8"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "101", Text: "finally", UserID: "u9", InReplyToID: "100"}))
+	reply := func(args ...string) string {
+		bot.handleAnalyzeTweetCommand(1, args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply(), "Usage")
+	assert.Contains(t, reply("someone"), "Usage", "a username is not a tweet")
+	assert.Contains(t, reply("999"), "Tweet 999 not found")
+
+	task := &AnalysisTaskModel{ID: "tweet-task", Kind: ANALYSIS_KIND_TWEET, Username: "replier", TweetID: "103", Status: ANALYSIS_STATUS_PENDING}
+	_, _, err := bot.createAnalysisTask(task)
+	require.NoError(t, err)
+	bot.processTweetAnalysisTask("tweet-task")
+
+	require.Len(t, bot.analysisChannel, 1)
+	message := <-bot.analysisChannel
+	assert.Equal(t, "103", message.TweetID)
+	assert.Equal(t, "replier", message.Author.UserName)
+	assert.Equal(t, "team is dumping on you", message.Text)
+	assert.True(t, message.FocusTweet)
+	assert.Equal(t, "holder", message.ParentTweet.Author)
+	assert.Equal(t, "finally", message.ParentTweet.Text)
+	assert.Equal(t, "founder", message.GrandParentTweet.Author)
+	assert.Equal(t, [][]string{{"999"}, {"103"}}, fetched, "the thread above is read from the database")
+
+	result, _ := json.Marshal(AnalysisTaskResult{AnalysisComplete: true, IsFUD: true, FUDType: "dump_fud", FUDProbability: 0.82})
+	require.NoError(t, db.CompleteAnalysisTask("tweet-task", string(result)))
+	stored, err := db.GetAnalysisTask("tweet-task")
+	require.NoError(t, err)
+	progress := bot.formatAnalysisProgress(stored)
+	assert.Contains(t, progress, "Tweet verdict:</b> 🚨 FUD, dump_fud (82%)")
+	assert.Contains(t, progress, "https://x.com/replier/status/103")
+
+	_, _, err = bot.createAnalysisTask(&AnalysisTaskModel{ID: "deleted-task", Kind: ANALYSIS_KIND_TWEET, Username: "replier", TweetID: "105", Status: ANALYSIS_STATUS_PENDING})
+	require.NoError(t, err)
+	bot.processTweetAnalysisTask("deleted-task")
+	failed, err := db.GetAnalysisTask("deleted-task")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_FAILED, failed.Status, "another tweet is never judged in its place")
+	assert.Empty(t, bot.analysisChannel)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	TWEET_DEDUP_REPORT_WINDOW = 7 * 24 * time.Hour // merges /dedup reports on
+	TWEET_DEDUP_SHOWN         = 10                 // merge kinds listed by /dedup, the rest are counted
+	TWEET_DEDUP_ID_VARIANT    = "id_variant"       // merged source of a row stored under a non-canonical ID
+)
+
+// tweetSourceRanks orders the sources by how complete their records are: monitored tweets carry the
+// community, ticker searches the ticker, context and timeline fetches only the tweet itself
+var tweetSourceRanks = map[string]int{
+	TWEET_SOURCE_COMMUNITY:     4,
+	TWEET_SOURCE_MONITORING:    4,
+	TWEET_SOURCE_TICKER_SEARCH: 3,
+	TWEET_SOURCE_TIMELINE:      2,
+	TWEET_SOURCE_CONTEXT:       1,
+}
+
+// canonicalTweetID is the numeric ID a stored reference names: imports bring IDs with spaces, spreadsheet
+// quotes or as status URLs. IDs that name no tweet, like the placeholders of manual analysis, stay as they are.
+func canonicalTweetID(tweetID string) string {
+	canonical := strings.Trim(strings.TrimSpace(tweetID), `'"`)
+	if i := strings.LastIndex(canonical, "/status/"); i >= 0 {
+		canonical = canonical[i+len("/status/"):]
+		if end := strings.IndexAny(canonical, "/?#"); end >= 0 {
+			canonical = canonical[:end]
+		}
+	}
+	if !isTrackableTweetID(canonical) {
+		return tweetID
+	}
+	return canonical
+}
+
+// mergeTweet combines a stored tweet with another copy of it and keeps the richest record: the better
+// source, the earliest creation time, the highest reply count and every field either copy has. Text
+// is taken from a source at least as good as the stored one, so edits seen by the monitoring replace it.
+// It returns the fields taken from the incoming copy.
+func mergeTweet(stored, incoming TweetModel) (TweetModel, []string) {
+	merged := stored
+	var fields []string
+	take := func(field string, current *string, value string) {
+		if *current == "" && value != "" {
+			*current = value
+			fields = append(fields, field)
+		}
+	}
+
+	better := tweetSourceRanks[incoming.SourceType] >= tweetSourceRanks[stored.SourceType]
+	if incoming.Text != "" && incoming.Text != stored.Text && (stored.Text == "" || better) {
+		merged.Text = incoming.Text
+		fields = append(fields, "text")
+	}
+	if !incoming.CreatedAt.IsZero() && (stored.CreatedAt.IsZero() || incoming.CreatedAt.Before(stored.CreatedAt)) {
+		merged.CreatedAt = incoming.CreatedAt
+		fields = append(fields, "created_at")
+	}
+	if incoming.ReplyCount > stored.ReplyCount {
+		merged.ReplyCount = incoming.ReplyCount
+	}
+	if tweetSourceRanks[incoming.SourceType] > tweetSourceRanks[stored.SourceType] {
+		merged.SourceType = incoming.SourceType
+		fields = append(fields, "source_type")
+	}
+	take("user_id", &merged.UserID, incoming.UserID)
+	take("username", &merged.Username, incoming.Username)
+	take("in_reply_to_id", &merged.InReplyToID, incoming.InReplyToID)
+	take("community_id", &merged.CommunityID, incoming.CommunityID)
+	take("ticker_mention", &merged.TickerMention, incoming.TickerMention)
+	take("search_query", &merged.SearchQuery, incoming.SearchQuery)
+	return merged, fields
+}
+
+// handleDedupCommand processes /dedup: it merges tweets stored under ID variants and reports the
+// cross-source merges of the upsert path
+func (b *BotController) handleDedupCommand(chatID int64) {
+	result, err := b.dbService.DeduplicateTweetIDs()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Deduplication failed: %v", err))
+		return
+	}
+	summary, err := b.dbService.GetTweetMergeSummary(time.Now().Add(-TWEET_DEDUP_REPORT_WINDOW))
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Failed to load merges: %v", err))
+		return
+	}
+	b.SendMessage(chatID, formatTweetDedupReport(result, summary))
+}
+
+// formatTweetDedupReport is the /dedup report
+func formatTweetDedupReport(result TweetDedupResult, summary []TweetMergeSummary) string {
+	var text strings.Builder
+	text.WriteString("🧹 <b>Tweet deduplication</b>\n\n")
+	if result.Variants == 0 && result.RelinkedReplies == 0 {
+		text.WriteString("✅ No tweets stored under a non-canonical ID\n")
+	} else {
+		text.WriteString(fmt.Sprintf("🔀 %d tweets stored under a non-canonical ID: %d merged into the stored tweet, %d renamed, %d reply links fixed\n",
+			result.Variants, result.Merged, result.Renamed, result.RelinkedReplies))
+	}
+
+	total := 0
+	for _, merge := range summary {
+		total += merge.Count
+	}
+	text.WriteString(fmt.Sprintf("\n📥 <b>Merged on ingestion, last %dd:</b> %d\n", int(TWEET_DEDUP_REPORT_WINDOW.Hours()/24), total))
+	for i, merge := range summary {
+		if i == TWEET_DEDUP_SHOWN {
+			text.WriteString(fmt.Sprintf("... and %d more kinds\n", len(summary)-TWEET_DEDUP_SHOWN))
+			break
+		}
+		text.WriteString(fmt.Sprintf("• %s → %s: %d (%s)\n", merge.MergedSource, merge.KeptSource, merge.Count, strings.ReplaceAll(merge.Fields, ",", ", ")))
+	}
+	text.WriteString("\nRules: the better source and every filled field are kept, the earliest time and the highest reply count win.")
+	return text.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalTweetID(t *testing.T) {
+	assert.Equal(t, "123", canonicalTweetID(" 123 "))
+	assert.Equal(t, "123", canonicalTweetID("'123"))
+	assert.Equal(t, "123", canonicalTweetID("https://x.com/someone/status/123?s=20"))
+	assert.Equal(t, "manual_analysis_1", canonicalTweetID("manual_analysis_1"), "placeholders are kept")
+	assert.Equal(t, "", canonicalTweetID(""))
+}
+
+func TestMergeTweet(t *testing.T) {
+	posted := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stored := TweetModel{ID: "1", Text: "short", CreatedAt: posted.Add(time.Hour), SourceType: TWEET_SOURCE_CONTEXT, ReplyCount: 5}
+	incoming := TweetModel{ID: "1", Text: "full text", CreatedAt: posted, SourceType: TWEET_SOURCE_COMMUNITY, CommunityID: "c1", UserID: "u1", ReplyCount: 2}
+
+	merged, fields := mergeTweet(stored, incoming)
+	assert.Equal(t, "full text", merged.Text)
+	assert.Equal(t, posted, merged.CreatedAt, "the earliest time wins")
+	assert.Equal(t, TWEET_SOURCE_COMMUNITY, merged.SourceType)
+	assert.Equal(t, "c1", merged.CommunityID)
+	assert.Equal(t, 5, merged.ReplyCount, "the highest reply count wins")
+	assert.Equal(t, []string{"text", "created_at", "source_type", "user_id", "community_id"}, fields)
+
+	merged, fields = mergeTweet(merged, TweetModel{ID: "1", Text: "other", SourceType: TWEET_SOURCE_CONTEXT, InReplyToID: "0"})
+	assert.Equal(t, "full text", merged.Text, "a worse source does not replace the text")
+	assert.Equal(t, TWEET_SOURCE_COMMUNITY, merged.SourceType)
+	assert.Equal(t, []string{"in_reply_to_id"}, fields)
+}
+
+func TestSaveTweet_MergesAcrossSources(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "100", Text: "gm", UserID: "u1", SourceType: TWEET_SOURCE_COMMUNITY, CommunityID: "c1"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "100", Text: "gm", UserID: "u1", SourceType: TWEET_SOURCE_COMMUNITY, ReplyCount: 3}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: " 100", Text: "gm", UserID: "u1", SourceType: TWEET_SOURCE_TICKER_SEARCH, TickerMention: "GRUT"}))
+
+	stored, err := db.GetTweet("100")
+	require.NoError(t, err)
+	assert.Equal(t, TWEET_SOURCE_COMMUNITY, stored.SourceType)
+	assert.Equal(t, "c1", stored.CommunityID, "a later copy does not clear fields")
+	assert.Equal(t, "GRUT", stored.TickerMention)
+	assert.Equal(t, 3, stored.ReplyCount)
+
+	summary, err := db.GetTweetMergeSummary(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []TweetMergeSummary{{MergedSource: TWEET_SOURCE_TICKER_SEARCH, KeptSource: TWEET_SOURCE_COMMUNITY, Fields: "ticker_mention", Count: 1}}, summary, "re-polls of the same source are not merges")
+}
+
+func TestDedupCommand(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	db := setupTestDB(t)
+	// Rows written before IDs were canonicalized
+	require.NoError(t, db.db.Create(&TweetModel{ID: "200", Text: "original", UserID: "u1", SourceType: TWEET_SOURCE_CONTEXT}).Error)
+	require.NoError(t, db.db.Create(&TweetModel{ID: "'200", Text: "original", UserID: "u1", SourceType: TWEET_SOURCE_COMMUNITY, CommunityID: "c1"}).Error)
+	require.NoError(t, db.db.Create(&TweetModel{ID: "https://x.com/u/status/300", Text: "reply", UserID: "u2", InReplyToID: "'200", SourceType: TWEET_SOURCE_COMMUNITY}).Error)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	bot.handleDedupCommand(1)
+
+	stored, err := db.GetTweet("200")
+	require.NoError(t, err)
+	assert.Equal(t, TWEET_SOURCE_COMMUNITY, stored.SourceType)
+	assert.Equal(t, "c1", stored.CommunityID)
+	assert.False(t, db.TweetExists("'200"))
+	reply, err := db.GetTweet("300")
+	require.NoError(t, err)
+	assert.Equal(t, "200", reply.InReplyToID)
+
+	sent := transport.sentMessages()
+	report := sent[len(sent)-1].Text
+	assert.Contains(t, report, "2 tweets stored under a non-canonical ID: 1 merged into the stored tweet, 1 renamed, 1 reply links fixed")
+	assert.Contains(t, report, "• id_variant → community: 1 (source_type, community_id)")
+
+	bot.handleDedupCommand(1)
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "No tweets stored under a non-canonical ID", "the scan is idempotent")
+}
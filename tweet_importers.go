@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// Import formats of /import and ENV_IMPORT_CSV_PATH
+const (
+	IMPORT_FORMAT_CSV        = "csv"        // community export, see CSVImporter.mapColumns
+	IMPORT_FORMAT_TWITTERAPI = "twitterapi" // dumps of twitterapi responses or tweets, one JSON value or one per line
+	IMPORT_FORMAT_ARCHIVE    = "archive"    // tweets.js of a Twitter/X data archive
+)
+
+// ImportOptions selects how a file is imported
+type ImportOptions struct {
+	Format   string // IMPORT_FORMAT_*, detected from the file when empty
+	Author   string // username or username:id of an archive owner, tweets.js does not name its author
+	Backfill int    // missing parents of orphaned replies to fetch, see EnableParentBackfill
+	DryRun   bool   // validate the file without importing it, see ValidateFile
+}
+
+// importFormats lists the formats accepted by /import format:
+var importFormats = []string{IMPORT_FORMAT_CSV, IMPORT_FORMAT_TWITTERAPI, IMPORT_FORMAT_ARCHIVE}
+
+func isImportFormat(format string) bool {
+	for _, known := range importFormats {
+		if format == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isImportFile tells whether a file name has an extension detectImportFormat knows
+func isImportFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".js", ".json", ".jsonl":
+		return true
+	}
+	return false
+}
+
+// detectImportFormat picks the format of a file from its extension, .json files are told apart by content
+func detectImportFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return IMPORT_FORMAT_CSV, nil
+	case ".js":
+		return IMPORT_FORMAT_ARCHIVE, nil
+	case ".json", ".jsonl":
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(file, head)
+		head = bytes.TrimSpace(head[:n])
+		if bytes.HasPrefix(head, []byte("window.YTD")) || bytes.Contains(head, []byte(`"full_text"`)) || bytes.Contains(head, []byte(`"id_str"`)) {
+			return IMPORT_FORMAT_ARCHIVE, nil
+		}
+		return IMPORT_FORMAT_TWITTERAPI, nil
+	}
+	return "", fmt.Errorf("unsupported file type %s, use .csv, .json or tweets.js", filepath.Ext(path))
+}
+
+// ImportFile imports a file in any supported format through the same dedup and parent linking as CSV imports
+func (c *CSVImporter) ImportFile(path string, options ImportOptions) (*ImportResult, error) {
+	c.startedAt = time.Now()
+	_, tweetsData, rowErrors, err := c.parseFile(path, options)
+	if err != nil {
+		return nil, err
+	}
+	logImportRowErrors(rowErrors)
+	return c.ImportTweets(tweetsData), nil
+}
+
+// parseFile reads the tweets of a file in the given or detected format
+func (c *CSVImporter) parseFile(path string, options ImportOptions) (string, []CSVTweetData, []string, error) {
+	format := options.Format
+	if format == "" {
+		detected, err := detectImportFormat(path)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		format = detected
+	}
+	var tweetsData []CSVTweetData
+	var rowErrors []string
+	var err error
+	switch format {
+	case IMPORT_FORMAT_CSV:
+		tweetsData, rowErrors, err = c.parseCSV(path)
+	case IMPORT_FORMAT_TWITTERAPI:
+		tweetsData, rowErrors, err = parseTwitterAPIDump(path)
+	case IMPORT_FORMAT_ARCHIVE:
+		tweetsData, rowErrors, err = c.parseArchive(path, options.Author)
+	default:
+		err = fmt.Errorf("unknown import format %q, use %s", format, strings.Join(importFormats, ", "))
+	}
+	return format, tweetsData, rowErrors, err
+}
+
+// logImportRowErrors prints the rows an import skips
+func logImportRowErrors(rowErrors []string) {
+	for _, rowError := range rowErrors {
+		fmt.Printf("Skipped %s\n", rowError)
+	}
+}
+
+// ImportTwitterAPIDump imports tweets saved from the twitterapi package: a response ({"tweets": [...]} or
+// {"data": {"tweets": [...]}}), a tweet, or arrays of them, either as one JSON value or one per line
+func (c *CSVImporter) ImportTwitterAPIDump(path string) (*ImportResult, error) {
+	c.startedAt = time.Now()
+	tweetsData, rowErrors, err := parseTwitterAPIDump(path)
+	if err != nil {
+		return nil, err
+	}
+	logImportRowErrors(rowErrors)
+	return c.ImportTweets(tweetsData), nil
+}
+
+func parseTwitterAPIDump(path string) ([]CSVTweetData, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open JSON file: %w", err)
+	}
+	defer file.Close()
+
+	var tweetsData []CSVTweetData
+	var rowErrors []string
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for value := 1; ; value++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read JSON: %w", err)
+		}
+		tweets, invalid, err := twitterAPIDumpTweets(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if invalid > 0 {
+			rowErrors = append(rowErrors, fmt.Sprintf("JSON value %d: %d tweets without id or author", value, invalid))
+		}
+		for _, tweet := range tweets {
+			tweetsData = append(tweetsData, CSVTweetData{
+				AuthorUsername: tweet.Author.UserName,
+				TweetID:        tweet.Id,
+				AuthorID:       tweet.Author.Id,
+				Date:           tweet.CreatedAt,
+				ReplyCount:     tweet.ReplyCount,
+				ReplyToID:      tweet.InReplyToId,
+				Text:           tweet.Text,
+			})
+		}
+	}
+	if len(tweetsData) == 0 {
+		return nil, nil, fmt.Errorf("no tweets found in the JSON file")
+	}
+	return tweetsData, rowErrors, nil
+}
+
+// twitterAPIDumpTweets finds the tweets in one dumped JSON value and counts those without id or author
+func twitterAPIDumpTweets(value json.RawMessage) ([]twitterapi.Tweet, int, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) > 0 && value[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return nil, 0, fmt.Errorf("failed to read JSON: %w", err)
+		}
+		var tweets []twitterapi.Tweet
+		invalid := 0
+		for _, item := range items {
+			found, skipped, err := twitterAPIDumpTweets(item)
+			if err != nil {
+				return nil, 0, err
+			}
+			tweets = append(tweets, found...)
+			invalid += skipped
+		}
+		return tweets, invalid, nil
+	}
+
+	var dump struct {
+		twitterapi.Tweet
+		Tweets []twitterapi.Tweet `json:"tweets"`
+		Data   struct {
+			Tweets []twitterapi.Tweet `json:"tweets"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(value, &dump); err != nil {
+		return nil, 0, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	tweets := append(dump.Tweets, dump.Data.Tweets...)
+	if dump.Id != "" || dump.Text != "" {
+		tweets = append(tweets, dump.Tweet)
+	}
+	var valid []twitterapi.Tweet
+	invalid := 0
+	for _, tweet := range tweets {
+		switch {
+		case tweet.Id == "" || tweet.Author.Id == "":
+			invalid++
+		case tweet.RetweetedTweet != nil:
+			// Retweets are not the words of the retweeting account
+		default:
+			valid = append(valid, tweet)
+		}
+	}
+	return valid, invalid, nil
+}
+
+// archiveTweet is a tweet of a Twitter/X data archive
+type archiveTweet struct {
+	ID          string `json:"id_str"`
+	FullText    string `json:"full_text"`
+	CreatedAt   string `json:"created_at"`
+	InReplyToID string `json:"in_reply_to_status_id_str"`
+}
+
+// ImportArchive imports tweets.js of a Twitter/X data archive. Archives hold the tweets of their owner
+// only and do not name them, author is username or username:id, the ID is looked up when not given.
+func (c *CSVImporter) ImportArchive(path string, author string) (*ImportResult, error) {
+	c.startedAt = time.Now()
+	tweetsData, rowErrors, err := c.parseArchive(path, author)
+	if err != nil {
+		return nil, err
+	}
+	logImportRowErrors(rowErrors)
+	return c.ImportTweets(tweetsData), nil
+}
+
+func (c *CSVImporter) parseArchive(path string, author string) ([]CSVTweetData, []string, error) {
+	username, userID, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(author), "@"), ":")
+	if username == "" {
+		return nil, nil, fmt.Errorf("archives do not name their author, pass author:username or author:username:id")
+	}
+	if userID == "" {
+		user, err := c.dbService.GetUserByUsername(username)
+		if err != nil {
+			return nil, nil, fmt.Errorf("@%s is not in the database, pass author:%s:<user id>", username, username)
+		}
+		username, userID = user.Username, user.ID
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	// tweets.js assigns the array to a variable: window.YTD.tweets.part0 = [...]
+	if start := bytes.IndexByte(content, '['); start > 0 && bytes.HasPrefix(bytes.TrimSpace(content), []byte("window.")) {
+		content = content[start:]
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(content, &items); err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var tweetsData []CSVTweetData
+	var rowErrors []string
+	for i, item := range items {
+		// Current archives wrap each tweet in {"tweet": {...}}, older ones do not
+		var wrapped struct {
+			Tweet *archiveTweet `json:"tweet"`
+		}
+		if err := json.Unmarshal(item, &wrapped); err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		tweet := wrapped.Tweet
+		if tweet == nil {
+			tweet = &archiveTweet{}
+			json.Unmarshal(item, tweet)
+		}
+		if tweet.ID == "" {
+			rowErrors = append(rowErrors, fmt.Sprintf("archive item %d: no id_str", i+1))
+			continue
+		}
+		if strings.HasPrefix(tweet.FullText, "RT @") {
+			continue
+		}
+		tweetsData = append(tweetsData, CSVTweetData{
+			AuthorUsername: username,
+			TweetID:        tweet.ID,
+			AuthorID:       userID,
+			Date:           tweet.CreatedAt,
+			ReplyToID:      tweet.InReplyToID,
+			Text:           tweet.FullText,
+		})
+	}
+	if len(tweetsData) == 0 {
+		return nil, nil, fmt.Errorf("no tweets found in the archive")
+	}
+	return tweetsData, rowErrors, nil
+}
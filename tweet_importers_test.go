@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeImportFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestCSVImporter_TwitterAPIDump(t *testing.T) {
+	db := setupTestDB(t)
+	// One response per line, the reply comes before its parent and a retweet is left out
+	path := writeImportFile(t, "dump.json", `{"status":"success","tweets":[{"id":"2","text":"reply","inReplyToId":"1","createdAt":"Tue Jan 03 10:00:00 +0000 2023","author":{"id":"u2","userName":"bob"}}]}
+{"status":"success","data":{"tweets":[{"id":"1","text":"post","createdAt":"Mon Jan 02 10:00:00 +0000 2023","author":{"id":"u1","userName":"alice"}},{"id":"3","text":"RT","author":{"id":"u1","userName":"alice"},"retweeted_tweet":{"id":"9"}}]}}
+[{"id":"4","text":"orphan","inReplyToId":"404","author":{"id":"u2","userName":"bob"}}]`)
+
+	format, err := detectImportFormat(path)
+	require.NoError(t, err)
+	assert.Equal(t, IMPORT_FORMAT_TWITTERAPI, format)
+
+	result, err := NewCSVImporter(db).ImportFile(path, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.OriginalTweets)
+	assert.Equal(t, 1, result.ReplyTweets)
+	assert.Equal(t, 1, result.SkippedTweets)
+	reply, err := db.GetTweet("2")
+	require.NoError(t, err)
+	assert.Equal(t, "1", reply.InReplyToID)
+	assert.False(t, db.TweetExists("3"))
+	assert.True(t, db.UserExists("u2"))
+
+	// Imported again, everything is deduplicated
+	result, err = NewCSVImporter(db).ImportFile(path, ImportOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, result.TotalProcessed)
+}
+
+func TestCSVImporter_Archive(t *testing.T) {
+	db := setupTestDB(t)
+	path := writeImportFile(t, "tweets.js", `window.YTD.tweets.part0 = [
+  {"tweet": {"id_str": "10", "full_text": "gm", "created_at": "Wed Oct 10 20:19:24 +0000 2018"}},
+  {"tweet": {"id_str": "11", "full_text": "thread", "created_at": "Wed Oct 10 20:20:24 +0000 2018", "in_reply_to_status_id_str": "10"}},
+  {"tweet": {"id_str": "12", "full_text": "RT @someone: hi", "created_at": "Wed Oct 10 20:21:24 +0000 2018"}}
+]`)
+
+	_, err := NewCSVImporter(db).ImportFile(path, ImportOptions{})
+	assert.ErrorContains(t, err, "author:username")
+	_, err = NewCSVImporter(db).ImportFile(path, ImportOptions{Author: "carol"})
+	assert.ErrorContains(t, err, "author:carol:<user id>")
+
+	result, err := NewCSVImporter(db).ImportFile(path, ImportOptions{Author: "@carol:u3"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.OriginalTweets)
+	assert.Equal(t, 1, result.ReplyTweets)
+	tweet, err := db.GetTweet("11")
+	require.NoError(t, err)
+	assert.Equal(t, "u3", tweet.UserID)
+	assert.Equal(t, 2018, tweet.CreatedAt.Year())
+	assert.False(t, db.TweetExists("12"))
+
+	// A .json archive is told apart from a twitterapi dump by its fields
+	format, err := detectImportFormat(writeImportFile(t, "tweets.json", `[{"tweet": {"id_str": "10", "full_text": "gm"}}]`))
+	require.NoError(t, err)
+	assert.Equal(t, IMPORT_FORMAT_ARCHIVE, format)
+}
+
+func TestParseImportArgs(t *testing.T) {
+	options, err := parseImportArgs([]string{"format:Archive", "author:carol:u3"})
+	require.NoError(t, err)
+	assert.Equal(t, ImportOptions{Format: IMPORT_FORMAT_ARCHIVE, Author: "carol:u3"}, options)
+	options, err = parseImportArgs([]string{"backfill"})
+	require.NoError(t, err)
+	assert.Equal(t, IMPORT_BACKFILL_DEFAULT_LIMIT, options.Backfill)
+	options, err = parseImportArgs([]string{"backfill:50"})
+	require.NoError(t, err)
+	assert.Equal(t, 50, options.Backfill)
+	_, err = parseImportArgs([]string{"backfill:none"})
+	assert.Error(t, err)
+	_, err = parseImportArgs([]string{"format:xml"})
+	assert.Error(t, err)
+	_, err = parseImportArgs([]string{"--force"})
+	assert.Error(t, err)
+}
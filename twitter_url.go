@@ -0,0 +1,51 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	tweetURLRegex    = regexp.MustCompile(`^(?:https?://)?(?:www\.|mobile\.)?(?:x|twitter)\.com/(\w{1,15})/status(?:es)?/(\d+)`)
+	webTweetURLRegex = regexp.MustCompile(`^(?:https?://)?(?:www\.|mobile\.)?(?:x|twitter)\.com/i/(?:web/)?status/(\d+)`)
+	profileURLRegex  = regexp.MustCompile(`^(?:https?://)?(?:www\.|mobile\.)?(?:x|twitter)\.com/(\w{1,15})/?(?:[?#].*)?$`)
+)
+
+// usernameCommands accept "/command <link>" as well as "/command_username"
+var usernameCommands = map[string]bool{
+	"/analyze":        true,
+	"/history":        true,
+	"/ticker_history": true,
+	"/export":         true,
+	"/cache":          true,
+	"/graph":          true,
+}
+
+// parseTwitterReference turns tweet links, profile links and @handles into a username
+// and optional tweet ID. Links like x.com/i/web/status/123 carry no username.
+// Anything else is returned unchanged as the username, so plain user IDs keep working.
+func parseTwitterReference(input string) (username string, tweetID string) {
+	input = strings.TrimSpace(input)
+
+	if match := tweetURLRegex.FindStringSubmatch(input); match != nil {
+		return match[1], match[2]
+	}
+	if match := webTweetURLRegex.FindStringSubmatch(input); match != nil {
+		return "", match[1]
+	}
+	if match := profileURLRegex.FindStringSubmatch(input); match != nil && match[1] != "i" {
+		return match[1], ""
+	}
+	return strings.TrimPrefix(input, "@"), ""
+}
+
+// resolveTwitterReference parses the input and looks up the author of links without a username
+func (b *BotController) resolveTwitterReference(input string) (username string, tweetID string) {
+	username, tweetID = parseTwitterReference(input)
+	if username == "" && tweetID != "" {
+		if tweet, err := b.dbService.GetTweet(tweetID); err == nil {
+			username = tweet.Username
+		}
+	}
+	return username, tweetID
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTwitterReference(t *testing.T) {
+	tests := []struct {
+		input    string
+		username string
+		tweetID  string
+	}{
+		{"https://x.com/alice/status/1234567890", "alice", "1234567890"},
+		{"https://twitter.com/Alice_1/status/42?s=20", "Alice_1", "42"},
+		{"mobile.twitter.com/bob/statuses/7", "bob", "7"},
+		{"https://x.com/i/web/status/99", "", "99"},
+		{"https://x.com/carol", "carol", ""},
+		{"https://www.x.com/carol/?lang=en", "carol", ""},
+		{"@dave", "dave", ""},
+		{"123456789", "123456789", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			username, tweetID := parseTwitterReference(tt.input)
+			assert.Equal(t, tt.username, username)
+			assert.Equal(t, tt.tweetID, tweetID)
+		})
+	}
+}
+
+func TestBotController_TwitterLinksInCommands(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[1] = true
+
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "alice", Name: "Alice"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "555", Text: "wen moon", CreatedAt: time.Now(), UserID: "u1", Username: "alice"}))
+
+	t.Run("Tweet link without username is resolved from the database", func(t *testing.T) {
+		username, tweetID := bot.resolveTwitterReference("https://x.com/i/web/status/555")
+		assert.Equal(t, "alice", username)
+		assert.Equal(t, "555", tweetID)
+	})
+
+	t.Run("Space separated link is routed like the underscore command", func(t *testing.T) {
+		bot.handleUpdate(newTestUpdate(1, "/history https://x.com/alice/status/555"))
+		assert.Eventually(t, func() bool {
+			return len(transport.sentMessages()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Contains(t, transport.sentMessages()[0].Text, "wen moon")
+	})
+}
@@ -0,0 +1,117 @@
+package twitterapi
+
+import (
+	"strings"
+	"time"
+)
+
+// CommunityTweetsFetcher is the part of the API a CommunityStream needs, implemented by TwitterAPIService
+type CommunityTweetsFetcher interface {
+	GetCommunityTweets(req CommunityTweetsRequest) (*CommunityTweetsResponse, error)
+}
+
+// CommunityStream polls a community incrementally: every poll returns the first page plus, when a
+// burst pushed older posts off it, the further pages needed to reach the newest tweet seen before
+// (since_id), so nothing is skipped between polls.
+type CommunityStream struct {
+	api         CommunityTweetsFetcher
+	communityID string
+	maxPages    int
+	sinceID     string
+}
+
+func NewCommunityStream(api CommunityTweetsFetcher, communityID string, maxPages int) *CommunityStream {
+	if maxPages < 1 {
+		maxPages = 1
+	}
+	return &CommunityStream{api: api, communityID: communityID, maxPages: maxPages}
+}
+
+// SinceID returns the newest tweet ID seen so far
+func (s *CommunityStream) SinceID() string {
+	return s.sinceID
+}
+
+// Poll fetches the community feed. It returns every tweet of the first page, so callers can track
+// reply counts of known posts, followed by the newer tweets found on further pages, and how many of
+// the returned tweets are newer than the previous poll.
+func (s *CommunityStream) Poll() ([]Tweet, int, error) {
+	var tweets []Tweet
+	newTweets := 0
+	newest := s.sinceID
+	cursor := ""
+	for page := 0; page < s.maxPages; page++ {
+		response, err := s.api.GetCommunityTweets(CommunityTweetsRequest{CommunityID: s.communityID, Cursor: cursor})
+		if err != nil {
+			if page == 0 {
+				return nil, 0, err
+			}
+			break
+		}
+
+		reachedSeen := false
+		for _, tweet := range response.Tweets {
+			isNew := s.sinceID == "" || CompareTweetIDs(tweet.Id, s.sinceID) > 0
+			if isNew {
+				newTweets++
+				if CompareTweetIDs(tweet.Id, newest) > 0 {
+					newest = tweet.Id
+				}
+			} else {
+				reachedSeen = true
+			}
+			// Further pages only contribute what was not seen yet
+			if page == 0 || isNew {
+				tweets = append(tweets, tweet)
+			}
+		}
+
+		// The first poll only establishes the since_id, pinned posts can make a page look all new
+		if s.sinceID == "" || reachedSeen || response.NextCursor == "" || len(response.Tweets) == 0 {
+			break
+		}
+		cursor = response.NextCursor
+	}
+	s.sinceID = newest
+	return tweets, newTweets, nil
+}
+
+// CompareTweetIDs compares two numeric tweet IDs (snowflakes), returning -1, 0 or 1
+func CompareTweetIDs(a string, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// AdaptiveInterval is the wait between polls: it drops to Min as soon as there is activity and
+// doubles after every quiet poll, up to Max
+type AdaptiveInterval struct {
+	Min     time.Duration
+	Max     time.Duration
+	current time.Duration
+}
+
+func NewAdaptiveInterval(min time.Duration, max time.Duration) *AdaptiveInterval {
+	if max < min {
+		max = min
+	}
+	return &AdaptiveInterval{Min: min, Max: max, current: min}
+}
+
+// Next returns the wait before the next poll given whether the last poll saw activity
+func (a *AdaptiveInterval) Next(activity bool) time.Duration {
+	if activity {
+		a.current = a.Min
+		return a.current
+	}
+	a.current *= 2
+	if a.current > a.Max {
+		a.current = a.Max
+	}
+	return a.current
+}
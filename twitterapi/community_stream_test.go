@@ -0,0 +1,82 @@
+package twitterapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCommunityFeed serves a newest-first feed in pages of pageSize
+type fakeCommunityFeed struct {
+	ids      []string
+	pageSize int
+	requests int
+}
+
+func (f *fakeCommunityFeed) GetCommunityTweets(req CommunityTweetsRequest) (*CommunityTweetsResponse, error) {
+	f.requests++
+	start := 0
+	if req.Cursor != "" {
+		for i, id := range f.ids {
+			if id == req.Cursor {
+				start = i
+			}
+		}
+	}
+	end := min(start+f.pageSize, len(f.ids))
+	response := &CommunityTweetsResponse{}
+	for _, id := range f.ids[start:end] {
+		response.Tweets = append(response.Tweets, Tweet{Id: id})
+	}
+	if end < len(f.ids) {
+		response.NextCursor = f.ids[end]
+	}
+	return response, nil
+}
+
+func tweetIDs(tweets []Tweet) []string {
+	var ids []string
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.Id)
+	}
+	return ids
+}
+
+func TestCommunityStream_Poll(t *testing.T) {
+	feed := &fakeCommunityFeed{ids: []string{"105", "104", "103", "102", "101"}, pageSize: 2}
+	stream := NewCommunityStream(feed, "c1", 5)
+
+	tweets, newTweets, err := stream.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"105", "104"}, tweetIDs(tweets), "the first poll only reads the first page")
+	assert.Equal(t, 2, newTweets)
+	assert.Equal(t, "105", stream.SinceID())
+
+	// A burst of 5 posts pushes the last seen one to the third page
+	feed.ids = append([]string{"1010", "109", "108", "107", "106"}, feed.ids...)
+	feed.requests = 0
+	tweets, newTweets, err = stream.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1010", "109", "108", "107", "106"}, tweetIDs(tweets))
+	assert.Equal(t, 5, newTweets)
+	assert.Equal(t, 3, feed.requests)
+	assert.Equal(t, "1010", stream.SinceID(), "IDs compare numerically, not as strings")
+
+	// Quiet poll: known posts of the first page come back for reply tracking, nothing is new
+	tweets, newTweets, err = stream.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1010", "109"}, tweetIDs(tweets))
+	assert.Zero(t, newTweets)
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	interval := NewAdaptiveInterval(5*time.Second, 60*time.Second)
+	assert.Equal(t, 10*time.Second, interval.Next(false))
+	assert.Equal(t, 20*time.Second, interval.Next(false))
+	assert.Equal(t, 40*time.Second, interval.Next(false))
+	assert.Equal(t, 60*time.Second, interval.Next(false))
+	assert.Equal(t, 60*time.Second, interval.Next(false))
+	assert.Equal(t, 5*time.Second, interval.Next(true))
+}
@@ -0,0 +1,331 @@
+package twitterapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	REQUEST_LOG_MAX_FILE_BYTES = 10 * 1024 * 1024
+	REQUEST_LOG_MAX_FILES      = 5
+	REQUEST_LOG_FILE_PREFIX    = "requests-"
+	REDACTED                   = "[REDACTED]"
+)
+
+// Headers and query parameters that never reach the request log
+var secretNames = map[string]bool{
+	"authorization":  true,
+	"x-api-key":      true,
+	"x-goog-api-key": true,
+	"api_key":        true,
+	"apikey":         true,
+	"key":            true,
+	"token":          true,
+}
+
+// Exchange is one logged request-response pair, also the on-disk format of a fixture
+type Exchange struct {
+	ID              string              `json:"id"`
+	Provider        string              `json:"provider"`
+	Time            time.Time           `json:"time"`
+	DurationMs      int64               `json:"duration_ms"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	RequestHeaders  map[string][]string `json:"request_headers,omitempty"`
+	RequestBody     string              `json:"request_body,omitempty"`
+	StatusCode      int                 `json:"status_code"`
+	ResponseHeaders map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody    string              `json:"response_body,omitempty"`
+	Error           string              `json:"error,omitempty"`
+}
+
+// RequestLogger appends exchanges to JSONL files in a directory, starting a new file once the
+// current one reaches maxFileBytes and keeping the newest maxFiles files
+type RequestLogger struct {
+	dir          string
+	maxFileBytes int64
+	maxFiles     int
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	sequence     int64
+}
+
+func NewRequestLogger(dir string, maxFileBytes int64, maxFiles int) (*RequestLogger, error) {
+	if maxFileBytes <= 0 {
+		maxFileBytes = REQUEST_LOG_MAX_FILE_BYTES
+	}
+	if maxFiles <= 0 {
+		maxFiles = REQUEST_LOG_MAX_FILES
+	}
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("error create request log dir: %w", err)
+	}
+	return &RequestLogger{dir: dir, maxFileBytes: maxFileBytes, maxFiles: maxFiles}, nil
+}
+
+// Wrap returns a transport that logs every exchange made through next under the provider name
+func (l *RequestLogger) Wrap(provider string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingTransport{logger: l, provider: provider, next: next}
+}
+
+func (l *RequestLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *RequestLogger) write(exchange *Exchange) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sequence++
+	exchange.ID = fmt.Sprintf("%s-%d-%d", exchange.Provider, exchange.Time.UnixNano(), l.sequence)
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if l.file == nil || l.size+int64(len(line)) > l.maxFileBytes {
+		err = l.rotate(exchange.Time)
+		if err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+func (l *RequestLogger) rotate(now time.Time) error {
+	if l.file != nil {
+		l.file.Close()
+	}
+	name := filepath.Join(l.dir, fmt.Sprintf("%s%s-%d.jsonl", REQUEST_LOG_FILE_PREFIX, now.UTC().Format("20060102T150405"), l.sequence))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		l.file = nil
+		return fmt.Errorf("error open request log: %w", err)
+	}
+	l.file = file
+	l.size = 0
+
+	files, err := RequestLogFiles(l.dir)
+	if err != nil {
+		return nil
+	}
+	for len(files) > l.maxFiles {
+		os.Remove(files[0])
+		files = files[1:]
+	}
+	return nil
+}
+
+// RequestLogFiles lists the log files in a directory, oldest first
+func RequestLogFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, REQUEST_LOG_FILE_PREFIX+"*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		infoI, errI := os.Stat(files[i])
+		infoJ, errJ := os.Stat(files[j])
+		if errI != nil || errJ != nil || infoI.ModTime().Equal(infoJ.ModTime()) {
+			return files[i] < files[j]
+		}
+		return infoI.ModTime().Before(infoJ.ModTime())
+	})
+	return files, nil
+}
+
+// LoadExchanges reads every exchange from a log file or a directory of log files
+func LoadExchanges(path string) ([]Exchange, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		files, err = RequestLogFiles(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var exchanges []Exchange
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+		for scanner.Scan() {
+			var exchange Exchange
+			if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("error parse %s: %w", name, err)
+			}
+			exchanges = append(exchanges, exchange)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return exchanges, nil
+}
+
+// WriteFixture saves an exchange as an indented JSON fixture file in dir and returns its path
+func WriteFixture(dir string, exchange Exchange) (string, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, exchange.ID+".json")
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// ReplayTransport answers requests from recorded fixtures instead of the network. Requests are
+// matched on method, path and query; each fixture is served once, in recording order.
+type ReplayTransport struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+func NewReplayTransport(fixturePaths ...string) (*ReplayTransport, error) {
+	replay := &ReplayTransport{}
+	for _, path := range fixturePaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(data, &exchange); err != nil {
+			return nil, fmt.Errorf("error parse fixture %s: %w", path, err)
+		}
+		replay.exchanges = append(replay.exchanges, exchange)
+	}
+	return replay, nil
+}
+
+func (r *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := req.Method + " " + replayKey(redactURL(req.URL))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, exchange := range r.exchanges {
+		recorded, err := url.Parse(exchange.URL)
+		if err != nil || exchange.Method+" "+replayKey(recorded) != key {
+			continue
+		}
+		r.exchanges = append(r.exchanges[:i], r.exchanges[i+1:]...)
+		if exchange.Error != "" {
+			return nil, fmt.Errorf("%s", exchange.Error)
+		}
+		return &http.Response{
+			StatusCode: exchange.StatusCode,
+			Status:     fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+			Header:     http.Header(exchange.ResponseHeaders),
+			Body:       io.NopCloser(strings.NewReader(exchange.ResponseBody)),
+			Request:    req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no fixture for %s", key)
+}
+
+func replayKey(u *url.URL) string {
+	return u.Path + "?" + u.Query().Encode()
+}
+
+type loggingTransport struct {
+	logger   *RequestLogger
+	provider string
+	next     http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &Exchange{
+		Provider:       t.provider,
+		Time:           time.Now(),
+		Method:         req.Method,
+		URL:            redactURL(req.URL).String(),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			exchange.RequestBody = string(data)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	exchange.DurationMs = time.Since(exchange.Time).Milliseconds()
+	if err != nil {
+		exchange.Error = err.Error()
+		t.logger.write(exchange)
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	exchange.StatusCode = resp.StatusCode
+	exchange.ResponseHeaders = redactHeaders(resp.Header)
+	exchange.ResponseBody = string(data)
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	t.logger.write(exchange)
+	return resp, nil
+}
+
+func redactHeaders(headers http.Header) map[string][]string {
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if secretNames[strings.ToLower(name)] {
+			values = []string{REDACTED}
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+func redactURL(u *url.URL) *url.URL {
+	redacted := *u
+	redacted.User = nil
+	query := u.Query()
+	for name := range query {
+		if secretNames[strings.ToLower(name)] {
+			query.Set(name, REDACTED)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return &redacted
+}
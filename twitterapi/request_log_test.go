@@ -0,0 +1,89 @@
+package twitterapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger_RecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"1","userName":"booster"}],"has_next_page":false,"status":"success"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	logger, err := NewRequestLogger(dir, 0, 0)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	api := NewTwitterAPIService("super-secret", server.URL, "")
+	api.LogRequests(logger)
+	resp, err := api.GetTweetRetweeters(TweetRetweetersRequest{TweetID: "555"})
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+
+	exchanges, err := LoadExchanges(dir)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	exchange := exchanges[0]
+	assert.Equal(t, "twitter", exchange.Provider)
+	assert.Equal(t, http.StatusOK, exchange.StatusCode)
+	assert.Contains(t, exchange.URL, "tweetId=555")
+	assert.Contains(t, exchange.ResponseBody, "booster")
+	assert.Equal(t, []string{REDACTED}, exchange.RequestHeaders["X-Api-Key"])
+
+	raw, err := os.ReadFile(mustLogFiles(t, dir)[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "super-secret")
+
+	// The fixture replays without the server
+	fixture, err := WriteFixture(t.TempDir(), exchange)
+	require.NoError(t, err)
+	replay, err := NewReplayTransport(fixture)
+	require.NoError(t, err)
+	server.Close()
+
+	api.httpClient.Transport = replay
+	resp, err = api.GetTweetRetweeters(TweetRetweetersRequest{TweetID: "555"})
+	require.NoError(t, err)
+	assert.Equal(t, "booster", resp.Users[0].UserName)
+
+	_, err = api.GetTweetRetweeters(TweetRetweetersRequest{TweetID: "555"})
+	assert.ErrorContains(t, err, "no fixture")
+}
+
+func TestRequestLogger_Rotation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 500)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	logger, err := NewRequestLogger(dir, 1000, 2)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	client := &http.Client{Transport: logger.Wrap("twitter", nil)}
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Len(t, mustLogFiles(t, dir), 2)
+	exchanges, err := LoadExchanges(dir)
+	require.NoError(t, err)
+	assert.Len(t, exchanges, 2)
+}
+
+func mustLogFiles(t *testing.T, dir string) []string {
+	files, err := RequestLogFiles(dir)
+	require.NoError(t, err)
+	return files
+}
@@ -0,0 +1,42 @@
+package twitterapi
+
+import (
+	"sync"
+	"time"
+)
+
+// APIStatus is the outcome of the latest requests to an API, reported by the health checks
+type APIStatus struct {
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+}
+
+// Failing is true when the latest request failed
+func (s APIStatus) Failing() bool {
+	return s.LastFailure.After(s.LastSuccess)
+}
+
+// StatusTracker records request outcomes from several goroutines
+type StatusTracker struct {
+	mu     sync.Mutex
+	status APIStatus
+}
+
+// Record stores the outcome of a request, nil is a success
+func (t *StatusTracker) Record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err == nil {
+		t.status.LastSuccess = time.Now()
+		return
+	}
+	t.status.LastFailure = time.Now()
+	t.status.LastError = err.Error()
+}
+
+func (t *StatusTracker) Status() APIStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
@@ -20,6 +20,10 @@ type UserLastTweetsRequest struct {
 	IncludeReplies bool
 }
 
+type UserInfoRequest struct {
+	UserName string
+}
+
 type UserFollowersRequest struct {
 	UserName string
 	Cursor   string
@@ -30,6 +34,10 @@ type UserFollowingsRequest struct {
 	Cursor   string
 	PageSize int
 }
+type TweetRetweetersRequest struct {
+	TweetID string
+	Cursor  string
+}
 
 type NewMessage struct {
 	TweetID      string
@@ -40,6 +48,7 @@ type NewMessage struct {
 		ID       string
 	}
 	Text        string
+	Lang        string // language X detected for the tweet, may be empty or "und"
 	CreatedAt   string
 	ParentTweet struct {
 		ID     string
@@ -58,6 +67,17 @@ type NewMessage struct {
 	ForceNotification bool
 	TaskID            string // For tracking manual analysis progress
 	TelegramChatID    int64  // Optional: if set, send notification only to this chat
+	DiscordChannelID  string // Optional: if set, send notification only to this Discord channel
+	NotificationRoute string // Optional: origin (default), broadcast or chat, how the two above are honored
+	RequestSource     string // Optional: external tool that submitted the analysis, shown in the result
+	RequestReason     string // Optional: why the external tool flagged the user
+	FocusTweet        bool   // Optional: the analysis was asked about this exact tweet, the verdict is reported for it
+	HistoryWindow     string // Optional: user history given to the second step, the configured default when empty
+	CommunityID       string // Community the message was posted in, empty for manual analyses
+	Ticker            string // Ticker of that community
+	CommunityContext  string // Optional: extra prompt context configured for the community
+	Watched           bool   // The author is on a watchlist: analyzed without the cache and summarized to the watching chats
+	Reanalysis        bool   // Scheduled re-check of a known FUD user: the verdict is compared with the previous one, no alert is sent
 }
 
 const (
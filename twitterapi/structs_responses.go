@@ -4,6 +4,7 @@ type APIResponse struct {
 	StatusCode int                 `json:"status_code"`
 	Headers    map[string][]string `json:"headers"`
 	RawBody    []byte              `json:"raw_body"`
+	Retries    int                 `json:"retries"` // transient failures retried before this response
 }
 
 type Author struct {
@@ -88,6 +89,7 @@ type Tweet struct {
 	InReplyToUserId   interface{} `json:"inReplyToUserId"`
 	InReplyToUsername interface{} `json:"inReplyToUsername"`
 	Author            Author      `json:"author"`
+	RetweetedTweet    *Tweet      `json:"retweeted_tweet,omitempty"` // set on retweets
 	ExtendedEntities  struct {
 		Media []struct {
 			AllowDownloadStatus struct {
@@ -223,6 +225,11 @@ type User struct {
 	ProfileImageUrlHttps string  `json:"profile_image_url_https"`
 	CanDm                bool    `json:"can_dm"`
 }
+type UserInfoResponse struct {
+	Data   Author `json:"data"`
+	Status string `json:"status"`
+	Msg    string `json:"msg"`
+}
 type UserFollowersResponse struct {
 	Followers   []User `json:"followers"`
 	HasNextPage bool   `json:"has_next_page"`
@@ -231,6 +238,14 @@ type UserFollowersResponse struct {
 	Msg         string `json:"msg"`
 	Code        int    `json:"code"`
 }
+type TweetRetweetersResponse struct {
+	Users       []User `json:"users"`
+	HasNextPage bool   `json:"has_next_page"`
+	NextCursor  string `json:"next_cursor"`
+	Status      string `json:"status"`
+	Msg         string `json:"msg"`
+	Code        int    `json:"code"`
+}
 type UserFollowingsResponse struct {
 	Followings  []User `json:"followings"`
 	HasNextPage bool   `json:"has_next_page"`
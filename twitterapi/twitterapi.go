@@ -4,13 +4,14 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/grutapig/hackaton/httpretry"
 )
 
 type TwitterAPIService struct {
@@ -20,6 +21,9 @@ type TwitterAPIService struct {
 	tweetStates    map[string]*TweetState
 	tweetMutex     sync.RWMutex
 	baseUrl        string
+	requestHook    func(endpoint string)
+	retry          httpretry.RetryPolicy
+	status         StatusTracker
 }
 
 func NewTwitterAPIService(apiKey string, baseUrl string, proxyDSN string) *TwitterAPIService {
@@ -47,9 +51,25 @@ func NewTwitterAPIService(apiKey string, baseUrl string, proxyDSN string) *Twitt
 		},
 		existingTweets: make(map[string]bool),
 		tweetStates:    make(map[string]*TweetState),
+		retry:          httpretry.NewRetryPolicy("twitter"),
 	}
 }
 
+// SetRequestHook registers a function called with the endpoint path of every request, used for usage stats
+func (s *TwitterAPIService) SetRequestHook(hook func(endpoint string)) {
+	s.requestHook = hook
+}
+
+// Status reports whether the latest request reached the API, for health checks
+func (s *TwitterAPIService) Status() APIStatus {
+	return s.status.Status()
+}
+
+// LogRequests records every request made by the service in the request log
+func (s *TwitterAPIService) LogRequests(logger *RequestLogger) {
+	s.httpClient.Transport = logger.Wrap("twitter", s.httpClient.Transport)
+}
+
 func (s *TwitterAPIService) makeRequest(uri string, params map[string]string) (*APIResponse, error) {
 	req, err := http.NewRequest("GET", uri, nil)
 	if err != nil {
@@ -70,21 +90,25 @@ func (s *TwitterAPIService) makeRequest(uri string, params map[string]string) (*
 	}
 
 	req.URL.RawQuery = q.Encode()
-	resp, err := s.httpClient.Do(req)
+	if s.requestHook != nil {
+		s.requestHook(strings.TrimPrefix(uri, s.baseUrl))
+	}
+	resp, bodyBytes, retries, err := s.retry.Do(s.httpClient, func() (*http.Request, error) { return req, nil })
 	if err != nil {
+		s.status.Record(err)
 		return nil, fmt.Errorf("error send request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error read response: %w", err)
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		s.status.Record(fmt.Errorf("status %d", resp.StatusCode))
+	} else {
+		s.status.Record(nil)
 	}
 
 	return &APIResponse{
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
 		RawBody:    bodyBytes,
+		Retries:    retries,
 	}, nil
 }
 
@@ -174,6 +198,25 @@ func (s *TwitterAPIService) GetTweetThreadContext(req TweetRepliesRequest) (*Twe
 	err = json.Unmarshal(response.RawBody, &tweetRepliesResponse)
 	return &tweetRepliesResponse, err
 }
+func (s *TwitterAPIService) GetUserInfo(req UserInfoRequest) (*UserInfoResponse, error) {
+	uri := s.baseUrl + "/twitter/user/info"
+
+	params := map[string]string{
+		"userName": req.UserName,
+	}
+
+	response, err := s.makeRequest(uri, params)
+	if err != nil {
+		return nil, fmt.Errorf("error user info: %w", err)
+	}
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("error user info, status non 200: %s", string(response.RawBody))
+	}
+	userInfoResponse := UserInfoResponse{}
+	err = json.Unmarshal(response.RawBody, &userInfoResponse)
+	return &userInfoResponse, err
+}
+
 func (s *TwitterAPIService) GetUserFollowers(req UserFollowersRequest) (*UserFollowersResponse, error) {
 	uri := s.baseUrl + "/twitter/user/followers"
 
@@ -216,6 +259,26 @@ func (s *TwitterAPIService) GetUserFollowings(req UserFollowingsRequest) (*UserF
 	return &userFollowingsResponse, err
 }
 
+func (s *TwitterAPIService) GetTweetRetweeters(req TweetRetweetersRequest) (*TweetRetweetersResponse, error) {
+	uri := s.baseUrl + "/twitter/tweet/retweeters"
+
+	params := map[string]string{
+		"tweetId": req.TweetID,
+		"cursor":  req.Cursor,
+	}
+
+	response, err := s.makeRequest(uri, params)
+	if err != nil {
+		return nil, fmt.Errorf("error retweeters: %w", err)
+	}
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("error retweeters, status not 200: %s", string(response.RawBody))
+	}
+	tweetRetweetersResponse := TweetRetweetersResponse{}
+	err = json.Unmarshal(response.RawBody, &tweetRetweetersResponse)
+	return &tweetRetweetersResponse, err
+}
+
 func (s *TwitterAPIService) GetTweetsByIds(tweetIds []string) (*TweetsByIdsResponse, error) {
 	uri := s.baseUrl + "/twitter/tweets"
 
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+// Usage stat categories
+const (
+	USAGE_TWEETS  = "tweets"      // tweets stored, by source
+	USAGE_LLM     = "llm"         // Claude calls and tokens, by step
+	USAGE_TWITTER = "twitter_api" // Twitter API calls, by endpoint
+	USAGE_CACHE   = "cache"       // second step cache lookups, hit or miss
+)
+
+// Pipeline steps Claude calls are counted under
+const (
+	USAGE_STEP_FIRST       = "first_step"
+	USAGE_STEP_KNOWN_FUD   = "known_fud_check"
+	USAGE_STEP_SECOND      = "second_step"
+	USAGE_CACHE_HIT        = "hit"
+	USAGE_CACHE_MISS       = "miss"
+	USAGE_MAX_PERIOD_DAYS  = 90
+	USAGE_DEFAULT_DAYS     = 1
+	USAGE_DAILY_TABLE_DAYS = 14
+)
+
+// Per-user cost attribution, see /costs
+const (
+	COSTS_DEFAULT_DAYS      = 7
+	COSTS_USER_DEFAULT_DAYS = 30
+	COSTS_TOP_USERS         = 20
+	COSTS_OUTLIER_FACTOR    = 3 // users costing this many times the per-user average are marked
+)
+
+type usageTotals struct {
+	Count        int64
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// parseUsagePeriod reads "today", "7d" or "7" into a number of days including today
+func parseUsagePeriod(args []string) (int, error) {
+	if len(args) == 0 || strings.EqualFold(args[0], "today") {
+		return USAGE_DEFAULT_DAYS, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(args[0]), "d"))
+	if err != nil || days < 1 || days > USAGE_MAX_PERIOD_DAYS {
+		return 0, fmt.Errorf("invalid period %s, use today or 1d to %dd", args[0], USAGE_MAX_PERIOD_DAYS)
+	}
+	return days, nil
+}
+
+// handleUsageCommand processes /usage [period]
+func (b *BotController) handleUsageCommand(chatID int64, args []string) {
+	days, err := parseUsagePeriod(args)
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1)).Format(time.DateOnly)
+	stats, err := b.dbService.GetUsageStats(since)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading usage: %v", err))
+		return
+	}
+	b.SendMessage(chatID, b.formatUsageReport(stats, days))
+}
+
+func (b *BotController) formatUsageReport(stats []UsageStatModel, days int) string {
+	byCategory := make(map[string]map[string]*usageTotals)
+	byDay := make(map[string]map[string]*usageTotals)
+	add := func(totals map[string]map[string]*usageTotals, key string, name string, stat UsageStatModel) {
+		if totals[key] == nil {
+			totals[key] = make(map[string]*usageTotals)
+		}
+		if totals[key][name] == nil {
+			totals[key][name] = &usageTotals{}
+		}
+		totals[key][name].Count += stat.Count
+		totals[key][name].InputTokens += stat.InputTokens
+		totals[key][name].OutputTokens += stat.OutputTokens
+	}
+	for _, stat := range stats {
+		add(byCategory, stat.Category, stat.Name, stat)
+		add(byDay, stat.Day, stat.Category, stat)
+	}
+
+	var message strings.Builder
+	if days == 1 {
+		message.WriteString("📊 <b>Usage today</b> (UTC)\n")
+	} else {
+		message.WriteString(fmt.Sprintf("📊 <b>Usage, last %d days</b> (UTC)\n", days))
+	}
+	if len(stats) == 0 {
+		message.WriteString("\n📭 No activity recorded.")
+		return message.String()
+	}
+
+	tweets := sumUsage(byCategory[USAGE_TWEETS])
+	message.WriteString(fmt.Sprintf("\n📥 <b>Tweets ingested:</b> %d\n", tweets.Count))
+	writeUsageLines(&message, byCategory[USAGE_TWEETS], false)
+
+	llm := sumUsage(byCategory[USAGE_LLM])
+	message.WriteString(fmt.Sprintf("\n🤖 <b>LLM calls:</b> %d · tokens %d in / %d out\n", llm.Count, llm.InputTokens, llm.OutputTokens))
+	writeUsageLines(&message, byCategory[USAGE_LLM], true)
+
+	twitter := sumUsage(byCategory[USAGE_TWITTER])
+	message.WriteString(fmt.Sprintf("\n🐦 <b>Twitter API calls:</b> %d\n", twitter.Count))
+	writeUsageLines(&message, byCategory[USAGE_TWITTER], false)
+
+	hits := byCategory[USAGE_CACHE][USAGE_CACHE_HIT]
+	misses := byCategory[USAGE_CACHE][USAGE_CACHE_MISS]
+	var hitCount, missCount int64
+	if hits != nil {
+		hitCount = hits.Count
+	}
+	if misses != nil {
+		missCount = misses.Count
+	}
+	message.WriteString("\n💾 <b>Analysis cache:</b> ")
+	if hitCount+missCount == 0 {
+		message.WriteString("no lookups\n")
+	} else {
+		message.WriteString(fmt.Sprintf("%d hits / %d misses (%.0f%% hit ratio)\n", hitCount, missCount, float64(hitCount)*100/float64(hitCount+missCount)))
+	}
+
+	if days > 1 {
+		dayKeys := make([]string, 0, len(byDay))
+		for day := range byDay {
+			dayKeys = append(dayKeys, day)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(dayKeys)))
+		message.WriteString("\n📅 <b>Per day</b> (tweets · LLM calls · tokens · Twitter calls)\n")
+		for i, day := range dayKeys {
+			if i == USAGE_DAILY_TABLE_DAYS {
+				message.WriteString(fmt.Sprintf("… %d earlier days\n", len(dayKeys)-i))
+				break
+			}
+			dayLLM := byDay[day][USAGE_LLM]
+			if dayLLM == nil {
+				dayLLM = &usageTotals{}
+			}
+			message.WriteString(fmt.Sprintf("<code>%s</code> %d · %d · %d · %d\n", day, countOf(byDay[day][USAGE_TWEETS]), dayLLM.Count, dayLLM.InputTokens+dayLLM.OutputTokens, countOf(byDay[day][USAGE_TWITTER])))
+		}
+	}
+	return strings.TrimRight(message.String(), "\n")
+}
+
+func writeUsageLines(message *strings.Builder, totals map[string]*usageTotals, withTokens bool) {
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if totals[names[i]].Count != totals[names[j]].Count {
+			return totals[names[i]].Count > totals[names[j]].Count
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		label := name
+		if label == "" {
+			label = "other"
+		}
+		if withTokens {
+			message.WriteString(fmt.Sprintf("  • %s: %d · %d in / %d out\n", label, totals[name].Count, totals[name].InputTokens, totals[name].OutputTokens))
+		} else {
+			message.WriteString(fmt.Sprintf("  • %s: %d\n", label, totals[name].Count))
+		}
+	}
+}
+
+func sumUsage(totals map[string]*usageTotals) usageTotals {
+	sum := usageTotals{}
+	for _, total := range totals {
+		sum.Count += total.Count
+		sum.InputTokens += total.InputTokens
+		sum.OutputTokens += total.OutputTokens
+	}
+	return sum
+}
+
+func countOf(total *usageTotals) int64 {
+	if total == nil {
+		return 0
+	}
+	return total.Count
+}
+
+// recordUserUsage attributes the tokens and price of an analysis call to the analyzed user
+func recordUserUsage(dbService *DatabaseService, newMessage twitterapi.NewMessage, step string, resp *ClaudeMessageResponse) {
+	if dbService == nil || resp == nil || newMessage.Author.ID == "" {
+		return
+	}
+	dbService.RecordUserUsage(newMessage.Author.ID, newMessage.Author.UserName, step, int64(resp.Usage.InputTokens), int64(resp.Usage.OutputTokens))
+	recordAnalysisCost(dbService, newMessage, step, resp)
+}
+
+// handleCostsCommand processes /costs [period] (spend per command and most expensive users) and /costs_<username> [period]
+func (b *BotController) handleCostsCommand(chatID int64, command string, args []string) {
+	username := strings.TrimPrefix(strings.TrimPrefix(command, "/costs"), "_")
+	days := COSTS_DEFAULT_DAYS
+	if username != "" {
+		days = COSTS_USER_DEFAULT_DAYS
+	}
+	if len(args) > 0 {
+		var err error
+		days, err = parseUsagePeriod(args)
+		if err != nil {
+			b.SendMessage(chatID, "❌ "+err.Error())
+			return
+		}
+	}
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1)).Format(time.DateOnly)
+
+	if username == "" {
+		totals, err := b.dbService.GetUserUsageTotals(since)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading costs: %v", err))
+			return
+		}
+		spend, err := b.dbService.GetAnalysisCostTotals(time.Now().UTC().AddDate(0, 0, -(COSTS_OVERVIEW_DAYS - 1)).Format(time.DateOnly))
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error loading costs: %v", err))
+			return
+		}
+		b.SendMessage(chatID, formatCostsOverview(spend)+"\n\n"+formatCostsReport(totals, days))
+		return
+	}
+
+	username, _ = b.resolveTwitterReference(username)
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		if user, err = b.dbService.GetUser(username); err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ User not found: %s", html.EscapeString(username)))
+			return
+		}
+	}
+	stats, err := b.dbService.GetUserUsage(user.ID, since)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading costs: %v", err))
+		return
+	}
+	b.SendMessage(chatID, formatUserCosts(user.Username, stats, days))
+}
+
+func formatCostsPeriod(days int) string {
+	if days == 1 {
+		return "today"
+	}
+	return fmt.Sprintf("last %d days", days)
+}
+
+func formatCostsReport(totals []UserUsageTotal, days int) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("💸 <b>Analysis cost per user, %s</b> (UTC)\n", formatCostsPeriod(days)))
+	if len(totals) == 0 {
+		message.WriteString("\n📭 No analyses recorded.")
+		return message.String()
+	}
+
+	var calls, tokens int64
+	for _, total := range totals {
+		calls += total.Calls
+		tokens += total.InputTokens + total.OutputTokens
+	}
+	average := tokens / int64(len(totals))
+	message.WriteString(fmt.Sprintf("\n🤖 %d LLM calls · %d tokens on %d users\n\n", calls, tokens, len(totals)))
+
+	outliers := 0
+	for i, total := range totals {
+		if i == COSTS_TOP_USERS {
+			message.WriteString(fmt.Sprintf("… and %d more users\n", len(totals)-COSTS_TOP_USERS))
+			break
+		}
+		userTokens := total.InputTokens + total.OutputTokens
+		marker := ""
+		if len(totals) > 1 && userTokens > average*COSTS_OUTLIER_FACTOR {
+			marker = " ⚠️"
+			outliers++
+		}
+		share := 0.0
+		if tokens > 0 {
+			share = float64(userTokens) * 100 / float64(tokens)
+		}
+		message.WriteString(fmt.Sprintf("%d. @%s — %d calls (%d analyses) · %d tokens (%.0f%%)%s\n   /costs_%s\n",
+			i+1, html.EscapeString(total.Username), total.Calls, total.Analyses, userTokens, share, marker, total.Username))
+	}
+	if outliers > 0 {
+		message.WriteString(fmt.Sprintf("\n⚠️ Costs more than %d× the average of %d tokens per user", COSTS_OUTLIER_FACTOR, average))
+	}
+	return strings.TrimRight(message.String(), "\n")
+}
+
+func formatUserCosts(username string, stats []UserUsageModel, days int) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("💸 <b>Analysis cost of @%s, %s</b> (UTC)\n", html.EscapeString(username), formatCostsPeriod(days)))
+	if len(stats) == 0 {
+		message.WriteString("\n📭 No analyses recorded.")
+		return message.String()
+	}
+
+	bySteps := make(map[string]*usageTotals)
+	byDay := make(map[string]*usageTotals)
+	var activeDays []string
+	for _, stat := range stats {
+		if bySteps[stat.Step] == nil {
+			bySteps[stat.Step] = &usageTotals{}
+		}
+		if byDay[stat.Day] == nil {
+			byDay[stat.Day] = &usageTotals{}
+			activeDays = append(activeDays, stat.Day)
+		}
+		for _, total := range []*usageTotals{bySteps[stat.Step], byDay[stat.Day]} {
+			total.Count += stat.Calls
+			total.InputTokens += stat.InputTokens
+			total.OutputTokens += stat.OutputTokens
+		}
+	}
+
+	sum := sumUsage(bySteps)
+	message.WriteString(fmt.Sprintf("\n🤖 <b>LLM calls:</b> %d · tokens %d in / %d out\n", sum.Count, sum.InputTokens, sum.OutputTokens))
+	writeUsageLines(&message, bySteps, true)
+
+	message.WriteString("\n📅 <b>Per day</b> (calls · tokens)\n")
+	for i, day := range activeDays {
+		if i == USAGE_DAILY_TABLE_DAYS {
+			message.WriteString(fmt.Sprintf("… %d earlier days\n", len(activeDays)-i))
+			break
+		}
+		message.WriteString(fmt.Sprintf("<code>%s</code> %d · %d\n", day, byDay[day].Count, byDay[day].InputTokens+byDay[day].OutputTokens))
+	}
+	return strings.TrimRight(message.String(), "\n")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_Usage(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+
+	claudeApi, err := NewClaudeClient("key", "", CLAUDE_MODEL)
+	require.NoError(t, err)
+	claudeApi.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":120,"output_tokens":30}}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})
+	claudeApi.SetUsageHook(func(step string, usage Usage) {
+		db.RecordUsage(USAGE_LLM, step, 1, int64(usage.InputTokens), int64(usage.OutputTokens))
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err = claudeApi.ForStep(USAGE_STEP_FIRST).SendMessage(ClaudeMessages{{ROLE_USER, "hi"}}, "")
+		require.NoError(t, err)
+	}
+	_, err = claudeApi.ForStep(USAGE_STEP_SECOND).SendMessage(ClaudeMessages{{ROLE_USER, "hi"}}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "1", UserID: "u1", SourceType: TWEET_SOURCE_COMMUNITY}))
+	db.RecordUsage(USAGE_TWITTER, "/twitter/community/tweets", 3, 0, 0)
+	db.RecordUsage(USAGE_CACHE, USAGE_CACHE_HIT, 1, 0, 0)
+	db.RecordUsage(USAGE_CACHE, USAGE_CACHE_MISS, 3, 0, 0)
+
+	bot.handleUsageCommand(1, nil)
+	sent := transport.sentMessages()
+	report := sent[len(sent)-1].Text
+	assert.Contains(t, report, "Usage today")
+	assert.Contains(t, report, "Tweets ingested:</b> 1")
+	assert.Contains(t, report, "LLM calls:</b> 3 · tokens 360 in / 90 out")
+	assert.Contains(t, report, "first_step: 2 · 240 in / 60 out")
+	assert.Contains(t, report, "/twitter/community/tweets: 3")
+	assert.Contains(t, report, "1 hits / 3 misses (25% hit ratio)")
+
+	bot.handleUsageCommand(1, []string{"7d"})
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "Per day")
+
+	bot.handleUsageCommand(1, []string{"year"})
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "invalid period")
+}
+
+func TestBotController_Costs(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "whale"}))
+
+	// One user re-analyzed over and over, a few users seen once
+	for i := 0; i < 5; i++ {
+		db.RecordUserUsage("u1", "whale", USAGE_STEP_FIRST, 1000, 100)
+		db.RecordUserUsage("u1", "whale", USAGE_STEP_SECOND, 8000, 500)
+	}
+	for _, user := range []string{"a", "b", "c", "d"} {
+		db.RecordUserUsage(user, user+"_user", USAGE_STEP_FIRST, 1000, 100)
+	}
+
+	bot.handleCostsCommand(1, "/costs", nil)
+	sent := transport.sentMessages()
+	report := sent[len(sent)-1].Text
+	assert.Contains(t, report, "last 7 days")
+	assert.Contains(t, report, "14 LLM calls · 52400 tokens on 5 users")
+	assert.Contains(t, report, "1. @whale — 10 calls (5 analyses) · 48000 tokens (92%) ⚠️")
+	assert.Contains(t, report, "/costs_whale")
+	assert.Contains(t, report, "@a_user — 1 calls (0 analyses) · 1100 tokens (2%)\n")
+
+	bot.handleCostsCommand(1, "/costs_whale", nil)
+	sent = transport.sentMessages()
+	userReport := sent[len(sent)-1].Text
+	assert.Contains(t, userReport, "Analysis cost of @whale, last 30 days")
+	assert.Contains(t, userReport, "second_step: 5 · 40000 in / 2500 out")
+
+	bot.handleCostsCommand(1, "/costs_nobody", nil)
+	sent = transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "User not found")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Graph export formats for /graph_ and the /api/graph endpoint
+const (
+	GRAPH_FORMAT_GRAPHML = "graphml"
+	GRAPH_FORMAT_DOT     = "dot"
+)
+
+// Edge types in the exported graph
+const (
+	GRAPH_EDGE_FOLLOWS = "follows"
+	GRAPH_EDGE_REPLIED = "replied_to"
+)
+
+// UserGraph is the follower and reply network around a user
+type UserGraph struct {
+	CenterID string
+	Nodes    []GraphNode
+	Edges    []GraphEdge
+}
+
+type GraphNode struct {
+	ID       string
+	Username string
+	IsFUD    bool
+	FUDType  string
+}
+
+// GraphEdge points from the follower or replying user to the followed or replied-to user
+type GraphEdge struct {
+	Source string
+	Target string
+	Type   string
+	Weight int // number of replies, 1 for follows
+}
+
+func (n GraphNode) label() string {
+	if n.Username == "" {
+		return n.ID
+	}
+	return "@" + n.Username
+}
+
+// renderUserGraph renders the graph as GraphML (Gephi, yEd) or DOT (Graphviz)
+func renderUserGraph(graph *UserGraph, format string) string {
+	if format == GRAPH_FORMAT_DOT {
+		return renderGraphDOT(graph)
+	}
+	return renderGraphML(graph)
+}
+
+func renderGraphML(graph *UserGraph) string {
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="fud" for="node" attr.name="fud" attr.type="boolean"/>
+  <key id="fud_type" for="node" attr.name="fud_type" attr.type="string"/>
+  <key id="center" for="node" attr.name="center" attr.type="boolean"/>
+  <key id="type" for="edge" attr.name="type" attr.type="string"/>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>
+  <graph id="G" edgedefault="directed">
+`)
+	for _, node := range graph.Nodes {
+		out.WriteString(fmt.Sprintf("    <node id=\"%s\">\n", html.EscapeString(node.ID)))
+		out.WriteString(fmt.Sprintf("      <data key=\"label\">%s</data>\n", html.EscapeString(node.label())))
+		out.WriteString(fmt.Sprintf("      <data key=\"fud\">%t</data>\n", node.IsFUD))
+		if node.FUDType != "" {
+			out.WriteString(fmt.Sprintf("      <data key=\"fud_type\">%s</data>\n", html.EscapeString(node.FUDType)))
+		}
+		out.WriteString(fmt.Sprintf("      <data key=\"center\">%t</data>\n", node.ID == graph.CenterID))
+		out.WriteString("    </node>\n")
+	}
+	for i, edge := range graph.Edges {
+		out.WriteString(fmt.Sprintf("    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, html.EscapeString(edge.Source), html.EscapeString(edge.Target)))
+		out.WriteString(fmt.Sprintf("      <data key=\"type\">%s</data>\n", edge.Type))
+		out.WriteString(fmt.Sprintf("      <data key=\"weight\">%d</data>\n", edge.Weight))
+		out.WriteString("    </edge>\n")
+	}
+	out.WriteString("  </graph>\n</graphml>\n")
+	return out.String()
+}
+
+func renderGraphDOT(graph *UserGraph) string {
+	quote := func(value string) string {
+		return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+	}
+
+	var out strings.Builder
+	out.WriteString("digraph users {\n")
+	for _, node := range graph.Nodes {
+		attrs := []string{"label=" + quote(node.label())}
+		if node.IsFUD {
+			attrs = append(attrs, "color=red", "fud_type="+quote(node.FUDType))
+		}
+		if node.ID == graph.CenterID {
+			attrs = append(attrs, "shape=doublecircle")
+		}
+		out.WriteString(fmt.Sprintf("  %s [%s];\n", quote(node.ID), strings.Join(attrs, ", ")))
+	}
+	for _, edge := range graph.Edges {
+		style := ""
+		if edge.Type == GRAPH_EDGE_REPLIED {
+			style = ", style=dashed"
+		}
+		out.WriteString(fmt.Sprintf("  %s -> %s [label=%s, weight=%d%s];\n", quote(edge.Source), quote(edge.Target), quote(edge.Type), edge.Weight, style))
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// handleGraphCommand exports the relationship graph around a user: /graph_username [dot]
+func (b *BotController) handleGraphCommand(chatID int64, command string, args []string) {
+	username, _ := b.resolveTwitterReference(strings.TrimPrefix(command, "/graph_"))
+	format := GRAPH_FORMAT_GRAPHML
+	if len(args) > 0 && strings.ToLower(args[0]) == GRAPH_FORMAT_DOT {
+		format = GRAPH_FORMAT_DOT
+	}
+
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ User @%s not found in database", username))
+		return
+	}
+
+	graph, err := b.dbService.GetUserGraph(user.ID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error building graph for @%s: %v", username, err))
+		return
+	}
+	if len(graph.Edges) == 0 {
+		b.SendMessage(chatID, fmt.Sprintf("📭 No stored followers, followings or replies for @%s. Run /analyze_%s first.", username, username))
+		return
+	}
+
+	fudNodes := 0
+	for _, node := range graph.Nodes {
+		if node.IsFUD {
+			fudNodes++
+		}
+	}
+
+	filename := fmt.Sprintf("%s_graph_%s.%s", user.Username, time.Now().Format("20060102_150405"), format)
+	rendered := renderUserGraph(graph, format)
+	err = b.writeToFile(filename, rendered)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error creating file: %v", err))
+		return
+	}
+
+	caption := fmt.Sprintf("🕸 <b>Relationship Graph</b>\n\n👤 User: @%s\n🔵 Nodes: %d (🚨 %d FUD)\n🔗 Edges: %d\n📐 Format: %s",
+		user.Username, len(graph.Nodes), fudNodes, len(graph.Edges), format)
+	// Graph formats have no room for a trailer, the seal goes into the caption only
+	seal := sealEvidence([]byte(rendered))
+	caption += seal.caption()
+	log.Printf("🔐 Graph export of @%s for chat %d sealed, sha256 %s", user.Username, chatID, seal.Digest)
+	err = b.SendDocument(chatID, filename, caption)
+	os.Remove(filename)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
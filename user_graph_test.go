@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserGraph(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "shady"}))
+	require.NoError(t, db.SaveUser(UserModel{ID: "u2", Username: "puppet"}))
+	require.NoError(t, db.SaveUser(UserModel{ID: "u3", Username: "holder"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u2", Username: "puppet", FUDType: "coordinated_attack"}))
+
+	// puppet follows shady, shady follows holder, puppet also follows holder (stored on puppet's side)
+	require.NoError(t, db.SaveUserRelations("u1", []string{"u2"}, RELATION_TYPE_FOLLOWER))
+	require.NoError(t, db.SaveUserRelations("u1", []string{"u3"}, RELATION_TYPE_FOLLOWING))
+	require.NoError(t, db.SaveUserRelations("u2", []string{"u3"}, RELATION_TYPE_FOLLOWING))
+
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "t1", UserID: "u3", Username: "holder", Text: "gm"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "t2", UserID: "u1", Username: "shady", Text: "rug soon", InReplyToID: "t1"}))
+	require.NoError(t, db.SaveTweet(TweetModel{ID: "t3", UserID: "u1", Username: "shady", Text: "told you", InReplyToID: "t1"}))
+
+	graph, err := db.GetUserGraph("u1")
+	require.NoError(t, err)
+	require.Len(t, graph.Nodes, 3)
+	assert.True(t, graph.Nodes[1].IsFUD)
+	assert.Contains(t, graph.Edges, GraphEdge{Source: "u2", Target: "u1", Type: GRAPH_EDGE_FOLLOWS, Weight: 1})
+	assert.Contains(t, graph.Edges, GraphEdge{Source: "u1", Target: "u3", Type: GRAPH_EDGE_FOLLOWS, Weight: 1})
+	assert.Contains(t, graph.Edges, GraphEdge{Source: "u2", Target: "u3", Type: GRAPH_EDGE_FOLLOWS, Weight: 1})
+	assert.Contains(t, graph.Edges, GraphEdge{Source: "u1", Target: "u3", Type: GRAPH_EDGE_REPLIED, Weight: 2})
+
+	graphML := renderUserGraph(graph, GRAPH_FORMAT_GRAPHML)
+	assert.Contains(t, graphML, `<edge id="e0" source="u2" target="u1">`)
+	assert.Contains(t, graphML, `<data key="fud_type">coordinated_attack</data>`)
+
+	dot := renderUserGraph(graph, GRAPH_FORMAT_DOT)
+	assert.Contains(t, dot, `"u1" [label="@shady", shape=doublecircle];`)
+	assert.Contains(t, dot, `"u1" -> "u3" [label="replied_to", weight=2, style=dashed];`)
+
+	t.Run("REST endpoint", func(t *testing.T) {
+		handler := newAPIHandler(db)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/graph/shady?format=dot", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, dot, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/graph/nobody", nil))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestFUDConnections(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveUser(UserModel{ID: "s", Username: "suspect"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "f1", Username: "shill", FUDType: "coordinated_attack"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "f2", Username: "troll", FUDType: "casual_fud"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "f3", Username: "loner", FUDType: "casual_fud"}))
+
+	// shill follows suspect (stored on the suspect's side), suspect follows shill (stored on shill's side),
+	// troll shares two followers and one following with the suspect, loner has nothing in common
+	require.NoError(t, db.SaveUserRelations("s", []string{"f1", "a", "b", "c"}, RELATION_TYPE_FOLLOWER))
+	require.NoError(t, db.SaveUserRelations("s", []string{"x"}, RELATION_TYPE_FOLLOWING))
+	require.NoError(t, db.SaveUserRelations("f1", []string{"s"}, RELATION_TYPE_FOLLOWER))
+	require.NoError(t, db.SaveUserRelations("f2", []string{"a", "b", "z"}, RELATION_TYPE_FOLLOWER))
+	require.NoError(t, db.SaveUserRelations("f2", []string{"x"}, RELATION_TYPE_FOLLOWING))
+	require.NoError(t, db.SaveUserRelations("f3", []string{"q"}, RELATION_TYPE_FOLLOWER))
+
+	connections, err := db.GetFUDConnections("s")
+	require.NoError(t, err)
+	require.Len(t, connections, 2)
+	assert.Equal(t, "shill", connections[0].Username, "direct links come first")
+	assert.Equal(t, "mutual follow", connections[0].describe())
+	assert.Equal(t, "2 shared followers, 1 shared followings", connections[1].describe())
+	assert.Equal(t, []string{"@shill (mutual follow)", "+1 more"}, summarizeFUDConnections(connections, 1))
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.handleNetworkCommand(1, "/network_suspect")
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Text, "Stored: 4 followers, 1 followings")
+	assert.Contains(t, sent[0].Text, "Connected to <b>2</b> known FUD accounts, 1 directly")
+	assert.Contains(t, sent[0].Text, "@troll <i>casual_fud</i> — 2 shared followers, 1 shared followings")
+
+	alert := benchmarkAlert()
+	alert.FUDConnections = summarizeFUDConnections(connections, FUD_NETWORK_ALERT_TOP)
+	assert.Contains(t, NewNotificationFormatter().FormatForTelegram(alert), "🕸 <b>Linked FUD:</b> @shill (mutual follow), @troll")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+var cashtagRegex = regexp.MustCompile(`\$[A-Za-z][A-Za-z0-9]{1,14}\b`)
+
+// UserProfileData is the bio and pinned tweet context sent to the second step
+type UserProfileData struct {
+	Bio                string   `json:"bio"`
+	PinnedTweets       []string `json:"pinned_tweets"`
+	TickerMentions     []string `json:"ticker_mentions"`     // where the bio or pinned tweets mention our ticker
+	CompetitorCashtags []string `json:"competitor_cashtags"` // other cashtags, often a sign of affiliation with rival projects
+}
+
+// getUserProfile fetches the user's bio and pinned tweets, stores them and flags ticker mentions
+func getUserProfile(twitterApi TwitterAPI, userID string, username string, ticker string, dbService *DatabaseService) *UserProfileData {
+	info, err := twitterApi.GetUserInfo(twitterapi.UserInfoRequest{UserName: username})
+	if err != nil {
+		log.Printf("Failed to get profile for user %s: %v", username, err)
+		return nil
+	}
+
+	profile := &UserProfileData{Bio: info.Data.Description}
+	if len(info.Data.PinnedTweetIds) > 0 {
+		pinned, err := twitterApi.GetTweetsByIds(info.Data.PinnedTweetIds)
+		if err != nil {
+			log.Printf("Failed to get pinned tweets for user %s: %v", username, err)
+		} else {
+			for _, tweet := range pinned.Tweets {
+				profile.PinnedTweets = append(profile.PinnedTweets, tweet.Text)
+			}
+		}
+	}
+	flagProfileMentions(profile, ticker)
+
+	err = dbService.UpdateUserProfile(userID, profile.Bio, strings.Join(profile.PinnedTweets, "\n---\n"))
+	if err != nil {
+		log.Printf("Failed to save profile for user %s: %v", username, err)
+	}
+	return profile
+}
+
+// flagProfileMentions fills TickerMentions and CompetitorCashtags from the bio and pinned tweets
+func flagProfileMentions(profile *UserProfileData, ticker string) {
+	ticker = strings.ToUpper(strings.TrimPrefix(ticker, "$"))
+	tickerWordRegex := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(ticker) + `\b`)
+
+	type profileSource struct{ name, text string }
+	sources := []profileSource{{"bio", profile.Bio}}
+	for i, text := range profile.PinnedTweets {
+		sources = append(sources, profileSource{fmt.Sprintf("pinned tweet %d", i+1), text})
+	}
+
+	seenCashtags := make(map[string]bool)
+	for _, source := range sources {
+		if ticker != "" && tickerWordRegex.MatchString(source.text) {
+			profile.TickerMentions = append(profile.TickerMentions, source.name)
+		}
+		for _, cashtag := range cashtagRegex.FindAllString(source.text, -1) {
+			cashtag = strings.ToUpper(cashtag)
+			if cashtag == "$"+ticker || seenCashtags[cashtag] {
+				continue
+			}
+			seenCashtags[cashtag] = true
+			profile.CompetitorCashtags = append(profile.CompetitorCashtags, cashtag)
+		}
+	}
+}
+
+// prepareUserProfileMessage renders the profile context for the second step prompt
+func prepareUserProfileMessage(profile *UserProfileData) ClaudeMessage {
+	if profile == nil || (profile.Bio == "" && len(profile.PinnedTweets) == 0) {
+		return ClaudeMessage{
+			Role:    ROLE_USER,
+			Content: "USER'S PROFILE (BIO AND PINNED TWEETS): Not available",
+		}
+	}
+
+	profileJSON, _ := json.Marshal(profile)
+	return ClaudeMessage{
+		Role:    ROLE_USER,
+		Content: fmt.Sprintf("USER'S PROFILE (BIO AND PINNED TWEETS, competitor_cashtags may reveal affiliation with rival projects):\n%s", string(profileJSON)),
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagProfileMentions(t *testing.T) {
+	profile := &UserProfileData{
+		Bio:          "Building on $RIVAL | ex grut holder | not financial advice",
+		PinnedTweets: []string{"$GRUT is dead, $rival and $PEPE are the future"},
+	}
+	flagProfileMentions(profile, "$GRUT")
+
+	assert.Equal(t, []string{"bio", "pinned tweet 1"}, profile.TickerMentions)
+	assert.Equal(t, []string{"$RIVAL", "$PEPE"}, profile.CompetitorCashtags)
+
+	message := prepareUserProfileMessage(profile)
+	assert.Contains(t, message.Content, "USER'S PROFILE")
+	assert.Contains(t, message.Content, `"competitor_cashtags":["$RIVAL","$PEPE"]`)
+	assert.Contains(t, prepareUserProfileMessage(nil).Content, "Not available")
+}
+
+func TestGetUserProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twitter/user/info":
+			assert.Equal(t, "alice", r.URL.Query().Get("userName"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "success",
+				"data":   map[string]interface{}{"description": "core contributor at $RIVAL", "pinnedTweetIds": []string{"p1"}},
+			})
+		case "/twitter/tweets":
+			assert.Equal(t, "p1", r.URL.Query().Get("tweet_ids"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "success",
+				"tweets": []map[string]string{{"id": "p1", "text": "$GRUT holders will get rugged"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "alice"}))
+
+	profile := getUserProfile(twitterapi.NewTwitterAPIService("key", server.URL, ""), "u1", "alice", "GRUT", db)
+	require.NotNil(t, profile)
+	assert.Equal(t, []string{"pinned tweet 1"}, profile.TickerMentions)
+	assert.Equal(t, []string{"$RIVAL"}, profile.CompetitorCashtags)
+
+	user, err := db.GetUser("u1")
+	require.NoError(t, err)
+	assert.Equal(t, "core contributor at $RIVAL", user.Bio)
+	assert.Equal(t, "$GRUT holders will get rugged", user.PinnedTweetText)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	USER_REPORT_EVIDENCE_TWEETS = 5
+	USER_REPORT_TIMELINE_ITEMS  = 15
+	USER_REPORT_TEXT_LENGTH     = 400
+)
+
+// userReport is what /report_<username> presents to non-technical stakeholders
+type userReport struct {
+	User          *UserModel
+	Username      string
+	Ticker        string
+	GeneratedAt   time.Time
+	MessageCount  int
+	FirstSeen     time.Time
+	LastSeen      time.Time
+	Analysis      *SecondStepClaudeResponse
+	Verdict       *LabeledVerdictModel
+	AlertCount    int64
+	EvidenceAlert []FUDAlertNotification
+	EvidenceText  map[string]string // full text of the alerted tweets by ID
+	Opinions      []UserTickerOpinionModel
+}
+
+// buildUserReport collects the stored profile, verdicts, alerts and ticker opinions of a user
+func (b *BotController) buildUserReport(username string) (*userReport, error) {
+	user, err := b.dbService.GetUserByUsername(username)
+	if err != nil {
+		if user, err = b.dbService.GetUser(username); err != nil {
+			return nil, fmt.Errorf("user @%s is not in the database", username)
+		}
+	}
+	report := &userReport{User: user, Username: user.Username, Ticker: b.ticker, GeneratedAt: time.Now().UTC(), EvidenceText: make(map[string]string)}
+
+	tweets, err := b.dbService.GetTweetsByUser(user.ID)
+	if err != nil {
+		log.Printf("Failed to load tweets of @%s for the report: %v", user.Username, err)
+	}
+	report.MessageCount = len(tweets)
+	for _, tweet := range tweets {
+		if report.FirstSeen.IsZero() || tweet.CreatedAt.Before(report.FirstSeen) {
+			report.FirstSeen = tweet.CreatedAt
+		}
+		if tweet.CreatedAt.After(report.LastSeen) {
+			report.LastSeen = tweet.CreatedAt
+		}
+	}
+
+	if analysis, err := b.dbService.GetCachedAnalysis(user.ID); err == nil {
+		report.Analysis = analysis
+	}
+	if verdict, err := b.dbService.GetLatestLabeledVerdict(user.ID); err == nil {
+		report.Verdict = verdict
+	}
+
+	notifications, total, err := b.dbService.GetUserNotifications(user.ID, USER_REPORT_EVIDENCE_TWEETS)
+	if err != nil {
+		log.Printf("Failed to load alerts of @%s for the report: %v", user.Username, err)
+	}
+	report.AlertCount = total
+	for _, notification := range notifications {
+		var alert FUDAlertNotification
+		if err := json.Unmarshal([]byte(notification.Payload), &alert); err != nil {
+			continue
+		}
+		report.EvidenceAlert = append(report.EvidenceAlert, alert)
+		if tweet, err := b.dbService.GetTweet(alert.FUDMessageID); err == nil {
+			report.EvidenceText[alert.FUDMessageID] = tweet.Text
+		}
+	}
+
+	report.Opinions, err = b.dbService.GetUserTickerOpinionsByUsername(user.Username, b.ticker, 0)
+	if err != nil {
+		log.Printf("Failed to load ticker opinions of @%s for the report: %v", user.Username, err)
+	}
+	sort.Slice(report.Opinions, func(i, j int) bool {
+		return report.Opinions[i].TweetCreatedAt.Before(report.Opinions[j].TweetCreatedAt)
+	})
+	return report, nil
+}
+
+func reportDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func reportText(text string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= USER_REPORT_TEXT_LENGTH {
+		return string(runes)
+	}
+	return string(runes[:USER_REPORT_TEXT_LENGTH-3]) + "..."
+}
+
+// renderUserReportPDF lays the report out as a PDF: profile, verdict, evidence and ticker timeline
+func renderUserReportPDF(report *userReport, formatter *NotificationFormatter) []byte {
+	doc := newPDFDocument("User report @" + report.Username)
+	doc.Title("User report: @" + report.Username)
+	doc.Field("Generated", reportDate(report.GeneratedAt))
+	if report.Ticker != "" {
+		doc.Field("Monitored ticker", report.Ticker)
+	}
+
+	doc.Heading("Profile summary")
+	doc.Field("Name", report.User.Name)
+	doc.Field("User ID", report.User.ID)
+	doc.Field("Profile", "https://x.com/"+report.Username)
+	if report.User.Bio != "" {
+		doc.Field("Bio", report.User.Bio)
+	}
+	doc.Field("Stored messages", fmt.Sprintf("%d", report.MessageCount))
+	doc.Field("First seen", reportDate(report.FirstSeen))
+	doc.Field("Last seen", reportDate(report.LastSeen))
+
+	doc.Heading("Detection verdict")
+	switch {
+	case report.Analysis == nil:
+		doc.Paragraph("The user has not been analyzed yet.")
+	case report.Analysis.IsFUDUser:
+		doc.Field("Verdict", "FUD user")
+		doc.Field("Type", formatter.formatFUDType(report.Analysis.FUDType))
+	default:
+		doc.Field("Verdict", "Not a FUD user")
+	}
+	if report.Analysis != nil {
+		doc.Field("Confidence", fmt.Sprintf("%.0f%%", report.Analysis.FUDProbability*100))
+		doc.Field("Risk level", strings.ToUpper(report.Analysis.UserRiskLevel))
+		doc.Field("Summary", report.Analysis.UserSummary)
+		doc.Field("Reasoning", report.Analysis.DecisionReason)
+	}
+	if report.Verdict != nil {
+		doc.Field("Moderator verdict", fmt.Sprintf("%s by %s on %s", strings.ToUpper(report.Verdict.Label), report.Verdict.LabeledBy, reportDate(report.Verdict.CreatedAt)))
+	}
+	doc.Field("Alerts raised", fmt.Sprintf("%d", report.AlertCount))
+
+	doc.Heading("Key evidence")
+	if report.Analysis != nil {
+		for _, evidence := range report.Analysis.KeyEvidence {
+			doc.Bullet(evidence)
+		}
+	}
+	if len(report.EvidenceAlert) == 0 {
+		doc.Paragraph("No alerted tweets.")
+	}
+	for _, alert := range report.EvidenceAlert {
+		text := report.EvidenceText[alert.FUDMessageID]
+		if text == "" {
+			text = alert.MessagePreview
+		}
+		doc.Space()
+		doc.Field("Alerted tweet", fmt.Sprintf("%s, %s severity, %s", formatter.formatTime(alert.DetectedAt), alert.AlertSeverity, formatter.formatFUDType(alert.FUDType)))
+		doc.Paragraph(reportText(text))
+		doc.Paragraph("https://x.com/" + report.Username + "/status/" + alert.FUDMessageID)
+	}
+
+	doc.Heading("Ticker opinion timeline")
+	if len(report.Opinions) == 0 {
+		doc.Paragraph("No ticker mentions found.")
+	} else {
+		months := make(map[string]int)
+		var order []string
+		for _, opinion := range report.Opinions {
+			month := opinion.TweetCreatedAt.UTC().Format("2006-01")
+			if months[month] == 0 {
+				order = append(order, month)
+			}
+			months[month]++
+		}
+		for _, month := range order {
+			doc.Bullet(fmt.Sprintf("%s: %d mentions", month, months[month]))
+		}
+		doc.Space()
+		latest := report.Opinions
+		if len(latest) > USER_REPORT_TIMELINE_ITEMS {
+			latest = latest[len(latest)-USER_REPORT_TIMELINE_ITEMS:]
+			doc.Paragraph(fmt.Sprintf("Latest %d of %d mentions:", USER_REPORT_TIMELINE_ITEMS, len(report.Opinions)))
+		}
+		for _, opinion := range latest {
+			doc.Bullet(reportDate(opinion.TweetCreatedAt) + ": " + reportText(opinion.Text))
+		}
+	}
+	return doc.Bytes()
+}
+
+// handleUserReportCommand sends a PDF report of an analyzed user: /report_username
+func (b *BotController) handleUserReportCommand(chatID int64, command string) {
+	username, _ := b.resolveTwitterReference(strings.TrimPrefix(command, "/report_"))
+	if username == "" {
+		b.SendMessage(chatID, "❌ Invalid command format. Use /report_username")
+		return
+	}
+	report, err := b.buildUserReport(username)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	content := renderUserReportPDF(report, b.formatter)
+	filename := fmt.Sprintf("%s_report_%s.pdf", report.Username, report.GeneratedAt.Format("20060102_150405"))
+	seal := sealEvidence(content)
+	caption := fmt.Sprintf("📑 <b>User Report</b>\n\n👤 User: @%s\n🚨 Alerts: %d\n📅 Generated: %s",
+		report.Username, report.AlertCount, report.GeneratedAt.Format("2006-01-02 15:04:05")) + seal.caption()
+	log.Printf("🔐 Report of @%s for chat %d sealed, sha256 %s", report.Username, chatID, seal.Digest)
+
+	if err := b.sendExportDocument(chatID, filename, string(content), caption); err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error sending file: %v", err))
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDFDocument_ValidXrefAndPages(t *testing.T) {
+	doc := newPDFDocument("Test (1)")
+	doc.Title("Report: café \U0001F680 (draft)")
+	for i := 0; i < 120; i++ {
+		doc.Bullet(fmt.Sprintf("line %d %s", i, strings.Repeat("word ", 30)))
+	}
+	content := doc.Bytes()
+
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(content, []byte("%%EOF\n")))
+	assert.Contains(t, string(content), `(Report: caf\351  \(draft\)) Tj`, "Latin-1 escaped, emoji dropped")
+	assert.Greater(t, len(doc.pages), 1)
+	assert.Contains(t, string(content), fmt.Sprintf("/Count %d", len(doc.pages)))
+
+	// Every xref entry points at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(content)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(content[xref:], -1)
+	require.Len(t, entries, 5+2*len(doc.pages))
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(content[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))), "object %d", i+1)
+	}
+}
+
+func TestWrapPDFText(t *testing.T) {
+	assert.Equal(t, []string{"one two", "three"}, wrapPDFText("one two three", 8))
+	assert.Equal(t, []string{"abcde", "fgh"}, wrapPDFText("abcdefgh", 5))
+	assert.Equal(t, []string{"a", "", "b"}, wrapPDFText("a\n\nb", 5))
+}
+
+func TestUserReport_SentAsPDF(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.ticker = "$GRUT"
+
+	require.NoError(t, db.SaveUser(UserModel{ID: "42", Username: "alice", Name: "Alice"}))
+	require.NoError(t, db.SaveCachedAnalysis("42", "alice", SecondStepClaudeResponse{
+		IsFUDUser: true, FUDType: "professional_trojan_horse", FUDProbability: 0.9, UserRiskLevel: "high",
+		KeyEvidence: []string{"Repeated rug pull claims"}, DecisionReason: "Spreads doubt", UserSummary: "Trojan horse",
+	}, ""))
+	require.NoError(t, db.SaveUserTickerOpinion(UserTickerOpinionModel{UserID: "42", Username: "alice", Ticker: "$GRUT", TweetID: "t1", Text: "$GRUT is dead", TweetCreatedAt: time.Now()}))
+
+	report, err := bot.buildUserReport("alice")
+	require.NoError(t, err)
+	content := string(renderUserReportPDF(report, bot.formatter))
+	assert.Contains(t, content, "(User report: @alice)")
+	assert.Contains(t, content, "(Verdict: FUD user)")
+	assert.Contains(t, content, "(- Repeated rug pull claims)")
+	assert.Contains(t, content, "$GRUT is dead")
+
+	bot.handleUserReportCommand(1, "/report_alice")
+	require.Len(t, transport.documents, 1)
+	assert.Contains(t, transport.documents[0].Caption, "User Report")
+
+	bot.handleUserReportCommand(1, "/report_nobody")
+	sent := transport.sentMessages()
+	assert.Contains(t, sent[len(sent)-1].Text, "not in the database")
+
+	// Rendering a PDF counts against the expensive command budget
+	assert.True(t, isExpensiveCommand("/report_alice"))
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MONITORING_POLL_INTERVAL      = 60 * time.Second
+	STREAM_MIN_INTERVAL           = 5 * time.Second // stream monitoring polls this often while the community is active
+	STREAM_MAX_PAGES              = 5               // pages stream monitoring walks back to catch up after a burst
+	WARROOM_POLL_INTERVAL         = 15 * time.Second
+	WARROOM_STATUS_INTERVAL       = 30 * time.Second
+	WARROOM_FIRST_STEP_THRESHOLD  = 40 // first step probability (%) that escalates to detailed analysis
+	WARROOM_BURST_WINDOW          = 10 * time.Minute
+	WARROOM_BURST_ALERTS          = 3
+	WARROOM_MAX_DURATION          = 24 * time.Hour
+	WARROOM_SUMMARY_TOP_OFFENDERS = 5
+)
+
+// warRoomState is the time-boxed elevated monitoring mode started with /warroom on.
+// The monitoring and first step loops read it, so all methods are safe for concurrent use.
+type warRoomState struct {
+	mu              sync.Mutex
+	startedAt       time.Time
+	until           time.Time
+	chatID          int64
+	statusMessageID int64
+	timer           *time.Timer
+	generation      int // bumped whenever the timer is set, a stopped timer that fired anyway is ignored
+	done            chan struct{}
+	stats           warRoomStats
+}
+
+type warRoomStats struct {
+	Messages    int
+	Escalations int
+	Alerts      map[string]int // by severity
+	Offenders   map[string]int // alerts by username
+	Bursts      int
+	alertTimes  []time.Time
+	lastBurstAt time.Time
+}
+
+// active reports whether the war room is running
+func (w *warRoomState) active() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.until.IsZero()
+}
+
+// pollInterval is how long monitoring waits between community polls
+func (w *warRoomState) pollInterval() time.Duration {
+	if w.active() {
+		return WARROOM_POLL_INTERVAL
+	}
+	return MONITORING_POLL_INTERVAL
+}
+
+// escalates reports whether a first step result below the FUD verdict should still get a detailed analysis
+func (w *warRoomState) escalates(fudProbability float64) bool {
+	if !w.active() || fudProbability < WARROOM_FIRST_STEP_THRESHOLD {
+		return false
+	}
+	w.mu.Lock()
+	w.stats.Escalations++
+	w.mu.Unlock()
+	return true
+}
+
+// recordMessage counts a message picked up by monitoring
+func (w *warRoomState) recordMessage() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.until.IsZero() {
+		w.stats.Messages++
+	}
+}
+
+// recordAlert counts an alert and returns the number of alerts in the burst window
+// when this alert starts a new burst, or 0 otherwise
+func (w *warRoomState) recordAlert(alert FUDAlertNotification, now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.until.IsZero() {
+		return 0
+	}
+
+	w.stats.Alerts[alert.AlertSeverity]++
+	w.stats.Offenders[alert.FUDUsername]++
+
+	recent := []time.Time{now}
+	for _, at := range w.stats.alertTimes {
+		if now.Sub(at) < WARROOM_BURST_WINDOW {
+			recent = append(recent, at)
+		}
+	}
+	w.stats.alertTimes = recent
+
+	// One burst per window, so a long attack isn't reported on every alert
+	if len(recent) < WARROOM_BURST_ALERTS || now.Sub(w.stats.lastBurstAt) < WARROOM_BURST_WINDOW {
+		return 0
+	}
+	w.stats.lastBurstAt = now
+	w.stats.Bursts++
+	return len(recent)
+}
+
+// handleWarRoomCommand processes /warroom on <duration>|off
+func (b *BotController) handleWarRoomCommand(chatID int64, args []string) {
+	if len(args) == 0 {
+		b.warRoom.mu.Lock()
+		until := b.warRoom.until
+		b.warRoom.mu.Unlock()
+
+		if until.IsZero() {
+			b.SendMessage(chatID, "🛡 War room is off.\n\nUsage: /warroom on 2h|off\nPolls more often, escalates borderline messages, delivers alerts of every severity and watches for alert bursts until the time is up.")
+			return
+		}
+		b.SendMessage(chatID, b.formatWarRoomStatus(time.Now()))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "off":
+		if !b.endWarRoom() {
+			b.SendMessage(chatID, "🛡 War room is off.")
+		}
+		return
+	case "on":
+	default:
+		b.SendMessage(chatID, "❌ Usage: /warroom on 2h|off")
+		return
+	}
+
+	duration := 2 * time.Hour
+	if len(args) > 1 {
+		parsed, err := time.ParseDuration(args[1])
+		if err != nil || parsed <= 0 || parsed > WARROOM_MAX_DURATION {
+			b.SendMessage(chatID, "❌ Invalid duration. Use something like 30m or 2h (max 24h)")
+			return
+		}
+		duration = parsed
+	}
+	b.startWarRoom(chatID, duration)
+}
+
+// startWarRoom opens the war room with its live status in chatID, or extends the running one
+func (b *BotController) startWarRoom(chatID int64, duration time.Duration) {
+	now := time.Now()
+	b.warRoom.mu.Lock()
+	if !b.warRoom.until.IsZero() {
+		// Extending a running war room keeps its stats
+		b.warRoom.timer.Stop()
+		b.warRoom.until = now.Add(duration)
+		b.scheduleWarRoomEnd(duration)
+		b.warRoom.mu.Unlock()
+		b.SendMessage(chatID, fmt.Sprintf("🛡 War room extended until %s UTC", now.Add(duration).UTC().Format("15:04")))
+		return
+	}
+	b.warRoom.startedAt = now
+	b.warRoom.until = now.Add(duration)
+	b.warRoom.chatID = chatID
+	b.warRoom.statusMessageID = 0
+	b.warRoom.stats = warRoomStats{Alerts: make(map[string]int), Offenders: make(map[string]int)}
+	b.warRoom.done = make(chan struct{})
+	b.scheduleWarRoomEnd(duration)
+	done := b.warRoom.done
+	b.warRoom.mu.Unlock()
+
+	log.Printf("🛡 War room started by chat %d for %s", chatID, duration)
+	b.BroadcastMessage(fmt.Sprintf("🛡 <b>War room active</b> until %s UTC\n\nMonitoring is elevated: faster polling, lower alert thresholds and burst detection.", now.Add(duration).UTC().Format("15:04")))
+
+	statusMessageID, err := b.SendMessageWithID(chatID, b.formatWarRoomStatus(now))
+	if err != nil {
+		log.Printf("Failed to post war room status: %v", err)
+		return
+	}
+	b.warRoom.mu.Lock()
+	b.warRoom.statusMessageID = statusMessageID
+	b.warRoom.mu.Unlock()
+
+	go b.updateWarRoomStatus(chatID, statusMessageID, done)
+}
+
+// updateWarRoomStatus keeps the status message current until the war room ends
+func (b *BotController) updateWarRoomStatus(chatID int64, messageID int64, done chan struct{}) {
+	ticker := time.NewTicker(WARROOM_STATUS_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			err := b.EditMessage(chatID, messageID, b.formatWarRoomStatus(now))
+			if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+				log.Printf("Failed to update war room status: %v", err)
+			}
+		}
+	}
+}
+
+// noteWarRoomAlert feeds a broadcast alert to burst detection
+func (b *BotController) noteWarRoomAlert(alert FUDAlertNotification) {
+	burst := b.warRoom.recordAlert(alert, time.Now())
+	if burst == 0 {
+		return
+	}
+
+	b.warRoom.mu.Lock()
+	chatID := b.warRoom.chatID
+	b.warRoom.mu.Unlock()
+
+	log.Printf("🔥 War room burst: %d alerts in %s", burst, WARROOM_BURST_WINDOW)
+	b.SendMessage(chatID, fmt.Sprintf("🔥 <b>Burst detected:</b> %d alerts in the last %d minutes. Possible coordinated attack.", burst, int(WARROOM_BURST_WINDOW.Minutes()))+b.formatAmplifiers(time.Now().Add(-AMPLIFIER_WINDOW)))
+}
+
+// scheduleWarRoomEnd sets the timer closing the war room, the caller holds b.warRoom.mu
+func (b *BotController) scheduleWarRoomEnd(duration time.Duration) {
+	b.warRoom.generation++
+	generation := b.warRoom.generation
+	b.warRoom.timer = time.AfterFunc(duration, func() { b.endWarRoomGeneration(generation) })
+}
+
+// endWarRoom reverts to normal monitoring and posts the incident summary
+func (b *BotController) endWarRoom() bool {
+	return b.endWarRoomGeneration(0)
+}
+
+// endWarRoomGeneration ends the war room. A timer passes its generation: Stop cannot recall a timer
+// that already fired, so one replaced by an extension ends nothing.
+func (b *BotController) endWarRoomGeneration(generation int) bool {
+	b.warRoom.mu.Lock()
+	if b.warRoom.until.IsZero() || generation != 0 && generation != b.warRoom.generation {
+		b.warRoom.mu.Unlock()
+		return false
+	}
+	b.warRoom.timer.Stop()
+	close(b.warRoom.done)
+	summary := b.formatWarRoomSummary(b.warRoom.startedAt, time.Now(), b.warRoom.stats)
+	chatID, statusMessageID := b.warRoom.chatID, b.warRoom.statusMessageID
+	b.warRoom.until = time.Time{}
+	b.warRoom.timer = nil
+	b.warRoom.mu.Unlock()
+
+	log.Printf("🛡 War room finished")
+	if statusMessageID != 0 {
+		b.EditMessage(chatID, statusMessageID, "🛡 <b>War room closed</b>")
+	}
+	b.BroadcastMessage("✅ <b>War room closed</b>, monitoring is back to normal.")
+	b.SendMessage(chatID, summary)
+	return true
+}
+
+func (b *BotController) formatWarRoomStatus(now time.Time) string {
+	b.warRoom.mu.Lock()
+	defer b.warRoom.mu.Unlock()
+
+	remaining := b.warRoom.until.Sub(now).Round(time.Minute)
+	if remaining < 0 {
+		remaining = 0
+	}
+	stats := b.warRoom.stats
+
+	var message strings.Builder
+	message.WriteString("🛡 <b>WAR ROOM ACTIVE</b>\n\n")
+	message.WriteString(fmt.Sprintf("⏳ Ends in %s (%s UTC)\n", remaining, b.warRoom.until.UTC().Format("15:04")))
+	message.WriteString(fmt.Sprintf("📡 Polling every %s\n\n", WARROOM_POLL_INTERVAL))
+	message.WriteString(fmt.Sprintf("💬 Messages scanned: %d\n", stats.Messages))
+	message.WriteString(fmt.Sprintf("⬆️ Escalated to detailed analysis: %d\n", stats.Escalations))
+	message.WriteString(fmt.Sprintf("🚨 Alerts: %d%s\n", sumCounts(stats.Alerts), b.formatSeverityCounts(stats.Alerts)))
+	message.WriteString(fmt.Sprintf("🔥 Bursts: %d\n", stats.Bursts))
+	message.WriteString(fmt.Sprintf("\n🕐 Updated %s UTC", now.UTC().Format("15:04:05")))
+	return message.String()
+}
+
+func (b *BotController) formatWarRoomSummary(startedAt time.Time, endedAt time.Time, stats warRoomStats) string {
+	var message strings.Builder
+	message.WriteString("📋 <b>War room incident summary</b>\n\n")
+	message.WriteString(fmt.Sprintf("🕐 %s — %s UTC (%s)\n\n", startedAt.UTC().Format("2006-01-02 15:04"), endedAt.UTC().Format("15:04"), endedAt.Sub(startedAt).Round(time.Minute)))
+	message.WriteString(fmt.Sprintf("💬 Messages scanned: %d\n", stats.Messages))
+	message.WriteString(fmt.Sprintf("⬆️ Escalated to detailed analysis: %d\n", stats.Escalations))
+	message.WriteString(fmt.Sprintf("🚨 Alerts: %d%s\n", sumCounts(stats.Alerts), b.formatSeverityCounts(stats.Alerts)))
+	message.WriteString(fmt.Sprintf("🔥 Bursts: %d\n", stats.Bursts))
+
+	if len(stats.Offenders) > 0 {
+		usernames := make([]string, 0, len(stats.Offenders))
+		for username := range stats.Offenders {
+			usernames = append(usernames, username)
+		}
+		sort.Slice(usernames, func(i, j int) bool {
+			if stats.Offenders[usernames[i]] != stats.Offenders[usernames[j]] {
+				return stats.Offenders[usernames[i]] > stats.Offenders[usernames[j]]
+			}
+			return usernames[i] < usernames[j]
+		})
+		message.WriteString("\n🏆 <b>Most alerted users:</b>\n")
+		for i, username := range usernames {
+			if i == WARROOM_SUMMARY_TOP_OFFENDERS {
+				break
+			}
+			message.WriteString(fmt.Sprintf("%d. @%s — %d alerts\n", i+1, username, stats.Offenders[username]))
+		}
+	}
+	message.WriteString(b.formatAmplifiers(startedAt))
+	return strings.TrimRight(message.String(), "\n")
+}
+
+func (b *BotController) formatSeverityCounts(counts map[string]int) string {
+	var parts strings.Builder
+	for _, severity := range []string{"critical", "high", "medium", "low"} {
+		if counts[severity] > 0 {
+			parts.WriteString(fmt.Sprintf(" · %s %d", b.formatter.getSeverityEmoji(severity), counts[severity]))
+		}
+	}
+	return parts.String()
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	WATCHDOG_STALL_THRESHOLD = 2 * time.Hour                // no new community tweets for this long is a stall
+	WATCHDOG_POLL_STALL      = 5 * MONITORING_POLL_INTERVAL // a monitor that has not finished a poll for this long is stuck
+	WATCHDOG_CHECK_EVERY     = time.Minute
+)
+
+// ingestionWatchdog tracks the heartbeats of the community monitors so a silently dead pipeline is noticed
+type ingestionWatchdog struct {
+	mu          sync.Mutex
+	communities map[string]*communityHeartbeat
+	stalled     bool // an alert was sent and recovery was not reported yet
+}
+
+type communityHeartbeat struct {
+	Ticker     string
+	LastPoll   time.Time // last finished poll, successful or not
+	LastIngest time.Time // last poll that found new tweets, the monitor start until then
+}
+
+// beat records a finished poll of a community and how many new tweets it found.
+// Monitors beat once with 0 when they start.
+func (w *ingestionWatchdog) beat(community CommunityModel, newTweets int) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if w.communities == nil {
+		w.communities = make(map[string]*communityHeartbeat)
+	}
+	heartbeat, ok := w.communities[community.ID]
+	if !ok {
+		heartbeat = &communityHeartbeat{Ticker: community.Ticker, LastIngest: now}
+		w.communities[community.ID] = heartbeat
+	}
+	heartbeat.LastPoll = now
+	if newTweets > 0 {
+		heartbeat.LastIngest = now
+	}
+}
+
+// forget stops watching a community that is no longer monitored
+func (w *ingestionWatchdog) forget(communityID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.communities, communityID)
+}
+
+// lastIngestion is the latest poll of any community that found new tweets, zero without monitors
+func (w *ingestionWatchdog) lastIngestion() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var last time.Time
+	for _, heartbeat := range w.communities {
+		if heartbeat.LastIngest.After(last) {
+			last = heartbeat.LastIngest
+		}
+	}
+	return last
+}
+
+// problems describes every community whose monitor is stuck or that got no new tweets for longer than stallAfter
+func (w *ingestionWatchdog) problems(now time.Time, stallAfter time.Duration) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var problems []string
+	for _, heartbeat := range w.communities {
+		ticker := html.EscapeString(heartbeat.Ticker)
+		if idle := now.Sub(heartbeat.LastPoll); idle > WATCHDOG_POLL_STALL {
+			problems = append(problems, fmt.Sprintf("%s: the poller has not finished a poll for %s", ticker, idle.Round(time.Minute)))
+		} else if quiet := now.Sub(heartbeat.LastIngest); quiet > stallAfter {
+			problems = append(problems, fmt.Sprintf("%s: no new community tweets for %s", ticker, quiet.Round(time.Minute)))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// watchdogStallThreshold reads ENV_WATCHDOG_STALL_MINUTES, falling back to WATCHDOG_STALL_THRESHOLD
+func watchdogStallThreshold() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv(ENV_WATCHDOG_STALL_MINUTES))
+	if err != nil || minutes <= 0 {
+		return WATCHDOG_STALL_THRESHOLD
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// StartIngestionWatchdog checks the monitor heartbeats periodically and alerts the admin chats
+func (b *BotController) StartIngestionWatchdog(stallAfter time.Duration, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			b.checkIngestion(time.Now(), stallAfter)
+		}
+	}()
+}
+
+// checkIngestion alerts the admin chats once when ingestion stalls and once when it recovers
+func (b *BotController) checkIngestion(now time.Time, stallAfter time.Duration) {
+	problems := b.watchdog.problems(now, stallAfter)
+
+	b.watchdog.mu.Lock()
+	wasStalled := b.watchdog.stalled
+	b.watchdog.stalled = len(problems) > 0
+	b.watchdog.mu.Unlock()
+
+	var message string
+	switch {
+	case len(problems) > 0 && !wasStalled:
+		log.Printf("🚨 Ingestion stalled: %s", strings.Join(problems, "; "))
+		message = fmt.Sprintf("🚨 <b>Ingestion stalled</b>\n\n• %s\n\nNo alerts are produced for these communities. Check the Twitter API key, the proxy and the process logs.", strings.Join(problems, "\n• "))
+	case len(problems) == 0 && wasStalled:
+		log.Println("✅ Ingestion recovered")
+		message = "✅ <b>Ingestion recovered</b>, all communities are polling and receiving tweets again."
+	default:
+		return
+	}
+
+	for _, chatID := range adminChatIDs() {
+		err := b.SendMessage(chatID, message)
+		if err != nil {
+			log.Printf("Failed to send watchdog alert to admin chat %d: %v", chatID, err)
+		}
+	}
+}
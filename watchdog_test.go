@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_IngestionWatchdog(t *testing.T) {
+	t.Setenv(ENV_TELEGRAM_ADMIN_CHAT_ID, "1")
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, setupTestDB(t))
+
+	grut := CommunityModel{ID: "1111111111", Ticker: "$GRUT"}
+	pepe := CommunityModel{ID: "2222222222", Ticker: "$PEPE"}
+	bot.watchdog.beat(grut, 0)
+	bot.watchdog.beat(pepe, 3)
+	now := time.Now()
+
+	bot.checkIngestion(now, time.Hour)
+	assert.Empty(t, transport.sentMessages(), "fresh monitors are healthy")
+
+	// GRUT keeps polling without new tweets, PEPE's poller hangs
+	bot.checkIngestion(now.Add(WATCHDOG_POLL_STALL+time.Minute), 2*WATCHDOG_POLL_STALL)
+	bot.watchdog.communities[grut.ID].LastPoll = now.Add(3 * WATCHDOG_POLL_STALL)
+	bot.checkIngestion(now.Add(3*WATCHDOG_POLL_STALL), 2*WATCHDOG_POLL_STALL)
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1, "one alert per stall")
+	assert.Equal(t, int64(1), sent[0].ChatID)
+	assert.Contains(t, sent[0].Text, "Ingestion stalled")
+	assert.Contains(t, sent[0].Text, "$PEPE: the poller has not finished a poll")
+
+	bot.watchdog.forget(pepe.ID)
+	bot.checkIngestion(now.Add(3*WATCHDOG_POLL_STALL), 2*WATCHDOG_POLL_STALL)
+	require.Len(t, transport.sentMessages(), 1, "GRUT alone is still stalled")
+	assert.Contains(t, bot.watchdog.problems(now.Add(3*WATCHDOG_POLL_STALL), 2*WATCHDOG_POLL_STALL)[0], "$GRUT: no new community tweets for")
+
+	bot.watchdog.beat(grut, 2)
+	bot.checkIngestion(time.Now(), 2*WATCHDOG_POLL_STALL)
+	sent = transport.sentMessages()
+	require.Len(t, sent, 2)
+	assert.Contains(t, sent[1].Text, "Ingestion recovered")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+)
+
+const (
+	WATCH_SUMMARY_TEXT_RUNES    = 400
+	WATCH_SUMMARY_CONTEXT_RUNES = 120
+)
+
+// handleWatchCommand manages the users a chat watches closely: /watch [list|add user [note: why]|remove user]
+func (b *BotController) handleWatchCommand(chatID int64, actor string, args []string) {
+	usage := "❌ Usage: /watch [list|add username [note: why]|remove username]"
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleWatchList(chatID)
+		return
+	}
+	if len(args) < 2 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	rest, note := splitNotifyNote(args[1:])
+	if len(rest) != 1 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	username, _ := b.resolveTwitterReference(rest[0])
+	username = strings.ToLower(username)
+	if !twitterUsernameRegex.MatchString(username) {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Invalid username: %s", html.EscapeString(rest[0])))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		entry := WatchlistModel{ChatID: chatID, Username: username, AddedBy: actor, Note: note}
+		user, err := b.dbService.GetUserByUsername(username)
+		if err == nil {
+			entry.UserID = user.ID
+		}
+		added, err := b.dbService.AddWatchedUser(entry)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error updating watchlist: %v", err))
+			return
+		}
+		if !added {
+			b.SendMessage(chatID, fmt.Sprintf("ℹ️ @%s is already on the watchlist of this chat", username))
+			return
+		}
+		log.Printf("👁 %s added @%s to the watchlist of chat %d", actor, username, chatID)
+		message := fmt.Sprintf("👁 Watching @%s: each new message gets the detailed analysis right away and is summarized here", username)
+		if b.dbService.IsTrustedUser(entry.UserID, username) {
+			message += "\n⚠️ Whitelisted, nothing is analyzed until /whitelist remove " + username
+		}
+		b.SendMessage(chatID, message)
+	case "remove":
+		removed, err := b.dbService.RemoveWatchedUser(chatID, username)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error updating watchlist: %v", err))
+			return
+		}
+		if !removed {
+			b.SendMessage(chatID, fmt.Sprintf("❌ @%s is not on the watchlist of this chat", username))
+			return
+		}
+		log.Printf("👁 %s removed @%s from the watchlist of chat %d", actor, username, chatID)
+		b.SendMessage(chatID, fmt.Sprintf("✅ Stopped watching @%s", username))
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+func (b *BotController) handleWatchList(chatID int64) {
+	entries, err := b.dbService.GetWatchlist(chatID)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading watchlist: %v", err))
+		return
+	}
+	if len(entries) == 0 {
+		b.SendMessage(chatID, "👁 This chat watches nobody.\n\nUsage: /watch add username note: why")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("👁 <b>Watchlist</b> (%d accounts)\n\n", len(entries)))
+	for _, entry := range entries {
+		message.WriteString(fmt.Sprintf("• @%s\n   ↳ added by %s on %s", entry.Username, html.EscapeString(entry.AddedBy), entry.CreatedAt.UTC().Format("2006-01-02")))
+		if entry.Note != "" {
+			message.WriteString(" — <i>" + html.EscapeString(entry.Note) + "</i>")
+		}
+		message.WriteString("\n")
+	}
+	message.WriteString("\n/watch add username note: why · /watch remove username")
+	b.SendMessage(chatID, message.String())
+}
+
+// notifyWatchers sends the summary of a watched user's message to the chats watching them
+func (b *BotController) notifyWatchers(alert FUDAlertNotification) {
+	chatIDs, err := b.dbService.GetWatchers(alert.FUDUserID, alert.FUDUsername)
+	if err != nil {
+		log.Printf("Failed to load the chats watching @%s: %v", alert.FUDUsername, err)
+		return
+	}
+	summary := b.formatWatchSummary(alert)
+	for _, chatID := range chatIDs {
+		// Chats removed from the notification list keep their watchlist for when they come back
+		if !b.isRegisteredChat(chatID) {
+			continue
+		}
+		if err := b.SendMessage(chatID, summary); err != nil {
+			log.Printf("Failed to send watchlist summary of @%s to chat %d: %v", alert.FUDUsername, chatID, err)
+		}
+	}
+}
+
+// formatWatchSummary is the short summary of a watched user's message with the verdict of its analysis
+func (b *BotController) formatWatchSummary(alert FUDAlertNotification) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("👁 <b>Watched @%s posted</b>\n", alert.FUDUsername))
+	if alert.ParentPostAuthor != "" {
+		message.WriteString(fmt.Sprintf("↩️ Reply to @%s: <i>%s</i>\n", html.EscapeString(alert.ParentPostAuthor),
+			b.formatter.escapeTruncated(strings.Join(strings.Fields(alert.ParentPostText), " "), WATCH_SUMMARY_CONTEXT_RUNES)))
+	}
+	message.WriteString("💬 " + b.formatter.escapeTruncated(alert.MessagePreview, WATCH_SUMMARY_TEXT_RUNES) + "\n")
+	if alert.WatchOnly {
+		message.WriteString(fmt.Sprintf("✅ Clean, FUD probability %.0f%%\n", alert.FUDProbability*100))
+	} else {
+		message.WriteString(fmt.Sprintf("%s <b>%s</b> · %s %.0f%%, the alert follows\n", b.formatter.getSeverityEmoji(alert.AlertSeverity),
+			strings.ToUpper(alert.AlertSeverity), b.formatter.formatFUDType(alert.FUDType), alert.FUDProbability*100))
+	}
+	if alert.UserSummary != "" {
+		message.WriteString("📝 " + b.formatter.escapeTruncated(alert.UserSummary, WATCH_SUMMARY_TEXT_RUNES) + "\n")
+	}
+	message.WriteString(fmt.Sprintf(`🔗 <a href="https://twitter.com/%s/status/%s">tweet</a> · /history_%s`, alert.FUDUsername, alert.FUDMessageID, alert.FUDUsername))
+	return message.String()
+}
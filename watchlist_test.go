@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_Watch(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "Shouter"}))
+
+	reply := func(chatID int64, args ...string) string {
+		bot.handleWatchCommand(chatID, "@admin", args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	assert.Contains(t, reply(1, "add", "@Shouter", "note:", "raid", "leader"), "Watching @shouter")
+	assert.Contains(t, reply(1, "add", "shouter"), "already on the watchlist")
+	assert.Contains(t, reply(2, "add", "https://x.com/Shouter"), "Watching @shouter")
+	assert.Contains(t, reply(1, "add", "bad-name!"), "Invalid username")
+	assert.Contains(t, reply(1, "list"), "raid leader")
+	assert.Contains(t, reply(3), "watches nobody")
+
+	assert.True(t, db.IsWatchedUser("u1", "renamed"), "matched by ID after a rename")
+	assert.True(t, db.IsWatchedUser("", "SHOUTER"))
+	assert.False(t, db.IsWatchedUser("u2", "someone"))
+	watchers, err := db.GetWatchers("u1", "shouter")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, watchers)
+
+	assert.Contains(t, reply(2, "remove", "shouter"), "Stopped watching @shouter")
+	assert.Contains(t, reply(2, "remove", "shouter"), "not on the watchlist")
+	watchers, err = db.GetWatchers("u1", "shouter")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, watchers)
+}
+
+func TestWatchedUserPipeline(t *testing.T) {
+	t.Setenv(ENV_FOLLOW_UP_DELAYS, "off")
+	db := setupTestDB(t)
+	require.NoError(t, db.MarkUserAsDetailAnalyzed("u1"))
+	require.NoError(t, db.SaveCachedAnalysis("u1", "shouter", SecondStepClaudeResponse{IsFUDUser: false}, ""))
+	_, err := db.AddWatchedUser(WatchlistModel{ChatID: 5, Username: "shouter"})
+	require.NoError(t, err)
+
+	message := twitterapi.NewMessage{TweetID: "t1", Text: "still holding, the chart looks fine"}
+	message.Author.ID, message.Author.UserName = "u1", "shouter"
+	message.ParentTweet.ID, message.ParentTweet.Author, message.ParentTweet.Text = "t0", "dev", "update is live"
+	newMessageCh := make(chan twitterapi.NewMessage, 1)
+	newMessageCh <- message
+	close(newMessageCh)
+	fudChannel := make(chan twitterapi.NewMessage, 1)
+	firstStep := newMockClaudeAPI(`"is_fud":false,"fud_probability":5}`, nil)
+	FirstStepHandler(newMessageCh, fudChannel, firstStep, nil, &mockUserStatusTracker{}, db, make(chan FUDAlertNotification, 1), &warRoomState{}, nil)
+	forwarded, ok := <-fudChannel
+	require.True(t, ok, "watched users go straight to the detailed analysis")
+	assert.True(t, forwarded.Watched)
+	assert.Empty(t, firstStep.recordedCalls())
+
+	secondStep := newMockClaudeAPI(`"is_fud_user":false,"fud_probability":0.05,"user_risk_level":"low","user_summary":"long-time holder"}`, nil)
+	notificationCh := make(chan FUDAlertNotification, 1)
+	SecondStepHandler(forwarded, notificationCh, &mockTwitterAPI{}, secondStep, nil, &mockUserStatusTracker{}, "GRUT", db)
+	assert.NotEmpty(t, secondStep.recordedCalls(), "the cached verdict is not reused for watched users")
+	require.Len(t, notificationCh, 1)
+	alert := <-notificationCh
+	assert.True(t, alert.Watched)
+	assert.True(t, alert.WatchOnly)
+
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	bot.chatIDs[5] = true
+	bot.chatIDs[6] = true
+	notificationCh <- alert
+	close(notificationCh)
+	NotificationHandler(notificationCh, bot)
+
+	sent := transport.sentMessages()
+	require.Len(t, sent, 1, "clean watched messages only reach the watching chats")
+	assert.Equal(t, int64(5), sent[0].ChatID)
+	assert.Contains(t, sent[0].Text, "Watched @shouter posted")
+	assert.Contains(t, sent[0].Text, "Reply to @dev")
+	assert.Contains(t, sent[0].Text, "Clean, FUD probability 5%")
+	assert.Contains(t, sent[0].Text, "long-time holder")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/grutapig/hackaton/twitterapi"
+)
+
+var twitterUsernameRegex = regexp.MustCompile(`^\w{1,15}$`)
+
+// isTrustedAuthor reports whether the message author is whitelisted. Both analysis steps check it
+// before calling Claude, a manual analysis of a trusted account fails its task with the reason.
+func isTrustedAuthor(newMessage twitterapi.NewMessage, dbService *DatabaseService) bool {
+	if !dbService.IsTrustedUser(newMessage.Author.ID, newMessage.Author.UserName) {
+		return false
+	}
+	log.Printf("🤝 Skipping analysis of whitelisted user %s", newMessage.Author.UserName)
+	if newMessage.IsManualAnalysis && newMessage.TaskID != "" {
+		failManualAnalysisTask(newMessage, newTaskError(TASK_ERROR_WHITELISTED, errors.New("@"+newMessage.Author.UserName+" is whitelisted, run /whitelist remove "+newMessage.Author.UserName+" to analyze them")), dbService)
+	}
+	return true
+}
+
+// handleWhitelistCommand manages the accounts that are never analyzed or flagged:
+// /whitelist [list|add user [note: why]|remove user]
+func (b *BotController) handleWhitelistCommand(chatID int64, actor string, args []string) {
+	usage := "❌ Usage: /whitelist [list|add username [note: why]|remove username]"
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		b.handleWhitelistList(chatID)
+		return
+	}
+	if len(args) < 2 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	rest, note := splitNotifyNote(args[1:])
+	if len(rest) != 1 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+	username, _ := b.resolveTwitterReference(rest[0])
+	username = strings.ToLower(username)
+	if !twitterUsernameRegex.MatchString(username) {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Invalid username: %s", html.EscapeString(rest[0])))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		b.handleWhitelistAdd(chatID, actor, username, note)
+	case "remove":
+		removed, err := b.dbService.RemoveTrustedUser(username, actor)
+		if err != nil {
+			b.SendMessage(chatID, fmt.Sprintf("❌ Error updating whitelist: %v", err))
+			return
+		}
+		if !removed {
+			b.SendMessage(chatID, fmt.Sprintf("❌ @%s is not whitelisted", username))
+			return
+		}
+		log.Printf("🤝 %s removed @%s from the whitelist", actor, username)
+		b.SendMessage(chatID, fmt.Sprintf("✅ @%s is analyzed again", username))
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+func (b *BotController) handleWhitelistAdd(chatID int64, actor string, username string, note string) {
+	trusted := TrustedUserModel{Username: username, AddedBy: actor, AddedFromChat: chatID, Note: note}
+	user, err := b.dbService.GetUserByUsername(username)
+	if err == nil {
+		trusted.UserID = user.ID
+	}
+	err = b.dbService.AddTrustedUser(trusted)
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error updating whitelist: %v", err))
+		return
+	}
+	log.Printf("🤝 %s whitelisted @%s", actor, username)
+
+	message := fmt.Sprintf("✅ @%s is whitelisted and will never be analyzed or flagged", username)
+	// A trusted account must not stay on the FUD list from an earlier detection
+	if user != nil && b.dbService.IsFUDUser(user.ID) {
+		if err := b.dbService.DeleteFUDUser(user.ID); err != nil {
+			log.Printf("Failed to remove whitelisted user %s from FUD list: %v", username, err)
+		} else if err := b.dbService.UpdateUserFUDStatus(user.ID, false, ""); err != nil {
+			log.Printf("Failed to update FUD status for whitelisted user %s: %v", username, err)
+		}
+		message += "\n🧹 Removed from the FUD list"
+	}
+	if user == nil {
+		message += "\n⚠️ Not in the database yet, matched by username until their first message is stored"
+	}
+	b.SendMessage(chatID, message)
+}
+
+func (b *BotController) handleWhitelistList(chatID int64) {
+	users, err := b.dbService.GetTrustedUsers()
+	if err != nil {
+		b.SendMessage(chatID, fmt.Sprintf("❌ Error loading whitelist: %v", err))
+		return
+	}
+	if len(users) == 0 {
+		b.SendMessage(chatID, "🤝 The whitelist is empty.\n\nUsage: /whitelist add username note: team member")
+		return
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🤝 <b>Whitelist</b> (%d accounts)\n\n", len(users)))
+	for _, user := range users {
+		message.WriteString(fmt.Sprintf("• @%s\n   ↳ added by %s on %s", user.Username, html.EscapeString(user.AddedBy), user.AddedAt.UTC().Format("2006-01-02")))
+		if user.Note != "" {
+			message.WriteString(" — <i>" + html.EscapeString(user.Note) + "</i>")
+		}
+		message.WriteString("\n")
+	}
+	message.WriteString("\n/whitelist add username note: why · /whitelist remove username")
+	b.SendMessage(chatID, message.String())
+}
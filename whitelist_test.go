@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/grutapig/hackaton/twitterapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotController_Whitelist(t *testing.T) {
+	db := setupTestDB(t)
+	transport := &fakeTelegramTransport{}
+	bot := newTestBotController(transport, db)
+	require.NoError(t, db.SaveUser(UserModel{ID: "u1", Username: "TeamLead"}))
+	require.NoError(t, db.SaveFUDUser(FUDUserModel{UserID: "u1", Username: "TeamLead", FUDType: "casual_fud"}))
+
+	reply := func(args ...string) string {
+		bot.handleWhitelistCommand(1, "@admin", args)
+		sent := transport.sentMessages()
+		return sent[len(sent)-1].Text
+	}
+
+	added := reply("add", "@TeamLead", "note:", "core", "team")
+	assert.Contains(t, added, "@teamlead is whitelisted")
+	assert.Contains(t, added, "Removed from the FUD list")
+	assert.False(t, db.IsFUDUser("u1"))
+	assert.Contains(t, reply("add", "https://x.com/newmod"), "matched by username")
+	assert.Contains(t, reply("add", "bad-name!"), "Invalid username")
+	assert.Contains(t, reply("add", "two", "users"), "Usage")
+
+	list := reply("list")
+	assert.Contains(t, list, "@teamlead")
+	assert.Contains(t, list, "added by @admin")
+	assert.Contains(t, list, "core team")
+
+	assert.True(t, db.IsTrustedUser("u1", "renamed"), "matched by ID after a rename")
+	assert.True(t, db.IsTrustedUser("", "NewMod"))
+	assert.False(t, db.IsTrustedUser("u2", "someone"))
+
+	message := twitterapi.NewMessage{TweetID: "t1", Text: "wen rug"}
+	message.Author.ID = "u1"
+	message.Author.UserName = "TeamLead"
+	newMessageCh := make(chan twitterapi.NewMessage, 1)
+	newMessageCh <- message
+	close(newMessageCh)
+	fudChannel := make(chan twitterapi.NewMessage, 1)
+	claudeApi := newMockClaudeAPI(`"is_fud":true,"fud_probability":0.9}`, nil)
+	FirstStepHandler(newMessageCh, fudChannel, claudeApi, nil, &mockUserStatusTracker{}, db, make(chan FUDAlertNotification, 1), &warRoomState{}, nil)
+	_, forwarded := <-fudChannel
+	assert.False(t, forwarded)
+	assert.Empty(t, claudeApi.recordedCalls(), "trusted users cost no Claude calls")
+
+	require.NoError(t, db.CreateAnalysisTask(&AnalysisTaskModel{ID: "task1", Status: ANALYSIS_STATUS_RUNNING}))
+	message.IsManualAnalysis, message.TaskID = true, "task1"
+	SecondStepHandler(message, make(chan FUDAlertNotification, 1), nil, claudeApi, nil, &mockUserStatusTracker{}, "$GRUT", db)
+	task, err := db.GetAnalysisTask("task1")
+	require.NoError(t, err)
+	assert.Equal(t, ANALYSIS_STATUS_FAILED, task.Status)
+	assert.Contains(t, task.ErrorMessage, "whitelisted")
+	assert.Empty(t, claudeApi.recordedCalls())
+
+	assert.Contains(t, reply("remove", "teamlead"), "analyzed again")
+	assert.False(t, db.IsTrustedUser("u1", "TeamLead"))
+	assert.Contains(t, reply("remove", "teamlead"), "not whitelisted")
+}